@@ -0,0 +1,226 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client provides access to a remote depreldb server. It uses
+// the same option functions as the scoll package (scoll.WithPoS,
+// scoll.WithLimit, ...) so it is easy to switch between an embedded
+// database and a remote one.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// REST API paths (relative to a server base URL)
+const (
+	PathCollocations = "/collocations/"
+	PathLemmaInfo    = "/lemma-info/"
+	PathLemmaProfile = "/lemma-profile/"
+	PathDeprelStats  = "/deprel-stats"
+	PathTextTypes    = "/text-types"
+
+	// ParamNumExamples specifies number of example pairs
+	// in deprel stats.
+	ParamNumExamples = "examples"
+
+	// HeaderAPIKey is a HTTP header used to pass a client API key
+	// (e.g. for accessing restricted text types)
+	HeaderAPIKey = "X-Api-Key"
+
+	// HeaderTotalCount is a HTTP response header containing the number
+	// of all the found collocations (regardless of offset and limit)
+	HeaderTotalCount = "X-Total-Count"
+
+	DefaultTimeout = 30 * time.Second
+)
+
+var _ scoll.CollocationProvider = (*Client)(nil)
+
+// ErrNoAPIKey is returned when access to restricted text types
+// is requested (scoll.WithRestrictedTextTypesAccess) by a client
+// without an API key
+var ErrNoAPIKey = errors.New("access to restricted text types requires an API key")
+
+// ErrorResponse is a JSON body returned by the server in case of an error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Client is a depreldb REST API client.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// WithAPIKey sets an API key sent along with all the requests.
+// The server grants access to restricted text types based on the key
+// (i.e. it is a remote equivalent of scoll.WithRestrictedTextTypesAccess).
+func WithAPIKey(key string) func(c *Client) {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(hc *http.Client) func(c *Client) {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// applyOptions creates calculation options from the option functions.
+// As the access to restricted text types is granted by the server
+// based on the API key, scoll.WithRestrictedTextTypesAccess cannot
+// be passed to the server and it just requires the key to be set.
+func (c *Client) applyOptions(options []func(opts *scoll.CalculationOptions)) (scoll.CalculationOptions, error) {
+	var opts scoll.CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.RestrictedTextTypesAccess && c.apiKey == "" {
+		return opts, ErrNoAPIKey
+	}
+	return opts, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, result any) (http.Header, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", scoll.EncodingJSON.ContentType())
+	if c.apiKey != "" {
+		req.Header.Set(HeaderAPIKey, c.apiKey)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		var errResp ErrorResponse
+		if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error == "" {
+			return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("server returned status %d: %s", resp.StatusCode, errResp.Error)
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// GetCollocations searches for collocations of the provided lemma.
+// See scoll.Calculator.GetCollocations.
+func (c *Client) GetCollocations(ctx context.Context, lemma string, options ...func(opts *scoll.CalculationOptions)) ([]storage.Collocation, error) {
+	var ans []storage.Collocation
+	opts, err := c.applyOptions(options)
+	if err != nil {
+		return ans, err
+	}
+	header, err := c.get(ctx, PathCollocations+url.PathEscape(lemma), opts.AsURLValues(), &ans)
+	if err != nil {
+		return ans, err
+	}
+	if opts.TotalCount != nil {
+		total, err := strconv.Atoi(header.Get(HeaderTotalCount))
+		if err != nil {
+			return ans, fmt.Errorf("failed to read total count: %w", err)
+		}
+		*opts.TotalCount = total
+	}
+	return ans, nil
+}
+
+// GetLemmaInfo provides information about lemma existence and frequency.
+// See scoll.Calculator.GetLemmaInfo (the only applicable option,
+// WithRestrictedTextTypesAccess, requires an API key - see applyOptions).
+func (c *Client) GetLemmaInfo(lemma string, options ...func(opts *scoll.CalculationOptions)) (storage.LemmaInfo, error) {
+	var ans storage.LemmaInfo
+	if _, err := c.applyOptions(options); err != nil {
+		return ans, err
+	}
+	_, err := c.get(context.Background(), PathLemmaInfo+url.PathEscape(lemma), nil, &ans)
+	return ans, err
+}
+
+// GetDeprelStats provides global statistics of syntactic relations.
+// See scoll.Calculator.GetDeprelStats.
+func (c *Client) GetDeprelStats(ctx context.Context, numExamples int, options ...func(opts *scoll.CalculationOptions)) ([]storage.DeprelStats, error) {
+	var ans []storage.DeprelStats
+	if _, err := c.applyOptions(options); err != nil {
+		return ans, err
+	}
+	query := make(url.Values)
+	query.Set(ParamNumExamples, strconv.Itoa(numExamples))
+	_, err := c.get(ctx, PathDeprelStats, query, &ans)
+	return ans, err
+}
+
+// GetLemmaProfile provides lemma frequencies split by PoS tags, text
+// types and syntactic relations. See scoll.Calculator.GetLemmaProfile.
+func (c *Client) GetLemmaProfile(lemma string, options ...func(opts *scoll.CalculationOptions)) (storage.LemmaProfile, error) {
+	var ans storage.LemmaProfile
+	if _, err := c.applyOptions(options); err != nil {
+		return ans, err
+	}
+	_, err := c.get(context.Background(), PathLemmaProfile+url.PathEscape(lemma), nil, &ans)
+	return ans, err
+}
+
+// GetTextTypes provides display names of text types in their display order.
+// See scoll.Calculator.GetTextTypes.
+func (c *Client) GetTextTypes(options ...func(opts *scoll.CalculationOptions)) ([]storage.TextTypeLabel, error) {
+	var ans []storage.TextTypeLabel
+	if _, err := c.applyOptions(options); err != nil {
+		return ans, err
+	}
+	_, err := c.get(context.Background(), PathTextTypes, nil, &ans)
+	return ans, err
+}
+
+// New creates a new client of a server available at baseURL.
+func New(baseURL string, options ...func(c *Client)) *Client {
+	ans := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range options {
+		opt(ans)
+	}
+	return ans
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCollocations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/collocations/team", r.URL.Path)
+		assert.Equal(t, "NOUN", r.URL.Query().Get(scoll.ParamPoS))
+		assert.Equal(t, "5", r.URL.Query().Get(scoll.ParamLimit))
+		assert.Equal(t, "10", r.URL.Query().Get(scoll.ParamOffset))
+		assert.Equal(t, "secret", r.Header.Get(HeaderAPIKey))
+		w.Header().Set(HeaderTotalCount, "42")
+		json.NewEncoder(w).Encode([]storage.Collocation{
+			{
+				Lemma:      storage.CollMember{Value: "team", PoS: "NOUN"},
+				Collocate:  storage.CollMember{Value: "play", PoS: "VERB"},
+				Deprel:     "nsubj",
+				LogDice:    9.5,
+				MutualDist: 1,
+			},
+		})
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithAPIKey("secret"))
+	var total int
+	ans, err := c.GetCollocations(
+		context.Background(),
+		"team",
+		scoll.WithPoS("NOUN"),
+		scoll.WithLimit(5),
+		scoll.WithOffset(10),
+		scoll.WithTotalCount(&total),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, total)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "play", ans[0].Collocate.Value)
+	assert.Equal(t, 9.5, ans[0].LogDice)
+	assert.Equal(t, 1.0, ans[0].MutualDist)
+	assert.False(t, ans[0].IsHead)
+}
+
+func TestGetCollocationsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid value of limit: x"})
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).GetCollocations(context.Background(), "team")
+	assert.ErrorContains(t, err, "invalid value of limit")
+}
+
+func TestGetLemmaInfoRestrictedAccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/lemma-info/team", r.URL.Path)
+		info := storage.LemmaInfo{Exists: true, Freq: 10}
+		if r.Header.Get(HeaderAPIKey) == "secret" {
+			info.Freq = 15
+		}
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer srv.Close()
+
+	info, err := New(srv.URL, WithAPIKey("secret")).GetLemmaInfo(
+		"team", scoll.WithRestrictedTextTypesAccess())
+	assert.NoError(t, err)
+	assert.Equal(t, 15, info.Freq)
+
+	info, err = New(srv.URL).GetLemmaInfo("team")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, info.Freq)
+
+	// the access cannot be granted without a key
+	_, err = New(srv.URL).GetLemmaInfo("team", scoll.WithRestrictedTextTypesAccess())
+	assert.ErrorIs(t, err, ErrNoAPIKey)
+	_, err = New(srv.URL).GetCollocations(context.Background(), "team", scoll.WithRestrictedTextTypesAccess())
+	assert.ErrorIs(t, err, ErrNoAPIKey)
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+func tokenLabel(tokenID uint64, lemma, pos string) string {
+	ans := fmt.Sprintf("%s#%d", lemma, tokenID)
+	if pos != "" {
+		ans += "/" + pos
+	}
+	return ans
+}
+
+// writeReadable writes a single line human-readable description
+// of a dumped record
+func writeReadable(w io.Writer, rec storage.DumpedRecord) error {
+	var desc string
+	switch {
+	case rec.Error != "":
+		desc = fmt.Sprintf("ERROR: %s (value: %s)", rec.Error, rec.Value)
+	case rec.Metadata != nil:
+		desc = string(rec.Metadata)
+	case rec.Namespace == "lemmaToID":
+		desc = fmt.Sprintf("%s -> %d", rec.Lemma, rec.TokenID)
+	case rec.Namespace == "foldedLemmaToID", rec.Namespace == "lowerLemmaToID":
+		desc = fmt.Sprintf("%s (%s) -> %d", rec.Folded, rec.Lemma, rec.TokenID)
+	case rec.Namespace == "idToLemma":
+		desc = fmt.Sprintf("%d -> %s", rec.TokenID, rec.Lemma)
+	case rec.Namespace == "pairExamples":
+		desc = fmt.Sprintf(
+			"%s -> %s, examples: %s",
+			tokenLabel(rec.TokenID, rec.Lemma, rec.PoS), tokenLabel(rec.Token2ID, rec.Lemma2, rec.PoS2), rec.Value,
+		)
+	case rec.Token2ID > 0:
+		desc = fmt.Sprintf(
+			"%s -%s-> %s, textType: %q, isHead: %t, freq: %d, dist: %.1f",
+			tokenLabel(rec.TokenID, rec.Lemma, rec.PoS), rec.Deprel,
+			tokenLabel(rec.Token2ID, rec.Lemma2, rec.PoS2),
+			rec.TextType, rec.IsHead, rec.Freq, rec.Dist,
+		)
+		if rec.SurfaceDist != nil {
+			desc += fmt.Sprintf(", surfaceDist: %.1f", *rec.SurfaceDist)
+		}
+	default:
+		desc = fmt.Sprintf(
+			"%s, textType: %q, freq: %d",
+			tokenLabel(rec.TokenID, rec.Lemma, rec.PoS), rec.TextType, rec.Freq,
+		)
+	}
+	ns := rec.Namespace
+	if ns == "" {
+		ns = "?"
+	}
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\n", ns, rec.Key, desc)
+	return err
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "dbdump - print decoded records of a collocation database (for debugging).\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] [db_path]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+	namespaces := flag.String("ns", "", "comma-separated record types to dump (metadata, lemmaToID, foldedLemmaToID, lowerLemmaToID, idToLemma, tokenFreq, tokenRollup, pairFreq, revPairFreq, hotPairFreq, hotRevPairFreq, pathPairFreq, revPathPairFreq, topColls, pairExamples; all records if empty)")
+	keyPrefix := flag.String("prefix", "", "hex encoded key prefix of dumped records")
+	limit := flag.Int("limit", 0, "max. number of dumped records (0 = unlimited)")
+	jsonl := flag.Bool("jsonl", false, "if set, records are written as JSON lines")
+	ignoreMetadata := flag.Bool("ignore-metadata", false, "if set, stored metadata are not loaded (e.g. for databases with missing or corrupted metadata); text types and deprels are not resolved then")
+	readOnly := flag.Bool("read-only", false, "if set, the database is opened in the read-only mode so it can be dumped while used by another process (cannot be combined with -ignore-metadata)")
+	logLevel := flag.String("log-level", "warn", "set log level (debug, info, warn, error)")
+	flag.Parse()
+
+	logging.SetupLogging(logging.LoggingConf{
+		Level: logging.LogLevel(*logLevel),
+	})
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *readOnly && *ignoreMetadata {
+		fmt.Fprintln(os.Stderr, "ERROR: ", "-read-only cannot be combined with -ignore-metadata")
+		os.Exit(1)
+	}
+	args := storage.DumpArgs{}
+	if *namespaces != "" {
+		args.Namespaces = strings.Split(*namespaces, ",")
+	}
+	if *keyPrefix != "" {
+		prefix, err := hex.DecodeString(*keyPrefix)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", fmt.Errorf("invalid key prefix: %w", err))
+			os.Exit(1)
+		}
+		args.KeyPrefix = prefix
+	}
+
+	var db *storage.DB
+	var err error
+	switch {
+	case *ignoreMetadata:
+		db, err = storage.OpenDBIgnoreMetadata(flag.Arg(0), nil)
+	case *readOnly:
+		db, err = storage.OpenDBReadOnly(flag.Arg(0))
+	default:
+		db, err = storage.OpenDB(flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+	var numDumped int
+	err = db.Dump(args, func(rec storage.DumpedRecord) error {
+		if *limit > 0 && numDumped >= *limit {
+			return storage.ErrStopDump
+		}
+		numDumped++
+		if *jsonl {
+			return enc.Encode(rec)
+		}
+		return writeReadable(out, rec)
+	})
+	if err != nil {
+		out.Flush()
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(2)
+	}
+}
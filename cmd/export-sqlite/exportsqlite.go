@@ -0,0 +1,98 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// exportToSQLite pipes the SQL export of db to the sqlite3 command line
+// tool creating the outPath database
+func exportToSQLite(db *storage.DB, sqlite3Path, outPath string) error {
+	if _, err := os.Stat(outPath); err == nil {
+		return fmt.Errorf("output file %s already exists", outPath)
+
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	cmd := exec.Command(sqlite3Path, "-bail", outPath)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", sqlite3Path, err)
+	}
+	exportErr := db.ExportSQL(stdin)
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to create SQLite database: %w", err)
+	}
+	return exportErr
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "export-sqlite - export a collocation database to an SQLite file.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] db_path output.sqlite\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "  %s -sql-only [options] db_path\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "The SQLite file is created by the sqlite3 command line tool.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+	sqlOnly := flag.Bool("sql-only", false, "if set, the SQL script is written to stdout instead of creating an SQLite file")
+	sqlite3Path := flag.String("sqlite3", "sqlite3", "path to the sqlite3 command line tool")
+	logLevel := flag.String("log-level", "warn", "set log level (debug, info, warn, error)")
+	flag.Parse()
+
+	logging.SetupLogging(logging.LoggingConf{
+		Level: logging.LogLevel(*logLevel),
+	})
+
+	if *sqlOnly && flag.NArg() != 1 || !*sqlOnly && flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	db, err := storage.OpenDBReadOnly(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if *sqlOnly {
+		err = db.ExportSQL(os.Stdout)
+
+	} else {
+		err = exportToSQLite(db, *sqlite3Path, flag.Arg(1))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// writeReadable writes a human-readable form of the report
+func writeReadable(w io.Writer, report storage.IntegrityReport) {
+	fmt.Fprintf(w, "lemmas:                  %d\n", report.NumLemmas)
+	fmt.Fprintf(w, "word forms:              %d\n", report.NumWordForms)
+	fmt.Fprintf(w, "reverse index entries:   %d\n", report.NumRevIndexEntries)
+	fmt.Fprintf(w, "lemma freq. records:     %d\n", report.NumLemmaFreqs)
+	fmt.Fprintf(w, "lemma freq. rollups:     %d\n", report.NumLemmaRollups)
+	fmt.Fprintf(w, "collocation records:     %d\n", report.NumCollFreqs)
+	fmt.Fprintf(w, "hot lemma summaries:     %d\n", report.NumHotCollFreqs)
+	fmt.Fprintf(w, "path collocations:       %d\n", report.NumPathCollFreqs)
+	fmt.Fprintf(w, "pairs with examples:     %d\n", report.NumPairExamples)
+	fmt.Fprintf(w, "summed lemma freqs:      %d\n", report.SumLemmaFreqs)
+	fmt.Fprintln(w)
+	if report.NumIssues() == 0 {
+		fmt.Fprintln(w, "no issues found")
+		return
+	}
+	kinds := make([]storage.IntegrityIssueKind, 0, len(report.IssueCounts))
+	for kind := range report.IssueCounts {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "%s: %d\n", kind, report.IssueCounts[kind])
+	}
+	fmt.Fprintln(w)
+	for _, issue := range report.Issues {
+		var repaired string
+		if issue.Repaired {
+			repaired = " [repaired]"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s%s\n", issue.Kind, issue.Key, issue.Message, repaired)
+	}
+	if report.NumRepaired > 0 {
+		fmt.Fprintf(w, "\nrepaired issues: %d\n", report.NumRepaired)
+	}
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "fsck - check integrity of a collocation database.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] [db_path]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Exit status is 3 if unrepaired issues are found.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+	repair := flag.Bool("repair", false, "if set, missing reverse index entries are created (other issues are only reported)")
+	maxIssues := flag.Int("max-issues", storage.DefaultMaxIssuesPerKind, "max. number of listed issues of a single kind (all issues are counted)")
+	jsonOutput := flag.Bool("json", false, "if set, the report is written as JSON")
+	readOnly := flag.Bool("read-only", false, "if set, the database is opened in the read-only mode so it can be checked while used by another process (cannot be combined with -repair)")
+	logLevel := flag.String("log-level", "warn", "set log level (debug, info, warn, error)")
+	flag.Parse()
+
+	logging.SetupLogging(logging.LoggingConf{
+		Level: logging.LogLevel(*logLevel),
+	})
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *readOnly && *repair {
+		fmt.Fprintln(os.Stderr, "ERROR: ", "-read-only cannot be combined with -repair")
+		os.Exit(1)
+	}
+
+	var db *storage.DB
+	var err error
+	if *readOnly {
+		db, err = storage.OpenDBReadOnly(flag.Arg(0))
+
+	} else {
+		db, err = storage.OpenDB(flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report, err := db.CheckIntegrity(
+		ctx,
+		storage.IntegrityCheckArgs{RepairRevIndex: *repair, MaxIssuesPerKind: *maxIssues},
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(2)
+	}
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		}
+
+	} else {
+		writeReadable(os.Stdout, report)
+	}
+	if !report.OK() {
+		db.Close()
+		os.Exit(3)
+	}
+}
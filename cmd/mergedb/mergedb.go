@@ -0,0 +1,159 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// mergedHistory combines import histories of all the source
+// databases (ordered by time) so the provenance of the merged
+// data remains traceable
+func mergedHistory(srcs []*storage.DB) ([]storage.ImportRun, error) {
+	var ans []storage.ImportRun
+	for _, src := range srcs {
+		history, err := src.ImportHistory()
+		if err != nil {
+			return nil, err
+		}
+		ans = append(ans, history...)
+	}
+	slices.SortStableFunc(ans, func(a, b storage.ImportRun) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return ans, nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "mergedb - merge two or more collocation databases into a new one.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] [output_db] [db_path1] [db_path2] ...\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+	tmpDir := flag.String("tmp-dir", "", "directory for temporary ID mapping databases (system default if empty)")
+	hotLemmaThreshold := flag.Int("hot-lemma-threshold", storage.DefaultHotLemmaThreshold, "lemmas with more collocation records than the value get pre-aggregated summaries speeding up searches (0 = disabled)")
+	writeBatchSize := flag.Int("write-batch-size", storage.DefaultWriteBatchSize, "number of records committed to the database at once")
+	logLevel := flag.String("log-level", "info", "set log level (debug, info, warn, error)")
+	flag.Parse()
+
+	logging.SetupLogging(logging.LoggingConf{
+		Level: logging.LogLevel(*logLevel),
+	})
+
+	if flag.NArg() < 3 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	dst, err := storage.OpenDBIgnoreMetadata(flag.Arg(0), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer dst.Close()
+	if _, err := dst.StoredMetadata(); err == nil {
+		fmt.Fprintf(os.Stderr, "ERROR: output database %s is not empty\n", flag.Arg(0))
+		os.Exit(1)
+	}
+	dst.SetWriteBatchSize(*writeBatchSize)
+
+	srcPaths := flag.Args()[1:]
+	srcs := make([]*storage.DB, 0, len(srcPaths))
+	for _, path := range srcPaths {
+		src, err := storage.OpenDB(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+		defer src.Close()
+		srcs = append(srcs, src)
+	}
+
+	for i, src := range srcs {
+		stats, err := dst.Merge(src, *tmpDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(2)
+		}
+		log.Info().
+			Str("database", srcPaths[i]).
+			Int("numLemmas", stats.NumLemmas).
+			Int("numNewLemmas", stats.NumNewLemmas).
+			Int("numNewDeprels", stats.NumNewDeprels).
+			Int("numLemmaFreqs", stats.NumLemmaFreqs).
+			Int("numCollFreqs", stats.NumCollFreqs).
+			Int("numSummedRecords", stats.NumSummedRecords).
+			Msg("merged database")
+	}
+
+	metadata := dst.Metadata
+	numHotLemmas, err := dst.StoreHotLemmaSummaries(*hotLemmaThreshold)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(3)
+	}
+	if numHotLemmas > 0 {
+		metadata.HotLemmaThreshold = *hotLemmaThreshold
+		metadata.NumHotLemmas = numHotLemmas
+		metadata.Features = append(metadata.Features, storage.FeatureHotLemmaSummaries)
+	}
+	if err := dst.StoreMetadata(metadata); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(4)
+	}
+
+	history, err := mergedHistory(srcs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(4)
+	}
+	run := storage.ImportRun{
+		Timestamp:         time.Now(),
+		Files:             srcPaths,
+		ProfileName:       metadata.ProfileName,
+		CorpusSize:        metadata.CorpusSize,
+		NumLemmas:         metadata.NumLemmas,
+		NumLemmaFreqs:     metadata.NumLemmaFreqs,
+		NumCollFreqs:      metadata.NumCollFreqs,
+		MinPairFreq:       metadata.MinPairFreq,
+		HotLemmaThreshold: *hotLemmaThreshold,
+		ClearedPrevious:   true,
+	}
+	if err := dst.StoreImportHistory(append(history, run)); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(4)
+	}
+
+	log.Info().
+		Int64("corpusSize", metadata.CorpusSize).
+		Int("numCollFreqs", metadata.NumCollFreqs).
+		Int("numLemmaFreqs", metadata.NumLemmaFreqs).
+		Int("numLemmas", metadata.NumLemmas).
+		Int("numHotLemmas", metadata.NumHotLemmas).
+		Str("profileName", metadata.ProfileName).
+		Msg("stored merged dataset metadata")
+}
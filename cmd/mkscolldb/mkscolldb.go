@@ -18,124 +18,507 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/czcorpus/depreldb/dataimport"
 	"github.com/czcorpus/depreldb/record"
 	"github.com/rs/zerolog/log"
 
-	"github.com/czcorpus/cnc-gokit/fs"
 	"github.com/czcorpus/cnc-gokit/logging"
 	"github.com/czcorpus/depreldb/storage"
 	"github.com/tomachalek/vertigo/v6"
 )
 
-func determineFilesToProc(path string) ([]string, error) {
-	isDir, err := fs.IsDir(path)
+func loadTextTypeLabels(path string) ([]storage.TextTypeLabel, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return []string{}, fmt.Errorf("failed to determine files to process: %w", err)
+		return nil, fmt.Errorf("failed to load text type labels: %w", err)
 	}
-	ans := make([]string, 0, 50)
-	if isDir {
-		entries, err := os.ReadDir(path)
-		if err != nil {
-			return []string{}, fmt.Errorf("failed to list directory contents: %w", err)
-		}
-		for _, entry := range entries {
-			ans = append(ans, filepath.Join(path, entry.Name()))
+	var ans []storage.TextTypeLabel
+	if err := json.Unmarshal(data, &ans); err != nil {
+		return nil, fmt.Errorf("failed to load text type labels: %w", err)
+	}
+	return ans, nil
+}
+
+func loadDeprelBlocklist(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deprel blocklist: %w", err)
+	}
+	ans := []string{}
+	if err := json.Unmarshal(data, &ans); err != nil {
+		return nil, fmt.Errorf("failed to load deprel blocklist: %w", err)
+	}
+	return ans, nil
+}
+
+// defaultManifestPath returns a path of an import manifest
+// placed next to the database directory
+func defaultManifestPath(dbPath string) string {
+	return filepath.Clean(dbPath) + ".import-manifest.json"
+}
+
+// resumeFromManifest restores the import state collected from
+// files completed by a previous (failed) run.
+func resumeFromManifest(
+	manifest *dataimport.ImportManifest,
+	freqColl dataimport.FreqsCollector,
+	proc *dataimport.Searcher,
+) error {
+	if err := record.UDDeprelMapping.RegisterAll(manifest.DeprelMap); err != nil {
+		return fmt.Errorf("failed to resume import: %w", err)
+	}
+	if err := manifest.RestoreState(freqColl); err != nil {
+		return fmt.Errorf("failed to resume import: %w", err)
+	}
+	proc.RestoreCorpusSize(manifest.CorpusSize)
+	log.Info().
+		Int("completedFiles", len(manifest.CompletedFiles)).
+		Int64("corpusSize", manifest.CorpusSize).
+		Msg("resuming import from manifest")
+	return nil
+}
+
+// recordCompletedFile updates the manifest (and the collected
+// frequencies state) once a file is fully processed.
+func recordCompletedFile(
+	manifest *dataimport.ImportManifest,
+	vertFile string,
+	freqColl dataimport.FreqsCollector,
+	proc *dataimport.Searcher,
+) error {
+	if err := manifest.MarkCompleted(vertFile); err != nil {
+		return err
+	}
+	manifest.CorpusSize = proc.ImportedCorpusSize()
+	manifest.DeprelMap = record.UDDeprelMapping.AsMap()
+	return manifest.Save(freqColl)
+}
+
+// appendedMetadata combines metadata of previously imported data
+// with the metadata of data appended to them.
+func appendedMetadata(prev, curr storage.Metadata) storage.Metadata {
+	ans := curr
+	// the previously imported records keep their layout
+	ans.SchemaVersion = prev.SchemaVersion
+	ans.CorpusSize += prev.CorpusSize
+	ans.NumCollFreqs += prev.NumCollFreqs
+	ans.NumLemmaFreqs += prev.NumLemmaFreqs
+	ans.NumLemmas += prev.NumLemmas
+	ans.NumWordForms += prev.NumWordForms
+	ans.RelationDists = storage.MergeRelationDists(prev.RelationDists, curr.RelationDists)
+	if !slices.Equal(prev.DeprelBlocklist, curr.DeprelBlocklist) {
+		ans.DeprelBlocklist = nil
+	}
+	if len(ans.Relations) == 0 {
+		ans.Relations = prev.Relations
+	}
+	// features not available in the previous data cannot be provided
+	// for the whole dataset
+	ans.SurfaceDist = curr.SurfaceDist && prev.HasFeature(storage.FeatureSurfaceDist)
+	ans.TokenFreqRollups = curr.TokenFreqRollups && prev.HasFeature(storage.FeatureTokenFreqRollups)
+	ans.Features = make([]storage.DatasetFeature, 0, len(curr.Features))
+	for _, f := range curr.Features {
+		if prev.HasFeature(f) || f == storage.FeatureHotLemmaSummaries {
+			ans.Features = append(ans.Features, f)
 		}
+	}
+	if ans.HasFeature(storage.FeaturePathCollocations) {
+		ans.PathCollocationsDepth = min(prev.PathCollocationsDepth, curr.PathCollocationsDepth)
 
 	} else {
-		ans = append(ans, path)
+		ans.PathCollocationsDepth = 0
 	}
-	return ans, nil
+	if ans.HasFeature(storage.FeaturePairExamples) {
+		ans.PairExamples = max(prev.PairExamples, curr.PairExamples)
+
+	} else {
+		ans.PairExamples = 0
+	}
+	return ans
+}
+
+// fileTextTypesMapping returns the text types mapping of the profile
+// in case the text types are derived from file names. Otherwise,
+// nil is returned.
+func fileTextTypesMapping(prof storage.Profile, fileTextTypes dataimport.FileTextTypeRules) map[string]byte {
+	if len(fileTextTypes) == 0 {
+		return nil
+	}
+	return prof.TextTypes
 }
 
-func runCommand(path, dbPath string, prof storage.Profile, minFreq int, verbose bool) {
+func runCommand(
+	path, dbPath string,
+	prof storage.Profile,
+	minFreq, hotLemmaThreshold, writeBatchSize, numWorkers int,
+	topCollsThreshold, topCollsLimit int,
+	spillThreshold int,
+	tmpDir string,
+	verbose bool,
+	notifyURL string,
+	progressInterval time.Duration,
+	progressPath string,
+	manifestPath string,
+	skipCompleted bool,
+	fileSel dataimport.FileSelection,
+	fileTextTypes dataimport.FileTextTypeRules,
+	conllu bool,
+	appendData bool,
+) {
 	var db *storage.DB
 	var err error
+	notifier := dataimport.NewImportNotifier(notifyURL, path, dbPath, prof.Name)
 
 	var freqColl dataimport.FreqsCollector
 	if dbPath != "" {
-		freqColl = dataimport.NewFreqs(
+		pairWeighting, err := dataimport.NewPairWeighting(prof.PairWeighting, prof.DeprelIdx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
+			os.Exit(1)
+		}
+		freqs := dataimport.NewFreqs(
 			prof.LemmaIdx,
 			prof.PosIdx,
 			prof.DeprelIdx,
 			prof.TextTypesAttr,
 			prof.TextTypes,
 		)
+		freqs.SetPairWeighting(pairWeighting)
+		freqs.SetDeprelPathLabels(prof.DeprelPathLabels)
+		freqs.SetPathCollocationsDepth(prof.PathCollocationsDepth)
+		freqs.SetPairExamples(prof.PairExamples)
+		freqs.SetIndexWordForms(prof.IndexWordForms)
+		freqs.SetMorphFeats(prof.FeatsIdx, prof.MorphFeats)
+		if err := freqs.SetSpilling(spillThreshold, tmpDir); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
+			os.Exit(1)
+		}
+		freqColl = freqs
 		db, err = storage.OpenDBIgnoreMetadata(dbPath, prof.TextTypes)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
 			os.Exit(2)
 		}
+		db.SetWriteBatchSize(writeBatchSize)
+		db.SetPairExamplesLimit(prof.PairExamples)
 
 	} else {
+		appendData = false
 		freqColl = dataimport.NewNullFreqs(prof.LemmaIdx, prof.PosIdx, prof.DeprelIdx, verbose)
 	}
+	freqColl.SetPathPolicy(prof.PathPolicy)
+
+	keys := record.NarrowKeyLayout
+	if prof.WideTokenIDs {
+		keys = record.WideKeyLayout
+	}
+
+	// appended data must share the deprel codes with the existing ones
+	var prevMetadata storage.Metadata
+	if appendData {
+		prevMetadata, err = db.StoredMetadata()
+		if err == nil && prevMetadata.ProfileName != prof.Name {
+			err = fmt.Errorf(
+				"cannot append data imported with profile %s to data imported with profile %s",
+				prof.Name, prevMetadata.ProfileName)
+		}
+		if err == nil && !slices.Equal(prevMetadata.MorphFeats, prof.MorphFeats) {
+			err = fmt.Errorf(
+				"cannot append data split by morphological features [%s] to data split by [%s]",
+				strings.Join(prof.MorphFeats, ", "), strings.Join(prevMetadata.MorphFeats, ", "))
+		}
+		if err == nil && !maps.Equal(prevMetadata.FileTextTypes, fileTextTypesMapping(prof, fileTextTypes)) {
+			err = fmt.Errorf("cannot append data with different file text types")
+		}
+		if err == nil && prof.WideTokenIDs && db.KeyLayout() != keys {
+			err = fmt.Errorf(
+				"cannot append data with 8-byte token IDs to data with %d-byte token IDs",
+				db.KeyLayout().TokenIDWidth())
+		}
+		if err == nil {
+			err = record.UDDeprelMapping.RegisterAll(prevMetadata.DeprelMap)
+		}
+		// the layout of the existing data is kept
+		keys = db.KeyLayout()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
+			os.Exit(2)
+		}
+	}
 	proc := dataimport.NewSearcher(
 		50, prof.LemmaIdx, prof.PosIdx, prof.ParentIdx, prof.DeprelIdx, freqColl,
 	)
+	proc.SetExtractSiblings(prof.ExtractSiblings)
+	proc.SetSentenceStruct(prof.SentenceStruct)
+	deprelBlocklist, err := dataimport.NewDeprelBlocklist(prof.DeprelBlocklist)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		notifier.Failure(err)
+		os.Exit(1)
+	}
+	proc.SetDeprelBlocklist(deprelBlocklist)
+	proc.SetWorkers(numWorkers)
 	ctx := context.Background()
-	files, err := determineFilesToProc(path)
+	files, err := dataimport.SelectVertFiles(path, fileSel)
+	if err == nil && len(files) == 0 {
+		err = fmt.Errorf("no files to import found in %s", path)
+	}
+	if err == nil {
+		err = fileTextTypes.Validate(files)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		notifier.Failure(err)
 		os.Exit(2)
 	}
+
+	progress := dataimport.NewImportProgress(files)
+	progress.SetJSONPath(progressPath)
+	if db != nil {
+		progress.SetWriteCounter(db.NumWrittenRecords)
+	}
+	proc.SetProgress(progress)
+	progress.Start(progressInterval)
+
+	// manifest allows resuming of a failed import (it makes sense
+	// only if the collected frequencies are going to be stored and
+	// they are kept in memory)
+	var manifest *dataimport.ImportManifest
+	if db != nil && spillThreshold <= 0 {
+		if manifestPath == "" {
+			manifestPath = defaultManifestPath(dbPath)
+		}
+		if skipCompleted {
+			manifest, err = dataimport.LoadImportManifest(manifestPath, prof.Name)
+			if err == nil && len(manifest.CompletedFiles) > 0 {
+				err = resumeFromManifest(manifest, freqColl, proc)
+			}
+
+		} else {
+			manifest = dataimport.NewImportManifest(manifestPath, prof.Name)
+			err = manifest.Remove()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
+			os.Exit(2)
+		}
+	}
+
 	for _, vertFile := range files {
-		pConf := vertigo.ParserConf{
-			InputFilePath:         vertFile,
-			Encoding:              "utf-8",
-			StructAttrAccumulator: "comb",
-			LogProgressEachNth:    100000,
+		if skipCompleted && manifest != nil && manifest.IsCompleted(vertFile) {
+			log.Info().Str("file", vertFile).Msg("skipping already completed file")
+			progress.SkipFile(vertFile)
+			continue
 		}
 		fmt.Fprintf(
 			os.Stderr,
 			"Starting to extract syntax data from file (min freq.: %d) %s\n-------------------\n",
 			minFreq, vertFile,
 		)
-		if parserErr := vertigo.ParseVerticalFile(ctx, &pConf, proc); parserErr != nil {
+		progress.StartFile(vertFile)
+		var fileProc vertigo.LineProcessor = proc
+		if tt, ok := fileTextTypes.TextTypeOf(vertFile); ok {
+			fileProc = dataimport.NewFileTextTypeProc(proc, tt)
+		}
+		var parserErr error
+		if conllu {
+			parserErr = dataimport.ParseConllUFile(ctx, vertFile, fileProc)
+
+		} else {
+			pConf := vertigo.ParserConf{
+				InputFilePath:         vertFile,
+				Encoding:              "utf-8",
+				StructAttrAccumulator: "comb",
+				LogProgressEachNth:    100000,
+			}
+			parserErr = vertigo.ParseVerticalFile(ctx, &pConf, fileProc)
+		}
+		// all the file's sentences must be analyzed before
+		// the collected data are saved
+		if err := proc.Wait(); err != nil && parserErr == nil {
+			parserErr = err
+		}
+		if parserErr != nil {
 			fmt.Fprintln(os.Stderr, "ERROR: ", parserErr)
+			notifier.Failure(parserErr)
 			os.Exit(3)
 		}
+		progress.FileDone()
+		if manifest != nil {
+			if err := recordCompletedFile(manifest, vertFile, freqColl, proc); err != nil {
+				fmt.Fprintln(os.Stderr, "ERROR: ", err)
+				notifier.Failure(err)
+				os.Exit(3)
+			}
+		}
 	}
 	freqColl.PrintPreview()
 
-	if err := db.Clear(); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to clear existing database: %s\n", err)
+	// the import history is preserved even if the data are replaced
+	var history []storage.ImportRun
+	if db != nil {
+		history, err = db.ImportHistory()
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to read previous import history, starting a new one")
+		}
+	}
+	progress.SetPhase(dataimport.PhaseStoring)
+	var stats storage.ImportStats
+	if appendData {
+		stats, err = freqColl.StoreToDb(dataimport.AppendingStorage{DB: db}, minFreq)
+
+	} else {
+		if err := db.Clear(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to clear existing database: %s\n", err)
+		}
+		db.SetKeyLayout(keys)
+		stats, err = freqColl.StoreToDb(db, minFreq)
 	}
-	stats, err := freqColl.StoreToDb(db, minFreq)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		notifier.Failure(err)
 		os.Exit(2)
 	}
+	progress.SetPhase(dataimport.PhasePostprocessing)
+	var numHotLemmas int
+	if db != nil {
+		numHotLemmas, err = db.StoreHotLemmaSummaries(hotLemmaThreshold)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
+			os.Exit(2)
+		}
+	}
 
 	metadata := storage.Metadata{
-		CorpusSize:    proc.ImportedCorpusSize(),
-		NumCollFreqs:  stats.NumCollFreqs,
-		NumLemmaFreqs: stats.NumLemmaFreqs,
-		NumLemmas:     stats.NumLemmas,
-		ProfileName:   prof.Name,
-		DeprelMap:     nil,
+		SchemaVersion:    storage.SchemaVersion,
+		TokenIDWidth:     keys.TokenIDWidth(),
+		CorpusSize:       proc.ImportedCorpusSize(),
+		NumCollFreqs:     stats.NumCollFreqs,
+		NumLemmaFreqs:    stats.NumLemmaFreqs,
+		NumLemmas:        stats.NumLemmas,
+		MinPairFreq:      minFreq,
+		ProfileName:      prof.Name,
+		DeprelMap:        nil,
+		PairWeighting:    prof.PairWeighting,
+		PathPolicy:       prof.PathPolicy,
+		Siblings:         prof.ExtractSiblings,
+		DeprelPathLabels: prof.DeprelPathLabels,
+		DeprelBlocklist:  prof.DeprelBlocklist,
+		SurfaceDist:      true,
+		TokenFreqRollups: true,
+		TextTypeLabels:   prof.TextTypeLabels,
+		FileTextTypes:    fileTextTypesMapping(prof, fileTextTypes),
+		Relations:        prof.Relations,
+		RelationDists:    stats.RelationDists,
 	}
-
-	for _, v := range proc.CollectedDeprels() {
-		record.UDDeprelMapping.Register(v)
+	if numHotLemmas > 0 {
+		metadata.HotLemmaThreshold = hotLemmaThreshold
+		metadata.NumHotLemmas = numHotLemmas
+	}
+	metadata.Features = append(metadata.AvailableFeatures(), storage.FeatureFoldedLemmas, storage.FeatureLowerLemmas)
+	if prof.IndexWordForms {
+		metadata.NumWordForms = stats.NumWordForms
+		metadata.Features = append(metadata.Features, storage.FeatureWordForms)
+	}
+	if len(prof.MorphFeats) > 0 {
+		metadata.MorphFeats = prof.MorphFeats
+		metadata.Features = append(metadata.Features, storage.FeatureMorphFeats)
 	}
+	if prof.PathCollocationsDepth > 1 {
+		metadata.PathCollocationsDepth = prof.PathCollocationsDepth
+		metadata.Features = append(metadata.Features, storage.FeaturePathCollocations)
+	}
+	if prof.PairExamples > 0 {
+		metadata.PairExamples = prof.PairExamples
+		metadata.Features = append(metadata.Features, storage.FeaturePairExamples)
+	}
+	if appendData {
+		metadata = appendedMetadata(prevMetadata, metadata)
+	}
+
+	// note: extended deprels are registered as soon as they are found
+	// during the import so here we just take the final mapping
+	log.Info().Strs("values", proc.CollectedDeprels()).Msg("collected extended deprels")
 	metadata.DeprelMap = record.UDDeprelMapping.AsMap()
+
+	// top collocations are calculated using the final metadata
+	// (corpus size, features) of the whole dataset
+	if db != nil && topCollsThreshold > 0 {
+		db.Metadata = metadata
+		db.DeprelMapping = record.DeprelMappingFromMap(metadata.DeprelMap)
+		numTopLemmas, err := db.StoreTopCollocations(
+			context.Background(),
+			storage.TopCollocationsArgs{
+				MinLemmaFreq:      topCollsThreshold,
+				Limit:             topCollsLimit,
+				ExcludedTextTypes: prof.RestrictedTextTypes,
+			},
+		)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
+			os.Exit(2)
+		}
+		if numTopLemmas > 0 {
+			metadata.TopCollocations = &storage.TopCollocationsInfo{
+				MinLemmaFreq:      topCollsThreshold,
+				Limit:             topCollsLimit,
+				NumLemmas:         numTopLemmas,
+				ExcludedTextTypes: prof.RestrictedTextTypes,
+			}
+			metadata.Features = append(metadata.Features, storage.FeatureTopCollocations)
+		}
+	}
 	if err := db.StoreMetadata(metadata); err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		notifier.Failure(err)
 		os.Exit(4)
 	}
 
+	if db != nil {
+		run := storage.ImportRun{
+			Timestamp:         time.Now(),
+			Files:             files,
+			ProfileName:       prof.Name,
+			CorpusSize:        metadata.CorpusSize,
+			NumLemmas:         stats.NumLemmas,
+			NumLemmaFreqs:     stats.NumLemmaFreqs,
+			NumCollFreqs:      stats.NumCollFreqs,
+			MinPairFreq:       minFreq,
+			HotLemmaThreshold: hotLemmaThreshold,
+			ClearedPrevious:   !appendData,
+		}
+		if err := db.StoreImportHistory(append(history, run)); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			notifier.Failure(err)
+			os.Exit(4)
+		}
+	}
+
 	log.Info().
 		Int64("corpusSize", metadata.CorpusSize).
 		Int("numCollFreqs", metadata.NumCollFreqs).
 		Int("numLemmaFreqs", metadata.NumLemmaFreqs).
 		Int("numLemmas", metadata.NumLemmas).
+		Int("numHotLemmas", metadata.NumHotLemmas).
+		Int("numPathCollFreqs", stats.NumPathCollFreqs).
+		Int("numPairExamples", stats.NumPairExamples).
 		Str("profileName", metadata.ProfileName).
 		Msg("collected and stored dataset metadata")
 	fmt.Fprintf(
@@ -143,8 +526,17 @@ func runCommand(path, dbPath string, prof storage.Profile, minFreq int, verbose
 		"import stats - total lemmas: %d, num single lemma freqs: %d, num coll freqs: %d",
 		stats.NumLemmas, stats.NumLemmaFreqs, stats.NumCollFreqs,
 	)
+	progress.SetPhase(dataimport.PhaseFinished)
+	progress.Stop()
+
+	if manifest != nil {
+		if err := manifest.Remove(); err != nil {
+			log.Warn().Err(err).Msg("failed to remove finished import manifest")
+		}
+	}
 
 	db.Close() // this is ok to be called on possible nil
+	notifier.Success(metadata)
 
 }
 
@@ -161,10 +553,45 @@ func main() {
 	posIdx := flag.Int("pos-idx", 5, "vertical file column position where PoS is located (overrides importProfile)")
 	parentIdx := flag.Int("parent-idx", 12, "vertical file column position where syntactic parent info is stored (overrides importProfile)")
 	deprelIdx := flag.Int("deprel-idx", 11, "vertical file column position where syntactic function is stored (overrides importProfile)")
+	sentStruct := flag.String("sent-struct", "", "name of the structure delimiting sentences in vertical files (default: s; overrides importProfile)")
 	iProfile := flag.String("import-profile", "", "select a predefined lemma-idx, pos-idx etc. based on corpus name (e.g. intercorp_v16ud)")
 	verbose := flag.Bool("verbose", true, "print more info about program activity")
 	minFreq := flag.Int("min-freq", 20, "minimal freq. of collocates to be accepted")
 	logLevel := flag.String("log-level", "info", "set log level (debug, info, warn, error)")
+	pairWeighting := flag.String("pair-weighting", "", "weighting applied to co-occurrence frequencies (none, direct-link, distance-decay, clause-boundary; overrides importProfile)")
+	pathPolicy := flag.String("path-policy", "", "token pairs on a syntax tree path imported as co-occurrences (head, grandparent, full-path; default: grandparent; overrides importProfile)")
+	pathMaxDepth := flag.Int("path-max-depth", 0, "max. distance of paired tokens for the full-path policy (0 = no limit)")
+	pathAncestorDepth := flag.Int("path-ancestor-depth", 0, "if positive, it limits the distance of ancestors paired with a token - i.e. it makes the path window asymmetric (overrides importProfile)")
+	pathDescendantDepth := flag.Int("path-descendant-depth", 0, "if positive, it limits the distance of descendants paired with a token - i.e. it makes the path window asymmetric (overrides importProfile)")
+	siblings := flag.Bool("siblings", false, "if set, pairs of tokens sharing the same head will be imported too (with the 'sibling' pseudo-deprel)")
+	deprelPathLabels := flag.Bool("deprel-path-labels", false, "if set, pairs connected via other nodes are stored with a whole relation path label (e.g. obj→amod)")
+	pathCollsDepth := flag.Int("path-collocations-depth", 0, "if at least 2, pairs connected via up to the number of relations (e.g. verb → obj → amod for 2) are stored separately with their relation path labels so they can be searched on demand (overrides importProfile)")
+	pairExamples := flag.Int("pair-examples", 0, "if positive, up to the number of occurrences (corpus positions of both the tokens) is stored for each pair so collocations can be linked to concordances (overrides importProfile)")
+	wideTokenIDs := flag.Bool("wide-token-ids", false, "if set, 8-byte token IDs are used so the database can store more than 2^31 distinct lemmas (e.g. for large multilingual corpora; overrides importProfile)")
+	wordForms := flag.Bool("word-forms", false, "if set, word forms will be indexed too so collocations can be searched also by word forms (databases with word forms cannot be merged)")
+	featsIdx := flag.Int("feats-idx", 0, "vertical file column position where UD morphological features (FEATS) are located (overrides importProfile)")
+	morphFeats := flag.String("morph-feats", "", "comma-separated UD morphological features (e.g. Case,Number) single tokens and pairs will be split by (overrides importProfile)")
+	deprelBlocklist := flag.String("deprel-blocklist", "", "comma-separated syntactic relations whose dependents are ignored (an item ending with * matches all relations with the prefix, e.g. aux*); 'none' disables the blocklist (default: punct,cc,det*,aux*,cop,mark,expl*,... - see README; overrides importProfile)")
+	deprelBlocklistFile := flag.String("deprel-blocklist-file", "", "a JSON file with a list of blocklisted syntactic relations (see -deprel-blocklist; overrides importProfile)")
+	relations := flag.String("relations", "", "a JSON file with a list of named relations ([{\"name\": ..., \"nodeSide\": \"head|dependent\", \"deprels\": [...], \"nodePos\": [...], \"collocatePos\": [...]}, ...]) available for searches (overrides importProfile)")
+	textTypeLabels := flag.String("text-type-labels", "", "a JSON file with a list of text type display names ([{\"value\": ..., \"displayName\": ...}, ...]) in their display order (overrides importProfile)")
+	topCollsThreshold := flag.Int("top-colls-threshold", 0, "lemmas with frequency at least the value get their top collocations (for each sorting measure) precomputed so plain searches are served without scanning (0 = disabled)")
+	topCollsLimit := flag.Int("top-colls-limit", storage.DefaultTopCollocationsLimit, "number of precomputed top collocations per lemma and sorting measure (see -top-colls-threshold)")
+	hotLemmaThreshold := flag.Int("hot-lemma-threshold", storage.DefaultHotLemmaThreshold, "lemmas with more collocation records than the value get pre-aggregated summaries speeding up searches (0 = disabled)")
+	progressInterval := flag.Duration("progress-interval", dataimport.DefaultProgressInterval, "period of import progress reports (processed tokens and sentences, collected and written records, ETA) logged during the import (0 = disabled)")
+	progressPath := flag.String("progress-file", "", "if set, the import progress is also written as JSON to the file on each report")
+	notifyURL := flag.String("notify-url", "", "if set, a JSON report with import stats will be POSTed to the URL once the import finishes (or fails)")
+	manifestPath := flag.String("manifest", "", "a path of a manifest recording completed vertical files along with their collected frequencies (default: [db_path].import-manifest.json)")
+	include := flag.String("include", "", "comma-separated file name patterns (e.g. *.vert,*.vrt) of files to be imported from a directory (default: all files)")
+	exclude := flag.String("exclude", "", "comma-separated file name patterns of files never imported from a directory (e.g. README*)")
+	inputFormat := flag.String("input-format", "", "format of input files (vert, conllu; default: conllu for a file with the .conllu suffix, vert otherwise); for CoNLL-U, column positions are set automatically")
+	writeBatchSize := flag.Int("write-batch-size", storage.DefaultWriteBatchSize, "number of records committed to the database at once when storing the collected data")
+	spillThreshold := flag.Int("spill-threshold", 0, "if positive, collected records are flushed to temporary files once their number in memory reaches the value so corpora larger than memory can be imported (cannot be combined with -skip-completed)")
+	tmpDir := flag.String("tmp-dir", "", "directory for temporary files with spilled records (system default if empty)")
+	numWorkers := flag.Int("workers", 1, "number of goroutines analyzing parsed sentences (each of them collects its own frequencies so memory usage grows with the value)")
+	appendData := flag.Bool("append", false, "if set, the imported data are merged into the existing database instead of replacing it (the same import profile must be used)")
+	fileTextTypes := flag.String("file-text-types", "", "comma-separated pattern=text_type rules (e.g. news*.vert=news,fic*=fiction) deriving text types of imported files from their names; the first matching rule is applied and each file must match some (cannot be combined with text types of importProfile)")
+	skipCompleted := flag.Bool("skip-completed", false, "if set, files recorded as completed in the manifest by a previous failed run are not processed again")
 	flag.Parse()
 
 	logging.SetupLogging(logging.LoggingConf{
@@ -188,6 +615,156 @@ func main() {
 			DeprelIdx: *deprelIdx,
 		}
 	}
-	runCommand(flag.Arg(0), flag.Arg(1), cprof, *minFreq, *verbose)
+	var conllu bool
+	switch *inputFormat {
+	case "conllu":
+		conllu = true
+	case "":
+		conllu = dataimport.IsConllUFile(flag.Arg(0))
+	case "vert":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown input format %s\n", *inputFormat)
+		os.Exit(1)
+	}
+	if *sentStruct != "" {
+		cprof.SentenceStruct = *sentStruct
+	}
+	if conllu {
+		cprof = dataimport.ConllUProfile(cprof)
+	}
+	if *pairWeighting != "" {
+		cprof.PairWeighting = *pairWeighting
+	}
+	if *siblings {
+		cprof.ExtractSiblings = true
+	}
+	if *deprelPathLabels {
+		cprof.DeprelPathLabels = true
+	}
+	if *pathCollsDepth > 0 {
+		cprof.PathCollocationsDepth = *pathCollsDepth
+	}
+	if *pairExamples > 0 {
+		cprof.PairExamples = *pairExamples
+	}
+	if *wideTokenIDs {
+		cprof.WideTokenIDs = true
+	}
+	if *wordForms {
+		cprof.IndexWordForms = true
+	}
+	if *featsIdx > 0 {
+		cprof.FeatsIdx = *featsIdx
+	}
+	if *morphFeats != "" {
+		cprof.MorphFeats = strings.Split(*morphFeats, ",")
+	}
+	if err := record.ValidateUDFeatNames(cprof.MorphFeats); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if len(cprof.MorphFeats) > 0 && cprof.FeatsIdx <= 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: ", "morphological features require a FEATS column position (-feats-idx)")
+		os.Exit(1)
+	}
+	if *deprelBlocklist == "none" {
+		cprof.DeprelBlocklist = []string{}
+
+	} else if *deprelBlocklist != "" {
+		cprof.DeprelBlocklist = strings.Split(*deprelBlocklist, ",")
+	}
+	if *deprelBlocklistFile != "" {
+		items, err := loadDeprelBlocklist(*deprelBlocklistFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+		cprof.DeprelBlocklist = items
+	}
+	if cprof.DeprelBlocklist == nil {
+		cprof.DeprelBlocklist = dataimport.DefaultDeprelBlocklist
+	}
+	if *pathPolicy != "" {
+		cprof.PathPolicy = storage.PathPolicy{Name: *pathPolicy, MaxDepth: *pathMaxDepth}
+	}
+	if *pathAncestorDepth > 0 {
+		cprof.PathPolicy.AncestorDepth = *pathAncestorDepth
+	}
+	if *pathDescendantDepth > 0 {
+		cprof.PathPolicy.DescendantDepth = *pathDescendantDepth
+	}
+	fileTTRules, err := dataimport.ParseFileTextTypes(*fileTextTypes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if len(fileTTRules) > 0 {
+		if cprof.TextTypesAttr != "" {
+			fmt.Fprintln(os.Stderr, "ERROR: ", fmt.Sprintf("-file-text-types cannot be combined with text types of import profile %s", cprof.Name))
+			os.Exit(1)
+		}
+		mapping, err := fileTTRules.Mapping()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+		cprof.TextTypesAttr = dataimport.FileTextTypeAttr
+		cprof.TextTypes = mapping
+	}
+	if n := len(storage.TextTypeAttrs(cprof.TextTypesAttr)); n > storage.MaxTextTypeDims {
+		fmt.Fprintln(os.Stderr, "ERROR: ", fmt.Sprintf("too many text type attributes (%d, max. %d)", n, storage.MaxTextTypeDims))
+		os.Exit(1)
+	}
+	if *spillThreshold > 0 && *skipCompleted {
+		fmt.Fprintln(os.Stderr, "ERROR: ", "-spill-threshold cannot be combined with -skip-completed")
+		os.Exit(1)
+	}
+	if *numWorkers < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: ", "number of workers must be at least 1")
+		os.Exit(1)
+	}
+	if err := cprof.PathPolicy.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	cprof.PathPolicy = cprof.PathPolicy.Normalized()
+	if *textTypeLabels != "" {
+		labels, err := loadTextTypeLabels(*textTypeLabels)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+		cprof.TextTypeLabels = labels
+	}
+	if err := storage.ValidateTextTypeLabels(cprof.TextTypeLabels, cprof.TextTypes); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if *relations != "" {
+		defs, err := storage.LoadRelationDefs(*relations)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+		cprof.Relations = defs
+	}
+	if err := storage.ValidateRelationDefs(cprof.Relations, &record.UDDeprelMapping); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	runCommand(
+		flag.Arg(0), flag.Arg(1), cprof, *minFreq, *hotLemmaThreshold, *writeBatchSize, *numWorkers,
+		*topCollsThreshold, *topCollsLimit,
+		*spillThreshold, *tmpDir, *verbose, *notifyURL,
+		*progressInterval, *progressPath,
+		*manifestPath, *skipCompleted,
+		dataimport.FileSelection{
+			Include: dataimport.ParseFilePatterns(*include),
+			Exclude: dataimport.ParseFilePatterns(*exclude),
+		},
+		fileTTRules,
+		conllu,
+		*appendData,
+	)
 
 }
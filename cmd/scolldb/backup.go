@@ -0,0 +1,121 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+type backupInfo struct {
+	Since     uint64 `json:"since"`
+	NextSince uint64 `json:"nextSince"`
+	SizeBytes int64  `json:"sizeBytes"`
+}
+
+func runBackup(args []string) {
+	fset := flag.NewFlagSet("backup", flag.ExitOnError)
+	since := fset.Uint64("since", 0, "create an incremental backup of records written after the version (use nextSince of the previous backup)")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "backup - write a full or an incremental backup of a database to a file\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  backup [options] [db_path] [backup_file]\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 2 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	// read-only mode allows for backing up a database used by a running server
+	db, err := storage.OpenDBReadOnly(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	f, err := os.OpenFile(fset.Arg(1), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(1)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	nextSince, err := db.Backup(w, *since)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		f.Close()
+		os.Remove(fset.Arg(1))
+		db.Close()
+		os.Exit(2)
+	}
+	info := backupInfo{Since: *since, NextSince: nextSince}
+	if stat, err := f.Stat(); err == nil {
+		info.SizeBytes = stat.Size()
+	}
+	printJSON(info)
+}
+
+func runRestore(args []string) {
+	fset := flag.NewFlagSet("restore", flag.ExitOnError)
+	maxPendingWrites := fset.Int("max-pending-writes", storage.DefaultRestoreMaxPendingWrites, "max. number of pending writes during the restore")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "restore - load backup files (a full backup followed by incremental ones) to a database\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  restore [options] [db_path] [backup_file]...\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() < 2 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	db, err := storage.OpenDBIgnoreMetadata(fset.Arg(0), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	for _, path := range fset.Args()[1:] {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			db.Close()
+			os.Exit(1)
+		}
+		err = db.Restore(f, *maxPendingWrites)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR:  %s: %s\n", path, err)
+			db.Close()
+			os.Exit(2)
+		}
+	}
+	metadata, err := db.StoredMetadata()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(2)
+	}
+	printJSON(metadata)
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/czcorpus/depreldb/evaluation"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// readLemmas reads a list of lemmas (one per line, empty lines
+// and lines starting with # are ignored)
+func readLemmas(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lemmas: %w", err)
+	}
+	defer f.Close()
+	ans := make([]string, 0, 100)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ans = append(ans, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lemmas: %w", err)
+	}
+	return ans, nil
+}
+
+func runCalibrate(args []string) {
+	fset := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	sortByA := fset.String("sort-by-a", "", "sorting measure for the first ranking (if omitted, corpus default is used)")
+	sortByB := fset.String("sort-by-b", "", "sorting measure for the second ranking (if omitted, -sort-by-a is used)")
+	limit := fset.Int("limit", 50, "max. number of collocates compared for each lemma")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "calibrate - compare collocate rankings of two databases or two measures\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  calibrate [options] [lemmas_file] [db_path or URL] [optional second db_path or URL]\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() < 2 || fset.NArg() > 3 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	lemmas, err := readLemmas(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if *sortByB == "" {
+		*sortByB = *sortByA
+	}
+	for _, m := range []string{*sortByA, *sortByB} {
+		if m != "" && !storage.SortingMeasure(m).Validate() {
+			fmt.Fprintf(os.Stderr, "ERROR: invalid sorting measure %s\n", m)
+			os.Exit(1)
+		}
+	}
+	providerA := openProvider(fset.Arg(1))
+	providerB := providerA
+	if fset.NArg() == 3 {
+		providerB = openProvider(fset.Arg(2))
+	}
+	report := evaluation.CalibrateRankings(
+		lemmas,
+		evaluation.RankingSource{
+			Provider: providerA,
+			Options: []func(opts *scoll.CalculationOptions){
+				scoll.WithLimit(*limit), scoll.WithSortBy(storage.SortingMeasure(*sortByA))},
+		},
+		evaluation.RankingSource{
+			Provider: providerB,
+			Options: []func(opts *scoll.CalculationOptions){
+				scoll.WithLimit(*limit), scoll.WithSortBy(storage.SortingMeasure(*sortByB))},
+		},
+	)
+	printJSON(report)
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+func runDeleteTextType(args []string) {
+	fset := flag.NewFlagSet("delete-text-type", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "delete-text-type - remove all the frequency records of a text type (e.g. a corpus section imported by mistake)\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  delete-text-type [db_path] [text_type]\n")
+	}
+	fset.Parse(args)
+	if fset.NArg() != 2 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	db, err := storage.OpenDB(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	ans, err := db.DeleteTextType(fset.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(2)
+	}
+	printJSON(ans)
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/czcorpus/depreldb/evaluation"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+func runEvaluate(args []string) {
+	fset := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	limit := fset.Int("limit", 100, "number of retrieved collocates per lemma")
+	measures := fset.String("measures", "", "comma-separated measures to evaluate (if omitted, all measures are evaluated)")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "evaluate - evaluate measures against a gold standard list of collocations\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  evaluate [options] [gold_tsv_path] [db_path or server URL]\n\n")
+		fmt.Fprintf(os.Stderr, "The gold standard file contains tab-separated lemma, collocate and optional deprel.\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 2 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	gold, err := evaluation.ReadGoldStandard(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	evalMeasures := storage.SortingMeasures
+	if *measures != "" {
+		evalMeasures = []storage.SortingMeasure{}
+		for _, m := range strings.Split(*measures, ",") {
+			measure := storage.SortingMeasure(strings.TrimSpace(m))
+			if !measure.Validate() {
+				fmt.Fprintf(os.Stderr, "ERROR: invalid measure %s\n", m)
+				os.Exit(1)
+			}
+			evalMeasures = append(evalMeasures, measure)
+		}
+	}
+	printJSON(evaluation.EvaluateGoldStandard(gold, openProvider(fset.Arg(1)), evalMeasures, *limit))
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/dataimport"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+func runInferProfile(args []string) {
+	fset := flag.NewFlagSet("infer-profile", flag.ExitOnError)
+	sentStruct := fset.String("sent-struct", dataimport.DefaultSentenceStruct, "name of the structure delimiting sentences")
+	sampleLines := fset.Int("sample-lines", dataimport.DefaultInferenceSampleLines, "number of vertical file lines to analyze")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "infer-profile - guess lemma, PoS, parent and deprel column positions of a vertical file\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  infer-profile [options] [vert_path]\n\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	inferrer := dataimport.NewProfileInferrer()
+	inferrer.SetSentenceStruct(*sentStruct)
+	pConf := vertigo.ParserConf{
+		InputFilePath:         fset.Arg(0),
+		Encoding:              "utf-8",
+		StructAttrAccumulator: "comb",
+		MaxReadLines:          *sampleLines,
+		LogProgressEachNth:    100000,
+	}
+	if err := vertigo.ParseVerticalFile(context.Background(), &pConf, inferrer); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	ans := inferrer.Infer()
+	printJSON(ans)
+	if !ans.Complete() {
+		fmt.Fprintln(os.Stderr, "ERROR: failed to find all the required columns")
+		os.Exit(2)
+	}
+	fmt.Fprintf(os.Stderr, "\nProposed import arguments (please check them):\n  mkscolldb %s\n", ans.ImportArgs())
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+func writeLexiconTSV(w io.Writer, items []storage.Collocation, sortBy storage.SortingMeasure) error {
+	fmt.Fprintln(w, "rank\thead\thead_pos\tdeprel\tdependent\tdependent_pos\tfreq\thead_freq\tdependent_freq\t"+string(sortBy))
+	for i, item := range items {
+		if _, err := fmt.Fprintf(
+			w, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%.4f\n",
+			i+1, item.Lemma.Value, item.Lemma.PoS, item.Deprel, item.Collocate.Value, item.Collocate.PoS,
+			item.Freq, item.LemmaFreq, item.CollocateFreq, sortBy.ValueOf(item),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeLexiconJSONL(w io.Writer, items []storage.Collocation) error {
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runLexicon(args []string) {
+	fset := flag.NewFlagSet("lexicon", flag.ExitOnError)
+	sortBy := fset.String("sort-by", "ldice", "measure the lexicon is ranked by (tscore, ldice, lmi, ll, rrf)")
+	minFreq := fset.Int("min-freq", 5, "min. frequency of a pair")
+	minScore := fset.Float64("min-score", 0, "if set, pairs with the ranking measure lower than the value are removed")
+	limit := fset.Int("limit", 0, "if set, max. number of top ranked pairs written")
+	excludeTT := fset.String("exclude-tt", "", "comma-separated text types ignored completely")
+	deprelGranularity := fset.String("deprel-granularity", "", "if set to core, relation subtypes are merged into their core relations")
+	format := fset.String("format", "tsv", "output format (tsv, jsonl)")
+	outPath := fset.String("o", "", "output file (if omitted, stdout is used)")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "lexicon - score all the stored pairs and write a ranked global collocation lexicon\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  lexicon [options] [db_path]\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "tsv" && *format != "jsonl" {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid output format %s\n", *format)
+		os.Exit(1)
+	}
+	lexArgs := storage.LexiconArgs{
+		SortBy:            storage.SortingMeasure(*sortBy),
+		MinFreq:           *minFreq,
+		Limit:             *limit,
+		DeprelGranularity: storage.DeprelGranularity(*deprelGranularity),
+	}
+	if !lexArgs.SortBy.Validate() {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid measure %s\n", *sortBy)
+		os.Exit(1)
+	}
+	if !lexArgs.DeprelGranularity.Validate() {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid deprel granularity %s\n", *deprelGranularity)
+		os.Exit(1)
+	}
+	fset.Visit(func(f *flag.Flag) {
+		if f.Name == "min-score" {
+			lexArgs.MinScore = minScore
+		}
+	})
+	if *excludeTT != "" {
+		for _, tt := range strings.Split(*excludeTT, ",") {
+			lexArgs.ExcludedTextTypes = append(lexArgs.ExcludedTextTypes, strings.TrimSpace(tt))
+		}
+	}
+
+	db, err := storage.OpenDB(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	items, err := db.ExtractLexicon(context.Background(), lexArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if *outPath != "" {
+		out, err = os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+	w := bufio.NewWriter(out)
+	if *format == "jsonl" {
+		err = writeLexiconJSONL(w, items)
+
+	} else {
+		err = writeLexiconTSV(w, items, lexArgs.SortBy)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "written %d collocations\n", len(items))
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+func runMigrate(args []string) {
+	fset := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fset.Bool("dry-run", false, "if set, only pending migrations are listed")
+	force := fset.Bool("force", false, "if set, the migration is performed even if the database contains keys of unknown namespaces")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "migrate - upgrade a database created by an older version to the current schema (version %d) in place\n\n", storage.SchemaVersion)
+		fmt.Fprintf(os.Stderr, "Usage:\n  migrate [options] [db_path]\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	db, err := storage.OpenDB(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if *dryRun {
+		printJSON(db.Metadata.PendingMigrations())
+		return
+	}
+	unknown, err := db.UnknownKeyPrefixes()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(2)
+	}
+	if len(unknown) > 0 && !*force {
+		fmt.Fprintln(os.Stderr, "ERROR:  the database contains keys of unknown namespaces (use -force to migrate anyway):")
+		printJSON(unknown)
+		db.Close()
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ans, err := db.Migrate(ctx)
+	printJSON(ans)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+func runRelations(args []string) {
+	fset := flag.NewFlagSet("relations", flag.ExitOnError)
+	setPath := fset.String("set", "", "a JSON file with relation definitions to be stored in the database (replacing the current ones)")
+	clearDefs := fset.Bool("clear", false, "if set, relations defined by the database are removed (built-in relations remain)")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "relations - show or set named relations available for searches in a database\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  relations [options] [db_path]\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 || *setPath != "" && *clearDefs {
+		fset.Usage()
+		os.Exit(1)
+	}
+	db, err := storage.OpenDB(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if *setPath != "" || *clearDefs {
+		var defs []storage.RelationDef
+		if *setPath != "" {
+			defs, err = storage.LoadRelationDefs(*setPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "ERROR: ", err)
+				db.Close()
+				os.Exit(1)
+			}
+		}
+		mapping := db.DeprelMapping
+		if mapping == nil {
+			mapping = &record.UDDeprelMapping
+		}
+		if err := storage.ValidateRelationDefs(defs, mapping); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			db.Close()
+			os.Exit(1)
+		}
+		db.Metadata.Relations = defs
+		if err := db.StoreMetadata(db.Metadata); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			db.Close()
+			os.Exit(1)
+		}
+	}
+	printJSON(scoll.FromDatabase(db).Relations())
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+func runRemapIDs(args []string) {
+	fset := flag.NewFlagSet("remap-ids", flag.ExitOnError)
+	tmpDir := fset.String("tmp-dir", "", "directory for a temporary ID mapping database (system default if empty)")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "remap-ids - copy a database with token IDs rewritten according to another database's lemma index\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  remap-ids [options] [source_db] [target_db] [output_db]\n\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 3 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	src, err := storage.OpenDB(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer src.Close()
+	target, err := storage.OpenDB(fset.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer target.Close()
+	dst, err := storage.OpenDBIgnoreMetadata(fset.Arg(2), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer dst.Close()
+	stats, err := storage.RemapTokenIDs(src, target, dst, *tmpDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(2)
+	}
+	printJSON(stats)
+}
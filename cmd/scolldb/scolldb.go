@@ -0,0 +1,152 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/client"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// subcommand represents a single scolldb action
+type subcommand struct {
+	help string
+	run  func(args []string)
+}
+
+var subcommands = map[string]subcommand{
+	"backup": {
+		help: "write a full or an incremental backup of a database to a file",
+		run:  runBackup,
+	},
+	"calibrate": {
+		help: "compare collocate rankings (Spearman, Kendall) of two databases or measures",
+		run:  runCalibrate,
+	},
+	"delete-text-type": {
+		help: "remove all the frequency records of a text type and update derived data",
+		run:  runDeleteTextType,
+	},
+	"evaluate": {
+		help: "evaluate measures against a gold standard (precision, recall, MAP)",
+		run:  runEvaluate,
+	},
+	"import-history": {
+		help: "show recorded import runs (time, files, sizes, thresholds) of a database",
+		run:  runImportHistory,
+	},
+	"infer-profile": {
+		help: "guess import column positions (lemma, PoS, parent, deprel) of a vertical file",
+		run:  runInferProfile,
+	},
+	"lexicon": {
+		help: "score all the stored pairs and write a ranked global collocation lexicon",
+		run:  runLexicon,
+	},
+	"migrate": {
+		help: "upgrade a database created by an older version to the current schema in place",
+		run:  runMigrate,
+	},
+	"relations": {
+		help: "show or set named relations (e.g. verbs-object) available for searches",
+		run:  runRelations,
+	},
+	"remap-ids": {
+		help: "rewrite token IDs of a database according to another database's lemma index (for merging)",
+		run:  runRemapIDs,
+	},
+	"restore": {
+		help: "load backup files (a full backup followed by incremental ones) to a database",
+		run:  runRestore,
+	},
+	"validate-vert": {
+		help: "check a vertical file (columns, parents, cycles, tag inventories) before an import",
+		run:  runValidateVert,
+	},
+	"verify-snapshot": {
+		help: "replay queries recorded in a snapshot file and report result drift",
+		run:  runVerifySnapshot,
+	},
+}
+
+// openProvider opens either a local database or a remote
+// server (in case the path is a http(s) URL).
+func openProvider(path string) scoll.CollocationProvider {
+	if isURL(path) {
+		return client.New(path)
+	}
+	db, err := storage.OpenDB(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	// all the queries of a batch should see the same data
+	db.PinSnapshot()
+	return scoll.FromDatabase(db)
+}
+
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+func printJSON(v any) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "scolldb - collocation database maintenance and evaluation tools\n\n")
+	fmt.Fprintf(os.Stderr, "Usage:\n  %s [-log-level level] subcommand [options] [args]\n\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(os.Stderr, "Subcommands:\n")
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, subcommands[name].help)
+	}
+	fmt.Fprintf(os.Stderr, "\nUse %s subcommand -h for subcommand options\n", filepath.Base(os.Args[0]))
+}
+
+func main() {
+	logLevel := flag.String("log-level", "warn", "set log level (debug, info, warn, error)")
+	flag.Usage = usage
+	flag.Parse()
+	logging.SetupLogging(logging.LoggingConf{
+		Level: logging.LogLevel(*logLevel),
+	})
+	cmd, ok := subcommands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(1)
+	}
+	cmd.run(flag.Args()[1:])
+}
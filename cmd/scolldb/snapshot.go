@@ -0,0 +1,55 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/evaluation"
+)
+
+func runVerifySnapshot(args []string) {
+	fset := flag.NewFlagSet("verify-snapshot", flag.ExitOnError)
+	maxRankShift := fset.Int("max-rank-shift", 0, "max. allowed change of a collocate position")
+	maxScoreDiff := fset.Float64("max-score-diff", 0.01, "max. allowed absolute difference of a score")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "verify-snapshot - replay recorded queries and report result drift\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  verify-snapshot [options] [snapshot_path] [db_path or server URL]\n\nOptions:\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 2 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	entries, err := evaluation.ReadSnapshot(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	report := evaluation.VerifySnapshot(
+		entries,
+		openProvider(fset.Arg(1)),
+		evaluation.DriftTolerance{MaxRankShift: *maxRankShift, MaxScoreDiff: *maxScoreDiff},
+	)
+	printJSON(report)
+	if !report.OK() {
+		os.Exit(2)
+	}
+}
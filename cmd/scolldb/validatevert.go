@@ -0,0 +1,82 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/dataimport"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+func runValidateVert(args []string) {
+	fset := flag.NewFlagSet("validate-vert", flag.ExitOnError)
+	lemmaIdx := fset.Int("lemma-idx", 2, "vertical file column position where lemma is located")
+	posIdx := fset.Int("pos-idx", 5, "vertical file column position where PoS is located")
+	parentIdx := fset.Int("parent-idx", 12, "vertical file column position where syntactic parent info is stored")
+	deprelIdx := fset.Int("deprel-idx", 11, "vertical file column position where syntactic function is stored")
+	sentStruct := fset.String("sent-struct", "", "name of the structure delimiting sentences (default: s; overrides import-profile)")
+	iProfile := fset.String("import-profile", "", "select a predefined lemma-idx, pos-idx etc. based on corpus name (e.g. intercorp_v16ud)")
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "validate-vert - check a vertical file for problems affecting an import (nothing is written)\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  validate-vert [options] [vert_path]\n\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(1)
+	}
+
+	prof := storage.Profile{
+		LemmaIdx:  *lemmaIdx,
+		PosIdx:    *posIdx,
+		ParentIdx: *parentIdx,
+		DeprelIdx: *deprelIdx,
+	}
+	if *iProfile != "" {
+		prof = storage.FindProfile(*iProfile)
+		if prof.IsZero() {
+			fmt.Fprintf(os.Stderr, "import profile %s not found\n", *iProfile)
+			os.Exit(1)
+		}
+	}
+	if *sentStruct != "" {
+		prof.SentenceStruct = *sentStruct
+	}
+	validator := dataimport.NewVertValidator(prof.LemmaIdx, prof.PosIdx, prof.ParentIdx, prof.DeprelIdx)
+	validator.SetSentenceStruct(prof.SentenceStruct)
+	pConf := vertigo.ParserConf{
+		InputFilePath:         fset.Arg(0),
+		Encoding:              "utf-8",
+		StructAttrAccumulator: "comb",
+		LogProgressEachNth:    100000,
+	}
+	if err := vertigo.ParseVerticalFile(context.Background(), &pConf, validator); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	report := validator.Report()
+	printJSON(report)
+	if !report.OK() {
+		os.Exit(2)
+	}
+}
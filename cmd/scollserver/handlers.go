@@ -0,0 +1,276 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/depreldb/client"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// pathMetrics is a path of the (optional) metrics endpoint
+const pathMetrics = "/metrics"
+
+// maxDeprelStatsExamples is the max. number of examples provided
+// for each relation by the deprel statistics endpoint. The statistics
+// are always calculated (and cached) with this number of examples
+// and then truncated as requested.
+const maxDeprelStatsExamples = 100
+
+// server exposes a collocation provider via a REST API
+// compatible with client.Client
+type server struct {
+	calc    scoll.CollocationProvider
+	apiKeys []string
+
+	// queryTimeout limits the time a single search may take
+	// (0 = no limit besides the client disconnecting)
+	queryTimeout time.Duration
+
+	// deprelStats caches results of deprel statistics (for public
+	// and restricted access) as they require walking through
+	// the whole database
+	deprelStats   map[bool][]storage.DeprelStats
+	deprelStatsMu sync.Mutex
+
+	// deprelStatsCalc makes concurrent requests share a single
+	// calculation of the statistics
+	deprelStatsCalc singleflight.Group
+}
+
+func newServer(calc scoll.CollocationProvider, apiKeys []string, queryTimeout time.Duration) *server {
+	return &server{
+		calc:         calc,
+		apiKeys:      apiKeys,
+		queryTimeout: queryTimeout,
+		deprelStats:  make(map[bool][]storage.DeprelStats),
+	}
+}
+
+// queryContext derives a context of a search from the request context
+// so the search is cancelled once the client disconnects or once
+// the query timeout is reached.
+func (srv *server) queryContext(req *http.Request) (context.Context, context.CancelFunc) {
+	if srv.queryTimeout > 0 {
+		return context.WithTimeout(req.Context(), srv.queryTimeout)
+	}
+	return context.WithCancel(req.Context())
+}
+
+func (srv *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+client.PathCollocations+"{lemma}", srv.handleCollocations)
+	mux.HandleFunc("GET "+client.PathLemmaInfo+"{lemma}", srv.handleLemmaInfo)
+	mux.HandleFunc("GET "+client.PathLemmaProfile+"{lemma}", srv.handleLemmaProfile)
+	mux.HandleFunc("GET "+client.PathDeprelStats, srv.handleDeprelStats)
+	mux.HandleFunc("GET "+client.PathTextTypes, srv.handleTextTypes)
+	return mux
+}
+
+// accessOptions returns options derived from client authorization
+// (i.e. access to restricted text types for clients with a valid API key)
+func (srv *server) accessOptions(req *http.Request) []func(opts *scoll.CalculationOptions) {
+	key := req.Header.Get(client.HeaderAPIKey)
+	if key != "" && slices.Contains(srv.apiKeys, key) {
+		return []func(opts *scoll.CalculationOptions){scoll.WithRestrictedTextTypesAccess()}
+	}
+	return []func(opts *scoll.CalculationOptions){}
+}
+
+func (srv *server) writeError(w http.ResponseWriter, req *http.Request, err error, status int) {
+	if status >= http.StatusInternalServerError {
+		log.Error().Err(err).Str("path", req.URL.Path).Msg("failed to handle request")
+	}
+	w.Header().Set("Content-Type", scoll.EncodingJSON.ContentType())
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(client.ErrorResponse{Error: err.Error()})
+}
+
+// errorStatus maps search errors to proper HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrInvalidCorpusSize),
+		errors.Is(err, storage.ErrInvalidOffset),
+		errors.Is(err, storage.ErrInvalidResultField),
+		errors.Is(err, storage.ErrUnknownFilterValue),
+		errors.Is(err, storage.ErrInvalidLemmaPattern),
+		errors.Is(err, storage.ErrFeatureUnavailable):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrScanQueueTimeout),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func (srv *server) writeValue(w http.ResponseWriter, req *http.Request, v any) {
+	enc := scoll.NegotiateEncoding(req.Header.Get("Accept"))
+	var body []byte
+	var err error
+	if items, ok := v.([]storage.Collocation); ok {
+		body, err = scoll.EncodeCollocations(items, enc)
+
+	} else {
+		body, err = scoll.EncodeValue(v, enc)
+	}
+	if err != nil {
+		srv.writeError(w, req, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Write(body)
+}
+
+func (srv *server) handleCollocations(w http.ResponseWriter, req *http.Request) {
+	opts, err := scoll.OptionsFromURLValues(req.URL.Query())
+	if err != nil {
+		srv.writeError(w, req, err, http.StatusBadRequest)
+		return
+	}
+	opts = append(opts, srv.accessOptions(req)...)
+	var total int
+	opts = append(opts, scoll.WithTotalCount(&total))
+	ctx, cancel := srv.queryContext(req)
+	defer cancel()
+	ans, err := srv.calc.GetCollocations(ctx, req.PathValue("lemma"), opts...)
+	if err != nil {
+		srv.writeError(w, req, err, errorStatus(err))
+		return
+	}
+	w.Header().Set(client.HeaderTotalCount, strconv.Itoa(total))
+	srv.writeValue(w, req, ans)
+}
+
+func (srv *server) handleLemmaInfo(w http.ResponseWriter, req *http.Request) {
+	ans, err := srv.calc.GetLemmaInfo(req.PathValue("lemma"), srv.accessOptions(req)...)
+	if err != nil {
+		srv.writeError(w, req, err, errorStatus(err))
+		return
+	}
+	srv.writeValue(w, req, ans)
+}
+
+func (srv *server) handleLemmaProfile(w http.ResponseWriter, req *http.Request) {
+	ans, err := srv.calc.GetLemmaProfile(req.PathValue("lemma"), srv.accessOptions(req)...)
+	if err != nil {
+		srv.writeError(w, req, err, errorStatus(err))
+		return
+	}
+	srv.writeValue(w, req, ans)
+}
+
+// deprelStatsFor provides (possibly cached) deprel statistics with
+// maxDeprelStatsExamples examples. The calculation is not bound
+// to a single request so a client disconnecting does not cancel
+// the calculation for other clients waiting for the result.
+func (srv *server) deprelStatsFor(req *http.Request) ([]storage.DeprelStats, error) {
+	accessOpts := srv.accessOptions(req)
+	restricted := len(accessOpts) > 0
+	srv.deprelStatsMu.Lock()
+	ans, ok := srv.deprelStats[restricted]
+	srv.deprelStatsMu.Unlock()
+	if ok {
+		return ans, nil
+	}
+	ch := srv.deprelStatsCalc.DoChan(strconv.FormatBool(restricted), func() (any, error) {
+		ctx := context.WithoutCancel(req.Context())
+		if srv.queryTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, srv.queryTimeout)
+			defer cancel()
+		}
+		ans, err := srv.calc.GetDeprelStats(ctx, maxDeprelStatsExamples, accessOpts...)
+		if err != nil {
+			return nil, err
+		}
+		srv.deprelStatsMu.Lock()
+		srv.deprelStats[restricted] = ans
+		srv.deprelStatsMu.Unlock()
+		return ans, nil
+	})
+	ctx, cancel := srv.queryContext(req)
+	defer cancel()
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]storage.DeprelStats), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (srv *server) handleDeprelStats(w http.ResponseWriter, req *http.Request) {
+	var numExamples int
+	if v := req.URL.Query().Get(client.ParamNumExamples); v != "" {
+		var err error
+		numExamples, err = strconv.Atoi(v)
+		if err != nil || numExamples < 0 {
+			srv.writeError(
+				w, req, fmt.Errorf("invalid value of %s: %s", client.ParamNumExamples, v),
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+	numExamples = min(numExamples, maxDeprelStatsExamples)
+	stats, err := srv.deprelStatsFor(req)
+	if err != nil {
+		srv.writeError(w, req, err, errorStatus(err))
+		return
+	}
+	// the cached items must not be modified
+	ans := make([]storage.DeprelStats, len(stats))
+	for i, item := range stats {
+		ans[i] = item
+		ans[i].Examples = item.Examples[:min(numExamples, len(item.Examples))]
+	}
+	srv.writeValue(w, req, ans)
+}
+
+func (srv *server) handleTextTypes(w http.ResponseWriter, req *http.Request) {
+	ans, err := srv.calc.GetTextTypes(srv.accessOptions(req)...)
+	if err != nil {
+		srv.writeError(w, req, err, errorStatus(err))
+		return
+	}
+	srv.writeValue(w, req, ans)
+}
+
+// handleMetrics provides database metrics for Prometheus
+func handleMetrics(db *storage.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := db.WriteMetrics(w); err != nil {
+			log.Error().Err(err).Msg("failed to write metrics")
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/czcorpus/depreldb/client"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider provides larger frequencies to callers with access
+// to restricted text types
+type fakeProvider struct {
+	err error
+
+	// deprelStatsCalls counts calculations of deprel statistics
+	deprelStatsCalls atomic.Int32
+
+	// deprelStatsBlock (if set) blocks the calculation
+	// of deprel statistics until closed
+	deprelStatsBlock chan struct{}
+}
+
+func (p *fakeProvider) applyOptions(options []func(opts *scoll.CalculationOptions)) scoll.CalculationOptions {
+	var opts scoll.CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+func (p *fakeProvider) freq(options []func(opts *scoll.CalculationOptions)) int {
+	if p.applyOptions(options).RestrictedTextTypesAccess {
+		return 15
+	}
+	return 10
+}
+
+func (p *fakeProvider) GetCollocations(ctx context.Context, lemma string, options ...func(opts *scoll.CalculationOptions)) ([]storage.Collocation, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []storage.Collocation{{Freq: p.freq(options)}}, nil
+}
+
+func (p *fakeProvider) GetLemmaInfo(lemma string, options ...func(opts *scoll.CalculationOptions)) (storage.LemmaInfo, error) {
+	if p.err != nil {
+		return storage.LemmaInfo{}, p.err
+	}
+	return storage.LemmaInfo{Exists: true, Freq: p.freq(options)}, nil
+}
+
+func (p *fakeProvider) GetLemmaProfile(lemma string, options ...func(opts *scoll.CalculationOptions)) (storage.LemmaProfile, error) {
+	return storage.LemmaProfile{}, p.err
+}
+
+func (p *fakeProvider) GetDeprelStats(ctx context.Context, numExamples int, options ...func(opts *scoll.CalculationOptions)) ([]storage.DeprelStats, error) {
+	p.deprelStatsCalls.Add(1)
+	if p.deprelStatsBlock != nil {
+		<-p.deprelStatsBlock
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	examples := make([]storage.DeprelExamplePair, numExamples)
+	for i := range examples {
+		examples[i] = storage.DeprelExamplePair{Head: "h", Dependent: fmt.Sprint(i), Freq: numExamples - i}
+	}
+	return []storage.DeprelStats{{Deprel: "nsubj", Freq: p.freq(options), Examples: examples}}, nil
+}
+
+func (p *fakeProvider) GetTextTypes(options ...func(opts *scoll.CalculationOptions)) ([]storage.TextTypeLabel, error) {
+	return nil, p.err
+}
+
+func doRequest(t *testing.T, srv *server, path, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if apiKey != "" {
+		req.Header.Set(client.HeaderAPIKey, apiKey)
+	}
+	w := httptest.NewRecorder()
+	srv.routes().ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{storage.ErrInvalidCorpusSize, http.StatusBadRequest},
+		{storage.ErrInvalidOffset, http.StatusBadRequest},
+		{storage.ErrInvalidResultField, http.StatusBadRequest},
+		{storage.ErrUnknownFilterValue, http.StatusBadRequest},
+		{storage.ErrInvalidLemmaPattern, http.StatusBadRequest},
+		{storage.ErrFeatureUnavailable, http.StatusBadRequest},
+		{storage.ErrScanQueueTimeout, http.StatusServiceUnavailable},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable},
+		{context.Canceled, http.StatusServiceUnavailable},
+		{fmt.Errorf("failed to search: %w", storage.ErrUnknownFilterValue), http.StatusBadRequest},
+		{errors.New("disk failure"), http.StatusInternalServerError},
+	} {
+		assert.Equal(t, tc.status, errorStatus(tc.err), tc.err.Error())
+		srv := newServer(&fakeProvider{err: tc.err}, nil, 0)
+		w := doRequest(t, srv, client.PathCollocations+"team", "")
+		assert.Equal(t, tc.status, w.Code, tc.err.Error())
+		var resp client.ErrorResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, tc.err.Error(), resp.Error)
+	}
+}
+
+func TestRestrictedTextTypesAPIKey(t *testing.T) {
+	srv := newServer(&fakeProvider{}, []string{"key1", "key2"}, 0)
+	for _, tc := range []struct {
+		apiKey string
+		freq   int
+	}{
+		{"", 10},
+		{"unknown", 10},
+		{"key1", 15},
+		{"key2", 15},
+	} {
+		w := doRequest(t, srv, client.PathLemmaInfo+"team", tc.apiKey)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var info storage.LemmaInfo
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&info))
+		assert.Equal(t, tc.freq, info.Freq, tc.apiKey)
+
+		w = doRequest(t, srv, client.PathCollocations+"team", tc.apiKey)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var colls []storage.Collocation
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&colls))
+		if assert.Len(t, colls, 1) {
+			assert.Equal(t, tc.freq, colls[0].Freq, tc.apiKey)
+		}
+	}
+}
+
+func TestDeprelStatsExamplesLimit(t *testing.T) {
+	calc := &fakeProvider{}
+	srv := newServer(calc, []string{"secret"}, 0)
+	for _, tc := range []struct {
+		numExamples string
+		expected    int
+	}{
+		{"", 0},
+		{"3", 3},
+		{"1000000000", maxDeprelStatsExamples},
+		{"0", 0},
+	} {
+		w := doRequest(t, srv, client.PathDeprelStats+"?examples="+tc.numExamples, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var stats []storage.DeprelStats
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+		if assert.Len(t, stats, 1) {
+			assert.Len(t, stats[0].Examples, tc.expected)
+			assert.Equal(t, 10, stats[0].Freq)
+		}
+	}
+	// the statistics are calculated just once (with max. examples)
+	assert.Equal(t, int32(1), calc.deprelStatsCalls.Load())
+
+	// and separately for clients with access to restricted text types
+	w := doRequest(t, srv, client.PathDeprelStats+"?examples=2", "secret")
+	var stats []storage.DeprelStats
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+	if assert.Len(t, stats, 1) {
+		assert.Len(t, stats[0].Examples, 2)
+		assert.Equal(t, 15, stats[0].Freq)
+	}
+	assert.Equal(t, int32(2), calc.deprelStatsCalls.Load())
+
+	w = doRequest(t, srv, client.PathDeprelStats+"?examples=-1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCollocationsLimitCaps(t *testing.T) {
+	srv := newServer(&fakeProvider{}, nil, 0)
+	w := doRequest(t, srv, client.PathCollocations+"team?limit=1000", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(t, srv, client.PathCollocations+"team?limit=1001", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doRequest(t, srv, client.PathCollocations+"team?limit=100&secondOrderLimit=5", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doRequest(t, srv, client.PathCollocations+"team?limit=10&secondOrderLimit=100", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeprelStatsConcurrentRequests(t *testing.T) {
+	calc := &fakeProvider{deprelStatsBlock: make(chan struct{})}
+	srv := newServer(calc, nil, 0)
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = doRequest(t, srv, client.PathDeprelStats+"?examples=1", "").Code
+		}()
+	}
+	// let all the requests wait for the first calculation
+	// (not guaranteed but late requests are served from the cache)
+	for calc.deprelStatsCalls.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(calc.deprelStatsBlock)
+	wg.Wait()
+	assert.Equal(t, []int{200, 200, 200, 200, 200}, codes)
+	assert.Equal(t, int32(1), calc.deprelStatsCalls.Load())
+}
+
+func TestDeprelStatsErrorNotCached(t *testing.T) {
+	calc := &fakeProvider{err: storage.ErrScanQueueTimeout}
+	srv := newServer(calc, nil, 0)
+	w := doRequest(t, srv, client.PathDeprelStats, "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	calc.err = nil
+	w = doRequest(t, srv, client.PathDeprelStats, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int32(2), calc.deprelStatsCalls.Load())
+}
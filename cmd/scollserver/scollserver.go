@@ -0,0 +1,149 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/grpcapi"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	listen := flag.String("listen", "localhost:8080", "address (host:port) the server listens on")
+	apiKeys := flag.String("api-keys", "", "comma-separated API keys allowing clients to search also in restricted text types")
+	queryLogPath := flag.String("query-log", "", "if set, queries will be logged (anonymized, in JSONL format) to the file; use '-' for stdout")
+	queryLogSecret := flag.String("query-log-secret", "", "secret key used to hash lemmas in the query log; if empty, lemmas are not logged")
+	lemmaCacheQuota := flag.Int64("lemma-cache-quota", storage.DefaultLemmaCacheQuota>>20, "max. memory (in MB) the in-memory reverse lemma index may occupy; larger vocabularies are resolved on demand (0 = disabled)")
+	requestTimeout := flag.Duration("request-timeout", 60*time.Second, "max. time for reading a request and writing its response; searches running longer are cancelled")
+	readOnly := flag.Bool("read-only", false, "if set, the database is opened in the read-only mode so other processes (e.g. command line searches) can access it at the same time")
+	metrics := flag.Bool("metrics", false, "if set, query and database metrics are collected and provided (in the Prometheus text format) via GET "+pathMetrics)
+	calcWorkers := flag.Int("calc-workers", storage.DefaultCalcWorkers, "max. number of goroutines scanning records of a single search (lemma variants and directions are scanned concurrently)")
+	lenientDecoding := flag.Bool("lenient-decoding", false, "if set, malformed database records (e.g. in a partially corrupted database) are skipped and logged instead of failing the searches")
+	grpcListen := flag.String("grpc-listen", "", "if set, a gRPC service (see grpcapi/depreldb.proto) will be provided on the address (host:port) too")
+	logLevel := flag.String("log-level", "info", "set log level (debug, info, warn, error)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "scollserver - provide collocation search over HTTP (REST API)\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] [db_path]\n\t", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\nOptions:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	logging.SetupLogging(logging.LoggingConf{
+		Level: logging.LogLevel(*logLevel),
+	})
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	openDB := storage.OpenDB
+	if *readOnly {
+		openDB = storage.OpenDBReadOnly
+	}
+	db, err := openDB(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if *lemmaCacheQuota != storage.DefaultLemmaCacheQuota>>20 {
+		if _, err := db.LoadLemmaCache(*lemmaCacheQuota << 20); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+	}
+	db.SetLenientDecoding(*lenientDecoding)
+	db.SetCalcWorkers(*calcWorkers)
+	calc := scoll.FromDatabase(db)
+	defer calc.Close()
+	if *queryLogPath != "" {
+		queryLog := scoll.NewQueryLog(*queryLogPath, *queryLogSecret)
+		defer queryLog.Close()
+		calc = calc.WithQueryLog(queryLog)
+	}
+
+	var keys []string
+	if *apiKeys != "" {
+		keys = strings.Split(*apiKeys, ",")
+	}
+	mux := newServer(calc, keys, *requestTimeout).routes()
+	if *metrics {
+		db.EnableMetrics()
+		mux.HandleFunc("GET "+pathMetrics, handleMetrics(db))
+	}
+	httpServer := &http.Server{
+		Addr:         *listen,
+		Handler:      mux,
+		ReadTimeout:  *requestTimeout,
+		WriteTimeout: *requestTimeout,
+	}
+
+	var grpcServer *grpc.Server
+	if *grpcListen != "" {
+		lsnr, err := net.Listen("tcp", *grpcListen)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+		grpcServer = grpc.NewServer()
+		grpcapi.NewServer(calc, db.DatasetMetadata(), keys, *requestTimeout).Register(grpcServer)
+		go func() {
+			log.Info().Str("address", *grpcListen).Msg("starting the gRPC server")
+			if err := grpcServer.Serve(lsnr); err != nil {
+				log.Error().Err(err).Msg("gRPC server failed")
+			}
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		log.Info().Msg("shutting down the server")
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+		shCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shCtx); err != nil {
+			log.Error().Err(err).Msg("failed to shut down the server gracefully")
+		}
+	}()
+
+	log.Info().Str("address", *listen).Str("database", flag.Arg(0)).Msg("starting the server")
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+}
@@ -25,10 +25,16 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/client"
+	"github.com/czcorpus/depreldb/evaluation"
+	"github.com/czcorpus/depreldb/record"
 	"github.com/czcorpus/depreldb/scoll"
 	"github.com/czcorpus/depreldb/storage"
 	"github.com/fatih/color"
@@ -41,6 +47,10 @@ type srchCommand struct {
 	textType string
 }
 
+// replRefreshCommand replaces a pinned database snapshot
+// with a current one in the REPL mode
+const replRefreshCommand = ":refresh"
+
 func evalREPLCommand(cmd string) srchCommand {
 	items := strings.Split(strings.TrimSpace(cmd), " ")
 	ans := srchCommand{lemma: items[0]}
@@ -53,19 +63,229 @@ func evalREPLCommand(cmd string) srchCommand {
 	return ans
 }
 
+func printDeprelStats(calc scoll.CollocationProvider, numExamples int, jsonOut bool) {
+	ans, err := calc.GetDeprelStats(context.Background(), numExamples, scoll.WithRestrictedTextTypesAccess())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if jsonOut {
+		out, err := json.Marshal(ans)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to json-encode value: %s", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	headerFmt := color.New(color.FgGreen).SprintfFunc()
+	columnFmt := color.New(color.FgHiMagenta).SprintfFunc()
+	tbl := table.New("dependency", "num. pairs", "freq", "avg. dist.", "examples")
+	tbl.
+		WithHeaderFormatter(headerFmt).
+		WithFirstColumnFormatter(columnFmt).
+		WithHeaderSeparatorRow('\u2550')
+	for _, item := range ans {
+		examples := make([]string, len(item.Examples))
+		for i, ex := range item.Examples {
+			examples[i] = fmt.Sprintf("%s→%s (%d)", ex.Head, ex.Dependent, ex.Freq)
+		}
+		tbl.AddRow(item.Deprel, item.NumPairs, item.Freq, fmt.Sprintf("%.2f", item.AVGDist), strings.Join(examples, ", "))
+	}
+	tbl.Print()
+}
+
+func printFilterStats(stats storage.FilterStats) {
+	fmt.Fprintln(os.Stderr, "candidate pairs filtering:")
+	fmt.Fprintf(os.Stderr, "  scanned pair records:           %d\n", stats.NumScanned)
+	fmt.Fprintf(os.Stderr, "  discarded by text type:         %d\n", stats.TextType)
+	fmt.Fprintf(os.Stderr, "  discarded by deprel/predef.:    %d\n", stats.CustomFilter)
+	fmt.Fprintf(os.Stderr, "  discarded by avg. distance:     %d\n", stats.MaxAvgDist)
+	fmt.Fprintf(os.Stderr, "  discarded by avg. surface dist: %d\n", stats.MaxAvgSurfaceDist)
+	fmt.Fprintf(os.Stderr, "  discarded by collocate order:   %d\n", stats.CollocateOrder)
+	fmt.Fprintf(os.Stderr, "  accepted pair records:          %d\n", stats.NumAccepted)
+	fmt.Fprintf(os.Stderr, "  discarded by min. coll. freq.:  %d\n", stats.MinCollFreq)
+	fmt.Fprintf(os.Stderr, "  grouped collocations:           %d\n", stats.NumCandidates)
+	fmt.Fprintf(os.Stderr, "  cut by result limit:            %d\n", stats.CutByLimit)
+	if stats.ImportMinFreq > 0 {
+		fmt.Fprintf(os.Stderr, "  (pairs with freq. below %d were not imported)\n", stats.ImportMinFreq)
+	}
+	if stats.UsedHotSummaries {
+		fmt.Fprintln(os.Stderr, "  (pre-aggregated records of a frequent lemma were used)")
+	}
+	if stats.ScanBudgetExhausted {
+		fmt.Fprintln(os.Stderr, "  (the search was stopped by max. number of scanned pairs, results may be incomplete)")
+	}
+}
+
+func printVariantSummary(summary []storage.NodeVariantSummary) {
+	fmt.Fprintf(os.Stderr, "matching node variants: %d\n", len(summary))
+	for _, v := range summary {
+		lemmas := ""
+		if len(v.Lemmas) > 1 || len(v.Lemmas) == 1 && v.Lemmas[0] != v.Node {
+			lemmas = fmt.Sprintf(" (%s)", strings.Join(v.Lemmas, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "  %s%s: %d of %d collocations\n", v.Node, lemmas, v.NumReturned, v.NumCandidates)
+	}
+}
+
+func printCategoryProfile(profile storage.CategoryProfile) {
+	fmt.Fprintf(
+		os.Stderr, "collocate categories (%d of %d collocations categorized):\n",
+		profile.NumCategorized, profile.NumCollocations,
+	)
+	for _, c := range profile.Categories {
+		fmt.Fprintf(
+			os.Stderr, "  %s: %d collocations (%.1f%%), freq. %d (%.1f%%)\n",
+			c.Category, c.NumCollocations, c.Share*100, c.Freq, c.FreqShare*100,
+		)
+	}
+}
+
+func printTextTypes(calc scoll.CollocationProvider, jsonOut bool) {
+	ans, err := calc.GetTextTypes(scoll.WithRestrictedTextTypesAccess())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if jsonOut {
+		out, err := json.Marshal(ans)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to json-encode value: %s", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	headerFmt := color.New(color.FgGreen).SprintfFunc()
+	columnFmt := color.New(color.FgHiMagenta).SprintfFunc()
+	tbl := table.New("text type", "display name")
+	tbl.
+		WithHeaderFormatter(headerFmt).
+		WithFirstColumnFormatter(columnFmt).
+		WithHeaderSeparatorRow('\u2550')
+	for _, item := range ans {
+		tbl.AddRow(item.Value, item.DisplayName)
+	}
+	tbl.Print()
+}
+
+// sortedFreqs returns keys of freqs ordered by their frequencies
+// (the most frequent first)
+func sortedFreqs(freqs map[string]int) []string {
+	ans := make([]string, 0, len(freqs))
+	for k := range freqs {
+		ans = append(ans, k)
+	}
+	slices.SortFunc(ans, func(a, b string) int {
+		if freqs[a] != freqs[b] {
+			return freqs[b] - freqs[a]
+		}
+		return strings.Compare(a, b)
+	})
+	return ans
+}
+
+func printLemmaProfile(calc scoll.CollocationProvider, lemma string, jsonOut bool) {
+	ans, err := calc.GetLemmaProfile(lemma, scoll.WithRestrictedTextTypesAccess())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	if jsonOut {
+		out, err := json.Marshal(ans)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to json-encode value: %s", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	fmt.Println()
+	if !ans.Exists {
+		fmt.Println("-- NO RESULT --")
+		return
+	}
+	fmt.Printf("lemma: %s, freq: %d\n\n", ans.Lemma, ans.Freq)
+	headerFmt := color.New(color.FgGreen).SprintfFunc()
+	columnFmt := color.New(color.FgHiMagenta).SprintfFunc()
+	tbl := table.New("breakdown", "value", "freq")
+	tbl.
+		WithHeaderFormatter(headerFmt).
+		WithFirstColumnFormatter(columnFmt).
+		WithHeaderSeparatorRow('\u2550')
+	for _, part := range []struct {
+		name  string
+		freqs map[string]int
+	}{
+		{name: "PoS", freqs: ans.PoSFreqs},
+		{name: "text type", freqs: ans.TextTypeFreqs},
+		{name: "as head", freqs: ans.HeadDeprelFreqs},
+		{name: "as dependent", freqs: ans.DependentDeprelFreqs},
+	} {
+		for _, k := range sortedFreqs(part.freqs) {
+			tbl.AddRow(part.name, k, part.freqs[k])
+		}
+	}
+	tbl.Print()
+}
+
 func main() {
-	limit := flag.Int("limit", 10, "max num. of matching items to show")
-	sortBy := flag.String("sort-by", "rrf", "sorting measure (either tscore or ldice)")
+	limit := flag.Int("limit", 0, "max num. of matching items to show (if omitted, corpus default is used)")
+	offset := flag.Int("offset", 0, "number of best ranking items skipped before the limit is applied (for paging)")
+	secondOrder := flag.Int("second-order", 0, "if positive, then for each found collocate, the specified number of its own collocates is shown as well")
+	sortBy := flag.String("sort-by", "", "sorting measure (tscore, ldice, lmi, ll, rrf, mi, mi3, dice, minsens, dpcoll, dpnode; if omitted, corpus default is used)")
 	collGroupByPos := flag.Bool("collocate-group-by-pos", false, "if set, then collocates will be split by their PoS")
 	groupByDeprel := flag.Bool("group-by-deprel", false, "if set, then collocates will be split by their Deprel variants")
+	groupByFeats := flag.Bool("group-by-feats", false, "if set, then the searched lemma will be split by its morphological features (the database must be imported with them)")
 	collGroupByTT := flag.Bool("collocate-group-by-tt", false, "if set, then collocates will be split by their text type (registry)")
-	predefinedSearch := flag.String("predefined-search", "", "use predefined search (modifiers-of, nouns-modified-by, verbs-subject, verbs-object)")
+	relation := flag.String("relation", "", "search for collocates in a named relation (built-in: modifiers-of, nouns-modified-by, verbs-subject, verbs-object, adverbs-of-verb, prepositional-objects, coordinated-with; a database can define more)")
+	prefixSearch := flag.Bool("prefix", false, "if set, then the searched lemma is treated as a prefix and all the matching lemmas are searched (each of them as a separate node)")
+	mergePrefixVariants := flag.Bool("merge-prefix-variants", false, "if set along with -prefix, then all the lemmas matching the prefix are searched as a single node")
+	limitPerVariant := flag.Bool("limit-per-variant", false, "if set along with -prefix, the result limit is applied to each matching lemma separately")
+	ignoreDiacritics := flag.Bool("ignore-diacritics", false, "if set, the searched lemma matches also lemmas differing only in diacritics (e.g. hriste matches hřiště)")
+	ignoreCase := flag.Bool("ignore-case", false, "if set, the searched lemma matches also lemmas differing in letter case (e.g. praha matches Praha)")
+	wordForm := flag.Bool("word-form", false, "if set, the searched value is a word form (the database must be imported with word forms)")
+	lemmaPattern := flag.String("pattern", "", "if set (glob, regexp), the searched lemma is treated as a pattern and all the matching lemmas are searched as a single node (e.g. 'run*' or '.*ization')")
+	lemmaSet := flag.Bool("lemma-set", false, "if set, then the searched lemma is treated as a comma-separated set of lemmas searched as a single node")
+	corpusSize := flag.Int64("corpus-size", 0, "if set, the value replaces the imported corpus size in measure formulas (e.g. when combining with external subcorpus sizes)")
+	adaptiveLimits := flag.Bool("adaptive-limits", false, "if set, rare lemmas return all the available collocates and extremely frequent lemmas scan a bounded number of pairs (unless -limit or -max-scanned-pairs are set)")
+	maxScannedPairs := flag.Int("max-scanned-pairs", 0, "if set, the search examines at most the number of pair records (results may be incomplete)")
+	minCollFreq := flag.Int("min-coll-freq", 0, "if set, collocations with co-occurrence frequency F(x,y) lower than the value are removed")
+	relDistSpread := flag.Float64("relation-dist-spread", 0, "if set, collocates with average distance above their relation's typical distance (avg. + value * std. deviation, measured during import) are removed")
+	maxSurfaceDist := flag.Float64("max-surface-dist", 0, "if set, only collocates with average linear (word order) distance up to the value are shown")
+	collocateOrder := flag.String("collocate-order", "", "if set, only collocates typically preceding (before) or following (after) the lemma are shown")
+	signedDist := flag.Bool("signed-distance", false, "if set, mutual distance is negative for collocations where the lemma is a dependent (legacy convention)")
+	fields := flag.String("fields", "", "if set, only the comma-separated optional fields (e.g. logDice,lmi) are calculated and returned in JSON output")
+	textTypeDims := flag.String("text-type-dims", "", "if set, only text types with the comma-separated values of individual text type dimensions (e.g. ,1990s for any genre of the period) are searched")
+	deprels := flag.String("deprel", "", "if set, only collocations with the comma-separated relations (e.g. amod,nmod) are shown")
+	pathColls := flag.Bool("path-collocations", false, "if set, collocates connected via other nodes are searched (use with -deprel obj→amod to find e.g. adjectives modifying objects of a verb)")
+	deprelGranularity := flag.String("deprel-granularity", "", "if set to core, relation subtypes (e.g. obl:arg) are merged into their core relations; full keeps relations as stored")
+	labelLang := flag.String("label-lang", "", "if set (en, cs), results contain descriptions of PoS tags and relations in the language (JSON output only)")
+	genCQL := flag.Bool("cql", false, "if set, each result item contains a CQL query retrieving the co-occurrences in the source corpus (JSON output only)")
+	categoryLexicon := flag.String("category-lexicon", "", "if set, a TSV file (lemma, category, optional PoS) used to print category aggregates (e.g. share of negative collocates) over all the found collocates to stderr (local databases only)")
+	explain := flag.Bool("explain", false, "if set, numbers of candidate pairs discarded by individual filters are printed to stderr (local databases only)")
 	jsonOut := flag.Bool("json-out", false, "if set then JSON format will be used to print results")
+	compactJSON := flag.Bool("compact-json", false, "if set then compact JSON format (with a string table) will be used to print results")
+	format := flag.String("format", "", "if set (csv, tsv), results are printed in the delimited format with a header row (e.g. for loading into R or pandas)")
 	logLevel := flag.String("log-level", "info", "set log level (debug, info, warn, error)")
+	queryLogPath := flag.String("query-log", "", "if set, queries will be logged (anonymized, in JSONL format) to the file; use '-' for stdout")
+	queryLogSecret := flag.String("query-log-secret", "", "secret key used to hash lemmas in the query log; if empty, lemmas are not logged")
+	noQueryLog := flag.Bool("no-query-log", false, "if set, no query will be logged even if -query-log is set")
+	snapshotPath := flag.String("record-snapshot", "", "if set, all the queries along with their results will be recorded to the file (see scolldb verify-snapshot)")
+	deprelStats := flag.Int("deprel-stats", 0, "if set to a positive number N, global statistics of syntactic relations with N example pairs each are printed instead of a search")
+	info := flag.Bool("info", false, "if set, a frequency profile of the lemma (by PoS, text types and relations) is printed instead of a search")
+	textTypes := flag.Bool("text-types", false, "if set, text types of the corpus along with their display names are printed instead of a search")
 	repl := flag.Bool("repl", false, "if set, then the search will run in an infinite read-eval-print loop (until Ctrl+C is pressed)")
+	federate := flag.String("federate", "", "comma-separated paths of additional local databases searched along with the main one as a single corpus (frequencies are summed)")
+	federateWeights := flag.String("federate-weights", "", "comma-separated weights of the main and the additional databases (in the order of -federate) applied when combining frequencies")
+	federateNormalize := flag.Bool("federate-normalize", false, "if set, frequencies of federated databases are normalized to the size of the largest one before they are combined")
+	readOnly := flag.Bool("read-only", false, "if set, local databases are opened in the read-only mode so other processes (e.g. a running scollserver) can access them at the same time")
+	pinSnapshot := flag.Bool("pin-snapshot", false, "if set, all the queries of a REPL session use the same database snapshot (enter :refresh to update it; local databases only)")
+	lemmaCacheQuota := flag.Int64("lemma-cache-quota", storage.DefaultLemmaCacheQuota>>20, "max. memory (in MB) the in-memory reverse lemma index of a local database may occupy; larger vocabularies are resolved on demand (0 = disabled)")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "search - search for collocations of a provided lemma\n\n")
-		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] [db_path] [lemma]\n\t", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] [db_path or server URL] [lemma]\n\t", filepath.Base(os.Args[0]))
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 	}
@@ -75,11 +295,27 @@ func main() {
 		Level: logging.LogLevel(*logLevel),
 	})
 
-	db, err := storage.OpenDB(flag.Arg(0))
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+	var delimiter rune
+	switch *format {
+	case "":
+	case "csv":
+		delimiter = ','
+	case "tsv":
+		delimiter = '\t'
+	default:
+		fmt.Fprintln(os.Stderr, "ERROR: ", "unknown output format "+*format)
+		os.Exit(1)
+	}
+	// the flat formats have no place for nested collocates
+	if *secondOrder > 0 && delimiter != 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: ", "-second-order cannot be combined with -format "+*format)
+		os.Exit(1)
+	}
+	if *secondOrder > 0 && *compactJSON {
+		fmt.Fprintln(os.Stderr, "ERROR: ", "-second-order cannot be combined with -compact-json")
 		os.Exit(1)
 	}
+
 	gbPos := scoll.WithNOP()
 	if *collGroupByPos {
 		gbPos = scoll.WithCollocateGroupByPos()
@@ -92,15 +328,160 @@ func main() {
 	if *collGroupByTT {
 		gbTT = scoll.WithCollocateGroupByTextType()
 	}
+	gbFeats := scoll.WithNOP()
+	if *groupByFeats {
+		gbFeats = scoll.WithGroupByFeats()
+	}
+
+	var catLexicon *storage.CategoryLexicon
+	if *categoryLexicon != "" {
+		var err error
+		catLexicon, err = storage.ReadCategoryLexicon(*categoryLexicon)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+	}
+
+	var calc scoll.CollocationProvider
+	var localDBs []*storage.DB
+	if strings.HasPrefix(flag.Arg(0), "http://") || strings.HasPrefix(flag.Arg(0), "https://") {
+		if *explain {
+			fmt.Fprintln(os.Stderr, "ERROR: ", "-explain is not supported for remote databases")
+			os.Exit(1)
+		}
+		if *pinSnapshot {
+			fmt.Fprintln(os.Stderr, "ERROR: ", "-pin-snapshot is not supported for remote databases")
+			os.Exit(1)
+		}
+		if *categoryLexicon != "" {
+			fmt.Fprintln(os.Stderr, "ERROR: ", "-category-lexicon is not supported for remote databases")
+			os.Exit(1)
+		}
+		if *federate != "" {
+			fmt.Fprintln(os.Stderr, "ERROR: ", "-federate is not supported for remote databases")
+			os.Exit(1)
+		}
+		calc = client.New(flag.Arg(0))
+
+	} else {
+		if *federate != "" && *explain {
+			fmt.Fprintln(os.Stderr, "ERROR: ", "-explain is not supported for federated search")
+			os.Exit(1)
+		}
+		dbPaths := []string{flag.Arg(0)}
+		if *federate != "" {
+			dbPaths = append(dbPaths, strings.Split(*federate, ",")...)
+		}
+		openDB := storage.OpenDB
+		if *readOnly {
+			openDB = storage.OpenDBReadOnly
+		}
+		for _, dbPath := range dbPaths {
+			db, err := openDB(strings.TrimSpace(dbPath))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "ERROR: ", err)
+				os.Exit(1)
+			}
+			if *lemmaCacheQuota<<20 != storage.DefaultLemmaCacheQuota {
+				if _, err := db.LoadLemmaCache(*lemmaCacheQuota << 20); err != nil {
+					fmt.Fprintln(os.Stderr, "ERROR: ", err)
+					os.Exit(1)
+				}
+			}
+			if *pinSnapshot {
+				db.PinSnapshot()
+			}
+			localDBs = append(localDBs, db)
+		}
+		var queryLog *scoll.QueryLog
+		if *queryLogPath != "" && !*noQueryLog {
+			queryLog = scoll.NewQueryLog(*queryLogPath, *queryLogSecret)
+			defer queryLog.Close()
+		}
+		if len(localDBs) > 1 {
+			fedCalc, err := scoll.FederatedFromDatabases(localDBs...)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "ERROR: ", err)
+				os.Exit(1)
+			}
+			if *federateWeights != "" {
+				var weights []float64
+				for _, w := range strings.Split(*federateWeights, ",") {
+					v, err := strconv.ParseFloat(strings.TrimSpace(w), 64)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, "ERROR: ", fmt.Errorf("invalid federated database weight: %w", err))
+						os.Exit(1)
+					}
+					weights = append(weights, v)
+				}
+				if err := fedCalc.SetWeights(weights...); err != nil {
+					fmt.Fprintln(os.Stderr, "ERROR: ", err)
+					os.Exit(1)
+				}
+			}
+			fedCalc.SetSizeNormalization(*federateNormalize)
+			calc = fedCalc.WithQueryLog(queryLog)
 
-	gbPredSrch := scoll.WithNOP()
-	if *predefinedSearch != "" {
-		tmp := scoll.PredefinedSearch(*predefinedSearch)
-		if !tmp.Validate() {
-			fmt.Fprintf(os.Stderr, "uknown predefined search: %s", *predefinedSearch)
+		} else {
+			calc = scoll.FromDatabase(localDBs[0]).WithQueryLog(queryLog)
+		}
+	}
+	if *snapshotPath != "" {
+		rec, err := evaluation.NewSnapshotRecorder(calc, *snapshotPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
 			os.Exit(1)
 		}
-		gbPredSrch = scoll.WithPredefinedSearch(tmp)
+		defer rec.Close()
+		calc = rec
+	}
+
+	if *deprelStats > 0 {
+		printDeprelStats(calc, *deprelStats, *jsonOut)
+		return
+	}
+	if *textTypes {
+		printTextTypes(calc, *jsonOut)
+		return
+	}
+
+	relationOpt := scoll.WithNOP()
+	if *relation != "" {
+		relationOpt = scoll.WithRelation(*relation)
+	}
+
+	if !storage.CollocateOrder(*collocateOrder).Validate() {
+		fmt.Fprintf(os.Stderr, "invalid collocate order: %s\n", *collocateOrder)
+		os.Exit(1)
+	}
+
+	fieldsOpt := scoll.WithNOP()
+	if *fields != "" {
+		selected := make([]storage.ResultField, 0, len(storage.ResultFields))
+		for _, v := range strings.Split(*fields, ",") {
+			f := storage.ResultField(strings.TrimSpace(v))
+			if !f.Validate() {
+				fmt.Fprintf(os.Stderr, "invalid result field: %s\n", f)
+				os.Exit(1)
+			}
+			selected = append(selected, f)
+		}
+		fieldsOpt = scoll.WithFields(selected...)
+	}
+
+	if !storage.LemmaPatternSyntax(*lemmaPattern).Validate() {
+		fmt.Fprintf(os.Stderr, "invalid lemma pattern syntax: %s\n", *lemmaPattern)
+		os.Exit(1)
+	}
+	if !storage.DeprelGranularity(*deprelGranularity).Validate() {
+		fmt.Fprintf(os.Stderr, "invalid deprel granularity: %s\n", *deprelGranularity)
+		os.Exit(1)
+	}
+
+	if *labelLang != "" && !record.LabelLang(*labelLang).Validate() {
+		fmt.Fprintf(os.Stderr, "invalid label language: %s\n", *labelLang)
+		os.Exit(1)
 	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
@@ -129,6 +510,13 @@ func main() {
 				fmt.Println("\nExiting...")
 				return
 			case cmd := <-cmdChan:
+				if strings.TrimSpace(cmd) == replRefreshCommand && len(localDBs) > 0 {
+					for _, db := range localDBs {
+						db.RefreshSnapshot()
+					}
+					fmt.Printf("database snapshot refreshed (%s)\n", localDBs[0].SnapshotTime().Format(time.RFC3339))
+					continue
+				}
 				currCommand = evalREPLCommand(cmd)
 			}
 		}
@@ -138,22 +526,160 @@ func main() {
 			continue
 		}
 
-		ans, err := scoll.FromDatabase(db).GetCollocations(
+		if *info {
+			printLemmaProfile(calc, currCommand.lemma, *jsonOut)
+			if !*repl {
+				return
+			}
+			currCommand = srchCommand{}
+			continue
+		}
+
+		signedDistOpt := scoll.WithNOP()
+		if *signedDist {
+			signedDistOpt = scoll.WithSignedDistance()
+		}
+		adaptiveOpt := scoll.WithNOP()
+		if *adaptiveLimits {
+			adaptiveOpt = scoll.WithAdaptiveLimits()
+		}
+		cqlOpt := scoll.WithNOP()
+		if *genCQL {
+			cqlOpt = scoll.WithCQL()
+		}
+		var filterStats storage.FilterStats
+		explainOpt := scoll.WithNOP()
+		if *explain {
+			explainOpt = scoll.WithFilterStats(&filterStats)
+		}
+		lemmaSetOpt := scoll.WithNOP()
+		if *lemmaSet {
+			lemmaSetOpt = scoll.WithLemmaSet(strings.Split(currCommand.lemma, ",")...)
+		}
+		prefixOpt := scoll.WithNOP()
+		if *prefixSearch {
+			prefixOpt = scoll.WithPrefixSearch()
+		}
+		patternOpt := scoll.WithNOP()
+		if *lemmaPattern != "" {
+			patternOpt = scoll.WithLemmaPattern(storage.LemmaPatternSyntax(*lemmaPattern))
+		}
+		mergeVariantsOpt := scoll.WithNOP()
+		if *mergePrefixVariants {
+			mergeVariantsOpt = scoll.WithMergedPrefixVariants()
+		}
+		ignoreDiacriticsOpt := scoll.WithNOP()
+		if *ignoreDiacritics {
+			ignoreDiacriticsOpt = scoll.WithIgnoredDiacritics()
+		}
+		ignoreCaseOpt := scoll.WithNOP()
+		if *ignoreCase {
+			ignoreCaseOpt = scoll.WithCaseInsensitive()
+		}
+		wordFormOpt := scoll.WithNOP()
+		if *wordForm {
+			wordFormOpt = scoll.WithSearchByWordForm()
+		}
+		limitPerVariantOpt := scoll.WithNOP()
+		if *limitPerVariant {
+			limitPerVariantOpt = scoll.WithLimitPerVariant()
+		}
+		deprelsOpt := scoll.WithNOP()
+		if *deprels != "" {
+			deprelsOpt = scoll.WithDeprels(strings.Split(*deprels, ","))
+		}
+		pathCollsOpt := scoll.WithNOP()
+		if *pathColls {
+			pathCollsOpt = scoll.WithPathCollocations()
+		}
+		ttDimsOpt := scoll.WithNOP()
+		if *textTypeDims != "" {
+			ttDimsOpt = scoll.WithTextTypeDims(strings.Split(*textTypeDims, ",")...)
+		}
+		var variantSummary []storage.NodeVariantSummary
+		variantSummaryOpt := scoll.WithNOP()
+		if *prefixSearch || *lemmaPattern != "" {
+			variantSummaryOpt = scoll.WithVariantSummary(&variantSummary)
+		}
+		var catProfile storage.CategoryProfile
+		catProfileOpt := scoll.WithNOP()
+		if catLexicon != nil {
+			catProfileOpt = scoll.WithCategoryProfile(catLexicon, &catProfile)
+		}
+		var totalCount int
+		ans, err := calc.GetCollocations(
+			context.Background(),
 			currCommand.lemma,
 			scoll.WithPoS(currCommand.pos),
 			scoll.WithTextType(currCommand.textType),
 			scoll.WithLimit(*limit),
+			scoll.WithOffset(*offset),
+			scoll.WithTotalCount(&totalCount),
+			scoll.WithSecondOrder(*secondOrder),
 			scoll.WithSortBy(storage.SortingMeasure(*sortBy)),
 			gbPos,
 			gbDeprel,
 			gbTT,
-			gbPredSrch,
+			gbFeats,
+			relationOpt,
+			lemmaSetOpt,
+			prefixOpt,
+			patternOpt,
+			mergeVariantsOpt,
+			ignoreDiacriticsOpt,
+			ignoreCaseOpt,
+			wordFormOpt,
+			limitPerVariantOpt,
+			variantSummaryOpt,
+			catProfileOpt,
+			scoll.WithCorpusSize(*corpusSize),
+			signedDistOpt,
+			scoll.WithMaxAvgSurfaceDist(*maxSurfaceDist),
+			scoll.WithRelationDistSpread(*relDistSpread),
+			scoll.WithMaxScannedPairs(*maxScannedPairs),
+			scoll.WithMinCollFreq(*minCollFreq),
+			adaptiveOpt,
+			scoll.WithCollocateOrder(storage.CollocateOrder(*collocateOrder)),
+			fieldsOpt,
+			scoll.WithDeprelGranularity(storage.DeprelGranularity(*deprelGranularity)),
+			deprelsOpt,
+			pathCollsOpt,
+			ttDimsOpt,
+			scoll.WithLabelLang(record.LabelLang(*labelLang)),
+			cqlOpt,
+			explainOpt,
+			// local database access implies access to all text types
+			// (a remote server decides by itself so the client ignores this)
+			scoll.WithRestrictedTextTypesAccess(),
 		)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "ERROR: ", err)
 			os.Exit(1)
 		}
-		if *jsonOut {
+		if *explain {
+			printFilterStats(filterStats)
+		}
+		if len(variantSummary) > 0 {
+			printVariantSummary(variantSummary)
+		}
+		if catLexicon != nil {
+			printCategoryProfile(catProfile)
+		}
+		if delimiter != 0 {
+			if err := storage.WriteCollocationsDelimited(os.Stdout, ans, delimiter); err != nil {
+				fmt.Fprintln(os.Stderr, "ERROR: ", err)
+				os.Exit(1)
+			}
+
+		} else if *compactJSON {
+			out, err := json.Marshal(storage.NewCompactCollocations(ans))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to json-encode value: %s", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(out))
+
+		} else if *jsonOut {
 			for _, item := range ans {
 				out, err := json.Marshal(item)
 				if err != nil {
@@ -188,8 +714,14 @@ func main() {
 					WithHeaderSeparatorRow('\u2550')
 				for _, item := range ans {
 					tbl.AddRow(item.AsRow()...)
+					for _, item2 := range item.SecondOrder {
+						row := item2.AsRow()
+						row[1] = fmt.Sprintf("  \u21b3 %v", row[1])
+						tbl.AddRow(row...)
+					}
 				}
 				tbl.Print()
+				fmt.Printf("\nshowing items %d-%d of %d\n", *offset+1, *offset+len(ans), totalCount)
 
 			} else {
 				fmt.Println("-- NO RESULT --")
@@ -0,0 +1,162 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// jsonlRecord is a single output line in the JSONL format
+// (top collocations of a lemma ranked by a measure)
+type jsonlRecord struct {
+	Lemma   string                 `json:"lemma"`
+	Measure storage.SortingMeasure `json:"measure"`
+	Items   []storage.Collocation  `json:"items"`
+}
+
+func writeTSVHeader(w io.Writer) error {
+	_, err := fmt.Fprintln(
+		w,
+		"lemma\tmeasure\trank\tlemma_pos\tdeprel\tis_head\tcollocate\tcollocate_pos\tfreq\tlemma_freq\tcollocate_freq\tscore",
+	)
+	return err
+}
+
+func writeTSV(w io.Writer, lemma string, measure storage.SortingMeasure, items []storage.Collocation) error {
+	for i, item := range items {
+		if _, err := fmt.Fprintf(
+			w, "%s\t%s\t%d\t%s\t%s\t%t\t%s\t%s\t%d\t%d\t%d\t%.4f\n",
+			lemma, measure, i+1, item.Lemma.PoS, item.Deprel, item.IsHead, item.Collocate.Value,
+			item.Collocate.PoS, item.Freq, item.LemmaFreq, item.CollocateFreq, measure.ValueOf(item),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "topcolls - write top collocations of all the frequent lemmas of a database.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] db_path\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Lemmas are written in alphabetical order so outputs can be compared.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+	minFreq := flag.Int("min-freq", 100, "min. frequency of a lemma to get its collocations written")
+	limit := flag.Int("limit", 20, "max. number of collocations of each lemma and measure")
+	measures := flag.String("measures", "ldice", "comma-separated measures collocations are ranked by (tscore, ldice, lmi, ll, rrf, mi, mi3, dice, minsens, dpcoll, dpnode)")
+	groupByDeprel := flag.Bool("group-by-deprel", false, "if set, collocations are split by their relations")
+	collGroupByPos := flag.Bool("collocate-group-by-pos", false, "if set, collocations are split by PoS tags of the collocates")
+	excludeTT := flag.String("exclude-tt", "", "comma-separated text types ignored completely")
+	format := flag.String("format", "tsv", "output format (tsv, jsonl)")
+	outPath := flag.String("o", "", "output file (if omitted, stdout is used)")
+	logLevel := flag.String("log-level", "warn", "set log level (debug, info, warn, error)")
+	flag.Parse()
+
+	logging.SetupLogging(logging.LoggingConf{
+		Level: logging.LogLevel(*logLevel),
+	})
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *minFreq < 1 {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid min. frequency %d (must be at least 1)\n", *minFreq)
+		os.Exit(1)
+	}
+	if *format != "tsv" && *format != "jsonl" {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid output format %s\n", *format)
+		os.Exit(1)
+	}
+	args := storage.TopCollocationsDumpArgs{
+		MinLemmaFreq:        *minFreq,
+		Limit:               *limit,
+		GroupByDeprel:       *groupByDeprel,
+		CollocateGroupByPos: *collGroupByPos,
+	}
+	for _, m := range strings.Split(*measures, ",") {
+		measure := storage.SortingMeasure(strings.TrimSpace(m))
+		if !measure.Validate() {
+			fmt.Fprintf(os.Stderr, "ERROR: invalid measure %s\n", measure)
+			os.Exit(1)
+		}
+		args.Measures = append(args.Measures, measure)
+	}
+	if *excludeTT != "" {
+		for _, tt := range strings.Split(*excludeTT, ",") {
+			args.ExcludedTextTypes = append(args.ExcludedTextTypes, strings.TrimSpace(tt))
+		}
+	}
+
+	db, err := storage.OpenDBReadOnly(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	out := os.Stdout
+	if *outPath != "" {
+		out, err = os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			db.Close()
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	if *format == "tsv" {
+		err = writeTSVHeader(w)
+	}
+	var numLemmas int
+	if err == nil {
+		numLemmas, err = db.ForEachTopCollocations(
+			context.Background(),
+			args,
+			func(lemma string, measure storage.SortingMeasure, items []storage.Collocation) error {
+				if *format == "jsonl" {
+					return enc.Encode(jsonlRecord{Lemma: lemma, Measure: measure, Items: items})
+				}
+				return writeTSV(w, lemma, measure, items)
+			},
+		)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		db.Close()
+		os.Exit(2)
+	}
+	fmt.Fprintf(os.Stderr, "written collocations of %d lemmas\n", numLemmas)
+}
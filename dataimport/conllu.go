@@ -0,0 +1,254 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+// Positions of token attributes produced by ParseConllU. The positions
+// are compatible with vertigo.Token.PosAttrByIndex (i.e. position 0
+// is the word form) so they can be used in import profiles.
+const (
+	ConllUFormIdx   = 0
+	ConllULemmaIdx  = 1
+	ConllUPosIdx    = 2
+	ConllUXPosIdx   = 3
+	ConllUFeatsIdx  = 4
+	ConllUParentIdx = 5
+	ConllUDeprelIdx = 6
+
+	conllUNumColumns = 10
+)
+
+// ConllUProfile returns a copy of the profile with column positions
+// and the sentence structure set to the ones produced by ParseConllU.
+func ConllUProfile(prof storage.Profile) storage.Profile {
+	prof.SentenceStruct = DefaultSentenceStruct
+	prof.LemmaIdx = ConllULemmaIdx
+	prof.PosIdx = ConllUPosIdx
+	prof.ParentIdx = ConllUParentIdx
+	prof.DeprelIdx = ConllUDeprelIdx
+	prof.FeatsIdx = ConllUFeatsIdx
+	return prof
+}
+
+// conllUParser converts CoNLL-U sentences into tokens and structures
+// as produced by the vertigo parser for vertical files
+type conllUParser struct {
+	proc     vertigo.LineProcessor
+	tokenIdx int
+
+	docOpen  bool
+	docAttrs map[string]string
+
+	// sentence being read along with its metadata (comments)
+	// and source line numbers of its tokens
+	sent      []*vertigo.Token
+	sentLines []int
+	sentAttrs map[string]string
+}
+
+// parseComment reads sentence metadata in the form "# key = value".
+// Document metadata are read from "# newdoc key = value" and
+// "# meta::key = value" comments.
+func (p *conllUParser) parseComment(line string, lineNum int) error {
+	comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+	key, value, _ := strings.Cut(comment, "=")
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if key == "newdoc" || strings.HasPrefix(key, "newdoc ") {
+		if err := p.closeDoc(lineNum); err != nil {
+			return err
+		}
+		p.docOpen = true
+		p.docAttrs = make(map[string]string)
+		if k := strings.TrimSpace(strings.TrimPrefix(key, "newdoc")); k != "" {
+			p.docAttrs[k] = value
+		}
+		return p.proc.ProcStruct(&vertigo.Structure{Name: "doc", Attrs: p.docAttrs}, lineNum, nil)
+	}
+	if metaKey, ok := strings.CutPrefix(key, "meta::"); ok {
+		if p.docAttrs == nil {
+			p.docAttrs = make(map[string]string)
+		}
+		p.docAttrs[metaKey] = value
+		return nil
+	}
+	if key != "" {
+		p.sentAttrs[key] = value
+	}
+	return nil
+}
+
+// parseToken reads a single token line. Multiword tokens (ID ranges)
+// and empty nodes (decimal IDs) are skipped as the syntactic words
+// carry all the required annotation.
+func (p *conllUParser) parseToken(line string, lineNum int) error {
+	cols := strings.Split(line, "\t")
+	if len(cols) != conllUNumColumns {
+		return fmt.Errorf("invalid CoNLL-U line %d: expected %d columns, found %d", lineNum, conllUNumColumns, len(cols))
+	}
+	if strings.ContainsAny(cols[0], "-.") {
+		return nil
+	}
+	id, err := strconv.Atoi(cols[0])
+	if err != nil {
+		return fmt.Errorf("invalid CoNLL-U line %d: invalid ID %s", lineNum, cols[0])
+	}
+	head, err := strconv.Atoi(cols[6])
+	if err != nil {
+		return fmt.Errorf("invalid CoNLL-U line %d: invalid HEAD %s", lineNum, cols[6])
+	}
+	if id != len(p.sent)+1 {
+		return fmt.Errorf("invalid CoNLL-U line %d: unexpected token ID %d", lineNum, id)
+	}
+	// vertical files store parents as relative positions
+	// (with zero for the root)
+	var parent int
+	if head > 0 {
+		parent = head - id
+	}
+	p.sentLines = append(p.sentLines, lineNum)
+	p.sent = append(p.sent, &vertigo.Token{
+		Idx:  p.tokenIdx,
+		Word: cols[1],
+		Attrs: []string{
+			cols[2], cols[3], cols[4], cols[5], strconv.Itoa(parent), cols[7], cols[8], cols[9],
+		},
+	})
+	p.tokenIdx++
+	return nil
+}
+
+func (p *conllUParser) structAttrs() map[string]string {
+	ans := make(map[string]string, len(p.docAttrs)+len(p.sentAttrs))
+	for k, v := range p.docAttrs {
+		ans["doc."+k] = v
+	}
+	for k, v := range p.sentAttrs {
+		ans["s."+k] = v
+	}
+	return ans
+}
+
+// flushSent passes the current sentence to the processor
+func (p *conllUParser) flushSent(lineNum int) error {
+	if len(p.sent) == 0 {
+		p.sentAttrs = make(map[string]string)
+		return nil
+	}
+	if err := p.proc.ProcStruct(&vertigo.Structure{Name: "s", Attrs: p.sentAttrs}, p.sentLines[0], nil); err != nil {
+		return err
+	}
+	attrs := p.structAttrs()
+	for i, tk := range p.sent {
+		tk.StructAttrs = attrs
+		if err := p.proc.ProcToken(tk, p.sentLines[i], nil); err != nil {
+			return err
+		}
+	}
+	if err := p.proc.ProcStructClose(&vertigo.StructureClose{Name: "s"}, lineNum, nil); err != nil {
+		return err
+	}
+	p.sent = p.sent[:0]
+	p.sentLines = p.sentLines[:0]
+	p.sentAttrs = make(map[string]string)
+	return nil
+}
+
+func (p *conllUParser) closeDoc(lineNum int) error {
+	if err := p.flushSent(lineNum); err != nil {
+		return err
+	}
+	if !p.docOpen {
+		return nil
+	}
+	p.docOpen = false
+	return p.proc.ProcStructClose(&vertigo.StructureClose{Name: "doc"}, lineNum, nil)
+}
+
+// ParseConllU reads CoNLL-U data and passes its sentences to the processor
+// the same way the vertigo parser does for vertical files (i.e. each
+// sentence is enclosed in an "s" structure, documents started by
+// "# newdoc" comments are enclosed in "doc" structures). Token attributes
+// are available at positions ConllULemmaIdx, ConllUPosIdx etc. with parents
+// converted to relative positions as used in vertical files. Sentence
+// comments (# key = value) are available as structural attributes
+// "s.key", document metadata (# newdoc key = value, # meta::key = value)
+// as "doc.key" (e.g. for a profile's TextTypesAttr).
+func ParseConllU(ctx context.Context, r io.Reader, proc vertigo.LineProcessor) error {
+	p := &conllUParser{
+		proc:      proc,
+		sentAttrs: make(map[string]string),
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lineNum int
+	for scanner.Scan() {
+		lineNum++
+		if lineNum%10000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		line := strings.TrimRight(scanner.Text(), "\r")
+		var err error
+		switch {
+		case strings.TrimSpace(line) == "":
+			err = p.flushSent(lineNum)
+		case strings.HasPrefix(line, "#"):
+			err = p.parseComment(line, lineNum)
+		default:
+			err = p.parseToken(line, lineNum)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse CoNLL-U data: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to parse CoNLL-U data: %w", err)
+	}
+	if err := p.closeDoc(lineNum); err != nil {
+		return fmt.Errorf("failed to parse CoNLL-U data: %w", err)
+	}
+	return nil
+}
+
+// ParseConllUFile reads a CoNLL-U file (see ParseConllU)
+func ParseConllUFile(ctx context.Context, path string, proc vertigo.LineProcessor) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse CoNLL-U file: %w", err)
+	}
+	defer f.Close()
+	return ParseConllU(ctx, f, proc)
+}
+
+// IsConllUFile tells whether the file name suggests CoNLL-U format
+func IsConllUFile(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".conllu")
+}
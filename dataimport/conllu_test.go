@@ -0,0 +1,98 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+const testConllU = `# newdoc id = doc1
+# meta::genre = fiction
+# sent_id = 1
+# text = The dog barks.
+1	The	the	DET	DT	_	2	det	_	_
+2	dog	dog	NOUN	NN	_	3	nsubj	_	_
+3	barks	bark	VERB	VBZ	_	0	root	_	SpaceAfter=No
+4	.	.	PUNCT	.	_	3	punct	_	_
+
+# sent_id = 2
+1-2	Don't	_	_	_	_	_	_	_	_
+1	Do	do	AUX	VBP	_	3	aux	_	_
+2	n't	not	PART	RB	_	3	advmod	_	_
+3	go	go	VERB	VB	_	0	root	_	_
+3.1	gone	go	VERB	VBN	_	_	_	3:conj	_
+`
+
+// recordingProcessor records all the events produced by a parser
+type recordingProcessor struct {
+	events []string
+	tokens []*vertigo.Token
+}
+
+func (rp *recordingProcessor) ProcToken(tk *vertigo.Token, line int, err error) error {
+	rp.events = append(rp.events, "token")
+	rp.tokens = append(rp.tokens, tk)
+	return nil
+}
+
+func (rp *recordingProcessor) ProcStruct(st *vertigo.Structure, line int, err error) error {
+	rp.events = append(rp.events, "<"+st.Name+">")
+	return nil
+}
+
+func (rp *recordingProcessor) ProcStructClose(st *vertigo.StructureClose, line int, err error) error {
+	rp.events = append(rp.events, "</"+st.Name+">")
+	return nil
+}
+
+func TestParseConllU(t *testing.T) {
+	var rp recordingProcessor
+	err := ParseConllU(context.Background(), strings.NewReader(testConllU), &rp)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			"<doc>", "<s>", "token", "token", "token", "token", "</s>",
+			"<s>", "token", "token", "token", "</s>", "</doc>",
+		},
+		rp.events,
+	)
+	assert.Len(t, rp.tokens, 7)
+	assert.Equal(t, "dog", rp.tokens[1].PosAttrByIndex(ConllULemmaIdx))
+	assert.Equal(t, "NOUN", rp.tokens[1].PosAttrByIndex(ConllUPosIdx))
+	assert.Equal(t, "nsubj", rp.tokens[1].PosAttrByIndex(ConllUDeprelIdx))
+	assert.Equal(t, "1", rp.tokens[1].PosAttrByIndex(ConllUParentIdx))
+	assert.Equal(t, "0", rp.tokens[2].PosAttrByIndex(ConllUParentIdx))
+	assert.Equal(t, "-1", rp.tokens[3].PosAttrByIndex(ConllUParentIdx))
+	assert.Equal(t, "not", rp.tokens[5].PosAttrByIndex(ConllULemmaIdx))
+	assert.Equal(t, "fiction", rp.tokens[0].StructAttrs["doc.genre"])
+	assert.Equal(t, "doc1", rp.tokens[0].StructAttrs["doc.id"])
+	assert.Equal(t, "1", rp.tokens[0].StructAttrs["s.sent_id"])
+	assert.Equal(t, "2", rp.tokens[4].StructAttrs["s.sent_id"])
+	assert.Equal(t, 6, rp.tokens[6].Idx)
+}
+
+func TestParseConllUInvalidLine(t *testing.T) {
+	var rp recordingProcessor
+	err := ParseConllU(context.Background(), strings.NewReader("1\tdog\tdog\tNOUN\n"), &rp)
+	assert.ErrorContains(t, err, "invalid CoNLL-U line 1")
+}
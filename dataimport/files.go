@@ -0,0 +1,113 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileSelection specifies which files of a directory are imported.
+// Patterns are shell file name patterns (see filepath.Match) matched
+// against file names (i.e. without the directory part).
+type FileSelection struct {
+
+	// Include, if non-empty, contains patterns at least one of which
+	// a file must match to be imported
+	Include []string
+
+	// Exclude contains patterns of files which are never imported
+	// (even if they match Include)
+	Exclude []string
+}
+
+// Validate tests whether all the patterns are well-formed
+func (sel FileSelection) Validate() error {
+	for _, p := range slices.Concat(sel.Include, sel.Exclude) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid file pattern %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Matches tests whether a file name passes the selection
+func (sel FileSelection) Matches(name string) bool {
+	matchesAny := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(p string) bool {
+			ok, _ := filepath.Match(p, name)
+			return ok
+		})
+	}
+	if len(sel.Include) > 0 && !matchesAny(sel.Include) {
+		return false
+	}
+	return !matchesAny(sel.Exclude)
+}
+
+// SelectVertFiles returns vertical files to be imported. For a regular
+// file, just the file is returned (the selection is not applied).
+// For a directory, all the regular files matching the selection are
+// returned in lexicographical order of their names. Subdirectories
+// and hidden files (starting with a dot) are always skipped.
+func SelectVertFiles(path string, sel FileSelection) ([]string, error) {
+	if err := sel.Validate(); err != nil {
+		return []string{}, fmt.Errorf("failed to determine files to process: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to determine files to process: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to list directory contents: %w", err)
+	}
+	ans := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || !sel.Matches(entry.Name()) {
+			continue
+		}
+		filePath := filepath.Join(path, entry.Name())
+		// note: Stat follows symlinks
+		if fileInfo, err := os.Stat(filePath); err != nil || !fileInfo.Mode().IsRegular() {
+			continue
+		}
+		ans = append(ans, filePath)
+	}
+	slices.Sort(ans)
+	return ans, nil
+}
+
+// ParseFilePatterns splits a comma-separated list of file patterns
+func ParseFilePatterns(v string) []string {
+	if v == "" {
+		return nil
+	}
+	ans := make([]string, 0, 4)
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			ans = append(ans, p)
+		}
+	}
+	return ans
+}
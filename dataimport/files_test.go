@@ -0,0 +1,69 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectVertFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.vert", "a.vert", "c.vrt", "README.md", ".hidden.vert", "old.vert"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub.vert"), 0755))
+
+	files, err := SelectVertFiles(dir, FileSelection{})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			filepath.Join(dir, "README.md"),
+			filepath.Join(dir, "a.vert"),
+			filepath.Join(dir, "b.vert"),
+			filepath.Join(dir, "c.vrt"),
+			filepath.Join(dir, "old.vert"),
+		},
+		files,
+	)
+
+	files, err = SelectVertFiles(
+		dir, FileSelection{Include: []string{"*.vert", "*.vrt"}, Exclude: []string{"old*"}})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{filepath.Join(dir, "a.vert"), filepath.Join(dir, "b.vert"), filepath.Join(dir, "c.vrt")},
+		files,
+	)
+
+	// a single file is returned as is
+	files, err = SelectVertFiles(filepath.Join(dir, "README.md"), FileSelection{Include: []string{"*.vert"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "README.md")}, files)
+
+	_, err = SelectVertFiles(dir, FileSelection{Include: []string{"[a-"}})
+	assert.Error(t, err)
+}
+
+func TestParseFilePatterns(t *testing.T) {
+	assert.Nil(t, ParseFilePatterns(""))
+	assert.Equal(t, []string{"*.vert", "*.vrt"}, ParseFilePatterns("*.vert, *.vrt,"))
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/tomachalek/vertigo/v6"
+)
+
+// FileTextTypeAttr is a pseudo structural attribute containing text
+// types derived from names of imported files (see FileTextTypeRules).
+// It is attached to all the tokens of a file by FileTextTypeProc.
+const FileTextTypeAttr = "file.tt"
+
+// FileTextTypeRule assigns a text type to files with names matching
+// a shell file name pattern (see filepath.Match)
+type FileTextTypeRule struct {
+	Pattern  string
+	TextType string
+}
+
+// FileTextTypeRules specify text types of imported files based on
+// their names. Rules are tested in their order and the first matching
+// one is applied.
+type FileTextTypeRules []FileTextTypeRule
+
+// ParseFileTextTypes parses a comma-separated list of pattern=text_type
+// rules (e.g. "news*.vert=news,fic*=fiction"). For an empty string,
+// nil is returned.
+func ParseFileTextTypes(spec string) (FileTextTypeRules, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	ans := make(FileTextTypeRules, 0, 4)
+	for _, item := range strings.Split(spec, ",") {
+		pattern, textType, ok := strings.Cut(item, "=")
+		pattern = strings.TrimSpace(pattern)
+		textType = strings.TrimSpace(textType)
+		if !ok || pattern == "" || textType == "" {
+			return nil, fmt.Errorf("invalid file text type rule '%s' (expected pattern=text_type)", item)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid file pattern %s: %w", pattern, err)
+		}
+		ans = append(ans, FileTextTypeRule{Pattern: pattern, TextType: textType})
+	}
+	return ans, nil
+}
+
+// TextTypeOf returns a text type of a file (the directory part of
+// the path is ignored). If no rule matches the file, false is returned.
+func (rules FileTextTypeRules) TextTypeOf(path string) (string, bool) {
+	name := filepath.Base(path)
+	for _, r := range rules {
+		if ok, _ := filepath.Match(r.Pattern, name); ok {
+			return r.TextType, true
+		}
+	}
+	return "", false
+}
+
+// Mapping returns a text type mapping with raw values assigned
+// to the text types in their lexicographical order (starting from 1
+// as 0 stands for an unknown text type).
+func (rules FileTextTypeRules) Mapping() (map[string]byte, error) {
+	textTypes := make(map[string]bool)
+	for _, r := range rules {
+		textTypes[r.TextType] = true
+	}
+	if len(textTypes) > math.MaxUint8 {
+		return nil, fmt.Errorf("too many file text types (%d, max. %d)", len(textTypes), math.MaxUint8)
+	}
+	ans := make(map[string]byte, len(textTypes))
+	for i, tt := range slices.Sorted(maps.Keys(textTypes)) {
+		ans[tt] = byte(i + 1)
+	}
+	return ans, nil
+}
+
+// Validate tests whether each of the files matches some of the rules.
+// Empty rules accept any files.
+func (rules FileTextTypeRules) Validate(files []string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	for _, f := range files {
+		if _, ok := rules.TextTypeOf(f); !ok {
+			return fmt.Errorf("no file text type rule matches file %s", f)
+		}
+	}
+	return nil
+}
+
+// ------------------------------
+
+// FileTextTypeProc wraps a vertigo.LineProcessor and attaches
+// a fixed text type (as FileTextTypeAttr) to all the tokens passed
+// to the wrapped processor.
+type FileTextTypeProc struct {
+	vertigo.LineProcessor
+	textType string
+}
+
+func (p *FileTextTypeProc) ProcToken(token *vertigo.Token, line int, err error) error {
+	if token != nil {
+		// the attribute maps may be shared by multiple tokens
+		// (e.g. in CoNLL-U sentences) so we do not modify them
+		attrs := maps.Clone(token.StructAttrs)
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		attrs[FileTextTypeAttr] = p.textType
+		token.StructAttrs = attrs
+	}
+	return p.LineProcessor.ProcToken(token, line, err)
+}
+
+// NewFileTextTypeProc creates a processor attaching a text type
+// of the file to the tokens passed to proc
+func NewFileTextTypeProc(proc vertigo.LineProcessor, textType string) *FileTextTypeProc {
+	return &FileTextTypeProc{LineProcessor: proc, textType: textType}
+}
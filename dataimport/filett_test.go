@@ -0,0 +1,118 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileTextTypes(t *testing.T) {
+	rules, err := ParseFileTextTypes("news*.vert=news, fic*=fiction,*=other")
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		FileTextTypeRules{
+			{Pattern: "news*.vert", TextType: "news"},
+			{Pattern: "fic*", TextType: "fiction"},
+			{Pattern: "*", TextType: "other"},
+		},
+		rules,
+	)
+
+	rules, err = ParseFileTextTypes("")
+	assert.NoError(t, err)
+	assert.Nil(t, rules)
+
+	_, err = ParseFileTextTypes("news*.vert")
+	assert.Error(t, err)
+	_, err = ParseFileTextTypes("=news")
+	assert.Error(t, err)
+	_, err = ParseFileTextTypes("[news=news")
+	assert.Error(t, err)
+}
+
+func TestFileTextTypeRulesTextTypeOf(t *testing.T) {
+	rules := FileTextTypeRules{
+		{Pattern: "news*.vert", TextType: "news"},
+		{Pattern: "*.vert", TextType: "other"},
+	}
+	tt, ok := rules.TextTypeOf("/data/corp/news2020.vert")
+	assert.True(t, ok)
+	assert.Equal(t, "news", tt)
+	tt, ok = rules.TextTypeOf("/data/corp/fiction.vert")
+	assert.True(t, ok)
+	assert.Equal(t, "other", tt)
+	_, ok = rules.TextTypeOf("/data/news/a.vrt")
+	assert.False(t, ok)
+
+	assert.NoError(t, rules.Validate([]string{"/data/news1.vert", "/data/b.vert"}))
+	assert.Error(t, rules.Validate([]string{"/data/news1.vert", "/data/b.vrt"}))
+	assert.NoError(t, FileTextTypeRules(nil).Validate([]string{"/data/b.vrt"}))
+}
+
+func TestFileTextTypeRulesMapping(t *testing.T) {
+	rules := FileTextTypeRules{
+		{Pattern: "n*", TextType: "news"},
+		{Pattern: "f*", TextType: "fiction"},
+		{Pattern: "x*", TextType: "news"},
+	}
+	mapping, err := rules.Mapping()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]byte{"fiction": 1, "news": 2}, mapping)
+}
+
+func TestFileTextTypeProc(t *testing.T) {
+	var rp recordingProcessor
+	proc := NewFileTextTypeProc(&rp, "news")
+	err := ParseConllU(context.Background(), strings.NewReader(testConllU), proc)
+	assert.NoError(t, err)
+	assert.Len(t, rp.tokens, 7)
+	for _, tk := range rp.tokens {
+		assert.Equal(t, "news", tk.StructAttrs[FileTextTypeAttr])
+	}
+	assert.Equal(t, "fiction", rp.tokens[0].StructAttrs["doc.genre"])
+	assert.Equal(t, []string{"<doc>", "<s>", "token"}, rp.events[:3])
+}
+
+func TestFileTextTypesMultipleFiles(t *testing.T) {
+	f := NewFreqs(
+		ConllULemmaIdx, ConllUPosIdx, ConllUDeprelIdx, FileTextTypeAttr,
+		map[string]byte{"fiction": 0x01, "news": 0x02},
+	)
+	proc := NewSearcher(50, ConllULemmaIdx, ConllUPosIdx, ConllUParentIdx, ConllUDeprelIdx, f)
+	data := fmt.Sprintf(testParallelSent, 1)
+	for _, tt := range []string{"news", "fiction"} {
+		assert.NoError(t, ParseConllU(context.Background(), strings.NewReader(data), NewFileTextTypeProc(proc, tt)))
+		assert.NoError(t, proc.Wait())
+	}
+	// tokens of the first file must not be counted again in the second one
+	assert.Equal(t, int64(12), proc.ImportedCorpusSize())
+	freqs := make(map[byte]int)
+	for _, v := range f.Single {
+		if v.Lemma == "chair" {
+			freqs[v.TextType.Raw] += v.Freq
+		}
+	}
+	assert.Len(t, freqs, 2)
+	assert.Positive(t, freqs[0x01])
+	assert.Equal(t, freqs[0x01], freqs[0x02])
+}
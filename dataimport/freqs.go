@@ -17,9 +17,14 @@
 package dataimport
 
 import (
+	"encoding/gob"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/czcorpus/depreldb/record"
 	"github.com/czcorpus/depreldb/storage"
@@ -27,6 +32,34 @@ import (
 	"github.com/tomachalek/vertigo/v6"
 )
 
+const (
+	siblingDistance = 2
+)
+
+// forEachPathPair calls fn for all the pairs (i, j) of tokens on a tree
+// path which are considered co-occurrences by the provided policy.
+// The path is expected to start with a leaf so for i < j, path[j] is
+// an ancestor of path[i].
+func forEachPathPair(policy storage.PathPolicy, path []*vertigo.Token, fn func(i, j int)) {
+	ancDepth, descDepth := policy.Depths()
+	if ancDepth == 0 {
+		ancDepth = len(path)
+	}
+	if descDepth == 0 {
+		descDepth = len(path)
+	}
+	for i := range path {
+		for j := max(0, i-descDepth); j < min(i+ancDepth+1, len(path)); j++ {
+			if i == j {
+				continue
+			}
+			fn(i, j)
+		}
+	}
+}
+
+// ------
+
 type freqs struct {
 	LemmaIdx     int
 	PosIdx       int
@@ -35,15 +68,215 @@ type freqs struct {
 	Single       map[record.GroupingKey]record.TokenFreq
 	Double       map[record.GroupingKey]record.CollocFreq
 	TTMapping    map[string]byte
+
+	// FormSingle and FormDouble are variants of Single and Double
+	// collected for word forms (see SetIndexWordForms)
+	FormSingle map[record.GroupingKey]record.TokenFreq
+	FormDouble map[record.GroupingKey]record.CollocFreq
+
+	// PathDouble contains pairs of tokens connected via other nodes
+	// (see SetPathCollocationsDepth)
+	PathDouble map[record.GroupingKey]record.CollocFreq
+
+	// ttAttrs contains structural attributes of individual
+	// text type dimensions (see storage.TextTypeAttrs)
+	ttAttrs []string
+
+	pairWeighting    PairWeighting
+	pathPolicy       storage.PathPolicy
+	deprelPathLabels bool
+	indexWordForms   bool
+
+	// pathCollocationsDepth is a max. number of relations between
+	// tokens collected to PathDouble (see SetPathCollocationsDepth)
+	pathCollocationsDepth int
+
+	// pairExamples is a max. number of examples (corpus positions)
+	// collected for each pair (see SetPairExamples)
+	pairExamples int
+
+	// featsIdx and morphFeats specify morphological features
+	// the collected frequencies are split by (see SetMorphFeats)
+	featsIdx   int
+	morphFeats []string
+
+	// spillThreshold, spillDir, runs and spillErr are used for
+	// flushing collected data to temporary files (see SetSpilling)
+	spillThreshold int
+	spillDir       string
+	runs           spilledRuns
+	spillErr       error
+
+	// importErr is the first error of tree path imports
+	// (reported by StoreToDb, see registerDeprelLabel)
+	importErr error
+}
+
+// SetDeprelPathLabels enables storing of pairs connected via other
+// nodes with a label describing the whole relation path (e.g. "obj→amod")
+// instead of just the dependent's own relation.
+func (f *freqs) SetDeprelPathLabels(v bool) {
+	f.deprelPathLabels = v
+}
+
+// SetPathCollocationsDepth enables collecting of pairs of tokens
+// connected via up to maxDepth relations (e.g. a verb and an adjective
+// modifying the verb's object for maxDepth >= 2) labeled with their
+// relation paths (e.g. "obj→amod"). Such pairs are collected separately
+// from the regular ones (see storage.DB.StorePathFreqs) regardless
+// of the path policy. Values lower than 2 disable the collecting.
+func (f *freqs) SetPathCollocationsDepth(maxDepth int) {
+	f.pathCollocationsDepth = maxDepth
+}
+
+// SetPairExamples enables collecting of up to n examples (corpus
+// positions of both the tokens) for each pair so the pairs can be linked
+// to concordances (see storage.DB.GetExamples). Zero disables the collecting.
+func (f *freqs) SetPairExamples(n int) {
+	f.pairExamples = n
+}
+
+// SetIndexWordForms enables collecting of frequencies also for
+// word forms (the "word" column) so they can be stored along with
+// the lemma data (see storage.DB.StoreWordFormFreqs).
+func (f *freqs) SetIndexWordForms(v bool) {
+	f.indexWordForms = v
+}
+
+// SetMorphFeats makes the collected frequencies of single tokens
+// and pairs (by their first token) to be split by the selected UD
+// morphological features read from the featsIdx column. An empty
+// list of features disables the splitting.
+func (f *freqs) SetMorphFeats(featsIdx int, names []string) {
+	f.featsIdx = featsIdx
+	f.morphFeats = names
+}
+
+// tokenFeats returns encoded morphological features of the token
+// (zero in case no features are collected)
+func (f *freqs) tokenFeats(token *vertigo.Token) record.UDFeats {
+	if len(f.morphFeats) == 0 {
+		return 0
+	}
+	return record.ParseUDFeats(token.PosAttrByIndex(f.featsIdx), f.morphFeats)
+}
+
+// SetSpilling enables a bounded-memory mode where collected data
+// are flushed to temporary sorted files (created within tmpDir,
+// empty = system default) once the number of in-memory records
+// reaches threshold. The files are merged when the data are stored
+// (see StoreToDb). A zero threshold disables the spilling.
+func (f *freqs) SetSpilling(threshold int, tmpDir string) error {
+	f.spillThreshold = threshold
+	if threshold <= 0 {
+		return nil
+	}
+	dir, err := os.MkdirTemp(tmpDir, "depreldb-spill-")
+	if err != nil {
+		return fmt.Errorf("failed to set up spilling of collected data: %w", err)
+	}
+	f.spillDir = dir
+	return nil
+}
+
+// NumRecords returns the number of records currently held in memory
+// (i.e. without the spilled ones)
+func (f *freqs) NumRecords() int {
+	return len(f.Single) + len(f.Double) + len(f.FormSingle) + len(f.FormDouble) + len(f.PathDouble)
+}
+
+// spillIfFull flushes collected data to temporary files in case
+// the spilling is enabled and the in-memory limit is reached.
+// As the method is called during tree path imports, a possible
+// error is kept and reported by StoreToDb.
+func (f *freqs) spillIfFull() {
+	if f.spillThreshold <= 0 || f.spillErr != nil || f.NumRecords() < f.spillThreshold {
+		return
+	}
+	f.spillErr = f.spill()
+}
+
+// spill writes all the in-memory data to new temporary
+// files and clears them
+func (f *freqs) spill() error {
+	var run spilledRuns
+	var err error
+	if len(f.Single) > 0 {
+		var path string
+		path, err = writeRun(f.spillDir, f.Single)
+		run.single = append(run.single, path)
+		f.Single = make(map[record.GroupingKey]record.TokenFreq)
+	}
+	if err == nil && len(f.Double) > 0 {
+		var path string
+		path, err = writeRun(f.spillDir, f.Double)
+		run.double = append(run.double, path)
+		f.Double = make(map[record.GroupingKey]record.CollocFreq)
+	}
+	if err == nil && len(f.FormSingle) > 0 {
+		var path string
+		path, err = writeRun(f.spillDir, f.FormSingle)
+		run.formSingle = append(run.formSingle, path)
+		f.FormSingle = make(map[record.GroupingKey]record.TokenFreq)
+	}
+	if err == nil && len(f.FormDouble) > 0 {
+		var path string
+		path, err = writeRun(f.spillDir, f.FormDouble)
+		run.formDouble = append(run.formDouble, path)
+		f.FormDouble = make(map[record.GroupingKey]record.CollocFreq)
+	}
+	if err == nil && len(f.PathDouble) > 0 {
+		var path string
+		path, err = writeRun(f.spillDir, f.PathDouble)
+		run.pathDouble = append(run.pathDouble, path)
+		f.PathDouble = make(map[record.GroupingKey]record.CollocFreq)
+	}
+	if err != nil {
+		return err
+	}
+	f.runs.add(run)
+	log.Debug().
+		Int("numSingleRuns", len(f.runs.single)).
+		Int("numDoubleRuns", len(f.runs.double)).
+		Msg("spilled collected data to temporary files")
+	return nil
+}
+
+// removeSpilled removes all the temporary files and disables
+// further spilling
+func (f *freqs) removeSpilled() {
+	if f.spillDir == "" {
+		return
+	}
+	if err := os.RemoveAll(f.spillDir); err != nil {
+		log.Warn().Err(err).Str("path", f.spillDir).Msg("failed to remove spilled data")
+	}
+	f.spillDir = ""
+	f.spillThreshold = 0
+	f.runs = spilledRuns{}
+}
+
+// SetPathPolicy sets a policy specifying which token pairs on
+// a tree path are considered co-occurrences.
+func (f *freqs) SetPathPolicy(p storage.PathPolicy) {
+	f.pathPolicy = p
+}
+
+// SetPairWeighting sets a weighting applied to all the
+// co-occurrences found on tree paths. Use nil to disable the weighting.
+func (f *freqs) SetPairWeighting(w PairWeighting) {
+	f.pairWeighting = w
 }
 
 func (f *freqs) newCollocFreq(token1, token2 *vertigo.Token, freq int, distance int) record.CollocFreq {
 	var dirDeprel record.UDDeprel
+	direction := record.DirectionHead
 	if distance > 0 {
 		dirDeprel = record.ImportUDDeprel(token2.PosAttrByIndex(f.DeprelIdx))
 
 	} else if distance < 0 {
 		dirDeprel = record.ImportUDDeprel(token1.PosAttrByIndex(f.DeprelIdx))
+		direction = record.DirectionDependent
 
 	} else {
 		panic(errors.New("newCollocFreq - cannot create entry with distance 0"))
@@ -55,66 +288,239 @@ func (f *freqs) newCollocFreq(token1, token2 *vertigo.Token, freq int, distance
 		Lemma2: token2.PosAttrByIndex(f.LemmaIdx),
 		PoS2:   record.ImportUDPoS(token2.PosAttrByIndex(f.PosIdx)),
 		TextType: record.TextType{
-			Readable: token1.StructAttrs[f.TextTypeAttr],
-			Raw:      f.TTMapping[token1.StructAttrs[f.TextTypeAttr]],
+			Readable: f.textType(token1),
+			Raw:      f.TTMapping[f.textType(token1)],
 		},
-		Freq:    freq,
-		AVGDist: float64(distance),
+		Freq:      freq,
+		AVGDist:   math.Abs(float64(distance)),
+		Direction: direction,
+		Feats1:    f.tokenFeats(token1),
 	}
 }
 
 func (f *freqs) AddLemma(token *vertigo.Token, freq int) {
+	// note: the frequency is added by addTokenFreq (setting it also
+	// here would count the first occurrence twice)
 	newEntry := record.TokenFreq{
 		Lemma: token.PosAttrByIndex(f.LemmaIdx),
 		PoS:   record.ImportUDPoS(token.PosAttrByIndex(f.PosIdx)),
-		Freq:  freq,
 		TextType: record.TextType{
-			Readable: token.StructAttrs[f.TextTypeAttr],
-			Raw:      f.TTMapping[token.StructAttrs[f.TextTypeAttr]],
+			Readable: f.textType(token),
+			Raw:      f.TTMapping[f.textType(token)],
 		},
+		Feats: f.tokenFeats(token),
+	}
+	addTokenFreq(f.Single, newEntry, freq)
+	if f.indexWordForms {
+		newEntry.Lemma = token.Word
+		addTokenFreq(f.FormSingle, newEntry, freq)
 	}
-	curr, ok := f.Single[newEntry.Key()]
+}
+
+func addTokenFreq(freqs map[record.GroupingKey]record.TokenFreq, newEntry record.TokenFreq, freq int) {
+	curr, ok := freqs[newEntry.Key()]
 	if !ok {
 		curr = newEntry
 	}
 	curr.UpdateFreq(freq)
-	f.Single[curr.Key()] = curr
+	freqs[curr.Key()] = curr
+}
+
+// textType returns a text type of the token. In case of multiple
+// text type dimensions, values of all the dimension attributes are
+// combined (e.g. "fiction|1990s").
+func (f *freqs) textType(token *vertigo.Token) string {
+	if len(f.ttAttrs) == 1 {
+		return token.StructAttrs[f.ttAttrs[0]]
+	}
+	values := make([]string, len(f.ttAttrs))
+	for i, attr := range f.ttAttrs {
+		values[i] = token.StructAttrs[attr]
+	}
+	return strings.Join(values, storage.TextTypeDimSeparator)
 }
 
 func (f *freqs) validateTT(token *vertigo.Token) {
-	_, ok := f.TTMapping[token.StructAttrs[f.TextTypeAttr]]
+	_, ok := f.TTMapping[f.textType(token)]
 	if !ok {
 		log.Warn().
 			Str("confAttribute", f.TextTypeAttr).
-			Str("sourceValue", token.StructAttrs[f.TextTypeAttr]).
+			Str("sourceValue", f.textType(token)).
 			Msg("cannot map text type value")
 	}
 }
 
 func (f *freqs) AddCooc(token1, token2 *vertigo.Token, freq int, distance int) {
+	f.addWeightedCooc(token1, token2, freq, distance, 1, "")
+}
+
+// addWeightedCooc adds a co-occurrence with a weight applied. If deprelLabel
+// is non-empty, it replaces the deprel otherwise derived from the tokens.
+func (f *freqs) addWeightedCooc(token1, token2 *vertigo.Token, freq int, distance int, weight float64, deprelLabel string) {
 	newEntry := f.newCollocFreq(token1, token2, 0, distance) // here we need the "distance" to get proper HEAD/DEPENDENT distinction
+	f.addCoocEntry(newEntry, token1, token2, freq, distance, weight, deprelLabel)
+}
+
+// addCoocEntry adds a co-occurrence of token1 and token2 described
+// by newEntry (see addWeightedCooc)
+func (f *freqs) addCoocEntry(
+	newEntry record.CollocFreq,
+	token1, token2 *vertigo.Token,
+	freq, distance int,
+	weight float64,
+	deprelLabel string,
+) {
+	if deprelLabel != "" {
+		code, ok := f.registerDeprelLabel(deprelLabel)
+		if !ok {
+			return
+		}
+		newEntry.Deprel = record.UDDeprel{Raw: code, Readable: deprelLabel}
+	}
+	surfaceDist := token2.Idx - token1.Idx
+	addCollocFreq(f.Double, newEntry, freq, distance, surfaceDist, weight)
+	if f.pairExamples > 0 {
+		f.addPairExample(newEntry.Key(), token1, token2)
+	}
+	if f.indexWordForms {
+		newEntry.Lemma1 = token1.Word
+		newEntry.Lemma2 = token2.Word
+		addCollocFreq(f.FormDouble, newEntry, freq, distance, surfaceDist, weight)
+	}
+}
+
+func addCollocFreq(
+	freqs map[record.GroupingKey]record.CollocFreq,
+	newEntry record.CollocFreq,
+	freq, distance, surfaceDist int,
+	weight float64,
+) {
 	entryKey := newEntry.Key()
-	curr, ok := f.Double[entryKey]
+	curr, ok := freqs[entryKey]
 	if !ok {
 		curr = newEntry
 	}
-	curr.UpdateFreqAndDist(freq, distance)
-	f.Double[entryKey] = curr
+	curr.UpdateSurfaceDist(freq, surfaceDist)
+	curr.UpdateFreqAndDist(freq, max(distance, -distance)) // direction is already in the key
+	curr.WeightedFreq += float64(freq) * weight
+	freqs[entryKey] = curr
+}
+
+// registerDeprelLabel provides a code of a relation label, registering
+// the label if needed. As the method is called during tree path imports,
+// a possible error (no free code left) is kept and reported by StoreToDb
+// and false is returned.
+func (f *freqs) registerDeprelLabel(label string) (uint16, bool) {
+	code, err := record.UDDeprelMapping.GetOrRegister(label)
+	if err != nil {
+		if f.importErr == nil {
+			f.importErr = err
+		}
+		return 0, false
+	}
+	return code, true
+}
+
+// addPairExample adds the occurrence of the pair to the examples
+// of the Double entry stored under the key unless the entry already
+// contains the required number of examples
+func (f *freqs) addPairExample(key record.GroupingKey, token1, token2 *vertigo.Token) {
+	curr := f.Double[key]
+	example := record.PairExample{Position: token1.Idx, CollocatePosition: token2.Idx}
+	if len(curr.Examples) >= f.pairExamples || slices.Contains(curr.Examples, example) {
+		return
+	}
+	curr.Examples = append(curr.Examples, example)
+	f.Double[key] = curr
+}
+
+// pathDeprelLabel creates a label describing relations between path[i]
+// and path[j] which are connected via at least one other node. Arrows
+// point from heads to dependents - e.g. "obj→amod" for path[i] being
+// a verb and path[j] an adjective modifying the verb's object.
+func (f *freqs) pathDeprelLabel(path []*vertigo.Token, i, j int) string {
+	rels := make([]string, 0, max(i-j, j-i))
+	for k := min(i, j); k < max(i, j); k++ {
+		rels = append(rels, strings.ToLower(path[k].PosAttrByIndex(f.DeprelIdx)))
+	}
+	if i > j {
+		slices.Reverse(rels)
+		return strings.Join(rels, "→")
+	}
+	return strings.Join(rels, "←")
 }
 
 func (f *freqs) ImportTreePath(sent []*vertigo.Token) {
 	if len(sent) > 0 {
 		f.validateTT(sent[0]) // just shows a warning in case of missing tt values
 	}
-	for i, tok := range sent {
+	for _, tok := range sent {
 		f.AddLemma(tok, 1)
-		for j := max(0, i-2); j < min(i+2, len(sent)); j++ {
+	}
+	forEachPathPair(f.pathPolicy, sent, func(i, j int) {
+		weight := 1.0
+		if f.pairWeighting != nil {
+			weight = f.pairWeighting.Weight(sent, i, j)
+		}
+		var deprelLabel string
+		if f.deprelPathLabels && (i-j > 1 || j-i > 1) {
+			deprelLabel = f.pathDeprelLabel(sent, i, j)
+		}
+		f.addWeightedCooc(sent[i], sent[j], 1, i-j, weight, deprelLabel)
+	})
+	if f.pathCollocationsDepth > 1 {
+		f.importPathCollocations(sent)
+	}
+	f.spillIfFull()
+}
+
+// importPathCollocations adds all the pairs of tokens on the path
+// connected via at least one and at most pathCollocationsDepth - 1
+// other nodes to PathDouble. The pairs are labeled with their whole
+// relation paths (see pathDeprelLabel).
+func (f *freqs) importPathCollocations(path []*vertigo.Token) {
+	for i := range path {
+		for j := max(0, i-f.pathCollocationsDepth); j < min(i+f.pathCollocationsDepth+1, len(path)); j++ {
+			if i-j < 2 && j-i < 2 {
+				continue
+			}
+			deprelLabel := f.pathDeprelLabel(path, i, j)
+			code, ok := f.registerDeprelLabel(deprelLabel)
+			if !ok {
+				continue
+			}
+			newEntry := f.newCollocFreq(path[i], path[j], 0, i-j)
+			newEntry.Deprel = record.UDDeprel{Raw: code, Readable: deprelLabel}
+			addCollocFreq(f.PathDouble, newEntry, 1, i-j, path[j].Idx-path[i].Idx, 1)
+		}
+	}
+}
+
+func (f *freqs) ImportSiblings(head *vertigo.Token, siblings []*vertigo.Token) {
+	for i, tok1 := range siblings {
+		for j, tok2 := range siblings {
 			if i == j {
 				continue
 			}
-			f.AddCooc(tok, sent[j], 1, i-j)
+			deprelLabel := record.UDDeprelMapping.GetRev(record.DeprelSibling)
+			if f.deprelPathLabels {
+				var headRel string
+				if head != nil {
+					headRel = head.PosAttrByIndex(f.DeprelIdx)
+				}
+				deprelLabel = strings.ToLower(fmt.Sprintf(
+					"%s←%s→%s",
+					tok1.PosAttrByIndex(f.DeprelIdx), headRel, tok2.PosAttrByIndex(f.DeprelIdx),
+				))
+			}
+			// siblings are connected via their head, i.e. their tree distance
+			// is 2, and none of them is the head of the other
+			newEntry := f.newCollocFreq(tok1, tok2, 0, siblingDistance)
+			newEntry.Direction = record.DirectionSibling
+			f.addCoocEntry(newEntry, tok1, tok2, 1, siblingDistance, 1, deprelLabel)
 		}
 	}
+	f.spillIfFull()
 }
 
 func (f *freqs) PrintPreview() {
@@ -136,9 +542,259 @@ func (f *freqs) PrintPreview() {
 	}
 }
 
-func (f *freqs) StoreToDb(db *storage.DB, minFreq int) (storage.ImportStats, error) {
-	seq := storage.NewTokenIDSequence()
-	return db.StoreData(seq, f.Single, f.Double, minFreq)
+// StoreToDb stores the collected frequencies. In case word forms
+// are indexed (see SetIndexWordForms), they are stored too and
+// the returned stats contain the number of stored word forms
+// (other numbers describe just the lemma data).
+// In case some data have been spilled to temporary files (see
+// SetSpilling), the files are merged and the data are stored
+// in chunks (a storage.DB is used in the append mode so the chunks
+// are merged) and the files are removed.
+func (f *freqs) StoreToDb(db FreqsStorage, minFreq int) (storage.ImportStats, error) {
+	if f.importErr != nil {
+		return storage.ImportStats{}, fmt.Errorf("failed to import tree paths: %w", f.importErr)
+	}
+	if f.spillErr != nil {
+		return storage.ImportStats{}, f.spillErr
+	}
+	if !f.runs.empty() {
+		return f.storeSpilled(db, minFreq)
+	}
+	if f.pairWeighting != nil {
+		applyPairWeighting(f.Double)
+		applyPairWeighting(f.FormDouble)
+	}
+	ans, err := db.StoreFreqs(f.Single, f.Double, minFreq)
+	if err == nil && len(f.PathDouble) > 0 {
+		var pathStats storage.ImportStats
+		pathStats, err = db.StorePathFreqs(f.PathDouble, minFreq)
+		ans.NumPathCollFreqs = pathStats.NumPathCollFreqs
+	}
+	if err != nil || !f.indexWordForms {
+		return ans, err
+	}
+	formStats, err := db.StoreWordFormFreqs(f.FormSingle, f.FormDouble, minFreq)
+	ans.NumWordForms = formStats.NumWordForms
+	return ans, err
+}
+
+// storeSpilled merges all the spilled runs (including the current
+// in-memory data) and stores them in chunks. Single token chunks are
+// stored first so all the lemmas are known once pairs are stored.
+func (f *freqs) storeSpilled(db FreqsStorage, minFreq int) (storage.ImportStats, error) {
+	defer f.removeSpilled()
+	var ans storage.ImportStats
+	if err := f.spill(); err != nil {
+		return ans, err
+	}
+	if sdb, ok := db.(*storage.DB); ok {
+		db = AppendingStorage{DB: sdb}
+	}
+	noSingle := make(map[record.GroupingKey]record.TokenFreq)
+	noDouble := make(map[record.GroupingKey]record.CollocFreq)
+	addStats := func(stats storage.ImportStats) {
+		ans.NumCollFreqs += stats.NumCollFreqs
+		ans.NumLemmaFreqs += stats.NumLemmaFreqs
+		ans.NumLemmas += stats.NumLemmas
+		ans.NumLemmaRollups += stats.NumLemmaRollups
+		ans.NumPairExamples += stats.NumPairExamples
+		ans.RelationDists = storage.MergeRelationDists(ans.RelationDists, stats.RelationDists)
+	}
+	err := mergeRunsInChunks(
+		f.runs.single, f.spillThreshold, combineTokenFreqs,
+		func(chunk map[record.GroupingKey]record.TokenFreq) error {
+			stats, err := db.StoreFreqs(chunk, noDouble, minFreq)
+			addStats(stats)
+			return err
+		},
+	)
+	if err == nil {
+		err = mergeRunsInChunks(
+			f.runs.double, f.spillThreshold, combineCollocFreqs,
+			func(chunk map[record.GroupingKey]record.CollocFreq) error {
+				if f.pairWeighting != nil {
+					applyPairWeighting(chunk)
+				}
+				stats, err := db.StoreFreqs(noSingle, chunk, minFreq)
+				addStats(stats)
+				return err
+			},
+		)
+	}
+	if err == nil {
+		err = mergeRunsInChunks(
+			f.runs.pathDouble, f.spillThreshold, combineCollocFreqs,
+			func(chunk map[record.GroupingKey]record.CollocFreq) error {
+				stats, err := db.StorePathFreqs(chunk, minFreq)
+				ans.NumPathCollFreqs += stats.NumPathCollFreqs
+				return err
+			},
+		)
+	}
+	if err != nil || !f.indexWordForms {
+		return ans, err
+	}
+	err = mergeRunsInChunks(
+		f.runs.formSingle, f.spillThreshold, combineTokenFreqs,
+		func(chunk map[record.GroupingKey]record.TokenFreq) error {
+			stats, err := db.StoreWordFormFreqs(chunk, noDouble, minFreq)
+			ans.NumWordForms += stats.NumWordForms
+			return err
+		},
+	)
+	if err == nil {
+		err = mergeRunsInChunks(
+			f.runs.formDouble, f.spillThreshold, combineCollocFreqs,
+			func(chunk map[record.GroupingKey]record.CollocFreq) error {
+				if f.pairWeighting != nil {
+					applyPairWeighting(chunk)
+				}
+				_, err := db.StoreWordFormFreqs(noSingle, chunk, minFreq)
+				return err
+			},
+		)
+	}
+	return ans, err
+}
+
+func combineTokenFreqs(curr *record.TokenFreq, v record.TokenFreq) {
+	curr.UpdateFreq(v.Freq)
+}
+
+func combineCollocFreqs(curr *record.CollocFreq, v record.CollocFreq) {
+	curr.Merge(v)
+}
+
+// applyPairWeighting replaces pair frequencies with their weighted values.
+// As the stored frequencies are integers, the weighted values are rounded
+// but a pair which has occurred never gets zero frequency (e.g. a single
+// co-occurrence at distance 3 weighs just 1/3 with the distance decay
+// weighting) so no pair is lost (e.g. by the min. frequency filter) just
+// because of the weighting.
+// Note that single token frequencies are never weighted. As all the weights
+// are <= 1, a weighted F(x,y) still cannot exceed F(x) and F(y).
+func applyPairWeighting(freqs map[record.GroupingKey]record.CollocFreq) {
+	for k, v := range freqs {
+		v.Freq = max(1, int(math.Round(v.WeightedFreq)))
+		freqs[k] = v
+	}
+}
+
+// collectedFreqsState is a serializable form of collected frequencies
+type collectedFreqsState struct {
+	Single     map[record.GroupingKey]record.TokenFreq
+	Double     map[record.GroupingKey]record.CollocFreq
+	FormSingle map[record.GroupingKey]record.TokenFreq
+	FormDouble map[record.GroupingKey]record.CollocFreq
+	PathDouble map[record.GroupingKey]record.CollocFreq
+}
+
+func (f *freqs) SaveState(w io.Writer) error {
+	if !f.runs.empty() {
+		return errors.New("failed to save collected frequencies: spilled data cannot be saved")
+	}
+	state := collectedFreqsState{
+		Single:     f.Single,
+		Double:     f.Double,
+		FormSingle: f.FormSingle,
+		FormDouble: f.FormDouble,
+		PathDouble: f.PathDouble,
+	}
+	if err := gob.NewEncoder(w).Encode(state); err != nil {
+		return fmt.Errorf("failed to save collected frequencies: %w", err)
+	}
+	return nil
+}
+
+func (f *freqs) LoadState(r io.Reader) error {
+	var state collectedFreqsState
+	if err := gob.NewDecoder(r).Decode(&state); err != nil {
+		return fmt.Errorf("failed to load collected frequencies: %w", err)
+	}
+	f.Single = state.Single
+	f.Double = state.Double
+	f.FormSingle = state.FormSingle
+	f.FormDouble = state.FormDouble
+	f.PathDouble = state.PathDouble
+	if f.Single == nil {
+		f.Single = make(map[record.GroupingKey]record.TokenFreq)
+	}
+	if f.Double == nil {
+		f.Double = make(map[record.GroupingKey]record.CollocFreq)
+	}
+	if f.FormSingle == nil {
+		f.FormSingle = make(map[record.GroupingKey]record.TokenFreq)
+	}
+	if f.FormDouble == nil {
+		f.FormDouble = make(map[record.GroupingKey]record.CollocFreq)
+	}
+	if f.PathDouble == nil {
+		f.PathDouble = make(map[record.GroupingKey]record.CollocFreq)
+	}
+	return nil
+}
+
+// NewShard creates an empty collector with the same configuration
+// (see ShardedCollector)
+func (f *freqs) NewShard() FreqsCollector {
+	shard := *f
+	shard.Single = make(map[record.GroupingKey]record.TokenFreq)
+	shard.Double = make(map[record.GroupingKey]record.CollocFreq)
+	shard.FormSingle = make(map[record.GroupingKey]record.TokenFreq)
+	shard.FormDouble = make(map[record.GroupingKey]record.CollocFreq)
+	shard.PathDouble = make(map[record.GroupingKey]record.CollocFreq)
+	// shards spill to the same directory but they keep their own runs
+	shard.runs = spilledRuns{}
+	shard.spillErr = nil
+	shard.importErr = nil
+	return &shard
+}
+
+// MergeShard adds all the frequencies collected by a shard created
+// via NewShard.
+func (f *freqs) MergeShard(shard FreqsCollector) error {
+	tShard, ok := shard.(*freqs)
+	if !ok {
+		return fmt.Errorf("failed to merge collected frequencies: incompatible shard type %T", shard)
+	}
+	mergeTokenFreqs(f.Single, tShard.Single)
+	mergeCollocFreqs(f.Double, tShard.Double)
+	mergeTokenFreqs(f.FormSingle, tShard.FormSingle)
+	mergeCollocFreqs(f.FormDouble, tShard.FormDouble)
+	mergeCollocFreqs(f.PathDouble, tShard.PathDouble)
+	f.runs.add(tShard.runs)
+	if tShard.spillErr != nil && f.spillErr == nil {
+		f.spillErr = tShard.spillErr
+	}
+	if tShard.importErr != nil && f.importErr == nil {
+		f.importErr = tShard.importErr
+	}
+	f.spillIfFull()
+	return nil
+}
+
+func mergeTokenFreqs(dst, src map[record.GroupingKey]record.TokenFreq) {
+	for k, v := range src {
+		curr, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		curr.UpdateFreq(v.Freq)
+		dst[k] = curr
+	}
+}
+
+func mergeCollocFreqs(dst, src map[record.GroupingKey]record.CollocFreq) {
+	for k, v := range src {
+		curr, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		curr.Merge(v)
+		dst[k] = curr
+	}
 }
 
 func NewFreqs(lemmaIdx, posIdx, deprelIdx int, ttAttr string, ttMapping map[string]byte) *freqs {
@@ -148,8 +804,12 @@ func NewFreqs(lemmaIdx, posIdx, deprelIdx int, ttAttr string, ttMapping map[stri
 		PosIdx:       posIdx,
 		Single:       make(map[record.GroupingKey]record.TokenFreq),
 		Double:       make(map[record.GroupingKey]record.CollocFreq),
+		FormSingle:   make(map[record.GroupingKey]record.TokenFreq),
+		FormDouble:   make(map[record.GroupingKey]record.CollocFreq),
+		PathDouble:   make(map[record.GroupingKey]record.CollocFreq),
 		TextTypeAttr: ttAttr,
 		TTMapping:    ttMapping,
+		ttAttrs:      storage.TextTypeAttrs(ttAttr),
 	}
 }
 
@@ -161,6 +821,11 @@ type nullFreqs struct {
 	posIdx       int
 	deprelIdx    int
 	textTypeAttr string
+	pathPolicy   storage.PathPolicy
+}
+
+func (f *nullFreqs) SetPathPolicy(p storage.PathPolicy) {
+	f.pathPolicy = p
 }
 
 func (f *nullFreqs) AddLemma(lemma *vertigo.Token, freq int) {
@@ -179,13 +844,20 @@ func (f *nullFreqs) AddCooc(lemma1, lemma2 *vertigo.Token, freq int, distance in
 }
 
 func (f *nullFreqs) ImportTreePath(sent []*vertigo.Token) {
-	for i, tok := range sent {
+	for _, tok := range sent {
 		f.AddLemma(tok, 1)
-		for j := max(0, i-2); j < min(i+2, len(sent)); j++ {
-			if i == j {
-				continue
+	}
+	forEachPathPair(f.pathPolicy, sent, func(i, j int) {
+		f.AddCooc(sent[i], sent[j], 1, i-j)
+	})
+}
+
+func (f *nullFreqs) ImportSiblings(head *vertigo.Token, siblings []*vertigo.Token) {
+	for i, tok1 := range siblings {
+		for j, tok2 := range siblings {
+			if i != j {
+				f.AddCooc(tok1, tok2, 1, siblingDistance)
 			}
-			f.AddCooc(tok, sent[j], 1, i-j)
 		}
 	}
 }
@@ -194,10 +866,18 @@ func (f *nullFreqs) PrintPreview() {
 	fmt.Println("NullFreqs ...")
 }
 
-func (f *nullFreqs) StoreToDb(db *storage.DB, minFreq int) (storage.ImportStats, error) {
+func (f *nullFreqs) StoreToDb(db FreqsStorage, minFreq int) (storage.ImportStats, error) {
 	return storage.ImportStats{}, nil
 }
 
+func (f *nullFreqs) SaveState(w io.Writer) error {
+	return nil
+}
+
+func (f *nullFreqs) LoadState(r io.Reader) error {
+	return nil
+}
+
 func NewNullFreqs(
 	lemmaIdx int,
 	posIdx int,
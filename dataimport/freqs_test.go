@@ -0,0 +1,287 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+func collectPathPairs(policy storage.PathPolicy, pathLen int) [][2]int {
+	path := make([]*vertigo.Token, pathLen)
+	ans := make([][2]int, 0, 10)
+	forEachPathPair(policy, path, func(i, j int) {
+		ans = append(ans, [2]int{i, j})
+	})
+	return ans
+}
+
+func TestForEachPathPairHead(t *testing.T) {
+	pairs := collectPathPairs(storage.PathPolicy{Name: storage.PathPolicyHead}, 3)
+	assert.Equal(t, [][2]int{{0, 1}, {1, 0}, {1, 2}, {2, 1}}, pairs)
+}
+
+func TestForEachPathPairGrandparentIsDefault(t *testing.T) {
+	// the original hardcoded window: grandparent-grandchild pairs
+	// are imported just once (with the grandparent as the first token)
+	originalPairs := func(pathLen int) [][2]int {
+		ans := make([][2]int, 0, 10)
+		for i := range pathLen {
+			for j := max(0, i-2); j < min(i+2, pathLen); j++ {
+				if i == j {
+					continue
+				}
+				ans = append(ans, [2]int{i, j})
+			}
+		}
+		return ans
+	}
+	pairs := collectPathPairs(storage.PathPolicy{}, 3)
+	assert.Equal(t, [][2]int{{0, 1}, {1, 0}, {1, 2}, {2, 0}, {2, 1}}, pairs)
+	for pathLen := range 7 {
+		assert.Equal(t, originalPairs(pathLen), collectPathPairs(storage.PathPolicy{}, pathLen))
+		assert.Equal(
+			t,
+			originalPairs(pathLen),
+			collectPathPairs(storage.PathPolicy{Name: storage.PathPolicyGrandparent}, pathLen),
+		)
+	}
+}
+
+func TestForEachPathPairFullPath(t *testing.T) {
+	assert.Len(t, collectPathPairs(storage.PathPolicy{Name: storage.PathPolicyFullPath}, 5), 20)
+	pairs := collectPathPairs(storage.PathPolicy{Name: storage.PathPolicyFullPath, MaxDepth: 3}, 5)
+	for _, p := range pairs {
+		assert.LessOrEqual(t, max(p[0]-p[1], p[1]-p[0]), 3)
+	}
+	assert.Len(t, pairs, 18)
+}
+
+func TestForEachPathPairAsymmetric(t *testing.T) {
+	// the grandparent policy made symmetric
+	pairs := collectPathPairs(storage.PathPolicy{AncestorDepth: 2}, 3)
+	assert.Equal(t, [][2]int{{0, 1}, {0, 2}, {1, 0}, {1, 2}, {2, 0}, {2, 1}}, pairs)
+	// heads and dependents only
+	pairs = collectPathPairs(storage.PathPolicy{DescendantDepth: 1}, 3)
+	assert.Equal(t, [][2]int{{0, 1}, {1, 0}, {1, 2}, {2, 1}}, pairs)
+	// asymmetric limits cannot extend the policy depth
+	pairs = collectPathPairs(storage.PathPolicy{Name: storage.PathPolicyHead, DescendantDepth: 3}, 3)
+	assert.Equal(t, [][2]int{{0, 1}, {1, 0}, {1, 2}, {2, 1}}, pairs)
+	pairs = collectPathPairs(storage.PathPolicy{AncestorDepth: 3}, 4)
+	assert.Equal(t, collectPathPairs(storage.PathPolicy{AncestorDepth: 2}, 4), pairs)
+	pairs = collectPathPairs(storage.PathPolicy{Name: storage.PathPolicyFullPath, DescendantDepth: 1}, 4)
+	for _, p := range pairs {
+		assert.LessOrEqual(t, p[0]-p[1], 1)
+	}
+	assert.Len(t, pairs, 9)
+}
+
+func TestFreqsTextTypeDims(t *testing.T) {
+	f := NewFreqs(1, 2, 3, "text.genre,text.period", map[string]byte{"fiction|1990s": 0x01})
+	tok := &vertigo.Token{
+		Word:        "dog",
+		Attrs:       []string{"dog", "NOUN", "nsubj"},
+		StructAttrs: map[string]string{"text.genre": "fiction", "text.period": "1990s"},
+	}
+	f.AddLemma(tok, 1)
+	assert.Len(t, f.Single, 1)
+	for _, v := range f.Single {
+		assert.Equal(t, "fiction|1990s", v.TextType.Readable)
+		assert.Equal(t, byte(0x01), v.TextType.Raw)
+	}
+}
+
+func TestFreqsAddLemmaCountsOnce(t *testing.T) {
+	f := NewFreqs(1, 2, 3, "text.genre", map[string]byte{"fiction": 0x01})
+	f.SetIndexWordForms(true)
+	tok := &vertigo.Token{
+		Word:        "dogs",
+		Attrs:       []string{"dog", "NOUN", "nsubj"},
+		StructAttrs: map[string]string{"text.genre": "fiction"},
+	}
+	f.AddLemma(tok, 1)
+	assert.Len(t, f.Single, 1)
+	for _, v := range f.Single {
+		assert.Equal(t, 1, v.Freq)
+	}
+	assert.Len(t, f.FormSingle, 1)
+	for _, v := range f.FormSingle {
+		assert.Equal(t, 1, v.Freq)
+	}
+	f.AddLemma(tok, 1)
+	for _, v := range f.Single {
+		assert.Equal(t, 2, v.Freq)
+	}
+	for _, v := range f.FormSingle {
+		assert.Equal(t, 2, v.Freq)
+	}
+}
+
+func TestFreqsWordForms(t *testing.T) {
+	f := NewFreqs(1, 2, 3, "text.genre", map[string]byte{"fiction": 0x01})
+	f.SetIndexWordForms(true)
+	head := &vertigo.Token{
+		Idx:         1,
+		Word:        "dogs",
+		Attrs:       []string{"dog", "NOUN", "nsubj"},
+		StructAttrs: map[string]string{"text.genre": "fiction"},
+	}
+	dep := &vertigo.Token{
+		Idx:         0,
+		Word:        "Big",
+		Attrs:       []string{"big", "ADJ", "amod"},
+		StructAttrs: map[string]string{"text.genre": "fiction"},
+	}
+	f.ImportTreePath([]*vertigo.Token{dep, head})
+	assert.Len(t, f.Single, 2)
+	assert.Len(t, f.FormSingle, 2)
+	forms := make([]string, 0, 2)
+	for _, v := range f.FormSingle {
+		forms = append(forms, v.Lemma)
+	}
+	assert.ElementsMatch(t, []string{"dogs", "Big"}, forms)
+	assert.Len(t, f.FormDouble, len(f.Double))
+	for _, v := range f.FormDouble {
+		assert.Contains(t, []string{"dogs", "Big"}, v.Lemma1)
+		assert.Contains(t, []string{"dogs", "Big"}, v.Lemma2)
+		assert.Equal(t, 1, v.Freq)
+	}
+}
+
+func TestFreqsMorphFeats(t *testing.T) {
+	f := NewFreqs(1, 2, 3, "text.genre", map[string]byte{"fiction": 0x01})
+	f.SetMorphFeats(4, []string{"Number"})
+	head := &vertigo.Token{
+		Idx:         1,
+		Word:        "books",
+		Attrs:       []string{"book", "NOUN", "nsubj", "Case=Nom|Number=Plur"},
+		StructAttrs: map[string]string{"text.genre": "fiction"},
+	}
+	dep := &vertigo.Token{
+		Idx:         0,
+		Word:        "old",
+		Attrs:       []string{"old", "ADJ", "amod", "Degree=Pos"},
+		StructAttrs: map[string]string{"text.genre": "fiction"},
+	}
+	f.ImportTreePath([]*vertigo.Token{dep, head})
+	for _, v := range f.Single {
+		if v.Lemma == "book" {
+			assert.Equal(t, "Number=Plur", v.Feats.String())
+
+		} else {
+			assert.Equal(t, record.UDFeats(0), v.Feats)
+		}
+	}
+	for _, v := range f.Double {
+		if v.Lemma1 == "book" {
+			assert.Equal(t, "Number=Plur", v.Feats1.String())
+
+		} else {
+			assert.Equal(t, record.UDFeats(0), v.Feats1)
+		}
+	}
+}
+
+func TestFreqsPathCollocations(t *testing.T) {
+	f := NewFreqs(1, 2, 3, "text.genre", map[string]byte{"fiction": 0x01})
+	f.SetPathPolicy(storage.PathPolicy{Name: storage.PathPolicyHead})
+	f.SetPathCollocationsDepth(2)
+	newToken := func(idx int, lemma, pos, deprel string) *vertigo.Token {
+		return &vertigo.Token{
+			Idx:         idx,
+			Word:        lemma,
+			Attrs:       []string{lemma, pos, deprel},
+			StructAttrs: map[string]string{"text.genre": "fiction"},
+		}
+	}
+	// read -obj-> book -amod-> interesting -advmod-> very
+	f.ImportTreePath([]*vertigo.Token{
+		newToken(2, "very", "ADV", "advmod"),
+		newToken(3, "interesting", "ADJ", "amod"),
+		newToken(4, "book", "NOUN", "obj"),
+		newToken(0, "read", "VERB", "root"),
+	})
+	assert.Len(t, f.Double, 6) // direct relations only (head policy)
+	labels := make(map[string]string)
+	for _, v := range f.PathDouble {
+		labels[v.Lemma1+" "+v.Lemma2] = v.Deprel.Readable
+		assert.Equal(t, 2.0, v.AVGDist)
+	}
+	assert.Equal(
+		t,
+		map[string]string{
+			"read interesting": "obj→amod",
+			"interesting read": "amod←obj",
+			"book very":        "amod→advmod",
+			"very book":        "advmod←amod",
+		},
+		labels,
+	)
+}
+
+func TestFreqsPairExamples(t *testing.T) {
+	f := NewFreqs(1, 2, 3, "text.genre", map[string]byte{"fiction": 0x01})
+	f.SetPathPolicy(storage.PathPolicy{Name: storage.PathPolicyHead})
+	f.SetPairExamples(2)
+	newToken := func(idx int, lemma, pos, deprel string) *vertigo.Token {
+		return &vertigo.Token{
+			Idx:         idx,
+			Word:        lemma,
+			Attrs:       []string{lemma, pos, deprel},
+			StructAttrs: map[string]string{"text.genre": "fiction"},
+		}
+	}
+	for _, start := range []int{10, 20, 30} {
+		f.ImportTreePath([]*vertigo.Token{
+			newToken(start+1, "book", "NOUN", "obj"),
+			newToken(start, "read", "VERB", "root"),
+		})
+	}
+	// the same path imported again (e.g. as a part of another leaf's path)
+	f.ImportTreePath([]*vertigo.Token{
+		newToken(11, "book", "NOUN", "obj"),
+		newToken(10, "read", "VERB", "root"),
+	})
+	assert.Len(t, f.Double, 2)
+	for _, v := range f.Double {
+		assert.Equal(t, 4, v.Freq)
+		if v.Lemma1 == "read" {
+			assert.Equal(t, []record.PairExample{{Position: 10, CollocatePosition: 11}, {Position: 20, CollocatePosition: 21}}, v.Examples)
+
+		} else {
+			assert.Equal(t, []record.PairExample{{Position: 11, CollocatePosition: 10}, {Position: 21, CollocatePosition: 20}}, v.Examples)
+		}
+	}
+}
+
+func TestFreqsImportErrorReported(t *testing.T) {
+	f := NewFreqs(1, 2, 3, "text.genre", map[string]byte{"fiction": 0x01})
+	shard := f.NewShard().(*freqs)
+	shard.importErr = record.ErrDeprelMappingFull
+	assert.NoError(t, f.MergeShard(shard))
+	st := &chunkRecordingStorage{
+		single: make(map[record.GroupingKey]record.TokenFreq),
+		double: make(map[record.GroupingKey]record.CollocFreq),
+	}
+	_, err := f.StoreToDb(st, 1)
+	assert.ErrorIs(t, err, record.ErrDeprelMappingFull)
+	assert.Zero(t, st.numChunks)
+}
@@ -0,0 +1,284 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+const (
+	// DefaultInferenceSampleLines is a default number of vertical file
+	// lines read by the profile inference
+	DefaultInferenceSampleLines = 50000
+
+	minLemmaPrefixMatch = 3
+
+	// maxLemmaWordIdentity is a max. ratio of values equal to lowercase
+	// word forms a lemma column can have (to distinguish lemmas from
+	// word copies and lowercase forms)
+	maxLemmaWordIdentity = 0.98
+)
+
+// ColumnScore describes how well a vertical file column matches
+// a specific kind of data (a ratio within the interval [0, 1])
+type ColumnScore struct {
+	Idx   int     `json:"idx"`
+	Score float64 `json:"score"`
+}
+
+// InferredProfile is a guess of the positions of columns required
+// for an import. A column index -1 means no suitable column was found.
+type InferredProfile struct {
+	NumSampledTokens    int           `json:"numSampledTokens"`
+	NumSampledSentences int           `json:"numSampledSentences"`
+	LemmaIdx            int           `json:"lemmaIdx"`
+	PosIdx              int           `json:"posIdx"`
+	ParentIdx           int           `json:"parentIdx"`
+	DeprelIdx           int           `json:"deprelIdx"`
+	SentenceStruct      string        `json:"sentenceStruct"`
+	LemmaCandidates     []ColumnScore `json:"lemmaCandidates"`
+	PosCandidates       []ColumnScore `json:"posCandidates"`
+	ParentCandidates    []ColumnScore `json:"parentCandidates"`
+	DeprelCandidates    []ColumnScore `json:"deprelCandidates"`
+}
+
+// Complete tells whether all the required columns have been found
+func (ip InferredProfile) Complete() bool {
+	return ip.LemmaIdx >= 0 && ip.PosIdx >= 0 && ip.ParentIdx >= 0 && ip.DeprelIdx >= 0
+}
+
+// ImportArgs formats the guessed column positions as mkscolldb arguments
+func (ip InferredProfile) ImportArgs() string {
+	ans := fmt.Sprintf(
+		"-lemma-idx %d -pos-idx %d -parent-idx %d -deprel-idx %d",
+		ip.LemmaIdx, ip.PosIdx, ip.ParentIdx, ip.DeprelIdx,
+	)
+	if ip.SentenceStruct != "" && ip.SentenceStruct != DefaultSentenceStruct {
+		ans += " -sent-struct " + ip.SentenceStruct
+	}
+	return ans
+}
+
+// ------
+
+type columnStats struct {
+	numValues      int
+	numUDPoS       int
+	numUDDeprel    int
+	numLemmaLike   int
+	numWordCopy    int
+	numValidOffset int
+}
+
+// ProfileInferrer is a vertigo.LineProcessor collecting statistics
+// of individual columns of a (sampled) vertical file so it is possible
+// to guess which columns contain lemmas, PoS tags, parents and deprels.
+// Positions use the same convention as import profiles (0 = word).
+type ProfileInferrer struct {
+	columns           []columnStats
+	numTokens         int
+	numSentences      int
+	numSingleRootSent []int
+	sentStruct        string
+	sentOpen          bool
+	currSent          []*vertigo.Token
+}
+
+func (pi *ProfileInferrer) column(idx int) *columnStats {
+	for len(pi.columns) <= idx {
+		pi.columns = append(pi.columns, columnStats{})
+		pi.numSingleRootSent = append(pi.numSingleRootSent, 0)
+	}
+	return &pi.columns[idx]
+}
+
+func isLemmaLike(word, value string) bool {
+	prefixLen := min(minLemmaPrefixMatch, len(word), len(value))
+	return prefixLen > 0 && word[:prefixLen] == value[:prefixLen]
+}
+
+func (pi *ProfileInferrer) ProcToken(tk *vertigo.Token, line int, err error) error {
+	if err != nil {
+		return nil
+	}
+	pi.numTokens++
+	for i, v := range tk.Attrs {
+		col := pi.column(i + 1)
+		col.numValues++
+		if _, ok := record.UDPoSMapping[strings.ToUpper(v)]; ok {
+			col.numUDPoS++
+		}
+		base, _, _ := strings.Cut(strings.ToLower(v), ":")
+		if _, ok := record.UDDeprelMapping.Get(base); ok {
+			col.numUDDeprel++
+		}
+		lcWord, lcValue := strings.ToLower(tk.Word), strings.ToLower(v)
+		if lcWord == lcValue {
+			col.numWordCopy++
+		}
+		if _, err := strconv.Atoi(v); err != nil && isLemmaLike(lcWord, lcValue) {
+			col.numLemmaLike++
+		}
+	}
+	if pi.sentOpen {
+		pi.currSent = append(pi.currSent, tk)
+	}
+	return nil
+}
+
+// SetSentenceStruct sets a name of the structure delimiting
+// sentences (see Searcher.SetSentenceStruct)
+func (pi *ProfileInferrer) SetSentenceStruct(name string) {
+	if name == "" {
+		name = DefaultSentenceStruct
+	}
+	pi.sentStruct = name
+}
+
+func (pi *ProfileInferrer) ProcStruct(st *vertigo.Structure, line int, err error) error {
+	if err == nil && st.Name == pi.sentStruct {
+		if pi.sentOpen {
+			pi.closeSentence()
+		}
+		pi.sentOpen = true
+	}
+	return nil
+}
+
+func (pi *ProfileInferrer) ProcStructClose(st *vertigo.StructureClose, line int, err error) error {
+	if err == nil && st.Name == pi.sentStruct && pi.sentOpen {
+		pi.closeSentence()
+	}
+	return nil
+}
+
+// closeSentence evaluates all the columns as possible relative
+// parent offsets within the current sentence
+func (pi *ProfileInferrer) closeSentence() {
+	pi.sentOpen = false
+	sent := pi.currSent
+	pi.currSent = make([]*vertigo.Token, 0, len(sent))
+	if len(sent) == 0 {
+		return
+	}
+	pi.numSentences++
+	for colIdx := 1; colIdx < len(pi.columns); colIdx++ {
+		var numRoots int
+		for i, tk := range sent {
+			offset, err := strconv.Atoi(strings.TrimPrefix(tk.PosAttrByIndex(colIdx), "+"))
+			if err != nil || i+offset < 0 || i+offset >= len(sent) {
+				continue
+			}
+			pi.columns[colIdx].numValidOffset++
+			if offset == 0 {
+				numRoots++
+			}
+		}
+		if numRoots == 1 {
+			pi.numSingleRootSent[colIdx]++
+		}
+	}
+}
+
+// bestColumn returns the column with the highest score and all the
+// candidates with a non-zero score. Already used columns are skipped.
+func (pi *ProfileInferrer) bestColumn(
+	used map[int]bool,
+	score func(idx int, col columnStats) float64,
+) (int, []ColumnScore) {
+	best := -1
+	var bestScore float64
+	candidates := make([]ColumnScore, 0, len(pi.columns))
+	for i := 1; i < len(pi.columns); i++ {
+		if pi.columns[i].numValues == 0 {
+			continue
+		}
+		s := score(i, pi.columns[i])
+		if s <= 0 {
+			continue
+		}
+		candidates = append(candidates, ColumnScore{Idx: i, Score: s})
+		if !used[i] && s > bestScore {
+			best = i
+			bestScore = s
+		}
+	}
+	if best >= 0 {
+		used[best] = true
+	}
+	return best, candidates
+}
+
+// Infer guesses the column positions based on the processed data.
+// The most distinctive columns (parent offsets, PoS tags) are resolved
+// first so they cannot be mistaken for less distinctive ones.
+func (pi *ProfileInferrer) Infer() InferredProfile {
+	if pi.sentOpen {
+		pi.closeSentence()
+	}
+	ans := InferredProfile{
+		NumSampledTokens:    pi.numTokens,
+		NumSampledSentences: pi.numSentences,
+		SentenceStruct:      pi.sentStruct,
+	}
+	used := make(map[int]bool)
+	ans.ParentIdx, ans.ParentCandidates = pi.bestColumn(
+		used,
+		func(idx int, col columnStats) float64 {
+			if pi.numSentences == 0 {
+				return 0
+			}
+			return float64(col.numValidOffset) / float64(col.numValues) *
+				float64(pi.numSingleRootSent[idx]) / float64(pi.numSentences)
+		},
+	)
+	ans.PosIdx, ans.PosCandidates = pi.bestColumn(
+		used,
+		func(idx int, col columnStats) float64 {
+			return float64(col.numUDPoS) / float64(col.numValues)
+		},
+	)
+	ans.DeprelIdx, ans.DeprelCandidates = pi.bestColumn(
+		used,
+		func(idx int, col columnStats) float64 {
+			return float64(col.numUDDeprel) / float64(col.numValues)
+		},
+	)
+	ans.LemmaIdx, ans.LemmaCandidates = pi.bestColumn(
+		used,
+		func(idx int, col columnStats) float64 {
+			if float64(col.numWordCopy)/float64(col.numValues) > maxLemmaWordIdentity {
+				return 0
+			}
+			return float64(col.numLemmaLike) / float64(col.numValues)
+		},
+	)
+	return ans
+}
+
+func NewProfileInferrer() *ProfileInferrer {
+	return &ProfileInferrer{
+		columns:           []columnStats{{}},
+		numSingleRootSent: []int{0},
+		sentStruct:        DefaultSentenceStruct,
+	}
+}
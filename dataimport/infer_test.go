@@ -0,0 +1,64 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+func TestProfileInferrer(t *testing.T) {
+	pi := NewProfileInferrer()
+	// columns: word, lc, id, parent, deprel, lemma, pos
+	sents := [][][]string{
+		{
+			{"Dogs", "dogs", "1", "+1", "nsubj", "dog", "NOUN"},
+			{"barked", "barked", "2", "0", "root", "bark", "VERB"},
+			{"loudly", "loudly", "3", "-1", "advmod", "loudly", "ADV"},
+		},
+		{
+			{"The", "the", "1", "+1", "det", "the", "DET"},
+			{"cats", "cats", "2", "+1", "nsubj", "cat", "NOUN"},
+			{"slept", "slept", "3", "0", "root", "sleep", "VERB"},
+			{".", ".", "4", "-1", "punct", ".", "PUNCT"},
+		},
+	}
+	for _, sent := range sents {
+		pi.ProcStruct(&vertigo.Structure{Name: "s"}, 0, nil)
+		for i, cols := range sent {
+			pi.ProcToken(&vertigo.Token{Idx: i, Word: cols[0], Attrs: cols[1:]}, 0, nil)
+		}
+		pi.ProcStructClose(&vertigo.StructureClose{Name: "s"}, 0, nil)
+	}
+	ans := pi.Infer()
+	assert.True(t, ans.Complete())
+	assert.Equal(t, 7, ans.NumSampledTokens)
+	assert.Equal(t, 2, ans.NumSampledSentences)
+	assert.Equal(t, 3, ans.ParentIdx)
+	assert.Equal(t, 4, ans.DeprelIdx)
+	assert.Equal(t, 5, ans.LemmaIdx)
+	assert.Equal(t, 6, ans.PosIdx)
+	assert.Equal(t, "-lemma-idx 5 -pos-idx 6 -parent-idx 3 -deprel-idx 4", ans.ImportArgs())
+}
+
+func TestProfileInferrerNoData(t *testing.T) {
+	ans := NewProfileInferrer().Infer()
+	assert.False(t, ans.Complete())
+	assert.Equal(t, -1, ans.ParentIdx)
+}
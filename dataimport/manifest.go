@@ -0,0 +1,175 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CompletedFile identifies a vertical file which has been fully
+// processed. The size and the modification time are used to detect
+// files changed since then.
+type CompletedFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// ImportManifest records progress of an import of multiple vertical
+// files so a failed import can be resumed without processing already
+// completed files again. As the frequencies are collected in memory
+// and written to the database once all the files are processed,
+// the manifest is accompanied by a state file containing frequencies
+// collected from the completed files (see FreqsCollector.SaveState).
+type ImportManifest struct {
+	ProfileName    string            `json:"profileName"`
+	CompletedFiles []CompletedFile   `json:"completedFiles"`
+	CorpusSize     int64             `json:"corpusSize"`
+	DeprelMap      map[string]uint16 `json:"deprelMap"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+
+	path string
+}
+
+// StatePath returns a path of the collected frequencies state file
+func (m *ImportManifest) StatePath() string {
+	return m.path + ".state"
+}
+
+// IsCompleted tests whether the file has been completed and whether
+// it has not changed since then.
+func (m *ImportManifest) IsCompleted(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	for _, cf := range m.CompletedFiles {
+		if cf.Path == path {
+			return cf.Size == info.Size() && cf.ModTime.Equal(info.ModTime())
+		}
+	}
+	return false
+}
+
+// MarkCompleted adds the file to the list of completed files.
+// The manifest is not saved automatically.
+func (m *ImportManifest) MarkCompleted(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to mark file as completed: %w", err)
+	}
+	m.CompletedFiles = append(
+		m.CompletedFiles,
+		CompletedFile{Path: path, Size: info.Size(), ModTime: info.ModTime()},
+	)
+	return nil
+}
+
+// Save writes both the collector state and the manifest.
+// Both files are replaced atomically (the manifest is written
+// last so it never refers to an older state).
+func (m *ImportManifest) Save(freqs FreqsCollector) error {
+	m.UpdatedAt = time.Now()
+	err := writeFileAtomic(m.StatePath(), func(w io.Writer) error {
+		return freqs.SaveState(w)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save import manifest: %w", err)
+	}
+	err = writeFileAtomic(m.path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save import manifest: %w", err)
+	}
+	return nil
+}
+
+// RestoreState loads frequencies collected from the completed
+// files into the collector.
+func (m *ImportManifest) RestoreState(freqs FreqsCollector) error {
+	f, err := os.Open(m.StatePath())
+	if err != nil {
+		return fmt.Errorf("failed to restore import state: %w", err)
+	}
+	defer f.Close()
+	if err := freqs.LoadState(f); err != nil {
+		return fmt.Errorf("failed to restore import state: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes both the manifest and the state file
+// (typically once the import is finished).
+func (m *ImportManifest) Remove() error {
+	for _, path := range []string{m.path, m.StatePath()} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove import manifest: %w", err)
+		}
+	}
+	return nil
+}
+
+// NewImportManifest creates an empty manifest stored at the path.
+func NewImportManifest(path, profileName string) *ImportManifest {
+	return &ImportManifest{path: path, ProfileName: profileName}
+}
+
+// LoadImportManifest loads a manifest stored at the path.
+// For a non-existing file, an empty manifest is returned.
+func LoadImportManifest(path, profileName string) (*ImportManifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewImportManifest(path, profileName), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load import manifest: %w", err)
+	}
+	ans := &ImportManifest{path: path}
+	if err := json.Unmarshal(data, ans); err != nil {
+		return nil, fmt.Errorf("failed to load import manifest: %w", err)
+	}
+	if ans.ProfileName != profileName {
+		return nil, fmt.Errorf(
+			"failed to load import manifest: created for profile %s, not %s", ans.ProfileName, profileName)
+	}
+	return ans, nil
+}
+
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // NOP after a successful rename
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportManifestResume(t *testing.T) {
+	dir := t.TempDir()
+	vertFile := filepath.Join(dir, "part1.vert")
+	assert.NoError(t, os.WriteFile(vertFile, []byte("data"), 0644))
+	manifestPath := filepath.Join(dir, "import-manifest.json")
+
+	freqs := NewFreqs(2, 5, 11, "", nil)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	freqs.Single["dog"] = record.TokenFreq{Lemma: "dog", Freq: 10, TextType: tt}
+	freqs.Double["dog-big"] = record.CollocFreq{Lemma1: "dog", Lemma2: "big", Freq: 3, AVGDist: 1, TextType: tt}
+
+	manifest := NewImportManifest(manifestPath, "test")
+	assert.False(t, manifest.IsCompleted(vertFile))
+	assert.NoError(t, manifest.MarkCompleted(vertFile))
+	manifest.CorpusSize = 1000
+	assert.NoError(t, manifest.Save(freqs))
+
+	loaded, err := LoadImportManifest(manifestPath, "test")
+	assert.NoError(t, err)
+	assert.True(t, loaded.IsCompleted(vertFile))
+	assert.Equal(t, int64(1000), loaded.CorpusSize)
+	restored := NewFreqs(2, 5, 11, "", nil)
+	assert.NoError(t, loaded.RestoreState(restored))
+	assert.Equal(t, freqs.Single, restored.Single)
+	assert.Equal(t, freqs.Double, restored.Double)
+
+	// a changed file is not considered completed anymore
+	later := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(vertFile, later, later))
+	assert.False(t, loaded.IsCompleted(vertFile))
+
+	_, err = LoadImportManifest(manifestPath, "other")
+	assert.Error(t, err)
+
+	assert.NoError(t, loaded.Remove())
+	_, err = os.Stat(manifestPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(loaded.StatePath())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	empty, err := LoadImportManifest(manifestPath, "test")
+	assert.NoError(t, err)
+	assert.Empty(t, empty.CompletedFiles)
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ImportStatusSuccess ImportStatus = "success"
+	ImportStatusFailure ImportStatus = "failure"
+
+	notifyTimeout = 30 * time.Second
+)
+
+type ImportStatus string
+
+// ImportReport is a payload sent to a configured URL once
+// an import finishes (either successfully or not).
+type ImportReport struct {
+	Status      ImportStatus     `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	VertPath    string           `json:"vertPath"`
+	DBPath      string           `json:"dbPath"`
+	ProfileName string           `json:"profileName"`
+	StartedAt   time.Time        `json:"startedAt"`
+	FinishedAt  time.Time        `json:"finishedAt"`
+	Metadata    storage.Metadata `json:"metadata"`
+}
+
+// ImportNotifier sends import completion reports to a URL.
+// It is possible to call its methods on a nil instance
+// in which case they are NOP.
+type ImportNotifier struct {
+	url    string
+	client *http.Client
+	report ImportReport
+}
+
+// Success sends a report about successfully finished import.
+func (n *ImportNotifier) Success(metadata storage.Metadata) {
+	if n == nil {
+		return
+	}
+	n.report.Status = ImportStatusSuccess
+	n.report.Metadata = metadata
+	n.send()
+}
+
+// Failure sends a report about failed import.
+func (n *ImportNotifier) Failure(err error) {
+	if n == nil {
+		return
+	}
+	n.report.Status = ImportStatusFailure
+	n.report.Error = err.Error()
+	n.send()
+}
+
+// send posts the report to the configured URL. Any problem is only
+// logged as the notification should never change the import result.
+func (n *ImportNotifier) send() {
+	n.report.FinishedAt = time.Now()
+	payload, err := json.Marshal(n.report)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode import report")
+		return
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Error().Err(err).Str("url", n.url).Msg("failed to send import report")
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		log.Error().
+			Err(fmt.Errorf("unexpected response status %d", resp.StatusCode)).
+			Str("url", n.url).
+			Msg("failed to send import report")
+		return
+	}
+	log.Info().
+		Str("url", n.url).
+		Str("status", string(n.report.Status)).
+		Msg("sent import report")
+}
+
+// NewImportNotifier creates a notifier for an import starting right now.
+// In case the url is empty, nil is returned (which is still safe to use).
+func NewImportNotifier(url, vertPath, dbPath, profileName string) *ImportNotifier {
+	if url == "" {
+		return nil
+	}
+	return &ImportNotifier{
+		url:    url,
+		client: &http.Client{Timeout: notifyTimeout},
+		report: ImportReport{
+			VertPath:    vertPath,
+			DBPath:      dbPath,
+			ProfileName: profileName,
+			StartedAt:   time.Now(),
+		},
+	}
+}
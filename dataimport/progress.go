@@ -0,0 +1,383 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+const (
+	PhaseParsing        ImportPhase = "parsing"
+	PhaseStoring        ImportPhase = "storing"
+	PhasePostprocessing ImportPhase = "postprocessing"
+	PhaseFinished       ImportPhase = "finished"
+
+	// DefaultProgressInterval is a default period of import
+	// progress reports
+	DefaultProgressInterval = 30 * time.Second
+
+	// collectedSizeEachNth specifies how often (in analyzed sentences)
+	// sizes of collected in-memory data are passed to the import progress
+	collectedSizeEachNth = 1000
+)
+
+// ImportPhase is a stage of an import
+type ImportPhase string
+
+// collectorSizer is a collector able to tell the number
+// of records it holds in memory (see freqs.NumRecords)
+type collectorSizer interface {
+	NumRecords() int
+}
+
+// ImportProgressState is a snapshot of an import progress.
+// Rates are calculated over the last reporting period.
+type ImportProgressState struct {
+	Phase        ImportPhase `json:"phase"`
+	CurrentFile  string      `json:"currentFile,omitempty"`
+	NumFiles     int         `json:"numFiles"`
+	NumDoneFiles int         `json:"numDoneFiles"`
+	NumTokens    int64       `json:"numTokens"`
+	NumSentences int64       `json:"numSentences"`
+	TokensPerSec float64     `json:"tokensPerSec"`
+
+	// NumCollectedRecords is a number of records held in memory
+	// by frequency collectors (aggregation maps)
+	NumCollectedRecords int64   `json:"numCollectedRecords"`
+	NumWrittenRecords   int64   `json:"numWrittenRecords"`
+	WrittenPerSec       float64 `json:"writtenPerSec"`
+
+	// ParsedPct is an estimated percentage of parsed input data
+	// (by size of files)
+	ParsedPct float64 `json:"parsedPct"`
+
+	// ETASecs is an estimated time in seconds remaining to finish
+	// parsing of the input files. It is available only during parsing.
+	ETASecs     *int64    `json:"etaSecs,omitempty"`
+	ElapsedSecs int64     `json:"elapsedSecs"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ImportProgress tracks progress of an import (processed tokens and
+// sentences, sizes of collected data, written records) and reports it
+// periodically via log and optionally to a JSON file (see Start).
+// The remaining time is estimated from the sizes of input files
+// (the processed part of the current file is estimated from the
+// sizes of its tokens).
+// It is possible to call its methods on a nil instance in which case
+// they are NOP.
+type ImportProgress struct {
+	mu            sync.Mutex
+	phase         ImportPhase
+	fileSizes     map[string]int64
+	totalBytes    int64
+	doneBytes     int64
+	numDoneFiles  int
+	currFile      string
+	currFileSize  int64
+	started       time.Time
+	parseStarted  time.Time
+	parseDuration time.Duration
+	collected     map[FreqsCollector]int64
+	numWritten    func() int64
+	jsonPath      string
+	prev          ImportProgressState
+
+	numTokens     atomic.Int64
+	numSentences  atomic.Int64
+	currFileBytes atomic.Int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// SetJSONPath makes the progress to be written also to a JSON
+// file (see ImportProgressState) on each report.
+func (p *ImportProgress) SetJSONPath(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jsonPath = path
+}
+
+// SetWriteCounter sets a function providing the number of records
+// written to a database so far (e.g. storage.DB.NumWrittenRecords).
+func (p *ImportProgress) SetWriteCounter(fn func() int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.numWritten = fn
+}
+
+// SetPhase sets the current phase of the import
+func (p *ImportProgress) SetPhase(phase ImportPhase) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setPhase(phase)
+}
+
+func (p *ImportProgress) setPhase(phase ImportPhase) {
+	if p.phase == PhaseParsing && phase != PhaseParsing {
+		p.parseDuration += time.Since(p.parseStarted)
+	}
+	if p.phase != PhaseParsing && phase == PhaseParsing {
+		p.parseStarted = time.Now()
+	}
+	p.phase = phase
+}
+
+// StartFile marks a start of parsing of the file
+func (p *ImportProgress) StartFile(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setPhase(PhaseParsing)
+	p.currFile = path
+	p.currFileSize = p.fileSizes[path]
+	p.currFileBytes.Store(0)
+}
+
+// FileDone marks the current file as fully processed
+func (p *ImportProgress) FileDone() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.doneBytes += p.currFileSize
+	p.numDoneFiles++
+	p.currFile = ""
+	p.currFileSize = 0
+	p.currFileBytes.Store(0)
+}
+
+// SkipFile marks the file as processed without parsing it (e.g.
+// when resuming an import). Skipped files do not affect the estimated
+// remaining time.
+func (p *ImportProgress) SkipFile(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.totalBytes -= p.fileSizes[path]
+	p.numDoneFiles++
+}
+
+// addToken counts a parsed token. The number of bytes the token
+// occupies in its vertical file is estimated from its attributes.
+func (p *ImportProgress) addToken(tk *vertigo.Token) {
+	if p == nil {
+		return
+	}
+	p.numTokens.Add(1)
+	size := len(tk.Word) + len(tk.Attrs) + 1 // tabs and newline
+	for _, attr := range tk.Attrs {
+		size += len(attr)
+	}
+	p.currFileBytes.Add(int64(size))
+}
+
+// addSentence counts an analyzed sentence
+func (p *ImportProgress) addSentence() {
+	if p == nil {
+		return
+	}
+	p.numSentences.Add(1)
+}
+
+// setCollected updates the number of records held in memory by
+// the collector (if it is able to tell the number). The method must
+// be called by the goroutine filling the collector.
+func (p *ImportProgress) setCollected(coll FreqsCollector) {
+	if p == nil {
+		return
+	}
+	sizer, ok := coll.(collectorSizer)
+	if !ok {
+		return
+	}
+	n := int64(sizer.NumRecords())
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.collected[coll] = n
+}
+
+// resetCollected forgets numbers of records of all the collectors
+// (e.g. once shards are merged)
+func (p *ImportProgress) resetCollected() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clear(p.collected)
+}
+
+// State returns the current state of the progress
+func (p *ImportProgress) State() ImportProgressState {
+	if p == nil {
+		return ImportProgressState{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state()
+}
+
+func (p *ImportProgress) state() ImportProgressState {
+	now := time.Now()
+	ans := ImportProgressState{
+		Phase:        p.phase,
+		CurrentFile:  p.currFile,
+		NumFiles:     len(p.fileSizes),
+		NumDoneFiles: p.numDoneFiles,
+		NumTokens:    p.numTokens.Load(),
+		NumSentences: p.numSentences.Load(),
+		ElapsedSecs:  int64(now.Sub(p.started).Seconds()),
+		UpdatedAt:    now,
+	}
+	for _, v := range p.collected {
+		ans.NumCollectedRecords += v
+	}
+	if p.numWritten != nil {
+		ans.NumWrittenRecords = p.numWritten()
+	}
+	if period := now.Sub(p.prev.UpdatedAt).Seconds(); !p.prev.UpdatedAt.IsZero() && period > 0 {
+		ans.TokensPerSec = math.Round(float64(ans.NumTokens-p.prev.NumTokens) / period)
+		ans.WrittenPerSec = math.Round(float64(ans.NumWrittenRecords-p.prev.NumWrittenRecords) / period)
+	}
+	processed := p.doneBytes + min(p.currFileBytes.Load(), p.currFileSize)
+	if p.totalBytes > 0 {
+		ans.ParsedPct = min(100, math.Round(float64(processed)/float64(p.totalBytes)*1000)/10)
+	}
+	if p.phase == PhaseParsing && processed > 0 {
+		parsing := p.parseDuration + now.Sub(p.parseStarted)
+		eta := int64(parsing.Seconds() * float64(max(0, p.totalBytes-processed)) / float64(processed))
+		ans.ETASecs = &eta
+	}
+	return ans
+}
+
+// report logs the current state and writes it to the JSON file
+// (if configured)
+func (p *ImportProgress) report() {
+	p.mu.Lock()
+	state := p.state()
+	p.prev = state
+	jsonPath := p.jsonPath
+	p.mu.Unlock()
+
+	event := log.Info().
+		Str("phase", string(state.Phase)).
+		Int("numDoneFiles", state.NumDoneFiles).
+		Int("numFiles", state.NumFiles).
+		Int64("numTokens", state.NumTokens).
+		Int64("numSentences", state.NumSentences).
+		Float64("tokensPerSec", state.TokensPerSec).
+		Int64("numCollectedRecords", state.NumCollectedRecords).
+		Int64("numWrittenRecords", state.NumWrittenRecords).
+		Float64("writtenPerSec", state.WrittenPerSec).
+		Float64("parsedPct", state.ParsedPct)
+	if state.ETASecs != nil {
+		event = event.Str("eta", (time.Duration(*state.ETASecs) * time.Second).String())
+	}
+	event.Msg("import progress")
+	if jsonPath != "" {
+		err := writeFileAtomic(jsonPath, func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(state)
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("path", jsonPath).Msg("failed to write import progress file")
+		}
+	}
+}
+
+// Start starts reporting the progress with the provided period.
+// With a non-positive period, the progress is reported only once
+// the reporting is stopped (see Stop).
+func (p *ImportProgress) Start(period time.Duration) {
+	if p == nil || period <= 0 {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.report()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops periodic reporting and reports the final state
+func (p *ImportProgress) Stop() {
+	if p == nil {
+		return
+	}
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+		p.stop = nil
+	}
+	p.report()
+}
+
+// NewImportProgress creates a progress of an import of the files.
+// Sizes of the files are used to estimate the remaining time
+// (files which cannot be accessed are just ignored).
+func NewImportProgress(files []string) *ImportProgress {
+	ans := &ImportProgress{
+		fileSizes: make(map[string]int64, len(files)),
+		started:   time.Now(),
+		collected: make(map[FreqsCollector]int64),
+	}
+	for _, path := range files {
+		if info, err := os.Stat(path); err == nil {
+			ans.fileSizes[path] = info.Size()
+			ans.totalBytes += info.Size()
+
+		} else {
+			ans.fileSizes[path] = 0
+		}
+	}
+	return ans
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+func TestImportProgress(t *testing.T) {
+	dir := t.TempDir()
+	file1 := filepath.Join(dir, "a.vert")
+	file2 := filepath.Join(dir, "b.vert")
+	assert.NoError(t, os.WriteFile(file1, []byte(strings.Repeat("x", 100)), 0644))
+	assert.NoError(t, os.WriteFile(file2, []byte(strings.Repeat("x", 100)), 0644))
+	progressPath := filepath.Join(dir, "progress.json")
+
+	p := NewImportProgress([]string{file1, file2})
+	p.SetJSONPath(progressPath)
+	p.SetWriteCounter(func() int64 { return 42 })
+	p.StartFile(file1)
+	for range 5 {
+		// 10 bytes each (incl. tabs and newline)
+		p.addToken(&vertigo.Token{Word: "abc", Attrs: []string{"abc", "x"}})
+	}
+	p.addSentence()
+	state := p.State()
+	assert.Equal(t, PhaseParsing, state.Phase)
+	assert.Equal(t, int64(5), state.NumTokens)
+	assert.Equal(t, int64(1), state.NumSentences)
+	assert.Equal(t, 25.0, state.ParsedPct)
+	assert.NotNil(t, state.ETASecs)
+
+	p.FileDone()
+	p.SkipFile(file2)
+	p.SetPhase(PhaseStoring)
+	state = p.State()
+	assert.Equal(t, 2, state.NumDoneFiles)
+	assert.Equal(t, 100.0, state.ParsedPct)
+	assert.Nil(t, state.ETASecs)
+
+	p.Stop()
+	data, err := os.ReadFile(progressPath)
+	assert.NoError(t, err)
+	var stored ImportProgressState
+	assert.NoError(t, json.Unmarshal(data, &stored))
+	assert.Equal(t, PhaseStoring, stored.Phase)
+	assert.Equal(t, int64(42), stored.NumWrittenRecords)
+}
+
+func TestImportProgressNil(t *testing.T) {
+	var p *ImportProgress
+	p.StartFile("a.vert")
+	p.addToken(&vertigo.Token{Word: "abc"})
+	p.FileDone()
+	p.Stop()
+	assert.Equal(t, ImportProgressState{}, p.State())
+}
@@ -17,11 +17,84 @@
 package dataimport
 
 import (
+	"fmt"
+	"io"
+	"sync"
+
 	"github.com/czcorpus/cnc-gokit/collections"
+	"github.com/czcorpus/depreldb/record"
 	"github.com/czcorpus/depreldb/storage"
+	"github.com/rs/zerolog/log"
 	"github.com/tomachalek/vertigo/v6"
 )
 
+const (
+	// sentQueueSizePerWorker specifies how many sentences per worker
+	// can wait for analysis before the parser is blocked
+	sentQueueSizePerWorker = 64
+
+	// DefaultSentenceStruct is a default name of the structure
+	// delimiting sentences in vertical files
+	DefaultSentenceStruct = "s"
+)
+
+// FreqsStorage is a database collected frequencies are written to.
+// It is implemented by storage.DB.
+type FreqsStorage interface {
+	StoreFreqs(
+		singleFreqs map[record.GroupingKey]record.TokenFreq,
+		pairFreqs map[record.GroupingKey]record.CollocFreq,
+		minPairFreq int,
+	) (storage.ImportStats, error)
+
+	// StoreWordFormFreqs stores frequencies collected
+	// for word forms (see storage.DB.StoreWordFormFreqs)
+	StoreWordFormFreqs(
+		singleFreqs map[record.GroupingKey]record.TokenFreq,
+		pairFreqs map[record.GroupingKey]record.CollocFreq,
+		minPairFreq int,
+	) (storage.ImportStats, error)
+
+	// StorePathFreqs stores frequencies of pairs connected
+	// via other nodes (see storage.DB.StorePathFreqs)
+	StorePathFreqs(
+		pairFreqs map[record.GroupingKey]record.CollocFreq,
+		minPairFreq int,
+	) (storage.ImportStats, error)
+}
+
+var _ FreqsStorage = (*storage.DB)(nil)
+
+// AppendingStorage is a FreqsStorage merging collected frequencies
+// into data already stored in the database (see storage.DB.AppendFreqs)
+// instead of storing them from scratch.
+type AppendingStorage struct {
+	DB *storage.DB
+}
+
+func (as AppendingStorage) StoreFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (storage.ImportStats, error) {
+	return as.DB.AppendFreqs(singleFreqs, pairFreqs, minPairFreq)
+}
+
+func (as AppendingStorage) StoreWordFormFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (storage.ImportStats, error) {
+	return as.DB.AppendWordFormFreqs(singleFreqs, pairFreqs, minPairFreq)
+}
+
+func (as AppendingStorage) StorePathFreqs(
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (storage.ImportStats, error) {
+	return as.DB.AppendPathFreqs(pairFreqs, minPairFreq)
+}
+
 type FreqsCollector interface {
 	AddLemma(lemma *vertigo.Token, freq int)
 	AddCooc(lemma1, lemma2 *vertigo.Token, freq int, distance int)
@@ -30,10 +103,37 @@ type FreqsCollector interface {
 	// (oriented from a leaf to the root - this preserves consistent
 	// node distance signs).
 	ImportTreePath(sent []*vertigo.Token)
+
+	// ImportSiblings imports all the pairs of tokens sharing
+	// the same syntactic head (which can be nil if unknown).
+	ImportSiblings(head *vertigo.Token, siblings []*vertigo.Token)
+
+	// SetPathPolicy specifies which token pairs on a tree path
+	// are imported as co-occurrences
+	SetPathPolicy(p storage.PathPolicy)
 	PrintPreview()
-	StoreToDb(db *storage.DB, minFreq int) (storage.ImportStats, error)
+	StoreToDb(db FreqsStorage, minFreq int) (storage.ImportStats, error)
+
+	// SaveState writes all the collected frequencies so
+	// they can be later restored via LoadState (see ImportManifest)
+	SaveState(w io.Writer) error
+
+	// LoadState replaces collected frequencies with the ones
+	// written by SaveState
+	LoadState(r io.Reader) error
+}
+
+// ShardedCollector is a FreqsCollector which can create empty
+// collectors with the same configuration (shards). Shards can be
+// filled concurrently and then merged back (see Searcher.SetWorkers).
+type ShardedCollector interface {
+	FreqsCollector
+	NewShard() FreqsCollector
+	MergeShard(shard FreqsCollector) error
 }
 
+var _ ShardedCollector = (*freqs)(nil)
+
 // ----------------------------
 
 type Searcher struct {
@@ -42,6 +142,8 @@ type Searcher struct {
 	lastSentStartIdx int
 	lastSentEndIdx   int
 	foundNewSent     bool
+	sentPending      bool
+	sentStruct       string
 	lemmaIdx         int
 	posIdx           int
 	parentIdx        int
@@ -49,10 +151,171 @@ type Searcher struct {
 	freqs            FreqsCollector
 	corpusSize       int64
 	extendedDeprels  *collections.Set[string]
+	extractSiblings  bool
+	deprelBlocklist  DeprelBlocklist
+
+	// numWorkers, sents, shards, shardDeprels and shardErrs are used
+	// for parallel analysis of sentences (see SetWorkers)
+	numWorkers   int
+	sents        chan []*vertigo.Token
+	workersWG    sync.WaitGroup
+	shards       []FreqsCollector
+	shardDeprels []*collections.Set[string]
+	shardErrs    []error
+
+	// progress and numAnalyzed are used for import
+	// progress reporting (see SetProgress)
+	progress    *ImportProgress
+	numAnalyzed int
+}
+
+// SetExtractSiblings enables or disables import of sibling
+// co-occurrences (i.e. tokens sharing the same head).
+func (vf *Searcher) SetExtractSiblings(v bool) {
+	vf.extractSiblings = v
 }
 
-func (vf *Searcher) analyzeLastSent() {
+// SetSentenceStruct sets a name of the structure delimiting
+// sentences (e.g. "sentence" or "seg"). An empty name sets
+// the default one (see DefaultSentenceStruct).
+func (vf *Searcher) SetSentenceStruct(name string) {
+	if name == "" {
+		name = DefaultSentenceStruct
+	}
+	vf.sentStruct = name
+}
+
+// SetDeprelBlocklist replaces the default blocklist
+// of relations ignored in tree paths (see DefaultDeprelBlocklist).
+func (vf *Searcher) SetDeprelBlocklist(bl DeprelBlocklist) {
+	vf.deprelBlocklist = bl
+}
+
+// SetProgress makes the searcher to report processed tokens
+// and sentences (along with sizes of collected data) to the progress
+func (vf *Searcher) SetProgress(p *ImportProgress) {
+	vf.progress = p
+}
+
+// SetWorkers sets a number of goroutines analyzing parsed sentences.
+// Each worker collects frequencies into its own shard of the collector
+// so the memory usage grows with the number of workers. The shards are
+// merged by Wait which must be called before the collected data are
+// used. Parallel analysis requires a ShardedCollector, for other
+// collectors the setting is ignored.
+func (vf *Searcher) SetWorkers(n int) {
+	if _, ok := vf.freqs.(ShardedCollector); !ok && n > 1 {
+		log.Warn().
+			Int("numWorkers", n).
+			Msg("frequencies collector does not support parallel analysis, using a single worker")
+		return
+	}
+	vf.numWorkers = n
+}
+
+// startWorkers starts analyzing goroutines, each of them
+// with its own shard of the collector
+func (vf *Searcher) startWorkers() {
+	coll := vf.freqs.(ShardedCollector)
+	sents := make(chan []*vertigo.Token, vf.numWorkers*sentQueueSizePerWorker)
+	vf.sents = sents
+	vf.shards = make([]FreqsCollector, vf.numWorkers)
+	vf.shardDeprels = make([]*collections.Set[string], vf.numWorkers)
+	vf.shardErrs = make([]error, vf.numWorkers)
+	for i := range vf.numWorkers {
+		shard := coll.NewShard()
+		deprels := collections.NewSet[string]()
+		vf.shards[i] = shard
+		vf.shardDeprels[i] = deprels
+		vf.workersWG.Add(1)
+		go func() {
+			defer vf.workersWG.Done()
+			var numAnalyzed int
+			for sent := range sents {
+				if vf.shardErrs[i] != nil {
+					continue // just drain the queue, the error is reported by Wait
+				}
+				vf.shardErrs[i] = vf.analyzeSent(sent, shard, deprels)
+				numAnalyzed++
+				if numAnalyzed%collectedSizeEachNth == 0 {
+					vf.progress.setCollected(shard)
+				}
+			}
+		}()
+	}
+}
+
+// Wait waits for all the sentences passed to workers to be analyzed
+// and merges the frequencies collected by the workers into the main
+// collector. The searcher can be used again afterwards (e.g. for
+// another file). With a single worker, the method just updates
+// the import progress (if any).
+func (vf *Searcher) Wait() error {
+	if vf.sents == nil {
+		vf.progress.setCollected(vf.freqs)
+		return nil
+	}
+	close(vf.sents)
+	vf.workersWG.Wait()
+	vf.sents = nil
+	for _, err := range vf.shardErrs {
+		if err != nil {
+			vf.shards = nil
+			vf.shardDeprels = nil
+			vf.shardErrs = nil
+			return fmt.Errorf("failed to analyze sentences: %w", err)
+		}
+	}
+	vf.shardErrs = nil
+	coll := vf.freqs.(ShardedCollector)
+	for i, shard := range vf.shards {
+		if err := coll.MergeShard(shard); err != nil {
+			return err
+		}
+		vf.shardDeprels[i].ForEach(func(item string) {
+			vf.extendedDeprels.Add(item)
+		})
+	}
+	vf.shards = nil
+	vf.shardDeprels = nil
+	vf.progress.resetCollected()
+	vf.progress.setCollected(vf.freqs)
+	return nil
+}
+
+// analyzeSent finds all the tree paths (and possibly sibling groups)
+// of the sentence and imports them to the collector
+func (vf *Searcher) analyzeSent(
+	sent []*vertigo.Token,
+	freqs FreqsCollector,
+	extendedDeprels *collections.Set[string],
+) error {
+	branches, err := findPathsToRoot(
+		sent,
+		vf.lemmaIdx,
+		vf.posIdx,
+		vf.parentIdx,
+		vf.deprelIdx,
+		vf.deprelBlocklist,
+		extendedDeprels,
+	)
+	if err != nil {
+		return err
+	}
+	for _, b := range branches {
+		freqs.ImportTreePath(b)
+	}
+	if vf.extractSiblings {
+		for _, g := range findSiblingGroups(sent, vf.parentIdx, vf.deprelIdx, vf.deprelBlocklist) {
+			freqs.ImportSiblings(g.head, g.members)
+		}
+	}
+	return nil
+}
+
+func (vf *Searcher) analyzeLastSent() error {
 	var sentOpen bool
+	var err error
 	sent := make([]*vertigo.Token, 0, vf.lastSentEndIdx-vf.lastSentStartIdx+1)
 	vf.prevTokens.ForEach(func(i int, item *vertigo.Token) bool {
 		if item.Idx == vf.lastSentStartIdx {
@@ -65,26 +328,39 @@ func (vf *Searcher) analyzeLastSent() {
 			sentOpen = false
 			if len(sent) > 0 {
 				vf.corpusSize += int64(len(sent))
-				branches := findPathsToRoot(
-					sent,
-					vf.lemmaIdx,
-					vf.posIdx,
-					vf.parentIdx,
-					vf.deprelIdx,
-					vf.extendedDeprels,
-				)
-				for _, b := range branches {
-					vf.freqs.ImportTreePath(b)
+				vf.progress.addSentence()
+				if vf.numWorkers > 1 {
+					if vf.sents == nil {
+						vf.startWorkers()
+					}
+					vf.sents <- sent
+
+				} else {
+					if err = vf.analyzeSent(sent, vf.freqs, vf.extendedDeprels); err != nil {
+						return false
+					}
+					vf.numAnalyzed++
+					if vf.numAnalyzed%collectedSizeEachNth == 0 {
+						vf.progress.setCollected(vf.freqs)
+					}
 				}
 			}
 		}
 		return true
 	})
+	return err
 }
 
 func (vf *Searcher) ProcToken(tk *vertigo.Token, line int, err error) error {
+	// token indices start from zero in each file so the buffered tokens
+	// of a previous file would be mixed with the ones of the current file
+	if tk.Idx <= vf.lastTokenIdx {
+		vf.prevTokens.ShiftUntil(func(item *vertigo.Token) bool { return true })
+	}
 	vf.prevTokens.Append(tk)
+	vf.progress.addToken(tk)
 	vf.lastTokenIdx = tk.Idx
+	vf.sentPending = true
 	if vf.foundNewSent {
 		vf.lastSentStartIdx = tk.Idx
 		vf.foundNewSent = false
@@ -92,16 +368,35 @@ func (vf *Searcher) ProcToken(tk *vertigo.Token, line int, err error) error {
 	return nil
 }
 
+// finishSent analyzes the last sentence unless it
+// has been already analyzed
+func (vf *Searcher) finishSent() error {
+	if !vf.sentPending {
+		return nil
+	}
+	vf.lastSentEndIdx = vf.lastTokenIdx
+	vf.sentPending = false
+	if err := vf.analyzeLastSent(); err != nil {
+		return fmt.Errorf("failed to analyze sentence: %w", err)
+	}
+	return nil
+}
+
 func (vf *Searcher) ProcStruct(st *vertigo.Structure, line int, err error) error {
-	if st.Name == "s" {
-		vf.lastSentEndIdx = vf.lastTokenIdx
-		vf.analyzeLastSent()
+	if st.Name == vf.sentStruct {
 		vf.foundNewSent = true
+		return vf.finishSent()
 	}
 	return nil
 }
 
 func (vf *Searcher) ProcStructClose(st *vertigo.StructureClose, line int, err error) error {
+	// note: analyzing the sentence once it is closed makes sure
+	// also the last sentence of a file is processed
+	if st.Name == vf.sentStruct {
+		vf.foundNewSent = true
+		return vf.finishSent()
+	}
 	return nil
 }
 
@@ -109,6 +404,14 @@ func (vf *Searcher) ImportedCorpusSize() int64 {
 	return vf.corpusSize
 }
 
+// RestoreCorpusSize sets the corpus size counted so far
+// (e.g. when resuming an import from a manifest)
+func (vf *Searcher) RestoreCorpusSize(size int64) {
+	vf.corpusSize = size
+}
+
+// CollectedDeprels returns extended deprels found so far. With
+// multiple workers, Wait must be called first.
 func (vf *Searcher) CollectedDeprels() []string {
 	return vf.extendedDeprels.ToSlice()
 }
@@ -124,7 +427,9 @@ func NewSearcher(
 		posIdx:          posIdx,
 		parentIdx:       parentAttrIdx,
 		deprelIdx:       deprelAttrIdx,
+		sentStruct:      DefaultSentenceStruct,
 		freqs:           freqs,
 		extendedDeprels: collections.NewSet[string](),
+		deprelBlocklist: mustNewDeprelBlocklist(DefaultDeprelBlocklist),
 	}
 }
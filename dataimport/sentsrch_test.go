@@ -0,0 +1,157 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const testParallelSent = `# sent_id = %d
+1	He	he	PRON	PRP	_	2	nsubj	_	_
+2	sat	sit	VERB	VBD	_	0	root	_	_
+3	on	on	ADP	IN	_	5	case	_	_
+4	the	the	DET	DT	_	5	det	_	_
+5	chair	chair	NOUN	NN	_	2	obl	_	_
+6	quietly	quietly	ADV	RB	_	2	advmod	_	_
+
+`
+
+func collectWithWorkers(t *testing.T, data string, numWorkers int) (*freqs, *Searcher) {
+	f := NewFreqs(ConllULemmaIdx, ConllUPosIdx, ConllUDeprelIdx, "doc.genre", map[string]byte{"fiction": 0x01})
+	proc := NewSearcher(50, ConllULemmaIdx, ConllUPosIdx, ConllUParentIdx, ConllUDeprelIdx, f)
+	proc.SetExtractSiblings(true)
+	proc.SetWorkers(numWorkers)
+	assert.NoError(t, ParseConllU(context.Background(), strings.NewReader(data), proc))
+	assert.NoError(t, proc.Wait())
+	return f, proc
+}
+
+func TestSearcherWorkers(t *testing.T) {
+	var data strings.Builder
+	data.WriteString(testConllU + "\n")
+	for i := range 500 {
+		fmt.Fprintf(&data, testParallelSent, i+3)
+	}
+	single, singleProc := collectWithWorkers(t, data.String(), 1)
+	parallel, parallelProc := collectWithWorkers(t, data.String(), 4)
+
+	assert.Equal(t, singleProc.ImportedCorpusSize(), parallelProc.ImportedCorpusSize())
+	assert.ElementsMatch(t, singleProc.CollectedDeprels(), parallelProc.CollectedDeprels())
+	assert.Contains(t, parallelProc.CollectedDeprels(), "obl:on")
+	assert.Equal(t, single.Single, parallel.Single)
+	assert.Equal(t, len(single.Double), len(parallel.Double))
+	for k, v := range single.Double {
+		pv, ok := parallel.Double[k]
+		if assert.True(t, ok) {
+			assert.Equal(t, v.Freq, pv.Freq)
+			assert.InDelta(t, v.WeightedFreq, pv.WeightedFreq, 0.0001)
+			assert.InDelta(t, v.AVGDist, pv.AVGDist, 0.0001)
+			assert.InDelta(t, v.AVGSurfaceDist, pv.AVGSurfaceDist, 0.0001)
+		}
+	}
+	// the searcher can be reused once the workers finished
+	assert.NoError(t, ParseConllU(context.Background(), strings.NewReader(data.String()), parallelProc))
+	assert.NoError(t, parallelProc.Wait())
+	assert.Equal(t, 2*singleProc.ImportedCorpusSize(), parallelProc.ImportedCorpusSize())
+}
+
+func TestImportedDeprelStats(t *testing.T) {
+	var data strings.Builder
+	for i := range 3 {
+		fmt.Fprintf(&data, testParallelSent, i+1)
+	}
+	f, _ := collectWithWorkers(t, data.String(), 1)
+	db, err := storage.OpenDBIgnoreMetadata(
+		t.TempDir(), storage.NewPreconfTextTypeMapping(map[string]byte{"fiction": 0x01}))
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.DeprelMapping = &record.UDDeprelMapping
+	_, err = f.StoreToDb(db, 1)
+	assert.NoError(t, err)
+
+	stats, err := db.GetDeprelStats(context.Background(), 1, nil)
+	assert.NoError(t, err)
+	byDeprel := make(map[string]storage.DeprelStats)
+	for _, item := range stats {
+		byDeprel[item.Deprel] = item
+	}
+	// each head-dependent pair is counted once even though
+	// it is stored from the perspective of both tokens
+	nsubj := byDeprel["nsubj"]
+	assert.Equal(t, 1, nsubj.NumPairs)
+	assert.Equal(t, 3, nsubj.Freq)
+	assert.Equal(t, []storage.DeprelExamplePair{{Head: "sit", Dependent: "he", Freq: 3}}, nsubj.Examples)
+	advmod := byDeprel["advmod"]
+	assert.Equal(t, 1, advmod.NumPairs)
+	assert.Equal(t, 3, advmod.Freq)
+	assert.Equal(t, []storage.DeprelExamplePair{{Head: "sit", Dependent: "quietly", Freq: 3}}, advmod.Examples)
+}
+
+func TestImportedSiblings(t *testing.T) {
+	var data strings.Builder
+	for i := range 3 {
+		fmt.Fprintf(&data, testParallelSent, i+1)
+	}
+	f, _ := collectWithWorkers(t, data.String(), 1)
+	var numSiblings int
+	for _, v := range f.Double {
+		if v.Deprel.Raw != record.DeprelSibling {
+			continue
+		}
+		numSiblings++
+		assert.Equal(t, record.DirectionSibling, v.Direction)
+		assert.False(t, v.IsHead())
+	}
+	// he, chair and quietly share the head, each in both orders
+	assert.Equal(t, 6, numSiblings)
+
+	db, err := storage.OpenDBIgnoreMetadata(
+		t.TempDir(), storage.NewPreconfTextTypeMapping(map[string]byte{"fiction": 0x01}))
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.DeprelMapping = &record.UDDeprelMapping
+	db.Metadata.Features = []storage.DatasetFeature{storage.FeatureSiblings}
+	_, err = f.StoreToDb(db, 1)
+	assert.NoError(t, err)
+
+	stats, err := db.GetDeprelStats(context.Background(), 10, nil)
+	assert.NoError(t, err)
+	var sibling storage.DeprelStats
+	for _, item := range stats {
+		if item.Deprel == "sibling" {
+			sibling = item
+		}
+	}
+	// each pair of siblings is counted once
+	assert.Equal(t, 3, sibling.NumPairs)
+	assert.Equal(t, 9, sibling.Freq)
+	assert.Len(t, sibling.Examples, 3)
+	pairs := make(map[[2]string]bool)
+	for _, ex := range sibling.Examples {
+		assert.Equal(t, 3, ex.Freq)
+		pairs[[2]string{ex.Head, ex.Dependent}] = true
+		assert.False(t, pairs[[2]string{ex.Dependent, ex.Head}])
+	}
+	assert.Len(t, pairs, 3)
+}
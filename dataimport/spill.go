@@ -0,0 +1,210 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/czcorpus/depreldb/record"
+)
+
+// spillEntry is a single record of a spilled run
+type spillEntry[T any] struct {
+	Key   record.GroupingKey
+	Value T
+}
+
+// spilledRuns contains paths of temporary files with collected
+// frequencies flushed from memory. Each file contains records
+// sorted by their keys.
+type spilledRuns struct {
+	single     []string
+	double     []string
+	formSingle []string
+	formDouble []string
+	pathDouble []string
+}
+
+func (sr spilledRuns) empty() bool {
+	return len(sr.single) == 0 && len(sr.double) == 0 &&
+		len(sr.formSingle) == 0 && len(sr.formDouble) == 0 && len(sr.pathDouble) == 0
+}
+
+func (sr *spilledRuns) add(other spilledRuns) {
+	sr.single = append(sr.single, other.single...)
+	sr.double = append(sr.double, other.double...)
+	sr.formSingle = append(sr.formSingle, other.formSingle...)
+	sr.formDouble = append(sr.formDouble, other.formDouble...)
+	sr.pathDouble = append(sr.pathDouble, other.pathDouble...)
+}
+
+// writeRun writes data sorted by their keys to a new temporary
+// file within dir and returns the file path
+func writeRun[T any](dir string, data map[record.GroupingKey]T) (string, error) {
+	file, err := os.CreateTemp(dir, "run-*.gob")
+	if err != nil {
+		return "", fmt.Errorf("failed to create spill file: %w", err)
+	}
+	defer file.Close()
+	keys := make([]record.GroupingKey, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	bw := bufio.NewWriter(file)
+	enc := gob.NewEncoder(bw)
+	for _, k := range keys {
+		if err := enc.Encode(spillEntry[T]{Key: k, Value: data[k]}); err != nil {
+			return "", fmt.Errorf("failed to write spill file: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return "", fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// runReader reads records of a single spilled run
+type runReader[T any] struct {
+	file *os.File
+	dec  *gob.Decoder
+	curr spillEntry[T]
+}
+
+// next reads the next record. For exhausted runs, io.EOF is returned.
+func (rr *runReader[T]) next() error {
+	// note: gob does not overwrite fields with zero values
+	// so a fresh entry must be used each time
+	var entry spillEntry[T]
+	if err := rr.dec.Decode(&entry); err != nil {
+		return err
+	}
+	rr.curr = entry
+	return nil
+}
+
+// runHeap orders run readers by their current keys
+type runHeap[T any] []*runReader[T]
+
+func (h runHeap[T]) Len() int           { return len(h) }
+func (h runHeap[T]) Less(i, j int) bool { return h[i].curr.Key < h[j].curr.Key }
+func (h runHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *runHeap[T]) Push(x any) {
+	*h = append(*h, x.(*runReader[T]))
+}
+
+func (h *runHeap[T]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// mergeRuns reads all the runs in the order of their keys and calls
+// emit for each key with values of the key combined from all the runs.
+func mergeRuns[T any](
+	paths []string,
+	combine func(curr *T, v T),
+	emit func(key record.GroupingKey, v T) error,
+) error {
+	h := make(runHeap[T], 0, len(paths))
+	defer func() {
+		for _, rr := range h {
+			rr.file.Close()
+		}
+	}()
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open spill file: %w", err)
+		}
+		rr := &runReader[T]{file: file, dec: gob.NewDecoder(bufio.NewReader(file))}
+		if err := rr.next(); errors.Is(err, io.EOF) {
+			file.Close()
+			continue
+
+		} else if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to read spill file: %w", err)
+		}
+		h = append(h, rr)
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		key := h[0].curr.Key
+		value := h[0].curr.Value
+		first := true
+		for h.Len() > 0 && h[0].curr.Key == key {
+			rr := h[0]
+			if !first {
+				combine(&value, rr.curr.Value)
+			}
+			first = false
+			if err := rr.next(); errors.Is(err, io.EOF) {
+				rr.file.Close()
+				heap.Pop(&h)
+
+			} else if err != nil {
+				return fmt.Errorf("failed to read spill file: %w", err)
+
+			} else {
+				heap.Fix(&h, 0)
+			}
+		}
+		if err := emit(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeRunsInChunks merges the runs (see mergeRuns) and passes the
+// merged records to store in chunks of at most chunkSize records.
+// As the runs are sorted, each key is complete within its chunk.
+func mergeRunsInChunks[T any](
+	paths []string,
+	chunkSize int,
+	combine func(curr *T, v T),
+	store func(chunk map[record.GroupingKey]T) error,
+) error {
+	chunk := make(map[record.GroupingKey]T)
+	err := mergeRuns(paths, combine, func(key record.GroupingKey, v T) error {
+		chunk[key] = v
+		if len(chunk) >= chunkSize {
+			if err := store(chunk); err != nil {
+				return err
+			}
+			chunk = make(map[record.GroupingKey]T)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(chunk) > 0 {
+		return store(chunk)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// chunkRecordingStorage collects all the stored chunks and
+// counts keys stored more than once
+type chunkRecordingStorage struct {
+	single       map[record.GroupingKey]record.TokenFreq
+	double       map[record.GroupingKey]record.CollocFreq
+	numChunks    int
+	numRepeated  int
+	numWordForms int
+}
+
+func (crs *chunkRecordingStorage) StoreFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (storage.ImportStats, error) {
+	crs.numChunks++
+	for k, v := range singleFreqs {
+		if _, ok := crs.single[k]; ok {
+			crs.numRepeated++
+		}
+		crs.single[k] = v
+	}
+	for k, v := range pairFreqs {
+		if _, ok := crs.double[k]; ok {
+			crs.numRepeated++
+		}
+		crs.double[k] = v
+	}
+	return storage.ImportStats{NumLemmas: len(singleFreqs), NumCollFreqs: len(pairFreqs)}, nil
+}
+
+func (crs *chunkRecordingStorage) StoreWordFormFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (storage.ImportStats, error) {
+	crs.numWordForms += len(singleFreqs)
+	return storage.ImportStats{NumWordForms: len(singleFreqs)}, nil
+}
+
+func (crs *chunkRecordingStorage) StorePathFreqs(
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (storage.ImportStats, error) {
+	return storage.ImportStats{NumPathCollFreqs: len(pairFreqs)}, nil
+}
+
+func TestFreqsSpilling(t *testing.T) {
+	var data strings.Builder
+	for i := range 30 {
+		fmt.Fprintf(&data, testParallelSent, i)
+		// vary lemmas to get more distinct records
+		data.WriteString(strings.ReplaceAll(
+			fmt.Sprintf(testParallelSent, i), "\tchair\t", fmt.Sprintf("\tchair%d\t", i%7)))
+	}
+	collect := func(spillThreshold, numWorkers int) *freqs {
+		f := NewFreqs(ConllULemmaIdx, ConllUPosIdx, ConllUDeprelIdx, "doc.genre", map[string]byte{"": 0x01})
+		f.SetIndexWordForms(true)
+		assert.NoError(t, f.SetSpilling(spillThreshold, t.TempDir()))
+		proc := NewSearcher(50, ConllULemmaIdx, ConllUPosIdx, ConllUParentIdx, ConllUDeprelIdx, f)
+		proc.SetWorkers(numWorkers)
+		assert.NoError(t, ParseConllU(context.Background(), strings.NewReader(data.String()), proc))
+		assert.NoError(t, proc.Wait())
+		return f
+	}
+	expected := collect(0, 1)
+	for _, numWorkers := range []int{1, 3} {
+		spilling := collect(5, numWorkers)
+		assert.NotEmpty(t, spilling.runs.double)
+		spillDir := spilling.spillDir
+		st := &chunkRecordingStorage{
+			single: make(map[record.GroupingKey]record.TokenFreq),
+			double: make(map[record.GroupingKey]record.CollocFreq),
+		}
+		stats, err := spilling.StoreToDb(st, 1)
+		assert.NoError(t, err)
+		assert.Greater(t, st.numChunks, 2)
+		assert.Equal(t, 0, st.numRepeated)
+		assert.Equal(t, expected.Single, st.single)
+		assert.Equal(t, len(expected.Double), len(st.double))
+		for k, v := range expected.Double {
+			assert.Equal(t, v.Freq, st.double[k].Freq)
+			assert.InDelta(t, v.AVGDist, st.double[k].AVGDist, 0.0001)
+			assert.InDelta(t, v.AVGSurfaceDist, st.double[k].AVGSurfaceDist, 0.0001)
+		}
+		assert.Equal(t, len(expected.Single), stats.NumLemmas)
+		assert.Equal(t, len(expected.FormSingle), stats.NumWordForms)
+		_, err = os.Stat(spillDir)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
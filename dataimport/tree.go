@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/czcorpus/cnc-gokit/collections"
+	"github.com/czcorpus/depreldb/record"
 	"github.com/rs/zerolog/log"
 	"github.com/tomachalek/vertigo/v6"
 )
@@ -67,11 +68,61 @@ func asExpandedSent(sent []*vertigo.Token, parentAttrIdx int) expandedSent {
 	return ans
 }
 
-func isBlocklistedRel(rel string) bool {
-	return rel == "punct" || rel == "cc" || strings.HasPrefix(rel, "det") || strings.HasPrefix(rel, "aux") ||
-		rel == "cop" || rel == "mark" || strings.HasPrefix(rel, "expl") || rel == "discourse" ||
-		rel == "goeswith" || rel == "reparandum" || rel == "orphan" || rel == "list" || rel == "vocative" ||
-		rel == "dep"
+// DefaultDeprelBlocklist lists relations ignored by default when
+// searching tree paths and sibling groups (see DeprelBlocklist)
+var DefaultDeprelBlocklist = []string{
+	"punct", "cc", "det*", "aux*", "cop", "mark", "expl*", "discourse",
+	"goeswith", "reparandum", "orphan", "list", "vocative", "dep",
+}
+
+// DeprelBlocklist contains syntactic relations whose dependents are
+// removed from tree paths (i.e. they are never imported as collocates).
+// An item ending with "*" matches all the relations starting with
+// the item (e.g. "aux*" matches both "aux" and "aux:pass"), other
+// items must match exactly.
+type DeprelBlocklist struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+// Contains tests whether the relation is blocklisted
+func (bl DeprelBlocklist) Contains(rel string) bool {
+	if bl.exact[rel] {
+		return true
+	}
+	for _, p := range bl.prefixes {
+		if strings.HasPrefix(rel, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDeprelBlocklist creates a blocklist from the provided items
+// (see DeprelBlocklist). Empty items are not allowed.
+func NewDeprelBlocklist(items []string) (DeprelBlocklist, error) {
+	ans := DeprelBlocklist{exact: make(map[string]bool)}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if prefix, ok := strings.CutSuffix(item, "*"); ok && prefix != "" {
+			ans.prefixes = append(ans.prefixes, prefix)
+
+		} else if item != "" && !ok {
+			ans.exact[item] = true
+
+		} else {
+			return DeprelBlocklist{}, fmt.Errorf("invalid deprel blocklist item '%s'", item)
+		}
+	}
+	return ans, nil
+}
+
+func mustNewDeprelBlocklist(items []string) DeprelBlocklist {
+	ans, err := NewDeprelBlocklist(items)
+	if err != nil {
+		panic(err)
+	}
+	return ans
 }
 
 func logCyclePath(path expandedSent, cycleToken *vertigo.Token, parentIdx int) {
@@ -105,8 +156,9 @@ func (vn visitedNode) valid() bool {
 func findPathsToRoot(
 	sent []*vertigo.Token,
 	lemmaIdx, posIdx, parentAttrIdx, deprelIdx int,
+	blocklist DeprelBlocklist,
 	deprelCollector *collections.Set[string],
-) []expandedSent {
+) ([]expandedSent, error) {
 	syntSent := asExpandedSent(sent, parentAttrIdx)
 	allToks := collections.NewSet[int]()
 	parents := collections.NewSet[int]()
@@ -173,13 +225,17 @@ func findPathsToRoot(
 				currNode.isMultival = strings.Contains(syntSent[parentNode.idx].PosAttrByIndex(parentAttrIdx), "|")
 			}
 
-			if isBlocklistedRel(syntTok.PosAttrByIndex(deprelIdx)) {
+			if blocklist.Contains(syntTok.PosAttrByIndex(deprelIdx)) {
 				// NOP
 
 			} else if parentNode.valid() && syntTok.PosAttrByIndex(posIdx) == "ADP" {
 				if syntSent[parentNode.idx].PosAttrByIndex(deprelIdx) == "obl" {
 					syntSent[parentNode.idx].Attrs[deprelIdx-1] = "obl:" + syntTok.PosAttrByIndex(lemmaIdx)
 					deprelCollector.Add(syntSent[parentNode.idx].Attrs[deprelIdx-1])
+					_, err := record.UDDeprelMapping.GetOrRegister(strings.ToLower(syntSent[parentNode.idx].Attrs[deprelIdx-1]))
+					if err != nil {
+						return branches, err
+					}
 					log.Debug().
 						Str("word", syntSent[parentNode.idx].Word).
 						Str("deprel", syntSent[parentNode.idx].Attrs[deprelIdx-1]).
@@ -195,5 +251,54 @@ func findPathsToRoot(
 		}
 		branches = append(branches, path)
 	}
-	return branches
+	return branches, nil
+}
+
+// siblingGroup is a list of tokens sharing the same syntactic head
+type siblingGroup struct {
+
+	// head is the common head of the members. It can be nil in case
+	// the head is out of the processed sentence.
+	head    *vertigo.Token
+	members []*vertigo.Token
+}
+
+// findSiblingGroups groups sentence tokens by their syntactic heads.
+// Only groups with at least two members are returned. Tokens attached
+// via blocklisted relations (and via "case") are ignored. For multi-value
+// parents, only the first value is considered.
+func findSiblingGroups(
+	sent []*vertigo.Token,
+	parentAttrIdx, deprelIdx int,
+	blocklist DeprelBlocklist,
+) []siblingGroup {
+	groups := make(map[int][]*vertigo.Token)
+	heads := make([]int, 0, len(sent))
+	for i, tok := range sent {
+		rel := tok.PosAttrByIndex(deprelIdx)
+		if blocklist.Contains(rel) || rel == "case" {
+			continue
+		}
+		par, _, _ := strings.Cut(tok.PosAttrByIndex(parentAttrIdx), "|")
+		iPar, err := strconv.Atoi(par)
+		if err != nil || iPar == 0 {
+			continue
+		}
+		head := i + iPar
+		if _, ok := groups[head]; !ok {
+			heads = append(heads, head)
+		}
+		groups[head] = append(groups[head], tok)
+	}
+	ans := make([]siblingGroup, 0, len(heads))
+	for _, head := range heads {
+		if len(groups[head]) > 1 {
+			grp := siblingGroup{members: groups[head]}
+			if head >= 0 && head < len(sent) {
+				grp.head = sent[head]
+			}
+			ans = append(ans, grp)
+		}
+	}
+	return ans
 }
@@ -0,0 +1,96 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+const (
+	testLemmaIdx  = 1
+	testPosIdx    = 2
+	testDeprelIdx = 3
+	testParentIdx = 4
+)
+
+// newTestToken creates a token with attributes lemma, pos, deprel, parent
+func newTestToken(idx int, lemma, pos, deprel, parent string) *vertigo.Token {
+	return &vertigo.Token{
+		Idx:   idx,
+		Word:  lemma,
+		Attrs: []string{lemma, pos, deprel, parent},
+	}
+}
+
+func TestFindSiblingGroups(t *testing.T) {
+	// "the big dog barks loudly ."
+	sent := []*vertigo.Token{
+		newTestToken(0, "the", "DET", "det", "2"),
+		newTestToken(1, "big", "ADJ", "amod", "1"),
+		newTestToken(2, "dog", "NOUN", "nsubj", "1"),
+		newTestToken(3, "bark", "VERB", "root", "0"),
+		newTestToken(4, "loudly", "ADV", "advmod", "-1"),
+		newTestToken(5, ".", "PUNCT", "punct", "-2"),
+	}
+	groups := findSiblingGroups(
+		sent, testParentIdx, testDeprelIdx, mustNewDeprelBlocklist(DefaultDeprelBlocklist))
+	assert.Len(t, groups, 1)
+	assert.Equal(t, "bark", groups[0].head.Word)
+	assert.Equal(t, []string{"dog", "loudly"}, []string{groups[0].members[0].Word, groups[0].members[1].Word})
+}
+
+func TestNewDeprelBlocklist(t *testing.T) {
+	bl, err := NewDeprelBlocklist([]string{"punct", "aux*", " det "})
+	assert.NoError(t, err)
+	assert.True(t, bl.Contains("punct"))
+	assert.True(t, bl.Contains("aux"))
+	assert.True(t, bl.Contains("aux:pass"))
+	assert.True(t, bl.Contains("det"))
+	assert.False(t, bl.Contains("det:poss"))
+	assert.False(t, bl.Contains("punctuation"))
+	assert.False(t, bl.Contains("nsubj"))
+
+	for _, invalid := range [][]string{{""}, {"*"}, {"punct", " "}} {
+		_, err = NewDeprelBlocklist(invalid)
+		assert.Error(t, err)
+	}
+}
+
+func TestFindSiblingGroupsCustomBlocklist(t *testing.T) {
+	// "the big dog barks ."
+	sent := []*vertigo.Token{
+		newTestToken(0, "the", "DET", "det", "2"),
+		newTestToken(1, "big", "ADJ", "amod", "1"),
+		newTestToken(2, "dog", "NOUN", "nsubj", "1"),
+		newTestToken(3, "bark", "VERB", "root", "0"),
+		newTestToken(4, ".", "PUNCT", "punct", "-1"),
+	}
+	groups := findSiblingGroups(
+		sent, testParentIdx, testDeprelIdx, mustNewDeprelBlocklist(DefaultDeprelBlocklist))
+	assert.Len(t, groups, 0)
+
+	groups = findSiblingGroups(sent, testParentIdx, testDeprelIdx, mustNewDeprelBlocklist([]string{}))
+	assert.Len(t, groups, 2)
+
+	groups = findSiblingGroups(sent, testParentIdx, testDeprelIdx, mustNewDeprelBlocklist([]string{"punct"}))
+	assert.Len(t, groups, 1)
+	assert.Equal(t, "dog", groups[0].head.Word)
+	assert.Equal(t, []string{"the", "big"}, []string{groups[0].members[0].Word, groups[0].members[1].Word})
+}
@@ -0,0 +1,320 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+const (
+	maxValidationExamples = 20
+
+	// maxAcceptableCycleRate is a ratio of sentences with cycles
+	// above which the import quality is considered questionable
+	maxAcceptableCycleRate = 0.01
+)
+
+// ValidationIssue is an example of a problem found in a vertical file
+type ValidationIssue struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ValidationReport summarizes properties of a vertical file relevant
+// for an import. Besides raw counts, it contains a list of predicted
+// import problems.
+type ValidationReport struct {
+	NumTokens               int               `json:"numTokens"`
+	NumSentences            int               `json:"numSentences"`
+	NumTokensOutOfSentences int               `json:"numTokensOutOfSentences"`
+	ColumnCounts            map[int]int       `json:"columnCounts"`
+	NumMissingColumns       int               `json:"numMissingColumns"`
+	NumInvalidParents       int               `json:"numInvalidParents"`
+	NumParentsOutOfSentence int               `json:"numParentsOutOfSentence"`
+	NumSentencesWithoutRoot int               `json:"numSentencesWithoutRoot"`
+	NumSentencesWithCycle   int               `json:"numSentencesWithCycle"`
+	CycleRate               float64           `json:"cycleRate"`
+	PoSInventory            map[string]int    `json:"posInventory"`
+	DeprelInventory         map[string]int    `json:"deprelInventory"`
+	UnknownPoS              []string          `json:"unknownPos"`
+	UnknownDeprels          []string          `json:"unknownDeprels"`
+	Examples                []ValidationIssue `json:"examples"`
+	Problems                []string          `json:"problems"`
+}
+
+// OK tells whether no import problems are predicted
+func (r ValidationReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+type validatedToken struct {
+	token *vertigo.Token
+	line  int
+}
+
+// VertValidator is a vertigo.LineProcessor checking a vertical file
+// for problems affecting the import (missing columns, broken parent
+// references, cycles, non-UD tags). It does not write anything.
+type VertValidator struct {
+	lemmaIdx    int
+	posIdx      int
+	parentIdx   int
+	deprelIdx   int
+	sentStruct  string
+	sentOpen    bool
+	currSent    []validatedToken
+	report      ValidationReport
+	unknownPoS  map[string]bool
+	unknownRels map[string]bool
+}
+
+func (vv *VertValidator) addExample(line int, msg string, args ...any) {
+	if len(vv.report.Examples) < maxValidationExamples {
+		vv.report.Examples = append(
+			vv.report.Examples,
+			ValidationIssue{Line: line, Message: fmt.Sprintf(msg, args...)},
+		)
+	}
+}
+
+func (vv *VertValidator) ProcToken(tk *vertigo.Token, line int, err error) error {
+	if err != nil {
+		vv.addExample(line, "failed to parse line: %s", err)
+		return nil
+	}
+	vv.report.NumTokens++
+	vv.report.ColumnCounts[len(tk.Attrs)+1]++
+	maxIdx := max(vv.lemmaIdx, vv.posIdx, vv.parentIdx, vv.deprelIdx)
+	if maxIdx > len(tk.Attrs) {
+		vv.report.NumMissingColumns++
+		vv.addExample(line, "expected at least %d columns, found %d", maxIdx+1, len(tk.Attrs)+1)
+	}
+	if pos := tk.PosAttrByIndex(vv.posIdx); pos != "" {
+		vv.report.PoSInventory[pos]++
+		if _, ok := record.UDPoSMapping[strings.ToUpper(pos)]; !ok {
+			vv.unknownPoS[pos] = true
+		}
+	}
+	if rel := tk.PosAttrByIndex(vv.deprelIdx); rel != "" {
+		vv.report.DeprelInventory[rel]++
+		base, _, _ := strings.Cut(strings.ToLower(rel), ":")
+		if _, ok := record.UDDeprelMapping.Get(base); !ok {
+			vv.unknownRels[rel] = true
+		}
+	}
+	if !vv.sentOpen {
+		vv.report.NumTokensOutOfSentences++
+		return nil
+	}
+	vv.currSent = append(vv.currSent, validatedToken{token: tk, line: line})
+	return nil
+}
+
+// SetSentenceStruct sets a name of the structure delimiting
+// sentences (see Searcher.SetSentenceStruct)
+func (vv *VertValidator) SetSentenceStruct(name string) {
+	if name == "" {
+		name = DefaultSentenceStruct
+	}
+	vv.sentStruct = name
+}
+
+func (vv *VertValidator) ProcStruct(st *vertigo.Structure, line int, err error) error {
+	if err == nil && st.Name == vv.sentStruct {
+		if vv.sentOpen {
+			vv.closeSentence()
+		}
+		vv.sentOpen = true
+	}
+	return nil
+}
+
+func (vv *VertValidator) ProcStructClose(st *vertigo.StructureClose, line int, err error) error {
+	if err == nil && st.Name == vv.sentStruct && vv.sentOpen {
+		vv.closeSentence()
+	}
+	return nil
+}
+
+// closeSentence validates syntactic structure of the current sentence
+func (vv *VertValidator) closeSentence() {
+	vv.sentOpen = false
+	sent := vv.currSent
+	vv.currSent = make([]validatedToken, 0, len(sent))
+	if len(sent) == 0 {
+		return
+	}
+	vv.report.NumSentences++
+	parents := make([][]int, len(sent))
+	var numRoots int
+	for i, vt := range sent {
+		rawPar := vt.token.PosAttrByIndex(vv.parentIdx)
+		for v := range strings.SplitSeq(rawPar, "|") {
+			iPar, err := strconv.Atoi(strings.TrimPrefix(v, "+"))
+			if err != nil {
+				vv.report.NumInvalidParents++
+				vv.addExample(vt.line, "invalid parent value '%s'", rawPar)
+				continue
+			}
+			if iPar == 0 {
+				numRoots++
+				continue
+			}
+			if i+iPar < 0 || i+iPar >= len(sent) {
+				vv.report.NumParentsOutOfSentence++
+				vv.addExample(vt.line, "parent %s points out of the sentence", rawPar)
+				continue
+			}
+			parents[i] = append(parents[i], i+iPar)
+		}
+	}
+	if numRoots == 0 {
+		vv.report.NumSentencesWithoutRoot++
+		vv.addExample(sent[0].line, "sentence without a root")
+	}
+	if hasCycle(parents) {
+		vv.report.NumSentencesWithCycle++
+		vv.addExample(sent[0].line, "sentence contains a cycle")
+	}
+}
+
+// hasCycle tests whether a graph given by lists of parent indices
+// (i.e. edges leading from a node to its parents) contains a cycle.
+func hasCycle(parents [][]int) bool {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make([]int, len(parents))
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = inProgress
+		for _, p := range parents[i] {
+			if state[p] == inProgress || state[p] == unvisited && visit(p) {
+				return true
+			}
+		}
+		state[i] = done
+		return false
+	}
+	for i := range parents {
+		if state[i] == unvisited && visit(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	ans := make([]string, 0, len(m))
+	for k := range m {
+		ans = append(ans, k)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// Report finishes the validation and returns the result
+// including predicted import problems.
+func (vv *VertValidator) Report() ValidationReport {
+	if vv.sentOpen {
+		vv.closeSentence()
+	}
+	ans := vv.report
+	ans.UnknownPoS = sortedKeys(vv.unknownPoS)
+	ans.UnknownDeprels = sortedKeys(vv.unknownRels)
+	if ans.NumSentences > 0 {
+		ans.CycleRate = float64(ans.NumSentencesWithCycle) / float64(ans.NumSentences)
+	}
+	ans.Problems = make([]string, 0, 10)
+	if ans.NumTokens == 0 {
+		ans.Problems = append(ans.Problems, "no tokens found")
+	}
+	if ans.NumTokens > 0 && ans.NumSentences == 0 {
+		ans.Problems = append(
+			ans.Problems,
+			fmt.Sprintf("no sentences (<%s> structures) found, nothing would be imported", vv.sentStruct),
+		)
+	}
+	if ans.NumTokensOutOfSentences > 0 {
+		ans.Problems = append(
+			ans.Problems,
+			fmt.Sprintf("%d tokens are out of sentences and would be ignored", ans.NumTokensOutOfSentences),
+		)
+	}
+	if ans.NumMissingColumns > 0 {
+		ans.Problems = append(
+			ans.Problems,
+			fmt.Sprintf("%d tokens miss some of the configured columns (check the profile/column indices)", ans.NumMissingColumns),
+		)
+	}
+	if ans.NumInvalidParents > 0 || ans.NumParentsOutOfSentence > 0 {
+		ans.Problems = append(
+			ans.Problems,
+			fmt.Sprintf(
+				"%d invalid and %d out-of-sentence parent references (check the parent column index)",
+				ans.NumInvalidParents, ans.NumParentsOutOfSentence,
+			),
+		)
+	}
+	if ans.CycleRate > maxAcceptableCycleRate {
+		ans.Problems = append(
+			ans.Problems,
+			fmt.Sprintf("%.1f%% of sentences contain a cycle (these are imported only partially)", ans.CycleRate*100),
+		)
+	}
+	if len(ans.UnknownPoS) > 0 {
+		ans.Problems = append(
+			ans.Problems,
+			fmt.Sprintf("found %d non-UD PoS tags (check the PoS column index)", len(ans.UnknownPoS)),
+		)
+	}
+	if len(ans.UnknownDeprels) > 0 {
+		ans.Problems = append(
+			ans.Problems,
+			fmt.Sprintf("found %d non-UD dependency relations (check the deprel column index)", len(ans.UnknownDeprels)),
+		)
+	}
+	return ans
+}
+
+// NewVertValidator creates a validator for a vertical file with the
+// provided column positions (the same as used for an import).
+func NewVertValidator(lemmaIdx, posIdx, parentIdx, deprelIdx int) *VertValidator {
+	return &VertValidator{
+		lemmaIdx:   lemmaIdx,
+		posIdx:     posIdx,
+		parentIdx:  parentIdx,
+		deprelIdx:  deprelIdx,
+		sentStruct: DefaultSentenceStruct,
+		report: ValidationReport{
+			ColumnCounts:    make(map[int]int),
+			PoSInventory:    make(map[string]int),
+			DeprelInventory: make(map[string]int),
+			Examples:        []ValidationIssue{},
+		},
+		unknownPoS:  make(map[string]bool),
+		unknownRels: make(map[string]bool),
+	}
+}
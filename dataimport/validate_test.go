@@ -0,0 +1,103 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+func feedTestSentence(vv *VertValidator, tokens ...*vertigo.Token) {
+	vv.ProcStruct(&vertigo.Structure{Name: "s"}, 0, nil)
+	for i, tk := range tokens {
+		vv.ProcToken(tk, i+1, nil)
+	}
+	vv.ProcStructClose(&vertigo.StructureClose{Name: "s"}, 0, nil)
+}
+
+func TestVertValidatorValidFile(t *testing.T) {
+	vv := NewVertValidator(testLemmaIdx, testPosIdx, testParentIdx, testDeprelIdx)
+	feedTestSentence(
+		vv,
+		newTestToken(0, "dog", "NOUN", "nsubj", "1"),
+		newTestToken(1, "bark", "VERB", "root", "0"),
+		newTestToken(2, ".", "PUNCT", "punct", "-1"),
+	)
+	report := vv.Report()
+	assert.True(t, report.OK())
+	assert.Equal(t, 3, report.NumTokens)
+	assert.Equal(t, 1, report.NumSentences)
+	assert.Equal(t, map[int]int{5: 3}, report.ColumnCounts)
+	assert.Equal(t, 1, report.DeprelInventory["nsubj"])
+	assert.Empty(t, report.UnknownPoS)
+	assert.Empty(t, report.UnknownDeprels)
+}
+
+func TestVertValidatorDetectsProblems(t *testing.T) {
+	vv := NewVertValidator(testLemmaIdx, testPosIdx, testParentIdx, testDeprelIdx)
+	feedTestSentence( // cycle, no root
+		vv,
+		newTestToken(0, "dog", "NOUN", "nsubj", "1"),
+		newTestToken(1, "bark", "VERB", "obj", "-1"),
+	)
+	feedTestSentence( // parent out of sentence, invalid parent, unknown tags
+		vv,
+		newTestToken(0, "dog", "NN", "nsubj", "5"),
+		newTestToken(1, "bark", "VERB", "root", "0"),
+		newTestToken(2, ".", "PUNCT", "xyz", "foo"),
+	)
+	vv.ProcToken(&vertigo.Token{Word: "x", Attrs: []string{"x"}}, 10, nil)
+	report := vv.Report()
+	assert.False(t, report.OK())
+	assert.Equal(t, 2, report.NumSentences)
+	assert.Equal(t, 1, report.NumSentencesWithCycle)
+	assert.Equal(t, 0.5, report.CycleRate)
+	assert.Equal(t, 1, report.NumSentencesWithoutRoot)
+	assert.Equal(t, 1, report.NumParentsOutOfSentence)
+	assert.Equal(t, 1, report.NumInvalidParents)
+	assert.Equal(t, 1, report.NumMissingColumns)
+	assert.Equal(t, 1, report.NumTokensOutOfSentences)
+	assert.Equal(t, []string{"NN"}, report.UnknownPoS)
+	assert.Equal(t, []string{"xyz"}, report.UnknownDeprels)
+}
+
+func TestVertValidatorSentenceStruct(t *testing.T) {
+	vv := NewVertValidator(testLemmaIdx, testPosIdx, testParentIdx, testDeprelIdx)
+	feedTestSentence(vv, newTestToken(0, "bark", "VERB", "root", "0"))
+	report := vv.Report()
+	assert.Equal(t, 1, report.NumSentences)
+
+	vv = NewVertValidator(testLemmaIdx, testPosIdx, testParentIdx, testDeprelIdx)
+	vv.SetSentenceStruct("seg")
+	feedTestSentence(vv, newTestToken(0, "bark", "VERB", "root", "0"))
+	report = vv.Report()
+	assert.Equal(t, 0, report.NumSentences)
+	assert.Equal(t, 1, report.NumTokensOutOfSentences)
+	assert.Contains(t, report.Problems, "no sentences (<seg> structures) found, nothing would be imported")
+
+	vv.SetSentenceStruct("")
+	feedTestSentence(vv, newTestToken(0, "bark", "VERB", "root", "0"))
+	assert.Equal(t, 1, vv.Report().NumSentences)
+}
+
+func TestHasCycle(t *testing.T) {
+	assert.False(t, hasCycle([][]int{{1}, {}, {1}}))
+	assert.True(t, hasCycle([][]int{{1}, {2}, {0}}))
+	assert.True(t, hasCycle([][]int{{}, {1}}))
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tomachalek/vertigo/v6"
+)
+
+const (
+	PairWeightingNone           = "none"
+	PairWeightingDirectLink     = "direct-link"
+	PairWeightingDistanceDecay  = "distance-decay"
+	PairWeightingClauseBoundary = "clause-boundary"
+
+	indirectLinkWeight        = 0.5
+	clauseBoundaryCrossWeight = 0.5
+)
+
+// PairWeighting provides a weight of a co-occurrence of tokens path[i]
+// and path[j] where path is a syntax tree path oriented from a leaf
+// to the root. The weight is applied to the co-occurrence frequency.
+type PairWeighting interface {
+	Weight(path []*vertigo.Token, i, j int) float64
+}
+
+// ------
+
+// directLinkWeighting prefers direct head-dependent links
+// over pairs connected via other nodes.
+type directLinkWeighting struct{}
+
+func (w directLinkWeighting) Weight(path []*vertigo.Token, i, j int) float64 {
+	if math.Abs(float64(i-j)) > 1 {
+		return indirectLinkWeight
+	}
+	return 1
+}
+
+// ------
+
+// distanceDecayWeighting weights pairs by a reciprocal
+// value of their distance on the tree path.
+type distanceDecayWeighting struct{}
+
+func (w distanceDecayWeighting) Weight(path []*vertigo.Token, i, j int) float64 {
+	return 1 / math.Abs(float64(i-j))
+}
+
+// ------
+
+// clauseBoundaryWeighting down-weights pairs where the path between
+// the two tokens leaves a clause (i.e. the lower token or some of the
+// intermediate ones is attached via a clausal relation).
+type clauseBoundaryWeighting struct {
+	deprelIdx int
+}
+
+func (w clauseBoundaryWeighting) isClausalRel(rel string) bool {
+	switch rel {
+	case "acl", "acl:relcl", "advcl", "ccomp", "csubj", "csubj:pass", "parataxis", "xcomp":
+		return true
+	}
+	return false
+}
+
+func (w clauseBoundaryWeighting) Weight(path []*vertigo.Token, i, j int) float64 {
+	for k := min(i, j); k < max(i, j); k++ {
+		if w.isClausalRel(path[k].PosAttrByIndex(w.deprelIdx)) {
+			return clauseBoundaryCrossWeight
+		}
+	}
+	return 1
+}
+
+// ------
+
+// NewPairWeighting creates a pair weighting based on its name.
+// For empty name or "none", nil is returned which means that no
+// weighting is applied.
+func NewPairWeighting(name string, deprelIdx int) (PairWeighting, error) {
+	switch name {
+	case "", PairWeightingNone:
+		return nil, nil
+	case PairWeightingDirectLink:
+		return directLinkWeighting{}, nil
+	case PairWeightingDistanceDecay:
+		return distanceDecayWeighting{}, nil
+	case PairWeightingClauseBoundary:
+		return clauseBoundaryWeighting{deprelIdx: deprelIdx}, nil
+	default:
+		return nil, fmt.Errorf("unknown pair weighting: %s", name)
+	}
+}
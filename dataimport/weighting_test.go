@@ -0,0 +1,128 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataimport
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/tomachalek/vertigo/v6"
+)
+
+// weightingTestPath creates a leaf-to-root path with tokens attached
+// via the provided deprels
+func weightingTestPath(deprels ...string) []*vertigo.Token {
+	lemmas := []string{"big", "dog", "bark", "say", "know"}
+	ans := make([]*vertigo.Token, len(deprels))
+	for i, deprel := range deprels {
+		ans[i] = &vertigo.Token{
+			Idx:         i,
+			Word:        lemmas[i],
+			Attrs:       []string{lemmas[i], "NOUN", deprel},
+			StructAttrs: map[string]string{"text.genre": "fiction"},
+		}
+	}
+	return ans
+}
+
+func TestDirectLinkWeighting(t *testing.T) {
+	w, err := NewPairWeighting(PairWeightingDirectLink, 3)
+	assert.NoError(t, err)
+	path := weightingTestPath("amod", "nsubj", "ccomp", "root")
+	assert.Equal(t, 1.0, w.Weight(path, 0, 1))
+	assert.Equal(t, 1.0, w.Weight(path, 2, 1))
+	assert.Equal(t, 0.5, w.Weight(path, 0, 2))
+	assert.Equal(t, 0.5, w.Weight(path, 3, 0))
+}
+
+func TestDistanceDecayWeighting(t *testing.T) {
+	w, err := NewPairWeighting(PairWeightingDistanceDecay, 3)
+	assert.NoError(t, err)
+	path := weightingTestPath("amod", "nsubj", "ccomp", "root")
+	assert.Equal(t, 1.0, w.Weight(path, 0, 1))
+	assert.Equal(t, 0.5, w.Weight(path, 2, 0))
+	assert.InDelta(t, 1.0/3, w.Weight(path, 0, 3), 0.0001)
+	assert.InDelta(t, 1.0/3, w.Weight(path, 3, 0), 0.0001)
+}
+
+func TestClauseBoundaryWeighting(t *testing.T) {
+	w, err := NewPairWeighting(PairWeightingClauseBoundary, 3)
+	assert.NoError(t, err)
+	path := weightingTestPath("amod", "nsubj", "ccomp", "root")
+	assert.Equal(t, 1.0, w.Weight(path, 0, 1))
+	assert.Equal(t, 1.0, w.Weight(path, 0, 2))
+	assert.Equal(t, 1.0, w.Weight(path, 2, 0))
+	// the path from "bark" to "say" leaves the clause
+	assert.Equal(t, 0.5, w.Weight(path, 2, 3))
+	assert.Equal(t, 0.5, w.Weight(path, 0, 3))
+	assert.Equal(t, 0.5, w.Weight(path, 3, 1))
+}
+
+func TestNewPairWeighting(t *testing.T) {
+	for _, name := range []string{"", PairWeightingNone} {
+		w, err := NewPairWeighting(name, 3)
+		assert.NoError(t, err)
+		assert.Nil(t, w)
+	}
+	_, err := NewPairWeighting("foo", 3)
+	assert.Error(t, err)
+}
+
+func TestWeightedPairFreqsStored(t *testing.T) {
+	weighting, err := NewPairWeighting(PairWeightingDistanceDecay, 3)
+	assert.NoError(t, err)
+	f := NewFreqs(1, 2, 3, "text.genre", map[string]byte{"fiction": 0x01})
+	f.SetPathPolicy(storage.PathPolicy{Name: storage.PathPolicyFullPath})
+	f.SetPairWeighting(weighting)
+	path := weightingTestPath("amod", "nsubj", "ccomp", "root")
+	for range 2 {
+		f.ImportTreePath(path)
+	}
+	st := &chunkRecordingStorage{
+		single: make(map[record.GroupingKey]record.TokenFreq),
+		double: make(map[record.GroupingKey]record.CollocFreq),
+	}
+	_, err = f.StoreToDb(st, 1)
+	assert.NoError(t, err)
+	pairFreqs := make(map[[2]string]int)
+	for _, v := range st.double {
+		pairFreqs[[2]string{v.Lemma1, v.Lemma2}] = v.Freq
+	}
+	assert.Equal(t, 2, pairFreqs[[2]string{"big", "dog"}])  // 2 * 1
+	assert.Equal(t, 1, pairFreqs[[2]string{"big", "bark"}]) // 2 * 1/2
+	assert.Equal(t, 1, pairFreqs[[2]string{"big", "say"}])  // 2 * 1/3 rounded
+	assert.Equal(t, 1, pairFreqs[[2]string{"say", "big"}])  // 2 * 1/3 rounded
+	assert.Equal(t, 2, pairFreqs[[2]string{"bark", "say"}]) // 2 * 1
+	// single token frequencies are not weighted
+	for _, v := range st.single {
+		assert.Equal(t, 2, v.Freq)
+	}
+}
+
+func TestApplyPairWeightingNeverZero(t *testing.T) {
+	freqs := map[record.GroupingKey]record.CollocFreq{
+		"a": {Freq: 1, WeightedFreq: 0.25},
+		"b": {Freq: 3, WeightedFreq: 1.5},
+		"c": {Freq: 9, WeightedFreq: 3},
+	}
+	applyPairWeighting(freqs)
+	assert.Equal(t, 1, freqs["a"].Freq)
+	assert.Equal(t, 2, freqs["b"].Freq)
+	assert.Equal(t, 3, freqs["c"].Freq)
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evaluation
+
+import (
+	"context"
+	"math"
+
+	"github.com/czcorpus/depreldb/scoll"
+)
+
+// RankingSource is a provider along with options producing
+// collocate rankings to be compared (e.g. two databases or
+// two measures on the same database).
+type RankingSource struct {
+	Provider scoll.CollocationProvider
+	Options  []func(opts *scoll.CalculationOptions)
+}
+
+func (src RankingSource) ranking(lemma string) ([]string, error) {
+	opts := append(
+		[]func(opts *scoll.CalculationOptions){scoll.WithRestrictedTextTypesAccess(), scoll.WithoutQueryLog()},
+		src.Options...,
+	)
+	ans, err := src.Provider.GetCollocations(context.Background(), lemma, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ranking := make([]string, len(ans))
+	for i, item := range ans {
+		ranking[i] = item.Hash()
+	}
+	return ranking, nil
+}
+
+// LemmaCalibration compares two rankings of collocates of a single lemma.
+// Correlations are calculated on collocates found in both rankings
+// and they are nil in case there are less than two such collocates.
+type LemmaCalibration struct {
+	Lemma     string   `json:"lemma"`
+	Error     string   `json:"error,omitempty"`
+	NumA      int      `json:"numA"`
+	NumB      int      `json:"numB"`
+	NumCommon int      `json:"numCommon"`
+	Overlap   float64  `json:"overlap"`
+	Spearman  *float64 `json:"spearman"`
+	Kendall   *float64 `json:"kendall"`
+}
+
+// CalibrationReport summarizes ranking comparison for a sample of lemmas.
+// Mean values are calculated from lemmas with defined values.
+type CalibrationReport struct {
+	Lemmas       []LemmaCalibration `json:"lemmas"`
+	MeanOverlap  float64            `json:"meanOverlap"`
+	MeanSpearman *float64           `json:"meanSpearman"`
+	MeanKendall  *float64           `json:"meanKendall"`
+}
+
+// commonRanks returns ranks (within the common items) of items
+// found in both rankings. Items are ordered as in rankingA.
+func commonRanks(rankingA, rankingB []string) ([]float64, []float64) {
+	inB := make(map[string]int)
+	for i, v := range rankingB {
+		inB[v] = i
+	}
+	posB := make([]int, 0, len(rankingA))
+	for _, v := range rankingA {
+		if i, ok := inB[v]; ok {
+			posB = append(posB, i)
+		}
+	}
+	ranksA := make([]float64, len(posB))
+	ranksB := make([]float64, len(posB))
+	for i, p := range posB {
+		ranksA[i] = float64(i + 1)
+		ranksB[i] = 1
+		for _, p2 := range posB {
+			if p2 < p {
+				ranksB[i]++
+			}
+		}
+	}
+	return ranksA, ranksB
+}
+
+// spearman calculates Spearman's rank correlation coefficient
+// for rankings without ties.
+func spearman(ranksA, ranksB []float64) float64 {
+	n := float64(len(ranksA))
+	var sumD2 float64
+	for i := range ranksA {
+		d := ranksA[i] - ranksB[i]
+		sumD2 += d * d
+	}
+	return 1 - 6*sumD2/(n*(n*n-1))
+}
+
+// kendall calculates Kendall's tau for rankings without ties.
+func kendall(ranksA, ranksB []float64) float64 {
+	var concordant, discordant float64
+	for i := 0; i < len(ranksA); i++ {
+		for j := i + 1; j < len(ranksA); j++ {
+			s := (ranksA[i] - ranksA[j]) * (ranksB[i] - ranksB[j])
+			if s > 0 {
+				concordant++
+
+			} else if s < 0 {
+				discordant++
+			}
+		}
+	}
+	return (concordant - discordant) / (concordant + discordant)
+}
+
+func compareRankings(lemma string, rankingA, rankingB []string) LemmaCalibration {
+	ans := LemmaCalibration{Lemma: lemma, NumA: len(rankingA), NumB: len(rankingB)}
+	ranksA, ranksB := commonRanks(rankingA, rankingB)
+	ans.NumCommon = len(ranksA)
+	if union := ans.NumA + ans.NumB - ans.NumCommon; union > 0 {
+		ans.Overlap = float64(ans.NumCommon) / float64(union)
+	}
+	if ans.NumCommon >= 2 {
+		s := spearman(ranksA, ranksB)
+		k := kendall(ranksA, ranksB)
+		ans.Spearman = &s
+		ans.Kendall = &k
+	}
+	return ans
+}
+
+func meanOf(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	ans := sum / float64(len(values))
+	if math.IsNaN(ans) {
+		return nil
+	}
+	return &ans
+}
+
+// CalibrateRankings compares collocate rankings of the provided lemmas
+// produced by two sources.
+func CalibrateRankings(lemmas []string, srcA, srcB RankingSource) CalibrationReport {
+	ans := CalibrationReport{Lemmas: make([]LemmaCalibration, 0, len(lemmas))}
+	var overlaps, spearmans, kendalls []float64
+	for _, lemma := range lemmas {
+		rankingA, err := srcA.ranking(lemma)
+		if err != nil {
+			ans.Lemmas = append(ans.Lemmas, LemmaCalibration{Lemma: lemma, Error: err.Error()})
+			continue
+		}
+		rankingB, err := srcB.ranking(lemma)
+		if err != nil {
+			ans.Lemmas = append(ans.Lemmas, LemmaCalibration{Lemma: lemma, Error: err.Error()})
+			continue
+		}
+		item := compareRankings(lemma, rankingA, rankingB)
+		ans.Lemmas = append(ans.Lemmas, item)
+		overlaps = append(overlaps, item.Overlap)
+		if item.Spearman != nil {
+			spearmans = append(spearmans, *item.Spearman)
+			kendalls = append(kendalls, *item.Kendall)
+		}
+	}
+	if m := meanOf(overlaps); m != nil {
+		ans.MeanOverlap = *m
+	}
+	ans.MeanSpearman = meanOf(spearmans)
+	ans.MeanKendall = meanOf(kendalls)
+	return ans
+}
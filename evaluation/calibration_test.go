@@ -0,0 +1,44 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareRankings(t *testing.T) {
+	ans := compareRankings("x", []string{"a", "b", "c", "d"}, []string{"a", "b", "c", "d"})
+	assert.Equal(t, 1.0, ans.Overlap)
+	assert.InDelta(t, 1.0, *ans.Spearman, 0.0001)
+	assert.InDelta(t, 1.0, *ans.Kendall, 0.0001)
+
+	ans = compareRankings("x", []string{"a", "b", "c"}, []string{"c", "b", "a"})
+	assert.InDelta(t, -1.0, *ans.Spearman, 0.0001)
+	assert.InDelta(t, -1.0, *ans.Kendall, 0.0001)
+
+	// common: a, b, d; in B ordered as d, a, b
+	ans = compareRankings("x", []string{"a", "b", "c", "d"}, []string{"d", "e", "a", "b"})
+	assert.Equal(t, 3, ans.NumCommon)
+	assert.InDelta(t, 3.0/5.0, ans.Overlap, 0.0001)
+	assert.InDelta(t, -0.5, *ans.Spearman, 0.0001)
+	assert.InDelta(t, -1.0/3.0, *ans.Kendall, 0.0001)
+
+	ans = compareRankings("x", []string{"a"}, []string{"a"})
+	assert.Nil(t, ans.Spearman)
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evaluation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// GoldPair is a single collocation pair of a gold standard (e.g. taken
+// from a dictionary). Deprel is optional - if empty, the pair matches
+// a collocate with any relation.
+type GoldPair struct {
+	Lemma     string
+	Collocate string
+	Deprel    string
+}
+
+// ReadGoldStandard reads gold pairs from a TSV file with columns
+// lemma, collocate and optional deprel. Empty lines and lines starting
+// with # are ignored.
+func ReadGoldStandard(path string) ([]GoldPair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gold standard: %w", err)
+	}
+	defer f.Close()
+	ans := make([]GoldPair, 0, 100)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		items := strings.Split(line, "\t")
+		if len(items) < 2 || len(items) > 3 {
+			return nil, fmt.Errorf("failed to read gold standard: invalid line %d", lineNum)
+		}
+		pair := GoldPair{Lemma: items[0], Collocate: items[1]}
+		if len(items) == 3 {
+			pair.Deprel = items[2]
+		}
+		ans = append(ans, pair)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read gold standard: %w", err)
+	}
+	return ans, nil
+}
+
+// ------
+
+// Metrics contains evaluation results of a set of rankings.
+// Precision and recall are micro-averaged, MAP is a mean of average
+// precision values of individual lemmas.
+type Metrics struct {
+	NumRetrieved int     `json:"numRetrieved"`
+	NumGold      int     `json:"numGold"`
+	NumHits      int     `json:"numHits"`
+	Precision    float64 `json:"precision"`
+	Recall       float64 `json:"recall"`
+	MAP          float64 `json:"map"`
+	numRankings  int
+	sumAP        float64
+}
+
+func (m *Metrics) add(numRetrieved, numGold, numHits int, ap float64) {
+	if numGold == 0 {
+		return
+	}
+	m.NumRetrieved += numRetrieved
+	m.NumGold += numGold
+	m.NumHits += numHits
+	m.numRankings++
+	m.sumAP += ap
+	if m.NumRetrieved > 0 {
+		m.Precision = float64(m.NumHits) / float64(m.NumRetrieved)
+	}
+	m.Recall = float64(m.NumHits) / float64(m.NumGold)
+	m.MAP = m.sumAP / float64(m.numRankings)
+}
+
+// MeasureEvaluation contains evaluation of a single measure
+// both in total and for individual relations. Relation specific
+// metrics consider only gold pairs with an explicit deprel
+// and collocates with the same deprel.
+type MeasureEvaluation struct {
+	Measure     storage.SortingMeasure `json:"measure"`
+	Overall     Metrics                `json:"overall"`
+	PerRelation map[string]*Metrics    `json:"perRelation"`
+}
+
+// GoldReport is a result of a gold standard evaluation.
+type GoldReport struct {
+	Limit    int                  `json:"limit"`
+	Measures []*MeasureEvaluation `json:"measures"`
+	Errors   map[string]string    `json:"errors,omitempty"`
+}
+
+// scoreRanking finds gold pairs in the retrieved collocates and returns
+// number of hits along with average precision. Each gold pair can be
+// matched just once.
+func scoreRanking(retrieved []storage.Collocation, gold []GoldPair) (int, float64) {
+	matched := make([]bool, len(gold))
+	var numHits int
+	var sumPrec float64
+	for i, item := range retrieved {
+		for j, g := range gold {
+			if matched[j] || !strings.EqualFold(g.Collocate, item.Collocate.Value) {
+				continue
+			}
+			if g.Deprel != "" && g.Deprel != item.Deprel {
+				continue
+			}
+			matched[j] = true
+			numHits++
+			sumPrec += float64(numHits) / float64(i+1)
+			break
+		}
+	}
+	if len(gold) == 0 {
+		return numHits, 0
+	}
+	return numHits, sumPrec / float64(len(gold))
+}
+
+func filterByDeprel(items []storage.Collocation, deprel string) []storage.Collocation {
+	ans := make([]storage.Collocation, 0, len(items))
+	for _, item := range items {
+		if item.Deprel == deprel {
+			ans = append(ans, item)
+		}
+	}
+	return ans
+}
+
+// EvaluateGoldStandard queries the provider for all the lemmas of the gold
+// standard using each of the measures (retrieving up to limit collocates)
+// and calculates precision, recall and MAP per measure and per relation.
+func EvaluateGoldStandard(
+	gold []GoldPair,
+	provider scoll.CollocationProvider,
+	measures []storage.SortingMeasure,
+	limit int,
+) GoldReport {
+	ans := GoldReport{Limit: limit, Measures: make([]*MeasureEvaluation, len(measures))}
+	byLemma := make(map[string][]GoldPair)
+	for _, g := range gold {
+		byLemma[g.Lemma] = append(byLemma[g.Lemma], g)
+	}
+	lemmas := make([]string, 0, len(byLemma))
+	for lemma := range byLemma {
+		lemmas = append(lemmas, lemma)
+	}
+	sort.Strings(lemmas)
+
+	for i, measure := range measures {
+		eval := &MeasureEvaluation{Measure: measure, PerRelation: make(map[string]*Metrics)}
+		ans.Measures[i] = eval
+		for _, lemma := range lemmas {
+			retrieved, err := provider.GetCollocations(
+				context.Background(),
+				lemma,
+				scoll.WithSortBy(measure),
+				scoll.WithLimit(limit),
+				scoll.WithGroupByDeprel(),
+				scoll.WithRestrictedTextTypesAccess(),
+				scoll.WithoutQueryLog(),
+			)
+			if err != nil {
+				if ans.Errors == nil {
+					ans.Errors = make(map[string]string)
+				}
+				ans.Errors[fmt.Sprintf("%s/%s", measure, lemma)] = err.Error()
+				continue
+			}
+			lemmaGold := byLemma[lemma]
+			numHits, ap := scoreRanking(retrieved, lemmaGold)
+			eval.Overall.add(len(retrieved), len(lemmaGold), numHits, ap)
+
+			goldByDeprel := make(map[string][]GoldPair)
+			for _, g := range lemmaGold {
+				if g.Deprel != "" {
+					goldByDeprel[g.Deprel] = append(goldByDeprel[g.Deprel], g)
+				}
+			}
+			for deprel, relGold := range goldByDeprel {
+				relRetrieved := filterByDeprel(retrieved, deprel)
+				numHits, ap := scoreRanking(relRetrieved, relGold)
+				m, ok := eval.PerRelation[deprel]
+				if !ok {
+					m = &Metrics{}
+					eval.PerRelation[deprel] = m
+				}
+				m.add(len(relRetrieved), len(relGold), numHits, ap)
+			}
+		}
+	}
+	return ans
+}
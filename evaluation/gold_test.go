@@ -0,0 +1,53 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreRanking(t *testing.T) {
+	retrieved := []storage.Collocation{
+		{Collocate: storage.CollMember{Value: "a"}, Deprel: "nmod"},
+		{Collocate: storage.CollMember{Value: "b"}, Deprel: "amod"},
+		{Collocate: storage.CollMember{Value: "c"}, Deprel: "nmod"},
+		{Collocate: storage.CollMember{Value: "b"}, Deprel: "nmod"},
+	}
+	gold := []GoldPair{
+		{Collocate: "A"},
+		{Collocate: "b", Deprel: "nmod"},
+		{Collocate: "x"},
+	}
+	numHits, ap := scoreRanking(retrieved, gold)
+	assert.Equal(t, 2, numHits)
+	// hits at ranks 1 and 4
+	assert.InDelta(t, (1.0+2.0/4.0)/3.0, ap, 0.0001)
+}
+
+func TestMetricsAdd(t *testing.T) {
+	var m Metrics
+	m.add(10, 4, 2, 0.5)
+	m.add(10, 0, 0, 0) // ignored, nothing to evaluate
+	m.add(10, 6, 3, 0.25)
+	assert.Equal(t, 20, m.NumRetrieved)
+	assert.InDelta(t, 5.0/20.0, m.Precision, 0.0001)
+	assert.InDelta(t, 5.0/10.0, m.Recall, 0.0001)
+	assert.InDelta(t, 0.375, m.MAP, 0.0001)
+}
@@ -0,0 +1,264 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package evaluation contains tools for validating collocation
+// databases and measures - result snapshots, comparison of rankings
+// and evaluation against gold-standard data.
+package evaluation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// SnapshotEntry is a single recorded query along with its result.
+type SnapshotEntry struct {
+	Lemma string `json:"lemma"`
+
+	// Options are encoded in the same way as in the REST API
+	Options url.Values            `json:"options"`
+	Results []storage.Collocation `json:"results"`
+}
+
+// ------
+
+// SnapshotRecorder is a CollocationProvider wrapping another provider
+// and recording all the successful collocation queries into a JSONL file.
+type SnapshotRecorder struct {
+	scoll.CollocationProvider
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+func (rec *SnapshotRecorder) GetCollocations(ctx context.Context, lemma string, options ...func(opts *scoll.CalculationOptions)) ([]storage.Collocation, error) {
+	ans, err := rec.CollocationProvider.GetCollocations(ctx, lemma, options...)
+	if err != nil {
+		return ans, err
+	}
+	var opts scoll.CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	data, err := json.Marshal(SnapshotEntry{Lemma: lemma, Options: opts.AsURLValues(), Results: ans})
+	if err != nil {
+		return ans, fmt.Errorf("failed to record snapshot entry: %w", err)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if _, err := rec.w.Write(append(data, '\n')); err != nil {
+		return ans, fmt.Errorf("failed to record snapshot entry: %w", err)
+	}
+	return ans, nil
+}
+
+// Close flushes all the recorded entries and closes the snapshot file.
+func (rec *SnapshotRecorder) Close() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if err := rec.w.Flush(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	return rec.file.Close()
+}
+
+// NewSnapshotRecorder creates a recorder writing to the provided path.
+// Existing files are appended.
+func NewSnapshotRecorder(provider scoll.CollocationProvider, path string) (*SnapshotRecorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot file: %w", err)
+	}
+	return &SnapshotRecorder{
+		CollocationProvider: provider,
+		file:                f,
+		w:                   bufio.NewWriter(f),
+	}, nil
+}
+
+// ------
+
+// ReadSnapshot loads all the entries of a snapshot file.
+func ReadSnapshot(path string) ([]SnapshotEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	defer f.Close()
+	ans := make([]SnapshotEntry, 0, 100)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		var entry SnapshotEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to read snapshot line %d: %w", lineNum, err)
+		}
+		ans = append(ans, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return ans, nil
+}
+
+// ------
+
+// DriftTolerance specifies allowed differences between
+// recorded and replayed results.
+type DriftTolerance struct {
+
+	// MaxRankShift is a max. allowed change of a collocate's position
+	MaxRankShift int
+
+	// MaxScoreDiff is a max. allowed absolute difference of any score.
+	// Please note that recorded scores are rounded to 3 decimal places.
+	MaxScoreDiff float64
+}
+
+// Drift describes a single difference found between
+// a recorded and a replayed result.
+type Drift struct {
+	Collocate string `json:"collocate"`
+
+	// Kind is one of "missing", "new", "rank", "score"
+	Kind     string  `json:"kind"`
+	Measure  string  `json:"measure,omitempty"`
+	Recorded float64 `json:"recorded"`
+	Replayed float64 `json:"replayed"`
+}
+
+// QueryDrift contains all the differences of a single query
+type QueryDrift struct {
+	Lemma   string     `json:"lemma"`
+	Options url.Values `json:"options"`
+	Error   string     `json:"error,omitempty"`
+	Drifts  []Drift    `json:"drifts"`
+}
+
+// SnapshotReport is a result of a snapshot verification
+type SnapshotReport struct {
+	NumQueries      int          `json:"numQueries"`
+	NumFailed       int          `json:"numFailed"`
+	DriftingQueries []QueryDrift `json:"driftingQueries"`
+}
+
+// OK tells whether all the queries passed the verification.
+func (r SnapshotReport) OK() bool {
+	return r.NumFailed == 0
+}
+
+func collocateLabel(c storage.Collocation) string {
+	dir := "←"
+	if !c.IsHead {
+		dir = "→"
+	}
+	return fmt.Sprintf("%s %s%s %s", c.TextType, c.Deprel, dir, c.Collocate.Value)
+}
+
+func scoresOf(c storage.Collocation) map[string]float64 {
+	return map[string]float64{
+		"logDice":         c.LogDice,
+		"tScore":          c.TScore,
+		"lmi":             c.LMI,
+		"logLikelihood":   c.LogLikelihood,
+		"rrfScore":        c.RRFScore,
+		"mi":              c.MI,
+		"mi3":             c.MI3,
+		"dice":            c.Dice,
+		"minSensitivity":  c.MinSensitivity,
+		"deltaPCollocate": c.DeltaPCollocate,
+		"deltaPNode":      c.DeltaPNode,
+	}
+}
+
+func compareResults(recorded, replayed []storage.Collocation, tol DriftTolerance) []Drift {
+	ans := make([]Drift, 0, 10)
+	replayedRanks := make(map[string]int)
+	for i, c := range replayed {
+		replayedRanks[c.Hash()] = i
+	}
+	recordedHashes := make(map[string]bool)
+	for i, rc := range recorded {
+		recordedHashes[rc.Hash()] = true
+		j, ok := replayedRanks[rc.Hash()]
+		if !ok {
+			ans = append(ans, Drift{Collocate: collocateLabel(rc), Kind: "missing", Recorded: float64(i + 1)})
+			continue
+		}
+		if abs(i-j) > tol.MaxRankShift {
+			ans = append(ans, Drift{
+				Collocate: collocateLabel(rc), Kind: "rank", Recorded: float64(i + 1), Replayed: float64(j + 1)})
+		}
+		replayedScores := scoresOf(replayed[j])
+		for measure, v := range scoresOf(rc) {
+			v2 := replayedScores[measure]
+			if math.Abs(v-v2) > tol.MaxScoreDiff {
+				ans = append(ans, Drift{
+					Collocate: collocateLabel(rc), Kind: "score", Measure: measure, Recorded: v, Replayed: v2})
+			}
+		}
+	}
+	for j, c := range replayed {
+		if !recordedHashes[c.Hash()] {
+			ans = append(ans, Drift{Collocate: collocateLabel(c), Kind: "new", Replayed: float64(j + 1)})
+		}
+	}
+	return ans
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// VerifySnapshot replays all the snapshot queries using the provider
+// and reports results drifting beyond the tolerance.
+func VerifySnapshot(entries []SnapshotEntry, provider scoll.CollocationProvider, tol DriftTolerance) SnapshotReport {
+	ans := SnapshotReport{NumQueries: len(entries), DriftingQueries: []QueryDrift{}}
+	for _, entry := range entries {
+		qd := QueryDrift{Lemma: entry.Lemma, Options: entry.Options}
+		opts, err := scoll.OptionsFromURLValues(entry.Options)
+		if err != nil {
+			qd.Error = err.Error()
+
+		} else {
+			opts = append(opts, scoll.WithRestrictedTextTypesAccess(), scoll.WithoutQueryLog())
+			replayed, err := provider.GetCollocations(context.Background(), entry.Lemma, opts...)
+			if err != nil {
+				qd.Error = err.Error()
+
+			} else {
+				qd.Drifts = compareResults(entry.Results, replayed, tol)
+			}
+		}
+		if qd.Error != "" || len(qd.Drifts) > 0 {
+			ans.NumFailed++
+			ans.DriftingQueries = append(ans.DriftingQueries, qd)
+		}
+	}
+	return ans
+}
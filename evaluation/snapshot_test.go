@@ -0,0 +1,55 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestColl(collocate string, logDice float64) storage.Collocation {
+	return storage.Collocation{
+		Lemma:      storage.CollMember{Value: "team"},
+		Collocate:  storage.CollMember{Value: collocate},
+		Deprel:     "nmod",
+		LogDice:    logDice,
+		IsHead:     true,
+		MutualDist: 1,
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	recorded := []storage.Collocation{
+		newTestColl("a", 10), newTestColl("b", 9), newTestColl("c", 8),
+	}
+	replayed := []storage.Collocation{
+		newTestColl("b", 9.5), newTestColl("a", 9.3), newTestColl("d", 7),
+	}
+	drifts := compareResults(recorded, replayed, DriftTolerance{MaxRankShift: 1, MaxScoreDiff: 0.6})
+	kinds := make(map[string]string)
+	for _, d := range drifts {
+		kinds[d.Collocate] = d.Kind
+	}
+	assert.Equal(
+		t,
+		map[string]string{" nmod← a": "score", " nmod← c": "missing", " nmod← d": "new"},
+		kinds,
+	)
+	assert.Empty(t, compareResults(recorded, recorded, DriftTolerance{}))
+}
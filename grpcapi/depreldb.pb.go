@@ -0,0 +1,1237 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: depreldb.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CollocationsRequest contains a searched lemma along with search
+// options. The options have the same meaning as the respective
+// URL query parameters of the REST API (e.g. text_type = textType).
+// Zero values mean that the option is not set.
+type CollocationsRequest struct {
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	Lemma                    string                 `protobuf:"bytes,1,opt,name=lemma,proto3" json:"lemma,omitempty"`
+	Pos                      string                 `protobuf:"bytes,2,opt,name=pos,proto3" json:"pos,omitempty"`
+	TextType                 string                 `protobuf:"bytes,3,opt,name=text_type,json=textType,proto3" json:"text_type,omitempty"`
+	TextTypeDims             []string               `protobuf:"bytes,4,rep,name=text_type_dims,json=textTypeDims,proto3" json:"text_type_dims,omitempty"`
+	Limit                    int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset                   int32                  `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	SortBy                   string                 `protobuf:"bytes,7,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	Deprels                  []string               `protobuf:"bytes,8,rep,name=deprels,proto3" json:"deprels,omitempty"`
+	ExcludedDeprels          []string               `protobuf:"bytes,9,rep,name=excluded_deprels,json=excludedDeprels,proto3" json:"excluded_deprels,omitempty"`
+	MinCollFreq              int32                  `protobuf:"varint,10,opt,name=min_coll_freq,json=minCollFreq,proto3" json:"min_coll_freq,omitempty"`
+	MaxAvgCollocateDist      float64                `protobuf:"fixed64,11,opt,name=max_avg_collocate_dist,json=maxAvgCollocateDist,proto3" json:"max_avg_collocate_dist,omitempty"`
+	MaxAvgSurfaceDist        float64                `protobuf:"fixed64,12,opt,name=max_avg_surface_dist,json=maxAvgSurfaceDist,proto3" json:"max_avg_surface_dist,omitempty"`
+	RelationDistSpread       float64                `protobuf:"fixed64,13,opt,name=relation_dist_spread,json=relationDistSpread,proto3" json:"relation_dist_spread,omitempty"`
+	MaxScannedPairs          int32                  `protobuf:"varint,14,opt,name=max_scanned_pairs,json=maxScannedPairs,proto3" json:"max_scanned_pairs,omitempty"`
+	SecondOrderLimit         int32                  `protobuf:"varint,15,opt,name=second_order_limit,json=secondOrderLimit,proto3" json:"second_order_limit,omitempty"`
+	CollocateOrder           string                 `protobuf:"bytes,16,opt,name=collocate_order,json=collocateOrder,proto3" json:"collocate_order,omitempty"`
+	LemmaAsHead              *bool                  `protobuf:"varint,17,opt,name=lemma_as_head,json=lemmaAsHead,proto3,oneof" json:"lemma_as_head,omitempty"`
+	PredefinedSearch         string                 `protobuf:"bytes,18,opt,name=predefined_search,json=predefinedSearch,proto3" json:"predefined_search,omitempty"`
+	LemmaSet                 []string               `protobuf:"bytes,19,rep,name=lemma_set,json=lemmaSet,proto3" json:"lemma_set,omitempty"`
+	LemmaPattern             string                 `protobuf:"bytes,20,opt,name=lemma_pattern,json=lemmaPattern,proto3" json:"lemma_pattern,omitempty"`
+	CorpusSize               int64                  `protobuf:"varint,21,opt,name=corpus_size,json=corpusSize,proto3" json:"corpus_size,omitempty"`
+	LabelLang                string                 `protobuf:"bytes,22,opt,name=label_lang,json=labelLang,proto3" json:"label_lang,omitempty"`
+	DeprelGranularity        string                 `protobuf:"bytes,23,opt,name=deprel_granularity,json=deprelGranularity,proto3" json:"deprel_granularity,omitempty"`
+	PrefixSearch             bool                   `protobuf:"varint,24,opt,name=prefix_search,json=prefixSearch,proto3" json:"prefix_search,omitempty"`
+	MergePrefixVariants      bool                   `protobuf:"varint,25,opt,name=merge_prefix_variants,json=mergePrefixVariants,proto3" json:"merge_prefix_variants,omitempty"`
+	IgnoreDiacritics         bool                   `protobuf:"varint,26,opt,name=ignore_diacritics,json=ignoreDiacritics,proto3" json:"ignore_diacritics,omitempty"`
+	WordForm                 bool                   `protobuf:"varint,27,opt,name=word_form,json=wordForm,proto3" json:"word_form,omitempty"`
+	GroupByFeats             bool                   `protobuf:"varint,28,opt,name=group_by_feats,json=groupByFeats,proto3" json:"group_by_feats,omitempty"`
+	LimitPerVariant          bool                   `protobuf:"varint,29,opt,name=limit_per_variant,json=limitPerVariant,proto3" json:"limit_per_variant,omitempty"`
+	CollocateGroupByPos      bool                   `protobuf:"varint,30,opt,name=collocate_group_by_pos,json=collocateGroupByPos,proto3" json:"collocate_group_by_pos,omitempty"`
+	GroupByDeprel            bool                   `protobuf:"varint,31,opt,name=group_by_deprel,json=groupByDeprel,proto3" json:"group_by_deprel,omitempty"`
+	CollocateGroupByTextType bool                   `protobuf:"varint,32,opt,name=collocate_group_by_text_type,json=collocateGroupByTextType,proto3" json:"collocate_group_by_text_type,omitempty"`
+	AdaptiveLimits           bool                   `protobuf:"varint,33,opt,name=adaptive_limits,json=adaptiveLimits,proto3" json:"adaptive_limits,omitempty"`
+	SignedDistance           bool                   `protobuf:"varint,34,opt,name=signed_distance,json=signedDistance,proto3" json:"signed_distance,omitempty"`
+	Cql                      bool                   `protobuf:"varint,35,opt,name=cql,proto3" json:"cql,omitempty"`
+	NoQueryLog               bool                   `protobuf:"varint,36,opt,name=no_query_log,json=noQueryLog,proto3" json:"no_query_log,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *CollocationsRequest) Reset() {
+	*x = CollocationsRequest{}
+	mi := &file_depreldb_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollocationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollocationsRequest) ProtoMessage() {}
+
+func (x *CollocationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollocationsRequest.ProtoReflect.Descriptor instead.
+func (*CollocationsRequest) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CollocationsRequest) GetLemma() string {
+	if x != nil {
+		return x.Lemma
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetPos() string {
+	if x != nil {
+		return x.Pos
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetTextType() string {
+	if x != nil {
+		return x.TextType
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetTextTypeDims() []string {
+	if x != nil {
+		return x.TextTypeDims
+	}
+	return nil
+}
+
+func (x *CollocationsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetDeprels() []string {
+	if x != nil {
+		return x.Deprels
+	}
+	return nil
+}
+
+func (x *CollocationsRequest) GetExcludedDeprels() []string {
+	if x != nil {
+		return x.ExcludedDeprels
+	}
+	return nil
+}
+
+func (x *CollocationsRequest) GetMinCollFreq() int32 {
+	if x != nil {
+		return x.MinCollFreq
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetMaxAvgCollocateDist() float64 {
+	if x != nil {
+		return x.MaxAvgCollocateDist
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetMaxAvgSurfaceDist() float64 {
+	if x != nil {
+		return x.MaxAvgSurfaceDist
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetRelationDistSpread() float64 {
+	if x != nil {
+		return x.RelationDistSpread
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetMaxScannedPairs() int32 {
+	if x != nil {
+		return x.MaxScannedPairs
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetSecondOrderLimit() int32 {
+	if x != nil {
+		return x.SecondOrderLimit
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetCollocateOrder() string {
+	if x != nil {
+		return x.CollocateOrder
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetLemmaAsHead() bool {
+	if x != nil && x.LemmaAsHead != nil {
+		return *x.LemmaAsHead
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetPredefinedSearch() string {
+	if x != nil {
+		return x.PredefinedSearch
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetLemmaSet() []string {
+	if x != nil {
+		return x.LemmaSet
+	}
+	return nil
+}
+
+func (x *CollocationsRequest) GetLemmaPattern() string {
+	if x != nil {
+		return x.LemmaPattern
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetCorpusSize() int64 {
+	if x != nil {
+		return x.CorpusSize
+	}
+	return 0
+}
+
+func (x *CollocationsRequest) GetLabelLang() string {
+	if x != nil {
+		return x.LabelLang
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetDeprelGranularity() string {
+	if x != nil {
+		return x.DeprelGranularity
+	}
+	return ""
+}
+
+func (x *CollocationsRequest) GetPrefixSearch() bool {
+	if x != nil {
+		return x.PrefixSearch
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetMergePrefixVariants() bool {
+	if x != nil {
+		return x.MergePrefixVariants
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetIgnoreDiacritics() bool {
+	if x != nil {
+		return x.IgnoreDiacritics
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetWordForm() bool {
+	if x != nil {
+		return x.WordForm
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetGroupByFeats() bool {
+	if x != nil {
+		return x.GroupByFeats
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetLimitPerVariant() bool {
+	if x != nil {
+		return x.LimitPerVariant
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetCollocateGroupByPos() bool {
+	if x != nil {
+		return x.CollocateGroupByPos
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetGroupByDeprel() bool {
+	if x != nil {
+		return x.GroupByDeprel
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetCollocateGroupByTextType() bool {
+	if x != nil {
+		return x.CollocateGroupByTextType
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetAdaptiveLimits() bool {
+	if x != nil {
+		return x.AdaptiveLimits
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetSignedDistance() bool {
+	if x != nil {
+		return x.SignedDistance
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetCql() bool {
+	if x != nil {
+		return x.Cql
+	}
+	return false
+}
+
+func (x *CollocationsRequest) GetNoQueryLog() bool {
+	if x != nil {
+		return x.NoQueryLog
+	}
+	return false
+}
+
+type CollMember struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Value          string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Pos            string                 `protobuf:"bytes,2,opt,name=pos,proto3" json:"pos,omitempty"`
+	Feats          string                 `protobuf:"bytes,3,opt,name=feats,proto3" json:"feats,omitempty"`
+	PosDescription string                 `protobuf:"bytes,4,opt,name=pos_description,json=posDescription,proto3" json:"pos_description,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CollMember) Reset() {
+	*x = CollMember{}
+	mi := &file_depreldb_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollMember) ProtoMessage() {}
+
+func (x *CollMember) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollMember.ProtoReflect.Descriptor instead.
+func (*CollMember) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CollMember) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *CollMember) GetPos() string {
+	if x != nil {
+		return x.Pos
+	}
+	return ""
+}
+
+func (x *CollMember) GetFeats() string {
+	if x != nil {
+		return x.Feats
+	}
+	return ""
+}
+
+func (x *CollMember) GetPosDescription() string {
+	if x != nil {
+		return x.PosDescription
+	}
+	return ""
+}
+
+type Collocation struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Lemma             *CollMember            `protobuf:"bytes,1,opt,name=lemma,proto3" json:"lemma,omitempty"`
+	Collocate         *CollMember            `protobuf:"bytes,2,opt,name=collocate,proto3" json:"collocate,omitempty"`
+	Deprel            string                 `protobuf:"bytes,3,opt,name=deprel,proto3" json:"deprel,omitempty"`
+	DeprelDescription string                 `protobuf:"bytes,4,opt,name=deprel_description,json=deprelDescription,proto3" json:"deprel_description,omitempty"`
+	Cql               string                 `protobuf:"bytes,5,opt,name=cql,proto3" json:"cql,omitempty"`
+	IsHead            bool                   `protobuf:"varint,6,opt,name=is_head,json=isHead,proto3" json:"is_head,omitempty"`
+	LogDice           float64                `protobuf:"fixed64,7,opt,name=log_dice,json=logDice,proto3" json:"log_dice,omitempty"`
+	TScore            float64                `protobuf:"fixed64,8,opt,name=t_score,json=tScore,proto3" json:"t_score,omitempty"`
+	MutualDist        float64                `protobuf:"fixed64,9,opt,name=mutual_dist,json=mutualDist,proto3" json:"mutual_dist,omitempty"`
+	SurfaceDist       float64                `protobuf:"fixed64,10,opt,name=surface_dist,json=surfaceDist,proto3" json:"surface_dist,omitempty"`
+	Lmi               float64                `protobuf:"fixed64,11,opt,name=lmi,proto3" json:"lmi,omitempty"`
+	LogLikelihood     float64                `protobuf:"fixed64,12,opt,name=log_likelihood,json=logLikelihood,proto3" json:"log_likelihood,omitempty"`
+	RrfScore          float64                `protobuf:"fixed64,13,opt,name=rrf_score,json=rrfScore,proto3" json:"rrf_score,omitempty"`
+	TextType          string                 `protobuf:"bytes,14,opt,name=text_type,json=textType,proto3" json:"text_type,omitempty"`
+	Mi                float64                `protobuf:"fixed64,15,opt,name=mi,proto3" json:"mi,omitempty"`
+	Mi3               float64                `protobuf:"fixed64,16,opt,name=mi3,proto3" json:"mi3,omitempty"`
+	Dice              float64                `protobuf:"fixed64,17,opt,name=dice,proto3" json:"dice,omitempty"`
+	MinSensitivity    float64                `protobuf:"fixed64,18,opt,name=min_sensitivity,json=minSensitivity,proto3" json:"min_sensitivity,omitempty"`
+	CorpusSize        int64                  `protobuf:"varint,19,opt,name=corpus_size,json=corpusSize,proto3" json:"corpus_size,omitempty"`
+	Freq              int64                  `protobuf:"varint,20,opt,name=freq,proto3" json:"freq,omitempty"`
+	LemmaFreq         int64                  `protobuf:"varint,21,opt,name=lemma_freq,json=lemmaFreq,proto3" json:"lemma_freq,omitempty"`
+	CollocateFreq     int64                  `protobuf:"varint,22,opt,name=collocate_freq,json=collocateFreq,proto3" json:"collocate_freq,omitempty"`
+	SecondOrder       []*Collocation         `protobuf:"bytes,23,rep,name=second_order,json=secondOrder,proto3" json:"second_order,omitempty"`
+	DeltaPCollocate   float64                `protobuf:"fixed64,24,opt,name=delta_p_collocate,json=deltaPCollocate,proto3" json:"delta_p_collocate,omitempty"`
+	DeltaPNode        float64                `protobuf:"fixed64,25,opt,name=delta_p_node,json=deltaPNode,proto3" json:"delta_p_node,omitempty"`
+	// frequencies normalized to instances per million
+	// tokens (with respect to corpus_size)
+	FreqIpm          float64 `protobuf:"fixed64,26,opt,name=freq_ipm,json=freqIpm,proto3" json:"freq_ipm,omitempty"`
+	LemmaFreqIpm     float64 `protobuf:"fixed64,27,opt,name=lemma_freq_ipm,json=lemmaFreqIpm,proto3" json:"lemma_freq_ipm,omitempty"`
+	CollocateFreqIpm float64 `protobuf:"fixed64,28,opt,name=collocate_freq_ipm,json=collocateFreqIpm,proto3" json:"collocate_freq_ipm,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Collocation) Reset() {
+	*x = Collocation{}
+	mi := &file_depreldb_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Collocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Collocation) ProtoMessage() {}
+
+func (x *Collocation) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Collocation.ProtoReflect.Descriptor instead.
+func (*Collocation) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Collocation) GetLemma() *CollMember {
+	if x != nil {
+		return x.Lemma
+	}
+	return nil
+}
+
+func (x *Collocation) GetCollocate() *CollMember {
+	if x != nil {
+		return x.Collocate
+	}
+	return nil
+}
+
+func (x *Collocation) GetDeprel() string {
+	if x != nil {
+		return x.Deprel
+	}
+	return ""
+}
+
+func (x *Collocation) GetDeprelDescription() string {
+	if x != nil {
+		return x.DeprelDescription
+	}
+	return ""
+}
+
+func (x *Collocation) GetCql() string {
+	if x != nil {
+		return x.Cql
+	}
+	return ""
+}
+
+func (x *Collocation) GetIsHead() bool {
+	if x != nil {
+		return x.IsHead
+	}
+	return false
+}
+
+func (x *Collocation) GetLogDice() float64 {
+	if x != nil {
+		return x.LogDice
+	}
+	return 0
+}
+
+func (x *Collocation) GetTScore() float64 {
+	if x != nil {
+		return x.TScore
+	}
+	return 0
+}
+
+func (x *Collocation) GetMutualDist() float64 {
+	if x != nil {
+		return x.MutualDist
+	}
+	return 0
+}
+
+func (x *Collocation) GetSurfaceDist() float64 {
+	if x != nil {
+		return x.SurfaceDist
+	}
+	return 0
+}
+
+func (x *Collocation) GetLmi() float64 {
+	if x != nil {
+		return x.Lmi
+	}
+	return 0
+}
+
+func (x *Collocation) GetLogLikelihood() float64 {
+	if x != nil {
+		return x.LogLikelihood
+	}
+	return 0
+}
+
+func (x *Collocation) GetRrfScore() float64 {
+	if x != nil {
+		return x.RrfScore
+	}
+	return 0
+}
+
+func (x *Collocation) GetTextType() string {
+	if x != nil {
+		return x.TextType
+	}
+	return ""
+}
+
+func (x *Collocation) GetMi() float64 {
+	if x != nil {
+		return x.Mi
+	}
+	return 0
+}
+
+func (x *Collocation) GetMi3() float64 {
+	if x != nil {
+		return x.Mi3
+	}
+	return 0
+}
+
+func (x *Collocation) GetDice() float64 {
+	if x != nil {
+		return x.Dice
+	}
+	return 0
+}
+
+func (x *Collocation) GetMinSensitivity() float64 {
+	if x != nil {
+		return x.MinSensitivity
+	}
+	return 0
+}
+
+func (x *Collocation) GetCorpusSize() int64 {
+	if x != nil {
+		return x.CorpusSize
+	}
+	return 0
+}
+
+func (x *Collocation) GetFreq() int64 {
+	if x != nil {
+		return x.Freq
+	}
+	return 0
+}
+
+func (x *Collocation) GetLemmaFreq() int64 {
+	if x != nil {
+		return x.LemmaFreq
+	}
+	return 0
+}
+
+func (x *Collocation) GetCollocateFreq() int64 {
+	if x != nil {
+		return x.CollocateFreq
+	}
+	return 0
+}
+
+func (x *Collocation) GetSecondOrder() []*Collocation {
+	if x != nil {
+		return x.SecondOrder
+	}
+	return nil
+}
+
+func (x *Collocation) GetDeltaPCollocate() float64 {
+	if x != nil {
+		return x.DeltaPCollocate
+	}
+	return 0
+}
+
+func (x *Collocation) GetDeltaPNode() float64 {
+	if x != nil {
+		return x.DeltaPNode
+	}
+	return 0
+}
+
+func (x *Collocation) GetFreqIpm() float64 {
+	if x != nil {
+		return x.FreqIpm
+	}
+	return 0
+}
+
+func (x *Collocation) GetLemmaFreqIpm() float64 {
+	if x != nil {
+		return x.LemmaFreqIpm
+	}
+	return 0
+}
+
+func (x *Collocation) GetCollocateFreqIpm() float64 {
+	if x != nil {
+		return x.CollocateFreqIpm
+	}
+	return 0
+}
+
+type LemmaInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lemma         string                 `protobuf:"bytes,1,opt,name=lemma,proto3" json:"lemma,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LemmaInfoRequest) Reset() {
+	*x = LemmaInfoRequest{}
+	mi := &file_depreldb_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LemmaInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LemmaInfoRequest) ProtoMessage() {}
+
+func (x *LemmaInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LemmaInfoRequest.ProtoReflect.Descriptor instead.
+func (*LemmaInfoRequest) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LemmaInfoRequest) GetLemma() string {
+	if x != nil {
+		return x.Lemma
+	}
+	return ""
+}
+
+type LemmaInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lemma         string                 `protobuf:"bytes,1,opt,name=lemma,proto3" json:"lemma,omitempty"`
+	Exists        bool                   `protobuf:"varint,2,opt,name=exists,proto3" json:"exists,omitempty"`
+	Freq          int64                  `protobuf:"varint,3,opt,name=freq,proto3" json:"freq,omitempty"`
+	PosFreqs      map[string]int64       `protobuf:"bytes,4,rep,name=pos_freqs,json=posFreqs,proto3" json:"pos_freqs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LemmaInfo) Reset() {
+	*x = LemmaInfo{}
+	mi := &file_depreldb_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LemmaInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LemmaInfo) ProtoMessage() {}
+
+func (x *LemmaInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LemmaInfo.ProtoReflect.Descriptor instead.
+func (*LemmaInfo) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LemmaInfo) GetLemma() string {
+	if x != nil {
+		return x.Lemma
+	}
+	return ""
+}
+
+func (x *LemmaInfo) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+func (x *LemmaInfo) GetFreq() int64 {
+	if x != nil {
+		return x.Freq
+	}
+	return 0
+}
+
+func (x *LemmaInfo) GetPosFreqs() map[string]int64 {
+	if x != nil {
+		return x.PosFreqs
+	}
+	return nil
+}
+
+type MetadataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetadataRequest) Reset() {
+	*x = MetadataRequest{}
+	mi := &file_depreldb_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataRequest) ProtoMessage() {}
+
+func (x *MetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataRequest.ProtoReflect.Descriptor instead.
+func (*MetadataRequest) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{5}
+}
+
+type TextTypeLabel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextTypeLabel) Reset() {
+	*x = TextTypeLabel{}
+	mi := &file_depreldb_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextTypeLabel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextTypeLabel) ProtoMessage() {}
+
+func (x *TextTypeLabel) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextTypeLabel.ProtoReflect.Descriptor instead.
+func (*TextTypeLabel) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TextTypeLabel) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *TextTypeLabel) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+type Metadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// corpus_size does not include text types restricted
+	// for the client (the size is estimated in such case)
+	CorpusSize  int64  `protobuf:"varint,1,opt,name=corpus_size,json=corpusSize,proto3" json:"corpus_size,omitempty"`
+	ProfileName string `protobuf:"bytes,2,opt,name=profile_name,json=profileName,proto3" json:"profile_name,omitempty"`
+	NumLemmas   int64  `protobuf:"varint,3,opt,name=num_lemmas,json=numLemmas,proto3" json:"num_lemmas,omitempty"`
+	// num_lemma_freqs and num_coll_freqs are not provided (i.e. 0)
+	// to clients without access to restricted text types
+	NumLemmaFreqs int64    `protobuf:"varint,4,opt,name=num_lemma_freqs,json=numLemmaFreqs,proto3" json:"num_lemma_freqs,omitempty"`
+	NumCollFreqs  int64    `protobuf:"varint,5,opt,name=num_coll_freqs,json=numCollFreqs,proto3" json:"num_coll_freqs,omitempty"`
+	NumWordForms  int64    `protobuf:"varint,6,opt,name=num_word_forms,json=numWordForms,proto3" json:"num_word_forms,omitempty"`
+	MinPairFreq   int32    `protobuf:"varint,7,opt,name=min_pair_freq,json=minPairFreq,proto3" json:"min_pair_freq,omitempty"`
+	PairWeighting string   `protobuf:"bytes,8,opt,name=pair_weighting,json=pairWeighting,proto3" json:"pair_weighting,omitempty"`
+	PathPolicy    string   `protobuf:"bytes,9,opt,name=path_policy,json=pathPolicy,proto3" json:"path_policy,omitempty"`
+	Features      []string `protobuf:"bytes,10,rep,name=features,proto3" json:"features,omitempty"`
+	MorphFeats    []string `protobuf:"bytes,11,rep,name=morph_feats,json=morphFeats,proto3" json:"morph_feats,omitempty"`
+	// text_types contains display names of text types available
+	// to the client in their display order
+	TextTypes     []*TextTypeLabel `protobuf:"bytes,12,rep,name=text_types,json=textTypes,proto3" json:"text_types,omitempty"`
+	Deprels       []string         `protobuf:"bytes,13,rep,name=deprels,proto3" json:"deprels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_depreldb_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_depreldb_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_depreldb_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Metadata) GetCorpusSize() int64 {
+	if x != nil {
+		return x.CorpusSize
+	}
+	return 0
+}
+
+func (x *Metadata) GetProfileName() string {
+	if x != nil {
+		return x.ProfileName
+	}
+	return ""
+}
+
+func (x *Metadata) GetNumLemmas() int64 {
+	if x != nil {
+		return x.NumLemmas
+	}
+	return 0
+}
+
+func (x *Metadata) GetNumLemmaFreqs() int64 {
+	if x != nil {
+		return x.NumLemmaFreqs
+	}
+	return 0
+}
+
+func (x *Metadata) GetNumCollFreqs() int64 {
+	if x != nil {
+		return x.NumCollFreqs
+	}
+	return 0
+}
+
+func (x *Metadata) GetNumWordForms() int64 {
+	if x != nil {
+		return x.NumWordForms
+	}
+	return 0
+}
+
+func (x *Metadata) GetMinPairFreq() int32 {
+	if x != nil {
+		return x.MinPairFreq
+	}
+	return 0
+}
+
+func (x *Metadata) GetPairWeighting() string {
+	if x != nil {
+		return x.PairWeighting
+	}
+	return ""
+}
+
+func (x *Metadata) GetPathPolicy() string {
+	if x != nil {
+		return x.PathPolicy
+	}
+	return ""
+}
+
+func (x *Metadata) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *Metadata) GetMorphFeats() []string {
+	if x != nil {
+		return x.MorphFeats
+	}
+	return nil
+}
+
+func (x *Metadata) GetTextTypes() []*TextTypeLabel {
+	if x != nil {
+		return x.TextTypes
+	}
+	return nil
+}
+
+func (x *Metadata) GetDeprels() []string {
+	if x != nil {
+		return x.Deprels
+	}
+	return nil
+}
+
+var File_depreldb_proto protoreflect.FileDescriptor
+
+const file_depreldb_proto_rawDesc = "" +
+	"\n" +
+	"\x0edepreldb.proto\x12\vdepreldb.v1\"\xfc\n" +
+	"\n" +
+	"\x13CollocationsRequest\x12\x14\n" +
+	"\x05lemma\x18\x01 \x01(\tR\x05lemma\x12\x10\n" +
+	"\x03pos\x18\x02 \x01(\tR\x03pos\x12\x1b\n" +
+	"\ttext_type\x18\x03 \x01(\tR\btextType\x12$\n" +
+	"\x0etext_type_dims\x18\x04 \x03(\tR\ftextTypeDims\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x06 \x01(\x05R\x06offset\x12\x17\n" +
+	"\asort_by\x18\a \x01(\tR\x06sortBy\x12\x18\n" +
+	"\adeprels\x18\b \x03(\tR\adeprels\x12)\n" +
+	"\x10excluded_deprels\x18\t \x03(\tR\x0fexcludedDeprels\x12\"\n" +
+	"\rmin_coll_freq\x18\n" +
+	" \x01(\x05R\vminCollFreq\x123\n" +
+	"\x16max_avg_collocate_dist\x18\v \x01(\x01R\x13maxAvgCollocateDist\x12/\n" +
+	"\x14max_avg_surface_dist\x18\f \x01(\x01R\x11maxAvgSurfaceDist\x120\n" +
+	"\x14relation_dist_spread\x18\r \x01(\x01R\x12relationDistSpread\x12*\n" +
+	"\x11max_scanned_pairs\x18\x0e \x01(\x05R\x0fmaxScannedPairs\x12,\n" +
+	"\x12second_order_limit\x18\x0f \x01(\x05R\x10secondOrderLimit\x12'\n" +
+	"\x0fcollocate_order\x18\x10 \x01(\tR\x0ecollocateOrder\x12'\n" +
+	"\rlemma_as_head\x18\x11 \x01(\bH\x00R\vlemmaAsHead\x88\x01\x01\x12+\n" +
+	"\x11predefined_search\x18\x12 \x01(\tR\x10predefinedSearch\x12\x1b\n" +
+	"\tlemma_set\x18\x13 \x03(\tR\blemmaSet\x12#\n" +
+	"\rlemma_pattern\x18\x14 \x01(\tR\flemmaPattern\x12\x1f\n" +
+	"\vcorpus_size\x18\x15 \x01(\x03R\n" +
+	"corpusSize\x12\x1d\n" +
+	"\n" +
+	"label_lang\x18\x16 \x01(\tR\tlabelLang\x12-\n" +
+	"\x12deprel_granularity\x18\x17 \x01(\tR\x11deprelGranularity\x12#\n" +
+	"\rprefix_search\x18\x18 \x01(\bR\fprefixSearch\x122\n" +
+	"\x15merge_prefix_variants\x18\x19 \x01(\bR\x13mergePrefixVariants\x12+\n" +
+	"\x11ignore_diacritics\x18\x1a \x01(\bR\x10ignoreDiacritics\x12\x1b\n" +
+	"\tword_form\x18\x1b \x01(\bR\bwordForm\x12$\n" +
+	"\x0egroup_by_feats\x18\x1c \x01(\bR\fgroupByFeats\x12*\n" +
+	"\x11limit_per_variant\x18\x1d \x01(\bR\x0flimitPerVariant\x123\n" +
+	"\x16collocate_group_by_pos\x18\x1e \x01(\bR\x13collocateGroupByPos\x12&\n" +
+	"\x0fgroup_by_deprel\x18\x1f \x01(\bR\rgroupByDeprel\x12>\n" +
+	"\x1ccollocate_group_by_text_type\x18  \x01(\bR\x18collocateGroupByTextType\x12'\n" +
+	"\x0fadaptive_limits\x18! \x01(\bR\x0eadaptiveLimits\x12'\n" +
+	"\x0fsigned_distance\x18\" \x01(\bR\x0esignedDistance\x12\x10\n" +
+	"\x03cql\x18# \x01(\bR\x03cql\x12 \n" +
+	"\fno_query_log\x18$ \x01(\bR\n" +
+	"noQueryLogB\x10\n" +
+	"\x0e_lemma_as_head\"s\n" +
+	"\n" +
+	"CollMember\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x10\n" +
+	"\x03pos\x18\x02 \x01(\tR\x03pos\x12\x14\n" +
+	"\x05feats\x18\x03 \x01(\tR\x05feats\x12'\n" +
+	"\x0fpos_description\x18\x04 \x01(\tR\x0eposDescription\"\xa4\a\n" +
+	"\vCollocation\x12-\n" +
+	"\x05lemma\x18\x01 \x01(\v2\x17.depreldb.v1.CollMemberR\x05lemma\x125\n" +
+	"\tcollocate\x18\x02 \x01(\v2\x17.depreldb.v1.CollMemberR\tcollocate\x12\x16\n" +
+	"\x06deprel\x18\x03 \x01(\tR\x06deprel\x12-\n" +
+	"\x12deprel_description\x18\x04 \x01(\tR\x11deprelDescription\x12\x10\n" +
+	"\x03cql\x18\x05 \x01(\tR\x03cql\x12\x17\n" +
+	"\ais_head\x18\x06 \x01(\bR\x06isHead\x12\x19\n" +
+	"\blog_dice\x18\a \x01(\x01R\alogDice\x12\x17\n" +
+	"\at_score\x18\b \x01(\x01R\x06tScore\x12\x1f\n" +
+	"\vmutual_dist\x18\t \x01(\x01R\n" +
+	"mutualDist\x12!\n" +
+	"\fsurface_dist\x18\n" +
+	" \x01(\x01R\vsurfaceDist\x12\x10\n" +
+	"\x03lmi\x18\v \x01(\x01R\x03lmi\x12%\n" +
+	"\x0elog_likelihood\x18\f \x01(\x01R\rlogLikelihood\x12\x1b\n" +
+	"\trrf_score\x18\r \x01(\x01R\brrfScore\x12\x1b\n" +
+	"\ttext_type\x18\x0e \x01(\tR\btextType\x12\x0e\n" +
+	"\x02mi\x18\x0f \x01(\x01R\x02mi\x12\x10\n" +
+	"\x03mi3\x18\x10 \x01(\x01R\x03mi3\x12\x12\n" +
+	"\x04dice\x18\x11 \x01(\x01R\x04dice\x12'\n" +
+	"\x0fmin_sensitivity\x18\x12 \x01(\x01R\x0eminSensitivity\x12\x1f\n" +
+	"\vcorpus_size\x18\x13 \x01(\x03R\n" +
+	"corpusSize\x12\x12\n" +
+	"\x04freq\x18\x14 \x01(\x03R\x04freq\x12\x1d\n" +
+	"\n" +
+	"lemma_freq\x18\x15 \x01(\x03R\tlemmaFreq\x12%\n" +
+	"\x0ecollocate_freq\x18\x16 \x01(\x03R\rcollocateFreq\x12;\n" +
+	"\fsecond_order\x18\x17 \x03(\v2\x18.depreldb.v1.CollocationR\vsecondOrder\x12*\n" +
+	"\x11delta_p_collocate\x18\x18 \x01(\x01R\x0fdeltaPCollocate\x12 \n" +
+	"\fdelta_p_node\x18\x19 \x01(\x01R\n" +
+	"deltaPNode\x12\x19\n" +
+	"\bfreq_ipm\x18\x1a \x01(\x01R\afreqIpm\x12$\n" +
+	"\x0elemma_freq_ipm\x18\x1b \x01(\x01R\flemmaFreqIpm\x12,\n" +
+	"\x12collocate_freq_ipm\x18\x1c \x01(\x01R\x10collocateFreqIpm\"(\n" +
+	"\x10LemmaInfoRequest\x12\x14\n" +
+	"\x05lemma\x18\x01 \x01(\tR\x05lemma\"\xcd\x01\n" +
+	"\tLemmaInfo\x12\x14\n" +
+	"\x05lemma\x18\x01 \x01(\tR\x05lemma\x12\x16\n" +
+	"\x06exists\x18\x02 \x01(\bR\x06exists\x12\x12\n" +
+	"\x04freq\x18\x03 \x01(\x03R\x04freq\x12A\n" +
+	"\tpos_freqs\x18\x04 \x03(\v2$.depreldb.v1.LemmaInfo.PosFreqsEntryR\bposFreqs\x1a;\n" +
+	"\rPosFreqsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x11\n" +
+	"\x0fMetadataRequest\"H\n" +
+	"\rTextTypeLabel\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\"\xdf\x03\n" +
+	"\bMetadata\x12\x1f\n" +
+	"\vcorpus_size\x18\x01 \x01(\x03R\n" +
+	"corpusSize\x12!\n" +
+	"\fprofile_name\x18\x02 \x01(\tR\vprofileName\x12\x1d\n" +
+	"\n" +
+	"num_lemmas\x18\x03 \x01(\x03R\tnumLemmas\x12&\n" +
+	"\x0fnum_lemma_freqs\x18\x04 \x01(\x03R\rnumLemmaFreqs\x12$\n" +
+	"\x0enum_coll_freqs\x18\x05 \x01(\x03R\fnumCollFreqs\x12$\n" +
+	"\x0enum_word_forms\x18\x06 \x01(\x03R\fnumWordForms\x12\"\n" +
+	"\rmin_pair_freq\x18\a \x01(\x05R\vminPairFreq\x12%\n" +
+	"\x0epair_weighting\x18\b \x01(\tR\rpairWeighting\x12\x1f\n" +
+	"\vpath_policy\x18\t \x01(\tR\n" +
+	"pathPolicy\x12\x1a\n" +
+	"\bfeatures\x18\n" +
+	" \x03(\tR\bfeatures\x12\x1f\n" +
+	"\vmorph_feats\x18\v \x03(\tR\n" +
+	"morphFeats\x129\n" +
+	"\n" +
+	"text_types\x18\f \x03(\v2\x1a.depreldb.v1.TextTypeLabelR\ttextTypes\x12\x18\n" +
+	"\adeprels\x18\r \x03(\tR\adeprels2\xe6\x01\n" +
+	"\bDepreldb\x12O\n" +
+	"\x0fGetCollocations\x12 .depreldb.v1.CollocationsRequest\x1a\x18.depreldb.v1.Collocation0\x01\x12E\n" +
+	"\fGetLemmaInfo\x12\x1d.depreldb.v1.LemmaInfoRequest\x1a\x16.depreldb.v1.LemmaInfo\x12B\n" +
+	"\vGetMetadata\x12\x1c.depreldb.v1.MetadataRequest\x1a\x15.depreldb.v1.MetadataB&Z$github.com/czcorpus/depreldb/grpcapib\x06proto3"
+
+var (
+	file_depreldb_proto_rawDescOnce sync.Once
+	file_depreldb_proto_rawDescData []byte
+)
+
+func file_depreldb_proto_rawDescGZIP() []byte {
+	file_depreldb_proto_rawDescOnce.Do(func() {
+		file_depreldb_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_depreldb_proto_rawDesc), len(file_depreldb_proto_rawDesc)))
+	})
+	return file_depreldb_proto_rawDescData
+}
+
+var file_depreldb_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_depreldb_proto_goTypes = []any{
+	(*CollocationsRequest)(nil), // 0: depreldb.v1.CollocationsRequest
+	(*CollMember)(nil),          // 1: depreldb.v1.CollMember
+	(*Collocation)(nil),         // 2: depreldb.v1.Collocation
+	(*LemmaInfoRequest)(nil),    // 3: depreldb.v1.LemmaInfoRequest
+	(*LemmaInfo)(nil),           // 4: depreldb.v1.LemmaInfo
+	(*MetadataRequest)(nil),     // 5: depreldb.v1.MetadataRequest
+	(*TextTypeLabel)(nil),       // 6: depreldb.v1.TextTypeLabel
+	(*Metadata)(nil),            // 7: depreldb.v1.Metadata
+	nil,                         // 8: depreldb.v1.LemmaInfo.PosFreqsEntry
+}
+var file_depreldb_proto_depIdxs = []int32{
+	1, // 0: depreldb.v1.Collocation.lemma:type_name -> depreldb.v1.CollMember
+	1, // 1: depreldb.v1.Collocation.collocate:type_name -> depreldb.v1.CollMember
+	2, // 2: depreldb.v1.Collocation.second_order:type_name -> depreldb.v1.Collocation
+	8, // 3: depreldb.v1.LemmaInfo.pos_freqs:type_name -> depreldb.v1.LemmaInfo.PosFreqsEntry
+	6, // 4: depreldb.v1.Metadata.text_types:type_name -> depreldb.v1.TextTypeLabel
+	0, // 5: depreldb.v1.Depreldb.GetCollocations:input_type -> depreldb.v1.CollocationsRequest
+	3, // 6: depreldb.v1.Depreldb.GetLemmaInfo:input_type -> depreldb.v1.LemmaInfoRequest
+	5, // 7: depreldb.v1.Depreldb.GetMetadata:input_type -> depreldb.v1.MetadataRequest
+	2, // 8: depreldb.v1.Depreldb.GetCollocations:output_type -> depreldb.v1.Collocation
+	4, // 9: depreldb.v1.Depreldb.GetLemmaInfo:output_type -> depreldb.v1.LemmaInfo
+	7, // 10: depreldb.v1.Depreldb.GetMetadata:output_type -> depreldb.v1.Metadata
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_depreldb_proto_init() }
+func file_depreldb_proto_init() {
+	if File_depreldb_proto != nil {
+		return
+	}
+	file_depreldb_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_depreldb_proto_rawDesc), len(file_depreldb_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_depreldb_proto_goTypes,
+		DependencyIndexes: file_depreldb_proto_depIdxs,
+		MessageInfos:      file_depreldb_proto_msgTypes,
+	}.Build()
+	File_depreldb_proto = out.File
+	file_depreldb_proto_goTypes = nil
+	file_depreldb_proto_depIdxs = nil
+}
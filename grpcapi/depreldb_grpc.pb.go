@@ -0,0 +1,237 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: depreldb.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Depreldb_GetCollocations_FullMethodName = "/depreldb.v1.Depreldb/GetCollocations"
+	Depreldb_GetLemmaInfo_FullMethodName    = "/depreldb.v1.Depreldb/GetLemmaInfo"
+	Depreldb_GetMetadata_FullMethodName     = "/depreldb.v1.Depreldb/GetMetadata"
+)
+
+// DepreldbClient is the client API for Depreldb service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Depreldb provides collocation queries over a single database.
+// Clients with a valid API key (sent in the "x-api-key" metadata)
+// can search also in restricted text types.
+type DepreldbClient interface {
+	// GetCollocations streams found collocations in the order
+	// of their ranking. The number of all the found collocations
+	// (regardless of offset and limit) is sent in the "x-total-count"
+	// header metadata.
+	GetCollocations(ctx context.Context, in *CollocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Collocation], error)
+	// GetLemmaInfo provides frequencies of a lemma
+	GetLemmaInfo(ctx context.Context, in *LemmaInfoRequest, opts ...grpc.CallOption) (*LemmaInfo, error)
+	// GetMetadata provides basic information about the database
+	GetMetadata(ctx context.Context, in *MetadataRequest, opts ...grpc.CallOption) (*Metadata, error)
+}
+
+type depreldbClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDepreldbClient(cc grpc.ClientConnInterface) DepreldbClient {
+	return &depreldbClient{cc}
+}
+
+func (c *depreldbClient) GetCollocations(ctx context.Context, in *CollocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Collocation], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Depreldb_ServiceDesc.Streams[0], Depreldb_GetCollocations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CollocationsRequest, Collocation]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Depreldb_GetCollocationsClient = grpc.ServerStreamingClient[Collocation]
+
+func (c *depreldbClient) GetLemmaInfo(ctx context.Context, in *LemmaInfoRequest, opts ...grpc.CallOption) (*LemmaInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LemmaInfo)
+	err := c.cc.Invoke(ctx, Depreldb_GetLemmaInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *depreldbClient) GetMetadata(ctx context.Context, in *MetadataRequest, opts ...grpc.CallOption) (*Metadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Metadata)
+	err := c.cc.Invoke(ctx, Depreldb_GetMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DepreldbServer is the server API for Depreldb service.
+// All implementations must embed UnimplementedDepreldbServer
+// for forward compatibility.
+//
+// Depreldb provides collocation queries over a single database.
+// Clients with a valid API key (sent in the "x-api-key" metadata)
+// can search also in restricted text types.
+type DepreldbServer interface {
+	// GetCollocations streams found collocations in the order
+	// of their ranking. The number of all the found collocations
+	// (regardless of offset and limit) is sent in the "x-total-count"
+	// header metadata.
+	GetCollocations(*CollocationsRequest, grpc.ServerStreamingServer[Collocation]) error
+	// GetLemmaInfo provides frequencies of a lemma
+	GetLemmaInfo(context.Context, *LemmaInfoRequest) (*LemmaInfo, error)
+	// GetMetadata provides basic information about the database
+	GetMetadata(context.Context, *MetadataRequest) (*Metadata, error)
+	mustEmbedUnimplementedDepreldbServer()
+}
+
+// UnimplementedDepreldbServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDepreldbServer struct{}
+
+func (UnimplementedDepreldbServer) GetCollocations(*CollocationsRequest, grpc.ServerStreamingServer[Collocation]) error {
+	return status.Errorf(codes.Unimplemented, "method GetCollocations not implemented")
+}
+func (UnimplementedDepreldbServer) GetLemmaInfo(context.Context, *LemmaInfoRequest) (*LemmaInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLemmaInfo not implemented")
+}
+func (UnimplementedDepreldbServer) GetMetadata(context.Context, *MetadataRequest) (*Metadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetadata not implemented")
+}
+func (UnimplementedDepreldbServer) mustEmbedUnimplementedDepreldbServer() {}
+func (UnimplementedDepreldbServer) testEmbeddedByValue()                  {}
+
+// UnsafeDepreldbServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DepreldbServer will
+// result in compilation errors.
+type UnsafeDepreldbServer interface {
+	mustEmbedUnimplementedDepreldbServer()
+}
+
+func RegisterDepreldbServer(s grpc.ServiceRegistrar, srv DepreldbServer) {
+	// If the following call pancis, it indicates UnimplementedDepreldbServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Depreldb_ServiceDesc, srv)
+}
+
+func _Depreldb_GetCollocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CollocationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DepreldbServer).GetCollocations(m, &grpc.GenericServerStream[CollocationsRequest, Collocation]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Depreldb_GetCollocationsServer = grpc.ServerStreamingServer[Collocation]
+
+func _Depreldb_GetLemmaInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LemmaInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DepreldbServer).GetLemmaInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Depreldb_GetLemmaInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DepreldbServer).GetLemmaInfo(ctx, req.(*LemmaInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Depreldb_GetMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DepreldbServer).GetMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Depreldb_GetMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DepreldbServer).GetMetadata(ctx, req.(*MetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Depreldb_ServiceDesc is the grpc.ServiceDesc for Depreldb service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Depreldb_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "depreldb.v1.Depreldb",
+	HandlerType: (*DepreldbServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLemmaInfo",
+			Handler:    _Depreldb_GetLemmaInfo_Handler,
+		},
+		{
+			MethodName: "GetMetadata",
+			Handler:    _Depreldb_GetMetadata_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetCollocations",
+			Handler:       _Depreldb_GetCollocations_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "depreldb.proto",
+}
@@ -0,0 +1,357 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpcapi provides a gRPC service for collocation queries
+// (see depreldb.proto). The Go code of the messages and of the service
+// stubs is generated by protoc.
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative depreldb.proto
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetadataAPIKey is a request metadata key of a client API key
+	MetadataAPIKey = "x-api-key"
+
+	// MetadataTotalCount is a response header metadata key
+	// of the number of all the found collocations
+	MetadataTotalCount = "x-total-count"
+)
+
+// CollocationStreamer is a collocation provider able to stream
+// search results (implemented by scoll.Calculator)
+type CollocationStreamer interface {
+	scoll.CollocationProvider
+	StreamCollocations(
+		ctx context.Context,
+		lemma string,
+		options ...func(opts *scoll.CalculationOptions),
+	) iter.Seq2[storage.Collocation, error]
+	GetCorpusSize(options ...func(opts *scoll.CalculationOptions)) (int64, bool, error)
+}
+
+var _ CollocationStreamer = (*scoll.Calculator)(nil)
+
+// Server implements the Depreldb gRPC service
+type Server struct {
+	UnimplementedDepreldbServer
+	calc     CollocationStreamer
+	metadata storage.Metadata
+	apiKeys  []string
+
+	// queryTimeout limits the time a single search may take
+	// (0 = no limit besides the client cancelling the call)
+	queryTimeout time.Duration
+}
+
+// NewServer creates a gRPC service searching via calc. The metadata
+// are those of the searched database (see storage.DB.DatasetMetadata).
+func NewServer(
+	calc CollocationStreamer,
+	metadata storage.Metadata,
+	apiKeys []string,
+	queryTimeout time.Duration,
+) *Server {
+	return &Server{
+		calc:         calc,
+		metadata:     metadata,
+		apiKeys:      apiKeys,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Register registers the service with a gRPC server
+func (srv *Server) Register(s *grpc.Server) {
+	RegisterDepreldbServer(s, srv)
+}
+
+func (srv *Server) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if srv.queryTimeout > 0 {
+		return context.WithTimeout(ctx, srv.queryTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// accessOptions returns options derived from client authorization
+// (i.e. access to restricted text types for clients with a valid API key)
+func (srv *Server) accessOptions(ctx context.Context) []func(opts *scoll.CalculationOptions) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range md.Get(MetadataAPIKey) {
+		if key != "" && slices.Contains(srv.apiKeys, key) {
+			return []func(opts *scoll.CalculationOptions){scoll.WithRestrictedTextTypesAccess()}
+		}
+	}
+	return []func(opts *scoll.CalculationOptions){}
+}
+
+// statusError maps search errors to proper gRPC status codes
+func statusError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, storage.ErrInvalidCorpusSize),
+		errors.Is(err, storage.ErrInvalidOffset),
+		errors.Is(err, storage.ErrInvalidResultField),
+		errors.Is(err, storage.ErrUnknownFilterValue),
+		errors.Is(err, storage.ErrInvalidLemmaPattern):
+		code = codes.InvalidArgument
+	case errors.Is(err, storage.ErrFeatureUnavailable):
+		code = codes.FailedPrecondition
+	case errors.Is(err, storage.ErrScanQueueTimeout):
+		code = codes.Unavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+	if code == codes.Internal {
+		log.Error().Err(err).Msg("failed to handle gRPC request")
+	}
+	return status.Error(code, err.Error())
+}
+
+func setParam(values url.Values, name, v string) {
+	if v != "" {
+		values.Set(name, v)
+	}
+}
+
+func setIntParam(values url.Values, name string, v int64) {
+	if v != 0 {
+		values.Set(name, strconv.FormatInt(v, 10))
+	}
+}
+
+func setFloatParam(values url.Values, name string, v float64) {
+	if v != 0 {
+		values.Set(name, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+}
+
+func setBoolParam(values url.Values, name string, v bool) {
+	if v {
+		values.Set(name, "1")
+	}
+}
+
+// asURLValues encodes the request options as the REST API query
+// parameters so they are validated and applied the same way
+// (see scoll.OptionsFromURLValues)
+func (req *CollocationsRequest) asURLValues() url.Values {
+	ans := make(url.Values)
+	setParam(ans, scoll.ParamPoS, req.Pos)
+	setParam(ans, scoll.ParamTextType, req.TextType)
+	ans[scoll.ParamTextTypeDim] = req.TextTypeDims
+	setIntParam(ans, scoll.ParamLimit, int64(req.Limit))
+	setIntParam(ans, scoll.ParamOffset, int64(req.Offset))
+	setParam(ans, scoll.ParamSortBy, req.SortBy)
+	ans[scoll.ParamDeprel] = req.Deprels
+	ans[scoll.ParamExcludedDeprel] = req.ExcludedDeprels
+	setIntParam(ans, scoll.ParamMinCollFreq, int64(req.MinCollFreq))
+	setFloatParam(ans, scoll.ParamMaxAvgCollocateDist, req.MaxAvgCollocateDist)
+	setFloatParam(ans, scoll.ParamMaxAvgSurfaceDist, req.MaxAvgSurfaceDist)
+	setFloatParam(ans, scoll.ParamRelationDistSpread, req.RelationDistSpread)
+	setIntParam(ans, scoll.ParamMaxScannedPairs, int64(req.MaxScannedPairs))
+	setIntParam(ans, scoll.ParamSecondOrderLimit, int64(req.SecondOrderLimit))
+	setParam(ans, scoll.ParamCollocateOrder, req.CollocateOrder)
+	if req.LemmaAsHead != nil {
+		ans.Set(scoll.ParamLemmaAsHead, strconv.FormatBool(*req.LemmaAsHead))
+	}
+	setParam(ans, scoll.ParamRelation, req.PredefinedSearch)
+	ans[scoll.ParamLemmaSet] = req.LemmaSet
+	setParam(ans, scoll.ParamLemmaPattern, req.LemmaPattern)
+	setIntParam(ans, scoll.ParamCorpusSize, req.CorpusSize)
+	setParam(ans, scoll.ParamLabelLang, req.LabelLang)
+	setParam(ans, scoll.ParamDeprelGranularity, req.DeprelGranularity)
+	setBoolParam(ans, scoll.ParamPrefixSearch, req.PrefixSearch)
+	setBoolParam(ans, scoll.ParamMergePrefixVariants, req.MergePrefixVariants)
+	setBoolParam(ans, scoll.ParamIgnoreDiacritics, req.IgnoreDiacritics)
+	setBoolParam(ans, scoll.ParamSearchByWordForm, req.WordForm)
+	setBoolParam(ans, scoll.ParamGroupByFeats, req.GroupByFeats)
+	setBoolParam(ans, scoll.ParamLimitPerVariant, req.LimitPerVariant)
+	setBoolParam(ans, scoll.ParamCollocateGroupByPos, req.CollocateGroupByPos)
+	setBoolParam(ans, scoll.ParamGroupByDeprel, req.GroupByDeprel)
+	setBoolParam(ans, scoll.ParamCollocateGroupByTextType, req.CollocateGroupByTextType)
+	setBoolParam(ans, scoll.ParamAdaptiveLimits, req.AdaptiveLimits)
+	setBoolParam(ans, scoll.ParamSignedDistance, req.SignedDistance)
+	setBoolParam(ans, scoll.ParamCQL, req.Cql)
+	setBoolParam(ans, scoll.ParamNoQueryLog, req.NoQueryLog)
+	for k, v := range ans {
+		if len(v) == 0 {
+			delete(ans, k)
+		}
+	}
+	return ans
+}
+
+func collMemberToProto(m storage.CollMember) *CollMember {
+	return &CollMember{
+		Value:          m.Value,
+		Pos:            m.PoS,
+		Feats:          m.Feats,
+		PosDescription: m.PoSDescription,
+	}
+}
+
+func collocationToProto(c storage.Collocation) *Collocation {
+	ans := &Collocation{
+		Lemma:             collMemberToProto(c.Lemma),
+		Collocate:         collMemberToProto(c.Collocate),
+		Deprel:            c.Deprel,
+		DeprelDescription: c.DeprelDescription,
+		Cql:               c.CQL,
+		IsHead:            c.IsHead,
+		LogDice:           c.LogDice,
+		TScore:            c.TScore,
+		MutualDist:        c.MutualDist,
+		SurfaceDist:       c.SurfaceDist,
+		Lmi:               c.LMI,
+		LogLikelihood:     c.LogLikelihood,
+		RrfScore:          c.RRFScore,
+		TextType:          c.TextType,
+		Mi:                c.MI,
+		Mi3:               c.MI3,
+		Dice:              c.Dice,
+		MinSensitivity:    c.MinSensitivity,
+		CorpusSize:        c.CorpusSize,
+		Freq:              int64(c.Freq),
+		LemmaFreq:         int64(c.LemmaFreq),
+		CollocateFreq:     int64(c.CollocateFreq),
+		DeltaPCollocate:   c.DeltaPCollocate,
+		DeltaPNode:        c.DeltaPNode,
+		FreqIpm:           c.FreqIPM,
+		LemmaFreqIpm:      c.LemmaFreqIPM,
+		CollocateFreqIpm:  c.CollocateFreqIPM,
+	}
+	for _, item := range c.SecondOrder {
+		ans.SecondOrder = append(ans.SecondOrder, collocationToProto(item))
+	}
+	return ans
+}
+
+// GetCollocations streams found collocations
+func (srv *Server) GetCollocations(
+	req *CollocationsRequest,
+	stream grpc.ServerStreamingServer[Collocation],
+) error {
+	opts, err := scoll.OptionsFromURLValues(req.asURLValues())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	opts = append(opts, srv.accessOptions(stream.Context())...)
+	var total int
+	opts = append(opts, scoll.WithTotalCount(&total))
+	ctx, cancel := srv.queryContext(stream.Context())
+	defer cancel()
+	var headerSent bool
+	sendHeader := func() error {
+		headerSent = true
+		return stream.SendHeader(metadata.Pairs(MetadataTotalCount, strconv.Itoa(total)))
+	}
+	for item, err := range srv.calc.StreamCollocations(ctx, req.Lemma, opts...) {
+		if err != nil {
+			return statusError(err)
+		}
+		// the total count is known once the first item is available
+		if !headerSent {
+			if err := sendHeader(); err != nil {
+				return err
+			}
+		}
+		if err := stream.Send(collocationToProto(item)); err != nil {
+			return err
+		}
+	}
+	if !headerSent {
+		return sendHeader()
+	}
+	return nil
+}
+
+// GetLemmaInfo provides frequencies of a lemma
+func (srv *Server) GetLemmaInfo(ctx context.Context, req *LemmaInfoRequest) (*LemmaInfo, error) {
+	info, err := srv.calc.GetLemmaInfo(req.Lemma, srv.accessOptions(ctx)...)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	ans := &LemmaInfo{
+		Lemma:    info.Lemma,
+		Exists:   info.Exists,
+		Freq:     int64(info.Freq),
+		PosFreqs: make(map[string]int64, len(info.PoSFreqs)),
+	}
+	for pos, freq := range info.PoSFreqs {
+		ans.PosFreqs[pos] = int64(freq)
+	}
+	return ans, nil
+}
+
+// GetMetadata provides basic information about the database.
+// Text types restricted for the client are not listed and the corpus
+// size does not include them. As the numbers of stored frequencies
+// cannot be split by text types, they are not provided in such case.
+func (srv *Server) GetMetadata(ctx context.Context, req *MetadataRequest) (*Metadata, error) {
+	access := srv.accessOptions(ctx)
+	textTypes, err := srv.calc.GetTextTypes(access...)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	corpusSize, complete, err := srv.calc.GetCorpusSize(access...)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	ans := &Metadata{
+		CorpusSize:    corpusSize,
+		ProfileName:   srv.metadata.ProfileName,
+		NumLemmas:     int64(srv.metadata.NumLemmas),
+		NumWordForms:  int64(srv.metadata.NumWordForms),
+		MinPairFreq:   int32(srv.metadata.MinPairFreq),
+		PairWeighting: srv.metadata.PairWeighting,
+		PathPolicy:    srv.metadata.PathPolicy.Name,
+		MorphFeats:    srv.metadata.MorphFeats,
+	}
+	if complete {
+		ans.NumLemmaFreqs = int64(srv.metadata.NumLemmaFreqs)
+		ans.NumCollFreqs = int64(srv.metadata.NumCollFreqs)
+	}
+	for _, f := range srv.metadata.AvailableFeatures() {
+		ans.Features = append(ans.Features, string(f))
+	}
+	for _, tt := range textTypes {
+		ans.TextTypes = append(ans.TextTypes, &TextTypeLabel{Value: tt.Value, DisplayName: tt.DisplayName})
+	}
+	for deprel := range srv.metadata.DeprelMap {
+		ans.Deprels = append(ans.Deprels, deprel)
+	}
+	slices.Sort(ans.Deprels)
+	return ans, nil
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// openTestDB creates a database with the lemma "dog" (noun)
+// modified by adjectives "big", "small" and "old"
+func openTestDB(t *testing.T) *storage.DB {
+	db, err := storage.OpenDBIgnoreMetadata(
+		t.TempDir(), storage.NewPreconfTextTypeMapping(map[string]byte{"fiction": 0x01}))
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.Metadata.CorpusSize = 1000
+	db.Metadata.ProfileName = "test"
+	db.Metadata.DeprelMap = map[string]uint16{"amod": 1, "nsubj": 2}
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"dog":   {Lemma: "dog", PoS: noun, Freq: 20, TextType: tt},
+		"big":   {Lemma: "big", PoS: adj, Freq: 10, TextType: tt},
+		"small": {Lemma: "small", PoS: adj, Freq: 8, TextType: tt},
+		"old":   {Lemma: "old", PoS: adj, Freq: 30, TextType: tt},
+	}
+	pairFreqs := make(map[record.GroupingKey]record.CollocFreq)
+	for lemma, freq := range map[string]int{"big": 5, "small": 4, "old": 3} {
+		pairFreqs[record.GroupingKey(lemma)] = record.CollocFreq{
+			Lemma1: "dog", PoS1: noun, Lemma2: lemma, PoS2: adj, Freq: freq,
+			AVGDist: 1, TextType: tt, Direction: record.DirectionHead}
+	}
+	_, err = db.StoreData(storage.NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	return db
+}
+
+// startTestServer serves the database via an in-memory connection
+// and returns a client connected to it
+func startTestServer(t *testing.T, db scoll.Database) (*scoll.Calculator, DepreldbClient) {
+	calc := scoll.FromDatabase(db)
+	lsnr := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	NewServer(calc, db.DatasetMetadata(), []string{"secret"}, 0).Register(grpcServer)
+	go grpcServer.Serve(lsnr)
+	t.Cleanup(grpcServer.Stop)
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lsnr.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return calc, NewDepreldbClient(conn)
+}
+
+func TestCollocationsRequestAsURLValues(t *testing.T) {
+	req := &CollocationsRequest{
+		Lemma:         "dog",
+		Pos:           "NOUN",
+		Limit:         5,
+		SortBy:        "ldice",
+		Deprels:       []string{"amod", "nmod"},
+		MinCollFreq:   3,
+		LemmaAsHead:   proto.Bool(false),
+		GroupByDeprel: true,
+	}
+	values := req.asURLValues()
+	assert.Equal(t, "NOUN", values.Get(scoll.ParamPoS))
+	assert.Equal(t, "5", values.Get(scoll.ParamLimit))
+	assert.Equal(t, "ldice", values.Get(scoll.ParamSortBy))
+	assert.Equal(t, []string{"amod", "nmod"}, values[scoll.ParamDeprel])
+	assert.Equal(t, "3", values.Get(scoll.ParamMinCollFreq))
+	assert.Equal(t, "false", values.Get(scoll.ParamLemmaAsHead))
+	assert.Equal(t, "1", values.Get(scoll.ParamGroupByDeprel))
+	assert.Len(t, values, 7)
+	_, err := scoll.OptionsFromURLValues(values)
+	assert.NoError(t, err)
+}
+
+func TestServerGetCollocations(t *testing.T) {
+	calc, client := startTestServer(t, openTestDB(t))
+	expected, err := calc.GetCollocations(
+		context.Background(), "dog", scoll.WithSortBy("ldice"), scoll.WithLimit(2), scoll.WithoutQueryLog())
+	assert.NoError(t, err)
+
+	stream, err := client.GetCollocations(
+		context.Background(),
+		&CollocationsRequest{Lemma: "dog", SortBy: "ldice", Limit: 2, NoQueryLog: true},
+	)
+	assert.NoError(t, err)
+	var items []*Collocation
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		items = append(items, item)
+	}
+	header, err := stream.Header()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3"}, header.Get(MetadataTotalCount))
+	if assert.Len(t, items, len(expected)) {
+		for i, item := range items {
+			assert.Equal(t, "dog", item.Lemma.Value)
+			assert.Equal(t, expected[i].Collocate.Value, item.Collocate.Value)
+			assert.Equal(t, expected[i].LogDice, item.LogDice)
+			assert.Equal(t, int64(expected[i].Freq), item.Freq)
+			assert.Equal(t, expected[i].FreqIPM, item.FreqIpm)
+			assert.Equal(t, expected[i].LemmaFreqIPM, item.LemmaFreqIpm)
+			assert.Equal(t, expected[i].CollocateFreqIPM, item.CollocateFreqIpm)
+			assert.Positive(t, item.FreqIpm)
+		}
+	}
+}
+
+func TestServerGetCollocationsInvalidOptions(t *testing.T) {
+	_, client := startTestServer(t, openTestDB(t))
+	stream, err := client.GetCollocations(
+		context.Background(), &CollocationsRequest{Lemma: "dog", SortBy: "foo"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerGetCollocationsLimitCaps(t *testing.T) {
+	_, client := startTestServer(t, openTestDB(t))
+	for _, req := range []*CollocationsRequest{
+		{Lemma: "dog", Limit: scoll.MaxURLLimit + 1},
+		{Lemma: "dog", Limit: 10, SecondOrderLimit: scoll.MaxURLSecondOrderLimit + 1},
+		{Lemma: "dog", Limit: scoll.MaxURLSecondOrderParents + 1, SecondOrderLimit: 5},
+	} {
+		stream, err := client.GetCollocations(context.Background(), req)
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestServerGetLemmaInfo(t *testing.T) {
+	_, client := startTestServer(t, openTestDB(t))
+	ans, err := client.GetLemmaInfo(context.Background(), &LemmaInfoRequest{Lemma: "dog"})
+	assert.NoError(t, err)
+	assert.True(t, ans.Exists)
+	assert.Equal(t, int64(20), ans.Freq)
+	assert.Equal(t, map[string]int64{"NOUN": 20}, ans.PosFreqs)
+}
+
+func TestServerGetMetadata(t *testing.T) {
+	_, client := startTestServer(t, openTestDB(t))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataAPIKey, "secret")
+	ans, err := client.GetMetadata(ctx, &MetadataRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), ans.CorpusSize)
+	assert.Equal(t, "test", ans.ProfileName)
+	assert.Equal(t, []string{"amod", "nsubj"}, ans.Deprels)
+}
+
+// restrictedDatabase is a Database with restricted text types
+// normally coming from an import profile
+type restrictedDatabase struct {
+	scoll.Database
+	restricted []string
+}
+
+func (db restrictedDatabase) RestrictedTextTypes() []string {
+	return db.restricted
+}
+
+func TestServerGetMetadataRestrictedTextTypes(t *testing.T) {
+	db, err := storage.OpenDBIgnoreMetadata(
+		t.TempDir(),
+		storage.NewPreconfTextTypeMapping(map[string]byte{"fiction": 0x01, "news": 0x02}),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: fiction},
+		"2": {Lemma: "dog", PoS: noun, Freq: 20, TextType: news},
+		"3": {Lemma: "big", PoS: adj, Freq: 10, TextType: fiction},
+		"4": {Lemma: "angry", PoS: adj, Freq: 10, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Freq: 5, AVGDist: 1,
+			TextType: fiction, Direction: record.DirectionHead},
+		"2": {Lemma1: "dog", PoS1: noun, Lemma2: "angry", PoS2: adj, Freq: 5, AVGDist: 1,
+			TextType: news, Direction: record.DirectionHead},
+	}
+	stats, err := db.StoreData(storage.NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	db.Metadata.NumLemmaFreqs = stats.NumLemmaFreqs
+	db.Metadata.NumCollFreqs = stats.NumCollFreqs
+	_, client := startTestServer(t, restrictedDatabase{Database: db, restricted: []string{"news"}})
+
+	// without the API key, the restricted text type is not included...
+	ans, err := client.GetMetadata(context.Background(), &MetadataRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), ans.CorpusSize) // 30 of 60 lemma tokens
+	assert.Zero(t, ans.NumLemmaFreqs)
+	assert.Zero(t, ans.NumCollFreqs)
+
+	// ...and with the key, the whole corpus is described
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataAPIKey, "secret")
+	ans, err = client.GetMetadata(ctx, &MetadataRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), ans.CorpusSize)
+	assert.Equal(t, int64(stats.NumLemmaFreqs), ans.NumLemmaFreqs)
+	assert.Equal(t, int64(stats.NumCollFreqs), ans.NumCollFreqs)
+	assert.Positive(t, ans.NumCollFreqs)
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import "strings"
+
+// LabelLang specifies a language of human-readable descriptions
+// of UD labels (PoS tags, dependency relations).
+type LabelLang string
+
+const (
+	LabelLangEN LabelLang = "en"
+	LabelLangCS LabelLang = "cs"
+)
+
+func (ll LabelLang) Validate() bool {
+	return ll == LabelLangEN || ll == LabelLangCS
+}
+
+type labelDesc struct {
+	en string
+	cs string
+}
+
+func (ld labelDesc) get(lang LabelLang) string {
+	if lang == LabelLangCS {
+		return ld.cs
+	}
+	return ld.en
+}
+
+var udPoSDescriptions = map[string]labelDesc{
+	"ADJ":   {"adjective", "přídavné jméno"},
+	"ADP":   {"adposition", "předložka"},
+	"ADV":   {"adverb", "příslovce"},
+	"AUX":   {"auxiliary", "pomocné sloveso"},
+	"CCONJ": {"coordinating conjunction", "souřadicí spojka"},
+	"DET":   {"determiner", "determinátor"},
+	"INTJ":  {"interjection", "citoslovce"},
+	"NOUN":  {"noun", "podstatné jméno"},
+	"NUM":   {"numeral", "číslovka"},
+	"PART":  {"particle", "částice"},
+	"PRON":  {"pronoun", "zájmeno"},
+	"PROPN": {"proper noun", "vlastní jméno"},
+	"PUNCT": {"punctuation", "interpunkce"},
+	"SCONJ": {"subordinating conjunction", "podřadicí spojka"},
+	"SYM":   {"symbol", "symbol"},
+	"VERB":  {"verb", "sloveso"},
+	"X":     {"other", "jiné"},
+}
+
+var udDeprelDescriptions = map[string]labelDesc{
+	"acl":          {"adnominal clause", "přívlastková věta"},
+	"acl:relcl":    {"relative clause", "vztažná věta"},
+	"advcl":        {"adverbial clause", "příslovečná věta"},
+	"advmod":       {"adverbial modifier", "příslovečné určení"},
+	"advmod:emph":  {"emphasizing word", "zdůrazňovací výraz"},
+	"amod":         {"adjectival modifier", "shodný přívlastek"},
+	"appos":        {"appositional modifier", "přístavek"},
+	"aux":          {"auxiliary", "pomocné sloveso"},
+	"aux:pass":     {"passive auxiliary", "pomocné sloveso pasiva"},
+	"case":         {"case marking", "předložka (pád)"},
+	"cc":           {"coordinating conjunction", "souřadicí spojka"},
+	"ccomp":        {"clausal complement", "předmětná věta"},
+	"clf":          {"classifier", "klasifikátor"},
+	"compound":     {"compound", "kompozitum"},
+	"conj":         {"conjunct", "člen koordinace"},
+	"cop":          {"copula", "spona"},
+	"csubj":        {"clausal subject", "podmětná věta"},
+	"csubj:pass":   {"clausal passive subject", "podmětná věta pasiva"},
+	"dep":          {"unspecified dependency", "blíže neurčená závislost"},
+	"det":          {"determiner", "determinátor"},
+	"det:numgov":   {"pronominal quantifier governing the case of the noun", "zájmenný kvantifikátor řídící pád"},
+	"det:nummod":   {"pronominal quantifier agreeing with the noun", "shodný zájmenný kvantifikátor"},
+	"discourse":    {"discourse element", "diskurzní prvek"},
+	"dislocated":   {"dislocated element", "vytčený prvek"},
+	"expl:pass":    {"reflexive passive", "zvratné pasivum"},
+	"expl:pv":      {"reflexive clitic with an inherently reflexive verb", "zvratné zájmeno reflexivního slovesa"},
+	"fixed":        {"fixed multiword expression", "ustálený víceslovný výraz"},
+	"flat":         {"flat multiword expression", "víceslovné jméno"},
+	"flat:foreign": {"foreign words", "cizojazyčný výraz"},
+	"goeswith":     {"goes with", "část rozděleného slova"},
+	"iobj":         {"indirect object", "nepřímý předmět"},
+	"list":         {"list", "seznam"},
+	"mark":         {"marker", "podřadicí spojka (uvozovací výraz)"},
+	"nmod":         {"nominal modifier", "jmenný přívlastek"},
+	"nsubj":        {"nominal subject", "podmět"},
+	"nsubj:pass":   {"passive nominal subject", "podmět pasiva"},
+	"nummod":       {"numeric modifier", "číslovka (shodná)"},
+	"nummod:gov":   {"numeric modifier governing the case of the noun", "číslovka řídící pád"},
+	"obj":          {"object", "předmět"},
+	"obl":          {"oblique nominal", "příslovečné určení (jmenné)"},
+	"obl:arg":      {"oblique argument", "předmětové příslovečné určení"},
+	"orphan":       {"orphan", "sirotek (elipsa)"},
+	"parataxis":    {"parataxis", "parataxe"},
+	"punct":        {"punctuation", "interpunkce"},
+	"reparandum":   {"overridden disfluency", "opravený výraz"},
+	"root":         {"root", "kořen"},
+	"vocative":     {"vocative", "oslovení"},
+	"xcomp":        {"open clausal complement", "doplněk"},
+	"sibling":      {"sibling (shares the same head)", "sourozenec (společný řídící člen)"},
+}
+
+// DescribePoS returns a human-readable description of a UD PoS tag
+// in the required language. Compound tags (e.g. "ADP|PRON") are described
+// part by part. For unknown tags, an empty string is returned.
+func DescribePoS(pos string, lang LabelLang) string {
+	if pos == "" {
+		return ""
+	}
+	parts := strings.Split(pos, "|")
+	descs := make([]string, 0, len(parts))
+	for _, p := range parts {
+		desc, ok := udPoSDescriptions[strings.ToUpper(p)]
+		if !ok {
+			return ""
+		}
+		descs = append(descs, desc.get(lang))
+	}
+	return strings.Join(descs, " + ")
+}
+
+func describeSingleDeprel(deprel string, lang LabelLang) string {
+	deprel = strings.ToLower(deprel)
+	if desc, ok := udDeprelDescriptions[deprel]; ok {
+		return desc.get(lang)
+	}
+	// a subtype not known to us (e.g. a merged preposition) is
+	// described via its base relation
+	base, subtype, found := strings.Cut(deprel, ":")
+	if desc, ok := udDeprelDescriptions[base]; ok && found {
+		return desc.get(lang) + " (" + subtype + ")"
+	}
+	return ""
+}
+
+// DescribeDeprel returns a human-readable description of a UD dependency
+// relation in the required language. Relation path labels (e.g. "obj→amod")
+// are described relation by relation. For unknown relations, an empty string
+// is returned.
+func DescribeDeprel(deprel string, lang LabelLang) string {
+	if deprel == "" {
+		return ""
+	}
+	var ans strings.Builder
+	var curr strings.Builder
+	flush := func() bool {
+		desc := describeSingleDeprel(curr.String(), lang)
+		curr.Reset()
+		if desc == "" {
+			return false
+		}
+		ans.WriteString(desc)
+		return true
+	}
+	for _, r := range deprel {
+		if r == '→' || r == '←' {
+			if !flush() {
+				return ""
+			}
+			ans.WriteString(" " + string(r) + " ")
+			continue
+		}
+		curr.WriteRune(r)
+	}
+	if !flush() {
+		return ""
+	}
+	return ans.String()
+}
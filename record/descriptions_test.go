@@ -0,0 +1,52 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribePoS(t *testing.T) {
+	assert.Equal(t, "noun", DescribePoS("NOUN", LabelLangEN))
+	assert.Equal(t, "podstatné jméno", DescribePoS("NOUN", LabelLangCS))
+	assert.Equal(t, "předložka + zájmeno", DescribePoS("ADP|PRON", LabelLangCS))
+	assert.Equal(t, "", DescribePoS("FOO", LabelLangEN))
+	assert.Equal(t, "", DescribePoS("", LabelLangEN))
+}
+
+func TestDescribeDeprel(t *testing.T) {
+	assert.Equal(t, "předmět", DescribeDeprel("obj", LabelLangCS))
+	assert.Equal(t, "relative clause", DescribeDeprel("acl:relcl", LabelLangEN))
+	assert.Equal(t, "nominal modifier (v)", DescribeDeprel("nmod:v", LabelLangEN))
+	assert.Equal(t, "object → adjectival modifier", DescribeDeprel("obj→amod", LabelLangEN))
+	assert.Equal(t, "object ← root → nominal subject", DescribeDeprel("obj←root→nsubj", LabelLangEN))
+	assert.Equal(t, "", DescribeDeprel("obj→foo", LabelLangEN))
+	assert.Equal(t, "", DescribeDeprel("foo", LabelLangEN))
+}
+
+func TestUDLabelsHaveDescriptions(t *testing.T) {
+	for deprel := range UDDeprelMapping.items {
+		assert.NotEmpty(t, DescribeDeprel(deprel, LabelLangEN), deprel)
+		assert.NotEmpty(t, DescribeDeprel(deprel, LabelLangCS), deprel)
+	}
+	for pos := range UDPoSMapping {
+		assert.NotEmpty(t, DescribePoS(pos, LabelLangEN), pos)
+		assert.NotEmpty(t, DescribePoS(pos, LabelLangCS), pos)
+	}
+}
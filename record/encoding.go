@@ -17,7 +17,9 @@
 package record
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 )
@@ -29,17 +31,129 @@ const (
 	singleTokenPrefix  byte = 0x04 // tokenID -> frequency
 	pairTokenPrefix    byte = 0x05 // (tokenID1, tokenID2) -> frequency&dist (where tokenID1 is HEAD)
 	revPairTokenPrefix byte = 0x06 // (tokenID1, tokenID2) -> frequency&dist (where tokenID1 is DEPENDENT)
+	tokenRollupPrefix  byte = 0x07 // (tokenID, pos) -> frequency summed over all text types
+	hotPairPrefix      byte = 0x08 // pre-aggregated (over text types) variant of pairTokenPrefix for hot lemmas
+	hotRevPairPrefix   byte = 0x09 // pre-aggregated (over text types) variant of revPairTokenPrefix for hot lemmas
+	foldedLemmaPrefix  byte = 0x0a // ("folded lemma", "lemma") -> tokenID (lemmas without diacritics)
+	wordFormToIDPrefix byte = 0x0b // "word form" -> tokenID (see WordFormTokenIDFlag)
+	topCollsPrefix     byte = 0x0c // (tokenID, variant, measure) -> precomputed top collocations
+	lowerLemmaPrefix   byte = 0x0d // ("lowercased folded lemma", "lemma") -> tokenID (lemmas with uppercase letters)
+	pathPairPrefix     byte = 0x0e // variant of pairTokenPrefix for tokens connected via other nodes (path labels as deprels)
+	revPathPairPrefix  byte = 0x0f // variant of revPairTokenPrefix for tokens connected via other nodes (path labels as deprels)
+	pairExamplesPrefix byte = 0x10 // (tokenID1, tokenID2) -> sample of corpus positions of the pair occurrences
 
 	MetadataKeyImportProfile byte = 0x01
+	MetadataKeyImportHistory byte = 0x02
 )
 
+// ErrMalformedRecord is returned by decoding functions in case
+// a key or a value does not match the expected format (e.g. because
+// of a corrupted database or a record written by an incompatible tool).
+var ErrMalformedRecord = errors.New("malformed record")
+
+// KeyLayout specifies how token IDs are encoded in keys and values.
+// The default (zero) layout stores IDs as 32-bit values, the wide
+// layout (see WideKeyLayout) uses 64-bit values for very large
+// databases (e.g. multilingual ones) where 2^31 IDs are not enough.
+// As keys of both the layouts may have the same lengths (e.g. a narrow
+// token key with features vs. a wide token key without them), the layout
+// cannot be detected from keys and must be stored along with the data.
+type KeyLayout struct {
+	wide bool
+}
+
+var (
+	// NarrowKeyLayout is the default layout with 4-byte token IDs
+	NarrowKeyLayout = KeyLayout{}
+
+	// WideKeyLayout is a layout with 8-byte token IDs
+	WideKeyLayout = KeyLayout{wide: true}
+)
+
+// KeyLayoutByIDWidth returns a layout encoding token IDs using
+// the provided number of bytes (4 or 8). For zero, the default
+// layout is returned.
+func KeyLayoutByIDWidth(width int) (KeyLayout, error) {
+	switch width {
+	case 0, 4:
+		return NarrowKeyLayout, nil
+	case 8:
+		return WideKeyLayout, nil
+	}
+	return KeyLayout{}, fmt.Errorf("unsupported token ID width %d (use 4 or 8)", width)
+}
+
+// TokenIDWidth returns the number of bytes of an encoded token ID
+func (kl KeyLayout) TokenIDWidth() int {
+	if kl.wide {
+		return 8
+	}
+	return 4
+}
+
+// MaxTokenID returns the highest token ID (regardless of
+// the word form flag) the layout is able to encode
+func (kl KeyLayout) MaxTokenID() uint64 {
+	return kl.WordFormTokenIDFlag() - 1
+}
+
+func (kl KeyLayout) putID(dst []byte, tokenID uint64) {
+	if kl.wide {
+		binary.LittleEndian.PutUint64(dst, tokenID)
+		return
+	}
+	binary.LittleEndian.PutUint32(dst, uint32(tokenID))
+}
+
+func (kl KeyLayout) id(src []byte) uint64 {
+	if kl.wide {
+		return binary.LittleEndian.Uint64(src)
+	}
+	return uint64(binary.LittleEndian.Uint32(src))
+}
+
+// collFreqKeyLen returns the length of a collocation key without features
+func (kl KeyLayout) collFreqKeyLen() int {
+	return 1 + 2*kl.TokenIDWidth() + 5
+}
+
+// tokenFreqKeyLen returns the length of a token key without features
+func (kl KeyLayout) tokenFreqKeyLen() int {
+	return 1 + kl.TokenIDWidth() + 2
+}
+
+// WordFormTokenIDFlag returns a flag marking token IDs of word forms.
+// Word forms share all the frequency key types with lemmas, the flag
+// just keeps both ID spaces separated. In the wide layout, the flag
+// is not the highest bit so the IDs fit into signed 64-bit integers
+// (e.g. in SQL exports).
+func (kl KeyLayout) WordFormTokenIDFlag() uint64 {
+	if kl.wide {
+		return 1 << 62
+	}
+	return 1 << 31
+}
+
+// IsWordFormTokenID tells whether the token ID belongs to a word form
+// (and not to a lemma)
+func (kl KeyLayout) IsWordFormTokenID(tokenID uint64) bool {
+	return tokenID&kl.WordFormTokenIDFlag() != 0
+}
+
 type DecodedKey struct {
-	Token1ID uint32
+	Token1ID uint64
 	Pos1     byte
 	Deprel   uint16
-	Token2ID uint32
+	Token2ID uint64
 	Pos2     byte
 	TextType byte
+
+	// IsHead is set for collocation keys where Token1 is the head
+	IsHead bool
+
+	// Feats contains morphological features of Token1 (zero for
+	// keys without features, see WithFeats)
+	Feats UDFeats
 }
 
 // EncodeLemmaKey creates a byte key representation for (Lemma) -> (Lemma ID) entries
@@ -62,6 +176,55 @@ func EncodeLemmaPrefixKey(lemmaPrefix string) []byte {
 	return key
 }
 
+// EncodeFoldedLemmaKey creates a key of the secondary lemma index
+// where lemmas are stored without diacritics (see FoldLemma).
+// As more lemmas can be folded to the same value, the original lemma
+// is also part of the key.
+func EncodeFoldedLemmaKey(folded, lemma string) []byte {
+	key := make([]byte, 0, 2+len(folded)+len(lemma))
+	key = append(key, foldedLemmaPrefix)
+	key = append(key, folded...)
+	key = append(key, 0x00)
+	return append(key, lemma...)
+}
+
+// EncodeFoldedLemmaSearchKey creates a search prefix for the folded lemma
+// index. With isPrefix set to false, only the exact folded value is matched.
+func EncodeFoldedLemmaSearchKey(folded string, isPrefix bool) []byte {
+	key := make([]byte, 0, 2+len(folded))
+	key = append(key, foldedLemmaPrefix)
+	key = append(key, folded...)
+	if !isPrefix {
+		key = append(key, 0x00)
+	}
+	return key
+}
+
+// DecodeFoldedLemmaKey returns the original lemma stored
+// in a folded lemma index key
+func DecodeFoldedLemmaKey(key []byte) string {
+	idx := bytes.IndexByte(key, 0x00)
+	if idx < 0 {
+		return ""
+	}
+	return string(key[idx+1:])
+}
+
+// EncodeWordFormKey creates a byte key representation for
+// (word form) -> (token ID) entries. Unlike lemmas, word forms
+// are stored exactly as they occur in the source data.
+func EncodeWordFormKey(form string) []byte {
+	key := make([]byte, 1+len(form))
+	key[0] = wordFormToIDPrefix
+	copy(key[1:], form)
+	return key
+}
+
+// EncodeWordFormPrefixKey creates a search prefix for the word form index
+func EncodeWordFormPrefixKey(formPrefix string) []byte {
+	return EncodeWordFormKey(formPrefix)
+}
+
 func CreateMetadataKey(keyID byte) []byte {
 	return []byte{metadataPrefix, keyID}
 }
@@ -69,7 +232,8 @@ func CreateMetadataKey(keyID byte) []byte {
 // CollFreqKey produces a byte slice representing a DB entry with a collocation freq. info.
 // The key is composed in a way allowing for searching via textType without knowing token1's deprel
 // or even token2 properties (this is given by prefix key search)
-// The key looks like this:
+// The key looks like this (for the narrow layout, in the wide one, the token IDs
+// have 8 bytes and the following fields are shifted accordingly):
 // byte 0:    key type
 // byte 1-4:  token1 ID
 // byte 5:    token1 PoS
@@ -77,52 +241,200 @@ func CreateMetadataKey(keyID byte) []byte {
 // byte 7-8:  token1 deprel
 // byte 9-12: token2 ID
 // byte 13:   token2 PoS
-// byte 14-15: token2 deprel
-func CollFreqKey(t1IsHead bool, token1ID uint32, pos1, textType byte, deprel uint16, token2ID uint32, pos2 byte) []byte {
-	key := make([]byte, 1+4+1+1+2+4+1)
+// byte 14-15: token2 deprel (legacy, not written anymore)
+// byte 16-17: token1 morphological features (optional, see WithFeats)
+func (kl KeyLayout) CollFreqKey(t1IsHead bool, token1ID uint64, pos1, textType byte, deprel uint16, token2ID uint64, pos2 byte) []byte {
+	w := kl.TokenIDWidth()
+	key := make([]byte, kl.collFreqKeyLen())
 	if t1IsHead {
 		key[0] = pairTokenPrefix
 
 	} else {
 		key[0] = revPairTokenPrefix
 	}
-	binary.LittleEndian.PutUint32(key[1:5], token1ID)
-	key[5] = pos1
-	key[6] = textType
-	binary.LittleEndian.PutUint16(key[7:9], deprel)
-	binary.LittleEndian.PutUint32(key[9:13], token2ID)
-	key[13] = pos2
+	kl.putID(key[1:1+w], token1ID)
+	key[1+w] = pos1
+	key[2+w] = textType
+	binary.LittleEndian.PutUint16(key[3+w:5+w], deprel)
+	kl.putID(key[5+w:5+2*w], token2ID)
+	key[5+2*w] = pos2
 	return key
 }
 
 // DecodeCollFreqKey is a reverse function to CollFreqKey. From a byte slice,
-// it extracts all the collocation properties.
-func DecodeCollFreqKey(key []byte) DecodedKey {
+// it extracts all the collocation properties. For keys too short to contain
+// all the properties, ErrMalformedRecord is returned.
+func (kl KeyLayout) DecodeCollFreqKey(key []byte) (DecodedKey, error) {
+	baseLen := kl.collFreqKeyLen()
+	if len(key) < baseLen {
+		return DecodedKey{}, fmt.Errorf(
+			"%w: collocation key expected to have at least %d bytes, found %d",
+			ErrMalformedRecord, baseLen, len(key))
+	}
+	w := kl.TokenIDWidth()
 	return DecodedKey{
-		Token1ID: binary.LittleEndian.Uint32(key[1:5]),
-		Pos1:     key[5],
-		TextType: key[6],
-		Deprel:   binary.LittleEndian.Uint16(key[7:9]),
-		Token2ID: binary.LittleEndian.Uint32(key[9:13]),
-		Pos2:     key[13],
+		Token1ID: kl.id(key[1 : 1+w]),
+		Pos1:     key[1+w],
+		TextType: key[2+w],
+		Deprel:   binary.LittleEndian.Uint16(key[3+w : 5+w]),
+		Token2ID: kl.id(key[5+w : 5+2*w]),
+		Pos2:     key[5+2*w],
+		IsHead:   key[0] == pairTokenPrefix || key[0] == hotPairPrefix || key[0] == pathPairPrefix,
+		Feats:    decodeFeats(key, baseLen),
+	}, nil
+}
+
+// WithFeats extends a single token frequency key (see TokenFreqKey)
+// or a collocation frequency key (see CollFreqKey) with morphological
+// features of the (first) token. To keep the keys compatible with
+// their legacy variants (which contained deprels), the features follow
+// a zero-filled 2-byte slot. For zero features, the key is returned
+// unchanged so databases without features use the original layout.
+// As the features are at the end of the keys, all the prefix searches
+// work the same way with records split by features.
+func WithFeats(key []byte, feats UDFeats) []byte {
+	if feats == 0 {
+		return key
+	}
+	return binary.LittleEndian.AppendUint16(append(key, 0, 0), uint16(feats))
+}
+
+// decodeFeats extracts features stored by WithFeats from a key
+// with the provided base length (i.e. without the features)
+func decodeFeats(key []byte, baseLen int) UDFeats {
+	if len(key) < baseLen+4 {
+		return 0
+	}
+	return UDFeats(binary.LittleEndian.Uint16(key[baseLen+2 : baseLen+4]))
+}
+
+// HotCollFreqKey produces a key of a pre-aggregated collocation freq. record
+// (i.e. a record summed over all the text types) of a "hot" lemma - a lemma
+// with a high number of collocation records. The layout is the same as
+// in case of CollFreqKey with text type always set to zero so the key can be
+// decoded using DecodeCollFreqKey.
+func (kl KeyLayout) HotCollFreqKey(t1IsHead bool, token1ID uint64, pos1 byte, deprel uint16, token2ID uint64, pos2 byte) []byte {
+	key := kl.CollFreqKey(t1IsHead, token1ID, pos1, 0, deprel, token2ID, pos2)
+	if t1IsHead {
+		key[0] = hotPairPrefix
+
+	} else {
+		key[0] = hotRevPairPrefix
+	}
+	return key
+}
+
+// AllHotCollFreqsOfToken is a variant of AllCollFreqsOfToken for
+// pre-aggregated records of hot lemmas (see HotCollFreqKey).
+func (kl KeyLayout) AllHotCollFreqsOfToken(isHead bool, tokenID uint64) []byte {
+	key := kl.AllCollFreqsOfToken(isHead, tokenID)
+	if isHead {
+		key[0] = hotPairPrefix
+
+	} else {
+		key[0] = hotRevPairPrefix
+	}
+	return key
+}
+
+// AsPathCollFreqKey converts a key produced by CollFreqKey (possibly
+// extended by WithFeats) into a key of a path collocation record - i.e.
+// a record of tokens connected via other nodes of a dependency tree path
+// (e.g. a verb and an adjective modifying the verb's object). Such records
+// have the whole path label (e.g. "obj→amod") as their deprel. The layout
+// is the same so the key can be decoded using DecodeCollFreqKey.
+// The key is modified in place. Other keys are returned unchanged.
+func AsPathCollFreqKey(key []byte) []byte {
+	switch key[0] {
+	case pairTokenPrefix:
+		key[0] = pathPairPrefix
+	case revPairTokenPrefix:
+		key[0] = revPathPairPrefix
+	}
+	return key
+}
+
+// AllPathCollFreqsOfToken is a variant of AllCollFreqsOfToken for
+// path collocation records (see AsPathCollFreqKey).
+func (kl KeyLayout) AllPathCollFreqsOfToken(isHead bool, tokenID uint64) []byte {
+	return AsPathCollFreqKey(kl.AllCollFreqsOfToken(isHead, tokenID))
+}
+
+// PairExamplesKey creates a key of the record containing examples
+// (corpus positions) of the pair of tokens. Unlike the frequency
+// records, examples are stored just for the two tokens regardless
+// of PoS, deprel, text type etc.
+func (kl KeyLayout) PairExamplesKey(token1ID, token2ID uint64) []byte {
+	w := kl.TokenIDWidth()
+	key := make([]byte, 1+2*w)
+	key[0] = pairExamplesPrefix
+	kl.putID(key[1:1+w], token1ID)
+	kl.putID(key[1+w:], token2ID)
+	return key
+}
+
+// DecodePairExamplesKey is a reverse function to PairExamplesKey.
+// For keys of unexpected lengths, ErrMalformedRecord is returned.
+func (kl KeyLayout) DecodePairExamplesKey(key []byte) (uint64, uint64, error) {
+	w := kl.TokenIDWidth()
+	if len(key) != 1+2*w {
+		return 0, 0, fmt.Errorf(
+			"%w: pair examples key expected to have %d bytes, found %d", ErrMalformedRecord, 1+2*w, len(key))
 	}
+	return kl.id(key[1 : 1+w]), kl.id(key[1+w:]), nil
 }
 
 // AllCollFreqsOfToken generates a db key to search for all
 // the collocation freq. records of this token (where the token
 // is the first one).
-func AllCollFreqsOfToken(isHead bool, tokenID uint32) []byte {
-	key := make([]byte, 5)
+func (kl KeyLayout) AllCollFreqsOfToken(isHead bool, tokenID uint64) []byte {
+	key := make([]byte, 1+kl.TokenIDWidth())
 	if isHead {
 		key[0] = pairTokenPrefix
 
 	} else {
 		key[0] = revPairTokenPrefix
 	}
-	binary.LittleEndian.PutUint32(key[1:5], tokenID)
+	kl.putID(key[1:], tokenID)
 	return key
 }
 
+// AllCollFreqs generates a db key prefix to search for all
+// the collocation freq. records of the provided direction.
+func AllCollFreqs(isHead bool) []byte {
+	if isHead {
+		return []byte{pairTokenPrefix}
+	}
+	return []byte{revPairTokenPrefix}
+}
+
+// AllTokenFreqs generates a db key prefix to search for all
+// the single token freq. records.
+func AllTokenFreqs() []byte {
+	return []byte{singleTokenPrefix}
+}
+
+// TopCollsKey creates a key of precomputed top collocations of a token
+// sorted by a measure. Each token can have two variants of the records -
+// one calculated from all the text types and one calculated with some
+// text types (typically the restricted ones) excluded.
+func (kl KeyLayout) TopCollsKey(tokenID uint64, excludesTextTypes bool, measure string) []byte {
+	w := kl.TokenIDWidth()
+	key := make([]byte, 2+w, 2+w+len(measure))
+	key[0] = topCollsPrefix
+	kl.putID(key[1:1+w], tokenID)
+	if excludesTextTypes {
+		key[1+w] = 1
+	}
+	return append(key, measure...)
+}
+
+// AllTopColls generates a db key prefix to search for all
+// the precomputed top collocation records.
+func AllTopColls() []byte {
+	return []byte{topCollsPrefix}
+}
+
 // TokenFreqKey generates a key for searching of single token
 // frequencies.
 // Note that this is not for generating search prefix keys as this
@@ -130,12 +442,13 @@ func AllCollFreqsOfToken(isHead bool, tokenID uint32) []byte {
 //
 // For generating search keys, use TokenFreqSearchKey which generates
 // proper key prefix in case you provide zero pos, textType or deprel.
-func TokenFreqKey(tokenID uint32, pos, textType byte) []byte {
-	key := make([]byte, 1+4+1+1)
+func (kl KeyLayout) TokenFreqKey(tokenID uint64, pos, textType byte) []byte {
+	w := kl.TokenIDWidth()
+	key := make([]byte, kl.tokenFreqKeyLen())
 	key[0] = singleTokenPrefix
-	binary.LittleEndian.PutUint32(key[1:5], tokenID)
-	key[5] = pos
-	key[6] = textType
+	kl.putID(key[1:1+w], tokenID)
+	key[1+w] = pos
+	key[2+w] = textType
 	return key
 }
 
@@ -143,10 +456,11 @@ func TokenFreqKey(tokenID uint32, pos, textType byte) []byte {
 // produces byte slice key without trailing zero values with pos having the
 // highest priority following by textType and deprel. I.e. if you provide zero
 // pos, then the key will contain just token ID (and the key identifier zero byte).
-func TokenFreqSearchKey(tokenID uint32, pos, textType byte) []byte {
-	key := make([]byte, 5, 9)
+func (kl KeyLayout) TokenFreqSearchKey(tokenID uint64, pos, textType byte) []byte {
+	w := kl.TokenIDWidth()
+	key := make([]byte, 1+w, 5+w)
 	key[0] = singleTokenPrefix
-	binary.LittleEndian.PutUint32(key[1:5], tokenID)
+	kl.putID(key[1:1+w], tokenID)
 	if pos > 0 {
 		key = append(key, pos)
 		if textType > 0 {
@@ -156,44 +470,111 @@ func TokenFreqSearchKey(tokenID uint32, pos, textType byte) []byte {
 	return key
 }
 
+// TokenFreqRollupKey creates a key for an aggregated single token
+// frequency record where all the text types of a (tokenID, pos) pair
+// are summed up. Such records allow F(x) retrieval with a single lookup
+// (or a short scan in case pos is not known) in situations where
+// text types are not needed.
+func (kl KeyLayout) TokenFreqRollupKey(tokenID uint64, pos byte) []byte {
+	w := kl.TokenIDWidth()
+	key := make([]byte, 2+w)
+	key[0] = tokenRollupPrefix
+	kl.putID(key[1:1+w], tokenID)
+	key[1+w] = pos
+	return key
+}
+
+// TokenFreqRollupSearchKey is a searching variant of TokenFreqRollupKey.
+// For zero pos, the key contains just the token ID.
+func (kl KeyLayout) TokenFreqRollupSearchKey(tokenID uint64, pos byte) []byte {
+	if pos > 0 {
+		return kl.TokenFreqRollupKey(tokenID, pos)
+	}
+	key := make([]byte, 1+kl.TokenIDWidth())
+	key[0] = tokenRollupPrefix
+	kl.putID(key[1:], tokenID)
+	return key
+}
+
+// DecodeTokenFreqRollupKey is a reverse function to TokenFreqRollupKey.
+// For keys of unexpected lengths, ErrMalformedRecord is returned.
+func (kl KeyLayout) DecodeTokenFreqRollupKey(key []byte) (DecodedKey, error) {
+	w := kl.TokenIDWidth()
+	if len(key) != 2+w {
+		return DecodedKey{}, fmt.Errorf(
+			"%w: token rollup key expected to have %d bytes, found %d", ErrMalformedRecord, 2+w, len(key))
+	}
+	return DecodedKey{
+		Token1ID: kl.id(key[1 : 1+w]),
+		Pos1:     key[1+w],
+	}, nil
+}
+
 // DecodeTokenFreqKey is a reverse function to TokenFreqKey. Given the provided
 // key, it extracts all the included properties. Note that the returned value
 // type DecodedKey is the same as in case of the collocation freq. records.
 // It means that here, all the attributes belonging to the second lemma will be
-// always zero.
-func DecodeTokenFreqKey(key []byte) DecodedKey {
-	if len(key) < 5 {
-		panic(fmt.Sprintf("DecodeTokenFreqKey failed, expected at least length of 5, found: %d", len(key)))
+// always zero. For keys too short to contain a token ID, ErrMalformedRecord
+// is returned.
+func (kl KeyLayout) DecodeTokenFreqKey(key []byte) (DecodedKey, error) {
+	w := kl.TokenIDWidth()
+	if len(key) < 1+w {
+		return DecodedKey{}, fmt.Errorf(
+			"%w: token key expected to have at least %d bytes, found %d", ErrMalformedRecord, 1+w, len(key))
 	}
 	ans := DecodedKey{
-		Token1ID: binary.LittleEndian.Uint32(key[1:5]),
+		Token1ID: kl.id(key[1 : 1+w]),
 	}
-	if len(key) >= 6 {
-		ans.Pos1 = key[5]
+	if len(key) >= 2+w {
+		ans.Pos1 = key[1+w]
 	}
-	if len(key) >= 7 {
-		ans.TextType = key[6]
+	if len(key) >= 3+w {
+		ans.TextType = key[2+w]
 	}
-	if len(key) >= 8 {
-		ans.Deprel = binary.LittleEndian.Uint16(key[7:9])
+	if len(key) >= 5+w {
+		ans.Deprel = binary.LittleEndian.Uint16(key[3+w : 5+w])
 	}
-	return ans
+	ans.Feats = decodeFeats(key, kl.tokenFreqKeyLen())
+	return ans, nil
 }
 
-func TokenIDToBytes(tokenID uint32) []byte {
-	buf := make([]byte, 4)
-	binary.LittleEndian.PutUint32(buf, tokenID)
+// TokenIDToBytes encodes a token ID stored as a value
+// of the lemma -> ID indexes
+func (kl KeyLayout) TokenIDToBytes(tokenID uint64) []byte {
+	buf := make([]byte, kl.TokenIDWidth())
+	kl.putID(buf, tokenID)
 	return buf
 }
 
+// DecodeTokenID is a reverse function to TokenIDToBytes.
+// For values of unexpected lengths, ErrMalformedRecord is returned.
+func (kl KeyLayout) DecodeTokenID(val []byte) (uint64, error) {
+	if len(val) != kl.TokenIDWidth() {
+		return 0, fmt.Errorf(
+			"%w: token ID expected to have %d bytes, found %d", ErrMalformedRecord, kl.TokenIDWidth(), len(val))
+	}
+	return kl.id(val), nil
+}
+
 // TokenIDToRevIndexKey creates a key entry for the reverse index
-func TokenIDToRevIndexKey(tokenID uint32) []byte {
-	key := make([]byte, 5)
+func (kl KeyLayout) TokenIDToRevIndexKey(tokenID uint64) []byte {
+	key := make([]byte, 1+kl.TokenIDWidth())
 	key[0] = idToLemmaPrefix
-	binary.LittleEndian.PutUint32(key[1:5], tokenID)
+	kl.putID(key[1:], tokenID)
 	return key
 }
 
+// AllRevIndexKeys generates a db key prefix to search
+// for all the reverse index (tokenID -> lemma) entries
+func AllRevIndexKeys() []byte {
+	return []byte{idToLemmaPrefix}
+}
+
+// DecodeRevIndexKey is a reverse function to TokenIDToRevIndexKey
+func (kl KeyLayout) DecodeRevIndexKey(key []byte) uint64 {
+	return kl.id(key[1 : 1+kl.TokenIDWidth()])
+}
+
 // EncodeDistance encodes a floating-point distance to a byte.
 // Range: -12.7 to +12.7 with 0.1 precision
 // Encoding: 0-127 for negative values (-12.7 to -0.1), 128-255 for positive values (0.0 to +12.7)
@@ -227,10 +608,18 @@ func DecodeDistance(encoded byte) float64 {
 	}
 }
 
-// CollocValue represents the binary format for collocation values
+// CollocValue represents the binary format for collocation values.
+// The distance is always non-negative as the direction of the relation
+// is encoded in the key.
 type CollocValue struct {
 	Freq uint32
 	Dist float64
+
+	// SurfaceDist is an average linear distance of token2 from token1
+	// (positive = token2 follows token1). It is available only if
+	// HasSurfaceDist is true (older databases do not store the value).
+	SurfaceDist    float64
+	HasSurfaceDist bool
 }
 
 // EncodeCollocValue encodes frequency and distance into a 5-byte binary format
@@ -241,15 +630,86 @@ func EncodeCollocValue(freq uint32, avgDist float64) []byte {
 	return value
 }
 
-// DecodeCollocValue decodes a 5-byte binary format back to frequency and distance
-func DecodeCollocValue(data []byte) CollocValue {
-	if len(data) != 5 {
-		panic(fmt.Sprintf("DecodeCollocValue expected 5 bytes, got %d", len(data)))
+// EncodeCollocValueWithSurfaceDist encodes frequency, tree distance
+// and surface distance into a 6-byte binary format
+func EncodeCollocValueWithSurfaceDist(freq uint32, avgDist, avgSurfaceDist float64) []byte {
+	value := make([]byte, 6)
+	binary.LittleEndian.PutUint32(value[0:4], freq)
+	value[4] = EncodeDistance(avgDist)
+	value[5] = EncodeDistance(avgSurfaceDist)
+	return value
+}
+
+// DecodeCollocValue decodes a 5-byte or 6-byte binary format back
+// to frequency and distance(s). For other lengths, ErrMalformedRecord
+// is returned.
+func DecodeCollocValue(data []byte) (CollocValue, error) {
+	if len(data) != 5 && len(data) != 6 {
+		return CollocValue{}, fmt.Errorf(
+			"%w: collocation value expected to have 5 or 6 bytes, found %d", ErrMalformedRecord, len(data))
 	}
-	return CollocValue{
+	ans := CollocValue{
 		Freq: binary.LittleEndian.Uint32(data[0:4]),
-		Dist: DecodeDistance(data[4]),
+		// older databases store negative distances for pairs where
+		// the first token is a dependent so we have to normalize them
+		Dist: math.Abs(DecodeDistance(data[4])),
 	}
+	if len(data) == 6 {
+		ans.SurfaceDist = DecodeDistance(data[5])
+		ans.HasSurfaceDist = true
+	}
+	return ans, nil
+}
+
+// UnsignedCollocValue returns a copy of an encoded collocation value
+// with a negative distance (as stored by older databases for pairs where
+// the first token is a dependent) replaced by its absolute value.
+// The second returned value tells whether the replacement has been
+// performed. For values without a negative distance (or malformed ones),
+// the original value and false are returned.
+func UnsignedCollocValue(data []byte) ([]byte, bool) {
+	if len(data) != 5 && len(data) != 6 {
+		return data, false
+	}
+	dist := DecodeDistance(data[4])
+	if dist >= 0 {
+		return data, false
+	}
+	ans := make([]byte, len(data))
+	copy(ans, data)
+	ans[4] = EncodeDistance(-dist)
+	return ans, true
+}
+
+// EncodePairExamples encodes pair examples into a sequence of varints
+// (the position of the first token followed by the distance of the second one).
+func EncodePairExamples(examples []PairExample) []byte {
+	ans := make([]byte, 0, len(examples)*2*binary.MaxVarintLen32)
+	for _, ex := range examples {
+		ans = binary.AppendUvarint(ans, uint64(ex.Position))
+		ans = binary.AppendVarint(ans, int64(ex.CollocatePosition-ex.Position))
+	}
+	return ans
+}
+
+// DecodePairExamples is a reverse function to EncodePairExamples.
+// For data not matching the format, ErrMalformedRecord is returned.
+func DecodePairExamples(data []byte) ([]PairExample, error) {
+	ans := make([]PairExample, 0, 4)
+	for len(data) > 0 {
+		pos, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: invalid pair example position", ErrMalformedRecord)
+		}
+		data = data[n:]
+		dist, n := binary.Varint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: invalid pair example distance", ErrMalformedRecord)
+		}
+		data = data[n:]
+		ans = append(ans, PairExample{Position: int(pos), CollocatePosition: int(pos) + int(dist)})
+	}
+	return ans, nil
 }
 
 // TokenValue represents the binary format for token frequency values
@@ -264,12 +724,158 @@ func EncodeTokenValue(freq uint32) []byte {
 	return value
 }
 
-// DecodeTokenValue decodes a 4-byte binary format back to frequency
-func DecodeTokenValue(data []byte) TokenValue {
+// DecodeTokenValue decodes a 4-byte binary format back to frequency.
+// For other lengths, ErrMalformedRecord is returned.
+func DecodeTokenValue(data []byte) (TokenValue, error) {
 	if len(data) != 4 {
-		panic(fmt.Sprintf("DecodeTokenValue expected 4 bytes, got %d", len(data)))
+		return TokenValue{}, fmt.Errorf(
+			"%w: token value expected to have 4 bytes, found %d", ErrMalformedRecord, len(data))
 	}
 	return TokenValue{
 		Freq: binary.LittleEndian.Uint32(data),
+	}, nil
+}
+
+// IsMetadataKey tells whether the key belongs to a metadata record
+func IsMetadataKey(key []byte) bool {
+	return len(key) > 0 && key[0] == metadataPrefix
+}
+
+// IsLemmaToIDKey tells whether the key belongs to the (Lemma) -> (Lemma ID)
+// index, to its folded and lowercased variants or to the word form index. In such records,
+// the token ID is stored in the value.
+func IsLemmaToIDKey(key []byte) bool {
+	return len(key) > 0 &&
+		(key[0] == lemmaToIDPrefix || key[0] == foldedLemmaPrefix || key[0] == lowerLemmaPrefix ||
+			key[0] == wordFormToIDPrefix)
+}
+
+// EncodeLowerLemmaKey creates a key of the secondary lemma index
+// where lemmas containing uppercase letters are stored lowercased
+// and without diacritics (see NormalizeLemma). As more lemmas can be
+// normalized to the same value, the original lemma is also part of the key.
+func EncodeLowerLemmaKey(normalized, lemma string) []byte {
+	key := EncodeFoldedLemmaKey(normalized, lemma)
+	key[0] = lowerLemmaPrefix
+	return key
+}
+
+// EncodeLowerLemmaSearchKey creates a search prefix for the lowercased
+// lemma index. With isPrefix set to false, only the exact normalized
+// value is matched.
+func EncodeLowerLemmaSearchKey(normalized string, isPrefix bool) []byte {
+	key := EncodeFoldedLemmaSearchKey(normalized, isPrefix)
+	key[0] = lowerLemmaPrefix
+	return key
+}
+
+// RemapKeyTokenIDs returns a copy of the key with all the token IDs
+// replaced using the remap function. Keys without token IDs (metadata,
+// lemma -> ID index) are just copied.
+func (kl KeyLayout) RemapKeyTokenIDs(key []byte, remap func(tokenID uint64) (uint64, error)) ([]byte, error) {
+	ans := make([]byte, len(key))
+	copy(ans, key)
+	if len(key) == 0 {
+		return ans, nil
+	}
+	w := kl.TokenIDWidth()
+	var offsets []int
+	switch key[0] {
+	case idToLemmaPrefix, singleTokenPrefix, tokenRollupPrefix, topCollsPrefix:
+		offsets = []int{1}
+	case pairTokenPrefix, revPairTokenPrefix, hotPairPrefix, hotRevPairPrefix, pathPairPrefix, revPathPairPrefix:
+		offsets = []int{1, 5 + w}
+	case pairExamplesPrefix:
+		offsets = []int{1, 1 + w}
+	}
+	for _, off := range offsets {
+		if len(key) < off+w {
+			return nil, fmt.Errorf("failed to remap token ID of key %x: key too short", key)
+		}
+		newID, err := remap(kl.id(key[off : off+w]))
+		if err != nil {
+			return nil, err
+		}
+		kl.putID(ans[off:off+w], newID)
+	}
+	return ans, nil
+}
+
+// KeyNamespace returns a name of the namespace (i.e. the record type)
+// the key belongs to. For keys with prefixes unknown to this version
+// (e.g. created by a newer version or by a foreign tool), an empty
+// string is returned. This applies also to metadata keys with unknown
+// metadata IDs.
+func KeyNamespace(key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	switch key[0] {
+	case metadataPrefix:
+		if len(key) == 2 && (key[1] == MetadataKeyImportProfile || key[1] == MetadataKeyImportHistory) {
+			return "metadata"
+		}
+		return ""
+	case lemmaToIDPrefix:
+		return "lemmaToID"
+	case foldedLemmaPrefix:
+		return "foldedLemmaToID"
+	case lowerLemmaPrefix:
+		return "lowerLemmaToID"
+	case wordFormToIDPrefix:
+		return "wordFormToID"
+	case idToLemmaPrefix:
+		return "idToLemma"
+	case singleTokenPrefix:
+		return "tokenFreq"
+	case pairTokenPrefix:
+		return "pairFreq"
+	case revPairTokenPrefix:
+		return "revPairFreq"
+	case tokenRollupPrefix:
+		return "tokenRollup"
+	case hotPairPrefix:
+		return "hotPairFreq"
+	case hotRevPairPrefix:
+		return "hotRevPairFreq"
+	case topCollsPrefix:
+		return "topColls"
+	case pathPairPrefix:
+		return "pathPairFreq"
+	case revPathPairPrefix:
+		return "revPathPairFreq"
+	case pairExamplesPrefix:
+		return "pairExamples"
+	}
+	return ""
+}
+
+var namespacePrefixes = map[string]byte{
+	"metadata":        metadataPrefix,
+	"lemmaToID":       lemmaToIDPrefix,
+	"foldedLemmaToID": foldedLemmaPrefix,
+	"lowerLemmaToID":  lowerLemmaPrefix,
+	"wordFormToID":    wordFormToIDPrefix,
+	"idToLemma":       idToLemmaPrefix,
+	"tokenFreq":       singleTokenPrefix,
+	"pairFreq":        pairTokenPrefix,
+	"revPairFreq":     revPairTokenPrefix,
+	"tokenRollup":     tokenRollupPrefix,
+	"hotPairFreq":     hotPairPrefix,
+	"hotRevPairFreq":  hotRevPairPrefix,
+	"topColls":        topCollsPrefix,
+	"pathPairFreq":    pathPairPrefix,
+	"revPathPairFreq": revPathPairPrefix,
+	"pairExamples":    pairExamplesPrefix,
+}
+
+// NamespaceKeyPrefix returns a key prefix shared by all the keys
+// of the namespace (see KeyNamespace). For unknown namespaces,
+// false is returned.
+func NamespaceKeyPrefix(ns string) ([]byte, bool) {
+	prefix, ok := namespacePrefixes[ns]
+	if !ok {
+		return nil, false
 	}
+	return []byte{prefix}, true
 }
@@ -0,0 +1,135 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// udFeatDef describes how a single UD morphological feature
+// is encoded within UDFeats. Values are encoded as their
+// index + 1 (zero means the feature is not set).
+type udFeatDef struct {
+	name   string
+	shift  uint
+	bits   uint
+	values []string
+}
+
+// udFeatDefs lists all the supported features (in the UD order,
+// i.e. sorted alphabetically). Values not listed here are not
+// encoded.
+var udFeatDefs = []udFeatDef{
+	{
+		name:  "Case",
+		shift: 0,
+		bits:  4,
+		values: []string{
+			"Nom", "Gen", "Dat", "Acc", "Voc", "Loc", "Ins", "Abl",
+			"Par", "Ess", "Tra", "Com", "Abe", "Erg", "Abs",
+		},
+	},
+	{
+		name:   "Degree",
+		shift:  4,
+		bits:   3,
+		values: []string{"Pos", "Cmp", "Sup", "Abs", "Equ"},
+	},
+	{
+		name:   "Number",
+		shift:  7,
+		bits:   3,
+		values: []string{"Sing", "Plur", "Dual", "Ptan", "Coll", "Count", "Pauc"},
+	},
+	{
+		name:   "VerbForm",
+		shift:  10,
+		bits:   3,
+		values: []string{"Fin", "Inf", "Part", "Ger", "Conv", "Sup", "Vnoun"},
+	},
+}
+
+// UDFeats is a compact (2 bytes) encoding of selected UD morphological
+// features (see SupportedUDFeats) of a token. Zero value means
+// no features.
+type UDFeats uint16
+
+// SupportedUDFeats returns names of all the features
+// which can be encoded in UDFeats
+func SupportedUDFeats() []string {
+	ans := make([]string, len(udFeatDefs))
+	for i, def := range udFeatDefs {
+		ans[i] = def.name
+	}
+	return ans
+}
+
+// ValidateUDFeatNames tests whether all the feature names
+// are supported by UDFeats
+func ValidateUDFeatNames(names []string) error {
+	for _, name := range names {
+		if !slices.ContainsFunc(udFeatDefs, func(def udFeatDef) bool { return def.name == name }) {
+			return fmt.Errorf(
+				"unsupported morphological feature %s (supported: %s)",
+				name, strings.Join(SupportedUDFeats(), ", "))
+		}
+	}
+	return nil
+}
+
+// ParseUDFeats encodes features of the UD FEATS format
+// (e.g. "Case=Nom|Gender=Fem|Number=Sing"). Only features listed
+// in selected are encoded (an empty list means all the supported
+// features). Unsupported features and values (including multi-values
+// like "Case=Acc,Nom") are ignored.
+func ParseUDFeats(feats string, selected []string) UDFeats {
+	var ans UDFeats
+	if feats == "" || feats == "_" {
+		return ans
+	}
+	for _, item := range strings.Split(feats, "|") {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok || len(selected) > 0 && !slices.Contains(selected, name) {
+			continue
+		}
+		for _, def := range udFeatDefs {
+			if def.name != name {
+				continue
+			}
+			if idx := slices.Index(def.values, value); idx >= 0 {
+				ans |= UDFeats(idx+1) << def.shift
+			}
+			break
+		}
+	}
+	return ans
+}
+
+// String returns the features in the UD FEATS format
+// (without the "_" placeholder for empty features)
+func (f UDFeats) String() string {
+	items := make([]string, 0, len(udFeatDefs))
+	for _, def := range udFeatDefs {
+		idx := int(f>>def.shift) & (1<<def.bits - 1)
+		if idx > 0 && idx <= len(def.values) {
+			items = append(items, def.name+"="+def.values[idx-1])
+		}
+	}
+	return strings.Join(items, "|")
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUDFeats(t *testing.T) {
+	feats := ParseUDFeats("Case=Gen|Gender=Fem|Number=Plur|VerbForm=Part", nil)
+	assert.Equal(t, "Case=Gen|Number=Plur|VerbForm=Part", feats.String())
+
+	feats = ParseUDFeats("Case=Gen|Gender=Fem|Number=Plur", []string{"Number"})
+	assert.Equal(t, "Number=Plur", feats.String())
+
+	// unsupported and multiple values are ignored
+	assert.Equal(t, UDFeats(0), ParseUDFeats("Case=Acc,Nom|Number=Foo", nil))
+	assert.Equal(t, UDFeats(0), ParseUDFeats("_", nil))
+	assert.Equal(t, "", UDFeats(0).String())
+}
+
+func TestValidateUDFeatNames(t *testing.T) {
+	assert.NoError(t, ValidateUDFeatNames([]string{"Case", "Number"}))
+	assert.Error(t, ValidateUDFeatNames([]string{"Case", "Gender"}))
+}
+
+func TestKeysWithFeats(t *testing.T) {
+	feats := ParseUDFeats("Number=Plur", nil)
+	key := WithFeats(NarrowKeyLayout.CollFreqKey(true, 7, PosNOUN, 0x01, 3, 9, PosADJ), feats)
+	assert.Len(t, key, 18)
+	dec, err := NarrowKeyLayout.DecodeCollFreqKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), dec.Token1ID)
+	assert.Equal(t, uint64(9), dec.Token2ID)
+	assert.Equal(t, feats, dec.Feats)
+
+	key = WithFeats(NarrowKeyLayout.TokenFreqKey(7, PosNOUN, 0x01), feats)
+	dec, err = NarrowKeyLayout.DecodeTokenFreqKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0), dec.Deprel)
+	assert.Equal(t, feats, dec.Feats)
+
+	// zero features keep the original layout
+	assert.Equal(t, NarrowKeyLayout.TokenFreqKey(7, PosNOUN, 0x01), WithFeats(NarrowKeyLayout.TokenFreqKey(7, PosNOUN, 0x01), 0))
+	dec, err = NarrowKeyLayout.DecodeTokenFreqKey(NarrowKeyLayout.TokenFreqKey(7, PosNOUN, 0x01))
+	assert.NoError(t, err)
+	assert.Equal(t, UDFeats(0), dec.Feats)
+}
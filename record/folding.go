@@ -0,0 +1,108 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import "strings"
+
+// foldedLetters maps ASCII letters to their variants with diacritics
+// (Latin-1 Supplement and Latin Extended-A/B blocks)
+var foldedLetters = map[string]string{
+	"A": "ÀÁÂÃÄÅĀĂĄǍǞǠǺȀȂȦ",
+	"C": "ÇĆĈĊČ",
+	"D": "Ď",
+	"E": "ÈÉÊËĒĔĖĘĚȄȆȨ",
+	"G": "ĜĞĠĢǦǴ",
+	"H": "ĤȞ",
+	"I": "ÌÍÎÏĨĪĬĮİǏȈȊ",
+	"J": "Ĵ",
+	"K": "ĶǨ",
+	"L": "ĹĻĽ",
+	"N": "ÑŃŅŇǸ",
+	"O": "ÒÓÔÕÖŌŎŐƠǑǪǬȌȎȪȬȮȰ",
+	"R": "ŔŖŘȐȒ",
+	"S": "ŚŜŞŠȘ",
+	"T": "ŢŤȚ",
+	"U": "ÙÚÛÜŨŪŬŮŰŲƯǓǕǗǙǛȔȖ",
+	"W": "Ŵ",
+	"Y": "ÝŶŸȲ",
+	"Z": "ŹŻŽ",
+	"a": "àáâãäåāăąǎǟǡǻȁȃȧ",
+	"c": "çćĉċč",
+	"d": "ď",
+	"e": "èéêëēĕėęěȅȇȩ",
+	"g": "ĝğġģǧǵ",
+	"h": "ĥȟ",
+	"i": "ìíîïĩīĭįǐȉȋ",
+	"j": "ĵǰ",
+	"k": "ķǩ",
+	"l": "ĺļľ",
+	"n": "ñńņňǹ",
+	"o": "òóôõöōŏőơǒǫǭȍȏȫȭȯȱ",
+	"r": "ŕŗřȑȓ",
+	"s": "śŝşšș",
+	"t": "ţťț",
+	"u": "ùúûüũūŭůűųưǔǖǘǚǜȕȗ",
+	"w": "ŵ",
+	"y": "ýÿŷȳ",
+	"z": "źżž",
+}
+
+// foldedSpecial contains transliterations of letters which are not
+// composed of a base letter and a diacritic mark
+var foldedSpecial = map[rune]string{
+	'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'ß': "ss",
+	'Ø': "O", 'ø': "o", 'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d",
+	'Ð': "D", 'ð': "d", 'Þ': "TH", 'þ': "th", 'Ħ': "H", 'ħ': "h",
+	'ı': "i",
+}
+
+var foldingTable map[rune]string
+
+func init() {
+	foldingTable = make(map[rune]string, 400)
+	for base, variants := range foldedLetters {
+		for _, v := range variants {
+			foldingTable[v] = base
+		}
+	}
+	for v, base := range foldedSpecial {
+		foldingTable[v] = base
+	}
+}
+
+// FoldLemma removes diacritics from a lemma and transliterates
+// special Latin letters (e.g. ß, ø, ł) to plain ASCII ones
+// (hřiště -> hriste). Letter case is preserved.
+func FoldLemma(lemma string) string {
+	var ans strings.Builder
+	ans.Grow(len(lemma))
+	for _, c := range lemma {
+		if f, ok := foldingTable[c]; ok {
+			ans.WriteString(f)
+
+		} else {
+			ans.WriteRune(c)
+		}
+	}
+	return ans.String()
+}
+
+// NormalizeLemma lowercases a lemma and removes its diacritics
+// (see FoldLemma), e.g. Hřiště -> hriste
+func NormalizeLemma(lemma string) string {
+	return strings.ToLower(FoldLemma(lemma))
+}
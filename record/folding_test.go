@@ -0,0 +1,59 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldLemma(t *testing.T) {
+	assert.Equal(t, "hriste", FoldLemma("hřiště"))
+	assert.Equal(t, "Zlutoucky kun", FoldLemma("Žluťoučký kůň"))
+	assert.Equal(t, "Strasse", FoldLemma("Straße"))
+	assert.Equal(t, "Lodz", FoldLemma("Łódź"))
+	assert.Equal(t, "plain", FoldLemma("plain"))
+}
+
+func TestFoldedLemmaKey(t *testing.T) {
+	key := EncodeFoldedLemmaKey("hriste", "hřiště")
+	assert.Equal(t, "foldedLemmaToID", KeyNamespace(key))
+	assert.True(t, IsLemmaToIDKey(key))
+	assert.Equal(t, "hřiště", DecodeFoldedLemmaKey(key))
+	assert.True(t, bytes.HasPrefix(key, EncodeFoldedLemmaSearchKey("hriste", false)))
+	assert.True(t, bytes.HasPrefix(key, EncodeFoldedLemmaSearchKey("hri", true)))
+	assert.False(t, bytes.HasPrefix(key, EncodeFoldedLemmaSearchKey("hri", false)))
+}
+
+func TestNormalizeLemma(t *testing.T) {
+	assert.Equal(t, "hriste", NormalizeLemma("Hřiště"))
+	assert.Equal(t, "praha", NormalizeLemma("PRAHA"))
+	assert.Equal(t, "lodz", NormalizeLemma("Łódź"))
+	assert.Equal(t, "plain", NormalizeLemma("plain"))
+}
+
+func TestLowerLemmaKey(t *testing.T) {
+	key := EncodeLowerLemmaKey("praha", "Praha")
+	assert.Equal(t, "lowerLemmaToID", KeyNamespace(key))
+	assert.True(t, IsLemmaToIDKey(key))
+	assert.Equal(t, "Praha", DecodeFoldedLemmaKey(key))
+	assert.True(t, bytes.HasPrefix(key, EncodeLowerLemmaSearchKey("praha", false)))
+	assert.True(t, bytes.HasPrefix(key, EncodeLowerLemmaSearchKey("pra", true)))
+	assert.False(t, bytes.HasPrefix(key, EncodeFoldedLemmaSearchKey("praha", true)))
+}
@@ -17,7 +17,9 @@
 package record
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 )
 
 // ----
@@ -35,6 +37,10 @@ type TokenFreq struct {
 	PoS      UDPoS
 	Freq     int
 	TextType TextType
+
+	// Feats contains selected morphological features
+	// of the token (if imported)
+	Feats UDFeats
 }
 
 func (otf TokenFreq) IsZero() bool {
@@ -58,10 +64,17 @@ func (otf TokenFreq) HasPoS() bool {
 // Key produces a key dependent on all value's properties except for the frequency. It allows
 // e.g. for incremental calculation of lemma's frequency as we process a text source file.
 func (otf TokenFreq) Key() GroupingKey {
+	var ans string
 	if otf.PoS.IsValid() {
-		return GroupingKey(fmt.Sprintf("%x|%s|%x", otf.TextType.Byte(), otf.Lemma, otf.PoS.Byte()))
+		ans = fmt.Sprintf("%x|%s|%x", otf.TextType.Byte(), otf.Lemma, otf.PoS.Byte())
+
+	} else {
+		ans = fmt.Sprintf("%x|%s|-", otf.TextType.Byte(), otf.Lemma)
 	}
-	return GroupingKey(fmt.Sprintf("%x|%s|-", otf.TextType.Byte(), otf.Lemma))
+	if otf.Feats != 0 {
+		ans += fmt.Sprintf("|%x", uint16(otf.Feats))
+	}
+	return GroupingKey(ans)
 }
 
 // LemmaKey generates a key dependent just on the actual lemma (i.e. no PoS etc.).
@@ -85,6 +98,24 @@ func SumTokenFreqs(items []TokenFreq) int {
 
 // -------
 
+// DepDirection specifies syntactic role of the first lemma of a pair
+type DepDirection byte
+
+const (
+
+	// DirectionUnknown is used by legacy producers which encode
+	// the direction via the sign of AVGDist (non-negative = head)
+	DirectionUnknown DepDirection = iota
+	DirectionHead
+	DirectionDependent
+
+	// DirectionSibling is used for pairs of tokens sharing the same
+	// head where none of the tokens is the head of the other. Such
+	// pairs are stored in both the orders, with neither of the
+	// lemmas being the head (i.e. IsHead returns false).
+	DirectionSibling
+)
+
 type CollocFreq struct {
 	Lemma1   string
 	PoS1     UDPoS
@@ -94,6 +125,27 @@ type CollocFreq struct {
 	Freq     int
 	AVGDist  float64
 	TextType TextType
+
+	// Direction tells whether Lemma1 is the head or the dependent.
+	// With explicit direction, AVGDist should be a non-negative value.
+	Direction DepDirection
+
+	// AVGSurfaceDist is an average linear (word order) distance
+	// of Lemma2 from Lemma1 (positive = Lemma2 follows Lemma1)
+	AVGSurfaceDist float64
+
+	// WeightedFreq is an alternative frequency accumulated
+	// with co-occurrence weights applied (if any weighting is used
+	// during import)
+	WeightedFreq float64
+
+	// Feats1 contains selected morphological features
+	// of Lemma1 (if imported)
+	Feats1 UDFeats
+
+	// Examples contains a sample of occurrences of the pair
+	// (if collected during import)
+	Examples []PairExample
 }
 
 func (cf CollocFreq) String() string {
@@ -110,24 +162,65 @@ func (cf CollocFreq) String() string {
 	)
 }
 
+// IsHead tells whether Lemma1 is the head of the pair
+func (cf CollocFreq) IsHead() bool {
+	switch cf.Direction {
+	case DirectionHead:
+		return true
+	case DirectionDependent, DirectionSibling:
+		return false
+	}
+	return cf.AVGDist >= 0
+}
+
 func (cf *CollocFreq) UpdateFreqAndDist(freq, dist int) {
 	// create a continuous average of distance between lemma1 and lemma2
 	cf.AVGDist = (float64(cf.Freq)*cf.AVGDist + float64(dist)) / float64(cf.Freq+1)
 	cf.Freq += freq
 }
 
+// UpdateSurfaceDist updates the average surface distance with
+// a new observation. It must be called before UpdateFreqAndDist
+// as it relies on the current frequency.
+func (cf *CollocFreq) UpdateSurfaceDist(freq, surfaceDist int) {
+	cf.AVGSurfaceDist = (float64(cf.Freq)*cf.AVGSurfaceDist + float64(freq*surfaceDist)) / float64(cf.Freq+freq)
+}
+
+// Merge adds frequencies of other (typically collected separately
+// for the same key) with average distances weighted by the respective
+// frequencies.
+func (cf *CollocFreq) Merge(other CollocFreq) {
+	total := cf.Freq + other.Freq
+	if total > 0 {
+		cf.AVGDist = (float64(cf.Freq)*cf.AVGDist + float64(other.Freq)*other.AVGDist) / float64(total)
+		cf.AVGSurfaceDist = (float64(cf.Freq)*cf.AVGSurfaceDist +
+			float64(other.Freq)*other.AVGSurfaceDist) / float64(total)
+	}
+	cf.Freq = total
+	cf.WeightedFreq += other.WeightedFreq
+	if len(other.Examples) > 0 {
+		cf.Examples = MergePairExamples(cf.Examples, other.Examples, 0)
+	}
+}
+
 func (cf CollocFreq) Key() GroupingKey {
 	headDep := "h"
-	if cf.AVGDist < 0 {
+	if !cf.IsHead() {
 		headDep = "d"
 	}
+	var ans string
 	if cf.PoS1.IsValid() && cf.PoS2.IsValid() {
-		return GroupingKey(
-			fmt.Sprintf(
-				"%x|%s|%s|%x|%x|%s|%x",
-				cf.TextType.Byte(), cf.Lemma1, headDep, cf.PoS1.Byte(), cf.Deprel.AsUint16(), cf.Lemma2, cf.PoS2.Byte()))
+		ans = fmt.Sprintf(
+			"%x|%s|%s|%x|%x|%s|%x",
+			cf.TextType.Byte(), cf.Lemma1, headDep, cf.PoS1.Byte(), cf.Deprel.AsUint16(), cf.Lemma2, cf.PoS2.Byte())
+
+	} else {
+		ans = fmt.Sprintf("%x|%s|%s|%x|%s", cf.TextType.Byte(), cf.Lemma1, headDep, cf.Deprel.AsUint16(), cf.Lemma2)
 	}
-	return GroupingKey(fmt.Sprintf("%x|%s|%s|%x|%s", cf.TextType.Byte(), cf.Lemma1, headDep, cf.Deprel.AsUint16(), cf.Lemma2))
+	if cf.Feats1 != 0 {
+		ans += fmt.Sprintf("|%x", uint16(cf.Feats1))
+	}
+	return GroupingKey(ans)
 }
 
 func (cf CollocFreq) Lemma1Key() string {
@@ -145,3 +238,31 @@ func SumCollocFreqs(items []CollocFreq) int {
 	}
 	return ans
 }
+
+// PairExample refers to an occurrence of a pair of tokens in the source
+// corpus using corpus positions of both the tokens. Sentences containing
+// the pair can be found via the positions (e.g. in a concordance).
+type PairExample struct {
+	Position          int
+	CollocatePosition int
+}
+
+// MergePairExamples combines two samples of pair examples. The result
+// is sorted by positions and contains no duplicates. With limit > 0,
+// only the first limit examples are kept.
+func MergePairExamples(a, b []PairExample, limit int) []PairExample {
+	ans := make([]PairExample, 0, len(a)+len(b))
+	ans = append(ans, a...)
+	ans = append(ans, b...)
+	slices.SortFunc(ans, func(e1, e2 PairExample) int {
+		return cmp.Or(
+			cmp.Compare(e1.Position, e2.Position),
+			cmp.Compare(e1.CollocatePosition, e2.CollocatePosition),
+		)
+	})
+	ans = slices.Compact(ans)
+	if limit > 0 && len(ans) > limit {
+		ans = ans[:limit]
+	}
+	return ans
+}
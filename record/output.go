@@ -23,22 +23,24 @@ import (
 )
 
 type RawTokenFreq struct {
-	TokenID  uint32
+	TokenID  uint64
 	PoS      byte
 	Freq     uint32
 	TextType byte
+	Feats    UDFeats
 }
 
 // BinaryKey represents a binary grouping key for high-performance map operations
-type BinaryKey [6]byte
+type BinaryKey [12]byte
 
-// GroupingKeyBinary creates a binary key (8 bytes) instead of string key
-// Layout: [TokenID:4][PoS:1][Deprel:2][TextType:1][padding:1]
+// GroupingKeyBinary creates a binary key (12 bytes) instead of string key
+// Layout: [TokenID:8][PoS:1][TextType:1][Feats:2]
 func (rtf RawTokenFreq) GroupingKeyBinary() BinaryKey {
 	var key BinaryKey
-	binary.LittleEndian.PutUint32(key[0:4], rtf.TokenID)
-	key[4] = rtf.PoS
-	key[5] = rtf.TextType
+	binary.LittleEndian.PutUint64(key[0:8], rtf.TokenID)
+	key[8] = rtf.PoS
+	key[9] = rtf.TextType
+	binary.LittleEndian.PutUint16(key[10:12], uint16(rtf.Feats))
 	return key
 }
 
@@ -73,55 +75,67 @@ func (rtf RawTokenFreq) GroupingKeyOptimized() string {
 // -------------------
 
 type RawCollocFreq struct {
-	Token1ID uint32
+	Token1ID uint64
 	PoS1     byte
 	Deprel   uint16
-	Token2ID uint32
+	Token2ID uint64
 	PoS2     byte
 	Freq     uint32
 	AVGDist  float64
 	TextType byte
+	IsHead   bool
+
+	// AVGSurfaceDist is an average linear distance of Token2
+	// from Token1 (positive = Token2 follows Token1)
+	AVGSurfaceDist float64
+
+	// Feats1 contains morphological features of Token1
+	Feats1 UDFeats
 }
 
-// CollBinaryKey represents a binary grouping key for collocation data (16 bytes)
-type CollBinaryKey [16]byte
+// CollBinaryKey represents a binary grouping key for collocation data (24 bytes)
+type CollBinaryKey [24]byte
 
 // GroupingKeyBinary creates a binary key for full collocation grouping
-// Layout: [Token1ID:4][PoS1:1][Deprel1:1][Token2ID:4][PoS2:1][Deprel2:1][TextType:1][padding:3]
+// Layout: [Token1ID:8][PoS1:1][Deprel:2][Token2ID:8][PoS2:1][TextType:1][IsHead:1][Feats1:2]
 func (rcf RawCollocFreq) GroupingKeyBinary() CollBinaryKey {
 	var key CollBinaryKey
-	binary.LittleEndian.PutUint32(key[0:4], rcf.Token1ID)
-	key[4] = rcf.PoS1
-	binary.LittleEndian.PutUint16(key[5:7], rcf.Deprel)
-	binary.LittleEndian.PutUint32(key[7:11], rcf.Token2ID)
-	key[11] = rcf.PoS2
-	key[12] = rcf.TextType
-	// key[13:16] is padding/unused
+	binary.LittleEndian.PutUint64(key[0:8], rcf.Token1ID)
+	key[8] = rcf.PoS1
+	binary.LittleEndian.PutUint16(key[9:11], rcf.Deprel)
+	binary.LittleEndian.PutUint64(key[11:19], rcf.Token2ID)
+	key[19] = rcf.PoS2
+	key[20] = rcf.TextType
+	if rcf.IsHead {
+		key[21] = 1
+	}
+	binary.LittleEndian.PutUint16(key[22:24], uint16(rcf.Feats1))
 	return key
 }
 
-// GroupingKeyLemma1Binary creates a binary key for first lemma grouping (8 bytes)
+// GroupingKeyLemma1Binary creates a binary key for first lemma grouping (12 bytes)
 func (rcf RawCollocFreq) GroupingKeyLemma1Binary() BinaryKey {
 	var key BinaryKey
-	binary.LittleEndian.PutUint32(key[0:4], rcf.Token1ID)
-	key[4] = rcf.PoS1
-	key[5] = rcf.TextType
+	binary.LittleEndian.PutUint64(key[0:8], rcf.Token1ID)
+	key[8] = rcf.PoS1
+	key[9] = rcf.TextType
+	binary.LittleEndian.PutUint16(key[10:12], uint16(rcf.Feats1))
 	return key
 }
 
-// GroupingKeyLemma2Binary creates a binary key for second lemma grouping (8 bytes)
+// GroupingKeyLemma2Binary creates a binary key for second lemma grouping (12 bytes)
 func (rcf RawCollocFreq) GroupingKeyLemma2Binary() BinaryKey {
 	var key BinaryKey
-	binary.LittleEndian.PutUint32(key[0:4], rcf.Token2ID)
-	key[4] = rcf.PoS2
-	key[5] = rcf.TextType
+	binary.LittleEndian.PutUint64(key[0:8], rcf.Token2ID)
+	key[8] = rcf.PoS2
+	key[9] = rcf.TextType
 	return key
 }
 
 func (rcf RawCollocFreq) GroupingKey() string {
 
 	var keyBuff strings.Builder
-	if rcf.AVGDist > 0 {
+	if rcf.IsHead {
 		keyBuff.WriteString("H")
 
 	} else {
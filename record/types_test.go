@@ -17,6 +17,7 @@
 package record
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -408,3 +409,151 @@ func TestCollocFreq_UpdateFreqAndDist_Calculation(t *testing.T) {
 		})
 	}
 }
+
+func TestCollocFreq_UpdateSurfaceDist(t *testing.T) {
+	cf := CollocFreq{Freq: 2, AVGSurfaceDist: -1}
+	cf.UpdateSurfaceDist(2, 2)
+	cf.UpdateFreqAndDist(2, 1)
+	assert.InDelta(t, 0.5, cf.AVGSurfaceDist, 0.0001)
+}
+
+func TestDecodeCollocValueVariants(t *testing.T) {
+	v, err := DecodeCollocValue(EncodeCollocValue(10, -1.5))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(10), v.Freq)
+	assert.InDelta(t, 1.5, v.Dist, 0.0001)
+	assert.False(t, v.HasSurfaceDist)
+
+	v, err = DecodeCollocValue(EncodeCollocValueWithSurfaceDist(10, 1.5, -2.3))
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.5, v.Dist, 0.0001)
+	assert.True(t, v.HasSurfaceDist)
+	assert.InDelta(t, -2.3, v.SurfaceDist, 0.0001)
+}
+
+func TestUnsignedCollocValue(t *testing.T) {
+	signed := EncodeCollocValueWithSurfaceDist(10, -1.5, -2.3)
+	v, changed := UnsignedCollocValue(signed)
+	assert.True(t, changed)
+	assert.Equal(t, EncodeCollocValueWithSurfaceDist(10, 1.5, -2.3), v)
+	assert.Equal(t, EncodeCollocValueWithSurfaceDist(10, -1.5, -2.3), signed)
+
+	unsigned := EncodeCollocValue(10, 1.5)
+	v, changed = UnsignedCollocValue(unsigned)
+	assert.False(t, changed)
+	assert.Equal(t, unsigned, v)
+
+	_, changed = UnsignedCollocValue([]byte{0x01, 0x02})
+	assert.False(t, changed)
+}
+
+func TestDecodeMalformedRecords(t *testing.T) {
+	_, err := DecodeCollocValue([]byte{0x01, 0x02})
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+	_, err = DecodeTokenValue([]byte{0x01, 0x02, 0x03, 0x04, 0x05})
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+	_, err = NarrowKeyLayout.DecodeTokenFreqKey([]byte{singleTokenPrefix, 0x01})
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+	_, err = NarrowKeyLayout.DecodeTokenFreqRollupKey([]byte{tokenRollupPrefix, 0x01, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+	_, err = NarrowKeyLayout.DecodeCollFreqKey(NarrowKeyLayout.CollFreqKey(true, 7, PosNOUN, 0x01, 3, 9, PosADJ)[:10])
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+}
+
+func TestPathCollFreqKey(t *testing.T) {
+	for _, isHead := range []bool{true, false} {
+		key := AsPathCollFreqKey(WithFeats(NarrowKeyLayout.CollFreqKey(isHead, 7, PosVERB, 0x01, 3, 9, PosADJ), 0x05))
+		decKey, err := NarrowKeyLayout.DecodeCollFreqKey(key)
+		assert.NoError(t, err)
+		assert.Equal(t, isHead, decKey.IsHead)
+		assert.Equal(t, uint64(7), decKey.Token1ID)
+		assert.Equal(t, uint64(9), decKey.Token2ID)
+		assert.Equal(t, UDFeats(0x05), decKey.Feats)
+		assert.True(t, bytes.HasPrefix(key, NarrowKeyLayout.AllPathCollFreqsOfToken(isHead, 7)))
+		assert.False(t, bytes.HasPrefix(key, NarrowKeyLayout.AllCollFreqsOfToken(isHead, 7)))
+	}
+	assert.Equal(t, "pathPairFreq", KeyNamespace(NarrowKeyLayout.AllPathCollFreqsOfToken(true, 7)))
+	assert.Equal(t, "revPathPairFreq", KeyNamespace(NarrowKeyLayout.AllPathCollFreqsOfToken(false, 7)))
+}
+
+func TestPairExamplesEncoding(t *testing.T) {
+	examples := []PairExample{{Position: 0, CollocatePosition: 2}, {Position: 1500, CollocatePosition: 1497}}
+	decoded, err := DecodePairExamples(EncodePairExamples(examples))
+	assert.NoError(t, err)
+	assert.Equal(t, examples, decoded)
+	_, err = DecodePairExamples([]byte{0x80})
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+
+	key := NarrowKeyLayout.PairExamplesKey(7, 9)
+	assert.Equal(t, "pairExamples", KeyNamespace(key))
+	token1ID, token2ID, err := NarrowKeyLayout.DecodePairExamplesKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), token1ID)
+	assert.Equal(t, uint64(9), token2ID)
+}
+
+func TestMergePairExamples(t *testing.T) {
+	a := []PairExample{{Position: 30, CollocatePosition: 31}, {Position: 10, CollocatePosition: 11}}
+	b := []PairExample{{Position: 20, CollocatePosition: 19}, {Position: 10, CollocatePosition: 11}}
+	assert.Equal(
+		t,
+		[]PairExample{{Position: 10, CollocatePosition: 11}, {Position: 20, CollocatePosition: 19}},
+		MergePairExamples(a, b, 2),
+	)
+	assert.Len(t, MergePairExamples(a, b, 0), 3)
+}
+
+func TestWideKeyLayout(t *testing.T) {
+	keys, err := KeyLayoutByIDWidth(8)
+	assert.NoError(t, err)
+	assert.Equal(t, WideKeyLayout, keys)
+	_, err = KeyLayoutByIDWidth(6)
+	assert.Error(t, err)
+
+	bigID := uint64(1)<<40 + 7
+	formID := keys.WordFormTokenIDFlag() | 9
+	assert.True(t, keys.IsWordFormTokenID(formID))
+	assert.False(t, keys.IsWordFormTokenID(bigID))
+	assert.Less(t, formID, uint64(1)<<63)
+
+	key := WithFeats(keys.CollFreqKey(true, bigID, PosNOUN, 0x01, 3, formID, PosADJ), 0x05)
+	assert.Len(t, key, 26)
+	dec, err := keys.DecodeCollFreqKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, bigID, dec.Token1ID)
+	assert.Equal(t, formID, dec.Token2ID)
+	assert.Equal(t, byte(PosNOUN), dec.Pos1)
+	assert.Equal(t, byte(0x01), dec.TextType)
+	assert.Equal(t, uint16(3), dec.Deprel)
+	assert.Equal(t, byte(PosADJ), dec.Pos2)
+	assert.Equal(t, UDFeats(0x05), dec.Feats)
+	assert.True(t, bytes.HasPrefix(key, keys.AllCollFreqsOfToken(true, bigID)))
+
+	// narrow token keys with features have the same length as wide ones without them
+	key = keys.TokenFreqKey(bigID, PosNOUN, 0x01)
+	assert.Len(t, key, len(WithFeats(NarrowKeyLayout.TokenFreqKey(7, PosNOUN, 0x01), 0x05)))
+	dec, err = keys.DecodeTokenFreqKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, bigID, dec.Token1ID)
+	assert.Equal(t, UDFeats(0), dec.Feats)
+
+	dec, err = keys.DecodeTokenFreqRollupKey(keys.TokenFreqRollupKey(bigID, PosNOUN))
+	assert.NoError(t, err)
+	assert.Equal(t, bigID, dec.Token1ID)
+	assert.Equal(t, bigID, keys.DecodeRevIndexKey(keys.TokenIDToRevIndexKey(bigID)))
+	tokenID, err := keys.DecodeTokenID(keys.TokenIDToBytes(bigID))
+	assert.NoError(t, err)
+	assert.Equal(t, bigID, tokenID)
+	_, err = keys.DecodeTokenID(NarrowKeyLayout.TokenIDToBytes(7))
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+
+	remapped, err := keys.RemapKeyTokenIDs(
+		keys.PairExamplesKey(bigID, 9),
+		func(tokenID uint64) (uint64, error) { return tokenID + 1, nil },
+	)
+	assert.NoError(t, err)
+	token1ID, token2ID, err := keys.DecodePairExamplesKey(remapped)
+	assert.NoError(t, err)
+	assert.Equal(t, bigID+1, token1ID)
+	assert.Equal(t, uint64(10), token2ID)
+}
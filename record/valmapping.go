@@ -16,7 +16,15 @@
 
 package record
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
 
 const (
 	DeprelAcl         = 0x0001
@@ -68,6 +76,10 @@ const (
 	DeprelVocative    = 0x002f
 	DeprelXcomp       = 0x0030
 
+	// DeprelSibling is a pseudo-deprel for pairs of tokens
+	// sharing the same head
+	DeprelSibling = 0x0031
+
 	PosADJ         = 0x01
 	PosADP         = 0x02 // (includes prepositions)
 	PosADV         = 0x03
@@ -98,11 +110,18 @@ const (
 	PosPROPN_PROPN = 0x1c
 )
 
+// ErrDeprelMappingFull is returned when there is no free code
+// for a newly registered deprel value
+var ErrDeprelMappingFull = errors.New("no free deprel code left")
+
 // DeprelMapping allows for mapping between string names/codes
 // of core deprel values and their internal byte representation.
 // It's native mapping is from strings to bytes but it is also
 // handle repeated reverse lookups via caching.
+// The mapping is safe for concurrent use (values can be registered
+// by parallel import workers).
 type DeprelMapping struct {
+	mu       sync.RWMutex
 	items    map[string]uint16
 	revCache map[uint16]string
 	maxValue uint16
@@ -110,6 +129,8 @@ type DeprelMapping struct {
 
 // Get provides a byte representation based on string name/code.
 func (udm *DeprelMapping) Get(key string) (uint16, bool) {
+	udm.mu.RLock()
+	defer udm.mu.RUnlock()
 	v, ok := udm.items[key]
 	return v, ok
 }
@@ -122,19 +143,53 @@ func (udm *DeprelMapping) Get(key string) (uint16, bool) {
 // removing/shrinking "useless" nodes.
 //
 // Calling the method with an already registered key causes panic.
-func (udm *DeprelMapping) Register(key string) {
+// In case all the codes are used, ErrDeprelMappingFull is returned.
+func (udm *DeprelMapping) Register(key string) error {
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
 	if _, test := udm.items[key]; test {
 		panic(fmt.Errorf("cannot register deprel value - %s is aleady registered", key))
 	}
+	_, err := udm.register(key)
+	return err
+}
+
+// register attaches a new code to the key. The caller
+// must hold the write lock.
+func (udm *DeprelMapping) register(key string) (uint16, error) {
+	if udm.maxValue == math.MaxUint16 {
+		return 0, fmt.Errorf("failed to register deprel %s: %w", key, ErrDeprelMappingFull)
+	}
 	udm.items[key] = udm.maxValue
 	udm.maxValue++
+	return udm.items[key], nil
+}
+
+// GetOrRegister provides a code of the provided value. In case the value
+// is not registered yet, it is registered first. In case all the codes
+// are used, ErrDeprelMappingFull is returned.
+func (udm *DeprelMapping) GetOrRegister(key string) (uint16, error) {
+	if v, ok := udm.Get(key); ok {
+		return v, nil
+	}
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
+	// the value may have been registered in the meantime
+	if v, ok := udm.items[key]; ok {
+		return v, nil
+	}
+	return udm.register(key)
 }
 
 func (udm *DeprelMapping) GetRev(val uint16) string {
+	udm.mu.RLock()
 	v, ok := udm.revCache[val]
+	udm.mu.RUnlock()
 	if ok {
 		return v
 	}
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
 	for k, v := range udm.items {
 		if v == val {
 			if udm.revCache == nil {
@@ -147,10 +202,79 @@ func (udm *DeprelMapping) GetRev(val uint16) string {
 	return ""
 }
 
-// AsMap returns the internal mapping representation
+// CoreLabel removes relation subtypes from a deprel label
+// (e.g. "obl:arg" => "obl"). In relation path labels, all the
+// involved relations are processed (e.g. "acl:relcl→obl:arg" => "acl→obl").
+func CoreLabel(label string) string {
+	var ans strings.Builder
+	inSubtype := false
+	for _, r := range label {
+		switch {
+		case r == '→' || r == '←':
+			inSubtype = false
+			ans.WriteRune(r)
+		case r == ':':
+			inSubtype = true
+		case !inSubtype:
+			ans.WriteRune(r)
+		}
+	}
+	return ans.String()
+}
+
+// CoreOf provides a code of the core relation of the provided deprel
+// (see CoreLabel). In case the core relation is not registered,
+// the original value is returned.
+func (udm *DeprelMapping) CoreOf(val uint16) uint16 {
+	label := udm.GetRev(val)
+	if !strings.Contains(label, ":") {
+		return val
+	}
+	if v, ok := udm.Get(CoreLabel(label)); ok {
+		return v
+	}
+	return val
+}
+
+// RegisterAll registers all the values of src (typically a previously
+// exported extended mapping) not known yet. The values are registered
+// in the order of their codes so for the same base mapping, the resulting
+// codes match the source ones. An error is returned if this is not
+// the case.
+func (udm *DeprelMapping) RegisterAll(src map[string]uint16) error {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return int(src[a]) - int(src[b])
+	})
+	for _, k := range keys {
+		v, err := udm.GetOrRegister(k)
+		if err != nil {
+			return err
+		}
+		if v != src[k] {
+			return fmt.Errorf("incompatible deprel mapping - %s has code %d, expected %d", k, v, src[k])
+		}
+	}
+	return nil
+}
+
+// AsMap returns a copy of the internal mapping representation
 // (i.e. string representation => byte code)
 func (udm *DeprelMapping) AsMap() map[string]uint16 {
-	return udm.items
+	udm.mu.RLock()
+	defer udm.mu.RUnlock()
+	return maps.Clone(udm.items)
+}
+
+// IsSiblingDeprel tells whether the relation label describes
+// a pair of siblings (i.e. the "sibling" pseudo-deprel or
+// a "dep1←head→dep2" label, see DeprelSibling).
+func IsSiblingDeprel(label string) bool {
+	return label == UDDeprelMapping.GetRev(DeprelSibling) ||
+		strings.Contains(label, "←") && strings.Contains(label, "→")
 }
 
 // DeprelMappingFromMap is used for instantiating (possibly extended) deprel
@@ -213,6 +337,7 @@ var UDDeprelMapping = DeprelMapping{
 		"root":         DeprelRoot,
 		"vocative":     DeprelVocative,
 		"xcomp":        DeprelXcomp,
+		"sibling":      DeprelSibling,
 		// dynamically generated values should start with 0x0100
 	},
 }
@@ -0,0 +1,77 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoreLabel(t *testing.T) {
+	assert.Equal(t, "obl", CoreLabel("obl:arg"))
+	assert.Equal(t, "obl", CoreLabel("obl"))
+	assert.Equal(t, "acl→obl", CoreLabel("acl:relcl→obl:arg"))
+	assert.Equal(t, "nsubj←amod", CoreLabel("nsubj:pass←amod"))
+}
+
+func TestDeprelMappingCoreOf(t *testing.T) {
+	assert.Equal(t, uint16(DeprelObl), UDDeprelMapping.CoreOf(DeprelOblArg))
+	assert.Equal(t, uint16(DeprelAcl), UDDeprelMapping.CoreOf(DeprelAclRelcl))
+	assert.Equal(t, uint16(DeprelNsubj), UDDeprelMapping.CoreOf(DeprelNsubj))
+}
+
+func TestDeprelMappingRegisterAll(t *testing.T) {
+	mapping := DeprelMapping{maxValue: 0x100, items: map[string]uint16{"amod": DeprelAmod}}
+	src := map[string]uint16{"amod": DeprelAmod, "x:second": 0x101, "x:first": 0x100}
+	assert.NoError(t, mapping.RegisterAll(src))
+	v, ok := mapping.Get("x:first")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x100), v)
+	v, ok = mapping.Get("x:second")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x101), v)
+
+	other := DeprelMapping{maxValue: 0x100, items: map[string]uint16{"x:other": 0x100}}
+	other.maxValue++
+	assert.Error(t, other.RegisterAll(map[string]uint16{"x:first": 0x100}))
+}
+
+func TestDeprelMappingFull(t *testing.T) {
+	mapping := DeprelMapping{maxValue: math.MaxUint16 - 1, items: map[string]uint16{"amod": DeprelAmod}}
+	v, err := mapping.GetOrRegister("x:last")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(math.MaxUint16-1), v)
+	_, err = mapping.GetOrRegister("x:next")
+	assert.ErrorIs(t, err, ErrDeprelMappingFull)
+	assert.ErrorIs(t, mapping.Register("x:next"), ErrDeprelMappingFull)
+	_, ok := mapping.Get("x:next")
+	assert.False(t, ok)
+	// already registered values are still available
+	v, err = mapping.GetOrRegister("x:last")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(math.MaxUint16-1), v)
+}
+
+func TestIsSiblingDeprel(t *testing.T) {
+	assert.True(t, IsSiblingDeprel("sibling"))
+	assert.True(t, IsSiblingDeprel("nsubj←root→obj"))
+	assert.False(t, IsSiblingDeprel("obj→amod"))
+	assert.False(t, IsSiblingDeprel("amod←obj"))
+	assert.False(t, IsSiblingDeprel("nsubj"))
+}
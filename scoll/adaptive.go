@@ -0,0 +1,78 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// AdaptiveRareLemmaMaxFreq is the max. frequency of a lemma
+	// for which adaptive limits return all the available collocates
+	AdaptiveRareLemmaMaxFreq = 500
+
+	// AdaptiveFrequentLemmaMinFreq is the min. frequency of a lemma
+	// for which adaptive limits bound the number of scanned pairs
+	AdaptiveFrequentLemmaMinFreq = 100000
+
+	// AdaptiveMaxScannedPairs is the max. number of scanned pairs
+	// applied by adaptive limits to frequent lemmas
+	AdaptiveMaxScannedPairs = 1000000
+)
+
+// adaptLimits sets result limit and max. number of scanned pairs
+// based on the frequency band of the searched lemma. Values set
+// explicitly by a client are preserved. Negative lemmaFreq means
+// the frequency is unknown so nothing is changed.
+func adaptLimits(opts *CalculationOptions, lemmaFreq int) {
+	switch {
+	case lemmaFreq < 0:
+		return
+	case lemmaFreq <= AdaptiveRareLemmaMaxFreq:
+		if opts.Limit == 0 {
+			opts.Limit = math.MaxInt32
+		}
+	case lemmaFreq >= AdaptiveFrequentLemmaMinFreq:
+		if opts.MaxScannedPairs == 0 {
+			opts.MaxScannedPairs = AdaptiveMaxScannedPairs
+		}
+	}
+}
+
+// nodeLemmaFreq returns frequency of the searched lemma (or a sum of
+// frequencies in case of a lemma set) with respect to the PoS and text
+// type options. For prefix and pattern searches, the frequency cannot be
+// determined so -1 is returned which means no adaptation.
+func (calc *Calculator) nodeLemmaFreq(lemma string, opts CalculationOptions) (int, error) {
+	if opts.PrefixSearch || opts.LemmaPattern != "" || opts.SearchByWordForm {
+		return -1, nil
+	}
+	lemmas := opts.LemmaSet
+	if len(lemmas) == 0 {
+		lemmas = []string{lemma}
+	}
+	var ans int
+	for _, v := range lemmas {
+		freq, err := calc.database.GetLemmaFreq(v, opts.PoS, opts.TextType, calc.excludedTextTypes(opts))
+		if err != nil {
+			return 0, fmt.Errorf("failed to determine lemma frequency band: %w", err)
+		}
+		ans += freq
+	}
+	return ans, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptLimits(t *testing.T) {
+	for _, tc := range []struct {
+		lemmaFreq       int
+		limit           int
+		maxScanned      int
+		expectedLimit   int
+		expectedScanned int
+	}{
+		{lemmaFreq: 10, expectedLimit: math.MaxInt32},
+		{lemmaFreq: 10, limit: 5, expectedLimit: 5},
+		{lemmaFreq: 5000},
+		{lemmaFreq: 200000, expectedScanned: AdaptiveMaxScannedPairs},
+		{lemmaFreq: 200000, maxScanned: 10, expectedScanned: 10},
+		{lemmaFreq: -1},
+	} {
+		opts := CalculationOptions{Limit: tc.limit, MaxScannedPairs: tc.maxScanned}
+		adaptLimits(&opts, tc.lemmaFreq)
+		assert.Equal(t, tc.expectedLimit, opts.Limit, "lemma freq: %d", tc.lemmaFreq)
+		assert.Equal(t, tc.expectedScanned, opts.MaxScannedPairs, "lemma freq: %d", tc.lemmaFreq)
+	}
+}
+
+func TestCalculatorAdaptiveLimits(t *testing.T) {
+	data := federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 100},
+		pairFreqs:   make(map[string]int),
+	}
+	for i := range DefaultLimit + 5 {
+		adj := fmt.Sprintf("adj%d", i)
+		data.singleFreqs[adj] = 10
+		data.pairFreqs[adj] = 2
+	}
+	calc := FromDatabase(openFederatedTestDB(t, data))
+
+	ans, err := calc.GetCollocations(context.Background(), "dog", WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, DefaultLimit)
+
+	// "dog" is rare so all the collocates are returned
+	// unless the limit is set explicitly
+	ans, err = calc.GetCollocations(context.Background(), "dog", WithAdaptiveLimits(), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, DefaultLimit+5)
+
+	ans, err = calc.GetCollocations(context.Background(), "dog", WithAdaptiveLimits(), WithLimit(3), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 3)
+}
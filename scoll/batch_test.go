@@ -0,0 +1,49 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculatorGetCollocationsBatch(t *testing.T) {
+	calc := FromDatabase(openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10, "small": 8},
+		pairFreqs:   map[string]int{"big": 5, "small": 4},
+	}))
+	var total int
+	ans, err := calc.GetCollocationsBatch(
+		context.Background(),
+		[]string{"dog", "big", "dog"},
+		WithLimit(1), WithTotalCount(&total), WithoutQueryLog(),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, 0, total)
+	expected, err := calc.GetCollocations(context.Background(), "dog", WithLimit(1), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, ans["dog"])
+	assert.Empty(t, ans["big"])
+
+	_, err = calc.GetCollocationsBatch(
+		context.Background(), []string{"dog"}, WithSecondOrder(5), WithoutQueryLog())
+	assert.ErrorIs(t, err, ErrBatchSecondOrder)
+}
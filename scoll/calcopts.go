@@ -16,29 +16,11 @@
 
 package scoll
 
-import "github.com/czcorpus/depreldb/storage"
-
-const (
-
-	// ModifiersOf represents CQL chunk [p_lemma="team" & deprel="nmod" & upos="NOUN"]
-	ModifiersOf PredefinedSearch = "modifiers-of"
-
-	// NounsModifiedBy represents CQL chunk [lemma="team" & deprel="nmod" & p_upos="NOUN"]
-	NounsModifiedBy PredefinedSearch = "nouns-modified-by"
-
-	// VerbsSubject represents CQL chunk [lemma="team" & deprel="nsubj" & p_upos="VERB"]
-	VerbsSubject PredefinedSearch = "verbs-subject"
-
-	// VerbsObject represents CQL chunk [lemma="team" & deprel="obj|iobj" & p_upos="VERB"]
-	VerbsObject PredefinedSearch = "verbs-object"
+import (
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
 )
 
-type PredefinedSearch string
-
-func (ps PredefinedSearch) Validate() bool {
-	return ps == ModifiersOf || ps == NounsModifiedBy || ps == VerbsSubject || ps == VerbsObject
-}
-
 type CalculationOptions struct {
 	PrefixSearch             bool
 	PoS                      string
@@ -49,8 +31,141 @@ type CalculationOptions struct {
 	GroupByDeprel            bool
 	CollocateGroupByTextType bool
 	MaxAvgCollocateDist      float64
+	MaxAvgSurfaceDist        float64
+	CollocateOrder           storage.CollocateOrder
 	LemmasAsHead             *bool
-	PredefinedSearch         PredefinedSearch
+	Relation                 string
+	ExcludedDeprels          []string
+	LemmaSet                 []string
+
+	// TextTypeDims contains required values of individual text type
+	// dimensions (see storage.CalculationArgs.TextTypeDims)
+	TextTypeDims []string
+
+	// LemmaPattern, if set, makes the searched lemma to be interpreted
+	// as a pattern of the syntax (glob, regexp). All the matching lemmas
+	// are searched as a single node labeled by the pattern.
+	LemmaPattern storage.LemmaPatternSyntax
+
+	// IgnoreDiacritics makes the searched lemma to match also lemmas
+	// which differ only in diacritics (e.g. hriste matches hřiště).
+	// Results contain the canonical (stored) forms of the lemmas.
+	IgnoreDiacritics bool
+
+	// IgnoreCase makes the searched lemma to match also lemmas
+	// which differ in letter case (e.g. praha matches Praha). It can
+	// be combined with IgnoreDiacritics. Results contain the canonical
+	// (stored) forms of the lemmas.
+	IgnoreCase bool
+
+	// SearchByWordForm makes the searched lemma to be interpreted
+	// as a word form (see storage.CalculationArgs.SearchByWordForm).
+	// CQL queries are not generated for such searches.
+	SearchByWordForm bool
+
+	// GroupByFeats makes the searched lemma to be split by its
+	// morphological features (see storage.CalculationArgs.GroupByFeats)
+	GroupByFeats bool
+
+	// MergePrefixVariants makes a prefix search to treat all the matching
+	// lemmas as a single node (i.e. their frequencies are summed up
+	// and measures are calculated for the merged node). Otherwise,
+	// each matching lemma is a separate node with its own result rows.
+	MergePrefixVariants bool
+
+	// LimitPerVariant makes Limit to be applied to each node variant
+	// (e.g. each lemma matching a prefix) separately
+	LimitPerVariant bool
+
+	// Offset is a number of leading results skipped before Limit
+	// is applied (i.e. Offset and Limit allow paging through results)
+	Offset int
+
+	// TotalCount, if set, is filled with the number of all the found
+	// collocations (i.e. before Offset and Limit are applied).
+	TotalCount *int
+
+	// SecondOrderLimit, if positive, makes the search to attach up to
+	// SecondOrderLimit collocates to each of the found collocates
+	// (see storage.Collocation.SecondOrder) so a collocation network
+	// can be built.
+	SecondOrderLimit int
+
+	// VariantSummary, if set, is filled with numbers of collocations
+	// found and returned for individual node variants. This is
+	// available only for local databases.
+	VariantSummary *[]storage.NodeVariantSummary
+
+	// CategoryLexicon maps collocates to categories (e.g. sentiment
+	// polarity) so category aggregates can be reported via CategoryProfile
+	CategoryLexicon *storage.CategoryLexicon
+
+	// CategoryProfile, if set (along with CategoryLexicon), is filled with
+	// category aggregates calculated over all the found collocations.
+	// This is available only for local databases.
+	CategoryProfile *storage.CategoryProfile
+
+	// RelationDistSpread, if positive, limits average collocate distance
+	// per relation using relation statistics stored during import
+	// (average + RelationDistSpread * std. deviation)
+	RelationDistSpread float64
+
+	// MaxScannedPairs, if positive, limits the number of examined
+	// pair records (the results may be incomplete then)
+	MaxScannedPairs int
+
+	// MinCollFreq, if positive, removes collocations with F(x,y)
+	// lower than the value
+	MinCollFreq int
+
+	// AdaptiveLimits makes Limit and MaxScannedPairs (if not set
+	// explicitly) to depend on the frequency of the searched lemma
+	AdaptiveLimits bool
+
+	// SignedDistance enables the legacy convention where
+	// negative MutualDist means the searched lemma is a dependent
+	SignedDistance bool
+
+	// CorpusSize, if positive, overrides the corpus size (N)
+	// used in measure formulas.
+	CorpusSize int64
+
+	// Fields selects optional result columns to be calculated
+	// and returned (empty = all)
+	Fields []storage.ResultField
+
+	// DeprelGranularity, if set to storage.DeprelGranularityCore,
+	// merges relation subtypes into their core relations
+	DeprelGranularity storage.DeprelGranularity
+
+	// Deprels, if non-empty, restricts collocations to the provided
+	// relations (see storage.CalculationArgs.Deprels)
+	Deprels []string
+
+	// PathCollocations makes the search to use pairs connected via
+	// other nodes (see storage.CalculationArgs.PathCollocations)
+	PathCollocations bool
+
+	// GenerateCQL makes the result items to contain CQL queries
+	// retrieving the respective co-occurrences in the source corpus
+	GenerateCQL bool
+
+	// LabelLang, if set, makes the results to contain human-readable
+	// descriptions of PoS tags and relations in the language
+	LabelLang record.LabelLang
+
+	// FilterStats, if set, is filled with numbers of candidates
+	// discarded by individual search filters. This is available only
+	// for local databases.
+	FilterStats *storage.FilterStats
+
+	// RestrictedTextTypesAccess allows searching in text types
+	// configured as restricted for the corpus.
+	RestrictedTextTypesAccess bool
+
+	// NoQueryLog prevents the search from being recorded
+	// in a query log.
+	NoQueryLog bool
 }
 
 func WithPoS(pos string) func(opts *CalculationOptions) {
@@ -65,12 +180,54 @@ func WithTextType(tt string) func(opts *CalculationOptions) {
 	}
 }
 
+// WithTextTypeDims restricts the search to text types with the provided
+// values of individual text type dimensions (for databases combining
+// multiple structural attributes into text types, e.g. genre and period).
+// Values are applied in the order of the dimensions, an empty value
+// matches anything.
+func WithTextTypeDims(values ...string) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.TextTypeDims = values
+	}
+}
+
 func WithLimit(lim int) func(opts *CalculationOptions) {
 	return func(opts *CalculationOptions) {
 		opts.Limit = lim
 	}
 }
 
+// WithOffset makes the search to skip offset best ranking results
+// (before the limit is applied) so it is possible to page through
+// the results. With WithLimitPerVariant, the offset is applied
+// to each node variant separately.
+func WithOffset(offset int) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.Offset = offset
+	}
+}
+
+// WithSecondOrder makes the search to find also up to limit collocates
+// of each of the found collocates (i.e. collocates of collocates). The second
+// level searches use the same filters as the main one. The searched lemma
+// itself is not repeated among the second level collocates.
+// Please note that each of the found collocates requires an additional
+// search so a low limit of the main search is recommended.
+func WithSecondOrder(limit int) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.SecondOrderLimit = limit
+	}
+}
+
+// WithTotalCount makes the search to store the number of all the found
+// collocations (i.e. regardless of offset and limit) into the provided
+// value so e.g. a pager can be rendered.
+func WithTotalCount(total *int) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.TotalCount = total
+	}
+}
+
 func WithSortBy(measure storage.SortingMeasure) func(opts *CalculationOptions) {
 	return func(opts *CalculationOptions) {
 		opts.SortBy = measure
@@ -83,6 +240,99 @@ func WithPrefixSearch() func(opts *CalculationOptions) {
 	}
 }
 
+// WithLemmaPattern makes the searched lemma to be interpreted as a pattern
+// of the provided syntax (e.g. "run*" for storage.LemmaPatternGlob or
+// ".*ization" for storage.LemmaPatternRegexp). Collocations of all
+// the matching lemmas are aggregated.
+func WithLemmaPattern(syntax storage.LemmaPatternSyntax) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.LemmaPattern = syntax
+	}
+}
+
+// WithMergedPrefixVariants makes a prefix search to merge results
+// of all the matching lemmas (see CalculationOptions.MergePrefixVariants).
+// Without prefix search, the option has no effect.
+func WithMergedPrefixVariants() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.MergePrefixVariants = true
+	}
+}
+
+// WithLimitPerVariant makes the result limit to be applied to each
+// node variant (e.g. each lemma matching a prefix) separately so
+// collocates of less frequent variants are not pushed out of
+// the result by the ones of more frequent variants.
+func WithLimitPerVariant() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.LimitPerVariant = true
+	}
+}
+
+// WithIgnoredDiacritics makes the search to match lemmas regardless
+// of their diacritics (e.g. for users typing on keyboards without
+// national characters). Results contain the canonical lemma forms.
+func WithIgnoredDiacritics() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.IgnoreDiacritics = true
+	}
+}
+
+// WithDiacriticsInsensitive is an alias of WithIgnoredDiacritics
+// named consistently with WithCaseInsensitive.
+func WithDiacriticsInsensitive() func(opts *CalculationOptions) {
+	return WithIgnoredDiacritics()
+}
+
+// WithCaseInsensitive makes the search to match lemmas regardless
+// of their letter case (e.g. to find proper nouns typed in lowercase).
+// Results contain the canonical lemma forms. The database must be
+// built with lowercased lemmas indexed (see storage.FeatureLowerLemmas).
+func WithCaseInsensitive() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.IgnoreCase = true
+	}
+}
+
+// WithSearchByWordForm makes the search to look for a word form
+// instead of a lemma. Collocates are then also word forms. The database
+// must be built with word forms indexed.
+func WithSearchByWordForm() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.SearchByWordForm = true
+	}
+}
+
+// WithGroupByFeats makes the searched lemma to be split by its
+// morphological features (e.g. singular and plural) so their collocation
+// profiles can be compared. The database must be built with the features.
+func WithGroupByFeats() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.GroupByFeats = true
+	}
+}
+
+// WithVariantSummary makes the search to report lemmas forming individual
+// nodes (e.g. lemmas matching a prefix) along with numbers of their found
+// and returned collocations into the provided value. It is supported only
+// by local databases (i.e. not by a remote client).
+func WithVariantSummary(summary *[]storage.NodeVariantSummary) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.VariantSummary = summary
+	}
+}
+
+// WithCategoryProfile makes the search to aggregate categories of collocates
+// (as defined by the provided lexicon) over all the found collocations
+// (i.e. not just the ones within the limit) and to store the result into
+// the provided profile. It is supported only by local databases.
+func WithCategoryProfile(lex *storage.CategoryLexicon, profile *storage.CategoryProfile) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.CategoryLexicon = lex
+		opts.CategoryProfile = profile
+	}
+}
+
 func WithCollocateGroupByPos() func(opts *CalculationOptions) {
 	return func(opts *CalculationOptions) {
 		opts.CollocateGroupByPos = true
@@ -115,13 +365,15 @@ func WithCollocateGroupByTextType() func(opts *CalculationOptions) {
 	}
 }
 
-func WithPredefinedSearch(srch PredefinedSearch) func(opts *CalculationOptions) {
+// WithRelation restricts the search to collocates in a named relation
+// (see Calculator.Relations) and groups them by deprel and PoS.
+// Unless set explicitly, the position of the searched lemma (head,
+// dependent) is given by the relation.
+func WithRelation(name string) func(opts *CalculationOptions) {
 	return func(opts *CalculationOptions) {
-		opts.PredefinedSearch = srch
+		opts.Relation = name
 		opts.GroupByDeprel = true
 		opts.CollocateGroupByPos = true
-		isHead := srch == ModifiersOf
-		opts.LemmasAsHead = &isHead
 	}
 }
 
@@ -133,6 +385,200 @@ func WithMaxAvgCollocateDist(dist float64) func(opts *CalculationOptions) {
 	}
 }
 
+// WithRelationDistSpread limits average distance of collocates
+// separately for each relation based on the relation's typical
+// distance (average + spread * std. deviation) as measured during
+// import. Relations without statistics (or databases imported without
+// them) are not limited this way. Unlike WithMaxAvgCollocateDist,
+// a single value works for both short (e.g. amod) and long (e.g. advcl)
+// relations.
+func WithRelationDistSpread(spread float64) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.RelationDistSpread = spread
+	}
+}
+
+// WithMaxScannedPairs limits the number of pair records examined
+// by the search. This bounds the time spent with extremely frequent
+// lemmas but the results may be incomplete then.
+func WithMaxScannedPairs(n int) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.MaxScannedPairs = n
+	}
+}
+
+// WithMinCollFreq removes collocations with co-occurrence frequency
+// F(x,y) lower than minFreq. This is useful mainly for smaller corpora
+// where pairs occurring just once or twice tend to dominate rankings
+// by measures like logDice.
+func WithMinCollFreq(minFreq int) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.MinCollFreq = minFreq
+	}
+}
+
+// WithAdaptiveLimits makes the result limit and the max. number
+// of scanned pairs depend on the frequency of the searched lemma
+// (see AdaptiveRareLemmaMaxFreq and AdaptiveFrequentLemmaMinFreq).
+// Explicitly set limits are always preserved.
+func WithAdaptiveLimits() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.AdaptiveLimits = true
+	}
+}
+
+// WithMaxAvgSurfaceDist defines max. absolute value of average
+// linear (word order) distance between tokens we want to have
+// in the result. Unlike WithMaxAvgCollocateDist, this is not
+// a distance in the syntax tree.
+func WithMaxAvgSurfaceDist(dist float64) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.MaxAvgSurfaceDist = dist
+	}
+}
+
+// WithCollocateOrder keeps only collocates typically preceding
+// (storage.CollocateBefore) or following (storage.CollocateAfter)
+// the searched lemma.
+func WithCollocateOrder(order storage.CollocateOrder) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.CollocateOrder = order
+	}
+}
+
+// WithExcludedDeprels removes collocations with the provided
+// dependency relations from the result. Setting the option replaces
+// possible excluded deprels configured for the corpus.
+func WithExcludedDeprels(deprels ...string) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.ExcludedDeprels = deprels
+	}
+}
+
+// WithDeprel restricts collocations to the provided dependency
+// relation (e.g. "amod"). Unlike WithExcludedDeprels, the restriction
+// is applied directly while reading the stored records so it is
+// cheap even for very frequent lemmas.
+func WithDeprel(deprel string) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.Deprels = []string{deprel}
+	}
+}
+
+// WithDeprels is a variant of WithDeprel restricting collocations
+// to any of the provided relations. An empty list means no restriction.
+func WithDeprels(deprels []string) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.Deprels = deprels
+	}
+}
+
+// WithPathCollocations makes the search to use pairs connected via
+// other nodes labeled with their relation paths instead of the regular
+// pairs. Combined with WithDeprel (e.g. "obj→amod" for a verb), this allows
+// for searching e.g. adjectives modifying objects of a verb. The database
+// must be built with path collocations (see storage.FeaturePathCollocations).
+func WithPathCollocations() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.PathCollocations = true
+	}
+}
+
+// WithDeprelGranularity specifies whether relation subtypes (e.g. "obl:arg")
+// are kept as stored (storage.DeprelGranularityFull) or merged into their
+// core relations (storage.DeprelGranularityCore). In the latter case, also
+// excluded deprels are applied to whole groups of relations.
+func WithDeprelGranularity(gran storage.DeprelGranularity) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.DeprelGranularity = gran
+	}
+}
+
+// WithCQL makes each result item to contain a CQL query retrieving
+// the respective co-occurrences (lemmas, relation, direction) in the
+// source corpus (e.g. for linking results to concordances).
+func WithCQL() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.GenerateCQL = true
+	}
+}
+
+// WithRestrictedTextTypesAccess includes text types marked as restricted
+// in corpus configuration into the search. This should be used only
+// for authorized users.
+func WithRestrictedTextTypesAccess() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.RestrictedTextTypesAccess = true
+	}
+}
+
+// WithoutQueryLog opts out the search from being recorded
+// in a query log (if configured).
+func WithoutQueryLog() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.NoQueryLog = true
+	}
+}
+
+// WithLemmaSet makes the search to treat all the provided lemmas
+// as a single node (i.e. their frequencies are summed before
+// calculating the measures). In such case, the searched lemma
+// is used just as a label of the set.
+func WithLemmaSet(lemmas ...string) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.LemmaSet = lemmas
+	}
+}
+
+// WithCorpusSize replaces the imported corpus size in measure formulas
+// with a custom value (e.g. a size of an externally defined subcorpus).
+// The value must be positive and not lower than frequencies of involved
+// lemmas. The applied value is echoed in each result item.
+func WithCorpusSize(size int64) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.CorpusSize = size
+	}
+}
+
+// WithSignedDistance makes the results to use the legacy sign convention
+// for the MutualDist value (negative values for collocations where the
+// searched lemma is a dependent). By default, MutualDist is always
+// non-negative and the direction is available via IsHead.
+func WithSignedDistance() func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.SignedDistance = true
+	}
+}
+
+// WithFields selects optional result columns (measures, text type,
+// corpus size) to be calculated and returned. This is useful for
+// skipping expensive measures and for shrinking payloads.
+// Measures required by the sorting are calculated anyway.
+func WithFields(fields ...storage.ResultField) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.Fields = fields
+	}
+}
+
+// WithLabelLang makes the results to contain descriptions of PoS tags
+// and relations (e.g. "předmět" for "obj") in the required language.
+func WithLabelLang(lang record.LabelLang) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.LabelLang = lang
+	}
+}
+
+// WithFilterStats makes the search to report how many candidate pairs
+// were discarded by individual filters (text type, excluded deprels, distance,
+// ...) into the provided value. This is intended for debugging of queries
+// (e.g. why an expected collocate is missing) and it is supported only
+// by a local database (i.e. not by a remote client).
+func WithFilterStats(stats *storage.FilterStats) func(opts *CalculationOptions) {
+	return func(opts *CalculationOptions) {
+		opts.FilterStats = stats
+	}
+}
+
 // WithNOP is a convenience function which sets no option and
 // can be used as an alternative to boolean With... functions
 // with no argument.
@@ -140,3 +586,9 @@ func WithNOP() func(opts *CalculationOptions) {
 	return func(opts *CalculationOptions) {
 	}
 }
+
+// lemmaInsensitive tells whether the searched lemma can match lemmas
+// with different canonical forms (see IgnoreDiacritics and IgnoreCase)
+func (opts CalculationOptions) lemmaInsensitive() bool {
+	return opts.IgnoreDiacritics || opts.IgnoreCase
+}
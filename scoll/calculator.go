@@ -17,59 +17,410 @@
 package scoll
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
 	"github.com/czcorpus/depreldb/record"
 	"github.com/czcorpus/depreldb/storage"
 )
 
+var (
+	ErrRestrictedTextType = errors.New("access to text type is restricted")
+	ErrBatchSecondOrder   = errors.New("second order collocations are not supported in batch search")
+)
+
+const (
+	DefaultLimit                         = 10
+	DefaultSortBy storage.SortingMeasure = "rrf"
+)
+
+// CollocationProvider is an abstraction of a collocation source. It is
+// implemented both by the embedded Calculator and by the remote
+// client.Client so code depending on the interface can be migrated
+// between local databases and a central server transparently.
+type CollocationProvider interface {
+	GetCollocations(ctx context.Context, lemma string, options ...func(opts *CalculationOptions)) ([]storage.Collocation, error)
+	GetLemmaInfo(lemma string, options ...func(opts *CalculationOptions)) (storage.LemmaInfo, error)
+	GetLemmaProfile(lemma string, options ...func(opts *CalculationOptions)) (storage.LemmaProfile, error)
+	GetDeprelStats(ctx context.Context, numExamples int, options ...func(opts *CalculationOptions)) ([]storage.DeprelStats, error)
+	GetTextTypes(options ...func(opts *CalculationOptions)) ([]storage.TextTypeLabel, error)
+}
+
+var _ CollocationProvider = (*Calculator)(nil)
+
 type Calculator struct {
-	database *storage.DB
+	database Database
+	queryLog *QueryLog
 }
 
-func FromDatabase(db *storage.DB) *Calculator {
-	return &Calculator{db}
+// FromDatabase creates a calculator searching in an already opened
+// database. To open a database by its path, use Open.
+func FromDatabase(db Database) *Calculator {
+	return &Calculator{database: db}
 }
 
-func createPredefinedSearchFilter(srch PredefinedSearch) storage.SearchFilter {
-	switch srch {
-	case ModifiersOf:
-		return func(pos1 byte, deprel uint16, pos2 byte, textType byte, dist float64) bool {
-			return dist > 0 && deprel == record.DeprelNmod && pos1 == record.PosNOUN
-		}
-	case NounsModifiedBy:
-		return func(pos1 byte, deprel uint16, pos2 byte, textType byte, dist float64) bool {
-			return dist < 0 && deprel == record.DeprelNmod && pos2 == record.PosNOUN
+// Close closes the underlying database.
+func (calc *Calculator) Close() error {
+	return calc.database.Close()
+}
+
+// WithQueryLog sets a query log where all the searches
+// (except for those opted-out via WithoutQueryLog) are recorded.
+func (calc *Calculator) WithQueryLog(ql *QueryLog) *Calculator {
+	calc.queryLog = ql
+	return calc
+}
+
+// applyDefaults fills in options omitted by a client using
+// corpus specific defaults (with hardcoded fallback values).
+func (calc *Calculator) applyDefaults(opts *CalculationOptions) {
+	defaults := calc.database.QueryDefaults()
+	if opts.SortBy == "" {
+		opts.SortBy = defaults.SortBy
+		if opts.SortBy == "" {
+			opts.SortBy = DefaultSortBy
 		}
-	case VerbsObject:
-		return func(pos1 byte, deprel uint16, pos2 byte, textType byte, dist float64) bool {
-			return dist < 0 && deprel == record.DeprelNsubj && pos2 == record.PosVERB
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaults.Limit
+		if opts.Limit == 0 {
+			opts.Limit = DefaultLimit
 		}
-	case VerbsSubject:
-		return func(pos1 byte, deprel uint16, pos2 byte, textType byte, dist float64) bool {
-			return dist < 0 && (deprel == record.DeprelObj || deprel == record.DeprelIobj) && pos2 == record.PosVERB
+	}
+	if opts.MaxAvgCollocateDist == 0 && opts.RelationDistSpread == 0 {
+		if defaults.RelationDistSpread > 0 && calc.database.DatasetMetadata().HasFeature(storage.FeatureRelationDists) {
+			opts.RelationDistSpread = defaults.RelationDistSpread
+
+		} else {
+			opts.MaxAvgCollocateDist = defaults.MaxAvgCollocateDist
 		}
-	default:
+	}
+	if opts.ExcludedDeprels == nil {
+		opts.ExcludedDeprels = defaults.ExcludedDeprels
+	}
+}
+
+// excludedTextTypes returns text types which must not be
+// involved in results unless the access to them is enabled.
+func (calc *Calculator) excludedTextTypes(opts CalculationOptions) []string {
+	if opts.RestrictedTextTypesAccess {
 		return nil
 	}
+	return calc.database.RestrictedTextTypes()
 }
 
-func (calc *Calculator) GetCollocations(lemma string, options ...func(opts *CalculationOptions)) ([]storage.Collocation, error) {
+// createExcludedDeprelsFilter wraps a possible existing filter with
+// a test removing all the provided deprels. Unknown deprels are ignored.
+// With the core granularity, also all the subtypes of the deprels are removed.
+func (calc *Calculator) createExcludedDeprelsFilter(
+	deprels []string,
+	gran storage.DeprelGranularity,
+	filter storage.SearchFilter,
+) storage.SearchFilter {
+	if len(deprels) == 0 {
+		return filter
+	}
+	mapping := calc.database.Deprels()
+	if mapping == nil {
+		mapping = &record.UDDeprelMapping
+	}
+	excluded := make(map[uint16]bool)
+	for _, d := range deprels {
+		if v, ok := mapping.Get(d); ok {
+			excluded[v] = true
+		}
+	}
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		if excluded[deprel] || gran == storage.DeprelGranularityCore && excluded[mapping.CoreOf(deprel)] {
+			return false
+		}
+		return filter == nil || filter(pos1, deprel, pos2, textType, isHead, dist)
+	}
+}
+
+// prepareOptions applies options of a search along with
+// adaptive limits and database defaults
+func (calc *Calculator) prepareOptions(lemma string, options ...func(opts *CalculationOptions)) (CalculationOptions, error) {
 	var opts CalculationOptions
 	for _, opt := range options {
 		opt(&opts)
 	}
-	customFilter := createPredefinedSearchFilter(opts.PredefinedSearch)
-	return calc.database.CalculateMeasures(
-		lemma,
-		opts.PoS,
-		opts.TextType,
-		opts.PrefixSearch,
-		opts.LemmasAsHead,
-		opts.MaxAvgCollocateDist,
-		opts.Limit,
-		opts.SortBy,
-		opts.CollocateGroupByPos,
-		opts.GroupByDeprel,
-		opts.CollocateGroupByTextType,
-		customFilter,
+	if opts.AdaptiveLimits {
+		lemmaFreq, err := calc.nodeLemmaFreq(lemma, opts)
+		if err != nil {
+			return opts, err
+		}
+		adaptLimits(&opts, lemmaFreq)
+	}
+	calc.applyDefaults(&opts)
+	if opts.GenerateCQL && opts.lemmaInsensitive() && opts.VariantSummary == nil {
+		// CQL queries must contain the canonical forms of the matching lemmas
+		opts.VariantSummary = new([]storage.NodeVariantSummary)
+	}
+	return opts, nil
+}
+
+// decorateResults attaches optional information (label descriptions,
+// CQL queries) to the result items
+func (calc *Calculator) decorateResults(items []storage.Collocation, opts CalculationOptions) {
+	if opts.LabelLang != "" {
+		addLabelDescriptions(items, opts.LabelLang)
+	}
+	if opts.GenerateCQL {
+		addCQL(items, opts, calc.database.TextTypesAttr())
+	}
+}
+
+func (calc *Calculator) GetCollocations(ctx context.Context, lemma string, options ...func(opts *CalculationOptions)) ([]storage.Collocation, error) {
+	opts, err := calc.prepareOptions(lemma, options...)
+	if err != nil {
+		return []storage.Collocation{}, err
+	}
+	t0 := time.Now()
+	ans, err := calc.getCollocations(ctx, lemma, opts)
+	if !opts.NoQueryLog {
+		calc.queryLog.LogQuery(lemma, opts, t0, len(ans), err)
+	}
+	if err == nil {
+		calc.decorateResults(ans, opts)
+	}
+	return ans, err
+}
+
+// GetCollocationsBatch searches for collocations of multiple lemmas at once.
+// All the searches are processed within a single database transaction
+// and they share cached lemma and collocate frequencies (see
+// storage.DB.CalculateMeasuresBatch) which makes the batch much faster
+// than separate GetCollocations calls. The options are applied to each
+// of the lemmas. Options providing additional outputs via pointers (total
+// count, filter stats, category profile, variant summary) are ignored as
+// they cannot be shared among the lemmas. Second order collocations are
+// not supported. The returned map is keyed by the searched lemmas.
+func (calc *Calculator) GetCollocationsBatch(
+	ctx context.Context,
+	lemmas []string,
+	options ...func(opts *CalculationOptions),
+) (map[string][]storage.Collocation, error) {
+	ans := make(map[string][]storage.Collocation)
+	lemmas = slices.Compact(slices.Sorted(slices.Values(lemmas)))
+	batchOpts := make([]CalculationOptions, len(lemmas))
+	batch := make([]storage.CalculationArgs, len(lemmas))
+	for i, lemma := range lemmas {
+		opts, err := calc.prepareOptions(lemma, options...)
+		if err != nil {
+			return ans, err
+		}
+		if opts.SecondOrderLimit > 0 {
+			return ans, ErrBatchSecondOrder
+		}
+		opts.TotalCount = nil
+		opts.FilterStats = nil
+		opts.CategoryProfile = nil
+		if opts.VariantSummary != nil {
+			// needed by CQL generation (see prepareOptions)
+			opts.VariantSummary = new([]storage.NodeVariantSummary)
+		}
+		batch[i], err = calc.calculationArgs(lemma, opts)
+		if err != nil {
+			return ans, err
+		}
+		batchOpts[i] = opts
+	}
+	t0 := time.Now()
+	results, err := calc.database.CalculateMeasuresBatch(ctx, batch)
+	for i, lemma := range lemmas {
+		var numResults int
+		if err == nil {
+			numResults = len(results[i])
+		}
+		if !batchOpts[i].NoQueryLog {
+			calc.queryLog.LogQuery(lemma, batchOpts[i], t0, numResults, err)
+		}
+	}
+	if err != nil {
+		return ans, err
+	}
+	for i, lemma := range lemmas {
+		calc.decorateResults(results[i], batchOpts[i])
+		ans[lemma] = results[i]
+	}
+	return ans, nil
+}
+
+// addLabelDescriptions attaches human-readable descriptions
+// of PoS tags and relations to the result items
+func addLabelDescriptions(items []storage.Collocation, lang record.LabelLang) {
+	for i := range items {
+		items[i].Lemma.PoSDescription = record.DescribePoS(items[i].Lemma.PoS, lang)
+		items[i].Collocate.PoSDescription = record.DescribePoS(items[i].Collocate.PoS, lang)
+		items[i].DeprelDescription = record.DescribeDeprel(items[i].Deprel, lang)
+		addLabelDescriptions(items[i].SecondOrder, lang)
+	}
+}
+
+// createRelationDistFilter wraps a possible existing filter with
+// a test removing collocates with average distance exceeding
+// the typical distance of their relation. Relations without
+// the statistics are not affected.
+func (calc *Calculator) createRelationDistFilter(spread float64, filter storage.SearchFilter) storage.SearchFilter {
+	if spread <= 0 {
+		return filter
+	}
+	limits := calc.database.RelationDistLimits(spread)
+	if len(limits) == 0 {
+		return filter
+	}
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		if limit, ok := limits[deprel]; ok && dist > limit {
+			return false
+		}
+		return filter == nil || filter(pos1, deprel, pos2, textType, isHead, dist)
+	}
+}
+
+func (calc *Calculator) getCollocations(ctx context.Context, lemma string, opts CalculationOptions) ([]storage.Collocation, error) {
+	args, err := calc.calculationArgs(lemma, opts)
+	if err != nil {
+		return []storage.Collocation{}, err
+	}
+	if opts.SecondOrderLimit > 0 {
+		return calc.database.CalculateSecondOrder(ctx, args, opts.SecondOrderLimit)
+	}
+	return calc.database.CalculateMeasures(ctx, args)
+}
+
+// calculationArgs converts search options to arguments
+// of the database search
+func (calc *Calculator) calculationArgs(lemma string, opts CalculationOptions) (storage.CalculationArgs, error) {
+	relFilter, relIsHead, err := calc.relationFilter(opts.Relation)
+	if err != nil {
+		return storage.CalculationArgs{}, err
+	}
+	customFilter := calc.createRelationDistFilter(
+		opts.RelationDistSpread,
+		calc.createExcludedDeprelsFilter(opts.ExcludedDeprels, opts.DeprelGranularity, relFilter),
 	)
+	isHead := opts.LemmasAsHead
+	if isHead == nil {
+		isHead = relIsHead
+	}
+	excludedTT := calc.excludedTextTypes(opts)
+	if slices.Contains(excludedTT, opts.TextType) {
+		return storage.CalculationArgs{}, fmt.Errorf("%w: %s", ErrRestrictedTextType, opts.TextType)
+	}
+	return storage.CalculationArgs{
+		Lemma:                    lemma,
+		PoS:                      opts.PoS,
+		TextType:                 opts.TextType,
+		TextTypeDims:             opts.TextTypeDims,
+		LemmaIsPrefix:            opts.PrefixSearch,
+		LemmaPattern:             opts.LemmaPattern,
+		MergePrefixVariants:      opts.MergePrefixVariants,
+		IgnoreDiacritics:         opts.IgnoreDiacritics,
+		IgnoreCase:               opts.IgnoreCase,
+		SearchByWordForm:         opts.SearchByWordForm,
+		GroupByFeats:             opts.GroupByFeats,
+		LimitPerVariant:          opts.LimitPerVariant,
+		Offset:                   opts.Offset,
+		TotalCount:               opts.TotalCount,
+		VariantSummary:           opts.VariantSummary,
+		CategoryLexicon:          opts.CategoryLexicon,
+		CategoryProfile:          opts.CategoryProfile,
+		IsHead:                   isHead,
+		MaxAvgCollocateDist:      opts.MaxAvgCollocateDist,
+		MaxAvgSurfaceDist:        opts.MaxAvgSurfaceDist,
+		CollocateOrder:           opts.CollocateOrder,
+		Limit:                    opts.Limit,
+		SortBy:                   opts.SortBy,
+		CollocateGroupByPos:      opts.CollocateGroupByPos,
+		GroupByDeprel:            opts.GroupByDeprel,
+		CollocateGroupByTextType: opts.CollocateGroupByTextType,
+		CustomFilter:             customFilter,
+		LemmaSet:                 opts.LemmaSet,
+		ExcludedTextTypes:        excludedTT,
+		CorpusSize:               opts.CorpusSize,
+		SignedDistance:           opts.SignedDistance,
+		Fields:                   opts.Fields,
+		FilterStats:              opts.FilterStats,
+		DeprelGranularity:        opts.DeprelGranularity,
+		Deprels:                  opts.Deprels,
+		PathCollocations:         opts.PathCollocations,
+		MaxScannedPairs:          opts.MaxScannedPairs,
+		MinCollFreq:              opts.MinCollFreq,
+	}, nil
+}
+
+// GetLemmaInfo provides a quick information about lemma existence and
+// frequency. It is much cheaper than GetCollocations and it is intended
+// e.g. for validating queries before submitting them.
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (calc *Calculator) GetLemmaInfo(lemma string, options ...func(opts *CalculationOptions)) (storage.LemmaInfo, error) {
+	var opts CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	excludedTT := calc.excludedTextTypes(opts)
+	return calc.database.GetLemmaInfo(lemma, excludedTT)
+}
+
+// GetLemmaProfile extends GetLemmaInfo with frequencies of the lemma
+// in individual text types and distributions of its syntactic relations.
+// It is intended for inspecting a lemma before running possibly expensive
+// searches (see storage.DB.GetLemmaProfile).
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (calc *Calculator) GetLemmaProfile(lemma string, options ...func(opts *CalculationOptions)) (storage.LemmaProfile, error) {
+	var opts CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	excludedTT := calc.excludedTextTypes(opts)
+	return calc.database.GetLemmaProfile(lemma, excludedTT)
+}
+
+// GetDeprelStats provides global statistics of individual syntactic relations
+// found in the database (including up to numExamples most frequent pairs).
+// The operation walks through the whole database so it should not be
+// called per user request without caching. The walk is aborted
+// once ctx is cancelled.
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (calc *Calculator) GetDeprelStats(ctx context.Context, numExamples int, options ...func(opts *CalculationOptions)) ([]storage.DeprelStats, error) {
+	var opts CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	excludedTT := calc.excludedTextTypes(opts)
+	return calc.database.GetDeprelStats(ctx, numExamples, excludedTT)
+}
+
+// GetCorpusSize provides the size of the corpus available to the client,
+// i.e. without the restricted text types (estimated from their
+// token frequencies) unless the access to them is enabled.
+// The returned flag tells whether the size covers the whole corpus.
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (calc *Calculator) GetCorpusSize(options ...func(opts *CalculationOptions)) (int64, bool, error) {
+	var opts CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	excludedTT := calc.excludedTextTypes(opts)
+	size, err := calc.database.CorpusSizeWithout(excludedTT)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get corpus size: %w", err)
+	}
+	return size, len(excludedTT) == 0, nil
+}
+
+// GetTextTypes provides display names of the corpus text types in their
+// intended display order (e.g. for building search forms).
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (calc *Calculator) GetTextTypes(options ...func(opts *CalculationOptions)) ([]storage.TextTypeLabel, error) {
+	var opts CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	excludedTT := calc.excludedTextTypes(opts)
+	return calc.database.TextTypeLabels(excludedTT), nil
 }
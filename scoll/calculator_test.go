@@ -0,0 +1,138 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// configuredDatabase is a Database with query defaults and restricted
+// text types normally coming from an import profile
+type configuredDatabase struct {
+	Database
+	defaults   storage.QueryDefaults
+	restricted []string
+}
+
+func (db configuredDatabase) QueryDefaults() storage.QueryDefaults {
+	return db.defaults
+}
+
+func (db configuredDatabase) RestrictedTextTypes() []string {
+	return db.restricted
+}
+
+func TestCalculatorQueryDefaults(t *testing.T) {
+	db := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10, "small": 8, "old": 30},
+		pairFreqs:   map[string]int{"big": 5, "small": 4, "old": 6},
+	})
+	calc := FromDatabase(configuredDatabase{
+		Database: db,
+		defaults: storage.QueryDefaults{SortBy: "lmi", Limit: 2, ExcludedDeprels: []string{"nmod"}},
+	})
+
+	// the profile defaults apply to omitted parameters
+	opts, err := calc.prepareOptions("dog")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.SortingMeasure("lmi"), opts.SortBy)
+	assert.Equal(t, 2, opts.Limit)
+	assert.Equal(t, []string{"nmod"}, opts.ExcludedDeprels)
+	ans, err := calc.GetCollocations(context.Background(), "dog")
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+
+	// ...and explicitly set parameters override them
+	opts, err = calc.prepareOptions("dog", WithSortBy("ldice"), WithLimit(5), WithExcludedDeprels("punct"))
+	assert.NoError(t, err)
+	assert.Equal(t, storage.SortingMeasure("ldice"), opts.SortBy)
+	assert.Equal(t, 5, opts.Limit)
+	assert.Equal(t, []string{"punct"}, opts.ExcludedDeprels)
+	ans, err = calc.GetCollocations(context.Background(), "dog", WithLimit(5))
+	assert.NoError(t, err)
+	assert.Len(t, ans, 3)
+
+	// without profile defaults, the hardcoded ones are used
+	opts, err = FromDatabase(db).prepareOptions("dog")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultSortBy, opts.SortBy)
+	assert.Equal(t, DefaultLimit, opts.Limit)
+}
+
+func TestCalculatorRestrictedTextTypes(t *testing.T) {
+	db, err := storage.OpenDBIgnoreMetadata(
+		t.TempDir(),
+		storage.NewPreconfTextTypeMapping(map[string]byte{"fiction": 0x01, "news": 0x02}),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: fiction},
+		"2": {Lemma: "dog", PoS: noun, Freq: 20, TextType: news},
+		"3": {Lemma: "big", PoS: adj, Freq: 10, TextType: fiction},
+		"4": {Lemma: "angry", PoS: adj, Freq: 10, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Freq: 5, AVGDist: 1,
+			TextType: fiction, Direction: record.DirectionHead},
+		"2": {Lemma1: "dog", PoS1: noun, Lemma2: "angry", PoS2: adj, Freq: 5, AVGDist: 1,
+			TextType: news, Direction: record.DirectionHead},
+	}
+	_, err = db.StoreData(storage.NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	calc := FromDatabase(configuredDatabase{Database: db, restricted: []string{"news"}})
+
+	// restricted text types are hidden by default...
+	ans, err := calc.GetCollocations(context.Background(), "dog", WithSortBy("ldice"))
+	assert.NoError(t, err)
+	if assert.Len(t, ans, 1) {
+		assert.Equal(t, "big", ans[0].Collocate.Value)
+		assert.Equal(t, 20, ans[0].LemmaFreq)
+		assert.Equal(t, int64(500), ans[0].CorpusSize) // 30 of 60 lemma tokens
+	}
+
+	// ...and available with the access granted
+	ans, err = calc.GetCollocations(
+		context.Background(), "dog", WithSortBy("ldice"), WithRestrictedTextTypesAccess())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	for _, item := range ans {
+		assert.Equal(t, 40, item.LemmaFreq)
+		assert.Equal(t, int64(1000), item.CorpusSize)
+	}
+
+	size, complete, err := calc.GetCorpusSize()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), size)
+	assert.False(t, complete)
+	size, complete, err = calc.GetCorpusSize(WithRestrictedTextTypesAccess())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), size)
+	assert.True(t, complete)
+}
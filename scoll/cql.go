@@ -0,0 +1,165 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// cqlSpecialChars are characters with a special meaning in CQL
+// regular expressions (and strings)
+const cqlSpecialChars = `\.*+?()[]{}|^$"`
+
+func escapeCQLValue(v string) string {
+	var ans strings.Builder
+	for _, r := range v {
+		if strings.ContainsRune(cqlSpecialChars, r) {
+			ans.WriteRune('\\')
+		}
+		ans.WriteRune(r)
+	}
+	return ans.String()
+}
+
+// isSingleHopDeprel tells whether pairs with the relation are directly
+// connected tokens (unlike e.g. relation paths or siblings) and thus
+// can be found via parent attributes.
+func isSingleHopDeprel(deprel string) bool {
+	return deprel != record.UDDeprelMapping.GetRev(record.DeprelSibling) &&
+		!strings.ContainsAny(deprel, "→←")
+}
+
+// collocationCQL creates a CQL query retrieving the co-occurrences
+// represented by the collocation. The query uses the attributes
+// lemma, upos, deprel and their parent variants (p_lemma, p_upos).
+// For a lemma set, all the lemmas are used. In case the co-occurrences
+// cannot be retrieved by a single token query (relation paths, siblings),
+// an empty string is returned.
+func collocationCQL(col storage.Collocation, nodeLemmas []string, textType, textTypesAttr string) string {
+	escNodeLemmas := make([]string, len(nodeLemmas))
+	for i, v := range nodeLemmas {
+		escNodeLemmas[i] = escapeCQLValue(v)
+	}
+	return collocationCQLNodeRE(col, strings.Join(escNodeLemmas, "|"), textType, textTypesAttr)
+}
+
+// collocationCQLNodeRE is a variant of collocationCQL where the node
+// lemma is specified by a CQL regular expression (e.g. for prefix searches).
+func collocationCQLNodeRE(col storage.Collocation, nodeLemma, textType, textTypesAttr string) string {
+	if !isSingleHopDeprel(col.Deprel) {
+		return ""
+	}
+	nodePrefix, collPrefix := "p_", ""
+	if !col.IsHead {
+		nodePrefix, collPrefix = "", "p_"
+	}
+	conds := []string{
+		fmt.Sprintf(`%slemma="%s"`, collPrefix, escapeCQLValue(col.Collocate.Value)),
+		fmt.Sprintf(`%slemma="%s"`, nodePrefix, nodeLemma),
+	}
+	if col.Deprel != "" {
+		conds = append(conds, fmt.Sprintf(`deprel="%s"`, escapeCQLValue(col.Deprel)))
+	}
+	// merged PoS values (e.g. "VERB|AUX") are not present in corpora
+	if col.Collocate.PoS != "" && !strings.Contains(col.Collocate.PoS, "|") {
+		conds = append(conds, fmt.Sprintf(`%supos="%s"`, collPrefix, escapeCQLValue(col.Collocate.PoS)))
+	}
+	if col.Lemma.PoS != "" && !strings.Contains(col.Lemma.PoS, "|") {
+		conds = append(conds, fmt.Sprintf(`%supos="%s"`, nodePrefix, escapeCQLValue(col.Lemma.PoS)))
+	}
+	ans := "[" + strings.Join(conds, " & ") + "]"
+	if textType != "" {
+		ans += textTypeCQL(textType, textTypesAttr)
+	}
+	return ans
+}
+
+// textTypeCQL creates "within" restrictions for a text type.
+// In case of multiple text type dimensions, the combined text type
+// is split into values of the individual attributes (empty values are
+// skipped) and attributes of the same structure are tested together.
+func textTypeCQL(textType, textTypesAttr string) string {
+	values := storage.TextTypeDimValues(textType)
+	structs := make([]string, 0, storage.MaxTextTypeDims)
+	conds := make(map[string][]string)
+	for i, attr := range storage.TextTypeAttrs(textTypesAttr) {
+		structName, attrName, ok := strings.Cut(attr, ".")
+		if !ok || i >= len(values) || values[i] == "" {
+			continue
+		}
+		if _, ok := conds[structName]; !ok {
+			structs = append(structs, structName)
+		}
+		conds[structName] = append(conds[structName], fmt.Sprintf(`%s="%s"`, attrName, escapeCQLValue(values[i])))
+	}
+	var ans strings.Builder
+	for _, structName := range structs {
+		fmt.Fprintf(&ans, ` within <%s %s/>`, structName, strings.Join(conds[structName], " & "))
+	}
+	return ans.String()
+}
+
+// addCQL attaches CQL queries retrieving the co-occurrences
+// to the result items. For searches ignoring diacritics, the options
+// are expected to contain VariantSummary so the queries can contain
+// the canonical forms of the matching lemmas. Word form searches
+// are not supported (the items are left without queries).
+func addCQL(items []storage.Collocation, opts CalculationOptions, textTypesAttr string) {
+	if opts.SearchByWordForm {
+		return
+	}
+	for i := range items {
+		// second order collocates are always searched for a single lemma
+		addCQL(items[i].SecondOrder, CalculationOptions{TextType: opts.TextType}, textTypesAttr)
+		nodeLemmas := opts.LemmaSet
+		if opts.lemmaInsensitive() && opts.VariantSummary != nil {
+			idx := slices.IndexFunc(*opts.VariantSummary, func(v storage.NodeVariantSummary) bool {
+				return v.Node == items[i].Lemma.Value
+			})
+			if idx >= 0 {
+				nodeLemmas = (*opts.VariantSummary)[idx].Lemmas
+			}
+		}
+		if len(nodeLemmas) == 0 {
+			nodeLemmas = []string{items[i].Lemma.Value}
+		}
+		textType := items[i].TextType
+		if textType == "" {
+			textType = opts.TextType
+		}
+		if textType == "" && len(opts.TextTypeDims) > 0 {
+			textType = strings.Join(opts.TextTypeDims, storage.TextTypeDimSeparator)
+		}
+		if opts.LemmaPattern != "" && !opts.lemmaInsensitive() && len(opts.LemmaSet) == 0 {
+			items[i].CQL = collocationCQLNodeRE(
+				items[i], strings.ReplaceAll(opts.LemmaPattern.ToRegexp(items[i].Lemma.Value), `"`, `\"`),
+				textType, textTypesAttr)
+			continue
+		}
+		if opts.PrefixSearch && opts.MergePrefixVariants && !opts.lemmaInsensitive() {
+			items[i].CQL = collocationCQLNodeRE(
+				items[i], escapeCQLValue(items[i].Lemma.Value)+".*", textType, textTypesAttr)
+			continue
+		}
+		items[i].CQL = collocationCQL(items[i], nodeLemmas, textType, textTypesAttr)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollocationCQLLemmaAsHead(t *testing.T) {
+	col := storage.Collocation{
+		Lemma:     storage.CollMember{Value: "team", PoS: "NOUN"},
+		Collocate: storage.CollMember{Value: "strong", PoS: "ADJ"},
+		Deprel:    "amod",
+		IsHead:    true,
+	}
+	assert.Equal(
+		t,
+		`[lemma="strong" & p_lemma="team" & deprel="amod" & upos="ADJ" & p_upos="NOUN"]`,
+		collocationCQL(col, []string{"team"}, "", ""),
+	)
+}
+
+func TestCollocationCQLLemmaAsDependent(t *testing.T) {
+	col := storage.Collocation{
+		Lemma:     storage.CollMember{Value: "team"},
+		Collocate: storage.CollMember{Value: "win", PoS: "VERB"},
+		Deprel:    "nsubj",
+		TextType:  "fiction",
+	}
+	assert.Equal(
+		t,
+		`[p_lemma="win" & lemma="team" & deprel="nsubj" & p_upos="VERB"] within <text txtype="fiction"/>`,
+		collocationCQL(col, []string{"team"}, col.TextType, "text.txtype"),
+	)
+}
+
+func TestCollocationCQLTextTypeDims(t *testing.T) {
+	col := storage.Collocation{
+		Lemma:     storage.CollMember{Value: "team"},
+		Collocate: storage.CollMember{Value: "win"},
+		Deprel:    "nsubj",
+	}
+	assert.Equal(
+		t,
+		`[p_lemma="win" & lemma="team" & deprel="nsubj"] within <text genre="fiction" & period="1990s"/> within <doc src="web"/>`,
+		collocationCQL(col, []string{"team"}, "fiction|1990s|web", "text.genre,text.period,doc.src"),
+	)
+	assert.Equal(
+		t,
+		`[p_lemma="win" & lemma="team" & deprel="nsubj"] within <text period="1990s"/>`,
+		collocationCQL(col, []string{"team"}, "|1990s", "text.genre,text.period"),
+	)
+}
+
+func TestCollocationCQLLemmaSetAndEscaping(t *testing.T) {
+	col := storage.Collocation{
+		Lemma:     storage.CollMember{Value: "weekdays"},
+		Collocate: storage.CollMember{Value: "a.b", PoS: "VERB|AUX"},
+		IsHead:    true,
+	}
+	assert.Equal(
+		t,
+		`[lemma="a\.b" & p_lemma="monday|tuesday"]`,
+		collocationCQL(col, []string{"monday", "tuesday"}, "", ""),
+	)
+}
+
+func TestCollocationCQLNotExpressible(t *testing.T) {
+	col := storage.Collocation{
+		Lemma:     storage.CollMember{Value: "team"},
+		Collocate: storage.CollMember{Value: "strong"},
+		Deprel:    "obj→amod",
+	}
+	assert.Empty(t, collocationCQL(col, []string{"team"}, "", ""))
+	col.Deprel = "sibling"
+	assert.Empty(t, collocationCQL(col, []string{"team"}, "", ""))
+}
+
+func TestAddCQLLemmaPattern(t *testing.T) {
+	items := []storage.Collocation{
+		{
+			Lemma:     storage.CollMember{Value: "run*"},
+			Collocate: storage.CollMember{Value: "fast"},
+			Deprel:    "advmod",
+			IsHead:    true,
+		},
+	}
+	addCQL(items, CalculationOptions{LemmaPattern: storage.LemmaPatternGlob}, "")
+	assert.Equal(t, `[lemma="fast" & p_lemma="run.*" & deprel="advmod"]`, items[0].CQL)
+}
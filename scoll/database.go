@@ -0,0 +1,72 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// Database is a collocation database a Calculator searches in.
+// It is implemented by storage.DB. Code using the interface (instead
+// of the concrete type) does not depend on how the data are stored.
+type Database interface {
+	CalculateMeasures(ctx context.Context, args storage.CalculationArgs) ([]storage.Collocation, error)
+	CalculateMeasuresBatch(ctx context.Context, batch []storage.CalculationArgs) ([][]storage.Collocation, error)
+	CalculateSecondOrder(ctx context.Context, args storage.CalculationArgs, limit int) ([]storage.Collocation, error)
+	SecondOrderCollocations(ctx context.Context, args storage.CalculationArgs, item storage.Collocation, limit int) ([]storage.Collocation, error)
+	GetLemmaInfo(lemma string, excludedTextTypes []string) (storage.LemmaInfo, error)
+	GetLemmaProfile(lemma string, excludedTextTypes []string) (storage.LemmaProfile, error)
+	GetLemmaFreq(lemma, pos, textType string, excludedTextTypes []string) (int, error)
+	GetDeprelStats(ctx context.Context, numExamples int, excludedTextTypes []string) ([]storage.DeprelStats, error)
+	TextTypeLabels(excludedTextTypes []string) []storage.TextTypeLabel
+	TextTypesNotMatching(dims []string) ([]string, error)
+	CorpusSizeWithout(textTypes []string) (int64, error)
+	RelationDistLimits(spread float64) map[uint16]float64
+	DatasetMetadata() storage.Metadata
+	Deprels() *record.DeprelMapping
+	QueryDefaults() storage.QueryDefaults
+	RestrictedTextTypes() []string
+	TextTypesAttr() string
+	Close() error
+}
+
+var _ Database = (*storage.DB)(nil)
+
+// Open opens a collocation database created by an import
+// (see the dataimport package) and returns a calculator
+// searching in it. Once not needed, the calculator should be
+// closed via Calculator.Close.
+func Open(path string) (*Calculator, error) {
+	db, err := storage.OpenDB(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromDatabase(db), nil
+}
+
+// OpenReadOnly is a variant of Open using the read-only mode
+// (see storage.OpenDBReadOnly) so multiple processes can search
+// in the same database at once.
+func OpenReadOnly(path string) (*Calculator, error) {
+	db, err := storage.OpenDBReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromDatabase(db), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+const (
+	EncodingJSON    ResponseEncoding = "application/json"
+	EncodingMsgpack ResponseEncoding = "application/msgpack"
+	EncodingCBOR    ResponseEncoding = "application/cbor"
+)
+
+// ResponseEncoding specifies a format of encoded results.
+// The value is also a proper MIME type of the format.
+type ResponseEncoding string
+
+func (enc ResponseEncoding) ContentType() string {
+	return string(enc)
+}
+
+// NegotiateEncoding selects a response encoding based on a HTTP
+// Accept header value. The first supported media type wins (quality
+// parameters are ignored). If nothing matches, JSON is used.
+func NegotiateEncoding(accept string) ResponseEncoding {
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(item, ";")
+		switch strings.TrimSpace(strings.ToLower(mediaType)) {
+		case "application/msgpack", "application/x-msgpack", "application/vnd.msgpack":
+			return EncodingMsgpack
+		case "application/cbor":
+			return EncodingCBOR
+		case "application/json":
+			return EncodingJSON
+		}
+	}
+	return EncodingJSON
+}
+
+// EncodeCollocations encodes collocations using the required encoding.
+func EncodeCollocations(items []storage.Collocation, enc ResponseEncoding) ([]byte, error) {
+	switch enc {
+	case EncodingMsgpack:
+		return storage.EncodeCollocationsMsgpack(items)
+	case EncodingCBOR:
+		return storage.EncodeCollocationsCBOR(items)
+	default:
+		return json.Marshal(items)
+	}
+}
+
+// EncodeValue encodes a general value (e.g. storage.LemmaInfo)
+// using the required encoding. For collocations, EncodeCollocations
+// must be used.
+func EncodeValue(v any, enc ResponseEncoding) ([]byte, error) {
+	switch enc {
+	case EncodingMsgpack:
+		return storage.EncodeMsgpack(v)
+	case EncodingCBOR:
+		return storage.EncodeCBOR(v)
+	default:
+		return json.Marshal(v)
+	}
+}
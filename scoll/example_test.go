@@ -0,0 +1,144 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// createExampleDB creates a tiny database (normally created by
+// the mkscolldb tool) with collocations of the lemma "house".
+func createExampleDB() string {
+	dbPath, err := os.MkdirTemp("", "scoll-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	prof := storage.FindProfile("intercorp_v16ud")
+	db, err := storage.OpenDBIgnoreMetadata(dbPath, prof.TextTypes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Readable: "fiction", Raw: prof.TextTypes.ReadableToRaw("fiction")}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	verb := record.UDPosFromByte(record.PosVERB)
+	amod := record.ImportUDDeprel("amod")
+	obj := record.ImportUDDeprel("obj")
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 50, TextType: tt},
+		"2": {Lemma: "big", PoS: adj, Freq: 30, TextType: tt},
+		"3": {Lemma: "old", PoS: adj, Freq: 20, TextType: tt},
+		"4": {Lemma: "build", PoS: verb, Freq: 40, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "house", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj,
+			Freq: 10, AVGDist: 1, TextType: tt, Direction: record.DirectionHead},
+		"2": {Lemma1: "house", PoS1: noun, Deprel: amod, Lemma2: "old", PoS2: adj,
+			Freq: 4, AVGDist: 1, TextType: tt, Direction: record.DirectionHead},
+		"3": {Lemma1: "house", PoS1: noun, Deprel: obj, Lemma2: "build", PoS2: verb,
+			Freq: 8, AVGDist: 1, TextType: tt, Direction: record.DirectionDependent},
+	}
+	stats, err := db.StoreData(storage.NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = db.StoreMetadata(storage.Metadata{
+		CorpusSize:       1000,
+		ProfileName:      prof.Name,
+		NumLemmas:        stats.NumLemmas,
+		NumLemmaFreqs:    stats.NumLemmaFreqs,
+		NumCollFreqs:     stats.NumCollFreqs,
+		DeprelMap:        record.UDDeprelMapping.AsMap(),
+		TokenFreqRollups: true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return dbPath
+}
+
+func ExampleOpen() {
+	dbPath := createExampleDB()
+	defer os.RemoveAll(dbPath)
+
+	calc, err := scoll.Open(dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer calc.Close()
+	colls, err := calc.GetCollocations(
+		context.Background(),
+		"house",
+		scoll.WithSortBy("ldice"),
+		scoll.WithLimit(3),
+		scoll.WithGroupByDeprel(),
+		scoll.WithoutQueryLog(),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range colls {
+		fmt.Printf(
+			"%s %s (%s, head: %t) freq: %d, logDice: %.2f\n",
+			c.Lemma.Value, c.Collocate.Value, c.Deprel, c.IsHead, c.Freq, c.LogDice)
+	}
+	// Output:
+	// house big (amod, head: true) freq: 10, logDice: 12.00
+	// house build (obj, head: false) freq: 8, logDice: 11.51
+	// house old (amod, head: true) freq: 4, logDice: 10.87
+}
+
+func ExampleCalculator_GetLemmaInfo() {
+	dbPath := createExampleDB()
+	defer os.RemoveAll(dbPath)
+
+	calc, err := scoll.Open(dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer calc.Close()
+	info, err := calc.GetLemmaInfo("house")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("exists: %t, freq: %d, NOUN: %d\n", info.Exists, info.Freq, info.PoSFreqs["NOUN"])
+	// Output:
+	// exists: true, freq: 50, NOUN: 50
+}
+
+func ExampleCalculationOptions_AsURLValues() {
+	var opts scoll.CalculationOptions
+	for _, opt := range []func(*scoll.CalculationOptions){
+		scoll.WithPoS("NOUN"),
+		scoll.WithLimit(20),
+		scoll.WithSortBy("ldice"),
+	} {
+		opt(&opts)
+	}
+	fmt.Println(opts.AsURLValues().Encode())
+	// Output:
+	// limit=20&pos=NOUN&sortBy=ldice
+}
@@ -0,0 +1,553 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+var (
+	ErrNoFederatedComponents   = errors.New("no databases provided for federated search")
+	ErrInvalidComponentWeights = errors.New("invalid weights of federated search components")
+	ErrFederatedWordFormSearch = errors.New("word form search is not supported by federated search")
+	ErrFederatedFeatsGrouping  = errors.New("grouping by morphological features is not supported by federated search")
+)
+
+var _ CollocationProvider = (*FederatedCalculator)(nil)
+
+// FederatedCalculator runs the same queries on multiple databases (e.g.
+// separately imported parts of a larger corpus) and combines the results
+// as if they came from a single database. Raw frequencies F(x,y), F(x),
+// F(y) are summed, N is the sum of the components' corpus sizes and
+// the measures are recalculated from the sums.
+//
+// Corpus specific query defaults (see storage.QueryDefaults) are taken
+// from the first database.
+//
+// By default, larger databases naturally dominate the combined results.
+// To prevent this, components can be weighted (see SetWeights) and/or
+// normalized to the same corpus size (see SetSizeNormalization). In such
+// case, the weighted frequencies are rounded to integers. Weighting
+// applies only to collocation searches.
+type FederatedCalculator struct {
+	components     []*Calculator
+	queryLog       *QueryLog
+	weights        []float64
+	normalizeSizes bool
+}
+
+func NewFederatedCalculator(components ...*Calculator) (*FederatedCalculator, error) {
+	if len(components) == 0 {
+		return nil, ErrNoFederatedComponents
+	}
+	return &FederatedCalculator{components: components}, nil
+}
+
+// FederatedFromDatabases is a convenience constructor creating
+// a FederatedCalculator directly from opened databases.
+func FederatedFromDatabases(dbs ...*storage.DB) (*FederatedCalculator, error) {
+	components := make([]*Calculator, len(dbs))
+	for i, db := range dbs {
+		components[i] = FromDatabase(db)
+	}
+	return NewFederatedCalculator(components...)
+}
+
+// WithQueryLog sets a query log where all the searches
+// (except for those opted-out via WithoutQueryLog) are recorded.
+// Individual components do not log the queries.
+func (fed *FederatedCalculator) WithQueryLog(ql *QueryLog) *FederatedCalculator {
+	fed.queryLog = ql
+	return fed
+}
+
+// SetWeights sets multipliers of the frequencies (and corpus sizes)
+// of individual components (in the order they were provided). The number
+// of weights must match the number of components and all the weights
+// must be positive. Calling the method without weights resets
+// the weighting.
+func (fed *FederatedCalculator) SetWeights(weights ...float64) error {
+	if len(weights) == 0 {
+		fed.weights = nil
+		return nil
+	}
+	if len(weights) != len(fed.components) {
+		return fmt.Errorf(
+			"%w: %d weights for %d components", ErrInvalidComponentWeights, len(weights), len(fed.components))
+	}
+	for _, w := range weights {
+		if w <= 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+			return fmt.Errorf("%w: %v", ErrInvalidComponentWeights, w)
+		}
+	}
+	fed.weights = slices.Clone(weights)
+	return nil
+}
+
+// SetSizeNormalization enables or disables scaling of component frequencies
+// so that each component contributes as if its corpus size was equal
+// to the size of the largest component. Combined with SetWeights,
+// the weights are applied to the normalized frequencies.
+func (fed *FederatedCalculator) SetSizeNormalization(enabled bool) {
+	fed.normalizeSizes = enabled
+}
+
+func (fed *FederatedCalculator) GetCollocations(ctx context.Context, lemma string, options ...func(opts *CalculationOptions)) ([]storage.Collocation, error) {
+	var opts CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.AdaptiveLimits {
+		lemmaFreq, err := fed.nodeLemmaFreq(lemma, opts)
+		if err != nil {
+			return []storage.Collocation{}, err
+		}
+		adaptLimits(&opts, lemmaFreq)
+	}
+	fed.components[0].applyDefaults(&opts)
+	if opts.GenerateCQL && opts.lemmaInsensitive() && opts.VariantSummary == nil {
+		// CQL queries must contain the canonical forms of the matching lemmas
+		opts.VariantSummary = new([]storage.NodeVariantSummary)
+	}
+	t0 := time.Now()
+	ans, err := fed.getCollocations(ctx, lemma, opts)
+	if !opts.NoQueryLog {
+		fed.queryLog.Log(newQueryLogRecord(lemma, opts, t0, len(ans), err))
+	}
+	if err == nil && opts.LabelLang != "" {
+		addLabelDescriptions(ans, opts.LabelLang)
+	}
+	if err == nil && opts.GenerateCQL {
+		addCQL(ans, opts, fed.components[0].database.TextTypesAttr())
+	}
+	return ans, err
+}
+
+// nodeLemmaFreq sums frequencies of the searched lemma in all
+// the components (see Calculator.nodeLemmaFreq). The frequency band
+// is determined from raw frequencies, i.e. without weighting.
+func (fed *FederatedCalculator) nodeLemmaFreq(lemma string, opts CalculationOptions) (int, error) {
+	var ans int
+	for _, calc := range fed.components {
+		freq, err := calc.nodeLemmaFreq(lemma, opts)
+		if err != nil || freq < 0 {
+			return freq, err
+		}
+		ans += freq
+	}
+	return ans, nil
+}
+
+// federatedKey identifies the same result row across the components.
+// Attributes the results are not grouped by are always empty
+// so they do not affect the matching.
+type federatedKey struct {
+	lemma, lemmaPoS         string
+	collocate, collocatePoS string
+	deprel, textType        string
+	isHead                  bool
+}
+
+func newFederatedKey(col storage.Collocation) federatedKey {
+	return federatedKey{
+		lemma:        col.Lemma.Value,
+		lemmaPoS:     col.Lemma.PoS,
+		collocate:    col.Collocate.Value,
+		collocatePoS: col.Collocate.PoS,
+		deprel:       col.Deprel,
+		textType:     col.TextType,
+		isHead:       col.IsHead,
+	}
+}
+
+// singleFreqKey identifies F(x) or F(y) within a component
+type singleFreqKey struct {
+	lemma, pos, textType string
+}
+
+// componentFreqs provides F(x) and F(y) of a single component
+// even for pairs not found in the component. Values known from
+// the component's results are preferred, others are looked up
+// in the database.
+type componentFreqs struct {
+	calc       *Calculator
+	excludedTT []string
+	fx         map[singleFreqKey]int
+	fy         map[singleFreqKey]int
+}
+
+func (cf *componentFreqs) get(cache map[singleFreqKey]int, key singleFreqKey) (int, error) {
+	if v, ok := cache[key]; ok {
+		return v, nil
+	}
+	v, err := cf.calc.database.GetLemmaFreq(key.lemma, key.pos, key.textType, cf.excludedTT)
+	if err != nil {
+		return 0, err
+	}
+	cache[key] = v
+	return v, nil
+}
+
+// componentScales returns factors the frequencies (and corpus size) of
+// individual components are multiplied by before they are combined.
+func (fed *FederatedCalculator) componentScales() ([]float64, error) {
+	ans := make([]float64, len(fed.components))
+	var maxSize int64
+	for _, calc := range fed.components {
+		maxSize = max(maxSize, calc.database.DatasetMetadata().CorpusSize)
+	}
+	for i, calc := range fed.components {
+		ans[i] = 1
+		if len(fed.weights) > 0 {
+			ans[i] = fed.weights[i]
+		}
+		if fed.normalizeSizes {
+			size := calc.database.DatasetMetadata().CorpusSize
+			if size <= 0 {
+				return ans, fmt.Errorf(
+					"%w: cannot normalize component with corpus size %d", storage.ErrInvalidCorpusSize, size)
+			}
+			ans[i] *= float64(maxSize) / float64(size)
+		}
+	}
+	return ans, nil
+}
+
+// federatedItem is a result item combined from the components
+// along with its (possibly weighted) frequencies
+type federatedItem struct {
+	item storage.Collocation
+	fxy  float64
+	fx   float64
+	fy   float64
+}
+
+func (fed *FederatedCalculator) getCollocations(ctx context.Context, lemma string, opts CalculationOptions) ([]storage.Collocation, error) {
+	// to be able to combine the frequencies, we need all the candidates
+	// from the components, N is applied only to the final calculation
+	compOpts := opts
+	compOpts.Limit = math.MaxInt32
+	compOpts.Offset = 0
+	compOpts.TotalCount = nil
+	compOpts.CorpusSize = 0
+	compOpts.CategoryProfile = nil
+	compOpts.SecondOrderLimit = 0
+	compOpts.MinCollFreq = 0 // must be applied to the combined frequencies
+	if opts.Offset < 0 {
+		return []storage.Collocation{}, fmt.Errorf("%w: %d", storage.ErrInvalidOffset, opts.Offset)
+	}
+	if opts.SearchByWordForm {
+		// combined F(x), F(y) are looked up via lemmas
+		return []storage.Collocation{}, ErrFederatedWordFormSearch
+	}
+	if opts.GroupByFeats {
+		// combined F(x) values are looked up regardless of features
+		return []storage.Collocation{}, ErrFederatedFeatsGrouping
+	}
+
+	scales, err := fed.componentScales()
+	if err != nil {
+		return []storage.Collocation{}, fmt.Errorf("federated search failed: %w", err)
+	}
+	merged := make(map[federatedKey]*federatedItem)
+	order := make([]federatedKey, 0, 100)
+	freqs := make([]*componentFreqs, len(fed.components))
+	var corpusSize float64
+	var variants []storage.NodeVariantSummary
+	for i, calc := range fed.components {
+		var compVariants []storage.NodeVariantSummary
+		compOpts.VariantSummary = &compVariants
+		items, err := calc.getCollocations(ctx, lemma, compOpts)
+		if err != nil {
+			return []storage.Collocation{}, fmt.Errorf("federated search failed: %w", err)
+		}
+		variants = mergeVariantSummaries(variants, compVariants)
+		excludedTT := calc.excludedTextTypes(opts)
+		if len(opts.TextTypeDims) > 0 {
+			// the frequencies looked up for missing items must respect the same restriction
+			mismatches, err := calc.database.TextTypesNotMatching(opts.TextTypeDims)
+			if err != nil {
+				return []storage.Collocation{}, fmt.Errorf("federated search failed: %w", err)
+			}
+			excludedTT = append(slices.Clone(excludedTT), mismatches...)
+		}
+		compSize, err := calc.database.CorpusSizeWithout(excludedTT)
+		if err != nil {
+			return []storage.Collocation{}, fmt.Errorf("federated search failed: %w", err)
+		}
+		corpusSize += scales[i] * float64(compSize)
+		freqs[i] = &componentFreqs{
+			calc:       calc,
+			excludedTT: excludedTT,
+			fx:         make(map[singleFreqKey]int),
+			fy:         make(map[singleFreqKey]int),
+		}
+		for _, item := range items {
+			freqs[i].fx[fed.lemmaFreqKey(item, opts)] = item.LemmaFreq
+			freqs[i].fy[fed.collocateFreqKey(item, opts)] = item.CollocateFreq
+			key := newFederatedKey(item)
+			fxy := scales[i] * float64(item.Freq)
+			curr, ok := merged[key]
+			if !ok {
+				merged[key] = &federatedItem{item: item, fxy: fxy}
+				order = append(order, key)
+				continue
+			}
+			total := curr.fxy + fxy
+			if total > 0 {
+				curr.item.MutualDist = (curr.fxy*curr.item.MutualDist + fxy*item.MutualDist) / total
+				curr.item.SurfaceDist = (curr.fxy*curr.item.SurfaceDist + fxy*item.SurfaceDist) / total
+			}
+			curr.fxy = total
+		}
+	}
+	n := int64(math.Round(corpusSize))
+	if opts.CorpusSize > 0 {
+		n = opts.CorpusSize
+	}
+
+	ans := make([]storage.Collocation, 0, len(order))
+	for _, key := range order {
+		fItem := merged[key]
+		for i, cf := range freqs {
+			fx, err := cf.get(cf.fx, fed.lemmaFreqKey(fItem.item, opts))
+			if err != nil {
+				return []storage.Collocation{}, fmt.Errorf("federated search failed: %w", err)
+			}
+			fy, err := cf.get(cf.fy, fed.collocateFreqKey(fItem.item, opts))
+			if err != nil {
+				return []storage.Collocation{}, fmt.Errorf("federated search failed: %w", err)
+			}
+			fItem.fx += scales[i] * float64(fx)
+			fItem.fy += scales[i] * float64(fy)
+		}
+		item := fItem.item
+		item.Freq = int(math.Round(fItem.fxy))
+		item.LemmaFreq = int(math.Round(fItem.fx))
+		item.CollocateFreq = int(math.Round(fItem.fy))
+		if item.Freq == 0 || item.Freq < opts.MinCollFreq {
+			// heavily down-weighted pairs would produce undefined scores
+			continue
+		}
+		if int64(item.LemmaFreq) > n || int64(item.CollocateFreq) > n {
+			return []storage.Collocation{}, fmt.Errorf(
+				"%w: %d is lower than the frequency of the searched lemma or a collocate",
+				storage.ErrInvalidCorpusSize, n,
+			)
+		}
+		item.CorpusSize = n
+		item.UpdateScores(nil)
+		ans = append(ans, item)
+	}
+	storage.SortCollocations(ans, opts.SortBy)
+	if opts.CategoryLexicon != nil && opts.CategoryProfile != nil {
+		*opts.CategoryProfile = storage.ProfileCategories(ans, opts.CategoryLexicon)
+	}
+	numCandidates := storage.CountVariantCollocations(ans)
+	if opts.TotalCount != nil {
+		*opts.TotalCount = len(ans)
+	}
+	ans = storage.PageCollocations(ans, opts.Offset, opts.Limit, opts.LimitPerVariant)
+	if opts.VariantSummary != nil {
+		numReturned := storage.CountVariantCollocations(ans)
+		for i, v := range variants {
+			variants[i].NumCandidates = numCandidates[v.Node]
+			variants[i].NumReturned = numReturned[v.Node]
+		}
+		*opts.VariantSummary = variants
+	}
+	if opts.SecondOrderLimit > 0 {
+		if err := fed.attachSecondOrder(ctx, ans, opts); err != nil {
+			return []storage.Collocation{}, err
+		}
+	}
+	return ans, nil
+}
+
+// attachSecondOrder searches for collocates of the found collocates
+// in the same way storage.DB.CalculateSecondOrder does it for a single
+// database.
+func (fed *FederatedCalculator) attachSecondOrder(ctx context.Context, items []storage.Collocation, opts CalculationOptions) error {
+	for i, item := range items {
+		sub, err := fed.getCollocations(ctx, item.Collocate.Value, secondOrderOptions(opts, item))
+		if err != nil {
+			return fmt.Errorf("failed to calculate second order collocations of %s: %w", item.Collocate.Value, err)
+		}
+		items[i].SecondOrder = make([]storage.Collocation, 0, opts.SecondOrderLimit)
+		for _, item2 := range sub {
+			if item2.Collocate.Value == item.Lemma.Value || len(items[i].SecondOrder) == opts.SecondOrderLimit {
+				continue
+			}
+			items[i].SecondOrder = append(items[i].SecondOrder, item2)
+		}
+	}
+	return nil
+}
+
+// secondOrderOptions derives options of a search for collocates
+// of the collocate item (see storage.DB.CalculateSecondOrder)
+func secondOrderOptions(opts CalculationOptions, item storage.Collocation) CalculationOptions {
+	ans := opts
+	ans.PoS = item.Collocate.PoS
+	ans.PrefixSearch = false
+	ans.MergePrefixVariants = false
+	ans.IgnoreDiacritics = false
+	ans.IgnoreCase = false
+	ans.GroupByFeats = false
+	ans.LemmaSet = nil
+	ans.LemmaPattern = ""
+	ans.LemmasAsHead = nil
+	ans.LimitPerVariant = false
+	ans.Offset = 0
+	ans.Limit = opts.SecondOrderLimit + 1
+	ans.TotalCount = nil
+	ans.VariantSummary = nil
+	ans.CategoryLexicon = nil
+	ans.CategoryProfile = nil
+	ans.FilterStats = nil
+	ans.SecondOrderLimit = 0
+	return ans
+}
+
+// mergeVariantSummaries adds node variants found by a component
+// to the ones found by the previous components. Numbers of collocations
+// are not merged as they must be determined from the combined result.
+func mergeVariantSummaries(curr, comp []storage.NodeVariantSummary) []storage.NodeVariantSummary {
+	for _, cv := range comp {
+		idx := slices.IndexFunc(curr, func(v storage.NodeVariantSummary) bool { return v.Node == cv.Node })
+		if idx < 0 {
+			curr = append(curr, storage.NodeVariantSummary{Node: cv.Node, Lemmas: slices.Clone(cv.Lemmas)})
+			continue
+		}
+		for _, lemma := range cv.Lemmas {
+			if !slices.Contains(curr[idx].Lemmas, lemma) {
+				curr[idx].Lemmas = append(curr[idx].Lemmas, lemma)
+			}
+		}
+	}
+	return curr
+}
+
+// lemmaFreqKey returns a key of F(x) related to the item.
+// Note that with a custom lemma set, only the frequency of the
+// main lemma is looked up for components not containing any
+// matching pair.
+func (fed *FederatedCalculator) lemmaFreqKey(item storage.Collocation, opts CalculationOptions) singleFreqKey {
+	return singleFreqKey{lemma: item.Lemma.Value, pos: opts.PoS, textType: item.TextType}
+}
+
+// collocateFreqKey returns a key of F(y) related to the item
+func (fed *FederatedCalculator) collocateFreqKey(item storage.Collocation, opts CalculationOptions) singleFreqKey {
+	ans := singleFreqKey{lemma: item.Collocate.Value, textType: item.TextType}
+	if opts.CollocateGroupByPos {
+		ans.pos = item.Collocate.PoS
+	}
+	return ans
+}
+
+// GetLemmaInfo sums the lemma frequencies found in the databases.
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (fed *FederatedCalculator) GetLemmaInfo(lemma string, options ...func(opts *CalculationOptions)) (storage.LemmaInfo, error) {
+	ans := storage.LemmaInfo{Lemma: lemma, PoSFreqs: make(map[string]int)}
+	for _, calc := range fed.components {
+		info, err := calc.GetLemmaInfo(lemma, options...)
+		if err != nil {
+			return ans, err
+		}
+		ans.Exists = ans.Exists || info.Exists
+		ans.Freq += info.Freq
+		for pos, freq := range info.PoSFreqs {
+			ans.PoSFreqs[pos] += freq
+		}
+	}
+	return ans, nil
+}
+
+// GetLemmaProfile sums the lemma profiles found in the databases.
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (fed *FederatedCalculator) GetLemmaProfile(lemma string, options ...func(opts *CalculationOptions)) (storage.LemmaProfile, error) {
+	ans := storage.LemmaProfile{
+		LemmaInfo:            storage.LemmaInfo{Lemma: lemma, PoSFreqs: make(map[string]int)},
+		TextTypeFreqs:        make(map[string]int),
+		HeadDeprelFreqs:      make(map[string]int),
+		DependentDeprelFreqs: make(map[string]int),
+	}
+	for _, calc := range fed.components {
+		prof, err := calc.GetLemmaProfile(lemma, options...)
+		if err != nil {
+			return ans, err
+		}
+		ans.Exists = ans.Exists || prof.Exists
+		ans.Freq += prof.Freq
+		for pos, freq := range prof.PoSFreqs {
+			ans.PoSFreqs[pos] += freq
+		}
+		for tt, freq := range prof.TextTypeFreqs {
+			ans.TextTypeFreqs[tt] += freq
+		}
+		for deprel, freq := range prof.HeadDeprelFreqs {
+			ans.HeadDeprelFreqs[deprel] += freq
+		}
+		for deprel, freq := range prof.DependentDeprelFreqs {
+			ans.DependentDeprelFreqs[deprel] += freq
+		}
+	}
+	return ans, nil
+}
+
+// GetDeprelStats combines statistics of individual databases
+// (see storage.MergeDeprelStats).
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (fed *FederatedCalculator) GetDeprelStats(ctx context.Context, numExamples int, options ...func(opts *CalculationOptions)) ([]storage.DeprelStats, error) {
+	parts := make([][]storage.DeprelStats, len(fed.components))
+	for i, calc := range fed.components {
+		stats, err := calc.GetDeprelStats(ctx, numExamples, options...)
+		if err != nil {
+			return []storage.DeprelStats{}, err
+		}
+		parts[i] = stats
+	}
+	return storage.MergeDeprelStats(numExamples, parts...), nil
+}
+
+// GetTextTypes provides a union of the databases' text types. The display
+// order is given by the first database containing the respective text type.
+// From the options, only WithRestrictedTextTypesAccess is applied.
+func (fed *FederatedCalculator) GetTextTypes(options ...func(opts *CalculationOptions)) ([]storage.TextTypeLabel, error) {
+	ans := make([]storage.TextTypeLabel, 0, 10)
+	seen := make(map[string]bool)
+	for _, calc := range fed.components {
+		labels, err := calc.GetTextTypes(options...)
+		if err != nil {
+			return []storage.TextTypeLabel{}, err
+		}
+		for _, label := range labels {
+			if !seen[label.Value] {
+				seen[label.Value] = true
+				ans = append(ans, label)
+			}
+		}
+	}
+	return ans, nil
+}
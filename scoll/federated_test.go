@@ -0,0 +1,216 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type federatedTestData struct {
+	corpusSize  int64
+	singleFreqs map[string]int
+	pairFreqs   map[string]int
+}
+
+// openFederatedTestDB creates a database where all the single tokens
+// are nouns and all the pairs are "lemma (head) -> adjective (dependent)"
+func openFederatedTestDB(t *testing.T, data federatedTestData) *storage.DB {
+	db, err := storage.OpenDBIgnoreMetadata(
+		t.TempDir(), storage.NewPreconfTextTypeMapping(map[string]byte{"fiction": 0x01}))
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.Metadata.CorpusSize = data.corpusSize
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := make(map[record.GroupingKey]record.TokenFreq)
+	for lemma, freq := range data.singleFreqs {
+		pos := noun
+		if lemma != "dog" {
+			pos = adj
+		}
+		singleFreqs[record.GroupingKey(lemma)] = record.TokenFreq{
+			Lemma: lemma, PoS: pos, Freq: freq, TextType: tt}
+	}
+	pairFreqs := make(map[record.GroupingKey]record.CollocFreq)
+	for lemma, freq := range data.pairFreqs {
+		pairFreqs[record.GroupingKey(lemma)] = record.CollocFreq{
+			Lemma1: "dog", PoS1: noun, Lemma2: lemma, PoS2: adj, Freq: freq,
+			AVGDist: 1, TextType: tt, Direction: record.DirectionHead}
+	}
+	_, err = db.StoreData(storage.NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	return db
+}
+
+func TestFederatedCalculatorGetCollocations(t *testing.T) {
+	db1 := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10},
+		pairFreqs:   map[string]int{"big": 5},
+	})
+	db2 := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  500,
+		singleFreqs: map[string]int{"dog": 10, "big": 5, "small": 8},
+		pairFreqs:   map[string]int{"small": 4},
+	})
+	fed, err := FederatedFromDatabases(db1, db2)
+	assert.NoError(t, err)
+
+	ans, err := fed.GetCollocations(context.Background(), "dog", WithSortBy("ldice"), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	// big: F(x,y) = 5, F(x) = 20 + 10, F(y) = 10 + 5 (found only via lookup in db2)
+	assert.Equal(t, "big", ans[0].Collocate.Value)
+	assert.Equal(t, 5, ans[0].Freq)
+	assert.Equal(t, 30, ans[0].LemmaFreq)
+	assert.Equal(t, 15, ans[0].CollocateFreq)
+	assert.InDelta(t, 14.0+math.Log2(2*5.0/(30+15)), ans[0].LogDice, 0.0001)
+	assert.Equal(t, int64(1500), ans[0].CorpusSize)
+	// small: F(x,y) = 4, F(x) = 20 + 10, F(y) = 0 + 8
+	assert.Equal(t, "small", ans[1].Collocate.Value)
+	assert.InDelta(t, 14.0+math.Log2(2*4.0/(30+8)), ans[1].LogDice, 0.0001)
+	assert.InDelta(t, 4*math.Log2(1500*4.0/(30*8)), ans[1].LMI, 0.0001)
+
+	ans, err = fed.GetCollocations(context.Background(), "dog", WithSortBy("ldice"), WithLimit(1), WithCorpusSize(3000), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, int64(3000), ans[0].CorpusSize)
+
+	var total int
+	ans, err = fed.GetCollocations(
+		context.Background(), "dog", WithSortBy("ldice"), WithLimit(1), WithOffset(1), WithTotalCount(&total), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "small", ans[0].Collocate.Value)
+	assert.Equal(t, 2, total)
+
+	_, err = fed.GetCollocations(context.Background(), "dog", WithOffset(-1), WithoutQueryLog())
+	assert.ErrorIs(t, err, storage.ErrInvalidOffset)
+
+	// the only collocate of the adjectives is the searched lemma itself
+	ans, err = fed.GetCollocations(context.Background(), "dog", WithSortBy("ldice"), WithSecondOrder(3), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	for _, item := range ans {
+		assert.NotNil(t, item.SecondOrder)
+		assert.Empty(t, item.SecondOrder)
+	}
+}
+
+func TestFederatedCalculatorWeighting(t *testing.T) {
+	db1 := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10},
+		pairFreqs:   map[string]int{"big": 5},
+	})
+	db2 := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  500,
+		singleFreqs: map[string]int{"dog": 10, "big": 5, "small": 8},
+		pairFreqs:   map[string]int{"small": 4},
+	})
+	fed, err := FederatedFromDatabases(db1, db2)
+	assert.NoError(t, err)
+
+	// F(x,y) = 5, F(x) = 20 + 2 * 10, F(y) = 10 + 2 * 5, N = 1000 + 2 * 500
+	expectedBig := func(ans []storage.Collocation) {
+		idx := slices.IndexFunc(ans, func(v storage.Collocation) bool { return v.Collocate.Value == "big" })
+		assert.GreaterOrEqual(t, idx, 0)
+		assert.Equal(t, 5, ans[idx].Freq)
+		assert.Equal(t, 40, ans[idx].LemmaFreq)
+		assert.Equal(t, 20, ans[idx].CollocateFreq)
+		assert.Equal(t, int64(2000), ans[idx].CorpusSize)
+		assert.InDelta(t, 5*math.Log2(2000*5.0/(40*20)), ans[idx].LMI, 0.0001)
+	}
+
+	assert.NoError(t, fed.SetWeights(1, 2))
+	ans, err := fed.GetCollocations(context.Background(), "dog", WithoutQueryLog())
+	assert.NoError(t, err)
+	expectedBig(ans)
+
+	// db2 is half the size of db1 so the normalization has the same effect
+	assert.NoError(t, fed.SetWeights())
+	fed.SetSizeNormalization(true)
+	ans, err = fed.GetCollocations(context.Background(), "dog", WithoutQueryLog())
+	assert.NoError(t, err)
+	expectedBig(ans)
+
+	assert.ErrorIs(t, fed.SetWeights(1), ErrInvalidComponentWeights)
+	assert.ErrorIs(t, fed.SetWeights(1, 0), ErrInvalidComponentWeights)
+	assert.ErrorIs(t, fed.SetWeights(1, math.NaN()), ErrInvalidComponentWeights)
+}
+
+func TestFederatedCalculatorGetLemmaInfo(t *testing.T) {
+	db1 := openFederatedTestDB(t, federatedTestData{
+		corpusSize: 1000, singleFreqs: map[string]int{"dog": 20, "big": 10}})
+	db2 := openFederatedTestDB(t, federatedTestData{
+		corpusSize: 500, singleFreqs: map[string]int{"big": 5}})
+	fed, err := FederatedFromDatabases(db1, db2)
+	assert.NoError(t, err)
+
+	info, err := fed.GetLemmaInfo("big")
+	assert.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, 15, info.Freq)
+	assert.Equal(t, map[string]int{"ADJ": 15}, info.PoSFreqs)
+
+	info, err = fed.GetLemmaInfo("dog")
+	assert.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, 20, info.Freq)
+}
+
+func TestFederatedCalculatorGetLemmaProfile(t *testing.T) {
+	db1 := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10},
+		pairFreqs:   map[string]int{"big": 5},
+	})
+	db2 := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  500,
+		singleFreqs: map[string]int{"dog": 10, "small": 8},
+		pairFreqs:   map[string]int{"small": 4},
+	})
+	fed, err := FederatedFromDatabases(db1, db2)
+	assert.NoError(t, err)
+
+	prof, err := fed.GetLemmaProfile("dog")
+	assert.NoError(t, err)
+	assert.True(t, prof.Exists)
+	assert.Equal(t, 30, prof.Freq)
+	assert.Equal(t, map[string]int{"NOUN": 30}, prof.PoSFreqs)
+	assert.Equal(t, map[string]int{"fiction": 30}, prof.TextTypeFreqs)
+	var headFreq int
+	for _, freq := range prof.HeadDeprelFreqs {
+		headFreq += freq
+	}
+	assert.Equal(t, 9, headFreq)
+	assert.Empty(t, prof.DependentDeprelFreqs)
+}
+
+func TestNewFederatedCalculatorNoComponents(t *testing.T) {
+	_, err := NewFederatedCalculator()
+	assert.ErrorIs(t, err, ErrNoFederatedComponents)
+}
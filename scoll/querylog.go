@@ -0,0 +1,222 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/natefinch/lumberjack"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	queryLogMaxSizeMB  = 100
+	queryLogMaxBackups = 10
+)
+
+// QueryLogRecord is a single entry of the query (usage analytics) log.
+// The searched lemma is never stored in its readable form. It is
+// represented by its HMAC keyed with a secret of the log and in case
+// no secret is configured, it is omitted completely. For the same
+// reason (error messages often contain the lemma), only names
+// of errors are stored (see queryLogErrors).
+type QueryLogRecord struct {
+	Time             time.Time              `json:"time"`
+	LemmaHash        string                 `json:"lemmaHash,omitempty"`
+	PoS              string                 `json:"pos,omitempty"`
+	TextType         string                 `json:"textType,omitempty"`
+	TextTypeDims     []string               `json:"textTypeDims,omitempty"`
+	PrefixSearch     bool                   `json:"prefixSearch,omitempty"`
+	MergedVariants   bool                   `json:"mergedVariants,omitempty"`
+	LemmaPattern     string                 `json:"lemmaPattern,omitempty"`
+	IgnoreDiacritics bool                   `json:"ignoreDiacritics,omitempty"`
+	IgnoreCase       bool                   `json:"ignoreCase,omitempty"`
+	SearchByWordForm bool                   `json:"wordForm,omitempty"`
+	GroupByFeats     bool                   `json:"groupByFeats,omitempty"`
+	Deprels          []string               `json:"deprels,omitempty"`
+	PathCollocations bool                   `json:"pathCollocations,omitempty"`
+	Limit            int                    `json:"limit"`
+	Offset           int                    `json:"offset,omitempty"`
+	SecondOrderLimit int                    `json:"secondOrderLimit,omitempty"`
+	SortBy           storage.SortingMeasure `json:"sortBy"`
+	Relation         string                 `json:"relation,omitempty"`
+	CorpusSize       int64                  `json:"corpusSize,omitempty"`
+	LatencyMs        float64                `json:"latencyMs"`
+	NumResults       int                    `json:"numResults"`
+	Error            string                 `json:"error,omitempty"`
+}
+
+// queryLogErrors maps known errors to their names stored
+// in the query log. Other errors are logged as "other".
+var queryLogErrors = []struct {
+	err  error
+	name string
+}{
+	{ErrRestrictedTextType, "restrictedTextType"},
+	{ErrBatchSecondOrder, "batchSecondOrder"},
+	{ErrInvalidComponentWeights, "invalidComponentWeights"},
+	{ErrFederatedWordFormSearch, "federatedWordFormSearch"},
+	{ErrFederatedFeatsGrouping, "federatedFeatsGrouping"},
+	{storage.ErrInvalidCorpusSize, "invalidCorpusSize"},
+	{storage.ErrInvalidOffset, "invalidOffset"},
+	{storage.ErrInvalidResultField, "invalidResultField"},
+	{storage.ErrUnknownFilterValue, "unknownFilterValue"},
+	{storage.ErrInvalidLemmaPattern, "invalidLemmaPattern"},
+	{storage.ErrInvalidRelationDef, "invalidRelationDef"},
+	{storage.ErrUnsupportedWordFormSearch, "unsupportedWordFormSearch"},
+	{storage.ErrFeatureUnavailable, "featureUnavailable"},
+	{storage.ErrScanQueueTimeout, "scanQueueTimeout"},
+	{context.DeadlineExceeded, "deadlineExceeded"},
+	{context.Canceled, "canceled"},
+}
+
+func queryLogErrorName(err error) string {
+	for _, v := range queryLogErrors {
+		if errors.Is(err, v.err) {
+			return v.name
+		}
+	}
+	return "other"
+}
+
+func newQueryLogRecord(
+	lemmaHash string,
+	opts CalculationOptions,
+	t0 time.Time,
+	numResults int,
+	err error,
+) QueryLogRecord {
+	ans := QueryLogRecord{
+		Time:             t0,
+		LemmaHash:        lemmaHash,
+		PoS:              opts.PoS,
+		TextType:         opts.TextType,
+		TextTypeDims:     opts.TextTypeDims,
+		PrefixSearch:     opts.PrefixSearch,
+		MergedVariants:   opts.PrefixSearch && opts.MergePrefixVariants,
+		LemmaPattern:     string(opts.LemmaPattern),
+		IgnoreDiacritics: opts.IgnoreDiacritics,
+		IgnoreCase:       opts.IgnoreCase,
+		SearchByWordForm: opts.SearchByWordForm,
+		GroupByFeats:     opts.GroupByFeats,
+		Deprels:          opts.Deprels,
+		PathCollocations: opts.PathCollocations,
+		Limit:            opts.Limit,
+		Offset:           opts.Offset,
+		SecondOrderLimit: opts.SecondOrderLimit,
+		SortBy:           opts.SortBy,
+		Relation:         opts.Relation,
+		CorpusSize:       opts.CorpusSize,
+		LatencyMs:        float64(time.Since(t0).Microseconds()) / 1000,
+		NumResults:       numResults,
+	}
+	if err != nil {
+		ans.Error = queryLogErrorName(err)
+	}
+	return ans
+}
+
+// QueryLog writes query log records as JSONL either to stdout
+// or to a size-rotated file.
+// It is possible to call its methods on nil instance in which
+// case they are NOP.
+type QueryLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	secret []byte
+}
+
+// lemmaHash returns a hex-encoded HMAC-SHA256 of the lemma keyed
+// with the secret of the log. Without a secret, an empty string
+// is returned as a plain hash of a lemma can be easily reversed
+// using a word list.
+func (ql *QueryLog) lemmaHash(lemma string) string {
+	if len(ql.secret) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, ql.secret)
+	mac.Write([]byte(lemma))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LogQuery writes a record of a search for lemma
+func (ql *QueryLog) LogQuery(
+	lemma string,
+	opts CalculationOptions,
+	t0 time.Time,
+	numResults int,
+	err error,
+) {
+	if ql == nil {
+		return
+	}
+	ql.Log(newQueryLogRecord(ql.lemmaHash(lemma), opts, t0, numResults, err))
+}
+
+func (ql *QueryLog) Log(rec QueryLogRecord) {
+	if ql == nil {
+		return
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode query log record")
+		return
+	}
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	if _, err := ql.w.Write(append(data, '\n')); err != nil {
+		log.Error().Err(err).Msg("failed to write query log record")
+	}
+}
+
+func (ql *QueryLog) Close() error {
+	if ql == nil {
+		return nil
+	}
+	if c, ok := ql.w.(io.Closer); ok && ql.w != os.Stdout {
+		return c.Close()
+	}
+	return nil
+}
+
+// NewQueryLog creates a new query log. For path "-", stdout is used.
+// Otherwise, a file is created and rotated once it reaches a size limit.
+// The secret is used to key hashes of searched lemmas. If empty, lemmas
+// are not logged at all.
+func NewQueryLog(path, secret string) *QueryLog {
+	if path == "-" {
+		return &QueryLog{w: os.Stdout, secret: []byte(secret)}
+	}
+	return &QueryLog{
+		w: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    queryLogMaxSizeMB,
+			MaxBackups: queryLogMaxBackups,
+		},
+		secret: []byte(secret),
+	}
+}
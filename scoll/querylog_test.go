@@ -0,0 +1,65 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryLogAnonymization(t *testing.T) {
+	var buff bytes.Buffer
+	ql := &QueryLog{w: &buff, secret: []byte("s3cret")}
+	lemmaErr := fmt.Errorf("failed to find lemma secretlemma: %w", storage.ErrInvalidLemmaPattern)
+	ql.LogQuery("secretlemma", CalculationOptions{Limit: 10}, time.Now(), 0, lemmaErr)
+	ql.LogQuery("secretlemma", CalculationOptions{Limit: 10}, time.Now(), 0, fmt.Errorf("secretlemma"))
+	ql.LogQuery("otherlemma", CalculationOptions{Limit: 10}, time.Now(), 3, nil)
+	assert.NotContains(t, buff.String(), "secretlemma")
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	assert.Len(t, lines, 3)
+	recs := make([]QueryLogRecord, len(lines))
+	for i, line := range lines {
+		assert.NoError(t, json.Unmarshal([]byte(line), &recs[i]))
+	}
+	assert.Equal(t, "invalidLemmaPattern", recs[0].Error)
+	assert.Equal(t, "other", recs[1].Error)
+	assert.Empty(t, recs[2].Error)
+	assert.Len(t, recs[0].LemmaHash, 64)
+	assert.Equal(t, recs[0].LemmaHash, recs[1].LemmaHash)
+	assert.NotEqual(t, recs[0].LemmaHash, recs[2].LemmaHash)
+
+	// a different secret produces different hashes
+	ql2 := &QueryLog{w: &bytes.Buffer{}, secret: []byte("other")}
+	assert.NotEqual(t, ql.lemmaHash("secretlemma"), ql2.lemmaHash("secretlemma"))
+
+	// without a secret, lemmas are not logged at all
+	buff.Reset()
+	ql.secret = nil
+	ql.LogQuery("secretlemma", CalculationOptions{Limit: 10}, time.Now(), 1, nil)
+	assert.NotContains(t, buff.String(), "lemmaHash")
+
+	// nil log is a NOP
+	var nilLog *QueryLog
+	nilLog.LogQuery("secretlemma", CalculationOptions{}, time.Now(), 0, nil)
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// names of the built-in relations (see DefaultRelations)
+const (
+
+	// ModifiersOf represents CQL chunk [p_lemma="team" & deprel="nmod" & upos="NOUN"]
+	ModifiersOf = "modifiers-of"
+
+	// NounsModifiedBy represents CQL chunk [lemma="team" & deprel="nmod" & p_upos="NOUN"]
+	NounsModifiedBy = "nouns-modified-by"
+
+	// VerbsSubject represents CQL chunk [lemma="team" & deprel="nsubj" & p_upos="VERB"]
+	VerbsSubject = "verbs-subject"
+
+	// VerbsObject represents CQL chunk [lemma="team" & deprel="obj|iobj" & p_upos="VERB"]
+	VerbsObject = "verbs-object"
+
+	// AdverbsOfVerb represents CQL chunk [p_lemma="run" & p_upos="VERB" & deprel="advmod" & upos="ADV"]
+	AdverbsOfVerb = "adverbs-of-verb"
+
+	// PrepositionalObjects represents CQL chunk
+	// [p_lemma="rely" & deprel="obl|obl:arg" & upos="NOUN|PROPN|PRON"]
+	// (in UD, prepositional objects are oblique nominals with a "case" dependent)
+	PrepositionalObjects = "prepositional-objects"
+
+	// CoordinatedWith represents CQL chunks [p_lemma="team" & deprel="conj"]
+	// and [lemma="team" & deprel="conj"] (i.e. both directions)
+	CoordinatedWith = "coordinated-with"
+)
+
+// DefaultRelations are relations available for all the databases.
+// A database can define additional relations (or redefine the default
+// ones) in its metadata (see storage.Metadata.Relations).
+var DefaultRelations = []storage.RelationDef{
+	{
+		Name:         ModifiersOf,
+		NodeSide:     storage.RelationNodeHead,
+		Deprels:      []string{"nmod"},
+		CollocatePoS: []string{"NOUN"},
+	},
+	{
+		Name:         NounsModifiedBy,
+		NodeSide:     storage.RelationNodeDependent,
+		Deprels:      []string{"nmod"},
+		CollocatePoS: []string{"NOUN"},
+	},
+	{
+		Name:         VerbsSubject,
+		NodeSide:     storage.RelationNodeDependent,
+		Deprels:      []string{"nsubj"},
+		CollocatePoS: []string{"VERB"},
+	},
+	{
+		Name:         VerbsObject,
+		NodeSide:     storage.RelationNodeDependent,
+		Deprels:      []string{"obj", "iobj"},
+		CollocatePoS: []string{"VERB"},
+	},
+	{
+		Name:         AdverbsOfVerb,
+		NodeSide:     storage.RelationNodeHead,
+		Deprels:      []string{"advmod"},
+		NodePoS:      []string{"VERB"},
+		CollocatePoS: []string{"ADV"},
+	},
+	{
+		Name:         PrepositionalObjects,
+		NodeSide:     storage.RelationNodeHead,
+		Deprels:      []string{"obl", "obl:arg"},
+		CollocatePoS: []string{"NOUN", "PROPN", "PRON"},
+	},
+	{
+		Name:     CoordinatedWith,
+		NodeSide: storage.RelationNodeAny,
+		Deprels:  []string{"conj"},
+	},
+}
+
+// Relations returns all the relations available for searches
+// (see WithRelation), i.e. DefaultRelations along with relations
+// defined by the database (which take precedence in case of equal names).
+func (calc *Calculator) Relations() []storage.RelationDef {
+	custom := calc.database.DatasetMetadata().Relations
+	ans := make([]storage.RelationDef, 0, len(DefaultRelations)+len(custom))
+	for _, def := range DefaultRelations {
+		if !slices.ContainsFunc(custom, func(v storage.RelationDef) bool { return v.Name == def.Name }) {
+			ans = append(ans, def)
+		}
+	}
+	return append(ans, custom...)
+}
+
+// relationFilter creates a search filter of the named relation
+// along with the node side (in the form of CalculationArgs.IsHead)
+// the relation requires. An empty name produces a nil filter.
+func (calc *Calculator) relationFilter(name string) (storage.SearchFilter, *bool, error) {
+	if name == "" {
+		return nil, nil, nil
+	}
+	relations := calc.Relations()
+	idx := slices.IndexFunc(relations, func(v storage.RelationDef) bool { return v.Name == name })
+	if idx < 0 {
+		return nil, nil, fmt.Errorf("%w: relation %s", storage.ErrUnknownFilterValue, name)
+	}
+	mapping := calc.database.Deprels()
+	if mapping == nil {
+		mapping = &record.UDDeprelMapping
+	}
+	filter, err := relations[idx].Filter(mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filter, relations[idx].IsHead(), nil
+}
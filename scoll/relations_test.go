@@ -0,0 +1,152 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type filterCase struct {
+	pos1     byte
+	deprel   uint16
+	pos2     byte
+	isHead   bool
+	expected bool
+}
+
+func testRelation(t *testing.T, srch string, cases []filterCase) {
+	calc := FromDatabase(openFederatedTestDB(t, federatedTestData{corpusSize: 1000}))
+	filter, _, err := calc.relationFilter(srch)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+	for i, c := range cases {
+		assert.Equal(
+			t, c.expected, filter(c.pos1, c.deprel, c.pos2, 0x01, c.isHead, 1),
+			"%s, case %d", srch, i,
+		)
+	}
+}
+
+func TestRelationModifiersOf(t *testing.T) {
+	testRelation(t, ModifiersOf, []filterCase{
+		{record.PosNOUN, record.DeprelNmod, record.PosNOUN, true, true},
+		{record.PosNOUN, record.DeprelNmod, record.PosNOUN, false, false},
+		{record.PosNOUN, record.DeprelAmod, record.PosADJ, true, false},
+	})
+}
+
+func TestRelationNounsModifiedBy(t *testing.T) {
+	testRelation(t, NounsModifiedBy, []filterCase{
+		{record.PosNOUN, record.DeprelNmod, record.PosNOUN, false, true},
+		{record.PosNOUN, record.DeprelNmod, record.PosNOUN, true, false},
+		{record.PosNOUN, record.DeprelNmod, record.PosVERB, false, false},
+	})
+}
+
+func TestRelationVerbsSubject(t *testing.T) {
+	testRelation(t, VerbsSubject, []filterCase{
+		{record.PosNOUN, record.DeprelNsubj, record.PosVERB, false, true},
+		{record.PosNOUN, record.DeprelObj, record.PosVERB, false, false},
+		{record.PosNOUN, record.DeprelNsubj, record.PosVERB, true, false},
+	})
+}
+
+func TestRelationVerbsObject(t *testing.T) {
+	testRelation(t, VerbsObject, []filterCase{
+		{record.PosNOUN, record.DeprelObj, record.PosVERB, false, true},
+		{record.PosNOUN, record.DeprelIobj, record.PosVERB, false, true},
+		{record.PosNOUN, record.DeprelNsubj, record.PosVERB, false, false},
+	})
+}
+
+func TestRelationAdverbsOfVerb(t *testing.T) {
+	testRelation(t, AdverbsOfVerb, []filterCase{
+		{record.PosVERB, record.DeprelAdvmod, record.PosADV, true, true},
+		{record.PosADJ, record.DeprelAdvmod, record.PosADV, true, false},
+		{record.PosVERB, record.DeprelAdvmod, record.PosADV, false, false},
+	})
+}
+
+func TestRelationPrepositionalObjects(t *testing.T) {
+	testRelation(t, PrepositionalObjects, []filterCase{
+		{record.PosVERB, record.DeprelObl, record.PosNOUN, true, true},
+		{record.PosVERB, record.DeprelOblArg, record.PosPRON, true, true},
+		{record.PosVERB, record.DeprelObl, record.PosADV, true, false},
+		{record.PosVERB, record.DeprelObj, record.PosNOUN, true, false},
+	})
+}
+
+func TestRelationCoordinatedWith(t *testing.T) {
+	testRelation(t, CoordinatedWith, []filterCase{
+		{record.PosNOUN, record.DeprelConj, record.PosNOUN, true, true},
+		{record.PosNOUN, record.DeprelConj, record.PosNOUN, false, true},
+		{record.PosNOUN, record.DeprelCc, record.PosCCONJ, true, false},
+	})
+}
+
+func TestRelationDirection(t *testing.T) {
+	calc := FromDatabase(openFederatedTestDB(t, federatedTestData{corpusSize: 1000}))
+	_, isHead, err := calc.relationFilter(VerbsObject)
+	assert.NoError(t, err)
+	assert.False(t, *isHead)
+	_, isHead, err = calc.relationFilter(AdverbsOfVerb)
+	assert.NoError(t, err)
+	assert.True(t, *isHead)
+	_, isHead, err = calc.relationFilter(CoordinatedWith)
+	assert.NoError(t, err)
+	assert.Nil(t, isHead)
+}
+
+func TestDefaultRelationsAreValid(t *testing.T) {
+	assert.NoError(t, storage.ValidateRelationDefs(DefaultRelations, &record.UDDeprelMapping))
+	calc := FromDatabase(openFederatedTestDB(t, federatedTestData{corpusSize: 1000}))
+	_, _, err := calc.relationFilter("foo")
+	assert.ErrorIs(t, err, storage.ErrUnknownFilterValue)
+}
+
+func TestCustomRelations(t *testing.T) {
+	db := openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10, "small": 8},
+		pairFreqs:   map[string]int{"big": 5, "small": 4},
+	})
+	db.Metadata.Relations = []storage.RelationDef{
+		{Name: "adjectives-of", NodeSide: storage.RelationNodeHead, CollocatePoS: []string{"ADJ"}},
+		{Name: CoordinatedWith, NodeSide: storage.RelationNodeHead, Deprels: []string{"conj"}},
+	}
+	calc := FromDatabase(db)
+	relations := calc.Relations()
+	assert.Len(t, relations, len(DefaultRelations)+1)
+	assert.Equal(t, db.Metadata.Relations, relations[len(relations)-2:])
+
+	ans, err := calc.GetCollocations(
+		context.Background(), "dog", WithRelation("adjectives-of"), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	ans, err = calc.GetCollocations(
+		context.Background(), "dog", WithRelation(VerbsObject), WithoutQueryLog())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 0)
+	_, err = calc.GetCollocations(
+		context.Background(), "dog", WithRelation("foo"), WithoutQueryLog())
+	assert.ErrorIs(t, err, storage.ErrUnknownFilterValue)
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// StreamCollocations searches for collocations the same way GetCollocations
+// does but instead of returning the whole result, it returns an iterator
+// yielding the result items one by one. As the candidates must be ranked
+// first, the first item is available once the (first level) search is
+// finished. But all the per-item work (second order collocations, label
+// descriptions, CQL queries) is done only when the consumer asks for
+// the next item, i.e. a slow consumer (e.g. a server writing to a slow
+// client) naturally slows down the calculation and breaking the loop
+// stops it.
+//
+// The search is performed each time the iteration starts. In case of
+// an error (including a cancelled context), the error is yielded as
+// the last value. The latency recorded in the query log covers only
+// the first level search.
+func (calc *Calculator) StreamCollocations(
+	ctx context.Context,
+	lemma string,
+	options ...func(opts *CalculationOptions),
+) iter.Seq2[storage.Collocation, error] {
+	return func(yield func(storage.Collocation, error) bool) {
+		opts, err := calc.prepareOptions(lemma, options...)
+		if err != nil {
+			yield(storage.Collocation{}, err)
+			return
+		}
+		t0 := time.Now()
+		args, err := calc.calculationArgs(lemma, opts)
+		var items []storage.Collocation
+		if err == nil {
+			items, err = calc.database.CalculateMeasures(ctx, args)
+		}
+		if !opts.NoQueryLog {
+			calc.queryLog.Log(newQueryLogRecord(lemma, opts, t0, len(items), err))
+		}
+		if err != nil {
+			yield(storage.Collocation{}, err)
+			return
+		}
+		for _, item := range items {
+			if err := ctx.Err(); err != nil {
+				yield(storage.Collocation{}, err)
+				return
+			}
+			if opts.SecondOrderLimit > 0 {
+				item.SecondOrder, err = calc.database.SecondOrderCollocations(
+					ctx, args, item, opts.SecondOrderLimit)
+				if err != nil {
+					yield(storage.Collocation{}, err)
+					return
+				}
+			}
+			chunk := []storage.Collocation{item}
+			calc.decorateResults(chunk, opts)
+			if !yield(chunk[0], nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamCollocations(t *testing.T) {
+	calc := FromDatabase(openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10, "small": 8, "old": 30},
+		pairFreqs:   map[string]int{"big": 5, "small": 4, "old": 3},
+	}))
+	opts := []func(opts *CalculationOptions){WithSortBy("ldice"), WithSecondOrder(2), WithoutQueryLog()}
+	expected, err := calc.GetCollocations(context.Background(), "dog", opts...)
+	assert.NoError(t, err)
+	assert.Len(t, expected, 3)
+
+	var streamed []storage.Collocation
+	for item, err := range calc.StreamCollocations(context.Background(), "dog", opts...) {
+		assert.NoError(t, err)
+		streamed = append(streamed, item)
+	}
+	assert.Equal(t, expected, streamed)
+
+	streamed = streamed[:0]
+	for item, err := range calc.StreamCollocations(context.Background(), "dog", opts...) {
+		assert.NoError(t, err)
+		streamed = append(streamed, item)
+		if len(streamed) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, expected[:2], streamed)
+}
+
+func TestStreamCollocationsErrors(t *testing.T) {
+	calc := FromDatabase(openFederatedTestDB(t, federatedTestData{
+		corpusSize:  1000,
+		singleFreqs: map[string]int{"dog": 20, "big": 10, "small": 8},
+		pairFreqs:   map[string]int{"big": 5, "small": 4},
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var numItems int
+	var lastErr error
+	for _, err := range calc.StreamCollocations(ctx, "dog", WithSortBy("ldice"), WithoutQueryLog()) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		numItems++
+		cancel()
+	}
+	assert.Equal(t, 1, numItems)
+	assert.ErrorIs(t, lastErr, context.Canceled)
+
+	// the context is already cancelled
+	numItems = 0
+	lastErr = nil
+	for _, err := range calc.StreamCollocations(ctx, "dog", WithSortBy("ldice"), WithoutQueryLog()) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		numItems++
+	}
+	assert.Zero(t, numItems)
+	assert.ErrorIs(t, lastErr, context.Canceled)
+}
@@ -0,0 +1,391 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+)
+
+// URL query parameters used by the REST API to pass calculation options.
+const (
+	ParamPoS                      = "pos"
+	ParamTextType                 = "textType"
+	ParamTextTypeDim              = "textTypeDim"
+	ParamLimit                    = "limit"
+	ParamOffset                   = "offset"
+	ParamSortBy                   = "sortBy"
+	ParamPrefixSearch             = "prefixSearch"
+	ParamMergePrefixVariants      = "mergePrefixVariants"
+	ParamIgnoreDiacritics         = "ignoreDiacritics"
+	ParamIgnoreCase               = "ignoreCase"
+	ParamSearchByWordForm         = "wordForm"
+	ParamGroupByFeats             = "groupByFeats"
+	ParamLimitPerVariant          = "limitPerVariant"
+	ParamCollocateGroupByPos      = "collocateGroupByPos"
+	ParamGroupByDeprel            = "groupByDeprel"
+	ParamCollocateGroupByTextType = "collocateGroupByTextType"
+	ParamMaxAvgCollocateDist      = "maxAvgCollocateDist"
+	ParamMaxAvgSurfaceDist        = "maxAvgSurfaceDist"
+	ParamRelationDistSpread       = "relationDistSpread"
+	ParamMaxScannedPairs          = "maxScannedPairs"
+	ParamMinCollFreq              = "minCollFreq"
+	ParamAdaptiveLimits           = "adaptiveLimits"
+	ParamCollocateOrder           = "collocateOrder"
+	ParamLemmaAsHead              = "lemmaAsHead"
+	ParamRelation                 = "relation"
+	ParamExcludedDeprel           = "excludedDeprel"
+	ParamDeprel                   = "deprel"
+	ParamPathCollocations         = "pathCollocations"
+	ParamLemmaSet                 = "lemmaSet"
+	ParamLemmaPattern             = "lemmaPattern"
+	ParamCorpusSize               = "corpusSize"
+	ParamNoQueryLog               = "noQueryLog"
+	ParamSignedDistance           = "signedDistance"
+	ParamField                    = "field"
+	ParamLabelLang                = "labelLang"
+	ParamDeprelGranularity        = "deprelGranularity"
+	ParamCQL                      = "cql"
+	ParamSecondOrderLimit         = "secondOrderLimit"
+)
+
+// Max. values of the size related parameters accepted by OptionsFromURLValues
+// so a single request of a server API cannot trigger an excessive amount of work.
+const (
+	MaxURLLimit            = 1000
+	MaxURLSecondOrderLimit = 50
+
+	// MaxURLSecondOrderParents is a max. limit of a search with second
+	// order collocates (each found collocate requires an additional search)
+	MaxURLSecondOrderParents = 50
+)
+
+// paramLegacyPredefinedSearch is a former name of ParamRelation
+// still accepted by OptionsFromURLValues
+const paramLegacyPredefinedSearch = "predefinedSearch"
+
+func setBoolParam(values url.Values, name string, v bool) {
+	if v {
+		values.Set(name, "1")
+	}
+}
+
+// AsURLValues encodes the options as URL query parameters.
+// Please note that RestrictedTextTypesAccess is never encoded
+// as it must be derived from client authorization by a server.
+// FilterStats, VariantSummary and the category profile options are
+// local-only so they are not encoded either. The same applies to
+// TotalCount which a server returns via a response header.
+func (opts CalculationOptions) AsURLValues() url.Values {
+	ans := make(url.Values)
+	if opts.PoS != "" {
+		ans.Set(ParamPoS, opts.PoS)
+	}
+	if opts.TextType != "" {
+		ans.Set(ParamTextType, opts.TextType)
+	}
+	if opts.Limit > 0 {
+		ans.Set(ParamLimit, strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		ans.Set(ParamOffset, strconv.Itoa(opts.Offset))
+	}
+	if opts.SecondOrderLimit > 0 {
+		ans.Set(ParamSecondOrderLimit, strconv.Itoa(opts.SecondOrderLimit))
+	}
+	if opts.SortBy != "" {
+		ans.Set(ParamSortBy, string(opts.SortBy))
+	}
+	setBoolParam(ans, ParamPrefixSearch, opts.PrefixSearch)
+	setBoolParam(ans, ParamMergePrefixVariants, opts.MergePrefixVariants)
+	setBoolParam(ans, ParamIgnoreDiacritics, opts.IgnoreDiacritics)
+	setBoolParam(ans, ParamIgnoreCase, opts.IgnoreCase)
+	setBoolParam(ans, ParamSearchByWordForm, opts.SearchByWordForm)
+	setBoolParam(ans, ParamGroupByFeats, opts.GroupByFeats)
+	setBoolParam(ans, ParamLimitPerVariant, opts.LimitPerVariant)
+	setBoolParam(ans, ParamCollocateGroupByPos, opts.CollocateGroupByPos)
+	setBoolParam(ans, ParamGroupByDeprel, opts.GroupByDeprel)
+	setBoolParam(ans, ParamCollocateGroupByTextType, opts.CollocateGroupByTextType)
+	setBoolParam(ans, ParamPathCollocations, opts.PathCollocations)
+	if opts.MaxAvgCollocateDist > 0 {
+		ans.Set(ParamMaxAvgCollocateDist, strconv.FormatFloat(opts.MaxAvgCollocateDist, 'f', -1, 64))
+	}
+	if opts.MaxAvgSurfaceDist > 0 {
+		ans.Set(ParamMaxAvgSurfaceDist, strconv.FormatFloat(opts.MaxAvgSurfaceDist, 'f', -1, 64))
+	}
+	if opts.RelationDistSpread > 0 {
+		ans.Set(ParamRelationDistSpread, strconv.FormatFloat(opts.RelationDistSpread, 'f', -1, 64))
+	}
+	if opts.MaxScannedPairs > 0 {
+		ans.Set(ParamMaxScannedPairs, strconv.Itoa(opts.MaxScannedPairs))
+	}
+	if opts.MinCollFreq > 0 {
+		ans.Set(ParamMinCollFreq, strconv.Itoa(opts.MinCollFreq))
+	}
+	setBoolParam(ans, ParamAdaptiveLimits, opts.AdaptiveLimits)
+	if opts.CollocateOrder != "" {
+		ans.Set(ParamCollocateOrder, string(opts.CollocateOrder))
+	}
+	if opts.LemmasAsHead != nil {
+		ans.Set(ParamLemmaAsHead, strconv.FormatBool(*opts.LemmasAsHead))
+	}
+	if opts.Relation != "" {
+		ans.Set(ParamRelation, opts.Relation)
+	}
+	for _, v := range opts.ExcludedDeprels {
+		ans.Add(ParamExcludedDeprel, v)
+	}
+	for _, v := range opts.Deprels {
+		ans.Add(ParamDeprel, v)
+	}
+	for _, v := range opts.LemmaSet {
+		ans.Add(ParamLemmaSet, v)
+	}
+	for _, v := range opts.TextTypeDims {
+		ans.Add(ParamTextTypeDim, v)
+	}
+	if opts.LemmaPattern != "" {
+		ans.Set(ParamLemmaPattern, string(opts.LemmaPattern))
+	}
+	if opts.CorpusSize > 0 {
+		ans.Set(ParamCorpusSize, strconv.FormatInt(opts.CorpusSize, 10))
+	}
+	setBoolParam(ans, ParamNoQueryLog, opts.NoQueryLog)
+	setBoolParam(ans, ParamSignedDistance, opts.SignedDistance)
+	setBoolParam(ans, ParamCQL, opts.GenerateCQL)
+	for _, v := range opts.Fields {
+		ans.Add(ParamField, string(v))
+	}
+	if opts.LabelLang != "" {
+		ans.Set(ParamLabelLang, string(opts.LabelLang))
+	}
+	if opts.DeprelGranularity != "" {
+		ans.Set(ParamDeprelGranularity, string(opts.DeprelGranularity))
+	}
+	return ans
+}
+
+func parseBoolParam(values url.Values, name string) (bool, error) {
+	v := values.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	ans, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value of %s: %w", name, err)
+	}
+	return ans, nil
+}
+
+// OptionsFromURLValues is a reverse function to CalculationOptions.AsURLValues.
+// It produces a list of option functions applicable to Calculator methods.
+func OptionsFromURLValues(values url.Values) ([]func(opts *CalculationOptions), error) {
+	ans := make([]func(opts *CalculationOptions), 0, len(values)+2)
+	ans = append(
+		ans,
+		WithPoS(values.Get(ParamPoS)),
+		WithTextType(values.Get(ParamTextType)),
+	)
+	var limit int
+	if v := values.Get(ParamLimit); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamLimit, v)
+		}
+		if limit > MaxURLLimit {
+			return ans, fmt.Errorf("invalid value of %s: %s (max. %d)", ParamLimit, v, MaxURLLimit)
+		}
+		ans = append(ans, WithLimit(limit))
+	}
+	if v := values.Get(ParamOffset); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamOffset, v)
+		}
+		ans = append(ans, WithOffset(offset))
+	}
+	if v := values.Get(ParamSecondOrderLimit); v != "" {
+		soLimit, err := strconv.Atoi(v)
+		if err != nil || soLimit < 0 {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamSecondOrderLimit, v)
+		}
+		if soLimit > MaxURLSecondOrderLimit {
+			return ans, fmt.Errorf(
+				"invalid value of %s: %s (max. %d)", ParamSecondOrderLimit, v, MaxURLSecondOrderLimit)
+		}
+		if soLimit > 0 && limit > MaxURLSecondOrderParents {
+			return ans, fmt.Errorf(
+				"invalid value of %s: %d (max. %d with %s)",
+				ParamLimit, limit, MaxURLSecondOrderParents, ParamSecondOrderLimit,
+			)
+		}
+		ans = append(ans, WithSecondOrder(soLimit))
+	}
+	if v := values.Get(ParamSortBy); v != "" {
+		sortBy := storage.SortingMeasure(v)
+		if !sortBy.Validate() {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamSortBy, v)
+		}
+		ans = append(ans, WithSortBy(sortBy))
+	}
+	for name, opt := range map[string]func(opts *CalculationOptions){
+		ParamPrefixSearch:             WithPrefixSearch(),
+		ParamMergePrefixVariants:      WithMergedPrefixVariants(),
+		ParamIgnoreDiacritics:         WithIgnoredDiacritics(),
+		ParamIgnoreCase:               WithCaseInsensitive(),
+		ParamSearchByWordForm:         WithSearchByWordForm(),
+		ParamGroupByFeats:             WithGroupByFeats(),
+		ParamLimitPerVariant:          WithLimitPerVariant(),
+		ParamCollocateGroupByPos:      WithCollocateGroupByPos(),
+		ParamGroupByDeprel:            WithGroupByDeprel(),
+		ParamCollocateGroupByTextType: WithCollocateGroupByTextType(),
+		ParamPathCollocations:         WithPathCollocations(),
+		ParamNoQueryLog:               WithoutQueryLog(),
+		ParamSignedDistance:           WithSignedDistance(),
+		ParamCQL:                      WithCQL(),
+		ParamAdaptiveLimits:           WithAdaptiveLimits(),
+	} {
+		isSet, err := parseBoolParam(values, name)
+		if err != nil {
+			return ans, err
+		}
+		if isSet {
+			ans = append(ans, opt)
+		}
+	}
+	if v := values.Get(ParamMaxAvgCollocateDist); v != "" {
+		dist, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return ans, fmt.Errorf("invalid value of %s: %w", ParamMaxAvgCollocateDist, err)
+		}
+		ans = append(ans, WithMaxAvgCollocateDist(dist))
+	}
+	if v := values.Get(ParamMaxAvgSurfaceDist); v != "" {
+		dist, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return ans, fmt.Errorf("invalid value of %s: %w", ParamMaxAvgSurfaceDist, err)
+		}
+		ans = append(ans, WithMaxAvgSurfaceDist(dist))
+	}
+	if v := values.Get(ParamMaxScannedPairs); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamMaxScannedPairs, v)
+		}
+		ans = append(ans, WithMaxScannedPairs(n))
+	}
+	if v := values.Get(ParamMinCollFreq); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamMinCollFreq, v)
+		}
+		ans = append(ans, WithMinCollFreq(n))
+	}
+	if v := values.Get(ParamRelationDistSpread); v != "" {
+		spread, err := strconv.ParseFloat(v, 64)
+		if err != nil || spread < 0 {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamRelationDistSpread, v)
+		}
+		ans = append(ans, WithRelationDistSpread(spread))
+	}
+	if v := values.Get(ParamCollocateOrder); v != "" {
+		order := storage.CollocateOrder(v)
+		if !order.Validate() {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamCollocateOrder, v)
+		}
+		ans = append(ans, WithCollocateOrder(order))
+	}
+	if v := values.Get(ParamLemmaAsHead); v != "" {
+		isHead, err := parseBoolParam(values, ParamLemmaAsHead)
+		if err != nil {
+			return ans, err
+		}
+		if isHead {
+			ans = append(ans, WithLemmaAsHead())
+
+		} else {
+			ans = append(ans, WithLemmaAsDependent())
+		}
+	}
+	// relation sets more options so it must be applied
+	// before anything it could override (the relation itself
+	// is validated once the search is run as relations depend
+	// on the database)
+	relation := values.Get(ParamRelation)
+	if relation == "" {
+		relation = values.Get(paramLegacyPredefinedSearch)
+	}
+	if relation != "" {
+		ans = append([]func(opts *CalculationOptions){WithRelation(relation)}, ans...)
+	}
+	if vals, ok := values[ParamExcludedDeprel]; ok {
+		ans = append(ans, WithExcludedDeprels(vals...))
+	}
+	if vals, ok := values[ParamDeprel]; ok {
+		ans = append(ans, WithDeprels(vals))
+	}
+	if vals, ok := values[ParamLemmaSet]; ok {
+		ans = append(ans, WithLemmaSet(vals...))
+	}
+	if vals, ok := values[ParamTextTypeDim]; ok {
+		ans = append(ans, WithTextTypeDims(vals...))
+	}
+	if v := values.Get(ParamLemmaPattern); v != "" {
+		syntax := storage.LemmaPatternSyntax(v)
+		if !syntax.Validate() {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamLemmaPattern, v)
+		}
+		ans = append(ans, WithLemmaPattern(syntax))
+	}
+	if v := values.Get(ParamCorpusSize); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size <= 0 {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamCorpusSize, v)
+		}
+		ans = append(ans, WithCorpusSize(size))
+	}
+	if vals, ok := values[ParamField]; ok {
+		fields := make([]storage.ResultField, len(vals))
+		for i, v := range vals {
+			fields[i] = storage.ResultField(v)
+			if !fields[i].Validate() {
+				return ans, fmt.Errorf("invalid value of %s: %s", ParamField, v)
+			}
+		}
+		ans = append(ans, WithFields(fields...))
+	}
+	if v := values.Get(ParamLabelLang); v != "" {
+		lang := record.LabelLang(v)
+		if !lang.Validate() {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamLabelLang, v)
+		}
+		ans = append(ans, WithLabelLang(lang))
+	}
+	if v := values.Get(ParamDeprelGranularity); v != "" {
+		gran := storage.DeprelGranularity(v)
+		if !gran.Validate() {
+			return ans, fmt.Errorf("invalid value of %s: %s", ParamDeprelGranularity, v)
+		}
+		ans = append(ans, WithDeprelGranularity(gran))
+	}
+	return ans, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoll
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLValuesRoundTrip(t *testing.T) {
+	var orig CalculationOptions
+	for _, opt := range []func(opts *CalculationOptions){
+		WithPoS("NOUN"),
+		WithTextType("fiction"),
+		WithTextTypeDims("", "1990s"),
+		WithLimit(20),
+		WithOffset(40),
+		WithSecondOrder(5),
+		WithSortBy("ldice"),
+		WithPrefixSearch(),
+		WithMergedPrefixVariants(),
+		WithLimitPerVariant(),
+		WithIgnoredDiacritics(),
+		WithCaseInsensitive(),
+		WithSearchByWordForm(),
+		WithGroupByFeats(),
+		WithLemmaPattern(storage.LemmaPatternGlob),
+		WithLemmaAsDependent(),
+		WithRelation(VerbsObject),
+		WithExcludedDeprels("punct", "det"),
+		WithDeprels([]string{"amod", "nmod"}),
+		WithPathCollocations(),
+		WithCorpusSize(1000),
+		WithRelationDistSpread(1.5),
+		WithMaxScannedPairs(5000),
+		WithMinCollFreq(3),
+		WithAdaptiveLimits(),
+		WithFields(storage.FieldLogDice, storage.FieldTextType),
+		WithLabelLang(record.LabelLangCS),
+		WithDeprelGranularity(storage.DeprelGranularityCore),
+		WithCQL(),
+		WithRestrictedTextTypesAccess(),
+	} {
+		opt(&orig)
+	}
+	decodedOpts, err := OptionsFromURLValues(orig.AsURLValues())
+	assert.NoError(t, err)
+	var decoded CalculationOptions
+	for _, opt := range decodedOpts {
+		opt(&decoded)
+	}
+	orig.RestrictedTextTypesAccess = false // this must never be passed via URL
+	assert.Equal(t, orig, decoded)
+}
+
+func TestOptionsFromURLValuesLegacyPredefinedSearch(t *testing.T) {
+	decodedOpts, err := OptionsFromURLValues(map[string][]string{"predefinedSearch": {VerbsSubject}})
+	assert.NoError(t, err)
+	var decoded CalculationOptions
+	for _, opt := range decodedOpts {
+		opt(&decoded)
+	}
+	assert.Equal(t, VerbsSubject, decoded.Relation)
+	assert.True(t, decoded.GroupByDeprel)
+}
+
+func TestOptionsFromURLValuesInvalid(t *testing.T) {
+	_, err := OptionsFromURLValues(map[string][]string{ParamSortBy: {"foo"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamLimit: {"-1"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamOffset: {"-1"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamSecondOrderLimit: {"x"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamField: {"logDice", "foo"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamLabelLang: {"de"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamDeprelGranularity: {"fine"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamRelationDistSpread: {"-1"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamMaxScannedPairs: {"x"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamMinCollFreq: {"-1"}})
+	assert.Error(t, err)
+}
+
+func TestOptionsFromURLValuesLimitCaps(t *testing.T) {
+	_, err := OptionsFromURLValues(map[string][]string{ParamLimit: {"1000"}})
+	assert.NoError(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamLimit: {"1001"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamSecondOrderLimit: {"51"}})
+	assert.Error(t, err)
+
+	// with second order collocates, the main search must be small
+	_, err = OptionsFromURLValues(map[string][]string{ParamLimit: {"50"}, ParamSecondOrderLimit: {"50"}})
+	assert.NoError(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamLimit: {"51"}, ParamSecondOrderLimit: {"5"}})
+	assert.Error(t, err)
+	_, err = OptionsFromURLValues(map[string][]string{ParamLimit: {"500"}, ParamSecondOrderLimit: {"0"}})
+	assert.NoError(t, err)
+}
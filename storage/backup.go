@@ -0,0 +1,57 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"io"
+)
+
+// DefaultRestoreMaxPendingWrites is a default number of pending
+// writes during a restore (see DB.Restore)
+const DefaultRestoreMaxPendingWrites = 256
+
+// Backup writes all the records written after the version since to w
+// (using the Badger backup format). For a full backup, use zero.
+// The returned value is the since argument for the next incremental
+// backup (i.e. the incremental backup will contain only records written
+// after this one, including deletions).
+// The backup can be made from a database opened in the read-only mode.
+func (db *DB) Backup(w io.Writer, since uint64) (uint64, error) {
+	lastVersion, err := db.bdb.Backup(w, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to backup database: %w", err)
+	}
+	// note: Badger skips records with version equal to since
+	// so the last backed up version is the right value
+	return max(lastVersion, since), nil
+}
+
+// Restore loads records written by Backup to the database. To restore
+// an incremental backup, the database must already contain the restored
+// previous backups. No other operations may run on the database during
+// the restore. The database is expected to be opened via OpenDBIgnoreMetadata
+// so it should be reopened to use the restored metadata.
+func (db *DB) Restore(r io.Reader, maxPendingWrites int) error {
+	if maxPendingWrites <= 0 {
+		maxPendingWrites = DefaultRestoreMaxPendingWrites
+	}
+	if err := db.bdb.Load(r, maxPendingWrites); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	return nil
+}
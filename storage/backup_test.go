@@ -0,0 +1,77 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupRestore(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, db.StoreMetadata(Metadata{CorpusSize: 1000, SchemaVersion: SchemaVersion}))
+
+	var full bytes.Buffer
+	since, err := db.Backup(&full, 0)
+	assert.NoError(t, err)
+	assert.Positive(t, since)
+
+	assert.NoError(t, db.StoreMetadata(Metadata{CorpusSize: 2000, SchemaVersion: SchemaVersion}))
+	var incremental bytes.Buffer
+	nextSince, err := db.Backup(&incremental, since)
+	assert.NoError(t, err)
+	assert.Greater(t, nextSince, since)
+	assert.Less(t, incremental.Len(), full.Len())
+
+	restored := openTestDB(t)
+	restored.DeprelMapping = &record.UDDeprelMapping
+	assert.NoError(t, restored.Restore(&full, 0))
+	metadata, err := restored.StoredMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), metadata.CorpusSize)
+	restored.Metadata = metadata
+	ans, err := restored.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:  "monday",
+		Limit:  10,
+		SortBy: sortByLogDice,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "work", ans[0].Collocate.Value)
+
+	assert.NoError(t, restored.Restore(&incremental, 0))
+	metadata, err = restored.StoredMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2000), metadata.CorpusSize)
+}
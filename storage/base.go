@@ -19,6 +19,7 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/czcorpus/depreldb/record"
 	"github.com/dgraph-io/badger/v4"
@@ -30,10 +31,25 @@ import (
 // DB is a wrapper around badger.DB providing concrete
 // methods for adding/retrieving collocation information.
 type DB struct {
-	bdb           *badger.DB
-	textTypes     record.TextTypeMapper
-	Metadata      Metadata
-	DeprelMapping *record.DeprelMapping
+	bdb                 *badger.DB
+	textTypes           record.TextTypeMapper
+	queryDefaults       QueryDefaults
+	restrictedTextTypes []string
+	textTypesAttr       string
+	Metadata            Metadata
+	DeprelMapping       *record.DeprelMapping
+	scanGate            *scanGate
+	textTypeLabels      []TextTypeLabel
+	snapshot            pinnedSnapshot
+	lemmaCache          *lemmaCache
+	writeBatchSize      int
+	calcWorkers         int
+	metrics             *metrics
+	lenientDecoding     bool
+	pairExamplesLimit   int
+	numWritten          atomic.Int64
+	ttSizes             textTypeSizes
+	keys                record.KeyLayout
 }
 
 // Close closes the internal Badger database.
@@ -44,11 +60,57 @@ type DB struct {
 // it is a NOP.
 func (db *DB) Close() error {
 	if db != nil && db.bdb != nil {
+		db.UnpinSnapshot()
 		return db.bdb.Close()
 	}
 	return nil
 }
 
+// DatasetMetadata returns metadata of the database stored
+// during import (corpus size, numbers of records, ...).
+func (db *DB) DatasetMetadata() Metadata {
+	return db.Metadata
+}
+
+// Deprels returns a mapping between readable syntactic relations
+// and their numeric codes used by the database.
+func (db *DB) Deprels() *record.DeprelMapping {
+	return db.DeprelMapping
+}
+
+// QueryDefaults returns query parameters configured for the
+// database's import profile. For unknown profiles, zero value is returned.
+func (db *DB) QueryDefaults() QueryDefaults {
+	return db.queryDefaults
+}
+
+// RestrictedTextTypes returns text types configured as restricted
+// for the database's import profile.
+func (db *DB) RestrictedTextTypes() []string {
+	return db.restrictedTextTypes
+}
+
+// TextTypesAttr returns a structural attribute (e.g. "text.txtype")
+// the text types were imported from. For unknown profiles, empty
+// string is returned.
+func (db *DB) TextTypesAttr() string {
+	return db.textTypesAttr
+}
+
+// KeyLayout returns the layout of keys (i.e. the width of token IDs)
+// used by the database
+func (db *DB) KeyLayout() record.KeyLayout {
+	return db.keys
+}
+
+// SetKeyLayout sets the layout of keys for a new database (opened via
+// OpenDBIgnoreMetadata). The layout must be also recorded in the stored
+// metadata (see Metadata.TokenIDWidth) so the database can be read.
+// Changing the layout of a non-empty database makes its records unreadable.
+func (db *DB) SetKeyLayout(keys record.KeyLayout) {
+	db.keys = keys
+}
+
 func (db *DB) Clear() error {
 	return db.bdb.DropAll()
 }
@@ -74,6 +136,13 @@ func (db *DB) StoreMetadata(data Metadata) error {
 	return nil
 }
 
+// StoredMetadata reads dataset metadata directly from the database.
+// Unlike the Metadata attribute (loaded by OpenDB), it works also
+// with databases opened via OpenDBIgnoreMetadata.
+func (db *DB) StoredMetadata() (Metadata, error) {
+	return db.readMetadata()
+}
+
 func (db *DB) readMetadata() (Metadata, error) {
 	k := record.CreateMetadataKey(record.MetadataKeyImportProfile)
 	var result Metadata
@@ -100,9 +169,11 @@ func (db *DB) readMetadata() (Metadata, error) {
 
 // OpenDBIgnoreMetadata opens a BadgerDB database but does not try
 // to fetch index metadata from it. It is suitable e.g. for creating
-// new databases or rewriting existing ones.
+// new databases or rewriting existing ones. Only the key layout is
+// taken from the stored metadata (if available). For new databases,
+// the default layout is used (see SetKeyLayout).
 func OpenDBIgnoreMetadata(path string, textTypes record.TextTypeMapper) (*DB, error) {
-	db, err := openDB(path, false)
+	db, err := openDB(path, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -117,10 +188,20 @@ func OpenDBIgnoreMetadata(path string, textTypes record.TextTypeMapper) (*DB, er
 // The database must have proper metadata set as otherwise, it won't open.
 // For creating a new db, use OpenDBIgnoreMetadata
 func OpenDB(path string) (*DB, error) {
-	return openDB(path, true)
+	return openDB(path, true, false)
+}
+
+// OpenDBReadOnly opens a database the same way OpenDB does but
+// in the read-only mode which allows multiple processes (e.g. a server
+// and command line searches) to open the same database directory
+// at once. Any attempt to write to the database fails.
+// Please note that a database which has not been closed properly
+// (e.g. after a crashed import) cannot be opened in the read-only mode.
+func OpenDBReadOnly(path string) (*DB, error) {
+	return openDB(path, true, true)
 }
 
-func openDB(path string, loadProfile bool) (*DB, error) {
+func openDB(path string, loadProfile, readOnly bool) (*DB, error) {
 	opts := badger.DefaultOptions(path).
 		// Read-optimized settings for large datasets
 		WithValueLogFileSize(1 << 30). // 1GB value log files for better compression
@@ -128,6 +209,7 @@ func openDB(path string, loadProfile bool) (*DB, error) {
 		WithIndexCacheSize(256 << 20). // 256MB index cache
 		WithNumMemtables(2).           // Minimal memtables
 		WithNumLevelZeroTables(2).     // Minimal level zero tables
+		WithReadOnly(readOnly).
 		WithLogger(&ZerologWrapper{})
 
 	ans := &DB{}
@@ -142,9 +224,24 @@ func openDB(path string, loadProfile bool) (*DB, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read data import profile: %w", err)
 		}
+		if err := metadata.checkSchemaVersion(); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open collocations database: %w", err)
+		}
+		ans.keys, err = metadata.KeyLayout()
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open collocations database: %w", err)
+		}
+		if pending := metadata.PendingMigrations(); len(pending) > 0 {
+			log.Warn().
+				Int("schemaVersion", metadata.SchemaVersion).
+				Int("numPendingMigrations", len(pending)).
+				Msg("database uses an older schema, older records will be converted on the fly (use scolldb migrate to upgrade it)")
+		}
 		ans.Metadata = metadata
 		prof := FindProfile(metadata.ProfileName)
-		if prof.IsZero() {
+		if prof.IsZero() && len(metadata.FileTextTypes) == 0 {
 			log.Warn().
 				Str("profile", metadata.ProfileName).
 				Msg("unknown import profile, text types mapping won't be available")
@@ -158,8 +255,45 @@ func openDB(path string, loadProfile bool) (*DB, error) {
 				Int("numCollFreqs", metadata.NumCollFreqs).
 				Msg("loaded dataset metadata")
 		}
-		ans.textTypes = prof.TextTypes
+		textTypes := prof.TextTypes
+		ans.textTypesAttr = prof.TextTypesAttr
+		if len(metadata.FileTextTypes) > 0 {
+			// text types derived from file names cannot be queried
+			// by any structural attribute in the corpus
+			textTypes = metadata.FileTextTypes
+			ans.textTypesAttr = ""
+		}
+		ans.textTypes = textTypes
+		ans.queryDefaults = prof.QueryDefaults
+		ans.restrictedTextTypes = prof.RestrictedTextTypes
+		ans.textTypeLabels = resolveTextTypeLabels(metadata.TextTypeLabels, textTypes)
 		ans.DeprelMapping = record.DeprelMappingFromMap(ans.Metadata.DeprelMap)
+
+		loaded, err := ans.LoadLemmaCache(DefaultLemmaCacheQuota)
+		if err != nil {
+			log.Warn().Err(err).Msg("lemma cache not available, lemmas will be resolved on demand")
+
+		} else if loaded {
+			stats := ans.LemmaCacheStats()
+			log.Info().
+				Int("numLemmas", stats.NumLemmas).
+				Int64("sizeBytes", stats.SizeBytes).
+				Msg("loaded in-memory lemma cache")
+
+		} else {
+			log.Info().
+				Int("numLemmas", metadata.NumLemmas).
+				Msg("vocabulary exceeds lemma cache quota, lemmas will be resolved on demand")
+		}
+
+	} else if metadata, err := ans.readMetadata(); err == nil {
+		// even without metadata loaded, existing records must be
+		// accessed using the right key layout
+		ans.keys, err = metadata.KeyLayout()
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open collocations database: %w", err)
+		}
 	}
 
 	return ans, nil
@@ -0,0 +1,61 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenDBReadOnlyConcurrent(t *testing.T) {
+	path := t.TempDir()
+	db, err := OpenDBIgnoreMetadata(path, NewPreconfTextTypeMapping(nil))
+	assert.NoError(t, err)
+	assert.NoError(t, db.StoreMetadata(Metadata{CorpusSize: 1000}))
+	assert.NoError(t, db.Close())
+
+	db1, err := OpenDBReadOnly(path)
+	assert.NoError(t, err)
+	defer db1.Close()
+	db2, err := OpenDBReadOnly(path)
+	assert.NoError(t, err)
+	defer db2.Close()
+	assert.Equal(t, int64(1000), db1.Metadata.CorpusSize)
+	assert.Equal(t, int64(1000), db2.Metadata.CorpusSize)
+	assert.Error(t, db1.StoreMetadata(Metadata{CorpusSize: 2000}))
+}
+
+func TestOpenDBFileTextTypes(t *testing.T) {
+	path := t.TempDir()
+	db, err := OpenDBIgnoreMetadata(path, NewPreconfTextTypeMapping(nil))
+	assert.NoError(t, err)
+	assert.NoError(t, db.StoreMetadata(Metadata{
+		CorpusSize:    1000,
+		ProfileName:   "intercorp_v16ud",
+		FileTextTypes: map[string]byte{"fiction": 1, "news": 2},
+	}))
+	assert.NoError(t, db.Close())
+
+	db, err = OpenDB(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, byte(2), db.textTypes.ReadableToRaw("news"))
+	assert.Equal(t, "fiction", db.textTypes.RawToReadable(1))
+	assert.Empty(t, db.TextTypesAttr())
+	assert.Len(t, db.TextTypeLabels(nil), 2)
+}
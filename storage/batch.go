@@ -0,0 +1,106 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// CalculateMeasuresBatch performs CalculateMeasures for each of the provided
+// args within a single read transaction and a single slot of the scan gate.
+// Lemma and collocate frequencies and collocate lemmas read from the database
+// are shared among all the searches so lemmas with many common collocates
+// are processed much faster than with separate calls. Searches which can be
+// served from precomputed top collocations are served from them.
+//
+// The returned slice contains results in the same order as the batch.
+// Additional outputs (TotalCount, FilterStats etc.) are filled in for
+// each of the args separately so they should not be shared among them.
+func (db *DB) CalculateMeasuresBatch(ctx context.Context, batch []CalculationArgs) ([][]Collocation, error) {
+	ans := make([][]Collocation, len(batch))
+	queries := make([]*measuresQuery, len(batch))
+	for i, args := range batch {
+		calcFields, err := db.validateCalculationArgs(args)
+		if err != nil {
+			return [][]Collocation{}, fmt.Errorf("failed to prepare search for %s: %w", args.Lemma, err)
+		}
+		results, found, err := db.calculateFromTopCollocations(args, calcFields)
+		if err != nil {
+			return [][]Collocation{}, fmt.Errorf("failed to prepare search for %s: %w", args.Lemma, err)
+
+		} else if found {
+			ans[i] = results
+			continue
+		}
+		queries[i], err = db.prepareMeasuresQuery(args, calcFields)
+		if err != nil {
+			return [][]Collocation{}, fmt.Errorf("failed to prepare search for %s: %w", args.Lemma, err)
+		}
+	}
+
+	t0 := time.Now()
+	releaseScan, err := db.scanGate.acquire(ctx)
+	if err != nil {
+		return [][]Collocation{}, fmt.Errorf("failed to calculate collocation scores: %w", err)
+	}
+	defer releaseScan()
+	queueWait := time.Since(t0)
+
+	// the maps are created here so all the copies of the cache share them
+	// (while hit/miss counters remain specific for each query)
+	sharedCache := itemsWalktrhoughCache{
+		db:                db,
+		idToLemmaCache:    make(map[uint64]string),
+		rawTokenFreqCache: make(map[string][]record.RawTokenFreq),
+	}
+	err = db.view(func(txn *badger.Txn) error {
+		// all the parts of all the searches share the transaction
+		sharedTxn := func(fn func(txn *badger.Txn) error) error {
+			return fn(txn)
+		}
+		for i, query := range queries {
+			if query == nil {
+				continue
+			}
+			t1 := time.Now()
+			cache := sharedCache
+			results, filterStats, err := query.calculate(ctx, sharedTxn, &cache)
+			db.metrics.observeQuery(time.Since(t1), err, filterStats.NumScanned, cache)
+			if err != nil {
+				return fmt.Errorf("failed to search collocations of %s: %w", query.args.Lemma, err)
+			}
+			ans[i] = results
+		}
+		return nil
+	})
+	if err != nil {
+		return [][]Collocation{}, err
+	}
+	log.Debug().
+		Int("batchSize", len(batch)).
+		Int("numCachedLemmas", len(sharedCache.idToLemmaCache)).
+		Str("procTime", fmt.Sprintf("%1.2f", time.Since(t0).Seconds())).
+		Str("queueWait", fmt.Sprintf("%1.2f", queueWait.Seconds())).
+		Msg("finished batch collocation search")
+	return ans, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateMeasuresBatch(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "friday", PoS: noun, Freq: 30, TextType: fiction},
+		"3": {Lemma: "busy", PoS: adj, Freq: 30, TextType: fiction},
+		"4": {Lemma: "free", PoS: adj, Freq: 10, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 6, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "free", PoS2: adj, Freq: 2, AVGDist: 1, TextType: fiction},
+		"3": {Lemma1: "friday", PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 1, AVGDist: 1, TextType: fiction},
+		"4": {Lemma1: "friday", PoS1: noun, Lemma2: "free", PoS2: adj, Freq: 5, AVGDist: 1, TextType: fiction},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	batch := []CalculationArgs{
+		{Lemma: "monday", Limit: 10, SortBy: sortByLogDice},
+		{Lemma: "friday", Limit: 1, SortBy: sortByTScore},
+		{Lemma: "unknown", Limit: 10, SortBy: sortByLogDice},
+		{Lemma: "monday", Limit: 10, SortBy: sortByLMI, PoS: "NOUN"},
+	}
+	totals := make([]int, len(batch))
+	stats := make([]FilterStats, len(batch))
+	for i := range batch {
+		batch[i].TotalCount = &totals[i]
+		batch[i].FilterStats = &stats[i]
+	}
+	ans, err := db.CalculateMeasuresBatch(context.Background(), batch)
+	assert.NoError(t, err)
+	if assert.Len(t, ans, len(batch)) {
+		for i, args := range batch {
+			var total int
+			var stat FilterStats
+			args.TotalCount = &total
+			args.FilterStats = &stat
+			expected, err := db.CalculateMeasures(context.Background(), args)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, ans[i], "lemma: %s", args.Lemma)
+			assert.Equal(t, total, totals[i], "lemma: %s", args.Lemma)
+			assert.Equal(t, stat, stats[i], "lemma: %s", args.Lemma)
+		}
+	}
+	assert.Equal(t, []int{2, 2, 0, 2}, totals)
+
+	_, err = db.CalculateMeasuresBatch(
+		context.Background(),
+		[]CalculationArgs{
+			{Lemma: "monday", Limit: 10, SortBy: sortByLogDice},
+			{Lemma: "friday", Limit: 10, SortBy: sortByLogDice, CorpusSize: -1},
+		},
+	)
+	assert.ErrorIs(t, err, ErrInvalidCorpusSize)
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"sync/atomic"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultWriteBatchSize is a default number of records committed
+// at once when storing imported data.
+const DefaultWriteBatchSize = 50000
+
+// SetWriteBatchSize sets the number of records committed at once
+// by StoreData and AppendFreqs. Larger batches mean fewer commits
+// at the cost of memory. A non-positive value sets the default size.
+func (db *DB) SetWriteBatchSize(size int) {
+	db.writeBatchSize = size
+}
+
+// NumWrittenRecords returns the number of records committed
+// by batch writes (i.e. by imports, merges etc.) since the database
+// has been opened. The method can be called concurrently with the writes
+// (e.g. to report import progress).
+func (db *DB) NumWrittenRecords() int64 {
+	return db.numWritten.Load()
+}
+
+// keyValueSetter is anything records can be written to
+// (i.e. a transaction or a batch writer)
+type keyValueSetter interface {
+	Set(key, value []byte) error
+}
+
+// batchWriter writes records using badger's WriteBatch. The records
+// are committed in chunks of a configured size which is much faster
+// than using a separate transaction for each record. It also logs
+// progress of processing of a known number of items.
+// Please note that the written records become visible only once
+// their chunk is committed.
+type batchWriter struct {
+	bdb        *badger.DB
+	wb         *badger.WriteBatch
+	batchSize  int
+	numPending int
+	phase      string
+	numItems   int
+	numDone    int
+	lastLogged int
+	numWritten *atomic.Int64
+}
+
+// newBatchWriter creates a writer for a processing phase (used
+// in log messages) with expected numItems processed items.
+func (db *DB) newBatchWriter(phase string, numItems int) *batchWriter {
+	batchSize := db.writeBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultWriteBatchSize
+	}
+	return &batchWriter{
+		bdb:        db.bdb,
+		wb:         db.bdb.NewWriteBatch(),
+		batchSize:  batchSize,
+		phase:      phase,
+		numItems:   numItems,
+		numWritten: &db.numWritten,
+	}
+}
+
+// Set adds a record to the current chunk. Once the chunk is full,
+// it is committed. The key and the value must not be modified after
+// the call.
+func (bw *batchWriter) Set(key, value []byte) error {
+	if err := bw.wb.Set(key, value); err != nil {
+		return err
+	}
+	bw.numPending++
+	if bw.numPending >= bw.batchSize {
+		return bw.commit()
+	}
+	return nil
+}
+
+// Delete adds a removal of a record to the current chunk. Just like
+// with Set, the key must not be modified after the call.
+func (bw *batchWriter) Delete(key []byte) error {
+	if err := bw.wb.Delete(key); err != nil {
+		return err
+	}
+	bw.numPending++
+	if bw.numPending >= bw.batchSize {
+		return bw.commit()
+	}
+	return nil
+}
+
+// itemDone marks a single processed item (which may have produced
+// any number of records) for progress logging. The progress is logged
+// only for phases with more items than fits into a single batch.
+func (bw *batchWriter) itemDone() {
+	bw.numDone++
+	if bw.numItems <= bw.batchSize {
+		return
+	}
+	if pct := bw.numDone * 100 / bw.numItems; pct/10 > bw.lastLogged/10 {
+		bw.lastLogged = pct
+		log.Info().
+			Str("phase", bw.phase).
+			Int("numDone", bw.numDone).
+			Int("numItems", bw.numItems).
+			Msgf("storing imported data - %d%%", pct)
+	}
+}
+
+func (bw *batchWriter) commit() error {
+	if err := bw.wb.Flush(); err != nil {
+		return err
+	}
+	bw.numWritten.Add(int64(bw.numPending))
+	bw.wb = bw.bdb.NewWriteBatch()
+	bw.numPending = 0
+	return nil
+}
+
+// Flush commits all the pending records. The writer must not
+// be used after the call.
+func (bw *batchWriter) Flush() error {
+	if err := bw.wb.Flush(); err != nil {
+		return err
+	}
+	bw.numWritten.Add(int64(bw.numPending))
+	bw.numPending = 0
+	return nil
+}
+
+// Cancel discards all the uncommitted records. It is safe to call
+// the method after Flush (so it can be deferred).
+func (bw *batchWriter) Cancel() {
+	bw.wb.Cancel()
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Binary encodings use the same field names as JSON (the codec
+// library reads the `json` struct tags). Unlike JSON, float values
+// are not rounded as there is no size benefit in doing so.
+
+var (
+	msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+	cborHandle    = &codec.CborHandle{}
+)
+
+func encodeWithHandle(v any, h codec.Handle) ([]byte, error) {
+	var ans []byte
+	if err := codec.NewEncoderBytes(&ans, h).Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+	return ans, nil
+}
+
+func collocationsAsRecords(items []Collocation) []collocationRecord {
+	ans := make([]collocationRecord, len(items))
+	for i, item := range items {
+		ans[i] = item.asRecord()
+		sanitizeRecordScores(&ans[i])
+	}
+	return ans
+}
+
+// sanitizeRecordScores replaces infinite values which are not representable
+// in JSON as we want all the encodings to produce the same values
+func sanitizeRecordScores(rec *collocationRecord) {
+	for _, v := range []*roundedFloat{
+		rec.LogDice, rec.TScore, rec.LMI, rec.LogLikelihood,
+		rec.MI, rec.MI3, rec.Dice, rec.MinSensitivity,
+		rec.DeltaPCollocate, rec.DeltaPNode,
+		rec.FreqIPM, rec.LemmaFreqIPM, rec.CollocateFreqIPM,
+	} {
+		if v != nil && (math.IsInf(float64(*v), 0) || math.IsNaN(float64(*v))) {
+			*v = 0
+		}
+	}
+	for i := range rec.SecondOrder {
+		sanitizeRecordScores(&rec.SecondOrder[i])
+	}
+}
+
+// MarshalMsgpack encodes the collocation using the MessagePack format
+func (col Collocation) MarshalMsgpack() ([]byte, error) {
+	return encodeWithHandle(collocationsAsRecords([]Collocation{col})[0], msgpackHandle)
+}
+
+// MarshalCBOR encodes the collocation using the CBOR format
+func (col Collocation) MarshalCBOR() ([]byte, error) {
+	return encodeWithHandle(collocationsAsRecords([]Collocation{col})[0], cborHandle)
+}
+
+// EncodeCollocationsMsgpack encodes a list of collocations
+// as a MessagePack array.
+func EncodeCollocationsMsgpack(items []Collocation) ([]byte, error) {
+	return encodeWithHandle(collocationsAsRecords(items), msgpackHandle)
+}
+
+// EncodeCollocationsCBOR encodes a list of collocations
+// as a CBOR array.
+func EncodeCollocationsCBOR(items []Collocation) ([]byte, error) {
+	return encodeWithHandle(collocationsAsRecords(items), cborHandle)
+}
+
+// EncodeMsgpack encodes any value (e.g. LemmaInfo, DeprelStats)
+// using the MessagePack format.
+// For collocations, use EncodeCollocationsMsgpack.
+func EncodeMsgpack(v any) ([]byte, error) {
+	return encodeWithHandle(v, msgpackHandle)
+}
+
+// EncodeCBOR encodes any value (e.g. LemmaInfo, DeprelStats)
+// using the CBOR format.
+// For collocations, use EncodeCollocationsCBOR.
+func EncodeCBOR(v any) ([]byte, error) {
+	return encodeWithHandle(v, cborHandle)
+}
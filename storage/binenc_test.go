@@ -0,0 +1,59 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestEncodeCollocationsBinary(t *testing.T) {
+	items := []Collocation{
+		{
+			Lemma:      CollMember{Value: "dog", PoS: "NOUN"},
+			Collocate:  CollMember{Value: "big", PoS: "ADJ"},
+			Deprel:     "amod",
+			LogDice:    10.5,
+			LMI:        math.Inf(-1),
+			IsHead:     true,
+			MutualDist: 1,
+			CorpusSize: 100,
+		},
+	}
+	for _, h := range []codec.Handle{msgpackHandle, cborHandle} {
+		var data []byte
+		var err error
+		if h == msgpackHandle {
+			data, err = EncodeCollocationsMsgpack(items)
+
+		} else {
+			data, err = EncodeCollocationsCBOR(items)
+		}
+		assert.NoError(t, err)
+		var decoded []map[string]any
+		assert.NoError(t, codec.NewDecoderBytes(data, h).Decode(&decoded))
+		assert.Len(t, decoded, 1)
+		assert.Equal(t, "amod", decoded[0]["deprel"])
+		assert.Equal(t, true, decoded[0]["isHead"])
+		assert.Equal(t, 10.5, decoded[0]["logDice"])
+		assert.Equal(t, 0.0, decoded[0]["lmi"])
+		assert.EqualValues(t, 100, decoded[0]["corpusSize"])
+	}
+}
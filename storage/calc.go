@@ -71,6 +71,87 @@ func SortByRRF(items []Collocation) {
 
 }
 
+// UpdateScores calculates association measures from the raw frequencies
+// (Freq, LemmaFreq, CollocateFreq) and CorpusSize. Only measures included
+// in fields are calculated (empty fields = all the measures). RRFScore is
+// not affected as it depends on other items (see SortByRRF).
+func (col *Collocation) UpdateScores(fields []ResultField) {
+	fxy, fx, fy := float64(col.Freq), float64(col.LemmaFreq), float64(col.CollocateFreq)
+	n := float64(col.CorpusSize)
+	if hasField(fields, FieldLogDice) {
+		col.LogDice = 14.0 + math.Log2(2*fxy/(fx+fy))
+	}
+	if hasField(fields, FieldTScore) {
+		col.TScore = (fxy - fx*fy/n) / math.Sqrt(fxy)
+	}
+	if hasField(fields, FieldLMI) {
+		col.LMI = fxy * math.Log2(n*fxy/(fx*fy))
+	}
+	if hasField(fields, FieldLogLikelihood) {
+		col.LogLikelihood = LLScore(uint32(col.Freq), uint32(col.LemmaFreq), uint32(col.CollocateFreq), col.CorpusSize)
+	}
+	if hasField(fields, FieldMI) {
+		col.MI = math.Log2(n * fxy / (fx * fy))
+	}
+	if hasField(fields, FieldMI3) {
+		col.MI3 = math.Log2(n * fxy * fxy * fxy / (fx * fy))
+	}
+	if hasField(fields, FieldDice) {
+		col.Dice = 2 * fxy / (fx + fy)
+	}
+	if hasField(fields, FieldMinSensitivity) {
+		col.MinSensitivity = math.Min(fxy/fx, fxy/fy)
+	}
+	if hasField(fields, FieldDeltaPCollocate) {
+		col.DeltaPCollocate = fxy/fx - (fy-fxy)/(n-fx)
+	}
+	if hasField(fields, FieldDeltaPNode) {
+		col.DeltaPNode = fxy/fy - (fx-fxy)/(n-fy)
+	}
+	col.FreqIPM = perMillion(col.Freq, col.CorpusSize)
+	col.LemmaFreqIPM = perMillion(col.LemmaFreq, col.CorpusSize)
+	col.CollocateFreqIPM = perMillion(col.CollocateFreq, col.CorpusSize)
+}
+
+// perMillion returns a frequency normalized to the corpus size
+// (instances per million tokens). For an unknown corpus size,
+// zero is returned.
+func perMillion(freq int, corpusSize int64) float64 {
+	if corpusSize <= 0 {
+		return 0
+	}
+	return float64(freq) * 1e6 / float64(corpusSize)
+}
+
+// SortCollocations orders items by the measure (in descending order).
+// In case of RRF, the RRF scores are calculated first.
+func SortCollocations(items []Collocation, sortBy SortingMeasure) {
+	switch sortBy {
+	case sortByTScore:
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].TScore > items[j].TScore
+		})
+	case sortByLogDice:
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].LogDice > items[j].LogDice
+		})
+	case sortByLMI:
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].LMI > items[j].LMI
+		})
+	case sortByLL:
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].LogLikelihood > items[j].LogLikelihood
+		})
+	case sortByRRF:
+		SortByRRF(items)
+	default:
+		sort.Slice(items, func(i, j int) bool {
+			return sortBy.ValueOf(items[i]) > sortBy.ValueOf(items[j])
+		})
+	}
+}
+
 /*
 |     |  y  | !y    | total |
 |  x  |  a  |  b    | a + b |
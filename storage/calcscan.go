@@ -0,0 +1,322 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DefaultCalcWorkers is a default max. number of goroutines
+// scanning collocation records of a single search
+const DefaultCalcWorkers = 4
+
+// SetCalcWorkers sets a max. number of goroutines scanning collocation
+// records of a single search. The records of each node variant (e.g.
+// a lemma matching a searched prefix) and direction (head/dependent)
+// are scanned separately so searches with many variants benefit the most.
+// A non-positive value sets the default.
+// The method is expected to be called before the database starts
+// serving queries.
+func (db *DB) SetCalcWorkers(n int) {
+	db.calcWorkers = n
+}
+
+func (db *DB) numCalcWorkers() int {
+	if db.calcWorkers <= 0 {
+		return DefaultCalcWorkers
+	}
+	return db.calcWorkers
+}
+
+// txnViewer runs a read operation within a transaction (see DB.view)
+type txnViewer func(fn func(txn *badger.Txn) error) error
+
+// measuresScanUnit is a part of a collocation search scanned
+// by a single goroutine - collocation records of one node variant
+// in one direction
+type measuresScanUnit struct {
+	variant nodeVariant
+	isHead  bool
+
+	// withLemmaFreq makes the unit to read also F(x) of the variant
+	// (i.e. it is set just for one unit of each variant)
+	withLemmaFreq bool
+}
+
+// measuresScanResult contains partial data collected by a measuresScanUnit.
+// Results of all the units are combined by measuresQuery.calculate.
+type measuresScanResult struct {
+	lemmaFreqs []record.RawTokenFreq
+	collFreqs  *collFreqGrouping
+
+	// collocateFreqs contains F(y) records of the collocates keyed
+	// by their search keys (so they can be added just once even
+	// if more units find the same collocate)
+	collocateFreqs map[string][]record.RawTokenFreq
+	filterStats    FilterStats
+}
+
+// scanUnits splits the query into parts which can be scanned
+// concurrently
+func (q *measuresQuery) scanUnits() []measuresScanUnit {
+	directions := []bool{true, false}
+	if q.args.IsHead != nil {
+		directions = []bool{*q.args.IsHead}
+	}
+	ans := make([]measuresScanUnit, 0, len(q.variants)*len(directions))
+	for _, v := range q.variants {
+		for i, isHead := range directions {
+			ans = append(ans, measuresScanUnit{variant: v, isHead: isHead, withLemmaFreq: i == 0})
+		}
+	}
+	return ans
+}
+
+// scan processes all the scan units of the query using a limited number
+// of goroutines (see DB.SetCalcWorkers). Each unit runs its read operation
+// via view (i.e. either within its own transaction or within a shared one).
+// The results are returned in the order of the units. In case any of the
+// units fails, the others are cancelled and the first error is returned.
+func (q *measuresQuery) scan(
+	ctx context.Context,
+	view txnViewer,
+	walkthruCache *itemsWalktrhoughCache,
+) ([]measuresScanResult, error) {
+	units := q.scanUnits()
+	ans := make([]measuresScanResult, len(units))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error
+	var errOnce sync.Once
+	// the scan budget is shared by all the units
+	var numScanned atomic.Int64
+	next := make(chan int)
+	var wg sync.WaitGroup
+	caches := make([]itemsWalktrhoughCache, min(len(units), q.db.numCalcWorkers()))
+	for w := range caches {
+		caches[w] = walkthruCache.share()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				err := view(func(txn *badger.Txn) error {
+					var err error
+					ans[i], err = q.scanUnit(ctx, txn, units[i], &numScanned, &caches[w])
+					return err
+				})
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	for i := range units {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for _, cache := range caches {
+		walkthruCache.numHits += cache.numHits
+		walkthruCache.numMisses += cache.numMisses
+	}
+	if firstErr != nil {
+		return nil, fmt.Errorf("failed to calculate collocation scores: %w", firstErr)
+	}
+	return ans, nil
+}
+
+// scanUnit reads F(x) (if required by the unit) and scans collocation
+// records of the unit's variant and direction. Accepted records are
+// grouped and F(y) of their collocates is read.
+func (q *measuresQuery) scanUnit(
+	ctx context.Context,
+	txn *badger.Txn,
+	unit measuresScanUnit,
+	numScanned *atomic.Int64,
+	walkthruCache *itemsWalktrhoughCache,
+) (measuresScanResult, error) {
+	db := q.db
+	args := q.args
+	ttID := q.ttID
+	excludedTT := q.excludedTT
+	lemmaMatch := unit.variant
+	_, _, collFreqs := q.newGroupings()
+	ans := measuresScanResult{
+		collFreqs:      collFreqs,
+		collocateFreqs: make(map[string][]record.RawTokenFreq),
+	}
+	filterStats := &ans.filterStats
+
+	if unit.withLemmaFreq {
+		// First, get F(x) (i.e. freq. of the searched lemma). This search respects
+		// possible provided PoS and text type specification. Attribute deprel cannot
+		// be used in filter this way so it is filtered later (if needed).
+		partialFreqs1, err := walkthruCache.getSingleTokenFreqTx(
+			txn, q.useRollups, lemmaMatch.TokenID, q.posID, ttID)
+		if err != nil {
+			return ans, err
+		}
+		for _, pf1 := range partialFreqs1 {
+			if excludedTT[pf1.TextType] {
+				continue
+			}
+			pf1.TokenID = lemmaMatch.nodeID
+			ans.lemmaFreqs = append(ans.lemmaFreqs, pf1)
+		}
+	}
+
+	pairPrefix := db.keys.AllCollFreqsOfToken(unit.isHead, lemmaMatch.TokenID)
+	if args.PathCollocations {
+		pairPrefix = db.keys.AllPathCollFreqsOfToken(unit.isHead, lemmaMatch.TokenID)
+	}
+	if q.useHotSummaries && db.hasHotLemmaSummaryTx(txn, unit.isHead, lemmaMatch.TokenID) {
+		pairPrefix = db.keys.AllHotCollFreqsOfToken(unit.isHead, lemmaMatch.TokenID)
+		filterStats.UsedHotSummaries = true
+	}
+	opts := badger.IteratorOptions{
+		Prefix:         pairPrefix,
+		PrefetchValues: true,
+		PrefetchSize:   1000,
+	}
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	cancelled := cancelCheck{ctx: ctx}
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := cancelled.err(); err != nil {
+			return ans, err
+		}
+		if q.deprelSeek != nil && !q.deprelSeek.seek(it) {
+			break
+		}
+		if args.MaxScannedPairs > 0 && numScanned.Add(1) > int64(args.MaxScannedPairs) {
+			filterStats.ScanBudgetExhausted = true
+			break
+		}
+		item := it.Item()
+		key := item.Key()
+		decKey, err := db.keys.DecodeCollFreqKey(key)
+		if err != nil {
+			if err := db.skipMalformed(key, err); err != nil {
+				return ans, err
+			}
+			continue
+		}
+		filterStats.NumScanned++
+
+		if ttID > 0 && decKey.TextType != ttID || excludedTT[decKey.TextType] {
+			filterStats.TextType++
+			continue
+		}
+
+		// Get F(x,y) frequency information
+		collValue, err := decodeItemValue(item, record.DecodeCollocValue)
+		if err != nil {
+			if err := db.skipMalformed(key, err); err != nil {
+				return ans, err
+			}
+			continue
+		}
+
+		if args.CustomFilter != nil && !args.CustomFilter(
+			decKey.Pos1, decKey.Deprel, decKey.Pos2, decKey.TextType, decKey.IsHead, collValue.Dist) {
+			filterStats.CustomFilter++
+			continue
+		}
+
+		if args.MaxAvgCollocateDist > 0 && collValue.Dist > args.MaxAvgCollocateDist {
+			filterStats.MaxAvgDist++
+			continue
+		}
+
+		if args.MaxAvgSurfaceDist > 0 && math.Abs(collValue.SurfaceDist) > args.MaxAvgSurfaceDist {
+			filterStats.MaxAvgSurfaceDist++
+			continue
+		}
+
+		if args.CollocateOrder == CollocateBefore && collValue.SurfaceDist >= 0 ||
+			args.CollocateOrder == CollocateAfter && collValue.SurfaceDist <= 0 {
+			filterStats.CollocateOrder++
+			continue
+		}
+		filterStats.NumAccepted++
+
+		deprel := decKey.Deprel
+		if args.DeprelGranularity == DeprelGranularityCore {
+			deprel = db.DeprelMapping.CoreOf(deprel)
+		}
+
+		// F(x, y)
+		collFreqs.add(record.RawCollocFreq{
+			Token1ID:       lemmaMatch.nodeID,
+			PoS1:           decKey.Pos1,
+			Deprel:         deprel,
+			Token2ID:       decKey.Token2ID,
+			PoS2:           decKey.Pos2,
+			Freq:           collValue.Freq,
+			AVGDist:        collValue.Dist,
+			TextType:       decKey.TextType,
+			IsHead:         decKey.IsHead,
+			AVGSurfaceDist: collValue.SurfaceDist,
+			Feats1:         decKey.Feats,
+		})
+
+		// Get F(y) - frequency of second lemma
+		collocateKey := string(db.keys.TokenFreqSearchKey(decKey.Token2ID, decKey.Pos2, ttID))
+		if _, ok := ans.collocateFreqs[collocateKey]; ok {
+			continue
+		}
+		partialSplitFreq2, err := walkthruCache.getSingleTokenFreqTx(
+			txn, q.useRollups, decKey.Token2ID, decKey.Pos2, ttID)
+		if err != nil {
+			ans.collocateFreqs[collocateKey] = []record.RawTokenFreq{}
+			continue // Skip if we can't find single freq
+		}
+		freqs2 := make([]record.RawTokenFreq, 0, len(partialSplitFreq2))
+		for _, psf2 := range partialSplitFreq2 {
+			if excludedTT[psf2.TextType] {
+				continue
+			}
+			freqs2 = append(freqs2, psf2)
+		}
+		ans.collocateFreqs[collocateKey] = freqs2
+	}
+	return ans, nil
+}
+
+// addScanStats adds counters of records examined by a scan unit
+func (fs *FilterStats) addScanStats(other FilterStats) {
+	fs.NumScanned += other.NumScanned
+	fs.TextType += other.TextType
+	fs.CustomFilter += other.CustomFilter
+	fs.MaxAvgDist += other.MaxAvgDist
+	fs.MaxAvgSurfaceDist += other.MaxAvgSurfaceDist
+	fs.CollocateOrder += other.CollocateOrder
+	fs.NumAccepted += other.NumAccepted
+	fs.UsedHotSummaries = fs.UsedHotSummaries || other.UsedHotSummaries
+	fs.ScanBudgetExhausted = fs.ScanBudgetExhausted || other.ScanBudgetExhausted
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateMeasuresParallelVariants(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 10000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"busy": {Lemma: "busy", PoS: adj, Freq: 300, TextType: fiction},
+		"free": {Lemma: "free", PoS: adj, Freq: 200, TextType: fiction},
+	}
+	pairFreqs := make(map[record.GroupingKey]record.CollocFreq)
+	for i := range 10 {
+		lemma := fmt.Sprintf("day%d", i)
+		singleFreqs[record.GroupingKey(lemma)] = record.TokenFreq{Lemma: lemma, PoS: noun, Freq: 50 + i, TextType: fiction}
+		pairFreqs[record.GroupingKey(lemma+"-busy")] = record.CollocFreq{
+			Lemma1: lemma, PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 5 + i, AVGDist: 1, TextType: fiction}
+		pairFreqs[record.GroupingKey(lemma+"-free")] = record.CollocFreq{
+			Lemma1: lemma, PoS1: noun, Lemma2: "free", PoS2: adj, Freq: 2 + i, AVGDist: 2, TextType: fiction}
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	search := func(workers int, merge bool) ([]Collocation, FilterStats) {
+		db.SetCalcWorkers(workers)
+		var stats FilterStats
+		ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+			Lemma:               "day",
+			LemmaIsPrefix:       true,
+			MergePrefixVariants: merge,
+			Limit:               100,
+			SortBy:              sortByLogDice,
+			FilterStats:         &stats,
+		})
+		assert.NoError(t, err)
+		return ans, stats
+	}
+	for _, merge := range []bool{false, true} {
+		expected, expectedStats := search(1, merge)
+		ans, stats := search(8, merge)
+		assert.Equal(t, expectedStats, stats)
+		if assert.Len(t, ans, len(expected)) {
+			for i, item := range ans {
+				assert.Equal(t, expected[i].Lemma, item.Lemma)
+				assert.Equal(t, expected[i].Collocate, item.Collocate)
+				assert.Equal(t, expected[i].Freq, item.Freq)
+				assert.Equal(t, expected[i].LemmaFreq, item.LemmaFreq)
+				assert.Equal(t, expected[i].CollocateFreq, item.CollocateFreq)
+				assert.InDelta(t, expected[i].MutualDist, item.MutualDist, 0.0001)
+			}
+		}
+	}
+	ans, stats := search(8, false)
+	assert.Len(t, ans, 20)
+	assert.Equal(t, 20, stats.NumAccepted)
+	ans, _ = search(8, true)
+	if assert.Len(t, ans, 2) {
+		assert.Equal(t, "busy", ans[0].Collocate.Value)
+		assert.Equal(t, 95, ans[0].Freq)
+		// F(y) is counted just once
+		assert.Equal(t, 300, ans[0].CollocateFreq)
+	}
+}
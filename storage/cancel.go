@@ -0,0 +1,40 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import "context"
+
+// ctxCheckInterval is a number of examined records after which
+// a long running scan tests whether its context has been cancelled
+const ctxCheckInterval = 1000
+
+// cancelCheck tests a context for cancellation only once per
+// ctxCheckInterval calls so the test does not slow down scans
+type cancelCheck struct {
+	ctx     context.Context
+	numRecs int
+}
+
+// err returns the context error in case the context has been
+// cancelled (or its deadline has passed).
+func (cc *cancelCheck) err() error {
+	cc.numRecs++
+	if cc.numRecs%ctxCheckInterval != 0 {
+		return nil
+	}
+	return cc.ctx.Err()
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// CategoryLexicon maps collocate lemmas to categories (e.g. sentiment
+// polarity or semantic classes) provided by an external resource.
+// An entry for a (lemma, PoS) pair has priority over an entry
+// for the lemma only.
+type CategoryLexicon struct {
+	byLemma    map[string]string
+	byLemmaPoS map[string]string
+}
+
+// NewCategoryLexicon creates an empty lexicon
+func NewCategoryLexicon() *CategoryLexicon {
+	return &CategoryLexicon{
+		byLemma:    make(map[string]string),
+		byLemmaPoS: make(map[string]string),
+	}
+}
+
+// Add adds an entry to the lexicon. With an empty pos, the entry
+// applies to all the PoS variants of the lemma.
+func (lex *CategoryLexicon) Add(lemma, pos, category string) {
+	if pos == "" {
+		lex.byLemma[lemma] = category
+		return
+	}
+	lex.byLemmaPoS[lemma+"\t"+pos] = category
+}
+
+// Category returns a category of the lemma (with PoS). In case
+// the lemma is not in the lexicon, false is returned.
+func (lex *CategoryLexicon) Category(lemma, pos string) (string, bool) {
+	if pos != "" {
+		if cat, ok := lex.byLemmaPoS[lemma+"\t"+pos]; ok {
+			return cat, true
+		}
+	}
+	cat, ok := lex.byLemma[lemma]
+	return cat, ok
+}
+
+// Len returns number of entries of the lexicon
+func (lex *CategoryLexicon) Len() int {
+	return len(lex.byLemma) + len(lex.byLemmaPoS)
+}
+
+// ParseCategoryLexicon reads lexicon entries in TSV format with
+// columns lemma, category and optional PoS. Empty lines and lines
+// starting with # are ignored.
+func ParseCategoryLexicon(r io.Reader) (*CategoryLexicon, error) {
+	ans := NewCategoryLexicon()
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		items := strings.Split(line, "\t")
+		if len(items) < 2 || len(items) > 3 || items[1] == "" {
+			return nil, fmt.Errorf("failed to read category lexicon: invalid line %d", lineNum)
+		}
+		var pos string
+		if len(items) == 3 {
+			pos = items[2]
+		}
+		ans.Add(items[0], pos, items[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read category lexicon: %w", err)
+	}
+	return ans, nil
+}
+
+// ReadCategoryLexicon reads a lexicon from a TSV file
+// (see ParseCategoryLexicon).
+func ReadCategoryLexicon(path string) (*CategoryLexicon, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read category lexicon: %w", err)
+	}
+	defer f.Close()
+	return ParseCategoryLexicon(f)
+}
+
+// CategoryAggregate summarizes collocations of a single category.
+// Shares are related to all the collocations (including the ones
+// not found in the lexicon).
+type CategoryAggregate struct {
+	Category        string  `json:"category"`
+	NumCollocations int     `json:"numCollocations"`
+	Freq            int     `json:"freq"`
+	Share           float64 `json:"share"`
+	FreqShare       float64 `json:"freqShare"`
+}
+
+// CategoryProfile describes categories of all the collocations
+// found for a node (i.e. not just the ones within a result limit).
+type CategoryProfile struct {
+
+	// NumCollocations is a number of all the collocations
+	NumCollocations int `json:"numCollocations"`
+
+	// NumCategorized is a number of collocations with
+	// a collocate found in the lexicon
+	NumCategorized int `json:"numCategorized"`
+
+	// Freq is a sum of F(x,y) of all the collocations
+	Freq int `json:"freq"`
+
+	// Categories contains per-category aggregates sorted
+	// by numbers of collocations in descending order
+	Categories []CategoryAggregate `json:"categories"`
+}
+
+// ProfileCategories calculates category aggregates of the collocates.
+// The items should contain all the candidates of a search (i.e. before
+// a result limit is applied).
+func ProfileCategories(items []Collocation, lex *CategoryLexicon) CategoryProfile {
+	ans := CategoryProfile{Categories: []CategoryAggregate{}}
+	aggs := make(map[string]*CategoryAggregate)
+	for _, item := range items {
+		ans.NumCollocations++
+		ans.Freq += item.Freq
+		cat, ok := lex.Category(item.Collocate.Value, item.Collocate.PoS)
+		if !ok {
+			continue
+		}
+		ans.NumCategorized++
+		agg, ok := aggs[cat]
+		if !ok {
+			agg = &CategoryAggregate{Category: cat}
+			aggs[cat] = agg
+		}
+		agg.NumCollocations++
+		agg.Freq += item.Freq
+	}
+	for _, agg := range aggs {
+		if ans.NumCollocations > 0 {
+			agg.Share = float64(agg.NumCollocations) / float64(ans.NumCollocations)
+		}
+		if ans.Freq > 0 {
+			agg.FreqShare = float64(agg.Freq) / float64(ans.Freq)
+		}
+		ans.Categories = append(ans.Categories, *agg)
+	}
+	slices.SortFunc(ans.Categories, func(a, b CategoryAggregate) int {
+		if a.NumCollocations != b.NumCollocations {
+			return b.NumCollocations - a.NumCollocations
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+	return ans
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCategoryLexicon(t *testing.T) {
+	lex, err := ParseCategoryLexicon(strings.NewReader(
+		"# sentiment lexicon\nhard\tnegative\n\nstart\tpositive\nstart\tneutral\tNOUN\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, lex.Len())
+	cat, ok := lex.Category("start", "NOUN")
+	assert.True(t, ok)
+	assert.Equal(t, "neutral", cat)
+	cat, ok = lex.Category("start", "VERB")
+	assert.True(t, ok)
+	assert.Equal(t, "positive", cat)
+	_, ok = lex.Category("foo", "")
+	assert.False(t, ok)
+
+	_, err = ParseCategoryLexicon(strings.NewReader("hard\n"))
+	assert.ErrorContains(t, err, "invalid line 1")
+}
+
+func TestCalculateMeasuresCategoryProfile(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "work", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "hard", PoS: verb, Freq: 50, TextType: tt},
+		"3": {Lemma: "start", PoS: verb, Freq: 30, TextType: tt},
+		"4": {Lemma: "fail", PoS: verb, Freq: 20, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "work", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 20, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "work", PoS1: noun, Lemma2: "start", PoS2: verb, Freq: 15, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "work", PoS1: noun, Lemma2: "fail", PoS2: verb, Freq: 5, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	lex := NewCategoryLexicon()
+	lex.Add("hard", "", "negative")
+	lex.Add("fail", "", "negative")
+	var profile CategoryProfile
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:           "work",
+		Limit:           1,
+		SortBy:          sortByLogDice,
+		CategoryLexicon: lex,
+		CategoryProfile: &profile,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 3, profile.NumCollocations)
+	assert.Equal(t, 2, profile.NumCategorized)
+	assert.Equal(t, 40, profile.Freq)
+	assert.Len(t, profile.Categories, 1)
+	assert.Equal(t, "negative", profile.Categories[0].Category)
+	assert.Equal(t, 25, profile.Categories[0].Freq)
+	assert.InDelta(t, 2.0/3.0, profile.Categories[0].Share, 1e-9)
+	assert.InDelta(t, 0.625, profile.Categories[0].FreqShare, 1e-9)
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// CompactCollocationFields describes columns of CompactCollocations rows.
+// Columns marked with the "@" prefix contain indices to the string table.
+// In case the collocations have selected fields (see Collocation.Fields),
+// only the selected optional columns are present.
+var CompactCollocationFields = []string{
+	"@lemma",
+	"@lemmaPos",
+	"@collocate",
+	"@collocatePos",
+	"@deprel",
+	"@textType",
+	"isHead",
+	"logDice",
+	"tScore",
+	"mutualDist",
+	"surfaceDist",
+	"lmi",
+	"logLikelihood",
+	"rrfScore",
+	"corpusSize",
+	"mi",
+	"mi3",
+	"dice",
+	"minSensitivity",
+	"freq",
+	"lemmaFreq",
+	"collocateFreq",
+	"freqIpm",
+	"lemmaFreqIpm",
+	"collocateFreqIpm",
+	"deltaPCollocate",
+	"deltaPNode",
+}
+
+// compactColumn describes how to obtain a value of a compact column.
+// An empty field means the column is always present.
+type compactColumn struct {
+	field ResultField
+	value func(item Collocation, st *stringTable) any
+}
+
+var compactColumns = []compactColumn{
+	{"", func(item Collocation, st *stringTable) any { return st.index(item.Lemma.Value) }},
+	{"", func(item Collocation, st *stringTable) any { return st.index(item.Lemma.PoS) }},
+	{"", func(item Collocation, st *stringTable) any { return st.index(item.Collocate.Value) }},
+	{"", func(item Collocation, st *stringTable) any { return st.index(item.Collocate.PoS) }},
+	{"", func(item Collocation, st *stringTable) any { return st.index(item.Deprel) }},
+	{FieldTextType, func(item Collocation, st *stringTable) any { return st.index(item.TextType) }},
+	{"", func(item Collocation, st *stringTable) any { return item.IsHead }},
+	{FieldLogDice, func(item Collocation, st *stringTable) any { return roundedFloat(item.LogDice) }},
+	{FieldTScore, func(item Collocation, st *stringTable) any { return roundedFloat(item.TScore) }},
+	{FieldMutualDist, func(item Collocation, st *stringTable) any { return roundedFloat(item.MutualDist) }},
+	{FieldSurfaceDist, func(item Collocation, st *stringTable) any { return roundedFloat(item.SurfaceDist) }},
+	{FieldLMI, func(item Collocation, st *stringTable) any { return roundedFloat(item.LMI) }},
+	{FieldLogLikelihood, func(item Collocation, st *stringTable) any { return roundedFloat(item.LogLikelihood) }},
+	{FieldRRFScore, func(item Collocation, st *stringTable) any { return roundedFloat(item.RRFScore) }},
+	{FieldCorpusSize, func(item Collocation, st *stringTable) any { return item.CorpusSize }},
+	{FieldMI, func(item Collocation, st *stringTable) any { return roundedFloat(item.MI) }},
+	{FieldMI3, func(item Collocation, st *stringTable) any { return roundedFloat(item.MI3) }},
+	{FieldDice, func(item Collocation, st *stringTable) any { return roundedFloat(item.Dice) }},
+	{FieldMinSensitivity, func(item Collocation, st *stringTable) any { return roundedFloat(item.MinSensitivity) }},
+	{FieldFreq, func(item Collocation, st *stringTable) any { return int64(item.Freq) }},
+	{FieldLemmaFreq, func(item Collocation, st *stringTable) any { return int64(item.LemmaFreq) }},
+	{FieldCollocateFreq, func(item Collocation, st *stringTable) any { return int64(item.CollocateFreq) }},
+	{FieldFreqIPM, func(item Collocation, st *stringTable) any { return roundedFloat(item.FreqIPM) }},
+	{FieldLemmaFreqIPM, func(item Collocation, st *stringTable) any { return roundedFloat(item.LemmaFreqIPM) }},
+	{FieldCollocateFreqIPM, func(item Collocation, st *stringTable) any { return roundedFloat(item.CollocateFreqIPM) }},
+	{FieldDeltaPCollocate, func(item Collocation, st *stringTable) any { return roundedFloat(item.DeltaPCollocate) }},
+	{FieldDeltaPNode, func(item Collocation, st *stringTable) any { return roundedFloat(item.DeltaPNode) }},
+}
+
+// stringTable assigns each distinct string a stable index
+type stringTable struct {
+	values  []string
+	indices map[string]int
+}
+
+func (st *stringTable) index(v string) int {
+	idx, ok := st.indices[v]
+	if !ok {
+		idx = len(st.values)
+		st.values = append(st.values, v)
+		st.indices[v] = idx
+	}
+	return idx
+}
+
+// CompactCollocations is an alternative JSON representation
+// of a list of collocations where all the readable values
+// (lemmas, PoS, deprels, text types) are stored just once in a string
+// table and the rows refer to them by their indices. For large
+// results, this makes the encoded data much smaller.
+type CompactCollocations struct {
+	Strings []string `json:"strings"`
+	Fields  []string `json:"fields"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// NewCompactCollocations creates a compact representation
+// of the provided collocations. The selected fields are taken
+// from the first item (all the items of a result share them).
+func NewCompactCollocations(items []Collocation) CompactCollocations {
+	st := stringTable{indices: make(map[string]int)}
+	var selected []ResultField
+	if len(items) > 0 {
+		selected = items[0].Fields
+	}
+	ans := CompactCollocations{
+		Fields: make([]string, 0, len(compactColumns)),
+		Rows:   make([][]any, len(items)),
+	}
+	columns := make([]compactColumn, 0, len(compactColumns))
+	for i, col := range compactColumns {
+		if col.field == "" || hasField(selected, col.field) {
+			columns = append(columns, col)
+			ans.Fields = append(ans.Fields, CompactCollocationFields[i])
+		}
+	}
+	for i, item := range items {
+		ans.Rows[i] = make([]any, len(columns))
+		for j, col := range columns {
+			ans.Rows[i][j] = col.value(item, &st)
+		}
+	}
+	ans.Strings = st.values
+	if ans.Strings == nil {
+		ans.Strings = []string{}
+	}
+	return ans
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCompactCollocations(t *testing.T) {
+	items := []Collocation{
+		{
+			Lemma:      CollMember{Value: "dog", PoS: "NOUN"},
+			Collocate:  CollMember{Value: "big", PoS: "ADJ"},
+			Deprel:     "amod",
+			LogDice:    10.12345,
+			IsHead:     true,
+			MutualDist: 1,
+			CorpusSize: 100,
+		},
+		{
+			Lemma:      CollMember{Value: "dog", PoS: "NOUN"},
+			Collocate:  CollMember{Value: "bark", PoS: "VERB"},
+			Deprel:     "nsubj",
+			MutualDist: 1,
+			CorpusSize: 100,
+		},
+	}
+	ans := NewCompactCollocations(items)
+	assert.Equal(t, []string{"dog", "NOUN", "big", "ADJ", "amod", "", "bark", "VERB", "nsubj"}, ans.Strings)
+	assert.Len(t, ans.Rows, 2)
+	assert.Equal(t, []any{0, 1, 6, 7, 8, 5}, ans.Rows[1][:6])
+
+	data, err := json.Marshal(ans)
+	assert.NoError(t, err)
+	var decoded struct {
+		Rows [][]any `json:"rows"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, 10.123, decoded.Rows[0][7])
+	assert.Equal(t, true, decoded.Rows[0][6])
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// zeroFreqSmoothing replaces zero frequencies
+	// when calculating log-ratio
+	zeroFreqSmoothing = 0.5
+)
+
+var ErrUnknownTextType = errors.New("unknown text type")
+
+// ComparisonArgs configures a comparison of two collocation
+// profiles of a lemma (see CompareCollocations).
+type ComparisonArgs struct {
+
+	// Limit, if positive, specifies max. number of returned collocates
+	// typical for each of the compared profiles
+	Limit int
+
+	// MinFreq, if positive, removes collocates with F(x,y)
+	// summed over both the profiles lower than the value
+	MinFreq int
+
+	// MinLogLikelihood, if positive, removes collocates with
+	// the frequency difference less significant than the value
+	// (e.g. 3.84 for p < 0.05)
+	MinLogLikelihood float64
+}
+
+// CollocationDiff describes a collocate found in at least one
+// of two compared collocation profiles (A and B).
+type CollocationDiff struct {
+	Lemma     CollMember `json:"lemma"`
+	Collocate CollMember `json:"collocate"`
+	Deprel    string     `json:"deprel"`
+	IsHead    bool       `json:"isHead"`
+
+	// FreqA and FreqB are F(x,y) in the respective profiles
+	FreqA int `json:"freqA"`
+	FreqB int `json:"freqB"`
+
+	// RankA and RankB are positions (starting from 1) of the
+	// collocate in the respective profiles ranked by the searches'
+	// measure. Zero means that the collocate is missing.
+	RankA int `json:"rankA"`
+	RankB int `json:"rankB"`
+
+	// LogRatio is a binary logarithm of the ratio of relative
+	// frequencies of the collocate in A and B (positive values
+	// mean the collocate is typical for A). Zero frequencies are
+	// replaced by 0.5.
+	LogRatio float64 `json:"logRatio"`
+
+	// LogLikelihood is a log-likelihood of the frequency difference
+	LogLikelihood float64 `json:"logLikelihood"`
+}
+
+type profileItemKey struct {
+	lemma        string
+	collocate    string
+	collocatePoS string
+	deprel       string
+	isHead       bool
+}
+
+type profileItem struct {
+	colloc Collocation
+	freq   int
+	rank   int
+}
+
+// collocationProfile contains all the collocations found
+// by a search (with frequencies summed over text types)
+type collocationProfile struct {
+	items     map[profileItemKey]*profileItem
+	totalFreq int
+}
+
+func findCollocationProfile(ctx context.Context, db *DB, args CalculationArgs) (collocationProfile, error) {
+	args.Limit = math.MaxInt32
+	args.Offset = 0
+	args.LimitPerVariant = false
+	args.TotalCount = nil
+	args.VariantSummary = nil
+	args.CategoryLexicon = nil
+	args.CategoryProfile = nil
+	items, err := db.CalculateMeasures(ctx, args)
+	if err != nil {
+		return collocationProfile{}, err
+	}
+	ans := collocationProfile{items: make(map[profileItemKey]*profileItem)}
+	for _, item := range items {
+		key := profileItemKey{
+			lemma:        item.Lemma.Value,
+			collocate:    item.Collocate.Value,
+			collocatePoS: item.Collocate.PoS,
+			deprel:       item.Deprel,
+			isHead:       item.IsHead,
+		}
+		pItem, ok := ans.items[key]
+		if !ok {
+			pItem = &profileItem{colloc: item, rank: len(ans.items) + 1}
+			ans.items[key] = pItem
+		}
+		pItem.freq += item.Freq
+		ans.totalFreq += item.Freq
+	}
+	return ans, nil
+}
+
+// diffLogRatio calculates the log-ratio of relative frequencies
+// a/c and b/d (https://doi.org/10.1075/ijcl.19.3.05har)
+func diffLogRatio(a, b, c, d float64) float64 {
+	return math.Log2((math.Max(a, zeroFreqSmoothing) / c) / (math.Max(b, zeroFreqSmoothing) / d))
+}
+
+// diffLLScore calculates the log-likelihood of the difference
+// between frequencies a and b in samples of sizes c and d
+// (https://doi.org/10.3115/1117729.1117730)
+func diffLLScore(a, b, c, d float64) float64 {
+	e1 := c * (a + b) / (c + d)
+	e2 := d * (a + b) / (c + d)
+	var ans float64
+	if a > 0 {
+		ans += a * math.Log(a/e1)
+	}
+	if b > 0 {
+		ans += b * math.Log(b/e2)
+	}
+	return 2 * ans
+}
+
+// CompareCollocations compares collocation profiles of a lemma found by
+// the search argsA in dbA and the search argsB in dbB (which can be the
+// same database, e.g. with different text types). Limits and paging of
+// the searches are ignored as the whole profiles are needed.
+// Relative frequencies of collocates are based on the sums of F(x,y)
+// of all the found collocations in each profile.
+//
+// The result is ordered by the log-ratio so collocates typical for A
+// are at the beginning and the ones typical for B are at the end.
+// ComparisonArgs.Limit applies to each of the parts separately.
+// In case any of the profiles is empty, the result is empty too.
+func CompareCollocations(
+	ctx context.Context,
+	dbA *DB,
+	argsA CalculationArgs,
+	dbB *DB,
+	argsB CalculationArgs,
+	cmpArgs ComparisonArgs,
+) ([]CollocationDiff, error) {
+	if cmpArgs.Limit < 0 {
+		panic("CompareCollocations - invalid limit value")
+	}
+	profA, err := findCollocationProfile(ctx, dbA, argsA)
+	if err != nil {
+		return []CollocationDiff{}, fmt.Errorf("failed to compare collocations: %w", err)
+	}
+	profB, err := findCollocationProfile(ctx, dbB, argsB)
+	if err != nil {
+		return []CollocationDiff{}, fmt.Errorf("failed to compare collocations: %w", err)
+	}
+	if profA.totalFreq == 0 || profB.totalFreq == 0 {
+		return []CollocationDiff{}, nil
+	}
+	ans := make([]CollocationDiff, 0, len(profA.items)+len(profB.items))
+	addItem := func(key profileItemKey, itemA, itemB *profileItem) {
+		var diff CollocationDiff
+		if itemA != nil {
+			diff.Lemma = itemA.colloc.Lemma
+			diff.Collocate = itemA.colloc.Collocate
+			diff.FreqA = itemA.freq
+			diff.RankA = itemA.rank
+
+		} else {
+			diff.Lemma = itemB.colloc.Lemma
+			diff.Collocate = itemB.colloc.Collocate
+		}
+		if itemB != nil {
+			diff.FreqB = itemB.freq
+			diff.RankB = itemB.rank
+		}
+		diff.Deprel = key.deprel
+		diff.IsHead = key.isHead
+		if diff.FreqA+diff.FreqB < cmpArgs.MinFreq {
+			return
+		}
+		a, b := float64(diff.FreqA), float64(diff.FreqB)
+		c, d := float64(profA.totalFreq), float64(profB.totalFreq)
+		diff.LogLikelihood = diffLLScore(a, b, c, d)
+		if diff.LogLikelihood < cmpArgs.MinLogLikelihood {
+			return
+		}
+		diff.LogRatio = diffLogRatio(a, b, c, d)
+		ans = append(ans, diff)
+	}
+	for key, itemA := range profA.items {
+		addItem(key, itemA, profB.items[key])
+	}
+	for key, itemB := range profB.items {
+		if _, ok := profA.items[key]; !ok {
+			addItem(key, nil, itemB)
+		}
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		if ans[i].LogRatio != ans[j].LogRatio {
+			return ans[i].LogRatio > ans[j].LogRatio
+		}
+		if ans[i].LogLikelihood != ans[j].LogLikelihood {
+			return ans[i].LogLikelihood > ans[j].LogLikelihood
+		}
+		return ans[i].Collocate.Value < ans[j].Collocate.Value
+	})
+	if cmpArgs.Limit > 0 {
+		ans = limitComparedParts(ans, cmpArgs.Limit)
+	}
+	return ans, nil
+}
+
+// limitComparedParts keeps at most limit leading items with positive
+// log-ratio and limit trailing items with non-positive log-ratio
+func limitComparedParts(items []CollocationDiff, limit int) []CollocationDiff {
+	split := sort.Search(len(items), func(i int) bool {
+		return items[i].LogRatio <= 0
+	})
+	partA := items[:min(split, limit)]
+	partB := items[split:]
+	if len(partB) > limit {
+		partB = partB[len(partB)-limit:]
+	}
+	return append(partA[:len(partA):len(partA)], partB...)
+}
+
+// CompareTextTypes compares collocation profiles of the searched lemma
+// in two text types (see CompareCollocations). Text types specified
+// by args are replaced.
+func (db *DB) CompareTextTypes(
+	ctx context.Context,
+	args CalculationArgs,
+	textTypeA, textTypeB string,
+	cmpArgs ComparisonArgs,
+) ([]CollocationDiff, error) {
+	for _, tt := range []string{textTypeA, textTypeB} {
+		if db.textTypes.ReadableToRaw(tt) == 0 {
+			return []CollocationDiff{}, fmt.Errorf("failed to compare text types: %w: %s", ErrUnknownTextType, tt)
+		}
+	}
+	argsA := args
+	argsA.TextType = textTypeA
+	argsA.CollocateGroupByTextType = false
+	argsA.TextTypeDims = nil
+	argsB := argsA
+	argsB.TextType = textTypeB
+	return CompareCollocations(ctx, db, argsA, db, argsB, cmpArgs)
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareTextTypes(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "night", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "night", PoS: noun, Freq: 40, TextType: news},
+		"3": {Lemma: "dark", PoS: adj, Freq: 30, TextType: fiction},
+		"4": {Lemma: "dark", PoS: adj, Freq: 5, TextType: news},
+		"5": {Lemma: "election", PoS: noun, Freq: 20, TextType: news},
+		"6": {Lemma: "last", PoS: adj, Freq: 30, TextType: fiction},
+		"7": {Lemma: "last", PoS: adj, Freq: 30, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "night", PoS1: noun, Lemma2: "dark", PoS2: adj, Freq: 18, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "night", PoS1: noun, Lemma2: "dark", PoS2: adj, Freq: 2, AVGDist: 1, TextType: news},
+		"3": {Lemma1: "night", PoS1: noun, Lemma2: "election", PoS2: noun, Freq: 10, AVGDist: 1, TextType: news},
+		"4": {Lemma1: "night", PoS1: noun, Lemma2: "last", PoS2: adj, Freq: 10, AVGDist: 1, TextType: fiction},
+		"5": {Lemma1: "night", PoS1: noun, Lemma2: "last", PoS2: adj, Freq: 10, AVGDist: 1, TextType: news},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{Lemma: "night", Limit: 1, SortBy: sortByLogDice}
+	ans, err := db.CompareTextTypes(context.Background(), args, "fiction", "news", ComparisonArgs{})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 3)
+	// profile sizes: fiction = 28, news = 22
+	assert.Equal(t, "dark", ans[0].Collocate.Value)
+	assert.Equal(t, 18, ans[0].FreqA)
+	assert.Equal(t, 2, ans[0].FreqB)
+	assert.Equal(t, 1, ans[0].RankA)
+	assert.InDelta(t, math.Log2((18.0/28)/(2.0/22)), ans[0].LogRatio, 0.0001)
+	assert.Greater(t, ans[0].LogLikelihood, 0.0)
+	assert.Equal(t, "last", ans[1].Collocate.Value)
+	assert.Equal(t, "election", ans[2].Collocate.Value)
+	assert.Equal(t, 0, ans[2].FreqA)
+	assert.Equal(t, 0, ans[2].RankA)
+	assert.InDelta(t, math.Log2((0.5/28)/(10.0/22)), ans[2].LogRatio, 0.0001)
+
+	ans, err = db.CompareTextTypes(
+		context.Background(), args, "fiction", "news", ComparisonArgs{Limit: 1, MinLogLikelihood: 0.1})
+	assert.NoError(t, err)
+	if assert.Len(t, ans, 2) {
+		assert.Equal(t, "dark", ans[0].Collocate.Value)
+		assert.Equal(t, "election", ans[1].Collocate.Value)
+	}
+
+	_, err = db.CompareTextTypes(context.Background(), args, "fiction", "poetry", ComparisonArgs{})
+	assert.ErrorIs(t, err, ErrUnknownTextType)
+}
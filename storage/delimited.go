@@ -0,0 +1,82 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WriteCollocationsDelimited writes the collocations as CSV
+// (or as TSV in case comma is '\t') with a header row. The columns
+// and their order are the same as in CompactCollocations (incl. the
+// selected fields), only the string values are written directly
+// and measures are rounded the same way as in the JSON output.
+// Values are quoted according to RFC 4180 where needed.
+func WriteCollocationsDelimited(w io.Writer, items []Collocation, comma rune) error {
+	var selected []ResultField
+	if len(items) > 0 {
+		selected = items[0].Fields
+	}
+	header := make([]string, 0, len(compactColumns))
+	columns := make([]compactColumn, 0, len(compactColumns))
+	for i, col := range compactColumns {
+		if col.field == "" || hasField(selected, col.field) {
+			columns = append(columns, col)
+			header = append(header, strings.TrimPrefix(CompactCollocationFields[i], "@"))
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write collocations: %w", err)
+	}
+	st := stringTable{indices: make(map[string]int)}
+	row := make([]string, len(columns))
+	for _, item := range items {
+		for j, col := range columns {
+			row[j] = delimitedValue(col.value(item, &st), &st)
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write collocations: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write collocations: %w", err)
+	}
+	return nil
+}
+
+// delimitedValue converts a compact column value to its textual form.
+// String table indices are resolved back to the strings.
+func delimitedValue(v any, st *stringTable) string {
+	switch tv := v.(type) {
+	case int:
+		return st.values[tv]
+	case roundedFloat:
+		return strconv.FormatFloat(math.Round(float64(tv)*1000)/1000, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(tv)
+	default:
+		return fmt.Sprint(tv)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCollocationsDelimited(t *testing.T) {
+	items := []Collocation{
+		{
+			Lemma:      CollMember{Value: "dog", PoS: "NOUN"},
+			Collocate:  CollMember{Value: "big, old", PoS: "ADJ"},
+			Deprel:     "amod",
+			LogDice:    10.12345,
+			IsHead:     true,
+			MutualDist: 1,
+			CorpusSize: 100,
+			Fields:     []ResultField{FieldLogDice, FieldCorpusSize},
+		},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, WriteCollocationsDelimited(&buf, items, ','))
+	assert.Equal(
+		t,
+		"lemma,lemmaPos,collocate,collocatePos,deprel,isHead,logDice,corpusSize\n"+
+			"dog,NOUN,\"big, old\",ADJ,amod,true,10.123,100\n",
+		buf.String(),
+	)
+
+	buf.Reset()
+	items[0].Fields = nil
+	assert.NoError(t, WriteCollocationsDelimited(&buf, items, '\t'))
+	assert.Equal(
+		t,
+		"lemma\tlemmaPos\tcollocate\tcollocatePos\tdeprel\ttextType\tisHead\tlogDice\ttScore\t"+
+			"mutualDist\tsurfaceDist\tlmi\tlogLikelihood\trrfScore\tcorpusSize\tmi\tmi3\tdice\tminSensitivity\t"+
+			"freq\tlemmaFreq\tcollocateFreq\tfreqIpm\tlemmaFreqIpm\tcollocateFreqIpm\tdeltaPCollocate\tdeltaPNode\n"+
+			"dog\tNOUN\tbig, old\tADJ\tamod\t\ttrue\t10.123\t0\t1\t0\t0\t0\t0\t100\t0\t0\t0\t0\t0\t0\t0\t0\t0\t0\t0\t0\n",
+		buf.String(),
+	)
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// collKeyDeprelOffset returns a position of the deprel code within
+// a collocation key of the layout (see record.KeyLayout.CollFreqKey)
+func collKeyDeprelOffset(keys record.KeyLayout) int {
+	return 3 + keys.TokenIDWidth()
+}
+
+// deprelSeeker allows iterating over collocation records of a token
+// with only the required relations. As the relation is stored after
+// PoS and text type in the key, records of other relations cannot be
+// excluded by a key prefix. Instead, the iterator jumps over them -
+// for each (PoS, text type) group, it seeks directly to the required
+// relations.
+type deprelSeeker struct {
+	// codes are encoded deprel codes in their key byte order
+	codes [][]byte
+
+	// deprelOffset is a position of the deprel code within keys
+	deprelOffset int
+}
+
+func newDeprelSeeker(codes []uint16, keys record.KeyLayout) *deprelSeeker {
+	ans := &deprelSeeker{
+		codes:        make([][]byte, 0, len(codes)),
+		deprelOffset: collKeyDeprelOffset(keys),
+	}
+	for _, c := range codes {
+		enc := binary.LittleEndian.AppendUint16(nil, c)
+		if !slices.ContainsFunc(ans.codes, func(v []byte) bool { return bytes.Equal(v, enc) }) {
+			ans.codes = append(ans.codes, enc)
+		}
+	}
+	slices.SortFunc(ans.codes, bytes.Compare)
+	return ans
+}
+
+// nextKey tests whether the collocation key contains one of the required
+// relations. If not, the smallest key possibly containing a required
+// relation and following the provided key is returned. A nil key means
+// there are no such keys for the token.
+func (ds *deprelSeeker) nextKey(key []byte) (bool, []byte) {
+	off := ds.deprelOffset
+	curr := key[off : off+2]
+	idx, found := slices.BinarySearchFunc(ds.codes, curr, bytes.Compare)
+	if found {
+		return true, nil
+	}
+	ans := make([]byte, off, off+2)
+	copy(ans, key[:off])
+	if idx < len(ds.codes) {
+		return false, append(ans, ds.codes[idx]...)
+	}
+	// move to the next (PoS, text type) group
+	group := binary.BigEndian.Uint16(ans[off-2 : off])
+	if group == 0xffff {
+		return false, nil
+	}
+	binary.BigEndian.PutUint16(ans[off-2:off], group+1)
+	return false, append(ans, ds.codes[0]...)
+}
+
+// seek moves the iterator to the nearest record (starting from the current
+// one) with a required relation. The returned value tells whether such
+// record has been found.
+func (ds *deprelSeeker) seek(it *badger.Iterator) bool {
+	for it.Valid() {
+		match, next := ds.nextKey(it.Item().Key())
+		if match {
+			return true
+		}
+		if next == nil {
+			return false
+		}
+		it.Seek(next)
+	}
+	return false
+}
+
+// deprelCodes translates relation names into codes stored in the database.
+// With the core granularity, a core relation matches also all its subtypes.
+func (db *DB) deprelCodes(names []string, gran DeprelGranularity) ([]uint16, error) {
+	mapping := db.DeprelMapping
+	if mapping == nil {
+		mapping = &record.UDDeprelMapping
+	}
+	ans := make([]uint16, 0, len(names))
+	for _, name := range names {
+		v, ok := mapping.Get(strings.ToLower(name))
+		if !ok {
+			return nil, fmt.Errorf("%w: deprel %s", ErrUnknownFilterValue, name)
+		}
+		ans = append(ans, v)
+	}
+	if gran == DeprelGranularityCore {
+		selected := slices.Clone(ans)
+		for _, v := range mapping.AsMap() {
+			if slices.Contains(selected, mapping.CoreOf(v)) && !slices.Contains(selected, v) {
+				ans = append(ans, v)
+			}
+		}
+	}
+	return ans, nil
+}
@@ -0,0 +1,300 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrStopDump can be returned by a callback of DB.Dump
+// to finish the dump without an error
+var ErrStopDump = errors.New("dump stopped")
+
+// DumpArgs specifies which database records are dumped by DB.Dump
+type DumpArgs struct {
+
+	// Namespaces, if non-empty, restricts the dump to the listed
+	// record types (see record.KeyNamespace)
+	Namespaces []string
+
+	// KeyPrefix, if non-empty, restricts the dump to records
+	// with keys starting with the prefix
+	KeyPrefix []byte
+}
+
+// DumpedRecord is a decoded database record intended for inspection
+// of stored data. Only the attributes relevant to the record type
+// are filled in. Records which cannot be decoded (e.g. unknown keys
+// or corrupted data) have Error set and contain the raw value.
+type DumpedRecord struct {
+	Namespace   string          `json:"ns"`
+	Key         string          `json:"key"`
+	TokenID     uint64          `json:"tokenId,omitempty"`
+	Lemma       string          `json:"lemma,omitempty"`
+	Folded      string          `json:"folded,omitempty"`
+	PoS         string          `json:"pos,omitempty"`
+	TextType    string          `json:"textType,omitempty"`
+	Deprel      string          `json:"deprel,omitempty"`
+	IsHead      bool            `json:"isHead,omitempty"`
+	Token2ID    uint64          `json:"token2Id,omitempty"`
+	Lemma2      string          `json:"lemma2,omitempty"`
+	PoS2        string          `json:"pos2,omitempty"`
+	Feats       string          `json:"feats,omitempty"`
+	Freq        uint32          `json:"freq,omitempty"`
+	Dist        float64         `json:"dist,omitempty"`
+	SurfaceDist *float64        `json:"surfaceDist,omitempty"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	Value       string          `json:"value,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// dumpKeyPrefixes returns key prefixes to be iterated over to dump
+// records matching args
+func dumpKeyPrefixes(args DumpArgs) ([][]byte, error) {
+	if len(args.Namespaces) == 0 {
+		return [][]byte{args.KeyPrefix}, nil
+	}
+	ans := make([][]byte, 0, len(args.Namespaces))
+	for _, ns := range args.Namespaces {
+		prefix, ok := record.NamespaceKeyPrefix(ns)
+		if !ok {
+			return nil, fmt.Errorf("unknown key namespace: %s", ns)
+		}
+		switch {
+		case bytes.HasPrefix(args.KeyPrefix, prefix):
+			ans = append(ans, args.KeyPrefix)
+		case bytes.HasPrefix(prefix, args.KeyPrefix):
+			ans = append(ans, prefix)
+		}
+	}
+	return ans, nil
+}
+
+// dumpDecoder decodes raw records within a single transaction.
+// Lemmas of token IDs are resolved via the reverse index.
+type dumpDecoder struct {
+	db     *DB
+	txn    *badger.Txn
+	lemmas map[uint64]string
+}
+
+func (dd *dumpDecoder) lemma(tokenID uint64) string {
+	if v, ok := dd.lemmas[tokenID]; ok {
+		return v
+	}
+	if v, ok := dd.db.lemmaCache.get(tokenID); ok {
+		return v
+	}
+	var ans string
+	item, err := dd.txn.Get(dd.db.keys.TokenIDToRevIndexKey(tokenID))
+	if err == nil {
+		ans, _ = readItemValue(item, DecodeLemma)
+	}
+	dd.lemmas[tokenID] = ans
+	return ans
+}
+
+func (dd *dumpDecoder) textType(raw byte) string {
+	if dd.db.textTypes == nil {
+		return ""
+	}
+	return dd.db.textTypes.RawToReadable(raw)
+}
+
+func (dd *dumpDecoder) deprel(raw uint16) string {
+	if dd.db.DeprelMapping == nil {
+		return ""
+	}
+	return dd.db.DeprelMapping.GetRev(raw)
+}
+
+// decode fills in the record attributes based on the key and the value.
+// In case of unexpected lengths (which would make the record package
+// decoders to panic), false is returned.
+func (dd *dumpDecoder) decode(rec *DumpedRecord, key, val []byte) bool {
+	keys := dd.db.keys
+	tokenKeyLen := len(keys.TokenFreqKey(0, 0, 0))
+	collKeyLen := len(keys.CollFreqKey(true, 0, 0, 0, 0, 0, 0))
+	switch rec.Namespace {
+	case "metadata":
+		if !json.Valid(val) {
+			return false
+		}
+		rec.Metadata = json.RawMessage(bytes.Clone(val))
+	case "lemmaToID":
+		tokenID, err := keys.DecodeTokenID(val)
+		if err != nil {
+			return false
+		}
+		rec.Lemma = string(key[1:])
+		rec.TokenID = tokenID
+	case "foldedLemmaToID", "lowerLemmaToID":
+		idx := bytes.IndexByte(key, 0x00)
+		tokenID, err := keys.DecodeTokenID(val)
+		if idx < 0 || err != nil {
+			return false
+		}
+		rec.Folded = string(key[1:idx])
+		rec.Lemma = record.DecodeFoldedLemmaKey(key)
+		rec.TokenID = tokenID
+	case "idToLemma":
+		if len(key) != 1+keys.TokenIDWidth() {
+			return false
+		}
+		rec.TokenID = keys.DecodeRevIndexKey(key)
+		rec.Lemma = DecodeLemma(val)
+	case "tokenFreq", "tokenRollup":
+		var decKey record.DecodedKey
+		var err error
+		if rec.Namespace == "tokenFreq" && (len(key) == tokenKeyLen || len(key) == tokenKeyLen+4) {
+			decKey, err = keys.DecodeTokenFreqKey(key)
+			rec.TextType = dd.textType(decKey.TextType)
+			rec.Feats = decKey.Feats.String()
+
+		} else if rec.Namespace == "tokenRollup" {
+			decKey, err = keys.DecodeTokenFreqRollupKey(key)
+
+		} else {
+			return false
+		}
+		tokenValue, valErr := record.DecodeTokenValue(val)
+		if err != nil || valErr != nil {
+			return false
+		}
+		rec.TokenID = decKey.Token1ID
+		rec.Lemma = dd.lemma(decKey.Token1ID)
+		rec.PoS = record.UDPosFromByte(decKey.Pos1).Readable
+		rec.Freq = tokenValue.Freq
+	case "pairFreq", "revPairFreq", "hotPairFreq", "hotRevPairFreq", "pathPairFreq", "revPathPairFreq":
+		if len(key) != collKeyLen && len(key) != collKeyLen+4 {
+			return false
+		}
+		decKey, err := keys.DecodeCollFreqKey(key)
+		if err != nil {
+			return false
+		}
+		collValue, err := record.DecodeCollocValue(val)
+		if err != nil {
+			return false
+		}
+		rec.TokenID = decKey.Token1ID
+		rec.Lemma = dd.lemma(decKey.Token1ID)
+		rec.PoS = record.UDPosFromByte(decKey.Pos1).Readable
+		rec.TextType = dd.textType(decKey.TextType)
+		rec.Deprel = dd.deprel(decKey.Deprel)
+		rec.IsHead = decKey.IsHead
+		rec.Token2ID = decKey.Token2ID
+		rec.Lemma2 = dd.lemma(decKey.Token2ID)
+		rec.PoS2 = record.UDPosFromByte(decKey.Pos2).Readable
+		rec.Feats = decKey.Feats.String()
+		rec.Freq = collValue.Freq
+		rec.Dist = collValue.Dist
+		if collValue.HasSurfaceDist {
+			rec.SurfaceDist = &collValue.SurfaceDist
+		}
+	case "pairExamples":
+		token1ID, token2ID, err := keys.DecodePairExamplesKey(key)
+		if err != nil {
+			return false
+		}
+		examples, err := record.DecodePairExamples(val)
+		if err != nil {
+			return false
+		}
+		rec.TokenID = token1ID
+		rec.Lemma = dd.lemma(token1ID)
+		rec.Token2ID = token2ID
+		rec.Lemma2 = dd.lemma(token2ID)
+		rec.Value = formatPairExamples(examples)
+	case "topColls":
+		if len(key) < 3+keys.TokenIDWidth() {
+			return false
+		}
+		rec.TokenID = keys.DecodeRevIndexKey(key)
+		rec.Lemma = dd.lemma(rec.TokenID)
+		rec.Value = hex.EncodeToString(val)
+	default:
+		return false
+	}
+	return true
+}
+
+// formatPairExamples creates a readable representation of pair
+// examples (a list of "position:collocatePosition" items)
+func formatPairExamples(examples []record.PairExample) string {
+	items := make([]string, len(examples))
+	for i, ex := range examples {
+		items[i] = fmt.Sprintf("%d:%d", ex.Position, ex.CollocatePosition)
+	}
+	return strings.Join(items, ",")
+}
+
+// Dump walks through database records matching args (ordered by keys)
+// and passes them decoded to fn. The dump is intended for debugging
+// of unexpected or corrupted data so records which cannot be decoded
+// are passed too (with Error set). To finish the dump early,
+// fn can return ErrStopDump. As in case of UnknownKeyPrefixes, current
+// data are always used, even if a snapshot is pinned.
+func (db *DB) Dump(args DumpArgs, fn func(rec DumpedRecord) error) error {
+	prefixes, err := dumpKeyPrefixes(args)
+	if err != nil {
+		return fmt.Errorf("failed to dump database: %w", err)
+	}
+	err = db.bdb.View(func(txn *badger.Txn) error {
+		dec := &dumpDecoder{db: db, txn: txn, lemmas: make(map[uint64]string)}
+		for _, prefix := range prefixes {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = prefix
+			it := txn.NewIterator(opts)
+			for it.Rewind(); it.Valid(); it.Next() {
+				item := it.Item()
+				key := item.Key()
+				val, err := item.ValueCopy(nil)
+				if err != nil {
+					it.Close()
+					return err
+				}
+				rec := DumpedRecord{
+					Namespace: record.KeyNamespace(key),
+					Key:       hex.EncodeToString(key),
+				}
+				if !dec.decode(&rec, key, val) {
+					rec.Value = hex.EncodeToString(val)
+					rec.Error = "cannot decode record"
+				}
+				if err := fn(rec); err != nil {
+					it.Close()
+					return err
+				}
+			}
+			it.Close()
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrStopDump) {
+		return fmt.Errorf("failed to dump database: %w", err)
+	}
+	return nil
+}
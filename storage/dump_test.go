@@ -0,0 +1,111 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func dumpAll(t *testing.T, db *DB, args DumpArgs) []DumpedRecord {
+	var ans []DumpedRecord
+	err := db.Dump(args, func(rec DumpedRecord) error {
+		ans = append(ans, rec)
+		return nil
+	})
+	assert.NoError(t, err)
+	return ans
+}
+
+func TestDump(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	obl := record.ImportUDDeprel("obl")
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Deprel: obl, Freq: 6, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, db.StoreMetadata(Metadata{CorpusSize: 100}))
+
+	ans := dumpAll(t, db, DumpArgs{Namespaces: []string{"metadata", "tokenFreq"}})
+	assert.Len(t, ans, 3)
+	assert.Equal(t, "metadata", ans[0].Namespace)
+	assert.Contains(t, string(ans[0].Metadata), `"corpusSize":100`)
+	for _, rec := range ans[1:] {
+		assert.Equal(t, "tokenFreq", rec.Namespace)
+		assert.Equal(t, "fiction", rec.TextType)
+		assert.Empty(t, rec.Error)
+	}
+	assert.ElementsMatch(t, []string{"monday", "work"}, []string{ans[1].Lemma, ans[2].Lemma})
+
+	ans = dumpAll(t, db, DumpArgs{Namespaces: []string{"pairFreq", "revPairFreq"}})
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "pairFreq", ans[0].Namespace)
+	assert.Equal(t, "obl", ans[0].Deprel)
+	assert.Equal(t, uint32(6), ans[0].Freq)
+	assert.True(t, ans[0].IsHead)
+	assert.Equal(t, "monday", ans[0].Lemma)
+	assert.Equal(t, "work", ans[0].Lemma2)
+	assert.Equal(t, "VERB", ans[0].PoS2)
+
+	// key prefix must be combined with namespaces
+	ans = dumpAll(t, db, DumpArgs{Namespaces: []string{"pairFreq", "tokenFreq"}, KeyPrefix: []byte{0x05}})
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "pairFreq", ans[0].Namespace)
+
+	err = db.Dump(DumpArgs{Namespaces: []string{"foo"}}, func(rec DumpedRecord) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestDumpUndecodableRecords(t *testing.T) {
+	db := openTestDB(t)
+	err := db.bdb.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte{0xf0, 0x01}, []byte{0xab}); err != nil {
+			return err
+		}
+		return txn.Set(db.keys.TokenFreqKey(1, record.PosNOUN, 0x01), []byte{0x01})
+	})
+	assert.NoError(t, err)
+
+	ans := dumpAll(t, db, DumpArgs{})
+	assert.Len(t, ans, 2)
+	assert.Equal(t, "tokenFreq", ans[0].Namespace)
+	assert.Equal(t, "01", ans[0].Value)
+	assert.NotEmpty(t, ans[0].Error)
+	assert.Equal(t, "", ans[1].Namespace)
+	assert.Equal(t, "f001", ans[1].Key)
+	assert.Equal(t, "ab", ans[1].Value)
+
+	var numCalls int
+	err = db.Dump(DumpArgs{}, func(rec DumpedRecord) error {
+		numCalls++
+		return ErrStopDump
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, numCalls)
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// pairExamplesKey identifies examples of a pair of tokens
+// regardless of other properties of the pair records
+type pairExamplesKey struct {
+	token1ID uint64
+	token2ID uint64
+}
+
+// SetPairExamplesLimit sets a max. number of examples stored for
+// each pair of tokens (see record.CollocFreq.Examples). With zero
+// (the default), all the provided examples are stored.
+func (db *DB) SetPairExamplesLimit(limit int) {
+	db.pairExamplesLimit = limit
+}
+
+// mergedPairExamples combines stored examples with new ones. Without
+// an explicit limit (see SetPairExamplesLimit), the result has the size
+// of the larger input so the limit applied when the inputs were stored
+// is preserved.
+func (db *DB) mergedPairExamples(curr, examples []record.PairExample) []record.PairExample {
+	limit := db.pairExamplesLimit
+	if limit == 0 {
+		limit = max(len(curr), len(examples))
+	}
+	return record.MergePairExamples(curr, examples, limit)
+}
+
+// readPairExamples reads examples stored under the key. For
+// a missing record, an empty list is returned.
+func readPairExamples(txn *badger.Txn, key []byte) ([]record.PairExample, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return []record.PairExample{}, nil
+	}
+	if err != nil {
+		return []record.PairExample{}, err
+	}
+	return decodeItemValue(item, record.DecodePairExamples)
+}
+
+// storePairExamples writes examples collected for individual pairs
+// of tokens. With merge set to true, the examples are combined with
+// the stored ones.
+func (db *DB) storePairExamples(
+	txn *badger.Txn,
+	w keyValueSetter,
+	examples map[pairExamplesKey][]record.PairExample,
+	merge bool,
+	res *ImportStats,
+) error {
+	for ids, pairExamples := range examples {
+		key := db.keys.PairExamplesKey(ids.token1ID, ids.token2ID)
+		created := true
+		if merge {
+			curr, err := readPairExamples(txn, key)
+			if err != nil {
+				return fmt.Errorf("failed to read stored pair examples: %w", err)
+			}
+			created = len(curr) == 0
+			pairExamples = db.mergedPairExamples(curr, pairExamples)
+		}
+		if err := w.Set(key, record.EncodePairExamples(pairExamples)); err != nil {
+			return err
+		}
+		if created {
+			res.NumPairExamples++
+		}
+	}
+	return nil
+}
+
+// GetExamples returns stored occurrences (corpus positions of both
+// the tokens) of the lemma along with the collocate so they can be
+// linked to a concordance. The examples are sorted by their positions.
+// The database must be built with FeaturePairExamples. In case any
+// of the lemmas or the pair is not found, an empty list is returned.
+func (db *DB) GetExamples(lemma, collocate string) ([]record.PairExample, error) {
+	if !db.Metadata.HasFeature(FeaturePairExamples) {
+		return []record.PairExample{}, fmt.Errorf(
+			"failed to get pair examples: %w: %s", ErrFeatureUnavailable, FeaturePairExamples)
+	}
+	ans := []record.PairExample{}
+	err := db.view(func(txn *badger.Txn) error {
+		var tokenIDs [2]uint64
+		for i, v := range []string{lemma, collocate} {
+			item, err := txn.Get(record.EncodeLemmaKey(record.TokenFreq{Lemma: v}))
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			tokenIDs[i], err = decodeItemValue(item, db.keys.DecodeTokenID)
+			if err != nil {
+				return err
+			}
+		}
+		var err error
+		ans, err = readPairExamples(txn, db.keys.PairExamplesKey(tokenIDs[0], tokenIDs[1]))
+		return err
+	})
+	if err != nil {
+		return []record.PairExample{}, fmt.Errorf("failed to get pair examples: %w", err)
+	}
+	return ans, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExamples(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	db.SetPairExamplesLimit(3)
+	tt1 := record.TextType{Raw: 0x01, Readable: "fiction"}
+	tt2 := record.TextType{Raw: 0x02, Readable: "news"}
+	verb := record.UDPosFromByte(record.PosVERB)
+	noun := record.UDPosFromByte(record.PosNOUN)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "read", PoS: verb, Freq: 50, TextType: tt1},
+		"2": {Lemma: "book", PoS: noun, Freq: 40, TextType: tt1},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "read", PoS1: verb, Deprel: record.ImportUDDeprel("obj"), Lemma2: "book", PoS2: noun,
+			Freq: 10, AVGDist: 1, TextType: tt1,
+			Examples: []record.PairExample{{Position: 40, CollocatePosition: 41}, {Position: 50, CollocatePosition: 52}}},
+		"2": {Lemma1: "read", PoS1: verb, Deprel: record.ImportUDDeprel("obj"), Lemma2: "book", PoS2: noun,
+			Freq: 5, AVGDist: 1, TextType: tt2,
+			Examples: []record.PairExample{{Position: 30, CollocatePosition: 31}}},
+	}
+	stats, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.NumPairExamples)
+
+	_, err = db.GetExamples("read", "book")
+	assert.ErrorIs(t, err, ErrFeatureUnavailable)
+	db.Metadata.Features = []DatasetFeature{FeaturePairExamples}
+
+	ans, err := db.GetExamples("read", "book")
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]record.PairExample{
+			{Position: 30, CollocatePosition: 31},
+			{Position: 40, CollocatePosition: 41},
+			{Position: 50, CollocatePosition: 52},
+		},
+		ans,
+	)
+	// appended examples are merged with the stored ones (up to the limit)
+	pairFreqs = map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "read", PoS1: verb, Deprel: record.ImportUDDeprel("obj"), Lemma2: "book", PoS2: noun,
+			Freq: 2, AVGDist: 1, TextType: tt1,
+			Examples: []record.PairExample{{Position: 5, CollocatePosition: 7}}},
+	}
+	stats, err = db.AppendFreqs(map[record.GroupingKey]record.TokenFreq{}, pairFreqs, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.NumPairExamples)
+	ans, err = db.GetExamples("read", "book")
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]record.PairExample{
+			{Position: 5, CollocatePosition: 7},
+			{Position: 30, CollocatePosition: 31},
+			{Position: 40, CollocatePosition: 41},
+		},
+		ans,
+	)
+
+	ans, err = db.GetExamples("book", "read")
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+	ans, err = db.GetExamples("read", "unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrFeatureUnavailable is returned in case a query requires an optional
+// subsystem the database has not been built with.
+var ErrFeatureUnavailable = errors.New("feature not available in the database")
+
+// DatasetFeature identifies an optional subsystem (index, pre-aggregated
+// records etc.) a database can be built with.
+type DatasetFeature string
+
+const (
+
+	// FeatureSurfaceDist - pair records contain linear (word order)
+	// distances of collocates
+	FeatureSurfaceDist DatasetFeature = "surfaceDist"
+
+	// FeatureTokenFreqRollups - single token frequencies summed over
+	// all text types are stored
+	FeatureTokenFreqRollups DatasetFeature = "tokenFreqRollups"
+
+	// FeatureHotLemmaSummaries - frequent lemmas have pre-aggregated
+	// collocation records
+	FeatureHotLemmaSummaries DatasetFeature = "hotLemmaSummaries"
+
+	// FeatureRelationDists - typical distances of individual relations
+	// are stored
+	FeatureRelationDists DatasetFeature = "relationDists"
+
+	// FeatureSiblings - pairs of tokens sharing a head are stored
+	FeatureSiblings DatasetFeature = "siblings"
+
+	// FeatureFoldedLemmas - lemmas are indexed also without diacritics
+	FeatureFoldedLemmas DatasetFeature = "foldedLemmas"
+
+	// FeatureLowerLemmas - lemmas containing uppercase letters are indexed
+	// also lowercased (and without diacritics)
+	FeatureLowerLemmas DatasetFeature = "lowerLemmas"
+
+	// FeatureWordForms - word forms are indexed along with lemmas
+	FeatureWordForms DatasetFeature = "wordForms"
+
+	// FeatureMorphFeats - single token and pair records are split
+	// by selected morphological features (see Metadata.MorphFeats)
+	FeatureMorphFeats DatasetFeature = "morphFeats"
+
+	// FeatureTopCollocations - frequent lemmas have precomputed
+	// top collocations for each sorting measure
+	FeatureTopCollocations DatasetFeature = "topCollocations"
+
+	// FeaturePathCollocations - pairs of tokens connected via other nodes
+	// are stored with their relation path labels (see Metadata.PathCollocationsDepth)
+	FeaturePathCollocations DatasetFeature = "pathCollocations"
+
+	// FeaturePairExamples - pairs have samples of their occurrences
+	// (corpus positions) stored (see DB.GetExamples)
+	FeaturePairExamples DatasetFeature = "pairExamples"
+)
+
+// AllDatasetFeatures contains all the features known to this version
+var AllDatasetFeatures = []DatasetFeature{
+	FeatureSurfaceDist,
+	FeatureTokenFreqRollups,
+	FeatureHotLemmaSummaries,
+	FeatureRelationDists,
+	FeatureSiblings,
+	FeatureFoldedLemmas,
+	FeatureLowerLemmas,
+	FeatureWordForms,
+	FeatureMorphFeats,
+	FeatureTopCollocations,
+	FeaturePathCollocations,
+	FeaturePairExamples,
+}
+
+// HasFeature tells whether the database has been built with the feature.
+// For databases created before features were recorded, the availability
+// is derived from the legacy metadata attributes (features without such
+// attributes are considered unavailable).
+func (m Metadata) HasFeature(f DatasetFeature) bool {
+	if m.Features != nil {
+		return slices.Contains(m.Features, f)
+	}
+	switch f {
+	case FeatureSurfaceDist:
+		return m.SurfaceDist
+	case FeatureTokenFreqRollups:
+		return m.TokenFreqRollups
+	case FeatureHotLemmaSummaries:
+		return m.HotLemmaThreshold > 0
+	case FeatureRelationDists:
+		return len(m.RelationDists) > 0
+	case FeatureSiblings:
+		return m.Siblings
+	}
+	return false
+}
+
+// AvailableFeatures returns all the known features the database
+// has been built with.
+func (m Metadata) AvailableFeatures() []DatasetFeature {
+	ans := make([]DatasetFeature, 0, len(AllDatasetFeatures))
+	for _, f := range AllDatasetFeatures {
+		if m.HasFeature(f) {
+			ans = append(ans, f)
+		}
+	}
+	return ans
+}
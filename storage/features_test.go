@@ -0,0 +1,58 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataHasFeatureLegacy(t *testing.T) {
+	m := Metadata{
+		SurfaceDist:       true,
+		HotLemmaThreshold: 100,
+	}
+	assert.True(t, m.HasFeature(FeatureSurfaceDist))
+	assert.True(t, m.HasFeature(FeatureHotLemmaSummaries))
+	assert.False(t, m.HasFeature(FeatureTokenFreqRollups))
+	assert.False(t, m.HasFeature(FeatureFoldedLemmas))
+	assert.Equal(t, []DatasetFeature{FeatureSurfaceDist, FeatureHotLemmaSummaries}, m.AvailableFeatures())
+}
+
+func TestMetadataHasFeatureRecorded(t *testing.T) {
+	m := Metadata{
+		SurfaceDist: true,
+		Features:    []DatasetFeature{FeatureFoldedLemmas},
+	}
+	assert.False(t, m.HasFeature(FeatureSurfaceDist))
+	assert.True(t, m.HasFeature(FeatureFoldedLemmas))
+}
+
+func TestCalculateMeasuresMissingSurfaceDist(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	_, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:             "work",
+		Limit:             10,
+		SortBy:            sortByLogDice,
+		MaxAvgSurfaceDist: 2,
+	})
+	assert.ErrorIs(t, err, ErrFeatureUnavailable)
+	assert.ErrorIs(t, err, ErrSurfaceDistUnavailable)
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrInvalidResultField is returned for an unknown selected result field
+var ErrInvalidResultField = errors.New("invalid result field")
+
+// ResultField is an optional (selectable) column of a collocation
+// result. The identifying columns (lemma, collocate, deprel, isHead)
+// are always present. The values match the JSON attribute names.
+type ResultField string
+
+const (
+	FieldLogDice       ResultField = "logDice"
+	FieldTScore        ResultField = "tScore"
+	FieldMutualDist    ResultField = "mutualDist"
+	FieldSurfaceDist   ResultField = "surfaceDist"
+	FieldLMI           ResultField = "lmi"
+	FieldLogLikelihood ResultField = "logLikelihood"
+	FieldRRFScore      ResultField = "rrfScore"
+	FieldTextType      ResultField = "textType"
+	FieldCorpusSize    ResultField = "corpusSize"
+
+	FieldMI             ResultField = "mi"
+	FieldMI3            ResultField = "mi3"
+	FieldDice           ResultField = "dice"
+	FieldMinSensitivity ResultField = "minSensitivity"
+
+	FieldDeltaPCollocate ResultField = "deltaPCollocate"
+	FieldDeltaPNode      ResultField = "deltaPNode"
+
+	FieldFreq             ResultField = "freq"
+	FieldLemmaFreq        ResultField = "lemmaFreq"
+	FieldCollocateFreq    ResultField = "collocateFreq"
+	FieldFreqIPM          ResultField = "freqIpm"
+	FieldLemmaFreqIPM     ResultField = "lemmaFreqIpm"
+	FieldCollocateFreqIPM ResultField = "collocateFreqIpm"
+)
+
+// ResultFields lists all the selectable result fields
+var ResultFields = []ResultField{
+	FieldLogDice, FieldTScore, FieldMutualDist, FieldSurfaceDist, FieldLMI,
+	FieldLogLikelihood, FieldRRFScore, FieldTextType, FieldCorpusSize,
+	FieldMI, FieldMI3, FieldDice, FieldMinSensitivity,
+	FieldDeltaPCollocate, FieldDeltaPNode,
+	FieldFreq, FieldLemmaFreq, FieldCollocateFreq,
+	FieldFreqIPM, FieldLemmaFreqIPM, FieldCollocateFreqIPM,
+}
+
+func (f ResultField) Validate() bool {
+	return slices.Contains(ResultFields, f)
+}
+
+// hasField tells whether the field is among selected fields.
+// An empty selection means "all the fields".
+func hasField(selected []ResultField, f ResultField) bool {
+	return len(selected) == 0 || slices.Contains(selected, f)
+}
+
+// requiredFields returns measures needed to sort by the measure
+func (m SortingMeasure) requiredFields() []ResultField {
+	switch m {
+	case sortByLogDice:
+		return []ResultField{FieldLogDice}
+	case sortByTScore:
+		return []ResultField{FieldTScore}
+	case sortByLMI:
+		return []ResultField{FieldLMI}
+	case sortByLL:
+		return []ResultField{FieldLogLikelihood}
+	case sortByRRF:
+		return []ResultField{FieldLogDice, FieldTScore, FieldLMI, FieldLogLikelihood, FieldRRFScore}
+	case sortByMI:
+		return []ResultField{FieldMI}
+	case sortByMI3:
+		return []ResultField{FieldMI3}
+	case sortByDice:
+		return []ResultField{FieldDice}
+	case sortByMinSens:
+		return []ResultField{FieldMinSensitivity}
+	case sortByDeltaPColl:
+		return []ResultField{FieldDeltaPCollocate}
+	case sortByDeltaPNode:
+		return []ResultField{FieldDeltaPNode}
+	}
+	return []ResultField{}
+}
+
+// ValueOf returns the value of the measure calculated for the item
+func (m SortingMeasure) ValueOf(item Collocation) float64 {
+	switch m {
+	case sortByLogDice:
+		return item.LogDice
+	case sortByTScore:
+		return item.TScore
+	case sortByLMI:
+		return item.LMI
+	case sortByLL:
+		return item.LogLikelihood
+	case sortByRRF:
+		return item.RRFScore
+	case sortByMI:
+		return item.MI
+	case sortByMI3:
+		return item.MI3
+	case sortByDice:
+		return item.Dice
+	case sortByMinSens:
+		return item.MinSensitivity
+	case sortByDeltaPColl:
+		return item.DeltaPCollocate
+	case sortByDeltaPNode:
+		return item.DeltaPNode
+	}
+	return 0
+}
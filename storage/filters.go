@@ -0,0 +1,155 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+)
+
+// ErrUnknownFilterValue is returned by filter constructors
+// when a provided PoS or relation name is not known
+var ErrUnknownFilterValue = errors.New("unknown filter value")
+
+// And creates a filter accepting records accepted by all the provided
+// filters. Nil filters (= accept everything) are ignored.
+func And(filters ...SearchFilter) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		for _, f := range filters {
+			if f != nil && !f(pos1, deprel, pos2, textType, isHead, dist) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or creates a filter accepting records accepted by at least one
+// of the provided filters. A nil filter accepts everything.
+func Or(filters ...SearchFilter) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		for _, f := range filters {
+			if f == nil || f(pos1, deprel, pos2, textType, isHead, dist) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not creates a filter accepting records rejected by the provided
+// filter. For a nil filter, the result rejects everything.
+func Not(filter SearchFilter) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		return filter != nil && !filter(pos1, deprel, pos2, textType, isHead, dist)
+	}
+}
+
+// ------
+
+// DeprelIn accepts records with any of the provided relations
+func DeprelIn(deprels ...uint16) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		return slices.Contains(deprels, deprel)
+	}
+}
+
+// NodePoSIn accepts records where the searched lemma has any of the provided PoS
+func NodePoSIn(pos ...byte) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		return slices.Contains(pos, pos1)
+	}
+}
+
+// CollocatePoSIn accepts records where the collocate has any of the provided PoS
+func CollocatePoSIn(pos ...byte) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		return slices.Contains(pos, pos2)
+	}
+}
+
+// TextTypeIn accepts records from any of the provided (raw) text types
+func TextTypeIn(textTypes ...byte) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		return slices.Contains(textTypes, textType)
+	}
+}
+
+// NodeAsHead accepts records where the searched lemma is the head
+// (isHead = true) or the dependent (isHead = false)
+func NodeAsHead(isHead bool) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, recIsHead bool, dist float64) bool {
+		return recIsHead == isHead
+	}
+}
+
+// MaxDist accepts records with average syntactic distance up to the value
+func MaxDist(maxDist float64) SearchFilter {
+	return func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool {
+		return dist <= maxDist
+	}
+}
+
+// ------
+
+// DeprelNamesIn is a variant of DeprelIn with relations specified
+// by their names (e.g. "obl:arg") as registered in the mapping.
+func DeprelNamesIn(mapping *record.DeprelMapping, names ...string) (SearchFilter, error) {
+	codes := make([]uint16, len(names))
+	for i, name := range names {
+		v, ok := mapping.Get(strings.ToLower(name))
+		if !ok {
+			return nil, fmt.Errorf("%w: deprel %s", ErrUnknownFilterValue, name)
+		}
+		codes[i] = v
+	}
+	return DeprelIn(codes...), nil
+}
+
+func posNamesToCodes(names []string) ([]byte, error) {
+	codes := make([]byte, len(names))
+	for i, name := range names {
+		v, ok := record.UDPoSMapping[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: PoS %s", ErrUnknownFilterValue, name)
+		}
+		codes[i] = v
+	}
+	return codes, nil
+}
+
+// NodePoSNamesIn is a variant of NodePoSIn with UD PoS names (e.g. "NOUN")
+func NodePoSNamesIn(names ...string) (SearchFilter, error) {
+	codes, err := posNamesToCodes(names)
+	if err != nil {
+		return nil, err
+	}
+	return NodePoSIn(codes...), nil
+}
+
+// CollocatePoSNamesIn is a variant of CollocatePoSIn with UD PoS names (e.g. "NOUN")
+func CollocatePoSNamesIn(names ...string) (SearchFilter, error) {
+	codes, err := posNamesToCodes(names)
+	if err != nil {
+		return nil, err
+	}
+	return CollocatePoSIn(codes...), nil
+}
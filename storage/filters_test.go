@@ -0,0 +1,73 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func applyFilter(f SearchFilter, deprel uint16, pos2 byte, isHead bool) bool {
+	return f(record.PosNOUN, deprel, pos2, 0x01, isHead, 1.5)
+}
+
+func TestFilterCombinators(t *testing.T) {
+	notConjAppos := Not(DeprelIn(record.DeprelConj, record.DeprelAppos))
+	assert.False(t, applyFilter(notConjAppos, record.DeprelConj, record.PosNOUN, true))
+	assert.False(t, applyFilter(notConjAppos, record.DeprelAppos, record.PosNOUN, true))
+	assert.True(t, applyFilter(notConjAppos, record.DeprelNmod, record.PosNOUN, true))
+
+	f := And(notConjAppos, NodeAsHead(true), Or(CollocatePoSIn(record.PosADJ), DeprelIn(record.DeprelNmod)))
+	assert.True(t, applyFilter(f, record.DeprelAmod, record.PosADJ, true))
+	assert.True(t, applyFilter(f, record.DeprelNmod, record.PosNOUN, true))
+	assert.False(t, applyFilter(f, record.DeprelNmod, record.PosNOUN, false))
+	assert.False(t, applyFilter(f, record.DeprelObj, record.PosNOUN, true))
+}
+
+func TestFilterCombinatorsNilAndEmpty(t *testing.T) {
+	assert.True(t, applyFilter(And(), record.DeprelObj, record.PosNOUN, true))
+	assert.True(t, applyFilter(And(nil, MaxDist(2)), record.DeprelObj, record.PosNOUN, true))
+	assert.False(t, applyFilter(And(MaxDist(1)), record.DeprelObj, record.PosNOUN, true))
+	assert.False(t, applyFilter(Or(), record.DeprelObj, record.PosNOUN, true))
+	assert.True(t, applyFilter(Or(nil), record.DeprelObj, record.PosNOUN, true))
+	assert.False(t, applyFilter(Not(nil), record.DeprelObj, record.PosNOUN, true))
+}
+
+func TestFilterNamedConstructors(t *testing.T) {
+	f, err := DeprelNamesIn(&record.UDDeprelMapping, "obl:arg", "NMOD")
+	assert.NoError(t, err)
+	assert.True(t, applyFilter(f, record.DeprelOblArg, record.PosNOUN, true))
+	assert.True(t, applyFilter(f, record.DeprelNmod, record.PosNOUN, true))
+	assert.False(t, applyFilter(f, record.DeprelObl, record.PosNOUN, true))
+
+	_, err = DeprelNamesIn(&record.UDDeprelMapping, "foo")
+	assert.ErrorIs(t, err, ErrUnknownFilterValue)
+
+	f, err = CollocatePoSNamesIn("adj", "ADV")
+	assert.NoError(t, err)
+	assert.True(t, applyFilter(f, record.DeprelAmod, record.PosADJ, true))
+	assert.False(t, applyFilter(f, record.DeprelAmod, record.PosNOUN, true))
+
+	f, err = NodePoSNamesIn("NOUN")
+	assert.NoError(t, err)
+	assert.True(t, applyFilter(f, record.DeprelAmod, record.PosADJ, true))
+
+	_, err = NodePoSNamesIn("XYZ")
+	assert.ErrorIs(t, err, ErrUnknownFilterValue)
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ImportRun describes a single import run into a database.
+// Runs are stored in a history separate from Metadata so
+// the provenance of the database content is traceable.
+type ImportRun struct {
+	Timestamp         time.Time `json:"timestamp"`
+	Files             []string  `json:"files"`
+	ProfileName       string    `json:"profileName"`
+	CorpusSize        int64     `json:"corpusSize"`
+	NumLemmas         int       `json:"numLemmas"`
+	NumLemmaFreqs     int       `json:"numLemmaFreqs"`
+	NumCollFreqs      int       `json:"numCollFreqs"`
+	MinPairFreq       int       `json:"minPairFreq"`
+	HotLemmaThreshold int       `json:"hotLemmaThreshold,omitempty"`
+
+	// ClearedPrevious tells whether the run replaced all
+	// the previous content of the database
+	ClearedPrevious bool `json:"clearedPrevious"`
+}
+
+// ImportHistory returns all the recorded import runs (oldest first).
+// For databases without a history, an empty list is returned.
+func (db *DB) ImportHistory() ([]ImportRun, error) {
+	ans := make([]ImportRun, 0, 5)
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(record.CreateMetadataKey(record.MetadataKeyImportHistory))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &ans)
+		})
+	})
+	if err != nil {
+		return ans, fmt.Errorf("failed to read import history: %w", err)
+	}
+	return ans, nil
+}
+
+// StoreImportHistory replaces the whole import history. This is mostly
+// useful for preserving the history when the database is cleared.
+func (db *DB) StoreImportHistory(history []ImportRun) error {
+	rawHistory, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to store import history: %w", err)
+	}
+	if err := db.bdb.Update(func(txn *badger.Txn) error {
+		return txn.Set(record.CreateMetadataKey(record.MetadataKeyImportHistory), rawHistory)
+	}); err != nil {
+		return fmt.Errorf("failed to store import history: %w", err)
+	}
+	return nil
+}
+
+// AppendImportRun adds a new entry to the import history.
+func (db *DB) AppendImportRun(run ImportRun) error {
+	history, err := db.ImportHistory()
+	if err != nil {
+		return err
+	}
+	return db.StoreImportHistory(append(history, run))
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DefaultHotLemmaThreshold is a default number of collocation records
+// (of one direction) a lemma must exceed to be considered "hot".
+const DefaultHotLemmaThreshold = 50000
+
+type hotLemmaKey struct {
+	isHead  bool
+	tokenID uint64
+}
+
+type hotSummaryAcc struct {
+	freq           int
+	distSum        float64
+	surfaceDistSum float64
+}
+
+// StoreHotLemmaSummaries detects lemmas with number of collocation records
+// (counted separately for each direction) higher than threshold and for them,
+// it writes pre-aggregated records with text types summed up.
+// CalculateMeasures then uses these records in case no text type filtering
+// or grouping is needed which saves it from scanning huge numbers of raw keys
+// for very frequent lemmas.
+// Previously stored summaries are always removed first (e.g. they
+// would be outdated once new data are appended).
+// The function should be called once the collocation data are imported.
+// It returns the number of detected hot lemmas (per direction).
+func (db *DB) StoreHotLemmaSummaries(threshold int) (int, error) {
+	for _, ns := range []string{"hotPairFreq", "hotRevPairFreq"} {
+		prefix, _ := record.NamespaceKeyPrefix(ns)
+		if err := db.bdb.DropPrefix(prefix); err != nil {
+			return 0, fmt.Errorf("failed to store hot lemma summaries: %w", err)
+		}
+	}
+	if threshold <= 0 {
+		return 0, nil
+	}
+	hotLemmas, err := db.findHotLemmas(threshold)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store hot lemma summaries: %w", err)
+	}
+	for _, hl := range hotLemmas {
+		if err := db.storeHotLemmaSummary(hl); err != nil {
+			return 0, fmt.Errorf("failed to store hot lemma summaries: %w", err)
+		}
+	}
+	return len(hotLemmas), nil
+}
+
+func (db *DB) findHotLemmas(threshold int) ([]hotLemmaKey, error) {
+	ans := make([]hotLemmaKey, 0, 100)
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		for _, isHead := range []bool{true, false} {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchValues = false
+			opts.Prefix = record.AllCollFreqs(isHead)
+			it := txn.NewIterator(opts)
+			var currToken uint64
+			var numRecords int
+			for it.Rewind(); it.Valid(); it.Next() {
+				key, err := db.keys.DecodeCollFreqKey(it.Item().Key())
+				if err != nil {
+					it.Close()
+					return err
+				}
+				if key.Token1ID != currToken {
+					if numRecords > threshold {
+						ans = append(ans, hotLemmaKey{isHead: isHead, tokenID: currToken})
+					}
+					currToken = key.Token1ID
+					numRecords = 0
+				}
+				numRecords++
+			}
+			if numRecords > threshold {
+				ans = append(ans, hotLemmaKey{isHead: isHead, tokenID: currToken})
+			}
+			it.Close()
+		}
+		return nil
+	})
+	return ans, err
+}
+
+func (db *DB) storeHotLemmaSummary(hl hotLemmaKey) error {
+	summary := make(map[string]*hotSummaryAcc)
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = db.keys.AllCollFreqsOfToken(hl.isHead, hl.tokenID)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key, err := db.keys.DecodeCollFreqKey(item.Key())
+			if err != nil {
+				return err
+			}
+			val, err := decodeItemValue(item, record.DecodeCollocValue)
+			if err != nil {
+				return err
+			}
+			sKey := string(db.keys.HotCollFreqKey(
+				hl.isHead, key.Token1ID, key.Pos1, key.Deprel, key.Token2ID, key.Pos2))
+			acc, ok := summary[sKey]
+			if !ok {
+				acc = &hotSummaryAcc{}
+				summary[sKey] = acc
+			}
+			acc.freq += int(val.Freq)
+			acc.distSum += val.Dist * float64(val.Freq)
+			acc.surfaceDistSum += val.SurfaceDist * float64(val.Freq)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	wb := db.bdb.NewWriteBatch()
+	defer wb.Cancel()
+	for k, acc := range summary {
+		var dist, surfaceDist float64
+		if acc.freq > 0 {
+			dist = acc.distSum / float64(acc.freq)
+			surfaceDist = acc.surfaceDistSum / float64(acc.freq)
+		}
+		encoded := record.EncodeCollocValueWithSurfaceDist(uint32(acc.freq), dist, surfaceDist)
+		if err := wb.Set([]byte(k), encoded); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// hasHotLemmaSummaryTx tests whether there are pre-aggregated collocation
+// records for the token and the direction.
+func (db *DB) hasHotLemmaSummaryTx(txn *badger.Txn, isHead bool, tokenID uint64) bool {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = db.keys.AllHotCollFreqsOfToken(isHead, tokenID)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	it.Rewind()
+	return it.Valid()
+}
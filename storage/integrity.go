@@ -0,0 +1,515 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DefaultMaxIssuesPerKind is a default number of reported
+// issues of a single kind (see IntegrityCheckArgs)
+const DefaultMaxIssuesPerKind = 20
+
+// IntegrityIssueKind is a type of a problem found by DB.CheckIntegrity
+type IntegrityIssueKind string
+
+const (
+
+	// IssueDanglingTokenID - a frequency record refers to a token ID
+	// known neither to the lemma (word form) index nor to the reverse index
+	IssueDanglingTokenID IntegrityIssueKind = "danglingTokenID"
+
+	// IssueMissingRevIndex - a lemma (or a word form) has no reverse
+	// index entry so its ID cannot be translated back (repairable)
+	IssueMissingRevIndex IntegrityIssueKind = "missingRevIndex"
+
+	// IssueRevIndexMismatch - a reverse index entry has no matching
+	// lemma (or word form) index entry
+	IssueRevIndexMismatch IntegrityIssueKind = "revIndexMismatch"
+
+	// IssueDuplicateTokenID - more lemmas (word forms) share a token ID
+	IssueDuplicateTokenID IntegrityIssueKind = "duplicateTokenID"
+
+	// IssueFreqMismatch - stored frequencies are inconsistent with each
+	// other or with the corpus size
+	IssueFreqMismatch IntegrityIssueKind = "freqMismatch"
+
+	// IssueMetadataMismatch - metadata do not match the stored records
+	IssueMetadataMismatch IntegrityIssueKind = "metadataMismatch"
+
+	// IssueInvalidRecord - a record of a known type cannot be decoded
+	IssueInvalidRecord IntegrityIssueKind = "invalidRecord"
+)
+
+// IntegrityIssue is a single problem found by DB.CheckIntegrity
+type IntegrityIssue struct {
+	Kind IntegrityIssueKind `json:"kind"`
+
+	// Key is a hex encoded key of the affected record
+	// (empty for issues not related to a single record)
+	Key string `json:"key,omitempty"`
+
+	Message string `json:"message"`
+
+	// Repaired is set for issues fixed by the check
+	// (see IntegrityCheckArgs.RepairRevIndex)
+	Repaired bool `json:"repaired,omitempty"`
+}
+
+// IntegrityCheckArgs configures DB.CheckIntegrity
+type IntegrityCheckArgs struct {
+
+	// RepairRevIndex makes the check to create missing reverse
+	// index entries (see IssueMissingRevIndex)
+	RepairRevIndex bool
+
+	// MaxIssuesPerKind limits the number of issues of a single kind
+	// listed in the report (all the issues are always counted).
+	// A non-positive value means DefaultMaxIssuesPerKind.
+	MaxIssuesPerKind int
+}
+
+// IntegrityReport is a result of DB.CheckIntegrity
+type IntegrityReport struct {
+	NumLemmas          int   `json:"numLemmas"`
+	NumWordForms       int   `json:"numWordForms"`
+	NumRevIndexEntries int   `json:"numRevIndexEntries"`
+	NumLemmaFreqs      int   `json:"numLemmaFreqs"`
+	NumLemmaRollups    int   `json:"numLemmaRollups"`
+	NumCollFreqs       int   `json:"numCollFreqs"`
+	NumHotCollFreqs    int   `json:"numHotCollFreqs"`
+	NumPathCollFreqs   int   `json:"numPathCollFreqs"`
+	NumPairExamples    int   `json:"numPairExamples"`
+	SumLemmaFreqs      int64 `json:"sumLemmaFreqs"`
+
+	// IssueCounts contains numbers of all the found issues by their kinds
+	IssueCounts map[IntegrityIssueKind]int `json:"issueCounts"`
+
+	// Issues lists found issues (limited by IntegrityCheckArgs.MaxIssuesPerKind)
+	Issues []IntegrityIssue `json:"issues"`
+
+	NumRepaired int `json:"numRepaired"`
+}
+
+// NumIssues returns the number of all the found issues
+func (r IntegrityReport) NumIssues() int {
+	var ans int
+	for _, v := range r.IssueCounts {
+		ans += v
+	}
+	return ans
+}
+
+// OK tells whether the database has no (unrepaired) issues
+func (r IntegrityReport) OK() bool {
+	return r.NumIssues() == r.NumRepaired
+}
+
+func (r *IntegrityReport) addIssue(maxPerKind int, issue IntegrityIssue) {
+	r.IssueCounts[issue.Kind]++
+	if r.IssueCounts[issue.Kind] <= maxPerKind {
+		r.Issues = append(r.Issues, issue)
+	}
+}
+
+// integrityChecker holds state of a single DB.CheckIntegrity run
+type integrityChecker struct {
+	db         *DB
+	txn        *badger.Txn
+	cancelled  cancelCheck
+	maxPerKind int
+	report     IntegrityReport
+
+	// indexed maps token IDs to lemmas (word forms) of the lemma index
+	indexed map[uint64]string
+
+	// revIndexed contains token IDs found in the reverse index
+	revIndexed map[uint64]bool
+
+	// missingRev contains entries to be added to the reverse index
+	missingRev map[uint64]string
+
+	// tokenFreqSums contains per text type frequencies summed
+	// for (token ID, PoS) pairs so rollups can be verified
+	tokenFreqSums map[tokenRollupKey]int
+
+	// knownDeprels contains codes of the metadata deprel mapping
+	knownDeprels map[uint16]bool
+}
+
+func (ic *integrityChecker) issue(kind IntegrityIssueKind, key []byte, msg string, args ...any) {
+	issue := IntegrityIssue{Kind: kind, Message: fmt.Sprintf(msg, args...)}
+	if key != nil {
+		issue.Key = hex.EncodeToString(key)
+	}
+	ic.report.addIssue(ic.maxPerKind, issue)
+}
+
+// scan calls fn for all the records of the namespace (see record.KeyNamespace)
+func (ic *integrityChecker) scan(ns string, fn func(item *badger.Item) error) error {
+	prefix, ok := record.NamespaceKeyPrefix(ns)
+	if !ok {
+		return fmt.Errorf("unknown key namespace: %s", ns)
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := ic.txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := ic.cancelled.err(); err != nil {
+			return err
+		}
+		if record.KeyNamespace(it.Item().Key()) != ns {
+			continue
+		}
+		if err := fn(it.Item()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ic *integrityChecker) checkTokenID(key []byte, tokenID uint64) {
+	if _, ok := ic.indexed[tokenID]; ok || ic.revIndexed[tokenID] {
+		return
+	}
+	ic.issue(IssueDanglingTokenID, key, "record refers to unknown token ID %d", tokenID)
+}
+
+// checkLemmaIndex reads the lemma (or word form) index
+func (ic *integrityChecker) checkLemmaIndex(ns string, counter *int) error {
+	return ic.scan(ns, func(item *badger.Item) error {
+		key := item.Key()
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		tokenID, err := ic.db.keys.DecodeTokenID(val)
+		if err != nil {
+			ic.issue(IssueInvalidRecord, key, "invalid token ID value %x", val)
+			return nil
+		}
+		*counter++
+		if prev, ok := ic.indexed[tokenID]; ok {
+			ic.issue(IssueDuplicateTokenID, key, "token ID %d is shared by %s and %s", tokenID, prev, key[1:])
+			return nil
+		}
+		ic.indexed[tokenID] = string(key[1:])
+		return nil
+	})
+}
+
+func (ic *integrityChecker) checkRevIndex() error {
+	err := ic.scan("idToLemma", func(item *badger.Item) error {
+		key := item.Key()
+		if len(key) != 1+ic.db.keys.TokenIDWidth() {
+			ic.issue(IssueInvalidRecord, key, "invalid reverse index key")
+			return nil
+		}
+		lemma, err := readItemValue(item, DecodeLemma)
+		if err != nil {
+			return err
+		}
+		ic.report.NumRevIndexEntries++
+		tokenID := ic.db.keys.DecodeRevIndexKey(key)
+		ic.revIndexed[tokenID] = true
+		indexed, ok := ic.indexed[tokenID]
+		if !ok {
+			ic.issue(IssueRevIndexMismatch, key, "token ID %d (%s) is missing in the lemma index", tokenID, lemma)
+
+		} else if indexed != lemma {
+			ic.issue(
+				IssueRevIndexMismatch, key, "token ID %d is %s in the lemma index but %s in the reverse index",
+				tokenID, indexed, lemma)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for tokenID, lemma := range ic.indexed {
+		if !ic.revIndexed[tokenID] {
+			ic.missingRev[tokenID] = lemma
+		}
+	}
+	return nil
+}
+
+func (ic *integrityChecker) checkTokenFreqs() error {
+	err := ic.scan("tokenFreq", func(item *badger.Item) error {
+		key := item.Key()
+		if len(key) < 3+ic.db.keys.TokenIDWidth() {
+			ic.issue(IssueInvalidRecord, key, "invalid token frequency key")
+			return nil
+		}
+		decKey, err := ic.db.keys.DecodeTokenFreqKey(key)
+		if err != nil {
+			return err
+		}
+		val, err := decodeItemValue(item, record.DecodeTokenValue)
+		if errors.Is(err, record.ErrMalformedRecord) {
+			ic.issue(IssueInvalidRecord, key, "invalid token frequency value")
+			return nil
+
+		} else if err != nil {
+			return err
+		}
+		ic.checkTokenID(key, decKey.Token1ID)
+		if val.Freq == 0 {
+			ic.issue(IssueFreqMismatch, key, "zero frequency of token ID %d", decKey.Token1ID)
+		}
+		ic.tokenFreqSums[tokenRollupKey{tokenID: decKey.Token1ID, pos: decKey.Pos1}] += int(val.Freq)
+		if !ic.db.keys.IsWordFormTokenID(decKey.Token1ID) {
+			ic.report.NumLemmaFreqs++
+			ic.report.SumLemmaFreqs += int64(val.Freq)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rollups := make(map[tokenRollupKey]bool)
+	err = ic.scan("tokenRollup", func(item *badger.Item) error {
+		key := item.Key()
+		decKey, err := ic.db.keys.DecodeTokenFreqRollupKey(key)
+		if err != nil {
+			ic.issue(IssueInvalidRecord, key, "invalid token frequency rollup key")
+			return nil
+		}
+		val, err := decodeItemValue(item, record.DecodeTokenValue)
+		if errors.Is(err, record.ErrMalformedRecord) {
+			ic.issue(IssueInvalidRecord, key, "invalid token frequency rollup value")
+			return nil
+
+		} else if err != nil {
+			return err
+		}
+		ic.checkTokenID(key, decKey.Token1ID)
+		ic.report.NumLemmaRollups++
+		rk := tokenRollupKey{tokenID: decKey.Token1ID, pos: decKey.Pos1}
+		rollups[rk] = true
+		if sum := ic.tokenFreqSums[rk]; sum != int(val.Freq) {
+			ic.issue(
+				IssueFreqMismatch, key, "rollup of token ID %d has frequency %d but its records sum up to %d",
+				rk.tokenID, val.Freq, sum)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if ic.report.NumLemmaRollups > 0 {
+		for rk := range ic.tokenFreqSums {
+			if !rollups[rk] {
+				ic.issue(
+					IssueFreqMismatch, ic.db.keys.TokenFreqRollupKey(rk.tokenID, rk.pos),
+					"missing rollup of token ID %d", rk.tokenID)
+			}
+		}
+	}
+	return nil
+}
+
+func (ic *integrityChecker) checkPairs(ns string, counter *int) error {
+	return ic.scan(ns, func(item *badger.Item) error {
+		key := item.Key()
+		decKey, err := ic.db.keys.DecodeCollFreqKey(key)
+		if err != nil {
+			ic.issue(IssueInvalidRecord, key, "invalid collocation frequency key")
+			return nil
+		}
+		ic.checkTokenID(key, decKey.Token1ID)
+		ic.checkTokenID(key, decKey.Token2ID)
+		// zero is used for relations unknown during import
+		if len(ic.knownDeprels) > 0 && decKey.Deprel != 0 && !ic.knownDeprels[decKey.Deprel] {
+			ic.issue(IssueMetadataMismatch, key, "relation code %d is missing in the metadata", decKey.Deprel)
+		}
+		if !ic.db.keys.IsWordFormTokenID(decKey.Token1ID) {
+			*counter++
+		}
+		return nil
+	})
+}
+
+func (ic *integrityChecker) checkPairExamples() error {
+	return ic.scan("pairExamples", func(item *badger.Item) error {
+		key := item.Key()
+		token1ID, token2ID, err := ic.db.keys.DecodePairExamplesKey(key)
+		if err != nil {
+			ic.issue(IssueInvalidRecord, key, "invalid pair examples key")
+			return nil
+		}
+		ic.checkTokenID(key, token1ID)
+		ic.checkTokenID(key, token2ID)
+		if _, err := decodeItemValue(item, record.DecodePairExamples); err != nil {
+			ic.issue(IssueInvalidRecord, key, "invalid pair examples value")
+			return nil
+		}
+		ic.report.NumPairExamples++
+		return nil
+	})
+}
+
+// checkMetadata compares the metadata with the numbers
+// of found records
+func (ic *integrityChecker) checkMetadata() {
+	m := ic.db.Metadata
+	r := &ic.report
+	if m.NumLemmas != r.NumLemmas {
+		ic.issue(IssueMetadataMismatch, nil, "metadata report %d lemmas, found %d", m.NumLemmas, r.NumLemmas)
+	}
+	if m.NumWordForms != r.NumWordForms {
+		ic.issue(IssueMetadataMismatch, nil, "metadata report %d word forms, found %d", m.NumWordForms, r.NumWordForms)
+	}
+	if m.NumLemmaFreqs != r.NumLemmaFreqs {
+		ic.issue(
+			IssueMetadataMismatch, nil, "metadata report %d lemma frequency records, found %d",
+			m.NumLemmaFreqs, r.NumLemmaFreqs)
+	}
+	if m.NumCollFreqs != r.NumCollFreqs {
+		ic.issue(
+			IssueMetadataMismatch, nil, "metadata report %d collocation frequency records, found %d",
+			m.NumCollFreqs, r.NumCollFreqs)
+	}
+	if m.HasFeature(FeatureTokenFreqRollups) && r.NumLemmaFreqs > 0 && r.NumLemmaRollups == 0 {
+		ic.issue(IssueMetadataMismatch, nil, "feature %s is set but no rollups found", FeatureTokenFreqRollups)
+	}
+	if m.HasFeature(FeatureHotLemmaSummaries) && m.NumHotLemmas > 0 && r.NumHotCollFreqs == 0 {
+		ic.issue(
+			IssueMetadataMismatch, nil, "feature %s is set but no hot lemma summaries found",
+			FeatureHotLemmaSummaries)
+	}
+	if m.HasFeature(FeatureWordForms) != (r.NumWordForms > 0) {
+		ic.issue(
+			IssueMetadataMismatch, nil, "feature %s does not match %d found word forms",
+			FeatureWordForms, r.NumWordForms)
+	}
+	// Single token frequencies are counted per tree path (and some tokens
+	// are removed by tree shrinking) so their sum differs from the corpus
+	// size in general. But both must be either zero or non-zero.
+	if (m.CorpusSize > 0) != (r.SumLemmaFreqs > 0) {
+		ic.issue(
+			IssueFreqMismatch, nil, "corpus size %d does not match summed lemma frequencies %d",
+			m.CorpusSize, r.SumLemmaFreqs)
+	}
+}
+
+// repairRevIndex writes missing reverse index entries
+func (ic *integrityChecker) repairRevIndex() error {
+	bw := ic.db.newBatchWriter("reverse index repair", len(ic.missingRev))
+	defer bw.Cancel()
+	for tokenID, lemma := range ic.missingRev {
+		if err := bw.Set(ic.db.keys.TokenIDToRevIndexKey(tokenID), []byte(lemma)); err != nil {
+			return err
+		}
+		bw.itemDone()
+	}
+	return bw.Flush()
+}
+
+// CheckIntegrity scans the whole database and reports dangling token IDs
+// in frequency records, inconsistencies between the lemma (and word form)
+// index and the reverse index, inconsistent frequencies (rollups vs.
+// per text type records, summed frequencies vs. the corpus size) and
+// metadata not matching the stored records. With args.RepairRevIndex set,
+// missing reverse index entries are created (other issues are only
+// reported). Just like UnknownKeyPrefixes, the check always uses
+// current data, even if a snapshot is pinned.
+//
+// Please note that the whole database is read and the lemma index
+// is kept in memory during the check.
+func (db *DB) CheckIntegrity(ctx context.Context, args IntegrityCheckArgs) (IntegrityReport, error) {
+	ic := &integrityChecker{
+		db:            db,
+		cancelled:     cancelCheck{ctx: ctx},
+		maxPerKind:    args.MaxIssuesPerKind,
+		indexed:       make(map[uint64]string),
+		revIndexed:    make(map[uint64]bool),
+		missingRev:    make(map[uint64]string),
+		tokenFreqSums: make(map[tokenRollupKey]int),
+		knownDeprels:  make(map[uint16]bool),
+		report: IntegrityReport{
+			IssueCounts: make(map[IntegrityIssueKind]int),
+			Issues:      []IntegrityIssue{},
+		},
+	}
+	if ic.maxPerKind <= 0 {
+		ic.maxPerKind = DefaultMaxIssuesPerKind
+	}
+	for _, code := range db.Metadata.DeprelMap {
+		ic.knownDeprels[code] = true
+	}
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		ic.txn = txn
+		if err := ic.checkLemmaIndex("lemmaToID", &ic.report.NumLemmas); err != nil {
+			return err
+		}
+		if err := ic.checkLemmaIndex("wordFormToID", &ic.report.NumWordForms); err != nil {
+			return err
+		}
+		if err := ic.checkRevIndex(); err != nil {
+			return err
+		}
+		if err := ic.checkTokenFreqs(); err != nil {
+			return err
+		}
+		for _, ns := range []string{"pairFreq", "revPairFreq"} {
+			if err := ic.checkPairs(ns, &ic.report.NumCollFreqs); err != nil {
+				return err
+			}
+		}
+		for _, ns := range []string{"hotPairFreq", "hotRevPairFreq"} {
+			if err := ic.checkPairs(ns, &ic.report.NumHotCollFreqs); err != nil {
+				return err
+			}
+		}
+		for _, ns := range []string{"pathPairFreq", "revPathPairFreq"} {
+			if err := ic.checkPairs(ns, &ic.report.NumPathCollFreqs); err != nil {
+				return err
+			}
+		}
+		if err := ic.checkPairExamples(); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ic.report, fmt.Errorf("failed to check database integrity: %w", err)
+	}
+	for tokenID, lemma := range ic.missingRev {
+		ic.report.addIssue(ic.maxPerKind, IntegrityIssue{
+			Kind:     IssueMissingRevIndex,
+			Key:      hex.EncodeToString(ic.db.keys.TokenIDToRevIndexKey(tokenID)),
+			Message:  fmt.Sprintf("missing reverse index entry of token ID %d (%s)", tokenID, lemma),
+			Repaired: args.RepairRevIndex,
+		})
+	}
+	ic.checkMetadata()
+	if args.RepairRevIndex && len(ic.missingRev) > 0 {
+		if err := ic.repairRevIndex(); err != nil {
+			return ic.report, fmt.Errorf("failed to repair reverse index: %w", err)
+		}
+		ic.report.NumRepaired = len(ic.missingRev)
+	}
+	return ic.report, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func storeIntegrityTestData(t *testing.T, db *DB) {
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "big", PoS: adj, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Freq: 10, AVGDist: 1, TextType: tt},
+	}
+	stats, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	db.Metadata = Metadata{
+		CorpusSize:    100,
+		NumLemmas:     stats.NumLemmas,
+		NumLemmaFreqs: stats.NumLemmaFreqs,
+		NumCollFreqs:  stats.NumCollFreqs,
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	db := openTestDB(t)
+	storeIntegrityTestData(t, db)
+	report, err := db.CheckIntegrity(context.Background(), IntegrityCheckArgs{})
+	assert.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, 2, report.NumLemmas)
+	assert.Equal(t, 2, report.NumRevIndexEntries)
+	assert.Equal(t, 2, report.NumLemmaFreqs)
+	assert.Equal(t, 1, report.NumCollFreqs)
+	assert.Equal(t, int64(70), report.SumLemmaFreqs)
+}
+
+func TestCheckIntegrityIssues(t *testing.T) {
+	db := openTestDB(t)
+	storeIntegrityTestData(t, db)
+	dogID, err := db.GetLemmaID(record.TokenFreq{Lemma: "dog"})
+	assert.NoError(t, err)
+	err = db.bdb.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(db.keys.TokenIDToRevIndexKey(dogID)); err != nil {
+			return err
+		}
+		return txn.Set(db.keys.TokenFreqKey(99, record.PosNOUN, 0x01), record.EncodeTokenValue(5))
+	})
+	assert.NoError(t, err)
+	db.Metadata.NumLemmas = 3
+
+	report, err := db.CheckIntegrity(context.Background(), IntegrityCheckArgs{})
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, 1, report.IssueCounts[IssueMissingRevIndex])
+	assert.Equal(t, 1, report.IssueCounts[IssueDanglingTokenID])
+	// the dangling record has no rollup
+	assert.Equal(t, 1, report.IssueCounts[IssueFreqMismatch])
+	// lemmas and lemma freq. records
+	assert.Equal(t, 2, report.IssueCounts[IssueMetadataMismatch])
+	assert.Equal(t, 0, report.NumRepaired)
+
+	report, err = db.CheckIntegrity(context.Background(), IntegrityCheckArgs{RepairRevIndex: true, MaxIssuesPerKind: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.NumRepaired)
+	assert.Len(t, report.Issues, 4)
+
+	report, err = db.CheckIntegrity(context.Background(), IntegrityCheckArgs{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.IssueCounts[IssueMissingRevIndex])
+	assert.Equal(t, 2, report.NumRevIndexEntries)
+}
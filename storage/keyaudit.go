@@ -0,0 +1,93 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// UnknownKeyPrefix describes a group of keys not recognized
+// by this version of depreldb (e.g. keys written by an older or
+// a newer version or by a foreign tool).
+type UnknownKeyPrefix struct {
+
+	// Prefix is a hex encoded first byte of the keys (or the first
+	// two bytes in case of unknown metadata records)
+	Prefix string `json:"prefix"`
+
+	// Count is the number of keys with the prefix
+	Count int `json:"count"`
+
+	// Example is a hex encoded first found key with the prefix
+	Example string `json:"example"`
+}
+
+// unknownKeyPrefixLen returns number of bytes identifying
+// a group of unknown keys
+func unknownKeyPrefixLen(key []byte) int {
+	if record.IsMetadataKey(key) && len(key) >= 2 {
+		return 2
+	}
+	return min(len(key), 1)
+}
+
+// UnknownKeyPrefixes walks through all the keys of the database and
+// reports keys not belonging to any known namespace (see record.KeyNamespace)
+// grouped by their prefixes (ordered by the prefixes). The operation
+// is intended as a check before destructive operations (migrations,
+// repairs) so it always uses current data, even if a snapshot is pinned.
+// The whole database is scanned (without reading values).
+func (db *DB) UnknownKeyPrefixes() ([]UnknownKeyPrefix, error) {
+	groups := make(map[string]*UnknownKeyPrefix)
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().Key()
+			if record.KeyNamespace(key) != "" {
+				continue
+			}
+			prefix := hex.EncodeToString(key[:unknownKeyPrefixLen(key)])
+			group, ok := groups[prefix]
+			if !ok {
+				group = &UnknownKeyPrefix{Prefix: prefix, Example: hex.EncodeToString(key)}
+				groups[prefix] = group
+			}
+			group.Count++
+		}
+		return nil
+	})
+	if err != nil {
+		return []UnknownKeyPrefix{}, fmt.Errorf("failed to audit database keys: %w", err)
+	}
+	ans := make([]UnknownKeyPrefix, 0, len(groups))
+	for _, group := range groups {
+		ans = append(ans, *group)
+	}
+	slices.SortFunc(ans, func(a, b UnknownKeyPrefix) int {
+		return strings.Compare(a.Prefix, b.Prefix)
+	})
+	return ans, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownKeyPrefixes(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: record.UDPosFromByte(record.PosNOUN), Freq: 20, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, nil, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, db.StoreMetadata(Metadata{CorpusSize: 100}))
+
+	ans, err := db.UnknownKeyPrefixes()
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+
+	err = db.bdb.Update(func(txn *badger.Txn) error {
+		for _, key := range [][]byte{{0xf0, 0x01}, {0xf0, 0x02}, {0x01, 0x7f}, {0x00}} {
+			if err := txn.Set(key, []byte{0x01}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	ans, err = db.UnknownKeyPrefixes()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]UnknownKeyPrefix{
+			{Prefix: "00", Count: 1, Example: "00"},
+			{Prefix: "017f", Count: 1, Example: "017f"},
+			{Prefix: "f0", Count: 2, Example: "f001"},
+		},
+		ans,
+	)
+}
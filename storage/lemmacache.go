@@ -0,0 +1,135 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DefaultLemmaCacheQuota is a default max. memory (in bytes) the in-memory
+// reverse lemma index loaded by OpenDB may occupy.
+const DefaultLemmaCacheQuota = 128 << 20
+
+// lemmaCacheEntryOverhead is an estimated memory needed by a single
+// cached entry on top of the lemma string bytes (map bucket slot,
+// string header, key)
+const lemmaCacheEntryOverhead = 48
+
+// lemmaCacheAvgLemmaLen is an estimated average lemma length used to
+// decide (based on metadata) whether it makes sense to scan the reverse
+// index at all
+const lemmaCacheAvgLemmaLen = 8
+
+// LemmaCacheStats describes the in-memory reverse lemma index.
+type LemmaCacheStats struct {
+	Loaded     bool  `json:"loaded"`
+	NumLemmas  int   `json:"numLemmas"`
+	SizeBytes  int64 `json:"sizeBytes"`
+	QuotaBytes int64 `json:"quotaBytes"`
+}
+
+// lemmaCache is a fully loaded (tokenID -> lemma) reverse index.
+// Once loaded, it is read-only so it can be shared by concurrent
+// queries. A nil cache means lemmas are fetched from the database.
+type lemmaCache struct {
+	lemmas map[uint64]string
+	size   int64
+	quota  int64
+}
+
+func (lc *lemmaCache) get(tokenID uint64) (string, bool) {
+	if lc == nil {
+		return "", false
+	}
+	ans, ok := lc.lemmas[tokenID]
+	return ans, ok
+}
+
+func (lc *lemmaCache) stats() LemmaCacheStats {
+	if lc == nil {
+		return LemmaCacheStats{}
+	}
+	return LemmaCacheStats{
+		Loaded:     true,
+		NumLemmas:  len(lc.lemmas),
+		SizeBytes:  lc.size,
+		QuotaBytes: lc.quota,
+	}
+}
+
+// LoadLemmaCache loads the whole reverse lemma index (tokenID -> lemma)
+// into memory so lemma resolution of search results does not need
+// to access the database. The index is loaded only if it fits into
+// the quota (in bytes). Otherwise (or with a non-positive quota),
+// any previously loaded index is dropped and lemmas are resolved
+// on demand. The returned value tells whether the index has been loaded.
+// Lemmas added to the database after the loading are still available
+// as cache misses are resolved via the database.
+// The method is expected to be called before the database starts
+// serving queries.
+func (db *DB) LoadLemmaCache(quota int64) (bool, error) {
+	db.lemmaCache = nil
+	if quota <= 0 {
+		return false, nil
+	}
+	numEntries := db.Metadata.NumLemmas + db.Metadata.NumWordForms
+	if int64(numEntries)*(lemmaCacheEntryOverhead+lemmaCacheAvgLemmaLen) > quota {
+		return false, nil
+	}
+	ans := &lemmaCache{
+		lemmas: make(map[uint64]string, numEntries),
+		quota:  quota,
+	}
+	var exceeded bool
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.AllRevIndexKeys()
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			lemma, err := readItemValue(item, DecodeLemma)
+			if err != nil {
+				return err
+			}
+			ans.size += int64(len(lemma)) + lemmaCacheEntryOverhead
+			if ans.size > quota {
+				exceeded = true
+				return nil
+			}
+			ans.lemmas[db.keys.DecodeRevIndexKey(item.Key())] = lemma
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to load lemma cache: %w", err)
+	}
+	if exceeded {
+		return false, nil
+	}
+	db.lemmaCache = ans
+	return true, nil
+}
+
+// LemmaCacheStats returns information about the in-memory reverse
+// lemma index. In case the index is not loaded, zero value is returned.
+func (db *DB) LemmaCacheStats() LemmaCacheStats {
+	return db.lemmaCache.stats()
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLemmaCache(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	seq := NewTokenIDSequence()
+	_, err := db.StoreData(seq, map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 10, TextType: tt},
+		"2": {Lemma: "garden", PoS: noun, Freq: 5, TextType: tt},
+	}, nil, 1)
+	assert.NoError(t, err)
+	db.Metadata.NumLemmas = 2
+
+	loaded, err := db.LoadLemmaCache(DefaultLemmaCacheQuota)
+	assert.NoError(t, err)
+	assert.True(t, loaded)
+	stats := db.LemmaCacheStats()
+	assert.Equal(t, 2, stats.NumLemmas)
+	assert.Equal(t, int64(len("house")+len("garden")+2*lemmaCacheEntryOverhead), stats.SizeBytes)
+
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: "garden", PoS: noun})
+	assert.NoError(t, err)
+	lemma, err := db.GetLemmaByID(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "garden", lemma)
+
+	// lemmas added after loading are resolved via the database
+	_, err = db.StoreData(seq, map[record.GroupingKey]record.TokenFreq{
+		"3": {Lemma: "tree", PoS: noun, Freq: 3, TextType: tt},
+	}, nil, 1)
+	assert.NoError(t, err)
+	tokenID, err = db.GetLemmaID(record.TokenFreq{Lemma: "tree", PoS: noun})
+	assert.NoError(t, err)
+	lemma, err = db.GetLemmaByID(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "tree", lemma)
+}
+
+func TestLemmaCacheQuotaExceeded(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	_, err := db.StoreData(NewTokenIDSequence(), map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 10, TextType: tt},
+		"2": {Lemma: "garden", PoS: noun, Freq: 5, TextType: tt},
+	}, nil, 1)
+	assert.NoError(t, err)
+
+	loaded, err := db.LoadLemmaCache(lemmaCacheEntryOverhead + 6)
+	assert.NoError(t, err)
+	assert.False(t, loaded)
+	assert.False(t, db.LemmaCacheStats().Loaded)
+
+	db.Metadata.NumLemmas = 1000
+	loaded, err = db.LoadLemmaCache(1000)
+	assert.NoError(t, err)
+	assert.False(t, loaded)
+
+	loaded, err = db.LoadLemmaCache(0)
+	assert.NoError(t, err)
+	assert.False(t, loaded)
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrInvalidLemmaPattern is returned for lemma patterns
+// which cannot be compiled or have an unknown syntax
+var ErrInvalidLemmaPattern = errors.New("invalid lemma pattern")
+
+// LemmaPatternSyntax specifies how a lemma pattern is interpreted
+type LemmaPatternSyntax string
+
+const (
+	// LemmaPatternGlob is a shell-like wildcard pattern
+	// (*, ?, [abc], [!abc])
+	LemmaPatternGlob LemmaPatternSyntax = "glob"
+
+	// LemmaPatternRegexp is a regular expression in the RE2 syntax
+	LemmaPatternRegexp LemmaPatternSyntax = "regexp"
+)
+
+func (lps LemmaPatternSyntax) Validate() bool {
+	return lps == "" || lps == LemmaPatternGlob || lps == LemmaPatternRegexp
+}
+
+// ToRegexp converts the pattern to a regular expression (without anchors)
+// matching the same lemmas. A whole lemma must match the expression.
+func (lps LemmaPatternSyntax) ToRegexp(pattern string) string {
+	if lps == LemmaPatternGlob {
+		return globToRegexp(pattern)
+	}
+	return pattern
+}
+
+func globToRegexp(glob string) string {
+	var ans strings.Builder
+	runes := []rune(glob)
+	inClass := false
+	for i, r := range runes {
+		switch {
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+			if r == '\\' {
+				ans.WriteString(`\\`)
+
+			} else {
+				ans.WriteRune(r)
+			}
+		case r == '*':
+			ans.WriteString(".*")
+		case r == '?':
+			ans.WriteString(".")
+		case r == '[' && strings.ContainsRune(string(runes[i+1:]), ']'):
+			inClass = true
+			ans.WriteRune(r)
+			if i+1 < len(runes) && runes[i+1] == '!' {
+				runes[i+1] = '^'
+			}
+		default:
+			ans.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return ans.String()
+}
+
+// compileLemmaPattern compiles the pattern into an anchored regular
+// expression. With foldDiacritics, the expression is expected to be
+// matched against lemmas folded by record.FoldLemma.
+func compileLemmaPattern(pattern string, syntax LemmaPatternSyntax, foldDiacritics bool) (*regexp.Regexp, error) {
+	if syntax == "" || !syntax.Validate() {
+		return nil, fmt.Errorf("%w: unknown syntax %s", ErrInvalidLemmaPattern, syntax)
+	}
+	src := syntax.ToRegexp(pattern)
+	if foldDiacritics {
+		src = record.FoldLemma(src)
+	}
+	ans, err := regexp.Compile("^(?:" + src + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidLemmaPattern, err)
+	}
+	return ans, nil
+}
+
+// GetLemmaIDsByPattern returns all the lemmas matching the pattern.
+// In case the pattern starts with a literal prefix, only lemmas with
+// the prefix are examined. Otherwise, the whole lemma index must be
+// scanned. With ignoreDiacritics, the pattern is matched against lemmas
+// with diacritics removed (e.g. "hri*" matches hřiště).
+// The returned lemmas are sorted alphabetically.
+func (db *DB) GetLemmaIDsByPattern(
+	pattern string,
+	syntax LemmaPatternSyntax,
+	ignoreDiacritics bool,
+) ([]lemmaWithID, error) {
+	re, err := compileLemmaPattern(pattern, syntax, ignoreDiacritics)
+	if err != nil {
+		return []lemmaWithID{}, err
+	}
+	var prefix string
+	if !ignoreDiacritics {
+		prefix, _ = re.LiteralPrefix()
+	}
+	ans := make([]lemmaWithID, 0, 8)
+	err = db.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.EncodeLemmaPrefixKey(prefix)
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			lemma := strings.TrimSpace(string(it.Item().Key()[1:]))
+			tested := lemma
+			if ignoreDiacritics {
+				tested = record.FoldLemma(lemma)
+			}
+			if !re.MatchString(tested) {
+				continue
+			}
+			tokenID, err := decodeItemValue(it.Item(), db.keys.DecodeTokenID)
+			if err != nil {
+				return err
+			}
+			ans = append(ans, lemmaWithID{Value: lemma, TokenID: tokenID})
+		}
+		return nil
+	})
+	return ans, err
+}
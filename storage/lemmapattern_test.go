@@ -0,0 +1,114 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	assert.Equal(t, `run.*`, LemmaPatternGlob.ToRegexp("run*"))
+	assert.Equal(t, `.*ization`, LemmaPatternGlob.ToRegexp("*ization"))
+	assert.Equal(t, `b.g\.x`, LemmaPatternGlob.ToRegexp("b?g.x"))
+	assert.Equal(t, `[^aeiou]at`, LemmaPatternGlob.ToRegexp("[!aeiou]at"))
+	assert.Equal(t, `a\[b`, LemmaPatternGlob.ToRegexp("a[b"))
+	assert.Equal(t, `.*ization`, LemmaPatternRegexp.ToRegexp(".*ization"))
+}
+
+func TestGetLemmaIDsByPattern(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{}
+	for _, lemma := range []string{"run", "runner", "rerun", "organization", "realization", "hřiště"} {
+		singleFreqs[record.GroupingKey(lemma)] = record.TokenFreq{Lemma: lemma, PoS: noun, Freq: 1, TextType: tt}
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, nil, 1)
+	assert.NoError(t, err)
+
+	lemmas := func(pattern string, syntax LemmaPatternSyntax, ignoreDiacritics bool) []string {
+		variants, err := db.GetLemmaIDsByPattern(pattern, syntax, ignoreDiacritics)
+		assert.NoError(t, err)
+		ans := make([]string, len(variants))
+		for i, v := range variants {
+			ans[i] = v.Value
+		}
+		return ans
+	}
+	assert.Equal(t, []string{"run", "runner"}, lemmas("run*", LemmaPatternGlob, false))
+	assert.Equal(t, []string{"rerun", "run"}, lemmas("*run", LemmaPatternGlob, false))
+	assert.Equal(t, []string{"organization", "realization"}, lemmas(".*ization", LemmaPatternRegexp, false))
+	// the whole lemma must match
+	assert.Equal(t, []string{"run"}, lemmas("run", LemmaPatternRegexp, false))
+	assert.Empty(t, lemmas("hri*", LemmaPatternGlob, false))
+	assert.Equal(t, []string{"hřiště"}, lemmas("hri*", LemmaPatternGlob, true))
+
+	_, err = db.GetLemmaIDsByPattern("run(", LemmaPatternRegexp, false)
+	assert.ErrorIs(t, err, ErrInvalidLemmaPattern)
+	_, err = db.GetLemmaIDsByPattern("run", "sql", false)
+	assert.ErrorIs(t, err, ErrInvalidLemmaPattern)
+}
+
+func TestCalculateMeasuresLemmaPattern(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amod := record.ImportUDDeprel("amod")
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "organization", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "realization", PoS: noun, Freq: 10, TextType: tt},
+		"3": {Lemma: "nation", PoS: noun, Freq: 10, TextType: tt},
+		"4": {Lemma: "large", PoS: adj, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "organization", PoS1: noun, Deprel: amod, Lemma2: "large", PoS2: adj,
+			Freq: 4, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "realization", PoS1: noun, Deprel: amod, Lemma2: "large", PoS2: adj,
+			Freq: 2, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "nation", PoS1: noun, Deprel: amod, Lemma2: "large", PoS2: adj,
+			Freq: 5, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:        "*ization",
+		LemmaPattern: LemmaPatternGlob,
+		Limit:        10,
+		SortBy:       sortByLogDice,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "*ization", ans[0].Lemma.Value)
+	assert.Equal(t, 6, ans[0].Freq)
+	assert.Equal(t, 30, ans[0].LemmaFreq)
+
+	_, err = db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:        "*ization",
+		LemmaPattern: "sql",
+		Limit:        10,
+		SortBy:       sortByLogDice,
+	})
+	assert.ErrorIs(t, err, ErrInvalidLemmaPattern)
+}
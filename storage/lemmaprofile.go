@@ -0,0 +1,138 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// LemmaProfile extends LemmaInfo with frequency breakdowns
+// by text types and by syntactic relations of a lemma
+type LemmaProfile struct {
+	LemmaInfo
+	TextTypeFreqs map[string]int `json:"textTypeFreqs"`
+
+	// HeadDeprelFreqs contains summed frequencies of stored pairs
+	// with the lemma as the head split by the pairs' relations
+	HeadDeprelFreqs map[string]int `json:"headDeprelFreqs"`
+
+	// DependentDeprelFreqs contains summed frequencies of stored pairs
+	// with the lemma as the dependent split by the pairs' relations
+	DependentDeprelFreqs map[string]int `json:"dependentDeprelFreqs"`
+}
+
+// GetLemmaProfile provides the same information as GetLemmaInfo along
+// with frequencies of the lemma in individual text types and distributions
+// of relations of the stored pairs containing the lemma. Please note that
+// pairs below the import min. frequency are not stored so the relation
+// frequencies do not have to sum up to the lemma frequency.
+// Entries of excludedTextTypes do not contribute to the frequencies.
+// The relation frequencies are read from the collocation records so the
+// function is more expensive than GetLemmaInfo but it is still much cheaper
+// than a collocation search (e.g. pre-aggregated records of frequent lemmas
+// are used if available). For a non-existing lemma, no error is returned.
+func (db *DB) GetLemmaProfile(lemma string, excludedTextTypes []string) (LemmaProfile, error) {
+	ans := LemmaProfile{
+		LemmaInfo:            LemmaInfo{Lemma: lemma, PoSFreqs: make(map[string]int)},
+		TextTypeFreqs:        make(map[string]int),
+		HeadDeprelFreqs:      make(map[string]int),
+		DependentDeprelFreqs: make(map[string]int),
+	}
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: lemma})
+	if err == badger.ErrKeyNotFound {
+		return ans, nil
+	}
+	if err != nil {
+		return ans, fmt.Errorf("failed to get lemma profile: %w", err)
+	}
+	excludedTT := make(map[byte]bool)
+	for _, tt := range excludedTextTypes {
+		if rawTT := db.textTypes.ReadableToRaw(tt); rawTT > 0 {
+			excludedTT[rawTT] = true
+		}
+	}
+	err = db.view(func(txn *badger.Txn) error {
+		items, err := db.getRawTokenFreqTx(txn, tokenID, 0, 0)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if excludedTT[item.TextType] {
+				continue
+			}
+			ans.Freq += int(item.Freq)
+			ans.PoSFreqs[record.UDPosFromByte(item.PoS).Readable] += int(item.Freq)
+			if item.TextType > 0 {
+				ans.TextTypeFreqs[db.textTypes.RawToReadable(item.TextType)] += int(item.Freq)
+			}
+		}
+		if err := db.addDeprelFreqsTx(txn, tokenID, true, excludedTT, ans.HeadDeprelFreqs); err != nil {
+			return err
+		}
+		return db.addDeprelFreqsTx(txn, tokenID, false, excludedTT, ans.DependentDeprelFreqs)
+	})
+	if err != nil {
+		return ans, fmt.Errorf("failed to get lemma profile: %w", err)
+	}
+	ans.Exists = ans.Freq > 0
+	return ans, nil
+}
+
+// addDeprelFreqsTx sums frequencies of the token's collocation records
+// of one direction by their relations
+func (db *DB) addDeprelFreqsTx(
+	txn *badger.Txn,
+	tokenID uint64,
+	isHead bool,
+	excludedTT map[byte]bool,
+	freqs map[string]int,
+) error {
+	prefix := db.keys.AllCollFreqsOfToken(isHead, tokenID)
+	// hot summaries have text types summed up
+	if len(excludedTT) == 0 && db.hasHotLemmaSummaryTx(txn, isHead, tokenID) {
+		prefix = db.keys.AllHotCollFreqsOfToken(isHead, tokenID)
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		decKey, err := db.keys.DecodeCollFreqKey(key)
+		if err != nil {
+			if err := db.skipMalformed(key, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if excludedTT[decKey.TextType] {
+			continue
+		}
+		val, err := decodeItemValue(it.Item(), record.DecodeCollocValue)
+		if err != nil {
+			if err := db.skipMalformed(key, err); err != nil {
+				return err
+			}
+			continue
+		}
+		freqs[db.DeprelMapping.GetRev(decKey.Deprel)] += int(val.Freq)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLemmaProfile(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adj := record.UDPosFromByte(record.PosADJ)
+	obj := record.ImportUDDeprel("obj")
+	amod := record.ImportUDDeprel("amod")
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 30, TextType: fiction},
+		"2": {Lemma: "house", PoS: noun, Freq: 10, TextType: news},
+		"3": {Lemma: "house", PoS: verb, Freq: 2, TextType: news},
+		"4": {Lemma: "build", PoS: verb, Freq: 20, TextType: fiction},
+		"5": {Lemma: "big", PoS: adj, Freq: 50, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "house", PoS1: noun, Deprel: obj, Lemma2: "build", PoS2: verb,
+			Freq: 6, AVGDist: 1, TextType: fiction, Direction: record.DirectionDependent},
+		"2": {Lemma1: "house", PoS1: noun, Deprel: obj, Lemma2: "build", PoS2: verb,
+			Freq: 3, AVGDist: 1, TextType: news, Direction: record.DirectionDependent},
+		"3": {Lemma1: "house", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj,
+			Freq: 5, AVGDist: 1, TextType: fiction, Direction: record.DirectionHead},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	prof, err := db.GetLemmaProfile("house", nil)
+	assert.NoError(t, err)
+	assert.True(t, prof.Exists)
+	assert.Equal(t, 42, prof.Freq)
+	assert.Equal(t, map[string]int{"NOUN": 40, "VERB": 2}, prof.PoSFreqs)
+	assert.Equal(t, map[string]int{"fiction": 30, "news": 12}, prof.TextTypeFreqs)
+	assert.Equal(t, map[string]int{"amod": 5}, prof.HeadDeprelFreqs)
+	assert.Equal(t, map[string]int{"obj": 9}, prof.DependentDeprelFreqs)
+
+	prof, err = db.GetLemmaProfile("house", []string{"news"})
+	assert.NoError(t, err)
+	assert.Equal(t, 30, prof.Freq)
+	assert.Equal(t, map[string]int{"fiction": 30}, prof.TextTypeFreqs)
+	assert.Equal(t, map[string]int{"obj": 6}, prof.DependentDeprelFreqs)
+
+	// pre-aggregated records provide the same distributions
+	_, err = db.StoreHotLemmaSummaries(1)
+	assert.NoError(t, err)
+	prof, err = db.GetLemmaProfile("house", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"amod": 5}, prof.HeadDeprelFreqs)
+	assert.Equal(t, map[string]int{"obj": 9}, prof.DependentDeprelFreqs)
+
+	prof, err = db.GetLemmaProfile("walk", nil)
+	assert.NoError(t, err)
+	assert.False(t, prof.Exists)
+	assert.Empty(t, prof.HeadDeprelFreqs)
+}
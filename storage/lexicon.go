@@ -0,0 +1,245 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// LexiconArgs configures extraction of a global (corpus-level)
+// collocation lexicon (see DB.ExtractLexicon).
+type LexiconArgs struct {
+
+	// SortBy is a measure the lexicon is ranked by
+	SortBy SortingMeasure
+
+	// MinFreq is a min. frequency of a pair (summed over text types)
+	MinFreq int
+
+	// MinScore, if set, removes pairs with the SortBy measure
+	// lower than the value
+	MinScore *float64
+
+	// Limit, if positive, specifies max. number of returned
+	// (i.e. top ranked) pairs
+	Limit int
+
+	// ExcludedTextTypes specifies text types ignored completely
+	ExcludedTextTypes []string
+
+	// DeprelGranularity, if set to DeprelGranularityCore, merges
+	// relation subtypes into their core relations
+	DeprelGranularity DeprelGranularity
+}
+
+type lexiconPairKey struct {
+	pos1     byte
+	deprel   uint16
+	token2ID uint64
+	pos2     byte
+}
+
+type lexiconPairAcc struct {
+	freq           int
+	distSum        float64
+	surfaceDistSum float64
+}
+
+// lexiconBuilder scores pairs of individual head tokens
+type lexiconBuilder struct {
+	db         *DB
+	args       LexiconArgs
+	excludedTT map[byte]bool
+	useRollups bool
+	corpusSize int64
+	cache      itemsWalktrhoughCache
+	items      []Collocation
+}
+
+func (lb *lexiconBuilder) singleFreqTx(txn *badger.Txn, tokenID uint64, pos byte) (int, error) {
+	freqs, err := lb.cache.getSingleTokenFreqTx(txn, lb.useRollups, tokenID, pos, 0)
+	if err != nil {
+		return 0, err
+	}
+	var ans int
+	for _, f := range freqs {
+		if !lb.excludedTT[f.TextType] {
+			ans += int(f.Freq)
+		}
+	}
+	return ans, nil
+}
+
+// flushTx scores all the accumulated pairs of the head token
+// and adds the ones passing the min. frequency to the lexicon
+func (lb *lexiconBuilder) flushTx(txn *badger.Txn, token1ID uint64, pairs map[lexiconPairKey]*lexiconPairAcc) error {
+	for k, acc := range pairs {
+		if acc.freq < lb.args.MinFreq || acc.freq == 0 {
+			continue
+		}
+		fx, err := lb.singleFreqTx(txn, token1ID, k.pos1)
+		if err != nil {
+			return err
+		}
+		fy, err := lb.singleFreqTx(txn, k.token2ID, k.pos2)
+		if err != nil {
+			return err
+		}
+		lemma1, err := lb.cache.getLemmaByIDTxn(txn, token1ID)
+		if err != nil {
+			return err
+		}
+		lemma2, err := lb.cache.getLemmaByIDTxn(txn, k.token2ID)
+		if err != nil {
+			return err
+		}
+		item := Collocation{
+			Lemma: CollMember{
+				Value: lemma1,
+				PoS:   record.UDPosFromByte(k.pos1).Readable,
+			},
+			Deprel: lb.db.DeprelMapping.GetRev(k.deprel),
+			Collocate: CollMember{
+				Value: lemma2,
+				PoS:   record.UDPosFromByte(k.pos2).Readable,
+			},
+			IsHead:        true,
+			MutualDist:    acc.distSum / float64(acc.freq),
+			SurfaceDist:   acc.surfaceDistSum / float64(acc.freq),
+			CorpusSize:    lb.corpusSize,
+			Freq:          acc.freq,
+			LemmaFreq:     fx,
+			CollocateFreq: fy,
+		}
+		item.UpdateScores(nil)
+		lb.items = append(lb.items, item)
+	}
+	return nil
+}
+
+// ExtractLexicon scores all the stored pairs (i.e. not just collocates
+// of a searched lemma) and returns a lexicon of collocations ranked by
+// args.SortBy. Each pair is returned once - with the head as Lemma and
+// the dependent as Collocate (siblings, having no head, are not included).
+// Frequencies are summed over text types.
+//
+// Please note that the function reads the whole pair index and keeps
+// all the pairs passing args.MinFreq in memory so it is intended for
+// offline jobs (e.g. preparing data for collocation dictionaries).
+func (db *DB) ExtractLexicon(ctx context.Context, args LexiconArgs) ([]Collocation, error) {
+	if !args.SortBy.Validate() {
+		return []Collocation{}, fmt.Errorf("failed to extract lexicon: invalid sorting measure %s", args.SortBy)
+	}
+	excludedTT := make(map[byte]bool)
+	for _, tt := range args.ExcludedTextTypes {
+		if rawTT := db.textTypes.ReadableToRaw(tt); rawTT > 0 {
+			excludedTT[rawTT] = true
+		}
+	}
+	lb := &lexiconBuilder{
+		db:         db,
+		args:       args,
+		excludedTT: excludedTT,
+		useRollups: db.Metadata.HasFeature(FeatureTokenFreqRollups) && len(excludedTT) == 0,
+		corpusSize: db.Metadata.CorpusSize,
+		cache:      itemsWalktrhoughCache{db: db},
+	}
+	releaseScan, err := db.scanGate.acquire(ctx)
+	if err != nil {
+		return []Collocation{}, fmt.Errorf("failed to extract lexicon: %w", err)
+	}
+	defer releaseScan()
+	cancelled := cancelCheck{ctx: ctx}
+	err = db.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.AllCollFreqs(true)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		var currToken uint64
+		pairs := make(map[lexiconPairKey]*lexiconPairAcc)
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := cancelled.err(); err != nil {
+				return err
+			}
+			item := it.Item()
+			key, err := db.keys.DecodeCollFreqKey(item.Key())
+			if err != nil {
+				if err := db.skipMalformed(item.Key(), err); err != nil {
+					return err
+				}
+				continue
+			}
+			if db.keys.IsWordFormTokenID(key.Token1ID) {
+				// word forms (if indexed) duplicate the lemma data
+				continue
+			}
+			if key.Token1ID != currToken {
+				if err := lb.flushTx(txn, currToken, pairs); err != nil {
+					return err
+				}
+				currToken = key.Token1ID
+				pairs = make(map[lexiconPairKey]*lexiconPairAcc)
+			}
+			if excludedTT[key.TextType] {
+				continue
+			}
+			val, err := decodeItemValue(item, record.DecodeCollocValue)
+			if err != nil {
+				if err := db.skipMalformed(item.Key(), err); err != nil {
+					return err
+				}
+				continue
+			}
+			deprel := key.Deprel
+			if args.DeprelGranularity == DeprelGranularityCore {
+				deprel = db.DeprelMapping.CoreOf(deprel)
+			}
+			pk := lexiconPairKey{pos1: key.Pos1, deprel: deprel, token2ID: key.Token2ID, pos2: key.Pos2}
+			acc, ok := pairs[pk]
+			if !ok {
+				acc = &lexiconPairAcc{}
+				pairs[pk] = acc
+			}
+			acc.freq += int(val.Freq)
+			acc.distSum += val.Dist * float64(val.Freq)
+			acc.surfaceDistSum += val.SurfaceDist * float64(val.Freq)
+		}
+		return lb.flushTx(txn, currToken, pairs)
+	})
+	if err != nil {
+		return []Collocation{}, fmt.Errorf("failed to extract lexicon: %w", err)
+	}
+
+	SortCollocations(lb.items, args.SortBy)
+	ans := lb.items
+	if args.MinScore != nil {
+		ans = make([]Collocation, 0, len(lb.items))
+		for _, item := range lb.items {
+			if args.SortBy.ValueOf(item) >= *args.MinScore {
+				ans = append(ans, item)
+			}
+		}
+	}
+	if args.Limit > 0 && len(ans) > args.Limit {
+		ans = ans[:args.Limit]
+	}
+	return ans, nil
+}
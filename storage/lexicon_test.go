@@ -0,0 +1,89 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractLexicon(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adj := record.UDPosFromByte(record.PosADJ)
+	obj := record.ImportUDDeprel("obj")
+	amod := record.ImportUDDeprel("amod")
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "build", PoS: verb, Freq: 20, TextType: fiction},
+		"2": {Lemma: "build", PoS: verb, Freq: 10, TextType: news},
+		"3": {Lemma: "house", PoS: noun, Freq: 40, TextType: fiction},
+		"4": {Lemma: "big", PoS: adj, Freq: 50, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "build", PoS1: verb, Deprel: obj, Lemma2: "house", PoS2: noun,
+			Freq: 6, AVGDist: 1, TextType: fiction, Direction: record.DirectionHead},
+		"2": {Lemma1: "build", PoS1: verb, Deprel: obj, Lemma2: "house", PoS2: noun,
+			Freq: 4, AVGDist: 2, TextType: news, Direction: record.DirectionHead},
+		"3": {Lemma1: "house", PoS1: noun, Deprel: obj, Lemma2: "build", PoS2: verb,
+			Freq: 10, AVGDist: 1, TextType: fiction, Direction: record.DirectionDependent},
+		"4": {Lemma1: "house", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj,
+			Freq: 2, AVGDist: 1, TextType: fiction, Direction: record.DirectionHead},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.ExtractLexicon(context.Background(), LexiconArgs{SortBy: sortByLogDice, MinFreq: 1})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, "build", ans[0].Lemma.Value)
+	assert.Equal(t, "house", ans[0].Collocate.Value)
+	assert.Equal(t, "obj", ans[0].Deprel)
+	assert.Equal(t, 10, ans[0].Freq)
+	assert.Equal(t, 30, ans[0].LemmaFreq)
+	assert.InDelta(t, 1.4, ans[0].MutualDist, 0.0001)
+	// F(x,y) = 10, F(x) = 30, F(y) = 40
+	assert.InDelta(t, 14.0+math.Log2(2*10.0/(30+40)), ans[0].LogDice, 0.0001)
+	assert.Equal(t, "big", ans[1].Collocate.Value)
+
+	ans, err = db.ExtractLexicon(context.Background(), LexiconArgs{SortBy: sortByLogDice, MinFreq: 5})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+
+	minScore := 12.0
+	ans, err = db.ExtractLexicon(context.Background(), LexiconArgs{SortBy: sortByLogDice, MinScore: &minScore})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+
+	ans, err = db.ExtractLexicon(context.Background(), LexiconArgs{SortBy: sortByLogDice, ExcludedTextTypes: []string{"news"}})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, 6, ans[0].Freq)
+	assert.Equal(t, 20, ans[0].LemmaFreq)
+
+	ans, err = db.ExtractLexicon(context.Background(), LexiconArgs{SortBy: sortByLogDice, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+}
@@ -0,0 +1,457 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrIncompatibleProfiles is returned when databases created using
+// different import profiles (and thus possibly different text type
+// codes) are about to be merged
+var ErrIncompatibleProfiles = errors.New("incompatible import profiles")
+
+// ErrWordFormsNotMergeable is returned when a database with indexed
+// word forms is about to be merged (the word form token IDs are
+// not remapped)
+var ErrWordFormsNotMergeable = errors.New("databases with word forms cannot be merged")
+
+// MergeStats describes a finished merge of a database into another one.
+// The numbers of records include only newly created records (i.e. records
+// with frequencies added to existing ones are counted separately).
+type MergeStats struct {
+	NumLemmas        int `json:"numLemmas"`
+	NumNewLemmas     int `json:"numNewLemmas"`
+	NumNewDeprels    int `json:"numNewDeprels"`
+	NumLemmaFreqs    int `json:"numLemmaFreqs"`
+	NumCollFreqs     int `json:"numCollFreqs"`
+	NumPathCollFreqs int `json:"numPathCollFreqs"`
+	NumPairExamples  int `json:"numPairExamples"`
+	NumSummedRecords int `json:"numSummedRecords"`
+}
+
+// unifyDeprels extends the deprel mapping target by the relations of src
+// not known to target yet. Such relations keep their src codes if the codes
+// are not used by target. Otherwise, they get new codes following
+// the highest code of both the mappings (in the order of their src codes).
+// The returned map translates src codes to the codes of the extended mapping.
+func unifyDeprels(target, src map[string]uint16) (map[string]uint16, map[uint16]uint16) {
+	ans := make(map[string]uint16, len(target)+len(src))
+	usedCodes := make(map[uint16]bool, len(target)+len(src))
+	var nextCode uint16
+	for k, v := range target {
+		ans[k] = v
+		usedCodes[v] = true
+		nextCode = max(nextCode, v+1)
+	}
+	labels := make([]string, 0, len(src))
+	for k, v := range src {
+		labels = append(labels, k)
+		nextCode = max(nextCode, v+1)
+	}
+	slices.SortFunc(labels, func(a, b string) int {
+		return int(src[a]) - int(src[b])
+	})
+	remap := make(map[uint16]uint16, len(src))
+	for _, label := range labels {
+		code, ok := ans[label]
+		if !ok {
+			code = src[label]
+			if usedCodes[code] {
+				code = nextCode
+				nextCode++
+			}
+			ans[label] = code
+			usedCodes[code] = true
+		}
+		remap[src[label]] = code
+	}
+	return ans, remap
+}
+
+// mergedMetadata combines metadata of two databases with disjoint
+// source data. Optional features are kept only if both the databases
+// have them. Hot lemma summaries and top collocations are never kept
+// as they are not mergeable (see StoreHotLemmaSummaries,
+// StoreTopCollocations).
+func mergedMetadata(curr, src Metadata, stats MergeStats) Metadata {
+	ans := curr
+	ans.CorpusSize += src.CorpusSize
+	// not all the records are re-encoded during merging so the merged
+	// database may need the migrations of both the databases
+	ans.SchemaVersion = min(curr.SchemaVersion, src.SchemaVersion)
+	ans.NumLemmas += stats.NumNewLemmas
+	ans.NumLemmaFreqs += stats.NumLemmaFreqs
+	ans.NumCollFreqs += stats.NumCollFreqs
+	// the pruning has been applied to the individual databases
+	ans.MinPairFreq = max(curr.MinPairFreq, src.MinPairFreq)
+	ans.RelationDists = MergeRelationDists(curr.RelationDists, src.RelationDists)
+	ans.Features = make([]DatasetFeature, 0, len(AllDatasetFeatures))
+	for _, f := range AllDatasetFeatures {
+		if curr.HasFeature(f) && src.HasFeature(f) {
+			ans.Features = append(ans.Features, f)
+		}
+	}
+	// records split by different features cannot be grouped consistently
+	// (but they still can be searched with the features ignored)
+	if !slices.Equal(curr.MorphFeats, src.MorphFeats) {
+		ans.Features = slices.DeleteFunc(ans.Features, func(f DatasetFeature) bool {
+			return f == FeatureMorphFeats
+		})
+	}
+	if !ans.HasFeature(FeatureMorphFeats) {
+		ans.MorphFeats = nil
+	}
+	if ans.HasFeature(FeaturePathCollocations) {
+		ans.PathCollocationsDepth = min(curr.PathCollocationsDepth, src.PathCollocationsDepth)
+
+	} else {
+		ans.PathCollocationsDepth = 0
+	}
+	if ans.HasFeature(FeaturePairExamples) {
+		ans.PairExamples = max(curr.PairExamples, src.PairExamples)
+
+	} else {
+		ans.PairExamples = 0
+	}
+	if !slices.Equal(curr.DeprelBlocklist, src.DeprelBlocklist) {
+		ans.DeprelBlocklist = nil
+	}
+	// relation definitions do not depend on data so the ones
+	// of the target database are preferred
+	if len(ans.Relations) == 0 {
+		ans.Relations = src.Relations
+	}
+	return ans
+}
+
+// withoutPrecomputedRecords returns metadata with the hot lemma
+// summaries and top collocations features removed and legacy feature
+// attributes synchronized with the list of features.
+func withoutPrecomputedRecords(m Metadata) Metadata {
+	m.Features = slices.DeleteFunc(m.AvailableFeatures(), func(f DatasetFeature) bool {
+		return f == FeatureHotLemmaSummaries || f == FeatureTopCollocations
+	})
+	m.HotLemmaThreshold = 0
+	m.NumHotLemmas = 0
+	m.TopCollocations = nil
+	m.SurfaceDist = m.HasFeature(FeatureSurfaceDist)
+	m.TokenFreqRollups = m.HasFeature(FeatureTokenFreqRollups)
+	m.Siblings = m.HasFeature(FeatureSiblings)
+	return m
+}
+
+// mergeCollocValue adds a pair frequency to a record stored under
+// the key with distances averaged using the frequencies as weights
+// (the record is created if it does not exist yet). The existing value
+// is read using txn and the result is written to w. The returned value
+// tells whether a new record has been created.
+func (db *DB) mergeCollocValue(txn *badger.Txn, w keyValueSetter, key []byte, value record.CollocValue) (bool, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return true, w.Set(
+			key, record.EncodeCollocValueWithSurfaceDist(value.Freq, value.Dist, value.SurfaceDist))
+	}
+	if err != nil {
+		return false, err
+	}
+	curr, err := decodeItemValue(item, record.DecodeCollocValue)
+	if err != nil {
+		return false, err
+	}
+	total := float64(curr.Freq) + float64(value.Freq)
+	if total == 0 {
+		return false, nil
+	}
+	dist := (curr.Dist*float64(curr.Freq) + value.Dist*float64(value.Freq)) / total
+	surfaceDist := value.SurfaceDist
+	if curr.HasSurfaceDist {
+		surfaceDist = (curr.SurfaceDist*float64(curr.Freq) + value.SurfaceDist*float64(value.Freq)) / total
+	}
+	return false, w.Set(
+		key, record.EncodeCollocValueWithSurfaceDist(curr.Freq+value.Freq, dist, surfaceDist))
+}
+
+// setIfMissing writes the record only if the key is not stored yet.
+func setIfMissing(txn *badger.Txn, w keyValueSetter, key, value []byte) (bool, error) {
+	_, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return true, w.Set(key, value)
+	}
+	return false, err
+}
+
+// Merge adds all the data of src to the database. Lemmas are matched
+// by their values (lemmas unknown to the database get new token IDs),
+// frequencies of matching records are summed and distances are averaged.
+// Relations unknown to the database are added to its deprel mapping.
+// Both the databases must be created using the same import profile
+// and key layout (see record.KeyLayout) and none of them may contain
+// word forms (see ErrWordFormsNotMergeable).
+// In case the database is empty (i.e. it has no metadata), the merge
+// just copies src.
+//
+// Metadata (with summed corpus size) are updated only in the Metadata
+// attribute, i.e. once all the sources are merged, StoreMetadata must
+// be called. Hot lemma summaries and top collocations are not merged -
+// they must be created again via StoreHotLemmaSummaries and
+// StoreTopCollocations.
+//
+// The token ID mapping is kept in a temporary on-disk database created
+// within tmpDir (empty = system default) so the memory usage does not
+// depend on the database sizes.
+func (db *DB) Merge(src *DB, tmpDir string) (MergeStats, error) {
+	var stats MergeStats
+	if src.Metadata.HasFeature(FeatureWordForms) || db.Metadata.HasFeature(FeatureWordForms) {
+		return stats, fmt.Errorf("failed to merge databases: %w", ErrWordFormsNotMergeable)
+	}
+	empty := db.Metadata.ProfileName == "" && db.Metadata.CorpusSize == 0
+	if !empty && db.Metadata.ProfileName != src.Metadata.ProfileName {
+		return stats, fmt.Errorf(
+			"failed to merge databases: %w: %s and %s",
+			ErrIncompatibleProfiles, db.Metadata.ProfileName, src.Metadata.ProfileName,
+		)
+	}
+	if !empty && !maps.Equal(db.Metadata.FileTextTypes, src.Metadata.FileTextTypes) {
+		return stats, fmt.Errorf(
+			"failed to merge databases: %w: different file text types", ErrIncompatibleProfiles)
+	}
+	if empty {
+		db.keys = src.keys
+
+	} else if db.keys != src.keys {
+		return stats, fmt.Errorf("failed to merge databases: %w", ErrIncompatibleKeyLayouts)
+	}
+	deprels, deprelRemap := unifyDeprels(db.Metadata.DeprelMap, src.Metadata.DeprelMap)
+	stats.NumNewDeprels = len(deprels) - len(db.Metadata.DeprelMap)
+	copyRollups := src.Metadata.HasFeature(FeatureTokenFreqRollups) &&
+		(empty || db.Metadata.HasFeature(FeatureTokenFreqRollups))
+
+	mappingDir, err := os.MkdirTemp(tmpDir, "depreldb-merge-")
+	if err != nil {
+		return stats, fmt.Errorf("failed to merge databases: %w", err)
+	}
+	defer os.RemoveAll(mappingDir)
+	mapping, err := badger.Open(badger.DefaultOptions(mappingDir).WithLogger(nil))
+	if err != nil {
+		return stats, fmt.Errorf("failed to merge databases: %w", err)
+	}
+	defer mapping.Close()
+	remapStats, err := buildIDMapping(src, db, mapping)
+	if err != nil {
+		return stats, fmt.Errorf("failed to merge databases: %w", err)
+	}
+	stats.NumLemmas = remapStats.NumLemmas
+	stats.NumNewLemmas = remapStats.NumNewLemmas
+
+	w := db.newBatchWriter("merge", 0)
+	defer w.Cancel()
+	err = db.bdb.View(func(txn *badger.Txn) error {
+		return mapping.View(func(mappingTxn *badger.Txn) error {
+			remap := func(tokenID uint64) (uint64, error) {
+				item, err := mappingTxn.Get(db.keys.TokenIDToBytes(tokenID))
+				if err != nil {
+					return 0, fmt.Errorf("failed to find mapping of token ID %d: %w", tokenID, err)
+				}
+				return decodeItemValue(item, db.keys.DecodeTokenID)
+			}
+			return src.bdb.View(func(srcTxn *badger.Txn) error {
+				it := srcTxn.NewIterator(badger.DefaultIteratorOptions)
+				defer it.Close()
+				for it.Rewind(); it.Valid(); it.Next() {
+					if err := db.mergeItem(txn, w, it.Item(), remap, deprelRemap, copyRollups, &stats); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to merge databases: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return stats, fmt.Errorf("failed to merge databases: %w", err)
+	}
+	if empty {
+		db.Metadata = src.Metadata
+		db.Metadata.NumLemmas = stats.NumNewLemmas
+		db.Metadata.NumLemmaFreqs = stats.NumLemmaFreqs
+		db.Metadata.NumCollFreqs = stats.NumCollFreqs
+
+	} else {
+		db.Metadata = mergedMetadata(db.Metadata, src.Metadata, stats)
+	}
+	db.Metadata = withoutPrecomputedRecords(db.Metadata)
+	db.Metadata.DeprelMap = deprels
+	db.DeprelMapping = record.DeprelMappingFromMap(deprels)
+	return stats, nil
+}
+
+// mergeItem writes a single src record (with token IDs and deprels
+// translated) to w. Index records are written only if missing,
+// frequency records are summed with the existing ones. Metadata,
+// hot lemma summaries, top collocations and unknown records are skipped.
+func (db *DB) mergeItem(
+	txn *badger.Txn,
+	w keyValueSetter,
+	item *badger.Item,
+	remap func(tokenID uint64) (uint64, error),
+	deprelRemap map[uint16]uint16,
+	copyRollups bool,
+	stats *MergeStats,
+) error {
+	switch record.KeyNamespace(item.Key()) {
+	case "lemmaToID", "foldedLemmaToID", "lowerLemmaToID":
+		srcID, err := decodeItemValue(item, db.keys.DecodeTokenID)
+		if err != nil {
+			return err
+		}
+		newID, err := remap(srcID)
+		if err != nil {
+			return err
+		}
+		_, err = setIfMissing(txn, w, item.KeyCopy(nil), db.keys.TokenIDToBytes(newID))
+		return err
+
+	case "idToLemma":
+		key, err := db.keys.RemapKeyTokenIDs(item.Key(), remap)
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		_, err = setIfMissing(txn, w, key, value)
+		return err
+
+	case "tokenFreq":
+		created, err := db.mergeTokenItem(txn, w, item, remap)
+		if err != nil {
+			return err
+		}
+		if created {
+			stats.NumLemmaFreqs++
+
+		} else {
+			stats.NumSummedRecords++
+		}
+
+	case "tokenRollup":
+		if !copyRollups {
+			return nil
+		}
+		created, err := db.mergeTokenItem(txn, w, item, remap)
+		if err != nil {
+			return err
+		}
+		if !created {
+			stats.NumSummedRecords++
+		}
+
+	case "pairFreq", "revPairFreq", "pathPairFreq", "revPathPairFreq":
+		ns := record.KeyNamespace(item.Key())
+		srcKey, err := db.keys.DecodeCollFreqKey(item.Key())
+		if err != nil {
+			return err
+		}
+		token1ID, err := remap(srcKey.Token1ID)
+		if err != nil {
+			return err
+		}
+		token2ID, err := remap(srcKey.Token2ID)
+		if err != nil {
+			return err
+		}
+		deprel, ok := deprelRemap[srcKey.Deprel]
+		if !ok {
+			// e.g. databases without a stored deprel mapping
+			deprel = srcKey.Deprel
+		}
+		key := record.WithFeats(
+			db.keys.CollFreqKey(
+				srcKey.IsHead, token1ID, srcKey.Pos1, srcKey.TextType, deprel, token2ID, srcKey.Pos2),
+			srcKey.Feats)
+		isPath := ns == "pathPairFreq" || ns == "revPathPairFreq"
+		if isPath {
+			key = record.AsPathCollFreqKey(key)
+		}
+		value, err := decodeItemValue(item, record.DecodeCollocValue)
+		if err != nil {
+			return err
+		}
+		created, err := db.mergeCollocValue(txn, w, key, value)
+		if err != nil {
+			return err
+		}
+		if created && isPath {
+			stats.NumPathCollFreqs++
+
+		} else if created {
+			stats.NumCollFreqs++
+
+		} else {
+			stats.NumSummedRecords++
+		}
+
+	case "pairExamples":
+		key, err := db.keys.RemapKeyTokenIDs(item.Key(), remap)
+		if err != nil {
+			return err
+		}
+		examples, err := decodeItemValue(item, record.DecodePairExamples)
+		if err != nil {
+			return err
+		}
+		curr, err := readPairExamples(txn, key)
+		if err != nil {
+			return err
+		}
+		if len(curr) == 0 {
+			stats.NumPairExamples++
+		}
+		return w.Set(key, record.EncodePairExamples(db.mergedPairExamples(curr, examples)))
+	}
+	return nil
+}
+
+// mergeTokenItem adds a src token frequency (or rollup) record
+// to the database (see mergeTokenValue).
+func (db *DB) mergeTokenItem(
+	txn *badger.Txn,
+	w keyValueSetter,
+	item *badger.Item,
+	remap func(tokenID uint64) (uint64, error),
+) (bool, error) {
+	key, err := db.keys.RemapKeyTokenIDs(item.Key(), remap)
+	if err != nil {
+		return false, err
+	}
+	value, err := decodeItemValue(item, record.DecodeTokenValue)
+	if err != nil {
+		return false, err
+	}
+	return db.mergeTokenValue(txn, w, key, int(value.Freq))
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeDBs(t *testing.T) {
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amod := record.ImportUDDeprel("amod")
+	objAmod := record.UDDeprel{Raw: 0x0100, Readable: "obj→amod"}
+	nsubjAmod := record.UDDeprel{Raw: 0x0100, Readable: "nsubj→amod"}
+
+	src1 := openTestDB(t)
+	_, err := src1.StoreData(
+		NewTokenIDSequence(),
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "house", PoS: noun, Freq: 10, TextType: tt},
+			"2": {Lemma: "green", PoS: adj, Freq: 6, TextType: tt},
+		},
+		map[record.GroupingKey]record.CollocFreq{
+			"1": {Lemma1: "house", PoS1: noun, Deprel: amod, Lemma2: "green", PoS2: adj,
+				Freq: 4, AVGDist: 1, TextType: tt, Direction: record.DirectionHead},
+			"2": {Lemma1: "house", PoS1: noun, Deprel: objAmod, Lemma2: "green", PoS2: adj,
+				Freq: 2, AVGDist: 2, TextType: tt, Direction: record.DirectionHead},
+		},
+		1,
+	)
+	assert.NoError(t, err)
+	src1.Metadata = Metadata{
+		ProfileName: "test",
+		CorpusSize:  1000,
+		DeprelMap:   map[string]uint16{"amod": amod.Raw, "obj→amod": 0x0100},
+	}
+
+	src2 := openTestDB(t)
+	_, err = src2.StoreData(
+		NewTokenIDSequence(),
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "red", PoS: adj, Freq: 3, TextType: tt},
+			"2": {Lemma: "house", PoS: noun, Freq: 5, TextType: tt},
+		},
+		map[record.GroupingKey]record.CollocFreq{
+			"1": {Lemma1: "house", PoS1: noun, Deprel: amod, Lemma2: "red", PoS2: adj,
+				Freq: 3, AVGDist: 1, TextType: tt, Direction: record.DirectionHead},
+			"2": {Lemma1: "house", PoS1: noun, Deprel: nsubjAmod, Lemma2: "red", PoS2: adj,
+				Freq: 1, AVGDist: 3, TextType: tt, Direction: record.DirectionHead},
+		},
+		1,
+	)
+	assert.NoError(t, err)
+	src2.Metadata = Metadata{
+		ProfileName: "test",
+		CorpusSize:  500,
+		DeprelMap:   map[string]uint16{"amod": amod.Raw, "nsubj→amod": 0x0100},
+	}
+
+	dst := openTestDB(t)
+	stats, err := dst.Merge(src1, t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.NumNewLemmas)
+	stats, err = dst.Merge(src2, t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.NumLemmas)
+	assert.Equal(t, 1, stats.NumNewLemmas)
+	assert.Equal(t, 1, stats.NumNewDeprels)
+
+	assert.Equal(t, int64(1500), dst.Metadata.CorpusSize)
+	assert.Equal(t, 3, dst.Metadata.NumLemmas)
+	assert.Equal(t, uint16(0x0101), dst.Metadata.DeprelMap["nsubj→amod"])
+	assert.Equal(t, uint16(0x0100), dst.Metadata.DeprelMap["obj→amod"])
+
+	freq, err := dst.GetLemmaFreq("house", "", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 15, freq)
+	freq, err = dst.GetLemmaFreq("red", "", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, freq)
+
+	deprelStats, err := dst.GetDeprelStats(context.Background(), 0, nil)
+	assert.NoError(t, err)
+	freqs := make(map[string]int)
+	for _, ds := range deprelStats {
+		freqs[ds.Deprel] = ds.Freq
+	}
+	assert.Equal(t, map[string]int{"amod": 7, "obj→amod": 2, "nsubj→amod": 1}, freqs)
+}
+
+func TestMergeDBsIncompatibleProfiles(t *testing.T) {
+	src := openTestDB(t)
+	src.Metadata = Metadata{ProfileName: "foo", CorpusSize: 10}
+	dst := openTestDB(t)
+	dst.Metadata = Metadata{ProfileName: "bar", CorpusSize: 10}
+	_, err := dst.Merge(src, t.TempDir())
+	assert.ErrorIs(t, err, ErrIncompatibleProfiles)
+}
+
+func TestMergeDBsDifferentFileTextTypes(t *testing.T) {
+	src := openTestDB(t)
+	src.Metadata = Metadata{
+		ProfileName: "foo", CorpusSize: 10, FileTextTypes: map[string]byte{"news": 1}}
+	dst := openTestDB(t)
+	dst.Metadata = Metadata{
+		ProfileName: "foo", CorpusSize: 10, FileTextTypes: map[string]byte{"fiction": 1, "news": 2}}
+	_, err := dst.Merge(src, t.TempDir())
+	assert.ErrorIs(t, err, ErrIncompatibleProfiles)
+}
+
+func TestMergeDBsWithWordForms(t *testing.T) {
+	src := openTestDB(t)
+	src.Metadata = Metadata{ProfileName: "foo", CorpusSize: 10, Features: []DatasetFeature{FeatureWordForms}}
+	dst := openTestDB(t)
+	dst.Metadata = Metadata{ProfileName: "foo", CorpusSize: 10}
+	_, err := dst.Merge(src, t.TempDir())
+	assert.ErrorIs(t, err, ErrWordFormsNotMergeable)
+}
+
+func TestUnifyDeprels(t *testing.T) {
+	unified, remap := unifyDeprels(
+		map[string]uint16{"amod": 1, "obj→amod": 5},
+		map[string]uint16{"amod": 1, "nsubj→amod": 5, "obl→amod": 6},
+	)
+	assert.Equal(t, map[string]uint16{"amod": 1, "obj→amod": 5, "nsubj→amod": 7, "obl→amod": 6}, unified)
+	assert.Equal(t, map[uint16]uint16{1: 1, 5: 7, 6: 6}, remap)
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are upper bounds (in seconds) of the query
+// latency histogram buckets
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metrics collects statistics about collocation queries and database
+// internals. A nil instance means the metrics are disabled and all
+// the methods are NOP.
+type metrics struct {
+	mu sync.Mutex
+
+	numQueries   int64
+	numErrors    int64
+	numScanned   int64
+	cacheHits    int64
+	cacheMisses  int64
+	latencySum   float64
+	buckets      []float64
+	bucketCounts []int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		buckets:      DefaultLatencyBuckets,
+		bucketCounts: make([]int64, len(DefaultLatencyBuckets)),
+	}
+}
+
+// observeQuery records a finished collocation query along with
+// the number of scanned pair records and hits/misses of the per-query
+// lookup cache (see itemsWalktrhoughCache)
+func (m *metrics) observeQuery(dur time.Duration, err error, numScanned int, cache itemsWalktrhoughCache) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.numQueries++
+	if err != nil {
+		m.numErrors++
+	}
+	m.numScanned += int64(numScanned)
+	m.cacheHits += int64(cache.numHits)
+	m.cacheMisses += int64(cache.numMisses)
+	m.latencySum += dur.Seconds()
+	for i, b := range m.buckets {
+		if dur.Seconds() <= b {
+			m.bucketCounts[i]++
+		}
+	}
+}
+
+// writePrometheus writes the metrics in the Prometheus text exposition format
+func (m *metrics) writePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pw := promWriter{w: w}
+	pw.metric("depreldb_queries_total", "counter", "Number of collocation queries.", m.numQueries)
+	pw.metric("depreldb_query_errors_total", "counter", "Number of failed collocation queries.", m.numErrors)
+	pw.header("depreldb_query_duration_seconds", "histogram", "Collocation query latency.")
+	for i, b := range m.buckets {
+		pw.printf("depreldb_query_duration_seconds_bucket{le=\"%g\"} %d\n", b, m.bucketCounts[i])
+	}
+	pw.printf("depreldb_query_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.numQueries)
+	pw.printf("depreldb_query_duration_seconds_sum %g\n", m.latencySum)
+	pw.printf("depreldb_query_duration_seconds_count %d\n", m.numQueries)
+	pw.metric("depreldb_scanned_items_total", "counter", "Number of pair records scanned by collocation queries.", m.numScanned)
+	pw.metric("depreldb_lookup_cache_hits_total", "counter", "Number of frequency and lemma lookups resolved by a per-query cache.", m.cacheHits)
+	pw.metric("depreldb_lookup_cache_misses_total", "counter", "Number of frequency and lemma lookups not resolved by a per-query cache.", m.cacheMisses)
+	return pw.err
+}
+
+// promWriter writes Prometheus text format lines and remembers
+// the first error so the error checking can be done just once
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (pw *promWriter) printf(format string, args ...any) {
+	if pw.err != nil {
+		return
+	}
+	_, pw.err = fmt.Fprintf(pw.w, format, args...)
+}
+
+func (pw *promWriter) header(name, typ, help string) {
+	pw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (pw *promWriter) metric(name, typ, help string, value any) {
+	pw.header(name, typ, help)
+	pw.printf("%s %v\n", name, value)
+}
+
+// EnableMetrics makes the database to collect statistics about
+// collocation queries (latency, number of scanned records, lookup cache
+// efficiency) which can be exported via WriteMetrics.
+// The method is expected to be called before the database starts
+// serving queries.
+func (db *DB) EnableMetrics() {
+	db.metrics = newMetrics()
+}
+
+// WriteMetrics writes the collected query statistics along with
+// the current state of the database (LSM tree and value log sizes,
+// scan concurrency limiter) in the Prometheus text exposition format.
+// In case the metrics are not enabled (see EnableMetrics), only
+// the database state is written.
+func (db *DB) WriteMetrics(w io.Writer) error {
+	if db.metrics != nil {
+		if err := db.metrics.writePrometheus(w); err != nil {
+			return fmt.Errorf("failed to write metrics: %w", err)
+		}
+	}
+	lsm, vlog := db.bdb.Size()
+	gate := db.ScanGateStats()
+	pw := promWriter{w: w}
+	pw.metric("depreldb_badger_lsm_size_bytes", "gauge", "Size of the Badger LSM tree.", lsm)
+	pw.metric("depreldb_badger_vlog_size_bytes", "gauge", "Size of the Badger value log.", vlog)
+	pw.metric("depreldb_scan_gate_running", "gauge", "Number of running heavy scans.", gate.Running)
+	pw.metric("depreldb_scan_gate_waiting", "gauge", "Number of queries waiting for a scan slot.", gate.Waiting)
+	pw.metric("depreldb_scan_gate_timeouts_total", "counter", "Number of queries which timed out waiting for a scan slot.", gate.NumTimeouts)
+	if pw.err != nil {
+		return fmt.Errorf("failed to write metrics: %w", pw.err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "work", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "start", PoS: verb, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "work", PoS1: noun, Lemma2: "start", PoS2: verb, Freq: 15, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, db.WriteMetrics(&buf))
+	assert.Contains(t, buf.String(), "depreldb_badger_lsm_size_bytes ")
+	assert.NotContains(t, buf.String(), "depreldb_queries_total")
+
+	db.EnableMetrics()
+	_, err = db.CalculateMeasures(context.Background(), CalculationArgs{Lemma: "work", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	db.SetScanLimit(1, 0)
+	_, err = db.CalculateMeasures(ctx, CalculationArgs{Lemma: "work", Limit: 10, SortBy: sortByLogDice})
+	assert.Error(t, err)
+
+	buf.Reset()
+	assert.NoError(t, db.WriteMetrics(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "# TYPE depreldb_queries_total counter\ndepreldb_queries_total 2\n")
+	assert.Contains(t, out, "depreldb_query_errors_total 1\n")
+	assert.Contains(t, out, "depreldb_query_duration_seconds_bucket{le=\"+Inf\"} 2\n")
+	assert.Contains(t, out, "depreldb_query_duration_seconds_count 2\n")
+	assert.Contains(t, out, "depreldb_scanned_items_total 1\n")
+	assert.Contains(t, out, "depreldb_scan_gate_running 0\n")
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// SchemaVersion is a version of the data layout (keys and values)
+// written by this version of depreldb. Each change of the layout
+// increases the version and registers a migration (see Migrate)
+// upgrading older databases. Reading code keeps supporting older
+// layouts where possible so the migrations are typically just
+// a way to get rid of the conversions performed on the fly.
+const SchemaVersion = 2
+
+// ErrUnsupportedSchema is returned when opening a database
+// created by a newer version of depreldb
+var ErrUnsupportedSchema = errors.New("unsupported database schema version")
+
+// migrationCheckInterval specifies how often (in number of records)
+// a migration checks for context cancellation
+const migrationCheckInterval = 10000
+
+// Migration upgrades records of a database from the previous
+// schema version to Version.
+type Migration struct {
+
+	// Version is the schema version the migration upgrades a database to
+	Version int `json:"version"`
+
+	// Description is a short human-readable description of the change
+	Description string `json:"description"`
+
+	// apply performs the migration and returns the number
+	// of updated records
+	apply func(ctx context.Context, db *DB) (int, error)
+}
+
+// MigrationResult describes an applied migration
+type MigrationResult struct {
+	Migration
+	NumUpdated int `json:"numUpdated"`
+}
+
+// migrations lists all the registered migrations ordered by their versions
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "replace signed distances of legacy pair records with absolute ones",
+		apply:       migrateUnsignedDistances,
+	},
+	{
+		Version:     2,
+		Description: "record width of token IDs in metadata (databases with 8-byte IDs require this version)",
+		apply:       migrateTokenIDWidth,
+	},
+}
+
+// PendingMigrations returns migrations needed to upgrade
+// a database with the metadata to the current SchemaVersion.
+// Databases created before versions were recorded have
+// version zero so all the migrations are applicable.
+func (m Metadata) PendingMigrations() []Migration {
+	ans := make([]Migration, 0, len(migrations))
+	for _, mg := range migrations {
+		if mg.Version > m.SchemaVersion {
+			ans = append(ans, mg)
+		}
+	}
+	return ans
+}
+
+// checkSchemaVersion tests whether the metadata describe
+// a database this version of depreldb is able to read.
+func (m Metadata) checkSchemaVersion() error {
+	if m.SchemaVersion > SchemaVersion {
+		return fmt.Errorf(
+			"%w: database has version %d, the highest supported is %d",
+			ErrUnsupportedSchema, m.SchemaVersion, SchemaVersion)
+	}
+	return nil
+}
+
+// KeyLayout returns the layout of keys (i.e. the width of token IDs)
+// of the database with the metadata
+func (m Metadata) KeyLayout() (record.KeyLayout, error) {
+	ans, err := record.KeyLayoutByIDWidth(m.TokenIDWidth)
+	if err != nil {
+		return ans, fmt.Errorf("%w: %w", ErrUnsupportedSchema, err)
+	}
+	return ans, nil
+}
+
+// Migrate applies all the pending migrations (see Metadata.PendingMigrations)
+// in place. The schema version stored in metadata is updated after each
+// of the migrations so an interrupted run can be resumed by calling
+// the method again (the migrations are written to be idempotent).
+// The database must be opened via OpenDB. It is recommended to check
+// the database for keys from unknown namespaces (see UnknownKeyPrefixes)
+// first as they are left untouched.
+func (db *DB) Migrate(ctx context.Context) ([]MigrationResult, error) {
+	if err := db.Metadata.checkSchemaVersion(); err != nil {
+		return []MigrationResult{}, err
+	}
+	pending := db.Metadata.PendingMigrations()
+	ans := make([]MigrationResult, 0, len(pending))
+	for _, mg := range pending {
+		numUpdated, err := mg.apply(ctx, db)
+		if err != nil {
+			return ans, fmt.Errorf("failed to migrate database to version %d: %w", mg.Version, err)
+		}
+		db.Metadata.SchemaVersion = mg.Version
+		if err := db.StoreMetadata(db.Metadata); err != nil {
+			return ans, fmt.Errorf("failed to migrate database to version %d: %w", mg.Version, err)
+		}
+		ans = append(ans, MigrationResult{Migration: mg, NumUpdated: numUpdated})
+	}
+	return ans, nil
+}
+
+// rewriteRecords walks through all the records with the provided key prefix
+// and stores the records changed by the rewrite function. In case
+// the function changes the key (i.e. the key layout is upgraded),
+// the original record is removed. The function must not change
+// the key prefix as the new records would be processed again.
+// The number of changed records is returned.
+func (db *DB) rewriteRecords(
+	ctx context.Context,
+	prefix []byte,
+	rewrite func(key, val []byte) ([]byte, []byte, bool, error),
+) (int, error) {
+	wb := db.bdb.NewWriteBatch()
+	defer wb.Cancel()
+	var numChanged int
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		var numRead int
+		for it.Rewind(); it.Valid(); it.Next() {
+			numRead++
+			if numRead%migrationCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			key := it.Item().KeyCopy(nil)
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			newKey, newVal, changed, err := rewrite(key, val)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			if !bytes.Equal(key, newKey) {
+				if err := wb.Delete(key); err != nil {
+					return err
+				}
+			}
+			if err := wb.Set(newKey, newVal); err != nil {
+				return err
+			}
+			numChanged++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, err
+	}
+	return numChanged, nil
+}
+
+// migrateUnsignedDistances replaces negative distances stored by older
+// versions in pair records where the first token is a dependent
+// (see record.UnsignedCollocValue)
+func migrateUnsignedDistances(ctx context.Context, db *DB) (int, error) {
+	var ans int
+	for _, isHead := range []bool{true, false} {
+		numChanged, err := db.rewriteRecords(
+			ctx,
+			record.AllCollFreqs(isHead),
+			func(key, val []byte) ([]byte, []byte, bool, error) {
+				newVal, changed := record.UnsignedCollocValue(val)
+				return key, newVal, changed, nil
+			},
+		)
+		if err != nil {
+			return 0, err
+		}
+		ans += numChanged
+	}
+	return ans, nil
+}
+
+// migrateTokenIDWidth stores the width of token IDs explicitly. Older
+// databases always use the default 4-byte IDs so no records need
+// to be converted (the updated metadata are stored by Migrate).
+func migrateTokenIDWidth(ctx context.Context, db *DB) (int, error) {
+	if db.Metadata.TokenIDWidth != 0 {
+		return 0, nil
+	}
+	db.Metadata.TokenIDWidth = db.keys.TokenIDWidth()
+	return 1, nil
+}
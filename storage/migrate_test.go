@@ -0,0 +1,89 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	legacyKey := db.keys.CollFreqKey(false, 7, record.PosADJ, 0x01, 3, 9, record.PosNOUN)
+	currentKey := db.keys.CollFreqKey(true, 9, record.PosNOUN, 0x01, 3, 7, record.PosADJ)
+	err := db.bdb.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(legacyKey, record.EncodeCollocValue(5, -1.5)); err != nil {
+			return err
+		}
+		return txn.Set(currentKey, record.EncodeCollocValue(5, 1.5))
+	})
+	assert.NoError(t, err)
+	assert.Len(t, db.Metadata.PendingMigrations(), len(migrations))
+
+	ans, err := db.Migrate(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, 1, ans[0].Version)
+	assert.Equal(t, 1, ans[0].NumUpdated)
+	assert.Equal(t, 2, ans[1].Version)
+	assert.Equal(t, 4, db.Metadata.TokenIDWidth)
+	assert.Equal(t, SchemaVersion, db.Metadata.SchemaVersion)
+	assert.Empty(t, db.Metadata.PendingMigrations())
+
+	stored, err := db.StoredMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersion, stored.SchemaVersion)
+	assert.Equal(t, 4, stored.TokenIDWidth)
+
+	err = db.bdb.View(func(txn *badger.Txn) error {
+		for _, key := range [][]byte{legacyKey, currentKey} {
+			item, err := txn.Get(key)
+			if err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, record.EncodeCollocValue(5, 1.5), val)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// nothing to do for an up-to-date database
+	ans, err = db.Migrate(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.SchemaVersion = SchemaVersion + 1
+	_, err := db.Migrate(context.Background())
+	assert.ErrorIs(t, err, ErrUnsupportedSchema)
+}
+
+func TestMergedMetadataSchemaVersion(t *testing.T) {
+	ans := mergedMetadata(Metadata{SchemaVersion: SchemaVersion}, Metadata{}, MergeStats{})
+	assert.Equal(t, 0, ans.SchemaVersion)
+}
@@ -16,6 +16,18 @@
 
 package storage
 
+import "fmt"
+
+const (
+	PathPolicyHead        = "head"
+	PathPolicyGrandparent = "grandparent"
+	PathPolicyFullPath    = "full-path"
+
+	// grandparentAncestorDepth is a default max. distance of ancestors
+	// paired with a token by the grandparent policy
+	grandparentAncestorDepth = 1
+)
+
 type bidirEncoding map[string]byte
 
 func (be bidirEncoding) GetRev(val byte) string {
@@ -46,14 +58,196 @@ func (tt hardcodedTextTypes) ReadableToRaw(val string) byte {
 
 // ------
 
+// PathPolicy specifies which pairs of tokens found on a syntax tree path
+// (from a leaf to the root) are considered co-occurrences:
+//   - head - only direct head-dependent pairs
+//   - grandparent - direct pairs plus grandparent-grandchild pairs
+//   - full-path - all the pairs on the path up to MaxDepth (0 = no limit)
+//
+// Except for the grandparent policy (see Depths), the window is symmetric
+// by default - i.e. a token is paired with its ancestors and descendants
+// up to the same distance. AncestorDepth and DescendantDepth allow for
+// changing the window on one of the sides (up to the policy depth).
+type PathPolicy struct {
+	Name     string `json:"name"`
+	MaxDepth int    `json:"maxDepth,omitempty"`
+
+	// AncestorDepth, if positive, limits the distance of ancestors
+	// (heads, grandparents, ...) paired with a token
+	AncestorDepth int `json:"ancestorDepth,omitempty"`
+
+	// DescendantDepth, if positive, limits the distance of descendants
+	// (dependents, grandchildren, ...) paired with a token
+	DescendantDepth int `json:"descendantDepth,omitempty"`
+}
+
+// Depth returns max. distance of paired tokens on a path.
+// Zero means there is no limit.
+func (pp PathPolicy) Depth() int {
+	switch pp.Name {
+	case PathPolicyHead:
+		return 1
+	case PathPolicyFullPath:
+		return pp.MaxDepth
+	default:
+		return 2
+	}
+}
+
+// Depths returns max. distances of paired ancestors and descendants
+// of a token with both the policy depth and the asymmetric limits applied.
+// Zero means there is no limit.
+// The grandparent policy is asymmetric by default - a token is paired
+// with its grandchildren but not with its grandparent (i.e. each
+// grandparent-grandchild pair is imported just once, with the grandparent
+// as the first token). An explicit AncestorDepth can make it symmetric.
+func (pp PathPolicy) Depths() (ancestors, descendants int) {
+	limit := func(v, dflt int) int {
+		if v <= 0 {
+			return dflt
+		}
+		if pp.Depth() == 0 {
+			return v
+		}
+		return min(v, pp.Depth())
+	}
+	ancDefault := pp.Depth()
+	if pp.Normalized().Name == PathPolicyGrandparent {
+		ancDefault = grandparentAncestorDepth
+	}
+	return limit(pp.AncestorDepth, ancDefault), limit(pp.DescendantDepth, pp.Depth())
+}
+
+func (pp PathPolicy) Validate() error {
+	switch pp.Name {
+	case "", PathPolicyHead, PathPolicyGrandparent, PathPolicyFullPath:
+	default:
+		return fmt.Errorf("unknown path policy: %s", pp.Name)
+	}
+	if pp.MaxDepth < 0 {
+		return fmt.Errorf("invalid path policy max. depth: %d", pp.MaxDepth)
+	}
+	if pp.AncestorDepth < 0 {
+		return fmt.Errorf("invalid path policy ancestor depth: %d", pp.AncestorDepth)
+	}
+	if pp.DescendantDepth < 0 {
+		return fmt.Errorf("invalid path policy descendant depth: %d", pp.DescendantDepth)
+	}
+	return nil
+}
+
+// Normalized returns the policy with all the defaults resolved
+func (pp PathPolicy) Normalized() PathPolicy {
+	if pp.Name == "" {
+		pp.Name = PathPolicyGrandparent
+	}
+	if pp.Name != PathPolicyFullPath {
+		pp.MaxDepth = pp.Depth()
+	}
+	return pp
+}
+
+// ------
+
+// QueryDefaults contains corpus specific query parameters
+// applied in case a client does not specify them.
+type QueryDefaults struct {
+	SortBy              SortingMeasure
+	Limit               int
+	MaxAvgCollocateDist float64
+	ExcludedDeprels     []string
+
+	// RelationDistSpread, if positive, replaces MaxAvgCollocateDist
+	// with per-relation limits derived from relation distance statistics
+	// (average + RelationDistSpread * std. deviation) in databases
+	// containing the statistics.
+	RelationDistSpread float64
+}
+
+// ------
+
 type Profile struct {
-	Name          string
-	LemmaIdx      int
-	PosIdx        int
-	ParentIdx     int
-	DeprelIdx     int
+	Name      string
+	LemmaIdx  int
+	PosIdx    int
+	ParentIdx int
+	DeprelIdx int
+
+	// SentenceStruct is a name of the structure delimiting sentences
+	// in vertical files (e.g. "sentence" or "seg"). If empty, "s" is used.
+	SentenceStruct string
+
+	// TextTypesAttr is a structural attribute (e.g. "text.txtype") defining
+	// text types. Up to MaxTextTypeDims comma-separated attributes
+	// (e.g. "text.genre,text.period") can be combined into independent
+	// text type dimensions. In such case, text types are the combinations
+	// of the attribute values joined by TextTypeDimSeparator
+	// (e.g. "fiction|1990s") and the TextTypes mapping must contain them.
 	TextTypesAttr string
 	TextTypes     hardcodedTextTypes
+	QueryDefaults QueryDefaults
+
+	// PairWeighting is a name of a weighting applied to co-occurrence
+	// frequencies during import (see dataimport.NewPairWeighting)
+	PairWeighting string
+
+	PathPolicy PathPolicy
+
+	// ExtractSiblings enables import of pairs of tokens sharing
+	// the same head (stored with the "sibling" pseudo-deprel)
+	ExtractSiblings bool
+
+	// DeprelPathLabels enables storing of pairs connected via other
+	// nodes with their whole relation path label (e.g. "obj→amod")
+	DeprelPathLabels bool
+
+	// PathCollocationsDepth, if at least 2, enables storing of pairs
+	// connected via up to PathCollocationsDepth relations (e.g. verb → obj → amod
+	// chains for 2) with their relation path labels. Unlike DeprelPathLabels,
+	// the pairs are stored separately and searched only on demand
+	// (see CalculationArgs.PathCollocations).
+	PathCollocationsDepth int
+
+	// PairExamples, if positive, enables storing of up to PairExamples
+	// occurrences (corpus positions) of each pair so the pairs can be
+	// linked to concordances (see DB.GetExamples).
+	PairExamples int
+
+	// WideTokenIDs makes the database to use 8-byte token IDs
+	// (see record.WideKeyLayout) for vocabularies exceeding the range
+	// of the default 4-byte IDs (e.g. in large multilingual corpora)
+	WideTokenIDs bool
+
+	// DeprelBlocklist lists syntactic relations whose dependents are
+	// ignored during import (see dataimport.DeprelBlocklist for the syntax).
+	// If nil, dataimport.DefaultDeprelBlocklist is used.
+	DeprelBlocklist []string
+
+	// IndexWordForms enables import of word forms (along with lemmas)
+	// so collocations can be searched also by word forms
+	IndexWordForms bool
+
+	// FeatsIdx is a column position of UD morphological features
+	// (the FEATS column). It is used only with MorphFeats set.
+	FeatsIdx int
+
+	// MorphFeats lists UD morphological features (e.g. Case, Number)
+	// the imported records are split by (see record.SupportedUDFeats)
+	MorphFeats []string
+
+	// RestrictedTextTypes lists text types (e.g. license-limited subcorpora)
+	// which must be excluded from search for unauthorized users.
+	RestrictedTextTypes []string
+
+	// TextTypeLabels provides display names of text types along with
+	// their display order. The labels are stored in metadata during
+	// import.
+	TextTypeLabels []TextTypeLabel
+
+	// Relations defines named relations (see RelationDef) available
+	// for searches in addition to the built-in ones. The definitions
+	// are stored in metadata during import.
+	Relations []RelationDef
 }
 
 func (p Profile) IsZero() bool {
@@ -84,6 +278,24 @@ func FindProfile(name string) Profile {
 				"religious":                 0x0b,
 				"subtitles":                 0x0c,
 			},
+			TextTypeLabels: []TextTypeLabel{
+				{Value: "fiction", DisplayName: "Fiction"},
+				{Value: "children's lit.", DisplayName: "Children's literature"},
+				{Value: "poetry", DisplayName: "Poetry"},
+				{Value: "drama", DisplayName: "Drama"},
+				{Value: "nonfiction", DisplayName: "Non-fiction"},
+				{Value: "journalism - news", DisplayName: "Journalism (news)"},
+				{Value: "journalism - commentaries", DisplayName: "Journalism (commentaries)"},
+				{Value: "legal texts", DisplayName: "Legal texts"},
+				{Value: "religious", DisplayName: "Religious texts"},
+				{Value: "discussions - transcripts", DisplayName: "Discussions (transcripts)"},
+				{Value: "subtitles", DisplayName: "Subtitles"},
+				{Value: "other", DisplayName: "Other"},
+			},
+			QueryDefaults: QueryDefaults{
+				SortBy: sortByRRF,
+				Limit:  10,
+			},
 		}
 	default:
 		return Profile{}
@@ -91,10 +303,88 @@ func FindProfile(name string) Profile {
 }
 
 type Metadata struct {
-	CorpusSize    int64             `json:"corpusSize"`
-	ProfileName   string            `json:"profileName"`
-	NumCollFreqs  int               `json:"numCollFreqs"`
-	NumLemmaFreqs int               `json:"numLemmaFreqs"`
-	NumLemmas     int               `json:"numLemmas"`
-	DeprelMap     map[string]uint16 `json:"deprelMap"`
+
+	// SchemaVersion is a version of the data layout (see storage.SchemaVersion).
+	// It is zero for databases created before versions were recorded.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+
+	// TokenIDWidth is a number of bytes used to encode token IDs
+	// in keys (see record.KeyLayout). Zero means the default 4-byte
+	// IDs used by databases created before the width was recorded.
+	TokenIDWidth int `json:"tokenIdWidth,omitempty"`
+
+	CorpusSize       int64             `json:"corpusSize"`
+	ProfileName      string            `json:"profileName"`
+	NumCollFreqs     int               `json:"numCollFreqs"`
+	NumLemmaFreqs    int               `json:"numLemmaFreqs"`
+	NumLemmas        int               `json:"numLemmas"`
+	MinPairFreq      int               `json:"minPairFreq,omitempty"`
+	DeprelMap        map[string]uint16 `json:"deprelMap"`
+	PairWeighting    string            `json:"pairWeighting,omitempty"`
+	PathPolicy       PathPolicy        `json:"pathPolicy"`
+	Siblings         bool              `json:"siblings"`
+	DeprelPathLabels bool              `json:"deprelPathLabels"`
+
+	// SurfaceDist is true for databases storing also linear
+	// (word order) distances of collocates
+	SurfaceDist bool `json:"surfaceDist"`
+
+	// TokenFreqRollups is true for databases storing also
+	// per-(lemma, pos) single token frequencies summed over
+	// all text types
+	TokenFreqRollups bool `json:"tokenFreqRollups"`
+
+	// HotLemmaThreshold is a number of collocation records a lemma
+	// had to exceed to get pre-aggregated summaries (zero means
+	// there are no such summaries in the database)
+	HotLemmaThreshold int `json:"hotLemmaThreshold,omitempty"`
+	NumHotLemmas      int `json:"numHotLemmas,omitempty"`
+
+	// TopCollocations describes precomputed top collocations
+	// (nil for databases without FeatureTopCollocations)
+	TopCollocations *TopCollocationsInfo `json:"topCollocations,omitempty"`
+
+	// NumWordForms is a number of indexed word forms (zero for
+	// databases without FeatureWordForms)
+	NumWordForms int `json:"numWordForms,omitempty"`
+
+	// MorphFeats lists UD morphological features the records are
+	// split by (empty for databases without FeatureMorphFeats)
+	MorphFeats []string `json:"morphFeats,omitempty"`
+
+	// PathCollocationsDepth is a max. number of relations connecting
+	// pairs of path collocations (zero for databases without
+	// FeaturePathCollocations)
+	PathCollocationsDepth int `json:"pathCollocationsDepth,omitempty"`
+
+	// PairExamples is a max. number of examples stored for each
+	// pair (zero for databases without FeaturePairExamples)
+	PairExamples int `json:"pairExamples,omitempty"`
+
+	// DeprelBlocklist lists relations ignored during import. It is nil
+	// for older databases and for databases merged from data imported
+	// with different blocklists.
+	DeprelBlocklist []string `json:"deprelBlocklist"`
+
+	// TextTypeLabels contains display names of text types
+	// in their intended display order
+	TextTypeLabels []TextTypeLabel `json:"textTypeLabels,omitempty"`
+
+	// FileTextTypes contains a text type mapping for databases with
+	// text types derived from names of imported files instead of
+	// a structural attribute. If set, it replaces the text types
+	// of the import profile.
+	FileTextTypes map[string]byte `json:"fileTextTypes,omitempty"`
+
+	// Relations contains named relations (see RelationDef) defined
+	// for the database in addition to the built-in ones
+	Relations []RelationDef `json:"relations,omitempty"`
+
+	// RelationDists contains typical distances of individual
+	// relations (keyed by deprel labels)
+	RelationDists map[string]RelationDistStats `json:"relationDists,omitempty"`
+
+	// Features lists optional subsystems the database has been built
+	// with. For older databases, the value is nil (see HasFeature).
+	Features []DatasetFeature `json:"features,omitempty"`
 }
@@ -17,14 +17,16 @@
 package storage
 
 import (
+	"context"
 	"crypto/sha1"
-	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"os"
-	"sort"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/czcorpus/depreldb/record"
@@ -38,89 +40,264 @@ const (
 	sortByLMI     SortingMeasure = "lmi"
 	sortByLL      SortingMeasure = "ll"
 	sortByRRF     SortingMeasure = "rrf"
+	sortByMI      SortingMeasure = "mi"
+	sortByMI3     SortingMeasure = "mi3"
+	sortByDice    SortingMeasure = "dice"
+	sortByMinSens SortingMeasure = "minsens"
+
+	sortByDeltaPColl SortingMeasure = "dpcoll"
+	sortByDeltaPNode SortingMeasure = "dpnode"
+)
+
+// ErrSurfaceDistUnavailable is returned in case a search requires
+// surface distances which are not stored in the database.
+var ErrSurfaceDistUnavailable = fmt.Errorf("%w: %s", ErrFeatureUnavailable, FeatureSurfaceDist)
+
+// ErrInvalidCorpusSize is returned in case a corpus size
+// override is not applicable to the searched data.
+var ErrInvalidCorpusSize = errors.New("invalid corpus size")
+
+// ErrInvalidOffset is returned in case a negative result
+// offset is requested.
+var ErrInvalidOffset = errors.New("invalid offset")
+
+// ErrUnsupportedWordFormSearch is returned in case a word form search
+// is combined with options applicable only to lemmas.
+var ErrUnsupportedWordFormSearch = errors.New("unsupported word form search")
+
+// SortingMeasures lists all the supported sorting measures
+var SortingMeasures = []SortingMeasure{
+	sortByLogDice, sortByTScore, sortByLMI, sortByLL, sortByRRF,
+	sortByMI, sortByMI3, sortByDice, sortByMinSens,
+	sortByDeltaPColl, sortByDeltaPNode,
+}
+
+const (
+	CollocateBefore CollocateOrder = "before"
+	CollocateAfter  CollocateOrder = "after"
 )
 
+// CollocateOrder specifies linear position of a collocate
+// with respect to the searched lemma
+type CollocateOrder string
+
+func (co CollocateOrder) Validate() bool {
+	return co == "" || co == CollocateBefore || co == CollocateAfter
+}
+
+const (
+	// DeprelGranularityFull keeps relations as stored (i.e. including
+	// possible subtypes like "obl:arg")
+	DeprelGranularityFull DeprelGranularity = "full"
+
+	// DeprelGranularityCore merges relation subtypes into their core
+	// relations (e.g. "obl:arg" and "obl:tmod" into "obl")
+	DeprelGranularityCore DeprelGranularity = "core"
+)
+
+// DeprelGranularity specifies how detailed the relations
+// in search results are
+type DeprelGranularity string
+
+func (dg DeprelGranularity) Validate() bool {
+	return dg == "" || dg == DeprelGranularityFull || dg == DeprelGranularityCore
+}
+
 type SortingMeasure string
 
 func (m SortingMeasure) Validate() bool {
-	return m == sortByLogDice || m == sortByTScore || m == sortByLMI || m == sortByRRF
+	return slices.Contains(SortingMeasures, m)
 }
 
 // -------
 
 type itemsWalktrhoughCache struct {
 	db                *DB
-	idToLemmaCache    map[uint32]string
+	idToLemmaCache    map[uint64]string
 	rawTokenFreqCache map[string][]record.RawTokenFreq
+	numHits           int
+	numMisses         int
+
+	// mu guards the maps in case they are shared by concurrently
+	// used copies of the cache (see share)
+	mu *sync.Mutex
 }
 
-func (clm *itemsWalktrhoughCache) getLemmaByIDTxn(txn *badger.Txn, tokenID uint32) (string, error) {
+// share returns a copy of the cache sharing its data with the original
+// (and with other shared copies) so the copies can be used concurrently.
+// Hits and misses are counted by each copy separately.
+func (clm *itemsWalktrhoughCache) share() itemsWalktrhoughCache {
 	if clm.idToLemmaCache == nil {
-		clm.idToLemmaCache = make(map[uint32]string)
+		clm.idToLemmaCache = make(map[uint64]string)
+	}
+	if clm.rawTokenFreqCache == nil {
+		clm.rawTokenFreqCache = make(map[string][]record.RawTokenFreq)
+	}
+	if clm.mu == nil {
+		clm.mu = new(sync.Mutex)
+	}
+	return itemsWalktrhoughCache{
+		db:                clm.db,
+		idToLemmaCache:    clm.idToLemmaCache,
+		rawTokenFreqCache: clm.rawTokenFreqCache,
+		mu:                clm.mu,
+	}
+}
+
+func (clm *itemsWalktrhoughCache) lock() {
+	if clm.mu != nil {
+		clm.mu.Lock()
+	}
+}
+
+func (clm *itemsWalktrhoughCache) unlock() {
+	if clm.mu != nil {
+		clm.mu.Unlock()
+	}
+}
+
+func (clm *itemsWalktrhoughCache) getLemmaByIDTxn(txn *badger.Txn, tokenID uint64) (string, error) {
+	if clm.idToLemmaCache == nil {
+		clm.idToLemmaCache = make(map[uint64]string)
 	}
 	var err error
+	clm.lock()
 	ans, ok := clm.idToLemmaCache[tokenID]
+	clm.unlock()
 	if !ok {
+		clm.numMisses++
 		ans, err = clm.db.getLemmaByIDTxn(txn, tokenID)
 		if err != nil {
 			return "", err
 		}
+		clm.lock()
 		clm.idToLemmaCache[tokenID] = ans
+		clm.unlock()
+
+	} else {
+		clm.numHits++
 	}
 	return ans, nil
 }
 
-func (clm *itemsWalktrhoughCache) getRawTokenFreqTx(txn *badger.Txn, tokenID uint32, pos, textType byte) ([]record.RawTokenFreq, error) {
+func (clm *itemsWalktrhoughCache) getRawTokenFreqTx(txn *badger.Txn, tokenID uint64, pos, textType byte) ([]record.RawTokenFreq, error) {
 	if clm.rawTokenFreqCache == nil {
 		clm.rawTokenFreqCache = make(map[string][]record.RawTokenFreq)
 	}
-	srchKey := record.TokenFreqSearchKey(tokenID, pos, textType)
+	srchKey := clm.db.keys.TokenFreqSearchKey(tokenID, pos, textType)
+	clm.lock()
 	ans, ok := clm.rawTokenFreqCache[string(srchKey)]
+	clm.unlock()
 	var err error
 	if !ok {
+		clm.numMisses++
 		ans, err = clm.db.getRawTokenFreqTx(txn, tokenID, pos, textType)
 		if err != nil {
 			return []record.RawTokenFreq{}, err
 		}
+		clm.lock()
+		clm.rawTokenFreqCache[string(srchKey)] = ans
+		clm.unlock()
+
+	} else {
+		clm.numHits++
+	}
+	return ans, nil
+}
+
+// getRawTokenFreqRollupTx is a cached variant of DB.getRawTokenFreqRollupTx
+func (clm *itemsWalktrhoughCache) getRawTokenFreqRollupTx(txn *badger.Txn, tokenID uint64, pos byte) ([]record.RawTokenFreq, error) {
+	if clm.rawTokenFreqCache == nil {
+		clm.rawTokenFreqCache = make(map[string][]record.RawTokenFreq)
+	}
+	srchKey := clm.db.keys.TokenFreqRollupSearchKey(tokenID, pos)
+	clm.lock()
+	ans, ok := clm.rawTokenFreqCache[string(srchKey)]
+	clm.unlock()
+	var err error
+	if !ok {
+		clm.numMisses++
+		ans, err = clm.db.getRawTokenFreqRollupTx(txn, tokenID, pos)
+		if err != nil {
+			return []record.RawTokenFreq{}, err
+		}
+		clm.lock()
 		clm.rawTokenFreqCache[string(srchKey)] = ans
+		clm.unlock()
+
+	} else {
+		clm.numHits++
 	}
 	return ans, nil
 }
 
+// getSingleTokenFreqTx returns single token frequencies either from the rollup
+// records (if useRollups is true) or from the regular per-text type records.
+func (clm *itemsWalktrhoughCache) getSingleTokenFreqTx(
+	txn *badger.Txn, useRollups bool, tokenID uint64, pos, textType byte,
+) ([]record.RawTokenFreq, error) {
+	if useRollups {
+		return clm.getRawTokenFreqRollupTx(txn, tokenID, pos)
+	}
+	return clm.getRawTokenFreqTx(txn, tokenID, pos, textType)
+}
+
 // -------
 
 // GetLemmaID returns numeric representation of a provided
 // lemma. In case the lemma is not found, zero is returned
 // (i.e. no error).
-func (db *DB) GetLemmaID(lemmaEntry record.TokenFreq) (uint32, error) {
-	var tokenID uint32
-	err := db.bdb.View(func(txn *badger.Txn) error {
+func (db *DB) GetLemmaID(lemmaEntry record.TokenFreq) (uint64, error) {
+	var tokenID uint64
+	err := db.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(record.EncodeLemmaKey(lemmaEntry))
 		if err != nil {
 			return err
 		}
 
-		tokenIDBytes, err := item.ValueCopy(nil)
+		tokenID, err = decodeItemValue(item, db.keys.DecodeTokenID)
+		return err
+	})
+	return tokenID, err
+}
+
+// GetWordFormID returns numeric representation of a provided
+// word form. In case the form is not found, badger.ErrKeyNotFound
+// is returned.
+func (db *DB) GetWordFormID(form string) (uint64, error) {
+	var tokenID uint64
+	err := db.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(record.EncodeWordFormKey(form))
 		if err != nil {
 			return err
 		}
-
-		tokenID = binary.LittleEndian.Uint32(tokenIDBytes)
-		return nil
+		tokenID, err = decodeItemValue(item, db.keys.DecodeTokenID)
+		return err
 	})
 	return tokenID, err
 }
 
 type lemmaWithID struct {
 	Value   string
-	TokenID uint32
+	TokenID uint64
 }
 
 // GetLemmaIDsByPrefix returns all the
 func (db *DB) GetLemmaIDsByPrefix(lemmaPrefix string) ([]lemmaWithID, error) {
+	return db.getIDsByKeyPrefix(record.EncodeLemmaPrefixKey(lemmaPrefix))
+}
+
+// GetWordFormIDsByPrefix returns all the word forms (along with
+// their token IDs) starting with the provided prefix
+func (db *DB) GetWordFormIDsByPrefix(formPrefix string) ([]lemmaWithID, error) {
+	return db.getIDsByKeyPrefix(record.EncodeWordFormPrefixKey(formPrefix))
+}
+
+// getIDsByKeyPrefix returns all the (value) -> (token ID) index
+// entries with keys starting with the provided key prefix
+func (db *DB) getIDsByKeyPrefix(key []byte) ([]lemmaWithID, error) {
 	ans := make([]lemmaWithID, 0, 8)
-	err := db.bdb.View(func(txn *badger.Txn) error {
-		key := record.EncodeLemmaPrefixKey(lemmaPrefix)
+	err := db.view(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = key
 		it := txn.NewIterator(opts)
@@ -128,11 +305,7 @@ func (db *DB) GetLemmaIDsByPrefix(lemmaPrefix string) ([]lemmaWithID, error) {
 
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item().Key()[1:]
-			var tokenID uint32
-			err := it.Item().Value(func(val []byte) error {
-				tokenID = binary.LittleEndian.Uint32(val)
-				return nil
-			})
+			tokenID, err := decodeItemValue(it.Item(), db.keys.DecodeTokenID)
 			if err != nil {
 				return err
 			}
@@ -149,6 +322,109 @@ func (db *DB) GetLemmaIDsByPrefix(lemmaPrefix string) ([]lemmaWithID, error) {
 	return ans, err
 }
 
+// GetLemmaIDsIgnoringDiacritics returns all the lemmas matching the provided
+// one (or starting with it, if isPrefix is set) when diacritics are ignored
+// (e.g. hriste matches hřiště). Returned lemmas are in their canonical form
+// (i.e. as stored in the database) and they are sorted alphabetically.
+// Please note that databases created by older versions of the importer
+// contain no folded lemma index so only lemmas without diacritics are found.
+func (db *DB) GetLemmaIDsIgnoringDiacritics(lemma string, isPrefix bool) ([]lemmaWithID, error) {
+	folded := record.FoldLemma(lemma)
+	variants, err := db.GetLemmaIDsByPrefix(folded)
+	if err != nil {
+		return variants, err
+	}
+	ans := make([]lemmaWithID, 0, len(variants)+4)
+	for _, v := range variants {
+		if isPrefix || v.Value == folded {
+			ans = append(ans, v)
+		}
+	}
+	err = db.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.EncodeFoldedLemmaSearchKey(folded, isPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			tokenID, err := decodeItemValue(it.Item(), db.keys.DecodeTokenID)
+			if err != nil {
+				return err
+			}
+			ans = append(
+				ans,
+				lemmaWithID{
+					Value:   record.DecodeFoldedLemmaKey(it.Item().Key()),
+					TokenID: tokenID,
+				},
+			)
+		}
+		return nil
+	})
+	if err != nil {
+		return ans, err
+	}
+	slices.SortFunc(ans, func(a, b lemmaWithID) int {
+		return strings.Compare(a.Value, b.Value)
+	})
+	return ans, nil
+}
+
+// GetLemmaIDsIgnoringCase returns all the lemmas matching the provided
+// one (or starting with it, if isPrefix is set) when letter case is ignored
+// (e.g. praha matches Praha). With ignoreDiacritics, also diacritics are
+// ignored (e.g. PRAHA matches Práha). Returned lemmas are in their canonical
+// form and they are sorted alphabetically. The database should be built
+// with FeatureLowerLemmas (otherwise, only lowercase lemmas are found).
+func (db *DB) GetLemmaIDsIgnoringCase(lemma string, isPrefix, ignoreDiacritics bool) ([]lemmaWithID, error) {
+	normalized := record.NormalizeLemma(lemma)
+	// lowercase lemmas are stored either in the main index (no diacritics)
+	// or in the folded one, other lemmas are in the lowercased index
+	candidates, err := db.GetLemmaIDsIgnoringDiacritics(normalized, isPrefix)
+	if err != nil {
+		return candidates, err
+	}
+	err = db.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.EncodeLowerLemmaSearchKey(normalized, isPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			tokenID, err := decodeItemValue(it.Item(), db.keys.DecodeTokenID)
+			if err != nil {
+				return err
+			}
+			candidates = append(
+				candidates,
+				lemmaWithID{
+					Value:   record.DecodeFoldedLemmaKey(it.Item().Key()),
+					TokenID: tokenID,
+				},
+			)
+		}
+		return nil
+	})
+	if err != nil {
+		return candidates, err
+	}
+	normalize := strings.ToLower
+	if ignoreDiacritics {
+		normalize = record.NormalizeLemma
+	}
+	searched := normalize(lemma)
+	ans := make([]lemmaWithID, 0, len(candidates))
+	for _, v := range candidates {
+		if value := normalize(v.Value); value == searched || isPrefix && strings.HasPrefix(value, searched) {
+			ans = append(ans, v)
+		}
+	}
+	slices.SortFunc(ans, func(a, b lemmaWithID) int {
+		return strings.Compare(a.Value, b.Value)
+	})
+	return ans, nil
+}
+
 type LemmaProps struct {
 	Pos      string
 	Deprel   string
@@ -156,10 +432,10 @@ type LemmaProps struct {
 	TextType string
 }
 
-func (db *DB) GetMatchingLemmaProps(tokenID uint32) ([]LemmaProps, error) {
+func (db *DB) GetMatchingLemmaProps(tokenID uint64) ([]LemmaProps, error) {
 	var results []LemmaProps
-	err := db.bdb.View(func(txn *badger.Txn) error {
-		searchKey := record.TokenFreqSearchKey(tokenID, 0, 0)
+	err := db.view(func(txn *badger.Txn) error {
+		searchKey := db.keys.TokenFreqSearchKey(tokenID, 0, 0)
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = searchKey
 		it := txn.NewIterator(opts)
@@ -167,18 +443,23 @@ func (db *DB) GetMatchingLemmaProps(tokenID uint32) ([]LemmaProps, error) {
 
 		for it.Rewind(); it.Valid(); it.Next() {
 			key := it.Item().Key()
-			decodedKey := record.DecodeTokenFreqKey(key)
+			decodedKey, err := db.keys.DecodeTokenFreqKey(key)
+			if err != nil {
+				if err := db.skipMalformed(key, err); err != nil {
+					return err
+				}
+				continue
+			}
 			pos := record.UDPosFromByte(decodedKey.Pos1)
 			deprel := record.UDDeprelFromUint16(decodedKey.Deprel)
 			textType := db.textTypes.RawToReadable(decodedKey.TextType)
 
-			var tokenValue record.TokenValue
-			err := it.Item().Value(func(val []byte) error {
-				tokenValue = record.DecodeTokenValue(val)
-				return nil
-			})
+			tokenValue, err := decodeItemValue(it.Item(), record.DecodeTokenValue)
 			if err != nil {
-				return err
+				if err := db.skipMalformed(key, err); err != nil {
+					return err
+				}
+				continue
 			}
 
 			results = append(results, LemmaProps{
@@ -193,42 +474,123 @@ func (db *DB) GetMatchingLemmaProps(tokenID uint32) ([]LemmaProps, error) {
 	return results, err
 }
 
-func (db *DB) getLemmaByIDTxn(txn *badger.Txn, tokenID uint32) (string, error) {
-	item, err := txn.Get(record.TokenIDToRevIndexKey(tokenID))
-	if err != nil {
-		return "", err
-	}
+// LemmaInfo provides basic information about a lemma without
+// any collocation calculation involved.
+type LemmaInfo struct {
+	Lemma    string         `json:"lemma"`
+	Exists   bool           `json:"exists"`
+	Freq     int            `json:"freq"`
+	PoSFreqs map[string]int `json:"posFreqs"`
+}
 
-	lemmaBytes, err := item.ValueCopy(nil)
+// GetLemmaInfo tests whether the lemma exists and returns its total
+// frequency along with frequencies of individual PoS variants.
+// Entries of excludedTextTypes do not contribute to the frequencies.
+// For a non-existing lemma, no error is returned.
+func (db *DB) GetLemmaInfo(lemma string, excludedTextTypes []string) (LemmaInfo, error) {
+	ans := LemmaInfo{Lemma: lemma, PoSFreqs: make(map[string]int)}
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: lemma})
+	if err == badger.ErrKeyNotFound {
+		return ans, nil
+	}
 	if err != nil {
-		return "", err
+		return ans, fmt.Errorf("failed to get lemma info: %w", err)
 	}
-
-	return strings.TrimSpace(string(lemmaBytes)), nil
-}
-
-func (db *DB) GetLemmaByID(tokenID uint32) (string, error) {
-	var lemma string
-	err := db.bdb.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(record.TokenIDToRevIndexKey(tokenID))
+	excludedTT := make(map[byte]bool)
+	for _, tt := range excludedTextTypes {
+		if rawTT := db.textTypes.ReadableToRaw(tt); rawTT > 0 {
+			excludedTT[rawTT] = true
+		}
+	}
+	err = db.view(func(txn *badger.Txn) error {
+		items, err := db.getRawTokenFreqTx(txn, tokenID, 0, 0)
 		if err != nil {
 			return err
 		}
+		for _, item := range items {
+			if excludedTT[item.TextType] {
+				continue
+			}
+			ans.Freq += int(item.Freq)
+			ans.PoSFreqs[record.UDPosFromByte(item.PoS).Readable] += int(item.Freq)
+		}
+		return nil
+	})
+	if err != nil {
+		return ans, fmt.Errorf("failed to get lemma info: %w", err)
+	}
+	ans.Exists = ans.Freq > 0
+	return ans, nil
+}
 
-		lemmaBytes, err := item.ValueCopy(nil)
+// GetLemmaFreq returns frequency of a lemma optionally restricted to
+// a PoS and/or a text type (empty value = any). Entries of excludedTextTypes
+// do not contribute to the frequency. For a non-existing lemma, zero
+// is returned (i.e. no error).
+func (db *DB) GetLemmaFreq(lemma, pos, textType string, excludedTextTypes []string) (int, error) {
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: lemma})
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get lemma frequency: %w", err)
+	}
+	ttID := db.textTypes.ReadableToRaw(textType)
+	if textType != "" && ttID == 0 {
+		return 0, nil
+	}
+	excludedTT := make(map[byte]bool)
+	for _, tt := range excludedTextTypes {
+		if rawTT := db.textTypes.ReadableToRaw(tt); rawTT > 0 {
+			excludedTT[rawTT] = true
+		}
+	}
+	var ans int
+	err = db.view(func(txn *badger.Txn) error {
+		items, err := db.getRawTokenFreqTx(txn, tokenID, record.UDPoSMapping[pos], ttID)
 		if err != nil {
 			return err
 		}
-
-		lemma = strings.TrimSpace(string(lemmaBytes))
+		for _, item := range items {
+			// with zero pos, the search key cannot contain text type
+			if ttID > 0 && item.TextType != ttID || excludedTT[item.TextType] {
+				continue
+			}
+			ans += int(item.Freq)
+		}
 		return nil
 	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get lemma frequency: %w", err)
+	}
+	return ans, nil
+}
+
+func (db *DB) getLemmaByIDTxn(txn *badger.Txn, tokenID uint64) (string, error) {
+	if lemma, ok := db.lemmaCache.get(tokenID); ok {
+		return lemma, nil
+	}
+	item, err := txn.Get(db.keys.TokenIDToRevIndexKey(tokenID))
+	if err != nil {
+		return "", err
+	}
+
+	return readItemValue(item, DecodeLemma)
+}
+
+func (db *DB) GetLemmaByID(tokenID uint64) (string, error) {
+	var lemma string
+	err := db.view(func(txn *badger.Txn) error {
+		var err error
+		lemma, err = db.getLemmaByIDTxn(txn, tokenID)
+		return err
+	})
 	return lemma, err
 }
 
-func (db *DB) GetSingleTokenFreq(tokenID uint32, pos, textType byte) ([]record.TokenFreq, error) {
+func (db *DB) GetSingleTokenFreq(tokenID uint64, pos, textType byte) ([]record.TokenFreq, error) {
 	ans := []record.TokenFreq{}
-	err := db.bdb.View(func(txn *badger.Txn) error {
+	err := db.view(func(txn *badger.Txn) error {
 		tmp, err := db.getSingleTokenFreqTx(txn, tokenID, pos, textType)
 		if err != nil {
 			return err
@@ -240,7 +602,7 @@ func (db *DB) GetSingleTokenFreq(tokenID uint32, pos, textType byte) ([]record.T
 	return ans, err
 }
 
-func (db *DB) getSingleTokenFreqTx(txn *badger.Txn, tokenID uint32, pos, textType byte) ([]record.TokenFreq, error) {
+func (db *DB) getSingleTokenFreqTx(txn *badger.Txn, tokenID uint64, pos, textType byte) ([]record.TokenFreq, error) {
 	cachedTokIDs := itemsWalktrhoughCache{db: db}
 	rawItems, err := db.getRawTokenFreqTx(txn, tokenID, pos, textType)
 	if err != nil {
@@ -273,24 +635,29 @@ func (db *DB) getSingleTokenFreqTx(txn *badger.Txn, tokenID uint32, pos, textTyp
 //
 // Also note that even if it is possible to filter by deprel, this value is not
 // a part of the result list item type.
-func (db *DB) getRawTokenFreqTx(txn *badger.Txn, tokenID uint32, pos, textType byte) ([]record.RawTokenFreq, error) {
+func (db *DB) getRawTokenFreqTx(txn *badger.Txn, tokenID uint64, pos, textType byte) ([]record.RawTokenFreq, error) {
 	ans := make([]record.RawTokenFreq, 0, 100)
-	srchKey := record.TokenFreqSearchKey(tokenID, pos, textType)
+	srchKey := db.keys.TokenFreqSearchKey(tokenID, pos, textType)
 	opts := badger.DefaultIteratorOptions
 	opts.Prefix = srchKey
 	it := txn.NewIterator(opts)
 	defer it.Close()
 
 	for it.Rewind(); it.Valid(); it.Next() {
-		var tokenValue record.TokenValue
-		err := it.Item().Value(func(val []byte) error {
-			tokenValue = record.DecodeTokenValue(val)
-			return nil
-		})
+		decKey, err := db.keys.DecodeTokenFreqKey(it.Item().Key())
 		if err != nil {
-			return []record.RawTokenFreq{}, err
+			if err := db.skipMalformed(it.Item().Key(), err); err != nil {
+				return []record.RawTokenFreq{}, err
+			}
+			continue
+		}
+		tokenValue, err := decodeItemValue(it.Item(), record.DecodeTokenValue)
+		if err != nil {
+			if err := db.skipMalformed(it.Item().Key(), err); err != nil {
+				return []record.RawTokenFreq{}, err
+			}
+			continue
 		}
-		decKey := record.DecodeTokenFreqKey(it.Item().Key())
 		ans = append(
 			ans,
 			record.RawTokenFreq{
@@ -298,6 +665,46 @@ func (db *DB) getRawTokenFreqTx(txn *badger.Txn, tokenID uint32, pos, textType b
 				Freq:     tokenValue.Freq,
 				PoS:      decKey.Pos1,
 				TextType: decKey.TextType,
+				Feats:    decKey.Feats,
+			},
+		)
+	}
+	return ans, nil
+}
+
+// getRawTokenFreqRollupTx returns single token frequencies summed over all
+// the text types. In case pos is zero, all the PoS variants of the token
+// are returned. The returned items have always zero text type.
+// Note that the function expects rollup records to be present in the database
+// (see Metadata.TokenFreqRollups).
+func (db *DB) getRawTokenFreqRollupTx(txn *badger.Txn, tokenID uint64, pos byte) ([]record.RawTokenFreq, error) {
+	ans := make([]record.RawTokenFreq, 0, 10)
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = db.keys.TokenFreqRollupSearchKey(tokenID, pos)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		decKey, err := db.keys.DecodeTokenFreqRollupKey(it.Item().Key())
+		if err != nil {
+			if err := db.skipMalformed(it.Item().Key(), err); err != nil {
+				return []record.RawTokenFreq{}, err
+			}
+			continue
+		}
+		tokenValue, err := decodeItemValue(it.Item(), record.DecodeTokenValue)
+		if err != nil {
+			if err := db.skipMalformed(it.Item().Key(), err); err != nil {
+				return []record.RawTokenFreq{}, err
+			}
+			continue
+		}
+		ans = append(
+			ans,
+			record.RawTokenFreq{
+				TokenID: tokenID,
+				Freq:    tokenValue.Freq,
+				PoS:     decKey.Pos1,
 			},
 		)
 	}
@@ -306,41 +713,611 @@ func (db *DB) getRawTokenFreqTx(txn *badger.Txn, tokenID uint32, pos, textType b
 
 // ------
 
-type SearchFilter func(pos1 byte, deprel uint16, pos2 byte, textType byte, dist float64) bool
+// FilterStats describes how individual filters of a collocation search
+// discarded candidate pair records. It is intended to help users understand
+// why an expected collocate is missing in a result.
+type FilterStats struct {
+
+	// NumScanned is a number of examined pair records
+	NumScanned int `json:"numScanned"`
+
+	// TextType is a number of records discarded because of
+	// a different or an excluded text type
+	TextType int `json:"textType"`
+
+	// CustomFilter is a number of records discarded by a custom filter
+	// (e.g. excluded deprels, a predefined search)
+	CustomFilter int `json:"customFilter"`
+
+	// MaxAvgDist is a number of records discarded by max. average
+	// syntactic distance
+	MaxAvgDist int `json:"maxAvgDist"`
+
+	// MaxAvgSurfaceDist is a number of records discarded by
+	// max. average linear distance
+	MaxAvgSurfaceDist int `json:"maxAvgSurfaceDist"`
+
+	// CollocateOrder is a number of records discarded by
+	// the required word order
+	CollocateOrder int `json:"collocateOrder"`
+
+	// NumAccepted is a number of records passing all the filters
+	NumAccepted int `json:"numAccepted"`
+
+	// MinCollFreq is a number of collocations (i.e. grouped records)
+	// discarded because of F(x,y) lower than the required min. frequency
+	MinCollFreq int `json:"minCollFreq"`
+
+	// NumCandidates is a number of collocations (i.e. grouped records)
+	// before the result limit is applied
+	NumCandidates int `json:"numCandidates"`
+
+	// CutByLimit is a number of collocations removed by the result limit
+	// (including the ones skipped due to an offset)
+	CutByLimit int `json:"cutByLimit"`
+
+	// ImportMinFreq is a min. pair frequency applied during import
+	// (pairs with lower frequencies are not in the database at all).
+	// Zero means the value is unknown.
+	ImportMinFreq int `json:"importMinFreq"`
+
+	// UsedHotSummaries tells whether pre-aggregated records of
+	// a frequent lemma were used instead of the raw ones
+	UsedHotSummaries bool `json:"usedHotSummaries"`
+
+	// UsedTopCollocations tells whether the result was served from
+	// precomputed top collocations (see DB.StoreTopCollocations)
+	// without scanning any records
+	UsedTopCollocations bool `json:"usedTopCollocations"`
+
+	// ScanBudgetExhausted tells whether the search was stopped
+	// because of the max. number of examined pair records
+	ScanBudgetExhausted bool `json:"scanBudgetExhausted"`
+}
+
+// ------
+
+type SearchFilter func(pos1 byte, deprel uint16, pos2 byte, textType byte, isHead bool, dist float64) bool
+
+// ------
+
+// CalculationArgs contains parameters of a collocation search
+// performed by CalculateMeasures.
+type CalculationArgs struct {
+	Lemma                    string
+	PoS                      string
+	TextType                 string
+	LemmaIsPrefix            bool
+	IsHead                   *bool
+	MaxAvgCollocateDist      float64
+	Limit                    int
+	SortBy                   SortingMeasure
+	CollocateGroupByPos      bool
+	GroupByDeprel            bool
+	CollocateGroupByTextType bool
+
+	// CustomFilter, if set, is applied to each examined pair record.
+	// The records are scanned concurrently (see DB.SetCalcWorkers)
+	// so the function must be safe for concurrent use.
+	CustomFilter SearchFilter
+
+	// LemmaSet, if non-empty, contains lemmas treated as a single
+	// node (e.g. inflectional variants or a semantic set). In such
+	// case, Lemma is used just as a label of the set and LemmaIsPrefix
+	// is ignored.
+	LemmaSet []string
+
+	// LemmaPattern, if set, makes Lemma to be interpreted as a pattern
+	// of the syntax. All the matching lemmas are treated as a single
+	// node labeled by the pattern (LemmaIsPrefix is ignored).
+	LemmaPattern LemmaPatternSyntax
+
+	// IgnoreDiacritics makes the lemma (or lemmas of LemmaSet) to match
+	// also lemmas which differ only in diacritics (e.g. hriste matches
+	// hřiště). Each matching lemma is a separate node (unless LemmaSet is
+	// used or MergePrefixVariants is set) labeled by its canonical form.
+	IgnoreDiacritics bool
+
+	// IgnoreCase makes the lemma (or lemmas of LemmaSet) to match
+	// also lemmas which differ in letter case (e.g. praha matches Praha).
+	// It can be combined with IgnoreDiacritics. Matching lemmas are
+	// treated the same way as in case of IgnoreDiacritics.
+	IgnoreCase bool
+
+	// GroupByFeats makes the searched lemma to be split by its
+	// morphological features (e.g. "book" with Number=Sing and
+	// Number=Plur are separate nodes) so their collocation profiles
+	// can be compared. The database must be built with FeatureMorphFeats.
+	GroupByFeats bool
+
+	// SearchByWordForm makes Lemma (or LemmaSet) to be interpreted
+	// as word forms. Collocates are then also word forms. The database
+	// must be built with FeatureWordForms. The search cannot be combined
+	// with LemmaPattern, IgnoreDiacritics and IgnoreCase.
+	SearchByWordForm bool
+
+	// MergePrefixVariants, if true (and LemmaIsPrefix is true),
+	// makes all the lemmas matching the prefix to be treated as
+	// a single node labeled by Lemma. I.e. frequencies of the same
+	// collocate found for different variants are summed up instead
+	// of producing a separate result row per variant.
+	MergePrefixVariants bool
+
+	// LimitPerVariant, if true, applies Limit to each node variant
+	// (e.g. each lemma matching a prefix) separately instead of
+	// to the whole result so collocates of less frequent variants
+	// are not pushed out by the ones of more frequent variants.
+	LimitPerVariant bool
+
+	// Offset is a number of leading (i.e. best ranking) results skipped
+	// before Limit is applied. Along with Limit, it allows paging through
+	// results. With LimitPerVariant, it is applied to each node variant.
+	Offset int
+
+	// TotalCount, if set, is filled with the number of all the found
+	// collocations (i.e. before Offset and Limit are applied).
+	TotalCount *int
+
+	// VariantSummary, if set, is filled with numbers of collocations
+	// found and returned for individual node variants.
+	VariantSummary *[]NodeVariantSummary
+
+	// CategoryLexicon, along with CategoryProfile, allows calculating
+	// aggregates of collocate categories (e.g. a share of negative
+	// collocates) over all the found collocations.
+	CategoryLexicon *CategoryLexicon
+
+	// CategoryProfile, if set (along with CategoryLexicon), is filled
+	// with category aggregates calculated before the result limit
+	// is applied.
+	CategoryProfile *CategoryProfile
+
+	// TextTypeDims contains required values of individual text type
+	// dimensions for databases combining multiple structural attributes
+	// into text types (see Profile.TextTypesAttr). E.g. for text types
+	// like "fiction|1990s", the value []string{"", "1990s"} selects all
+	// the genres of the period. Empty values match anything.
+	TextTypeDims []string
+
+	// ExcludedTextTypes specifies text types removed from
+	// the search completely - i.e. they do not contribute
+	// neither to F(x,y) nor to F(x) and F(y).
+	ExcludedTextTypes []string
+
+	// MaxAvgSurfaceDist, if positive, removes collocates with average
+	// absolute linear (word order) distance greater than the value
+	MaxAvgSurfaceDist float64
+
+	// CollocateOrder, if non-empty, keeps only collocates typically
+	// preceding (or following) the searched lemma
+	CollocateOrder CollocateOrder
+
+	// SignedDistance enables a legacy sign convention where
+	// MutualDist is negative for collocations where the searched
+	// lemma is a dependent.
+	SignedDistance bool
+
+	// MinCollFreq, if positive, removes collocations with F(x,y)
+	// (summed over the grouped records) lower than the value.
+	// Unlike the min. frequency applied during import, this can be
+	// adjusted per query (e.g. to suppress noisy hapax pairs).
+	MinCollFreq int
+
+	// CorpusSize, if positive, replaces the imported corpus size
+	// (N) in measure formulas. This is useful e.g. when combining
+	// results with external subcorpus sizes.
+	CorpusSize int64
+
+	// FilterStats, if set, is filled with numbers of candidate pairs
+	// discarded by individual filters (for debugging of queries).
+	FilterStats *FilterStats
+
+	// DeprelGranularity, if set to DeprelGranularityCore, merges relation
+	// subtypes into their core relations (this is applied after CustomFilter
+	// so the filter still receives the stored relations)
+	DeprelGranularity DeprelGranularity
+
+	// Deprels, if non-empty, restricts collocations to the provided
+	// relations. Unlike CustomFilter, the restriction is applied while
+	// iterating over the stored keys (records of other relations are
+	// skipped without being read) and it does not prevent the use
+	// of hot lemma summaries. With DeprelGranularityCore, a core relation
+	// matches also all its subtypes.
+	Deprels []string
+
+	// PathCollocations makes the search to use pairs connected via other
+	// nodes (e.g. a verb and an adjective modifying the verb's object)
+	// instead of the regular ones. The relations of such pairs are whole
+	// relation paths oriented from the heads (e.g. "obj→amod" for the verb
+	// as the node and "amod←obj" for the adjective as the node) so
+	// e.g. adjectives modifying objects of a verb can be searched
+	// via Deprels. The database must be built with FeaturePathCollocations.
+	PathCollocations bool
+
+	// MaxScannedPairs, if positive, limits the number of examined pair
+	// records. Once the limit is reached, the search is finished with
+	// the records examined so far so the results may be incomplete
+	// (see FilterStats.ScanBudgetExhausted).
+	MaxScannedPairs int
+
+	// Fields selects optional result columns to be calculated and
+	// returned (empty = all). Measures required by SortBy are always
+	// calculated but they are returned only if selected.
+	Fields []ResultField
+}
+
+// ------
+
+// nodeVariant is a lemma matching a search along with
+// a token ID representing it in frequency groupings.
+type nodeVariant struct {
+	lemmaWithID
+	nodeID uint64
+}
+
+// findNodeVariants finds all the lemmas matching provided search args.
+// Along with the variants, the method returns labels of all the distinct
+// nodes (i.e. for a lemma set, there is only one node).
+func (db *DB) findNodeVariants(args CalculationArgs) ([]nodeVariant, map[uint64]string, error) {
+	ans := make([]nodeVariant, 0, 8)
+	labels := make(map[uint64]string)
+	if len(args.LemmaSet) > 0 {
+		var nodeID uint64
+		for _, lemma := range args.LemmaSet {
+			var matches []lemmaWithID
+			if args.IgnoreCase {
+				var err error
+				matches, err = db.GetLemmaIDsIgnoringCase(lemma, false, args.IgnoreDiacritics)
+				if err != nil {
+					return ans, labels, err
+				}
+
+			} else if args.IgnoreDiacritics {
+				var err error
+				matches, err = db.GetLemmaIDsIgnoringDiacritics(lemma, false)
+				if err != nil {
+					return ans, labels, err
+				}
+
+			} else {
+				var tokenID uint64
+				var err error
+				if args.SearchByWordForm {
+					tokenID, err = db.GetWordFormID(lemma)
+
+				} else {
+					tokenID, err = db.GetLemmaID(record.TokenFreq{Lemma: lemma})
+				}
+				if err == badger.ErrKeyNotFound {
+					continue
+
+				} else if err != nil {
+					return ans, labels, err
+				}
+				matches = []lemmaWithID{{Value: lemma, TokenID: tokenID}}
+			}
+			for _, m := range matches {
+				if nodeID == 0 {
+					nodeID = m.TokenID
+
+				} else if slices.ContainsFunc(ans, func(v nodeVariant) bool { return v.TokenID == m.TokenID }) {
+					continue
+				}
+				ans = append(ans, nodeVariant{lemmaWithID: m, nodeID: nodeID})
+			}
+		}
+		if nodeID > 0 {
+			labels[nodeID] = args.Lemma
+		}
+		return ans, labels, nil
+	}
+	if args.LemmaPattern != "" {
+		pattern, syntax := args.Lemma, args.LemmaPattern
+		if args.IgnoreCase {
+			pattern, syntax = "(?i)"+syntax.ToRegexp(pattern), LemmaPatternRegexp
+		}
+		variants, err := db.GetLemmaIDsByPattern(pattern, syntax, args.IgnoreDiacritics)
+		if err != nil {
+			return ans, labels, err
+		}
+		for _, v := range variants {
+			nodeID := v.TokenID
+			if len(ans) > 0 {
+				nodeID = ans[0].nodeID
+
+			} else {
+				labels[nodeID] = args.Lemma
+			}
+			ans = append(ans, nodeVariant{lemmaWithID: v, nodeID: nodeID})
+		}
+		return ans, labels, nil
+	}
+	var variants []lemmaWithID
+	var err error
+	if args.IgnoreCase {
+		variants, err = db.GetLemmaIDsIgnoringCase(args.Lemma, args.LemmaIsPrefix, args.IgnoreDiacritics)
+
+	} else if args.IgnoreDiacritics {
+		variants, err = db.GetLemmaIDsIgnoringDiacritics(args.Lemma, args.LemmaIsPrefix)
+
+	} else if args.SearchByWordForm {
+		variants, err = db.GetWordFormIDsByPrefix(args.Lemma)
+
+	} else {
+		variants, err = db.GetLemmaIDsByPrefix(args.Lemma)
+	}
+	if err != nil {
+		return ans, labels, err
+	}
+	mergeVariants := args.LemmaIsPrefix && args.MergePrefixVariants
+	for _, v := range variants {
+		if !args.LemmaIsPrefix && !args.IgnoreDiacritics && !args.IgnoreCase && v.Value != args.Lemma {
+			continue
+		}
+		if mergeVariants {
+			var nodeID uint64
+			if len(ans) == 0 {
+				nodeID = v.TokenID
+				labels[nodeID] = args.Lemma
+
+			} else {
+				nodeID = ans[0].nodeID
+			}
+			ans = append(ans, nodeVariant{lemmaWithID: v, nodeID: nodeID})
+			continue
+		}
+		ans = append(ans, nodeVariant{lemmaWithID: v, nodeID: v.TokenID})
+		labels[v.TokenID] = v.Value
+	}
+	return ans, labels, nil
+}
 
 // ------
 
 // CalculateMeasures searches for all the matching collocates and calculates
 // their Log-Dice and T-Score in collocations with the searched 'lemma'.
 //
+// The calculation is aborted (with the context error) once ctx is cancelled.
+// Records of individual node variants (e.g. lemmas matching a prefix) and
+// directions are scanned concurrently, each within its own read transaction
+// unless a snapshot is pinned (see SetCalcWorkers).
+//
 // note: for more convenient access, use scoll.Calculator
-func (db *DB) CalculateMeasures(
-	lemma, pos, textType string,
-	lemmaIsPrefix bool,
-	isHead *bool,
-	maxAvgCollocateDist float64,
-	limit int,
-	sortBy SortingMeasure,
-	collocateGroupByPos, groupByDeprel, collocateGroupByTextType bool,
-	customFilter SearchFilter,
-) ([]Collocation, error) {
-	if limit < 0 {
+func (db *DB) CalculateMeasures(ctx context.Context, args CalculationArgs) ([]Collocation, error) {
+	calcFields, err := db.validateCalculationArgs(args)
+	if err != nil {
+		return []Collocation{}, err
+	}
+	results, found, err := db.calculateFromTopCollocations(args, calcFields)
+	if err != nil {
+		return []Collocation{}, err
+
+	} else if found {
+		return results, nil
+	}
+	query, err := db.prepareMeasuresQuery(args, calcFields)
+	if err != nil {
+		return []Collocation{}, err
+	}
+
+	var filterStats FilterStats
+	walkthruCache := itemsWalktrhoughCache{db: db}
+	t0 := time.Now()
+	defer func() {
+		db.metrics.observeQuery(time.Since(t0), err, filterStats.NumScanned, walkthruCache)
+	}()
+	releaseScan, err := db.scanGate.acquire(ctx)
+	if err != nil {
+		return []Collocation{}, fmt.Errorf("failed to calculate collocation scores: %w", err)
+	}
+	defer releaseScan()
+	queueWait := time.Since(t0)
+
+	// each part of the scan runs in its own transaction
+	// (unless a snapshot is pinned)
+	results, filterStats, err = query.calculate(ctx, db.view, &walkthruCache)
+	if err != nil {
+		return []Collocation{}, err
+	}
+	log.Debug().
+		Int("numTried", filterStats.NumCandidates).
+		Str("procTime", fmt.Sprintf("%1.2f", time.Since(t0).Seconds())).
+		Str("queueWait", fmt.Sprintf("%1.2f", queueWait.Seconds())).
+		Msg("finished collocation search")
+	return results, nil
+}
+
+// validateCalculationArgs checks args and the database features they
+// require and returns the measures to be actually calculated
+func (db *DB) validateCalculationArgs(args CalculationArgs) ([]ResultField, error) {
+	if args.Limit < 0 {
 		panic("CalculateMeasures - invalid limit value")
 	}
-	if !sortBy.Validate() {
+	if args.Offset < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidOffset, args.Offset)
+	}
+	if !args.SortBy.Validate() {
 		panic("CalculateMeasures - invalid sortBy value")
 	}
+	if args.CorpusSize < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCorpusSize, args.CorpusSize)
+	}
+	if (args.MaxAvgSurfaceDist > 0 || args.CollocateOrder != "") && !db.Metadata.HasFeature(FeatureSurfaceDist) {
+		return nil, ErrSurfaceDistUnavailable
+	}
+	if args.IgnoreDiacritics && !db.Metadata.HasFeature(FeatureFoldedLemmas) {
+		return nil, fmt.Errorf(
+			"diacritics-insensitive search failed: %w: %s", ErrFeatureUnavailable, FeatureFoldedLemmas)
+	}
+	if args.IgnoreCase && !db.Metadata.HasFeature(FeatureLowerLemmas) {
+		return nil, fmt.Errorf(
+			"case-insensitive search failed: %w: %s", ErrFeatureUnavailable, FeatureLowerLemmas)
+	}
+	if args.PathCollocations && !db.Metadata.HasFeature(FeaturePathCollocations) {
+		return nil, fmt.Errorf(
+			"path collocations search failed: %w: %s", ErrFeatureUnavailable, FeaturePathCollocations)
+	}
+	if args.GroupByFeats && !db.Metadata.HasFeature(FeatureMorphFeats) {
+		return nil, fmt.Errorf(
+			"grouping by morphological features failed: %w: %s", ErrFeatureUnavailable, FeatureMorphFeats)
+	}
+	if args.SearchByWordForm {
+		if !db.Metadata.HasFeature(FeatureWordForms) {
+			return nil, fmt.Errorf(
+				"word form search failed: %w: %s", ErrFeatureUnavailable, FeatureWordForms)
+		}
+		if args.LemmaPattern != "" || args.IgnoreDiacritics || args.IgnoreCase || args.PathCollocations {
+			return nil, fmt.Errorf(
+				"%w: lemma patterns, case and diacritics-insensitive search and path collocations apply only to lemmas",
+				ErrUnsupportedWordFormSearch)
+		}
+	}
+	for _, f := range args.Fields {
+		if !f.Validate() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidResultField, f)
+		}
+	}
+	if !args.LemmaPattern.Validate() {
+		return nil, fmt.Errorf("%w: unknown syntax %s", ErrInvalidLemmaPattern, args.LemmaPattern)
+	}
+	calcFields := args.Fields
+	if len(calcFields) > 0 {
+		calcFields = append(slices.Clone(args.Fields), args.SortBy.requiredFields()...)
+	}
+	return calcFields, nil
+}
+
+// calculateFromTopCollocations tries to serve the search from precomputed
+// top collocations (see DB.StoreTopCollocations). In case this is not
+// possible, false is returned.
+func (db *DB) calculateFromTopCollocations(
+	args CalculationArgs,
+	calcFields []ResultField,
+) ([]Collocation, bool, error) {
+	ok, excludesTT := db.topCollocationsApplicable(args)
+	if !ok {
+		return nil, false, nil
+	}
+	t0 := time.Now()
+	results, totalCount, found, err := db.getTopCollocations(args, excludesTT, calcFields)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to calculate collocation scores: %w", err)
+	}
+	if !found {
+		return nil, false, nil
+	}
+	db.metrics.observeQuery(time.Since(t0), nil, 0, itemsWalktrhoughCache{})
+	if args.TotalCount != nil {
+		*args.TotalCount = totalCount
+	}
+	if args.FilterStats != nil {
+		*args.FilterStats = FilterStats{
+			NumCandidates:       totalCount,
+			CutByLimit:          totalCount - len(results),
+			ImportMinFreq:       db.Metadata.MinPairFreq,
+			UsedTopCollocations: true,
+		}
+	}
+	log.Debug().
+		Str("lemma", args.Lemma).
+		Str("procTime", fmt.Sprintf("%1.2f", time.Since(t0).Seconds())).
+		Msg("served collocation search from precomputed top collocations")
+	return results, true, nil
+}
+
+// measuresQuery contains everything needed to scan collocation records
+// of a searched lemma. It is prepared outside of any transaction (some
+// of the lookups open their own ones) so multiple queries can be later
+// processed within a single transaction (see DB.CalculateMeasuresBatch).
+type measuresQuery struct {
+	db         *DB
+	args       CalculationArgs
+	calcFields []ResultField
+	corpusSize int64
+
+	// variants are token IDs matching the searched lemma
+	variants   []nodeVariant
+	nodeLabels map[uint64]string
+	deprelSeek *deprelSeeker
+	ttID       byte
+	posID      byte
+	excludedTT map[byte]bool
+
+	useRollups      bool
+	useHotSummaries bool
+}
+
+// prepareMeasuresQuery finds matching node variants and resolves all
+// the filters of args into their raw values
+func (db *DB) prepareMeasuresQuery(args CalculationArgs, calcFields []ResultField) (*measuresQuery, error) {
+	query := &measuresQuery{
+		db:         db,
+		args:       args,
+		calcFields: calcFields,
+		corpusSize: db.Metadata.CorpusSize,
+		ttID:       db.textTypes.ReadableToRaw(args.TextType),
+		posID:      record.UDPoSMapping[args.PoS],
+		excludedTT: make(map[byte]bool),
+	}
+	if args.CorpusSize > 0 {
+		query.corpusSize = args.CorpusSize
+	}
+	if len(args.Deprels) > 0 {
+		codes, err := db.deprelCodes(args.Deprels, args.DeprelGranularity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate collocation scores: %w", err)
+		}
+		query.deprelSeek = newDeprelSeeker(codes, db.keys)
+	}
 	// first we find matching lemmas without considering other attributes
 	// (PoS, deprel). If lemmaIsPrefix is false, then we should always find a single
 	// token ID matching the result.
-	variants, err := db.GetLemmaIDsByPrefix(lemma)
-	if err == badger.ErrKeyNotFound {
-		return []Collocation{}, fmt.Errorf("failed to find matching lemma(s): %w", err)
+	var err error
+	query.variants, query.nodeLabels, err = db.findNodeVariants(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find matching lemma(s): %w", err)
+	}
+	for _, tt := range args.ExcludedTextTypes {
+		if rawTT := db.textTypes.ReadableToRaw(tt); rawTT > 0 {
+			query.excludedTT[rawTT] = true
+		}
+	}
+	if len(args.TextTypeDims) > 0 {
+		mismatches, err := db.textTypeDimsMismatches(args.TextTypeDims)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate collocation scores: %w", err)
+		}
+		for _, rawTT := range mismatches {
+			query.excludedTT[rawTT] = true
+		}
+	}
+	if args.CorpusSize == 0 && len(query.excludedTT) > 0 {
+		query.corpusSize, err = db.corpusSizeExcluding(query.excludedTT)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate collocation scores: %w", err)
+		}
 	}
 
-	var results []Collocation
-	ttID := db.textTypes.ReadableToRaw(textType)
-	posID := record.UDPoSMapping[pos]
+	// Rollup records (summed over text types) can replace the per-text type
+	// single token records only if no text type related operation is needed.
+	// The same applies to features which are not present in the rollups.
+	query.useRollups = db.Metadata.HasFeature(FeatureTokenFreqRollups) && query.ttID == 0 &&
+		!args.CollocateGroupByTextType && len(query.excludedTT) == 0 && !args.GroupByFeats
+
+	// Pre-aggregated records of hot lemmas have no text type information
+	// (and no features) so the same rules apply here (plus a custom filter
+	// cannot be used as it may depend on text types). There are no such
+	// records for path collocations.
+	query.useHotSummaries = db.Metadata.HasFeature(FeatureHotLemmaSummaries) && query.ttID == 0 &&
+		!args.CollocateGroupByTextType && len(query.excludedTT) == 0 && args.CustomFilter == nil &&
+		!args.GroupByFeats && !args.PathCollocations
+	return query, nil
+}
+
+// newGroupings creates groupings of F(x), F(y) and F(x,y) records
+// configured according to the query
+func (q *measuresQuery) newGroupings() (*tokenFreqGrouping, *tokenFreqGrouping, *collFreqGrouping) {
+	args := q.args
 	sumFreqs1 := newTokenFreqGrouping()
 	sumFreqs2 := newTokenFreqGrouping()
 	sumCollFreqs := newCollFreqGrouping()
@@ -348,7 +1325,7 @@ func (db *DB) CalculateMeasures(
 	// if user entered part of speech, we need to distinguish
 	// the same lemmata with different pos in all the parts where
 	// the searched lemma occurs
-	if pos != "" {
+	if args.PoS != "" {
 		sumFreqs1.GroupByPos()
 		sumCollFreqs.GroupByPos1()
 	}
@@ -356,7 +1333,7 @@ func (db *DB) CalculateMeasures(
 	// if user wanted a concrete text type, we need to "group by" it
 	// in all the data (F(x), F(y), F(x, y)) so we will be able to remove
 	// unwanted text types
-	if textType != "" || collocateGroupByTextType {
+	if args.TextType != "" || args.CollocateGroupByTextType {
 		sumFreqs1.GroupByTT()
 		sumFreqs2.GroupByTT()
 		sumCollFreqs.GroupByTT()
@@ -364,176 +1341,151 @@ func (db *DB) CalculateMeasures(
 
 	// if groupByDeprel is true, it means, user wants separate occurrences
 	// of different deprels for the same lemmas
-	if groupByDeprel {
+	if args.GroupByDeprel {
 		sumCollFreqs.GroupByDeprel()
 	}
 
-	if collocateGroupByPos {
+	if args.CollocateGroupByPos {
 		sumFreqs2.GroupByPos()
 		sumCollFreqs.GroupByPos2()
 	}
 
-	walkthruCache := itemsWalktrhoughCache{db: db}
-	numProcVariants := 0
-	t0 := time.Now()
+	// only the searched lemma is split by its features
+	// (pair records contain features of the first token)
+	if args.GroupByFeats {
+		sumFreqs1.GroupByFeats()
+		sumCollFreqs.GroupByFeats1()
+	}
+	return sumFreqs1, sumFreqs2, sumCollFreqs
+}
 
-	err = db.bdb.View(func(txn *badger.Txn) error {
-		for _, lemmaMatch := range variants {
-			if !lemmaIsPrefix && lemmaMatch.Value != lemma {
+// calculate scans collocation records of the query (see measuresQuery.scan),
+// calculates the measures and returns the requested page of sorted collocations.
+// It also fills in all the additional outputs requested via q.args.
+// All the read operations are run via view.
+func (q *measuresQuery) calculate(
+	ctx context.Context,
+	view txnViewer,
+	walkthruCache *itemsWalktrhoughCache,
+) ([]Collocation, FilterStats, error) {
+	db := q.db
+	args := q.args
+	sumFreqs1, sumFreqs2, sumCollFreqs := q.newGroupings()
+
+	var filterStats FilterStats
+	filterStats.ImportMinFreq = db.Metadata.MinPairFreq
+
+	partials, err := q.scan(ctx, view, walkthruCache)
+	if err != nil {
+		return nil, filterStats, err
+	}
+	// F(y) must be added just once for each collocate (and its PoS)
+	// no matter how many F(x,y) records it is involved in
+	seenCollocates := make(map[string]bool)
+	for _, p := range partials {
+		for _, pf1 := range p.lemmaFreqs {
+			sumFreqs1.add(pf1)
+		}
+		sumCollFreqs.merge(p.collFreqs)
+		for collocateKey, freqs2 := range p.collocateFreqs {
+			if seenCollocates[collocateKey] {
 				continue
 			}
-			// First, get F(x) (i.e. freq. of the searched lemma). This search respects
-			// possible provided PoS and text type specification. Attribute deprel cannot
-			// be used in filter this way so it is filtered later (if needed).
-			partialFreqs1, err := walkthruCache.getRawTokenFreqTx(txn, lemmaMatch.TokenID, posID, ttID)
-			if err != nil {
-				return fmt.Errorf("failed to calculate collocation scores: %w", err)
-			}
-			for _, pf1 := range partialFreqs1 {
-				sumFreqs1.add(pf1)
+			seenCollocates[collocateKey] = true
+			for _, psf2 := range freqs2 {
+				sumFreqs2.add(psf2)
 			}
+		}
+		filterStats.addScanStats(p.filterStats)
+	}
 
-			var headDepSearches []bool
-			if isHead == nil {
-				headDepSearches = []bool{true, false}
-
-			} else {
-				headDepSearches = []bool{*isHead}
+	var results []Collocation
+	err = view(func(txn *badger.Txn) error {
+		for _, val := range sumCollFreqs.Iter {
+			if int(val.Freq) < args.MinCollFreq {
+				filterStats.MinCollFreq++
+				continue
 			}
-			for _, directionFlag := range headDepSearches {
-				pairPrefix := record.AllCollFreqsOfToken(directionFlag, lemmaMatch.TokenID)
-				opts := badger.IteratorOptions{
-					Prefix:         pairPrefix,
-					PrefetchValues: true,
-					PrefetchSize:   1000,
-				}
-				it := txn.NewIterator(opts)
-				defer it.Close()
-				numDbItems := 0
-
-				for it.Rewind(); it.Valid(); it.Next() {
-					item := it.Item()
-					key := item.Key()
-					decKey := record.DecodeCollFreqKey(key)
-
-					if ttID > 0 && decKey.TextType != ttID {
-						continue
-					}
-
-					var collValue record.CollocValue
-					// Get F(x,y) frequency information
-					err := item.Value(func(val []byte) error {
-						collValue = record.DecodeCollocValue(val)
-						return nil
-					})
-					if err != nil {
-						// TODO
-						fmt.Fprintf(os.Stderr, "failed to get freqs from db: %s", err)
-						continue
-					}
-
-					if customFilter != nil && !customFilter(
-						decKey.Pos1, decKey.Deprel, decKey.Pos2, decKey.TextType, collValue.Dist) {
-						continue
-					}
-
-					if maxAvgCollocateDist > 0 && math.Abs(collValue.Dist) > maxAvgCollocateDist {
-						continue
-					}
-
-					// F(x, y)
-					sumCollFreqs.add(record.RawCollocFreq{
-						Token1ID: decKey.Token1ID,
-						PoS1:     decKey.Pos1,
-						Deprel:   decKey.Deprel,
-						Token2ID: decKey.Token2ID,
-						PoS2:     decKey.Pos2,
-						Freq:     collValue.Freq,
-						AVGDist:  collValue.Dist,
-						TextType: decKey.TextType,
-					})
-
-					// Get F(y) - frequency of second lemma
-					partialSplitFreq2, err := walkthruCache.getRawTokenFreqTx(
-						txn, decKey.Token2ID, decKey.Pos2, ttID)
-					if err != nil {
-						continue // Skip if we can't find single freq
-					}
-					for _, psf2 := range partialSplitFreq2 {
-						sumFreqs2.add(psf2)
-					}
-					numDbItems++
-				}
+			lemma2, err := walkthruCache.getLemmaByIDTxn(txn, val.Token2ID)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "err: ", err)
+				// TODO !!
 			}
-			for _, val := range sumCollFreqs.Iter {
-				lemma2, err := walkthruCache.getLemmaByIDTxn(txn, val.Token2ID)
-				if err != nil {
-					fmt.Fprintln(os.Stderr, "err: ", err)
-					// TODO !!
-				}
-				f1 := sumFreqs1.get(val.GroupingKeyLemma1Binary())
-				f2 := sumFreqs2.get(val.GroupingKeyLemma2Binary())
-
-				logDice := 14.0 + math.Log2(float64(2*val.Freq)/float64(f1.Freq+f2.Freq))
-				tscore := (float64(val.Freq) - (float64(f1.Freq)*float64(f2.Freq))/float64(db.Metadata.CorpusSize)) / math.Sqrt(float64(val.Freq))
-				lmi := float64(val.Freq) * math.Log2(float64(db.Metadata.CorpusSize)*float64(val.Freq)/float64(f1.Freq*f2.Freq))
-				ll := LLScore(val.Freq, f1.Freq, f2.Freq, db.Metadata.CorpusSize)
-				results = append(results, Collocation{
-					Lemma: CollMember{
-						Value: lemmaMatch.Value,
-						PoS:   pos,
-					},
-					Deprel: db.DeprelMapping.GetRev(val.Deprel),
-					Collocate: CollMember{
-						Value: lemma2,
-						PoS:   record.UDPosFromByte(val.PoS2).Readable,
-					},
-					LogDice:       logDice,
-					TScore:        tscore,
-					LMI:           lmi,
-					TextType:      db.textTypes.RawToReadable(val.TextType),
-					LogLikelihood: ll,
-					MutualDist:    val.AVGDist,
-				})
-				numProcVariants++
+			f1 := sumFreqs1.get(val.GroupingKeyLemma1Binary())
+			f2 := sumFreqs2.get(val.GroupingKeyLemma2Binary())
+
+			if int64(f1.Freq) > q.corpusSize || int64(f2.Freq) > q.corpusSize {
+				return fmt.Errorf(
+					"%w: %d is lower than the frequency of the searched lemma or a collocate",
+					ErrInvalidCorpusSize, q.corpusSize,
+				)
+			}
+			mutualDist := val.AVGDist
+			if args.SignedDistance && !val.IsHead {
+				mutualDist = -mutualDist
 			}
+			item := Collocation{
+				Lemma: CollMember{
+					Value: q.nodeLabels[val.Token1ID],
+					PoS:   args.PoS,
+					Feats: val.Feats1.String(),
+				},
+				Deprel: db.DeprelMapping.GetRev(val.Deprel),
+				Collocate: CollMember{
+					Value: lemma2,
+					PoS:   record.UDPosFromByte(val.PoS2).Readable,
+				},
+				TextType:      db.textTypes.RawToReadable(val.TextType),
+				IsHead:        val.IsHead,
+				MutualDist:    mutualDist,
+				SurfaceDist:   val.AVGSurfaceDist,
+				CorpusSize:    q.corpusSize,
+				Freq:          int(val.Freq),
+				LemmaFreq:     int(f1.Freq),
+				CollocateFreq: int(f2.Freq),
+				Fields:        args.Fields,
+			}
+			item.UpdateScores(q.calcFields)
+			results = append(results, item)
 		}
-
 		return nil
 	})
 	if err != nil {
-		return []Collocation{}, err
+		return nil, filterStats, err
 	}
 
-	switch sortBy {
-	case sortByTScore:
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].TScore > results[j].TScore
-		})
-	case sortByLogDice:
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].LogDice > results[j].LogDice
-		})
-	case sortByLMI:
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].LMI > results[j].LMI
-		})
-	case sortByLL:
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].LogLikelihood > results[j].LogLikelihood
-		})
-	case sortByRRF:
-		SortByRRF(results)
-	}
-
-	if len(results) > limit {
-		results = results[:limit]
+	SortCollocations(results, args.SortBy)
+
+	filterStats.NumCandidates = len(results)
+	if args.CategoryLexicon != nil && args.CategoryProfile != nil {
+		*args.CategoryProfile = ProfileCategories(results, args.CategoryLexicon)
 	}
-	log.Debug().
-		Int("numTried", numProcVariants).
-		Str("procTime", fmt.Sprintf("%1.2f", time.Since(t0).Seconds())).
-		Msg("finished collocation search")
-	return results, err
+	var numVariantCandidates map[string]int
+	if args.VariantSummary != nil {
+		numVariantCandidates = CountVariantCollocations(results)
+	}
+	if args.TotalCount != nil {
+		*args.TotalCount = len(results)
+	}
+	results = PageCollocations(results, args.Offset, args.Limit, args.LimitPerVariant)
+	filterStats.CutByLimit = filterStats.NumCandidates - len(results)
+	if args.FilterStats != nil {
+		*args.FilterStats = filterStats
+	}
+	if args.VariantSummary != nil {
+		summary := summarizeVariants(q.variants, q.nodeLabels)
+		numReturned := CountVariantCollocations(results)
+		for i, v := range summary {
+			summary[i].NumCandidates = numVariantCandidates[v.Node]
+			summary[i].NumReturned = numReturned[v.Node]
+		}
+		*args.VariantSummary = summary
+	}
+	log.Trace().
+		Str("lemma", args.Lemma).
+		Any("filterStats", filterStats).
+		Msg("collocation search candidate filtering")
+	return results, filterStats, nil
 }
 
 // ------------------------------------
@@ -541,6 +1493,14 @@ func (db *DB) CalculateMeasures(
 type CollMember struct {
 	Value string `json:"value"`
 	PoS   string `json:"pos"`
+
+	// Feats contains morphological features (in the UD FEATS format)
+	// in case the search is grouped by them
+	Feats string `json:"feats,omitempty"`
+
+	// PoSDescription is an optional human-readable description
+	// of the PoS tag (see record.DescribePoS)
+	PoSDescription string `json:"posDescription,omitempty"`
 }
 
 type roundedFloat float64
@@ -551,44 +1511,257 @@ func (rf roundedFloat) MarshalJSON() ([]byte, error) {
 }
 
 type Collocation struct {
-	Lemma         CollMember
-	Collocate     CollMember
-	Deprel        string
-	LogDice       float64
-	TScore        float64
-	MutualDist    float64
+	Lemma     CollMember
+	Collocate CollMember
+	Deprel    string
+
+	// DeprelDescription is an optional human-readable description
+	// of the relation (see record.DescribeDeprel)
+	DeprelDescription string
+
+	// CQL is an optional query retrieving the co-occurrences
+	// in the source corpus
+	CQL string
+
+	// IsHead tells whether the searched lemma is the head of the relation
+	IsHead  bool
+	LogDice float64
+	TScore  float64
+
+	// MutualDist is an average distance between the lemma and the collocate.
+	// It is non-negative unless the legacy signed convention is requested
+	// (see CalculationArgs.SignedDistance).
+	MutualDist float64
+
+	// SurfaceDist is an average linear distance of the collocate from
+	// the lemma (negative = the collocate precedes the lemma)
+	SurfaceDist   float64
 	LMI           float64
 	LogLikelihood float64
 	RRFScore      float64
 	TextType      string
+
+	// MI, MI3, Dice and MinSensitivity are classic association measures
+	// (see UpdateScores) which are not used by RRF.
+	MI             float64
+	MI3            float64
+	Dice           float64
+	MinSensitivity float64
+
+	// DeltaPCollocate and DeltaPNode are asymmetric (directional)
+	// association measures - ΔP(collocate|node) tells how much the node
+	// increases the probability of the collocate and ΔP(node|collocate)
+	// tells the same in the opposite direction.
+	DeltaPCollocate float64
+	DeltaPNode      float64
+
+	// CorpusSize is the N used to calculate the measures
+	CorpusSize int64
+
+	// Freq, LemmaFreq and CollocateFreq are the raw frequencies
+	// F(x,y), F(x) and F(y) the measures are calculated from.
+	Freq          int
+	LemmaFreq     int
+	CollocateFreq int
+
+	// FreqIPM, LemmaFreqIPM and CollocateFreqIPM are the raw frequencies
+	// normalized to the corpus size (instances per million tokens).
+	// They are calculated along with the scores (see UpdateScores).
+	FreqIPM          float64
+	LemmaFreqIPM     float64
+	CollocateFreqIPM float64
+
+	// Fields contains selected optional fields (see CalculationArgs.Fields).
+	// Only these are encoded. Empty value means all the fields.
+	Fields []ResultField
+
+	// SecondOrder contains collocates of the collocate (see
+	// DB.CalculateSecondOrder). For ordinary searches, it is empty.
+	SecondOrder []Collocation
+}
+
+// collocationRecord is a serialization form of Collocation
+// shared by all the supported encodings
+type collocationRecord struct {
+	Lemma             CollMember    `json:"lemma"`
+	IsHead            bool          `json:"isHead"`
+	Collocate         CollMember    `json:"collocate"`
+	Deprel            string        `json:"deprel"`
+	DeprelDescription string        `json:"deprelDescription,omitempty"`
+	LogDice           *roundedFloat `json:"logDice,omitempty"`
+	TScore            *roundedFloat `json:"tScore,omitempty"`
+	MutualDist        *roundedFloat `json:"mutualDist,omitempty"`
+	SurfaceDist       *roundedFloat `json:"surfaceDist,omitempty"`
+	LMI               *roundedFloat `json:"lmi,omitempty"`
+	LogLikelihood     *roundedFloat `json:"logLikelihood,omitempty"`
+	RRFScore          *roundedFloat `json:"rrfScore,omitempty"`
+	MI                *roundedFloat `json:"mi,omitempty"`
+	MI3               *roundedFloat `json:"mi3,omitempty"`
+	Dice              *roundedFloat `json:"dice,omitempty"`
+	MinSensitivity    *roundedFloat `json:"minSensitivity,omitempty"`
+	DeltaPCollocate   *roundedFloat `json:"deltaPCollocate,omitempty"`
+	DeltaPNode        *roundedFloat `json:"deltaPNode,omitempty"`
+	TextType          *string       `json:"textType,omitempty"`
+	CorpusSize        *int64        `json:"corpusSize,omitempty"`
+	Freq              *int          `json:"freq,omitempty"`
+	LemmaFreq         *int          `json:"lemmaFreq,omitempty"`
+	CollocateFreq     *int          `json:"collocateFreq,omitempty"`
+	FreqIPM           *roundedFloat `json:"freqIpm,omitempty"`
+	LemmaFreqIPM      *roundedFloat `json:"lemmaFreqIpm,omitempty"`
+	CollocateFreqIPM  *roundedFloat `json:"collocateFreqIpm,omitempty"`
+	CQL               string        `json:"cql,omitempty"`
+
+	SecondOrder []collocationRecord `json:"secondOrder,omitempty"`
+}
+
+// selectedFloat returns a pointer to the value in case
+// the field is selected. Otherwise, nil is returned.
+func (col Collocation) selectedFloat(f ResultField, v float64) *roundedFloat {
+	if !hasField(col.Fields, f) {
+		return nil
+	}
+	ans := roundedFloat(v)
+	return &ans
+}
+
+func (col Collocation) asRecord() collocationRecord {
+	ans := collocationRecord{
+		Lemma:             col.Lemma,
+		IsHead:            col.IsHead,
+		Deprel:            col.Deprel,
+		DeprelDescription: col.DeprelDescription,
+		CQL:               col.CQL,
+		Collocate:         col.Collocate,
+		LogDice:           col.selectedFloat(FieldLogDice, col.LogDice),
+		TScore:            col.selectedFloat(FieldTScore, col.TScore),
+		MutualDist:        col.selectedFloat(FieldMutualDist, col.MutualDist),
+		SurfaceDist:       col.selectedFloat(FieldSurfaceDist, col.SurfaceDist),
+		LMI:               col.selectedFloat(FieldLMI, col.LMI),
+		RRFScore:          col.selectedFloat(FieldRRFScore, col.RRFScore),
+		LogLikelihood:     col.selectedFloat(FieldLogLikelihood, col.LogLikelihood),
+		MI:                col.selectedFloat(FieldMI, col.MI),
+		MI3:               col.selectedFloat(FieldMI3, col.MI3),
+		Dice:              col.selectedFloat(FieldDice, col.Dice),
+		MinSensitivity:    col.selectedFloat(FieldMinSensitivity, col.MinSensitivity),
+		DeltaPCollocate:   col.selectedFloat(FieldDeltaPCollocate, col.DeltaPCollocate),
+		DeltaPNode:        col.selectedFloat(FieldDeltaPNode, col.DeltaPNode),
+		FreqIPM:           col.selectedFloat(FieldFreqIPM, col.FreqIPM),
+		LemmaFreqIPM:      col.selectedFloat(FieldLemmaFreqIPM, col.LemmaFreqIPM),
+		CollocateFreqIPM:  col.selectedFloat(FieldCollocateFreqIPM, col.CollocateFreqIPM),
+	}
+	if hasField(col.Fields, FieldTextType) {
+		ans.TextType = &col.TextType
+	}
+	if hasField(col.Fields, FieldCorpusSize) {
+		ans.CorpusSize = &col.CorpusSize
+	}
+	if hasField(col.Fields, FieldFreq) {
+		ans.Freq = &col.Freq
+	}
+	if hasField(col.Fields, FieldLemmaFreq) {
+		ans.LemmaFreq = &col.LemmaFreq
+	}
+	if hasField(col.Fields, FieldCollocateFreq) {
+		ans.CollocateFreq = &col.CollocateFreq
+	}
+	if len(col.SecondOrder) > 0 {
+		ans.SecondOrder = make([]collocationRecord, len(col.SecondOrder))
+		for i, item := range col.SecondOrder {
+			ans.SecondOrder[i] = item.asRecord()
+		}
+	}
+	return ans
 }
 
 func (col Collocation) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Lemma         CollMember   `json:"lemma"`
-		IsHead        bool         `json:"isHead"`
-		Collocate     CollMember   `json:"collocate"`
-		Deprel        string       `json:"deprel"`
-		LogDice       roundedFloat `json:"logDice"`
-		TScore        roundedFloat `json:"tScore"`
-		MutualDist    roundedFloat `json:"mutualDist"`
-		LMI           roundedFloat `json:"lmi"`
-		LogLikelihood roundedFloat `json:"logLikelihood"`
-		RRFScore      roundedFloat `json:"rrfScore"`
-		TextType      string       `json:"textType"`
+	return json.Marshal(col.asRecord())
+}
+
+// UnmarshalJSON decodes a collocation encoded by MarshalJSON.
+// Please note that score values are rounded in the encoded form.
+// In case some optional fields are missing, the Fields attribute
+// is set to the present ones.
+func (col *Collocation) UnmarshalJSON(data []byte) error {
+	var rec collocationRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+	col.fromRecord(rec)
+	return nil
+}
+
+func (col *Collocation) fromRecord(rec collocationRecord) {
+	col.Lemma = rec.Lemma
+	col.IsHead = rec.IsHead
+	col.Collocate = rec.Collocate
+	col.Deprel = rec.Deprel
+	col.DeprelDescription = rec.DeprelDescription
+	col.CQL = rec.CQL
+	col.Fields = nil
+	present := make([]ResultField, 0, len(ResultFields))
+	for _, item := range []struct {
+		field ResultField
+		src   *roundedFloat
+		dst   *float64
 	}{
-		Lemma:         col.Lemma,
-		IsHead:        col.MutualDist > 0,
-		Deprel:        col.Deprel,
-		Collocate:     col.Collocate,
-		LogDice:       roundedFloat(col.LogDice),
-		TScore:        roundedFloat(col.TScore),
-		MutualDist:    roundedFloat(col.MutualDist),
-		LMI:           roundedFloat(col.LMI),
-		RRFScore:      roundedFloat(col.RRFScore),
-		LogLikelihood: roundedFloat(col.LogLikelihood),
-		TextType:      col.TextType,
-	})
+		{FieldLogDice, rec.LogDice, &col.LogDice},
+		{FieldTScore, rec.TScore, &col.TScore},
+		{FieldMutualDist, rec.MutualDist, &col.MutualDist},
+		{FieldSurfaceDist, rec.SurfaceDist, &col.SurfaceDist},
+		{FieldLMI, rec.LMI, &col.LMI},
+		{FieldLogLikelihood, rec.LogLikelihood, &col.LogLikelihood},
+		{FieldRRFScore, rec.RRFScore, &col.RRFScore},
+		{FieldMI, rec.MI, &col.MI},
+		{FieldMI3, rec.MI3, &col.MI3},
+		{FieldDice, rec.Dice, &col.Dice},
+		{FieldMinSensitivity, rec.MinSensitivity, &col.MinSensitivity},
+		{FieldDeltaPCollocate, rec.DeltaPCollocate, &col.DeltaPCollocate},
+		{FieldDeltaPNode, rec.DeltaPNode, &col.DeltaPNode},
+		{FieldFreqIPM, rec.FreqIPM, &col.FreqIPM},
+		{FieldLemmaFreqIPM, rec.LemmaFreqIPM, &col.LemmaFreqIPM},
+		{FieldCollocateFreqIPM, rec.CollocateFreqIPM, &col.CollocateFreqIPM},
+	} {
+		*item.dst = 0
+		if item.src != nil {
+			*item.dst = float64(*item.src)
+			present = append(present, item.field)
+		}
+	}
+	col.TextType = ""
+	if rec.TextType != nil {
+		col.TextType = *rec.TextType
+		present = append(present, FieldTextType)
+	}
+	col.CorpusSize = 0
+	if rec.CorpusSize != nil {
+		col.CorpusSize = *rec.CorpusSize
+		present = append(present, FieldCorpusSize)
+	}
+	for _, item := range []struct {
+		field ResultField
+		src   *int
+		dst   *int
+	}{
+		{FieldFreq, rec.Freq, &col.Freq},
+		{FieldLemmaFreq, rec.LemmaFreq, &col.LemmaFreq},
+		{FieldCollocateFreq, rec.CollocateFreq, &col.CollocateFreq},
+	} {
+		*item.dst = 0
+		if item.src != nil {
+			*item.dst = *item.src
+			present = append(present, item.field)
+		}
+	}
+	if len(present) < len(ResultFields) {
+		col.Fields = present
+	}
+	col.SecondOrder = nil
+	if len(rec.SecondOrder) > 0 {
+		col.SecondOrder = make([]Collocation, len(rec.SecondOrder))
+		for i, item := range rec.SecondOrder {
+			col.SecondOrder[i].fromRecord(item)
+		}
+	}
 }
 
 func (ldr Collocation) Hash() string {
@@ -596,19 +1769,26 @@ func (ldr Collocation) Hash() string {
 	data := fmt.Sprintf("%s|%s|%t|%s|%s",
 		ldr.Lemma.Value,
 		ldr.Lemma.PoS,
-		ldr.MutualDist > 0,
+		ldr.IsHead,
 		ldr.Collocate.Value,
 		ldr.TextType,
 	)
+	if ldr.Lemma.Feats != "" {
+		data += "|" + ldr.Lemma.Feats
+	}
 	hash.Write([]byte(data))
 	return fmt.Sprintf("%x", hash.Sum(nil))
 }
 
 func (ldr Collocation) lemmaPropsAsString() string {
-	if ldr.Lemma.PoS == "" {
-		return "(-)"
+	pos := ldr.Lemma.PoS
+	if pos == "" {
+		pos = "-"
+	}
+	if ldr.Lemma.Feats != "" {
+		return "(" + pos + "; " + ldr.Lemma.Feats + ")"
 	}
-	return "(" + ldr.Lemma.PoS + ")"
+	return "(" + pos + ")"
 }
 
 func (ldr Collocation) collocatePropsAsString() string {
@@ -641,7 +1821,7 @@ func (ldr Collocation) formatNum4(v float64) string {
 
 func (ldr Collocation) AsRow() []any {
 	var arr string
-	if ldr.MutualDist < 0 {
+	if !ldr.IsHead {
 		dpr := ""
 		if ldr.Deprel != "" {
 			dpr = ldr.Deprel + " "
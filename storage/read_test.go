@@ -0,0 +1,1432 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func openTestDB(t *testing.T) *DB {
+	opts := badger.DefaultOptions("").WithInMemory(true).WithLogger(nil)
+	bdb, err := badger.Open(opts)
+	assert.NoError(t, err, "Failed to open in-memory database")
+	t.Cleanup(func() { bdb.Close() })
+	return &DB{
+		bdb: bdb,
+		textTypes: &PreconfTextTypeMapping{
+			data: map[string]byte{"fiction": 0x01, "news": 0x02},
+		},
+	}
+}
+
+func TestSortingMeasureValidate(t *testing.T) {
+	for _, m := range SortingMeasures {
+		assert.True(t, m.Validate(), m)
+	}
+	assert.True(t, SortingMeasure("ll").Validate())
+	assert.False(t, SortingMeasure("foo").Validate())
+	assert.False(t, SortingMeasure("").Validate())
+}
+
+func TestGetLemmaInfo(t *testing.T) {
+	db := openTestDB(t)
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "run", PoS: record.UDPosFromByte(record.PosVERB), Freq: 10, TextType: fiction},
+		"2": {Lemma: "run", PoS: record.UDPosFromByte(record.PosNOUN), Freq: 4, TextType: fiction},
+		"3": {Lemma: "run", PoS: record.UDPosFromByte(record.PosVERB), Freq: 3, TextType: news},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, nil, 1)
+	assert.NoError(t, err)
+
+	info, err := db.GetLemmaInfo("run", nil)
+	assert.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, 17, info.Freq)
+	assert.Equal(t, map[string]int{"VERB": 13, "NOUN": 4}, info.PoSFreqs)
+
+	info, err = db.GetLemmaInfo("run", []string{"news"})
+	assert.NoError(t, err)
+	assert.Equal(t, 14, info.Freq)
+	assert.Equal(t, 10, info.PoSFreqs["VERB"])
+
+	info, err = db.GetLemmaInfo("walk", nil)
+	assert.NoError(t, err)
+	assert.False(t, info.Exists)
+	assert.Zero(t, info.Freq)
+}
+
+func TestGetLemmaFreq(t *testing.T) {
+	db := openTestDB(t)
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "run", PoS: record.UDPosFromByte(record.PosVERB), Freq: 10, TextType: fiction},
+		"2": {Lemma: "run", PoS: record.UDPosFromByte(record.PosNOUN), Freq: 4, TextType: fiction},
+		"3": {Lemma: "run", PoS: record.UDPosFromByte(record.PosVERB), Freq: 3, TextType: news},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, nil, 1)
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		pos, textType string
+		excluded      []string
+		expected      int
+	}{
+		{"", "", nil, 17},
+		{"VERB", "", nil, 13},
+		{"", "news", nil, 3},
+		{"VERB", "fiction", nil, 10},
+		{"", "", []string{"fiction"}, 3},
+		{"", "unknown", nil, 0},
+	} {
+		freq, err := db.GetLemmaFreq("run", tc.pos, tc.textType, tc.excluded)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, freq, "pos: %s, text type: %s", tc.pos, tc.textType)
+	}
+
+	freq, err := db.GetLemmaFreq("walk", "", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, freq)
+}
+
+func TestCalculateMeasuresLemmaSet(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "tuesday", PoS: noun, Freq: 10, TextType: tt},
+		"3": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "tuesday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 4, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:    "weekdays",
+		LemmaSet: []string{"monday", "tuesday", "unknown"},
+		Limit:    10,
+		SortBy:   sortByLogDice,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "weekdays", ans[0].Lemma.Value)
+	assert.Equal(t, "work", ans[0].Collocate.Value)
+	// F(x,y) = 6 + 4, F(x) = 20 + 10, F(y) = 50
+	assert.InDelta(t, 14.0+math.Log2(2*10.0/(30+50)), ans[0].LogDice, 0.0001)
+	assert.Equal(t, int64(1000), ans[0].CorpusSize)
+}
+
+func TestCalculateMeasuresCollocateFreqCountedOnce(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "tuesday", PoS: noun, Freq: 10, TextType: tt},
+		"3": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+	}
+	// the same collocate in multiple F(x,y) records (of one or more node lemmas)
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Deprel: record.ImportUDDeprel("obl"),
+			Freq: 6, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Deprel: record.ImportUDDeprel("nsubj"),
+			Freq: 2, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "tuesday", PoS1: noun, Lemma2: "work", PoS2: verb, Deprel: record.ImportUDDeprel("obl"),
+			Freq: 4, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.CalculateMeasures(
+		context.Background(), CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	if assert.Len(t, ans, 1) {
+		assert.Equal(t, 8, ans[0].Freq)
+		assert.Equal(t, 20, ans[0].LemmaFreq)
+		assert.Equal(t, 50, ans[0].CollocateFreq)
+	}
+
+	ans, err = db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:    "weekdays",
+		LemmaSet: []string{"monday", "tuesday"},
+		Limit:    10,
+		SortBy:   sortByLogDice,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, ans, 1) {
+		assert.Equal(t, 12, ans[0].Freq)
+		assert.Equal(t, 30, ans[0].LemmaFreq)
+		assert.Equal(t, 50, ans[0].CollocateFreq)
+	}
+}
+
+func TestCalculateMeasuresPrefixVariantsListedOnce(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "work", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "worker", PoS: noun, Freq: 10, TextType: tt},
+		"3": {Lemma: "workshop", PoS: noun, Freq: 5, TextType: tt},
+		"4": {Lemma: "hard", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "work", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "worker", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 4, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	// each variant's collocation must be listed exactly once no matter
+	// how many variants (incl. ones without collocations) are processed
+	ans, err := db.CalculateMeasures(
+		context.Background(),
+		CalculationArgs{Lemma: "work", LemmaIsPrefix: true, Limit: 10, SortBy: sortByLogDice},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	freqs := make(map[string]int)
+	for _, item := range ans {
+		freqs[item.Lemma.Value] += item.Freq
+	}
+	assert.Equal(t, map[string]int{"work": 6, "worker": 4}, freqs)
+}
+
+func TestCalculateMeasuresMergePrefixVariants(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "work", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "worker", PoS: noun, Freq: 10, TextType: tt},
+		"3": {Lemma: "hard", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "work", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "worker", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 4, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{
+		Lemma:         "work",
+		LemmaIsPrefix: true,
+		Limit:         10,
+		SortBy:        sortByLogDice,
+	}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	nodes := []string{ans[0].Lemma.Value, ans[1].Lemma.Value}
+	assert.ElementsMatch(t, []string{"work", "worker"}, nodes)
+
+	args.MergePrefixVariants = true
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "work", ans[0].Lemma.Value)
+	assert.Equal(t, "hard", ans[0].Collocate.Value)
+	assert.Equal(t, 10, ans[0].Freq)
+	assert.Equal(t, 30, ans[0].LemmaFreq)
+	// F(x,y) = 6 + 4, F(x) = 20 + 10, F(y) = 50
+	assert.InDelta(t, 14.0+math.Log2(2*10.0/(30+50)), ans[0].LogDice, 0.0001)
+}
+
+func TestCalculateMeasuresLimitPerVariant(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "work", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "worker", PoS: noun, Freq: 10, TextType: tt},
+		"3": {Lemma: "hard", PoS: verb, Freq: 50, TextType: tt},
+		"4": {Lemma: "start", PoS: verb, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "work", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 20, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "work", PoS1: noun, Lemma2: "start", PoS2: verb, Freq: 15, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "worker", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 2, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	var summary []NodeVariantSummary
+	args := CalculationArgs{
+		Lemma:          "work",
+		LemmaIsPrefix:  true,
+		Limit:          1,
+		SortBy:         sortByLogDice,
+		VariantSummary: &summary,
+	}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "work", ans[0].Lemma.Value)
+	assert.Equal(t, []NodeVariantSummary{
+		{Node: "work", Lemmas: []string{"work"}, NumCandidates: 2, NumReturned: 1},
+		{Node: "worker", Lemmas: []string{"worker"}, NumCandidates: 1, NumReturned: 0},
+	}, summary)
+
+	args.LimitPerVariant = true
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.ElementsMatch(t, []string{"work", "worker"}, []string{ans[0].Lemma.Value, ans[1].Lemma.Value})
+	assert.Equal(t, 1, summary[1].NumReturned)
+
+	args.MergePrefixVariants = true
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Equal(t, []NodeVariantSummary{
+		{Node: "work", Lemmas: []string{"work", "worker"}, NumCandidates: 2, NumReturned: 1},
+	}, summary)
+}
+
+func TestCalculateMeasuresOffset(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "work", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "hard", PoS: verb, Freq: 50, TextType: tt},
+		"3": {Lemma: "start", PoS: verb, Freq: 30, TextType: tt},
+		"4": {Lemma: "finish", PoS: verb, Freq: 20, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "work", PoS1: noun, Lemma2: "hard", PoS2: verb, Freq: 20, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "work", PoS1: noun, Lemma2: "start", PoS2: verb, Freq: 15, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "work", PoS1: noun, Lemma2: "finish", PoS2: verb, Freq: 5, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	all, err := db.CalculateMeasures(context.Background(), CalculationArgs{Lemma: "work", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	var total int
+	args := CalculationArgs{Lemma: "work", Limit: 2, Offset: 1, SortBy: sortByLogDice, TotalCount: &total}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{all[1].Collocate.Value, all[2].Collocate.Value}, []string{ans[0].Collocate.Value, ans[1].Collocate.Value})
+
+	args.Offset = 3
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+	assert.Equal(t, 3, total)
+
+	args.Offset = -1
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrInvalidOffset)
+}
+
+func TestCalculateMeasuresSearchByWordForm(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "big", PoS: adj, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Freq: 10, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	formSingleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dogs", PoS: noun, Freq: 15, TextType: tt},
+		"2": {Lemma: "dog", PoS: noun, Freq: 25, TextType: tt},
+		"3": {Lemma: "bigger", PoS: adj, Freq: 8, TextType: tt},
+	}
+	formPairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dogs", PoS1: noun, Lemma2: "bigger", PoS2: adj, Freq: 4, AVGDist: 1, TextType: tt},
+	}
+	stats, err := db.StoreWordFormFreqs(formSingleFreqs, formPairFreqs, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.NumWordForms)
+	assert.Equal(t, 0, stats.NumLemmas)
+
+	args := CalculationArgs{Lemma: "dogs", Limit: 10, SortBy: sortByLogDice, SearchByWordForm: true}
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrFeatureUnavailable)
+
+	db.Metadata.Features = []DatasetFeature{FeatureWordForms}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "dogs", ans[0].Lemma.Value)
+	assert.Equal(t, "bigger", ans[0].Collocate.Value)
+	assert.Equal(t, 4, ans[0].Freq)
+
+	// the lemma "dog" is not affected by the word form "dog"
+	ans, err = db.CalculateMeasures(context.Background(), CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "big", ans[0].Collocate.Value)
+
+	args.LemmaPattern = LemmaPatternGlob
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrUnsupportedWordFormSearch)
+
+	// appended word forms get IDs following the stored ones
+	// and lemma IDs are not affected
+	stats, err = db.AppendWordFormFreqs(
+		map[record.GroupingKey]record.TokenFreq{"1": {Lemma: "cats", PoS: noun, Freq: 3, TextType: tt}}, nil, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.NumWordForms)
+	catsID, err := db.GetWordFormID("cats")
+	assert.NoError(t, err)
+	assert.Equal(t, db.keys.WordFormTokenIDFlag()|4, catsID)
+	lemmaSeq, err := db.TokenIDSequence()
+	assert.NoError(t, err)
+	nextID, err := lemmaSeq.next("cat", db.keys)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), nextID)
+}
+
+func TestCalculateMeasuresGroupByFeats(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	sing := record.ParseUDFeats("Number=Sing", nil)
+	plur := record.ParseUDFeats("Number=Plur", nil)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "book", PoS: noun, Freq: 30, TextType: tt, Feats: sing},
+		"2": {Lemma: "book", PoS: noun, Freq: 10, TextType: tt, Feats: plur},
+		"3": {Lemma: "old", PoS: adj, Freq: 20, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "book", PoS1: noun, Lemma2: "old", PoS2: adj, Freq: 6, AVGDist: 1, TextType: tt, Feats1: sing},
+		"2": {Lemma1: "book", PoS1: noun, Lemma2: "old", PoS2: adj, Freq: 4, AVGDist: 1, TextType: tt, Feats1: plur},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	// without grouping, the features are summed up
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{Lemma: "book", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 10, ans[0].Freq)
+	assert.Equal(t, 40, ans[0].LemmaFreq)
+	assert.Equal(t, "", ans[0].Lemma.Feats)
+
+	args := CalculationArgs{Lemma: "book", Limit: 10, SortBy: sortByLogDice, GroupByFeats: true}
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrFeatureUnavailable)
+
+	db.Metadata.Features = []DatasetFeature{FeatureMorphFeats}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	byFeats := make(map[string]Collocation)
+	for _, item := range ans {
+		byFeats[item.Lemma.Feats] = item
+	}
+	assert.Equal(t, 6, byFeats["Number=Sing"].Freq)
+	assert.Equal(t, 30, byFeats["Number=Sing"].LemmaFreq)
+	assert.Equal(t, 4, byFeats["Number=Plur"].Freq)
+	assert.Equal(t, 10, byFeats["Number=Plur"].LemmaFreq)
+	assert.NotEqual(t, byFeats["Number=Sing"].Hash(), byFeats["Number=Plur"].Hash())
+}
+
+func TestCalculateMeasuresIgnoreDiacritics(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "hřiště", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "hriste", PoS: noun, Freq: 5, TextType: tt},
+		"3": {Lemma: "školní", PoS: adj, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "hřiště", PoS1: noun, Lemma2: "školní", PoS2: adj, Freq: 10, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "hriste", PoS1: noun, Lemma2: "školní", PoS2: adj, Freq: 2, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{Lemma: "hriste", Limit: 10, SortBy: sortByLogDice}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "hriste", ans[0].Lemma.Value)
+
+	args.IgnoreDiacritics = true
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrFeatureUnavailable)
+
+	db.Metadata.Features = []DatasetFeature{FeatureFoldedLemmas}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.ElementsMatch(t, []string{"hriste", "hřiště"}, []string{ans[0].Lemma.Value, ans[1].Lemma.Value})
+
+	variants, err := db.GetLemmaIDsIgnoringDiacritics("skol", true)
+	assert.NoError(t, err)
+	assert.Len(t, variants, 1)
+	assert.Equal(t, "školní", variants[0].Value)
+	variants, err = db.GetLemmaIDsIgnoringDiacritics("skol", false)
+	assert.NoError(t, err)
+	assert.Len(t, variants, 0)
+
+	args = CalculationArgs{
+		Lemma: "hriste", LemmaSet: []string{"hřiste"}, IgnoreDiacritics: true,
+		Limit: 10, SortBy: sortByLogDice,
+	}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 12, ans[0].Freq)
+}
+
+func TestCalculateMeasuresIgnoreCase(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	propn := record.UDPosFromByte(record.PosPROPN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "Praha", PoS: propn, Freq: 40, TextType: tt},
+		"2": {Lemma: "Práha", PoS: propn, Freq: 5, TextType: tt},
+		"3": {Lemma: "praha", PoS: propn, Freq: 3, TextType: tt},
+		"4": {Lemma: "krásný", PoS: adj, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "Praha", PoS1: propn, Lemma2: "krásný", PoS2: adj, Freq: 10, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "Práha", PoS1: propn, Lemma2: "krásný", PoS2: adj, Freq: 2, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "praha", PoS1: propn, Lemma2: "krásný", PoS2: adj, Freq: 1, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{Lemma: "PRAHA", Limit: 10, SortBy: sortByLogDice, IgnoreCase: true}
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrFeatureUnavailable)
+
+	db.Metadata.Features = []DatasetFeature{FeatureFoldedLemmas, FeatureLowerLemmas}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.ElementsMatch(t, []string{"Praha", "praha"}, []string{ans[0].Lemma.Value, ans[1].Lemma.Value})
+
+	args.IgnoreDiacritics = true
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 3)
+
+	variants, err := db.GetLemmaIDsIgnoringCase("KRÁS", true, false)
+	assert.NoError(t, err)
+	assert.Len(t, variants, 1)
+	assert.Equal(t, "krásný", variants[0].Value)
+	variants, err = db.GetLemmaIDsIgnoringCase("kras", true, false)
+	assert.NoError(t, err)
+	assert.Len(t, variants, 0)
+
+	args = CalculationArgs{
+		Lemma: "pr*", LemmaPattern: LemmaPatternGlob, IgnoreCase: true,
+		Limit: 10, SortBy: sortByLogDice,
+	}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 13, ans[0].Freq) // incl. Práha
+
+	args = CalculationArgs{
+		Lemma: "praha", LemmaSet: []string{"PRAHA"}, IgnoreCase: true,
+		Limit: 10, SortBy: sortByLogDice,
+	}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 11, ans[0].Freq)
+}
+
+func TestCalculateMeasuresCorpusSizeOverride(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLMI, CorpusSize: 500}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, int64(500), ans[0].CorpusSize)
+	assert.InDelta(t, 6*math.Log2(500*6.0/(20*50)), ans[0].LMI, 0.0001)
+
+	args.CorpusSize = 40
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrInvalidCorpusSize)
+
+	args.CorpusSize = -1
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrInvalidCorpusSize)
+}
+
+func TestGetDeprelStats(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	verb := record.UDPosFromByte(record.PosVERB)
+	amod := record.ImportUDDeprel("amod")
+	nsubj := record.ImportUDDeprel("nsubj")
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: fiction},
+		"2": {Lemma: "big", PoS: adj, Freq: 10, TextType: fiction},
+		"3": {Lemma: "small", PoS: adj, Freq: 10, TextType: fiction},
+		"4": {Lemma: "bark", PoS: verb, Freq: 10, TextType: fiction},
+	}
+	// as in imported data, each pair is stored from the perspective of both tokens
+	pairFreqs := make(map[record.GroupingKey]record.CollocFreq)
+	for i, pair := range []record.CollocFreq{
+		{Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Deprel: amod, Freq: 6, AVGDist: 1, TextType: fiction},
+		{Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Deprel: amod, Freq: 2, AVGDist: 1, TextType: news},
+		{Lemma1: "dog", PoS1: noun, Lemma2: "small", PoS2: adj, Deprel: amod, Freq: 4, AVGDist: 2, TextType: fiction},
+		{Lemma1: "bark", PoS1: verb, Lemma2: "dog", PoS2: noun, Deprel: nsubj, Freq: 3, AVGDist: 1, TextType: fiction},
+	} {
+		pair.Direction = record.DirectionHead
+		pairFreqs[record.GroupingKey(fmt.Sprintf("%d", i))] = pair
+		rev := pair
+		rev.Lemma1, rev.PoS1, rev.Lemma2, rev.PoS2 = pair.Lemma2, pair.PoS2, pair.Lemma1, pair.PoS1
+		rev.Direction = record.DirectionDependent
+		pairFreqs[record.GroupingKey(fmt.Sprintf("%d-rev", i))] = rev
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.GetDeprelStats(context.Background(), 1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, "amod", ans[0].Deprel)
+	assert.Equal(t, 3, ans[0].NumPairs)
+	assert.Equal(t, 12, ans[0].Freq)
+	assert.InDelta(t, (6.0+2+8)/12, float64(ans[0].AVGDist), 0.0001)
+	assert.Equal(t, []DeprelExamplePair{{Head: "dog", Dependent: "big", Freq: 8}}, ans[0].Examples)
+	assert.Equal(t, "nsubj", ans[1].Deprel)
+	assert.Equal(t, 1, ans[1].NumPairs)
+	assert.Equal(t, 3, ans[1].Freq)
+	assert.Equal(t, []DeprelExamplePair{{Head: "bark", Dependent: "dog", Freq: 3}}, ans[1].Examples)
+
+	ans, err = db.GetDeprelStats(context.Background(), 2, []string{"news"})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, ans[0].Freq)
+	assert.Len(t, ans[0].Examples, 2)
+}
+
+func TestGetDeprelStatsExamplesSplitOverTextTypes(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amod := record.ImportUDDeprel("amod")
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: fiction},
+		"2": {Lemma: "big", PoS: adj, Freq: 10, TextType: fiction},
+		"3": {Lemma: "small", PoS: adj, Freq: 10, TextType: fiction},
+	}
+	// in the key order, (dog, small) is stored between the two
+	// text type records of (dog, big)
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Deprel: amod, Freq: 4, AVGDist: 1,
+			TextType: fiction, Direction: record.DirectionHead},
+		"2": {Lemma1: "dog", PoS1: noun, Lemma2: "small", PoS2: adj, Deprel: amod, Freq: 5, AVGDist: 1,
+			TextType: fiction, Direction: record.DirectionHead},
+		"3": {Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Deprel: amod, Freq: 4, AVGDist: 1,
+			TextType: news, Direction: record.DirectionHead},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.GetDeprelStats(context.Background(), 1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, []DeprelExamplePair{{Head: "dog", Dependent: "big", Freq: 8}}, ans[0].Examples)
+
+	ans, err = db.GetDeprelStats(context.Background(), 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]DeprelExamplePair{
+			{Head: "dog", Dependent: "big", Freq: 8},
+			{Head: "dog", Dependent: "small", Freq: 5},
+		},
+		ans[0].Examples,
+	)
+}
+
+func TestMergeDeprelStats(t *testing.T) {
+	part1 := []DeprelStats{
+		{Deprel: "amod", NumPairs: 2, Freq: 10, AVGDist: 1, Examples: []DeprelExamplePair{
+			{Head: "dog", Dependent: "big", Freq: 6},
+			{Head: "cat", Dependent: "small", Freq: 4},
+		}},
+	}
+	part2 := []DeprelStats{
+		{Deprel: "nsubj", NumPairs: 1, Freq: 3, AVGDist: 2},
+		{Deprel: "amod", NumPairs: 3, Freq: 30, AVGDist: 2, Examples: []DeprelExamplePair{
+			{Head: "cat", Dependent: "small", Freq: 5},
+		}},
+	}
+	ans := MergeDeprelStats(1, part1, part2)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, "amod", ans[0].Deprel)
+	assert.Equal(t, 5, ans[0].NumPairs)
+	assert.Equal(t, 40, ans[0].Freq)
+	assert.InDelta(t, (10.0+60)/40, float64(ans[0].AVGDist), 0.0001)
+	assert.Equal(t, []DeprelExamplePair{{Head: "cat", Dependent: "small", Freq: 9}}, ans[0].Examples)
+	assert.Equal(t, "nsubj", ans[1].Deprel)
+	assert.Equal(t, []DeprelExamplePair{}, ans[1].Examples)
+}
+
+func TestCalculateMeasuresDirection(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "bark", PoS: verb, Freq: 10, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {
+			Lemma1: "dog", PoS1: noun, Lemma2: "bark", PoS2: verb, Freq: 6, AVGDist: 1.5,
+			TextType: tt, Direction: record.DirectionDependent,
+		},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.False(t, ans[0].IsHead)
+	assert.InDelta(t, 1.5, ans[0].MutualDist, 0.1)
+
+	args.SignedDistance = true
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.InDelta(t, -1.5, ans[0].MutualDist, 0.1)
+}
+
+func TestCalculateMeasuresCollocateOrder(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "big", PoS: adj, Freq: 10, TextType: tt},
+		"3": {Lemma: "hungry", PoS: adj, Freq: 10, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Freq: 6, AVGDist: 1, AVGSurfaceDist: -1, TextType: tt},
+		"2": {Lemma1: "dog", PoS1: noun, Lemma2: "hungry", PoS2: adj, Freq: 3, AVGDist: 1, AVGSurfaceDist: 3, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice, CollocateOrder: CollocateBefore}
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrSurfaceDistUnavailable)
+
+	db.Metadata.SurfaceDist = true
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "big", ans[0].Collocate.Value)
+	assert.InDelta(t, -1.0, ans[0].SurfaceDist, 0.0001)
+
+	args.CollocateOrder = CollocateAfter
+	args.MaxAvgSurfaceDist = 2
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+}
+
+func TestCalculateMeasuresTokenFreqRollups(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: fiction},
+		"2": {Lemma: "monday", PoS: noun, Freq: 15, TextType: news},
+		"3": {Lemma: "work", PoS: verb, Freq: 50, TextType: fiction},
+		"4": {Lemma: "work", PoS: verb, Freq: 30, TextType: news},
+		"5": {Lemma: "work", PoS: noun, Freq: 10, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 4, AVGDist: 1, TextType: news},
+	}
+	stats, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.NumLemmaRollups)
+
+	args := CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLogDice}
+	expected, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, expected, 1)
+
+	db.Metadata.TokenFreqRollups = true
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, ans)
+	// F(x,y) = 10, F(x) = 35, F(y) = 80 (verb only)
+	assert.InDelta(t, 14.0+math.Log2(2*10.0/(35+80)), ans[0].LogDice, 0.0001)
+
+	args.TextType = "news"
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.InDelta(t, 14.0+math.Log2(2*4.0/(15+30)), ans[0].LogDice, 0.0001)
+}
+
+func TestCalculateMeasuresHotLemmaSummaries(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: fiction},
+		"3": {Lemma: "work", PoS: verb, Freq: 30, TextType: news},
+		"4": {Lemma: "busy", PoS: adj, Freq: 30, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, AVGSurfaceDist: -1, TextType: fiction},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 2, AVGDist: 2, AVGSurfaceDist: -3, TextType: news},
+		"3": {Lemma1: "monday", PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 4, AVGDist: 1, AVGSurfaceDist: 1, TextType: news},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	numHot, err := db.StoreHotLemmaSummaries(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, numHot)
+	numHot, err = db.StoreHotLemmaSummaries(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, numHot)
+
+	args := CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLogDice}
+	expected, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, expected, 2)
+
+	db.Metadata.HotLemmaThreshold = 2
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	for i := range ans {
+		assert.Equal(t, expected[i].Collocate, ans[i].Collocate)
+		assert.Equal(t, expected[i].LogDice, ans[i].LogDice)
+		// summaries store distances with the precision of the value encoding
+		assert.InDelta(t, expected[i].MutualDist, ans[i].MutualDist, 0.05+1e-9)
+	}
+	assert.Equal(t, "work", ans[0].Collocate.Value)
+	assert.InDelta(t, -1.5, ans[0].SurfaceDist, 0.001)
+
+	// text type specific queries must still use the raw records
+	args.TextType = "news"
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, "busy", ans[0].Collocate.Value)
+}
+
+func TestCalculateMeasuresFields(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{
+		Lemma:  "monday",
+		Limit:  10,
+		SortBy: sortByLMI,
+		Fields: []ResultField{FieldLogDice},
+	}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.NotZero(t, ans[0].LogDice)
+	assert.NotZero(t, ans[0].LMI) // required by sorting
+	assert.Zero(t, ans[0].LogLikelihood)
+	assert.Zero(t, ans[0].TScore)
+
+	out, err := json.Marshal(ans[0])
+	assert.NoError(t, err)
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Contains(t, decoded, "logDice")
+	assert.NotContains(t, decoded, "lmi")
+	assert.NotContains(t, decoded, "textType")
+	assert.Contains(t, decoded, "collocate")
+
+	var col Collocation
+	assert.NoError(t, json.Unmarshal(out, &col))
+	assert.Equal(t, []ResultField{FieldLogDice}, col.Fields)
+
+	compact := NewCompactCollocations(ans)
+	assert.Equal(
+		t,
+		[]string{"@lemma", "@lemmaPos", "@collocate", "@collocatePos", "@deprel", "isHead", "logDice"},
+		compact.Fields,
+	)
+	assert.Len(t, compact.Rows[0], 7)
+
+	args.Fields = []ResultField{"foo"}
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrInvalidResultField)
+}
+
+func TestCalculateMeasuresAssociationMeasures(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+		"3": {Lemma: "rest", PoS: verb, Freq: 4, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 10, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "rest", PoS2: verb, Freq: 2, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{
+		Lemma:  "monday",
+		Limit:  10,
+		SortBy: sortByMI,
+		Fields: []ResultField{FieldMI, FieldMI3, FieldDice, FieldMinSensitivity},
+	}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	// MI prefers the rare "rest" (2*1000/(20*4) = 25 vs. 10*1000/(20*50) = 10)
+	assert.Equal(t, "rest", ans[0].Collocate.Value)
+	assert.InDelta(t, math.Log2(25), ans[0].MI, 0.0001)
+	assert.InDelta(t, math.Log2(8*1000.0/(20*4)), ans[0].MI3, 0.0001)
+	assert.InDelta(t, 2*2.0/24, ans[0].Dice, 0.0001)
+	assert.InDelta(t, 2.0/20, ans[0].MinSensitivity, 0.0001)
+	assert.Zero(t, ans[0].LogDice)
+
+	// MI3 favours frequent co-occurrences
+	args.SortBy = sortByMI3
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Equal(t, "work", ans[0].Collocate.Value)
+	assert.InDelta(t, math.Log2(1000*1000.0/(20*50)), ans[0].MI3, 0.0001)
+	assert.InDelta(t, 2*10.0/70, ans[0].Dice, 0.0001)
+	assert.InDelta(t, 10.0/50, ans[0].MinSensitivity, 0.0001)
+
+	args.SortBy = sortByMinSens
+	args.Fields = []ResultField{FieldLogDice}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Equal(t, "work", ans[0].Collocate.Value)
+	assert.NotZero(t, ans[0].MinSensitivity) // required by sorting
+	assert.Zero(t, ans[0].MI)
+}
+
+func TestCalculateMeasuresDeltaP(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+		"3": {Lemma: "rest", PoS: verb, Freq: 4, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 10, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "rest", PoS2: verb, Freq: 2, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{
+		Lemma:  "monday",
+		Limit:  10,
+		SortBy: sortByDeltaPColl,
+		Fields: []ResultField{FieldDeltaPCollocate, FieldDeltaPNode},
+	}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	// "monday" makes "work" more likely (10/20 - 40/980) than "rest" (2/20 - 2/980)
+	assert.Equal(t, "work", ans[0].Collocate.Value)
+	assert.InDelta(t, 10.0/20-40.0/980, ans[0].DeltaPCollocate, 0.0001)
+	assert.InDelta(t, 10.0/50-10.0/950, ans[0].DeltaPNode, 0.0001)
+	assert.InDelta(t, 2.0/20-2.0/980, ans[1].DeltaPCollocate, 0.0001)
+	assert.Zero(t, ans[0].LogDice)
+
+	// ...but "rest" predicts "monday" better (2/4 - 18/996) than "work" (10/50 - 10/950)
+	args.SortBy = sortByDeltaPNode
+	args.Fields = []ResultField{FieldLogDice}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Equal(t, "rest", ans[0].Collocate.Value)
+	assert.InDelta(t, 2.0/4-18.0/996, ans[0].DeltaPNode, 0.0001) // required by sorting
+	assert.Zero(t, ans[0].DeltaPCollocate)
+}
+
+func TestCalculateMeasuresNormalizedFreqs(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 4000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:  "monday",
+		Limit:  10,
+		SortBy: sortByLogDice,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 6, ans[0].Freq)
+	assert.Equal(t, 20, ans[0].LemmaFreq)
+	assert.Equal(t, 50, ans[0].CollocateFreq)
+	assert.InDelta(t, 1500.0, ans[0].FreqIPM, 0.0001)
+	assert.InDelta(t, 5000.0, ans[0].LemmaFreqIPM, 0.0001)
+	assert.InDelta(t, 12500.0, ans[0].CollocateFreqIPM, 0.0001)
+
+	out, err := json.Marshal(ans[0])
+	assert.NoError(t, err)
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, 6.0, decoded["freq"])
+	assert.Equal(t, 20.0, decoded["lemmaFreq"])
+	assert.Equal(t, 50.0, decoded["collocateFreq"])
+	assert.Equal(t, 1500.0, decoded["freqIpm"])
+
+	var col Collocation
+	assert.NoError(t, json.Unmarshal(out, &col))
+	assert.Nil(t, col.Fields)
+	assert.Equal(t, 50, col.CollocateFreq)
+	assert.InDelta(t, 12500.0, col.CollocateFreqIPM, 0.0001)
+
+	ans[0].Fields = []ResultField{FieldLogDice, FieldFreqIPM}
+	out, err = json.Marshal(ans[0])
+	assert.NoError(t, err)
+	decoded = nil
+	assert.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Contains(t, decoded, "freqIpm")
+	assert.NotContains(t, decoded, "freq")
+	assert.NotContains(t, decoded, "lemmaFreqIpm")
+}
+
+func TestCalculateMeasuresFilterStats(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.Metadata.MinPairFreq = 3
+	db.Metadata.SurfaceDist = true
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: fiction},
+		"3": {Lemma: "busy", PoS: adj, Freq: 20, TextType: news},
+		"4": {Lemma: "rainy", PoS: adj, Freq: 20, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, AVGSurfaceDist: 1, TextType: fiction},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 4, AVGDist: 1, AVGSurfaceDist: -1, TextType: news},
+		"3": {Lemma1: "monday", PoS1: noun, Lemma2: "rainy", PoS2: adj, Freq: 4, AVGDist: 3, AVGSurfaceDist: -1, TextType: fiction},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	var stats FilterStats
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:               "monday",
+		TextType:            "fiction",
+		Limit:               10,
+		SortBy:              sortByLogDice,
+		MaxAvgCollocateDist: 2,
+		FilterStats:         &stats,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(
+		t,
+		FilterStats{
+			NumScanned:    3,
+			TextType:      1,
+			MaxAvgDist:    1,
+			NumAccepted:   1,
+			NumCandidates: 1,
+			ImportMinFreq: 3,
+		},
+		stats,
+	)
+
+	_, err = db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:          "monday",
+		Limit:          1,
+		SortBy:         sortByLogDice,
+		CollocateOrder: CollocateBefore,
+		FilterStats:    &stats,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.CollocateOrder)
+	assert.Equal(t, 2, stats.NumCandidates)
+	assert.Equal(t, 1, stats.CutByLimit)
+	assert.False(t, stats.ScanBudgetExhausted)
+
+	ans, err = db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:           "monday",
+		Limit:           10,
+		SortBy:          sortByLogDice,
+		MaxScannedPairs: 2,
+		FilterStats:     &stats,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, 2, stats.NumScanned)
+	assert.True(t, stats.ScanBudgetExhausted)
+}
+
+func TestCalculateMeasuresMinCollFreq(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: tt},
+		"3": {Lemma: "rest", PoS: verb, Freq: 1, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "rest", PoS2: verb, Freq: 1, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{
+		Lemma:  "monday",
+		Limit:  10,
+		SortBy: sortByMI,
+	}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, "rest", ans[0].Collocate.Value) // the hapax wins by MI
+
+	var stats FilterStats
+	args.MinCollFreq = 2
+	args.FilterStats = &stats
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "work", ans[0].Collocate.Value)
+	assert.Equal(t, 1, stats.MinCollFreq)
+	assert.Equal(t, 1, stats.NumCandidates)
+}
+
+func TestCalculateMeasuresCoreDeprelGranularity(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "stay", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("obl"), Lemma2: "stay", PoS2: verb,
+			Freq: 6, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("obl:arg"), Lemma2: "stay", PoS2: verb,
+			Freq: 4, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	args := CalculationArgs{Lemma: "house", Limit: 10, SortBy: sortByLogDice, GroupByDeprel: true}
+	ans, err := db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+
+	args.DeprelGranularity = DeprelGranularityCore
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "obl", ans[0].Deprel)
+	assert.InDelta(t, 14.0+math.Log2(2*10.0/(20+50)), ans[0].LogDice, 0.0001)
+}
+
+func TestCalculateMeasuresDeprels(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 20, TextType: fiction},
+		"2": {Lemma: "house", PoS: noun, Freq: 10, TextType: news},
+		"3": {Lemma: "house", PoS: verb, Freq: 5, TextType: fiction},
+		"4": {Lemma: "big", PoS: adj, Freq: 30, TextType: fiction},
+		"5": {Lemma: "stay", PoS: verb, Freq: 50, TextType: fiction},
+		"6": {Lemma: "people", PoS: noun, Freq: 40, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("amod"), Lemma2: "big", PoS2: adj,
+			Freq: 6, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("amod"), Lemma2: "big", PoS2: adj,
+			Freq: 3, AVGDist: 1, TextType: news},
+		"3": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("obl"), Lemma2: "stay", PoS2: verb,
+			Freq: 4, AVGDist: -1, TextType: fiction},
+		"4": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("obl:arg"), Lemma2: "stay", PoS2: verb,
+			Freq: 2, AVGDist: -1, TextType: news},
+		"5": {Lemma1: "house", PoS1: verb, Deprel: record.ImportUDDeprel("obj"), Lemma2: "people", PoS2: noun,
+			Freq: 3, AVGDist: 1, TextType: fiction},
+		"6": {Lemma1: "house", PoS1: verb, Deprel: record.ImportUDDeprel("amod"), Lemma2: "big", PoS2: adj,
+			Freq: 1, AVGDist: 1, TextType: fiction},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	var stats FilterStats
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:       "house",
+		Limit:       10,
+		SortBy:      sortByLogDice,
+		Deprels:     []string{"amod"},
+		FilterStats: &stats,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "big", ans[0].Collocate.Value)
+	assert.Equal(t, 10, ans[0].Freq)
+	// records of other relations are not examined at all
+	assert.Equal(t, 3, stats.NumScanned)
+
+	ans, err = db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:   "house",
+		Limit:   10,
+		SortBy:  sortByLogDice,
+		Deprels: []string{"obj", "obl"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+
+	ans, err = db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:             "house",
+		Limit:             10,
+		SortBy:            sortByLogDice,
+		Deprels:           []string{"obl"},
+		DeprelGranularity: DeprelGranularityCore,
+		FilterStats:       &stats,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 6, ans[0].Freq)
+	assert.Equal(t, 2, stats.NumScanned)
+
+	_, err = db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:   "house",
+		Limit:   10,
+		SortBy:  sortByLogDice,
+		Deprels: []string{"foo"},
+	})
+	assert.ErrorIs(t, err, ErrUnknownFilterValue)
+}
+
+func TestCalculateMeasuresPathCollocations(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	verb := record.UDPosFromByte(record.PosVERB)
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "read", PoS: verb, Freq: 50, TextType: tt},
+		"2": {Lemma: "book", PoS: noun, Freq: 40, TextType: tt},
+		"3": {Lemma: "interesting", PoS: adj, Freq: 20, TextType: tt},
+		"4": {Lemma: "old", PoS: adj, Freq: 30, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "read", PoS1: verb, Deprel: record.ImportUDDeprel("obj"), Lemma2: "book", PoS2: noun,
+			Freq: 10, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "book", PoS1: noun, Deprel: record.ImportUDDeprel("amod"), Lemma2: "interesting", PoS2: adj,
+			Freq: 6, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	objAmodCode, err := record.UDDeprelMapping.GetOrRegister("obj→amod")
+	assert.NoError(t, err)
+	oblAmodCode, err := record.UDDeprelMapping.GetOrRegister("obl→amod")
+	assert.NoError(t, err)
+	objAmod := record.UDDeprel{Raw: objAmodCode, Readable: "obj→amod"}
+	oblAmod := record.UDDeprel{Raw: oblAmodCode, Readable: "obl→amod"}
+	pathFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "read", PoS1: verb, Deprel: objAmod, Lemma2: "interesting", PoS2: adj,
+			Freq: 5, AVGDist: 2, TextType: tt},
+		"2": {Lemma1: "read", PoS1: verb, Deprel: oblAmod, Lemma2: "old", PoS2: adj,
+			Freq: 3, AVGDist: 2, TextType: tt},
+	}
+	stats, err := db.StorePathFreqs(pathFreqs, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.NumPathCollFreqs)
+	assert.Equal(t, 0, stats.NumCollFreqs)
+
+	// path collocations do not affect regular searches
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma: "read", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "book", ans[0].Collocate.Value)
+
+	args := CalculationArgs{
+		Lemma:            "read",
+		Limit:            10,
+		SortBy:           sortByLogDice,
+		Deprels:          []string{"obj→amod"},
+		GroupByDeprel:    true,
+		PathCollocations: true,
+	}
+	_, err = db.CalculateMeasures(context.Background(), args)
+	assert.ErrorIs(t, err, ErrFeatureUnavailable)
+
+	db.Metadata.Features = []DatasetFeature{FeaturePathCollocations}
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "interesting", ans[0].Collocate.Value)
+	assert.Equal(t, "obj→amod", ans[0].Deprel)
+	assert.Equal(t, 5, ans[0].Freq)
+
+	args.Deprels = nil
+	ans, err = db.CalculateMeasures(context.Background(), args)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+}
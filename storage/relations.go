@@ -0,0 +1,135 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/record"
+)
+
+// ErrInvalidRelationDef is returned for relation definitions
+// with a missing or duplicate name or an unknown node side
+var ErrInvalidRelationDef = errors.New("invalid relation definition")
+
+// node sides of a searched lemma in RelationDef
+const (
+	RelationNodeAny       = ""
+	RelationNodeHead      = "head"
+	RelationNodeDependent = "dependent"
+)
+
+// RelationDef defines a named (sketch grammar style) relation,
+// e.g. "verbs-object", as a filter of collocations of a searched lemma.
+// Empty lists mean "no constraint".
+type RelationDef struct {
+	Name string `json:"name"`
+
+	// NodeSide is a position of the searched lemma in a head-dependent
+	// pair - "head", "dependent" or empty for any position
+	NodeSide string `json:"nodeSide,omitempty"`
+
+	// Deprels lists accepted relation names (e.g. "obl:arg")
+	Deprels []string `json:"deprels,omitempty"`
+
+	// NodePoS lists accepted UD PoS tags of the searched lemma
+	NodePoS []string `json:"nodePos,omitempty"`
+
+	// CollocatePoS lists accepted UD PoS tags of the collocate
+	CollocatePoS []string `json:"collocatePos,omitempty"`
+}
+
+// IsHead converts the node side into the form used by CalculationArgs.IsHead
+func (def RelationDef) IsHead() *bool {
+	if def.NodeSide == RelationNodeAny {
+		return nil
+	}
+	isHead := def.NodeSide == RelationNodeHead
+	return &isHead
+}
+
+// Filter creates a search filter matching the definition.
+// Relation names are resolved via the provided mapping.
+func (def RelationDef) Filter(mapping *record.DeprelMapping) (SearchFilter, error) {
+	filters := make([]SearchFilter, 0, 4)
+	switch def.NodeSide {
+	case RelationNodeAny:
+	case RelationNodeHead, RelationNodeDependent:
+		filters = append(filters, NodeAsHead(def.NodeSide == RelationNodeHead))
+	default:
+		return nil, fmt.Errorf("%w: %s: unknown node side %s", ErrInvalidRelationDef, def.Name, def.NodeSide)
+	}
+	if len(def.Deprels) > 0 {
+		f, err := DeprelNamesIn(mapping, def.Deprels...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create filter of relation %s: %w", def.Name, err)
+		}
+		filters = append(filters, f)
+	}
+	if len(def.NodePoS) > 0 {
+		f, err := NodePoSNamesIn(def.NodePoS...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create filter of relation %s: %w", def.Name, err)
+		}
+		filters = append(filters, f)
+	}
+	if len(def.CollocatePoS) > 0 {
+		f, err := CollocatePoSNamesIn(def.CollocatePoS...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create filter of relation %s: %w", def.Name, err)
+		}
+		filters = append(filters, f)
+	}
+	return And(filters...), nil
+}
+
+// ValidateRelationDefs tests whether all the definitions have unique
+// names and whether filters can be created from them (i.e. all the
+// relations and PoS tags are known).
+func ValidateRelationDefs(defs []RelationDef, mapping *record.DeprelMapping) error {
+	names := make(map[string]bool)
+	for _, def := range defs {
+		if def.Name == "" {
+			return fmt.Errorf("%w: missing name", ErrInvalidRelationDef)
+		}
+		if names[def.Name] {
+			return fmt.Errorf("%w: duplicate name %s", ErrInvalidRelationDef, def.Name)
+		}
+		names[def.Name] = true
+		if _, err := def.Filter(mapping); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadRelationDefs loads a JSON list of relation definitions
+// (see RelationDef) from a file
+func LoadRelationDefs(path string) ([]RelationDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load relation definitions: %w", err)
+	}
+	ans := []RelationDef{}
+	if err := json.Unmarshal(data, &ans); err != nil {
+		return nil, fmt.Errorf("failed to load relation definitions: %w", err)
+	}
+	return ans, nil
+}
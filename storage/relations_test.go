@@ -0,0 +1,82 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelationDefFilter(t *testing.T) {
+	def := RelationDef{
+		Name:         "verbs-object",
+		NodeSide:     RelationNodeDependent,
+		Deprels:      []string{"obj", "iobj"},
+		CollocatePoS: []string{"VERB"},
+	}
+	filter, err := def.Filter(&record.UDDeprelMapping)
+	assert.NoError(t, err)
+	assert.True(t, filter(record.PosNOUN, record.DeprelObj, record.PosVERB, 0x01, false, 1))
+	assert.True(t, filter(record.PosNOUN, record.DeprelIobj, record.PosVERB, 0x01, false, 1))
+	assert.False(t, filter(record.PosNOUN, record.DeprelObj, record.PosVERB, 0x01, true, 1))
+	assert.False(t, filter(record.PosNOUN, record.DeprelObj, record.PosNOUN, 0x01, false, 1))
+	assert.False(t, filter(record.PosNOUN, record.DeprelNsubj, record.PosVERB, 0x01, false, 1))
+	assert.False(t, *def.IsHead())
+	assert.Nil(t, RelationDef{Name: "any"}.IsHead())
+}
+
+func TestValidateRelationDefs(t *testing.T) {
+	mapping := &record.UDDeprelMapping
+	assert.NoError(t, ValidateRelationDefs(nil, mapping))
+	assert.NoError(t, ValidateRelationDefs([]RelationDef{{Name: "a", Deprels: []string{"amod"}}}, mapping))
+	err := ValidateRelationDefs([]RelationDef{{Deprels: []string{"amod"}}}, mapping)
+	assert.ErrorIs(t, err, ErrInvalidRelationDef)
+	err = ValidateRelationDefs([]RelationDef{{Name: "a"}, {Name: "a"}}, mapping)
+	assert.ErrorIs(t, err, ErrInvalidRelationDef)
+	err = ValidateRelationDefs([]RelationDef{{Name: "a", NodeSide: "left"}}, mapping)
+	assert.ErrorIs(t, err, ErrInvalidRelationDef)
+	err = ValidateRelationDefs([]RelationDef{{Name: "a", Deprels: []string{"foo"}}}, mapping)
+	assert.ErrorIs(t, err, ErrUnknownFilterValue)
+	err = ValidateRelationDefs([]RelationDef{{Name: "a", CollocatePoS: []string{"FOO"}}}, mapping)
+	assert.ErrorIs(t, err, ErrUnknownFilterValue)
+}
+
+func TestLoadRelationDefs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relations.json")
+	err := os.WriteFile(
+		path,
+		[]byte(`[{"name": "adj-modifiers", "nodeSide": "head", "deprels": ["amod"], "collocatePos": ["ADJ"]}]`),
+		0644,
+	)
+	assert.NoError(t, err)
+	defs, err := LoadRelationDefs(path)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]RelationDef{{
+			Name: "adj-modifiers", NodeSide: RelationNodeHead,
+			Deprels: []string{"amod"}, CollocatePoS: []string{"ADJ"},
+		}},
+		defs,
+	)
+	_, err = LoadRelationDefs(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"math"
+
+	"github.com/czcorpus/depreldb/record"
+)
+
+// RelationDistStats describes typical (absolute) syntactic distances
+// of a single relation. The values are calculated at import time
+// from the stored pairs so the spread describes variability of
+// average distances of individual pairs (weighted by their frequencies).
+type RelationDistStats struct {
+	Freq    int     `json:"freq"`
+	AVGDist float64 `json:"avgDist"`
+	StdDev  float64 `json:"stdDev"`
+}
+
+// MaxDist returns a distance limit considered typical for
+// the relation - i.e. the average plus the spread multiplied
+// by the provided factor.
+func (rds RelationDistStats) MaxDist(spread float64) float64 {
+	return rds.AVGDist + spread*rds.StdDev
+}
+
+type relationDistAcc struct {
+	freq   float64
+	sum    float64
+	sumSqr float64
+}
+
+// relationDistAccumulator collects per-relation distance statistics
+// from pairs stored during import.
+type relationDistAccumulator map[string]*relationDistAcc
+
+func (rda relationDistAccumulator) add(pair record.CollocFreq) {
+	rda.addDist(record.UDDeprelMapping.GetRev(pair.Deprel.AsUint16()), pair.Freq, pair.AVGDist)
+}
+
+// addDist adds a pair of the relation with the frequency
+// and the average distance
+func (rda relationDistAccumulator) addDist(deprel string, freq int, avgDist float64) {
+	acc, ok := rda[deprel]
+	if !ok {
+		acc = &relationDistAcc{}
+		rda[deprel] = acc
+	}
+	dist := math.Abs(avgDist)
+	acc.freq += float64(freq)
+	acc.sum += float64(freq) * dist
+	acc.sumSqr += float64(freq) * dist * dist
+}
+
+func (rda relationDistAccumulator) result() map[string]RelationDistStats {
+	ans := make(map[string]RelationDistStats, len(rda))
+	for deprel, acc := range rda {
+		if acc.freq == 0 {
+			continue
+		}
+		mean := acc.sum / acc.freq
+		ans[deprel] = RelationDistStats{
+			Freq:    int(acc.freq),
+			AVGDist: mean,
+			StdDev:  math.Sqrt(max(acc.sumSqr/acc.freq-mean*mean, 0)),
+		}
+	}
+	return ans
+}
+
+// RelationDistLimits returns per-relation limits of average collocate
+// distance based on relation statistics stored during import
+// (see RelationDistStats.MaxDist). The returned map is keyed by
+// internal deprel values. For databases without the statistics,
+// nil is returned.
+func (db *DB) RelationDistLimits(spread float64) map[uint16]float64 {
+	if !db.Metadata.HasFeature(FeatureRelationDists) {
+		return nil
+	}
+	mapping := db.DeprelMapping
+	if mapping == nil {
+		mapping = &record.UDDeprelMapping
+	}
+	ans := make(map[uint16]float64, len(db.Metadata.RelationDists))
+	for deprel, stats := range db.Metadata.RelationDists {
+		if v, ok := mapping.Get(deprel); ok {
+			ans[v] = stats.MaxDist(spread)
+		}
+	}
+	return ans
+}
+
+// MergeRelationDists combines relation distance statistics calculated
+// from two disjoint sets of pairs (e.g. from separate imports of
+// a corpus) as if they were calculated from both the sets at once.
+func MergeRelationDists(a, b map[string]RelationDistStats) map[string]RelationDistStats {
+	acc := make(relationDistAccumulator)
+	for _, src := range []map[string]RelationDistStats{a, b} {
+		for deprel, stats := range src {
+			acc.addStats(deprel, stats)
+		}
+	}
+	return acc.result()
+}
+
+// addStats adds already calculated statistics of the relation
+func (rda relationDistAccumulator) addStats(deprel string, stats RelationDistStats) {
+	item, ok := rda[deprel]
+	if !ok {
+		item = &relationDistAcc{}
+		rda[deprel] = item
+	}
+	freq := float64(stats.Freq)
+	item.freq += freq
+	item.sum += freq * stats.AVGDist
+	item.sumSqr += freq * (stats.StdDev*stats.StdDev + stats.AVGDist*stats.AVGDist)
+}
+
+// subtractRelationDists removes statistics of pairs collected
+// in removed (e.g. pairs of a deleted text type) from the stats.
+// Relations with no pairs left are removed.
+func subtractRelationDists(stats map[string]RelationDistStats, removed relationDistAccumulator) map[string]RelationDistStats {
+	if len(stats) == 0 {
+		return stats
+	}
+	acc := make(relationDistAccumulator)
+	for deprel, item := range stats {
+		acc.addStats(deprel, item)
+	}
+	for deprel, r := range removed {
+		item, ok := acc[deprel]
+		if !ok {
+			continue
+		}
+		item.freq -= r.freq
+		item.sum -= r.sum
+		item.sumSqr -= r.sumSqr
+		if item.freq <= 0 {
+			delete(acc, deprel)
+		}
+	}
+	return acc.result()
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreDataRelationDists(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amodVal, _ := record.UDDeprelMapping.Get("amod")
+	amod := record.UDDeprelFromUint16(amodVal)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "big", PoS: adj, Freq: 10, TextType: tt},
+		"3": {Lemma: "small", PoS: adj, Freq: 10, TextType: tt},
+		"4": {Lemma: "tiny", PoS: adj, Freq: 10, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj, Freq: 3, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "small", PoS2: adj, Freq: 1, AVGDist: -3, TextType: tt},
+		// below min. pair freq. so it must not affect the statistics
+		"3": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "tiny", PoS2: adj, Freq: 0, AVGDist: 10, TextType: tt},
+	}
+	stats, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	assert.Len(t, stats.RelationDists, 1)
+	amodStats := stats.RelationDists["amod"]
+	assert.Equal(t, 4, amodStats.Freq)
+	// avg = (3 * 1 + 1 * 3) / 4, variance = (3 * 1 + 1 * 9) / 4 - avg^2
+	assert.InDelta(t, 1.5, amodStats.AVGDist, 0.0001)
+	assert.InDelta(t, math.Sqrt(0.75), amodStats.StdDev, 0.0001)
+}
+
+func TestRelationDistLimits(t *testing.T) {
+	db := openTestDB(t)
+	assert.Nil(t, db.RelationDistLimits(1))
+
+	db.Metadata.RelationDists = map[string]RelationDistStats{
+		"amod":    {Freq: 10, AVGDist: 1.2, StdDev: 0.5},
+		"advcl":   {Freq: 5, AVGDist: 4, StdDev: 2},
+		"unknown": {Freq: 5, AVGDist: 1, StdDev: 1},
+	}
+	amod, _ := record.UDDeprelMapping.Get("amod")
+	advcl, _ := record.UDDeprelMapping.Get("advcl")
+	limits := db.RelationDistLimits(2)
+	assert.Len(t, limits, 2)
+	assert.InDelta(t, 2.2, limits[amod], 0.0001)
+	assert.InDelta(t, 8, limits[advcl], 0.0001)
+}
+
+func TestMergeRelationDists(t *testing.T) {
+	a := map[string]RelationDistStats{
+		"amod": {Freq: 3, AVGDist: 1, StdDev: 0},
+	}
+	b := map[string]RelationDistStats{
+		"amod": {Freq: 1, AVGDist: 3, StdDev: 0},
+		"obj":  {Freq: 2, AVGDist: 2, StdDev: 0.5},
+	}
+	ans := MergeRelationDists(a, b)
+	assert.Len(t, ans, 2)
+	// the same values as if calculated from all the pairs at once
+	assert.Equal(t, 4, ans["amod"].Freq)
+	assert.InDelta(t, 1.5, ans["amod"].AVGDist, 0.0001)
+	assert.InDelta(t, math.Sqrt(0.75), ans["amod"].StdDev, 0.0001)
+	assert.Equal(t, b["obj"], ans["obj"])
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrIncompatibleDeprels is returned when two databases use
+// different codes for the same relation
+var ErrIncompatibleDeprels = errors.New("incompatible deprel mappings")
+
+// ErrIncompatibleKeyLayouts is returned when two databases encode
+// token IDs differently (see record.KeyLayout)
+var ErrIncompatibleKeyLayouts = errors.New("incompatible key layouts")
+
+// RemapStats describes a finished token ID remapping
+type RemapStats struct {
+	NumLemmas        int `json:"numLemmas"`
+	NumMatchedLemmas int `json:"numMatchedLemmas"`
+	NumNewLemmas     int `json:"numNewLemmas"`
+	NumRecords       int `json:"numRecords"`
+}
+
+// checkDeprelCompatibility tests whether all the relations of src
+// are encoded the same way in target (or not used in target at all)
+func checkDeprelCompatibility(src, target map[string]uint16) error {
+	targetRev := make(map[uint16]string, len(target))
+	for k, v := range target {
+		targetRev[v] = k
+	}
+	for k, v := range src {
+		if tv, ok := target[k]; ok && tv != v {
+			return fmt.Errorf("%w: %s is encoded as %d and %d", ErrIncompatibleDeprels, k, v, tv)
+		}
+		if tk, ok := targetRev[v]; ok && tk != k {
+			return fmt.Errorf("%w: code %d means %s and %s", ErrIncompatibleDeprels, v, k, tk)
+		}
+	}
+	return nil
+}
+
+// maxTokenIDTx finds the highest lemma token ID used in a database
+// with the key layout
+func maxTokenIDTx(txn *badger.Txn, keys record.KeyLayout) uint64 {
+	var ans uint64
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = record.AllRevIndexKeys()
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		if tokenID := keys.DecodeRevIndexKey(it.Item().Key()); !keys.IsWordFormTokenID(tokenID) {
+			ans = max(ans, tokenID)
+		}
+	}
+	return ans
+}
+
+// buildIDMapping writes (source token ID) -> (target token ID) records
+// to the mapping database. Lemmas unknown to the target get new IDs
+// following the highest target ID. Both the databases must use
+// the same key layout.
+func buildIDMapping(src, target *DB, mapping *badger.DB) (RemapStats, error) {
+	var stats RemapStats
+	keys := target.keys
+	wb := mapping.NewWriteBatch()
+	defer wb.Cancel()
+	err := target.bdb.View(func(targetTxn *badger.Txn) error {
+		nextID := maxTokenIDTx(targetTxn, keys) + 1
+		return src.bdb.View(func(srcTxn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = record.EncodeLemmaPrefixKey("")
+			it := srcTxn.NewIterator(opts)
+			defer it.Close()
+			for it.Rewind(); it.Valid(); it.Next() {
+				srcID, err := decodeItemValue(it.Item(), keys.DecodeTokenID)
+				if err != nil {
+					return err
+				}
+				stats.NumLemmas++
+				var newID uint64
+				targetItem, err := targetTxn.Get(it.Item().Key())
+				if err == badger.ErrKeyNotFound {
+					if nextID > keys.MaxTokenID() {
+						return fmt.Errorf("%w: no free IDs for new lemmas", ErrTokenIDOverflow)
+					}
+					newID = nextID
+					nextID++
+					stats.NumNewLemmas++
+
+				} else if err != nil {
+					return err
+
+				} else {
+					newID, err = decodeItemValue(targetItem, keys.DecodeTokenID)
+					if err != nil {
+						return err
+					}
+					stats.NumMatchedLemmas++
+				}
+				if err := wb.Set(keys.TokenIDToBytes(srcID), keys.TokenIDToBytes(newID)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+	return stats, wb.Flush()
+}
+
+// RemapTokenIDs copies all the records of src to dst with token IDs
+// rewritten according to the lemma index of target. Lemmas not present
+// in target get new IDs not used by target. The resulting database can
+// be merged with target on the key level.
+// The ID mapping is kept in a temporary on-disk database created within
+// tmpDir (empty = system default) so both memory usage and processing
+// are independent of the database sizes (records are streamed).
+func RemapTokenIDs(src, target, dst *DB, tmpDir string) (RemapStats, error) {
+	if err := checkDeprelCompatibility(src.Metadata.DeprelMap, target.Metadata.DeprelMap); err != nil {
+		return RemapStats{}, fmt.Errorf("failed to remap token IDs: %w", err)
+	}
+	if src.Metadata.HasFeature(FeatureWordForms) || target.Metadata.HasFeature(FeatureWordForms) {
+		return RemapStats{}, fmt.Errorf("failed to remap token IDs: %w", ErrWordFormsNotMergeable)
+	}
+	if src.keys != target.keys {
+		return RemapStats{}, fmt.Errorf("failed to remap token IDs: %w", ErrIncompatibleKeyLayouts)
+	}
+	keys := src.keys
+	dst.keys = keys
+	mappingDir, err := os.MkdirTemp(tmpDir, "depreldb-remap-")
+	if err != nil {
+		return RemapStats{}, fmt.Errorf("failed to remap token IDs: %w", err)
+	}
+	defer os.RemoveAll(mappingDir)
+	mapping, err := badger.Open(badger.DefaultOptions(mappingDir).WithLogger(nil))
+	if err != nil {
+		return RemapStats{}, fmt.Errorf("failed to remap token IDs: %w", err)
+	}
+	defer mapping.Close()
+
+	stats, err := buildIDMapping(src, target, mapping)
+	if err != nil {
+		return stats, fmt.Errorf("failed to remap token IDs: %w", err)
+	}
+
+	wb := dst.bdb.NewWriteBatch()
+	defer wb.Cancel()
+	err = mapping.View(func(mappingTxn *badger.Txn) error {
+		remap := func(tokenID uint64) (uint64, error) {
+			item, err := mappingTxn.Get(keys.TokenIDToBytes(tokenID))
+			if err != nil {
+				return 0, fmt.Errorf("failed to find mapping of token ID %d: %w", tokenID, err)
+			}
+			return decodeItemValue(item, keys.DecodeTokenID)
+		}
+		return src.bdb.View(func(srcTxn *badger.Txn) error {
+			it := srcTxn.NewIterator(badger.DefaultIteratorOptions)
+			defer it.Close()
+			for it.Rewind(); it.Valid(); it.Next() {
+				item := it.Item()
+				key, err := keys.RemapKeyTokenIDs(item.Key(), remap)
+				if err != nil {
+					return err
+				}
+				// the write batch keeps the value until flushed so it must be copied
+				value, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				if record.IsLemmaToIDKey(key) {
+					srcID, err := keys.DecodeTokenID(value)
+					if err != nil {
+						return err
+					}
+					newID, err := remap(srcID)
+					if err != nil {
+						return err
+					}
+					value = keys.TokenIDToBytes(newID)
+				}
+				if err := wb.Set(key, value); err != nil {
+					return err
+				}
+				stats.NumRecords++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to remap token IDs: %w", err)
+	}
+	if err := wb.Flush(); err != nil {
+		return stats, fmt.Errorf("failed to remap token IDs: %w", err)
+	}
+	return stats, nil
+}
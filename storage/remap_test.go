@@ -0,0 +1,92 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemapTokenIDs(t *testing.T) {
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+
+	target := openTestDB(t)
+	_, err := target.StoreData(NewTokenIDSequence(), map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 10, TextType: tt},
+		"2": {Lemma: "garden", PoS: noun, Freq: 10, TextType: tt},
+	}, nil, 1)
+	assert.NoError(t, err)
+
+	src := openTestDB(t)
+	_, err = src.StoreData(
+		NewTokenIDSequence(),
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "green", PoS: adj, Freq: 20, TextType: tt},
+			"2": {Lemma: "garden", PoS: noun, Freq: 8, TextType: tt},
+		},
+		map[record.GroupingKey]record.CollocFreq{
+			"1": {Lemma1: "garden", PoS1: noun, Deprel: record.ImportUDDeprel("amod"), Lemma2: "green", PoS2: adj,
+				Freq: 5, AVGDist: 1, TextType: tt, Direction: record.DirectionHead},
+		},
+		1,
+	)
+	assert.NoError(t, err)
+
+	dst := openTestDB(t)
+	stats, err := RemapTokenIDs(src, target, dst, t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.NumLemmas)
+	assert.Equal(t, 1, stats.NumMatchedLemmas)
+	assert.Equal(t, 1, stats.NumNewLemmas)
+	assert.Positive(t, stats.NumRecords)
+
+	targetGardenID, err := target.GetLemmaID(record.TokenFreq{Lemma: "garden"})
+	assert.NoError(t, err)
+	gardenID, err := dst.GetLemmaID(record.TokenFreq{Lemma: "garden"})
+	assert.NoError(t, err)
+	assert.Equal(t, targetGardenID, gardenID)
+	lemma, err := dst.GetLemmaByID(gardenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "garden", lemma)
+
+	greenID, err := dst.GetLemmaID(record.TokenFreq{Lemma: "green"})
+	assert.NoError(t, err)
+	houseID, err := target.GetLemmaID(record.TokenFreq{Lemma: "house"})
+	assert.NoError(t, err)
+	assert.Greater(t, greenID, max(houseID, targetGardenID))
+
+	dst.Metadata.CorpusSize = 1000
+	dst.DeprelMapping = &record.UDDeprelMapping
+	ans, err := dst.CalculateMeasures(context.Background(), CalculationArgs{Lemma: "garden", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "green", ans[0].Collocate.Value)
+}
+
+func TestRemapTokenIDsIncompatibleDeprels(t *testing.T) {
+	src := openTestDB(t)
+	src.Metadata.DeprelMap = map[string]uint16{"obj→amod": 0x0100}
+	target := openTestDB(t)
+	target.Metadata.DeprelMap = map[string]uint16{"nsubj→amod": 0x0100}
+	_, err := RemapTokenIDs(src, target, openTestDB(t), t.TempDir())
+	assert.ErrorIs(t, err, ErrIncompatibleDeprels)
+}
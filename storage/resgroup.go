@@ -24,6 +24,7 @@ type tokenFreqGrouping struct {
 	groupByPos    bool
 	groupByTT     bool
 	groupByDeprel bool
+	groupByFeats  bool
 	data          map[record.BinaryKey]record.RawTokenFreq
 }
 
@@ -45,6 +46,11 @@ func (rg *tokenFreqGrouping) GroupByTT() *tokenFreqGrouping {
 	return rg
 }
 
+func (rg *tokenFreqGrouping) GroupByFeats() *tokenFreqGrouping {
+	rg.groupByFeats = true
+	return rg
+}
+
 func (rg *tokenFreqGrouping) add(f record.RawTokenFreq) {
 	if !rg.groupByTT {
 		f.TextType = 0
@@ -52,6 +58,9 @@ func (rg *tokenFreqGrouping) add(f record.RawTokenFreq) {
 	if !rg.groupByPos {
 		f.PoS = 0
 	}
+	if !rg.groupByFeats {
+		f.Feats = 0
+	}
 	key := f.GroupingKeyBinary()
 	curr, ok := rg.data[key]
 	if !ok {
@@ -80,6 +89,7 @@ type collFreqGrouping struct {
 	groupByDeprel bool
 	groupByPos2   bool
 	groupByTT     bool
+	groupByFeats1 bool
 	data          map[record.CollBinaryKey]record.RawCollocFreq
 }
 
@@ -111,10 +121,18 @@ func (rg *collFreqGrouping) GroupByTT() *collFreqGrouping {
 	return rg
 }
 
+func (rg *collFreqGrouping) GroupByFeats1() *collFreqGrouping {
+	rg.groupByFeats1 = true
+	return rg
+}
+
 func (rg *collFreqGrouping) add(f record.RawCollocFreq) {
 	if !rg.groupByTT {
 		f.TextType = 0
 	}
+	if !rg.groupByFeats1 {
+		f.Feats1 = 0
+	}
 	if !rg.groupByPos1 {
 		f.PoS1 = 0
 	}
@@ -132,11 +150,23 @@ func (rg *collFreqGrouping) add(f record.RawCollocFreq) {
 		curr = f
 
 	} else {
+		// distances are averaged with respect to the frequencies
+		total := float64(curr.Freq + f.Freq)
+		curr.AVGDist = (float64(curr.Freq)*curr.AVGDist + float64(f.Freq)*f.AVGDist) / total
+		curr.AVGSurfaceDist = (float64(curr.Freq)*curr.AVGSurfaceDist + float64(f.Freq)*f.AVGSurfaceDist) / total
 		curr.Freq += f.Freq
 	}
 	rg.data[key] = curr
 }
 
+// merge adds all the records of other grouping (which is expected
+// to be configured the same way)
+func (rg *collFreqGrouping) merge(other *collFreqGrouping) {
+	for _, v := range other.data {
+		rg.add(v)
+	}
+}
+
 func newCollFreqGrouping() *collFreqGrouping {
 	return &collFreqGrouping{
 		data: make(map[record.CollBinaryKey]record.RawCollocFreq),
@@ -0,0 +1,172 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrScanQueueTimeout is returned in case a query waited for a free
+// scan slot longer than configured (see DB.SetScanLimit).
+var ErrScanQueueTimeout = errors.New("timeout while waiting for a free scan slot")
+
+// ScanGateStats provides information about a scan concurrency limiter
+// and about queueing of queries waiting for a free slot.
+// Wait times are in seconds.
+type ScanGateStats struct {
+	MaxConcurrent int     `json:"maxConcurrent"`
+	Running       int     `json:"running"`
+	Waiting       int     `json:"waiting"`
+	NumAcquired   int64   `json:"numAcquired"`
+	NumQueued     int64   `json:"numQueued"`
+	NumTimeouts   int64   `json:"numTimeouts"`
+	TotalWait     float64 `json:"totalWait"`
+	AvgWait       float64 `json:"avgWait"`
+	MaxWait       float64 `json:"maxWait"`
+}
+
+// scanGate limits number of simultaneously running heavy prefix scans.
+// A nil gate means no limit.
+type scanGate struct {
+	slots   chan struct{}
+	maxWait time.Duration
+
+	mu          sync.Mutex
+	waiting     int
+	numAcquired int64
+	numQueued   int64
+	numTimeouts int64
+	totalWait   time.Duration
+	longestWait time.Duration
+}
+
+func newScanGate(maxConcurrent int, maxWait time.Duration) *scanGate {
+	return &scanGate{
+		slots:   make(chan struct{}, maxConcurrent),
+		maxWait: maxWait,
+	}
+}
+
+// acquire waits for a free slot and returns a function releasing the slot.
+// In case the gate has a max. waiting time configured and there is no free
+// slot within the time, ErrScanQueueTimeout is returned. Waiting is
+// also finished once ctx is cancelled (with the context error returned).
+func (g *scanGate) acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return func() {}, err
+	}
+	if g == nil {
+		return func() {}, nil
+	}
+	select {
+	case g.slots <- struct{}{}:
+		g.mu.Lock()
+		g.numAcquired++
+		g.mu.Unlock()
+		return g.release, nil
+	default:
+	}
+
+	g.mu.Lock()
+	g.waiting++
+	g.numQueued++
+	g.mu.Unlock()
+
+	t0 := time.Now()
+	var timeout <-chan time.Time
+	if g.maxWait > 0 {
+		timer := time.NewTimer(g.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case g.slots <- struct{}{}:
+	case <-timeout:
+		err = ErrScanQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	waited := time.Since(t0)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.waiting--
+	g.totalWait += waited
+	if waited > g.longestWait {
+		g.longestWait = waited
+	}
+	if err == ErrScanQueueTimeout {
+		g.numTimeouts++
+	}
+	if err != nil {
+		return func() {}, err
+	}
+	g.numAcquired++
+	return g.release, nil
+}
+
+func (g *scanGate) release() {
+	<-g.slots
+}
+
+func (g *scanGate) stats() ScanGateStats {
+	if g == nil {
+		return ScanGateStats{}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ans := ScanGateStats{
+		MaxConcurrent: cap(g.slots),
+		Running:       len(g.slots),
+		Waiting:       g.waiting,
+		NumAcquired:   g.numAcquired,
+		NumQueued:     g.numQueued,
+		NumTimeouts:   g.numTimeouts,
+		TotalWait:     g.totalWait.Seconds(),
+		MaxWait:       g.longestWait.Seconds(),
+	}
+	if g.numQueued > 0 {
+		ans.AvgWait = ans.TotalWait / float64(g.numQueued)
+	}
+	return ans
+}
+
+// SetScanLimit configures max. number of simultaneously running heavy
+// prefix scans (collocation search, relation statistics). Queries above
+// the limit wait in a queue. If maxWait is greater than zero, queries
+// waiting longer fail with ErrScanQueueTimeout. Zero maxConcurrent
+// disables the limit.
+// The method is expected to be called before the database starts
+// serving queries.
+func (db *DB) SetScanLimit(maxConcurrent int, maxWait time.Duration) {
+	if maxConcurrent <= 0 {
+		db.scanGate = nil
+		return
+	}
+	db.scanGate = newScanGate(maxConcurrent, maxWait)
+}
+
+// ScanGateStats returns current state and queue wait metrics
+// of the scan concurrency limiter. In case no limit is set,
+// zero value is returned.
+func (db *DB) ScanGateStats() ScanGateStats {
+	return db.scanGate.stats()
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanGateNil(t *testing.T) {
+	var g *scanGate
+	release, err := g.acquire(context.Background())
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, ScanGateStats{}, g.stats())
+}
+
+func TestScanGateQueueing(t *testing.T) {
+	g := newScanGate(1, 0)
+	release1, err := g.acquire(context.Background())
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		release2, err := g.acquire(context.Background())
+		assert.NoError(t, err)
+		release2()
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return g.stats().Waiting == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	release1()
+	<-done
+
+	stats := g.stats()
+	assert.Equal(t, 1, stats.MaxConcurrent)
+	assert.Equal(t, 0, stats.Running)
+	assert.Equal(t, 0, stats.Waiting)
+	assert.Equal(t, int64(2), stats.NumAcquired)
+	assert.Equal(t, int64(1), stats.NumQueued)
+	assert.GreaterOrEqual(t, stats.MaxWait, 0.01)
+	assert.Equal(t, stats.TotalWait, stats.AvgWait)
+}
+
+func TestScanGateTimeout(t *testing.T) {
+	g := newScanGate(1, 5*time.Millisecond)
+	release, err := g.acquire(context.Background())
+	assert.NoError(t, err)
+	defer release()
+
+	_, err = g.acquire(context.Background())
+	assert.ErrorIs(t, err, ErrScanQueueTimeout)
+	stats := g.stats()
+	assert.Equal(t, int64(1), stats.NumTimeouts)
+	assert.Equal(t, int64(1), stats.NumAcquired)
+	assert.Equal(t, 1, stats.Running)
+}
+
+func TestCalculateMeasuresScanLimitTimeout(t *testing.T) {
+	db := openTestDB(t)
+	db.SetScanLimit(1, time.Millisecond)
+	release, err := db.scanGate.acquire(context.Background())
+	assert.NoError(t, err)
+	defer release()
+	_, err = db.CalculateMeasures(context.Background(), CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLogDice})
+	assert.ErrorIs(t, err, ErrScanQueueTimeout)
+	assert.Equal(t, int64(1), db.ScanGateStats().NumTimeouts)
+}
+
+func TestScanGateCancelledWhileWaiting(t *testing.T) {
+	g := newScanGate(1, 0)
+	release, err := g.acquire(context.Background())
+	assert.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = g.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	stats := g.stats()
+	assert.Equal(t, int64(0), stats.NumTimeouts)
+	assert.Equal(t, 0, stats.Waiting)
+}
+
+func TestCalculateMeasuresCancelled(t *testing.T) {
+	db := openTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := db.CalculateMeasures(ctx, CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLogDice})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = db.GetDeprelStats(ctx, 1, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// secondOrderArgs derives arguments of a search for collocates
+// of a first level collocate. The search restrictions (filters,
+// text types, relations) are kept while the node-related options
+// and options reporting details of the first level search are reset.
+func secondOrderArgs(args CalculationArgs, item Collocation, limit int) CalculationArgs {
+	ans := args
+	ans.Lemma = item.Collocate.Value
+	ans.PoS = item.Collocate.PoS
+	ans.LemmaSet = nil
+	ans.LemmaPattern = ""
+	ans.LemmaIsPrefix = false
+	ans.MergePrefixVariants = false
+	ans.IgnoreDiacritics = false
+	ans.IgnoreCase = false
+	ans.GroupByFeats = false
+	ans.IsHead = nil
+	ans.LimitPerVariant = false
+	ans.Offset = 0
+	// the first level node is a collocate of each of its collocates,
+	// but we do not want to repeat it
+	ans.Limit = limit + 1
+	ans.TotalCount = nil
+	ans.VariantSummary = nil
+	ans.CategoryLexicon = nil
+	ans.CategoryProfile = nil
+	ans.FilterStats = nil
+	return ans
+}
+
+// CalculateSecondOrder searches for collocations as CalculateMeasures does
+// and for each of the found collocates, it searches for up to limit its
+// own collocates (stored in Collocation.SecondOrder) so a two-level
+// collocation graph (network) can be built. The searched lemma (node)
+// is not repeated among the second order collocates. The second level
+// searches apply the same filters as the first level one.
+//
+// Please note that the method performs a separate search for each
+// first level collocate so the first level limit should be reasonably low.
+func (db *DB) CalculateSecondOrder(ctx context.Context, args CalculationArgs, limit int) ([]Collocation, error) {
+	if limit < 0 {
+		panic("CalculateSecondOrder - invalid limit value")
+	}
+	ans, err := db.CalculateMeasures(ctx, args)
+	if err != nil {
+		return ans, err
+	}
+	for i, item := range ans {
+		ans[i].SecondOrder, err = db.SecondOrderCollocations(ctx, args, item, limit)
+		if err != nil {
+			return []Collocation{}, err
+		}
+	}
+	return ans, nil
+}
+
+// SecondOrderCollocations searches for up to limit collocates of a single
+// first level collocate item found by a search with args. This is what
+// CalculateSecondOrder does for each of the found collocates and it is
+// intended for callers attaching the second order collocates lazily
+// (e.g. when streaming results).
+func (db *DB) SecondOrderCollocations(ctx context.Context, args CalculationArgs, item Collocation, limit int) ([]Collocation, error) {
+	if limit < 0 {
+		panic("SecondOrderCollocations - invalid limit value")
+	}
+	items, err := db.CalculateMeasures(ctx, secondOrderArgs(args, item, limit))
+	if err != nil {
+		return []Collocation{}, fmt.Errorf(
+			"failed to calculate second order collocations of %s: %w", item.Collocate.Value, err)
+	}
+	ans := make([]Collocation, 0, limit)
+	for _, item2 := range items {
+		if item2.Collocate.Value == item.Lemma.Value || len(ans) == limit {
+			continue
+		}
+		ans = append(ans, item2)
+	}
+	return ans, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateSecondOrder(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adv := record.UDPosFromByte(record.PosADV)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "work", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "start", PoS: verb, Freq: 50, TextType: tt},
+		"3": {Lemma: "finish", PoS: verb, Freq: 30, TextType: tt},
+		"4": {Lemma: "quickly", PoS: adv, Freq: 20, TextType: tt},
+		"5": {Lemma: "slowly", PoS: adv, Freq: 10, TextType: tt},
+		"6": {Lemma: "again", PoS: adv, Freq: 10, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "work", PoS1: noun, Lemma2: "start", PoS2: verb, Freq: 20, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "work", PoS1: noun, Lemma2: "finish", PoS2: verb, Freq: 15, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "start", PoS1: verb, Lemma2: "quickly", PoS2: adv, Freq: 10, AVGDist: 1, TextType: tt},
+		"4": {Lemma1: "start", PoS1: verb, Lemma2: "slowly", PoS2: adv, Freq: 5, AVGDist: 1, TextType: tt},
+		"5": {Lemma1: "start", PoS1: verb, Lemma2: "again", PoS2: adv, Freq: 2, AVGDist: 1, TextType: tt},
+		"6": {Lemma1: "finish", PoS1: verb, Lemma2: "quickly", PoS2: adv, Freq: 8, AVGDist: 1, TextType: tt},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	ans, err := db.CalculateSecondOrder(
+		context.Background(), CalculationArgs{Lemma: "work", Limit: 10, SortBy: sortByLogDice}, 2)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	collocates := make(map[string][]string)
+	for _, item := range ans {
+		for _, item2 := range item.SecondOrder {
+			assert.Equal(t, item.Collocate.Value, item2.Lemma.Value)
+			assert.Greater(t, item2.LogDice, 0.0)
+			collocates[item.Collocate.Value] = append(collocates[item.Collocate.Value], item2.Collocate.Value)
+		}
+	}
+	assert.Equal(t, []string{"quickly", "slowly"}, collocates["start"])
+	assert.Equal(t, []string{"quickly"}, collocates["finish"])
+
+	data, err := json.Marshal(ans[0])
+	assert.NoError(t, err)
+	var decoded Collocation
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Len(t, decoded.SecondOrder, len(ans[0].SecondOrder))
+	assert.Equal(t, ans[0].SecondOrder[0].Collocate.Value, decoded.SecondOrder[0].Collocate.Value)
+}
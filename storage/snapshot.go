@@ -0,0 +1,93 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// pinnedSnapshot is a read transaction shared by consecutive
+// read operations (see DB.PinSnapshot)
+type pinnedSnapshot struct {
+	mu        sync.RWMutex
+	txn       *badger.Txn
+	createdAt time.Time
+}
+
+// PinSnapshot makes all the following read operations to use the same
+// read snapshot of the database until UnpinSnapshot is called. This
+// guarantees consistent results across consecutive queries (e.g. in an
+// interactive session) even if the data are being changed. To see the
+// changes, RefreshSnapshot must be called. Calling the method with
+// an already pinned snapshot is a NOP.
+func (db *DB) PinSnapshot() {
+	db.snapshot.mu.Lock()
+	defer db.snapshot.mu.Unlock()
+	if db.snapshot.txn == nil {
+		db.snapshot.txn = db.bdb.NewTransaction(false)
+		db.snapshot.createdAt = time.Now()
+	}
+}
+
+// RefreshSnapshot replaces a pinned snapshot with a current one.
+// In case no snapshot is pinned, the method is a NOP.
+func (db *DB) RefreshSnapshot() {
+	db.snapshot.mu.Lock()
+	defer db.snapshot.mu.Unlock()
+	if db.snapshot.txn != nil {
+		db.snapshot.txn.Discard()
+		db.snapshot.txn = db.bdb.NewTransaction(false)
+		db.snapshot.createdAt = time.Now()
+	}
+}
+
+// UnpinSnapshot releases a pinned snapshot so the following read
+// operations will use their own (current) snapshots again.
+func (db *DB) UnpinSnapshot() {
+	db.snapshot.mu.Lock()
+	defer db.snapshot.mu.Unlock()
+	if db.snapshot.txn != nil {
+		db.snapshot.txn.Discard()
+		db.snapshot.txn = nil
+	}
+}
+
+// SnapshotTime returns the time a pinned snapshot was created.
+// In case no snapshot is pinned, zero time is returned.
+func (db *DB) SnapshotTime() time.Time {
+	db.snapshot.mu.RLock()
+	defer db.snapshot.mu.RUnlock()
+	if db.snapshot.txn == nil {
+		return time.Time{}
+	}
+	return db.snapshot.createdAt
+}
+
+// view runs a read operation either within a pinned snapshot
+// or (if there is none) within a new read transaction.
+func (db *DB) view(fn func(txn *badger.Txn) error) error {
+	db.snapshot.mu.RLock()
+	if db.snapshot.txn != nil {
+		defer db.snapshot.mu.RUnlock()
+		return fn(db.snapshot.txn)
+	}
+	db.snapshot.mu.RUnlock()
+	return db.bdb.View(fn)
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinnedSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	seq := NewTokenIDSequence()
+	_, err := db.StoreData(seq, map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 10, TextType: tt},
+	}, nil, 1)
+	assert.NoError(t, err)
+
+	assert.True(t, db.SnapshotTime().IsZero())
+	db.PinSnapshot()
+	assert.False(t, db.SnapshotTime().IsZero())
+	_, err = db.StoreData(seq, map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "garden", PoS: noun, Freq: 5, TextType: tt},
+	}, nil, 1)
+	assert.NoError(t, err)
+
+	info, err := db.GetLemmaInfo("garden", nil)
+	assert.NoError(t, err)
+	assert.False(t, info.Exists)
+	info, err = db.GetLemmaInfo("house", nil)
+	assert.NoError(t, err)
+	assert.True(t, info.Exists)
+
+	db.RefreshSnapshot()
+	info, err = db.GetLemmaInfo("garden", nil)
+	assert.NoError(t, err)
+	assert.True(t, info.Exists)
+
+	db.UnpinSnapshot()
+	assert.True(t, db.SnapshotTime().IsZero())
+	info, err = db.GetLemmaInfo("garden", nil)
+	assert.NoError(t, err)
+	assert.True(t, info.Exists)
+}
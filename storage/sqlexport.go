@@ -0,0 +1,223 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sqlInsertBatchSize is a max. number of rows in a single INSERT statement
+const sqlInsertBatchSize = 500
+
+// sqlSchema describes the tables of an SQL export. Collocation records
+// are exported from the perspective of both tokens (as stored in the database)
+// so coll_freqs contains each dependency pair twice - once with the head
+// as token1 (is_head = 1) and once with the dependent as token1 (is_head = 0).
+const sqlSchema = `CREATE TABLE metadata (
+    name TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+CREATE TABLE lemmas (
+    token_id INTEGER PRIMARY KEY,
+    lemma TEXT NOT NULL,
+    is_word_form INTEGER NOT NULL
+);
+CREATE TABLE token_freqs (
+    token_id INTEGER NOT NULL REFERENCES lemmas(token_id),
+    pos TEXT,
+    text_type TEXT,
+    feats TEXT,
+    freq INTEGER NOT NULL
+);
+CREATE TABLE coll_freqs (
+    token1_id INTEGER NOT NULL REFERENCES lemmas(token_id),
+    pos1 TEXT,
+    feats1 TEXT,
+    is_head INTEGER NOT NULL,
+    deprel TEXT,
+    token2_id INTEGER NOT NULL REFERENCES lemmas(token_id),
+    pos2 TEXT,
+    text_type TEXT,
+    freq INTEGER NOT NULL,
+    avg_dist REAL NOT NULL,
+    avg_surface_dist REAL
+);
+`
+
+// sqlIndices are created once all the data are inserted
+const sqlIndices = `CREATE INDEX lemmas_lemma_idx ON lemmas(lemma);
+CREATE INDEX token_freqs_token_idx ON token_freqs(token_id, pos);
+CREATE INDEX coll_freqs_token1_idx ON coll_freqs(token1_id, is_head);
+CREATE INDEX coll_freqs_token2_idx ON coll_freqs(token2_id);
+CREATE INDEX coll_freqs_deprel_idx ON coll_freqs(deprel);
+`
+
+// sqlValue formats v as an SQL literal. Empty strings and nil
+// pointers are written as NULL.
+func sqlValue(v any) string {
+	switch tv := v.(type) {
+	case string:
+		if tv == "" {
+			return "NULL"
+		}
+		return "'" + strings.ReplaceAll(tv, "'", "''") + "'"
+	case bool:
+		if tv {
+			return "1"
+		}
+		return "0"
+	case uint32:
+		return strconv.FormatUint(uint64(tv), 10)
+	case uint64:
+		return strconv.FormatUint(tv, 10)
+	case int64:
+		return strconv.FormatInt(tv, 10)
+	case float64:
+		return strconv.FormatFloat(tv, 'g', -1, 64)
+	case *float64:
+		if tv == nil {
+			return "NULL"
+		}
+		return strconv.FormatFloat(*tv, 'g', -1, 64)
+	default:
+		panic(fmt.Sprintf("unsupported SQL value type %T", v))
+	}
+}
+
+// sqlInsertWriter writes rows of a table as multi-row INSERT statements
+type sqlInsertWriter struct {
+	w       *bufio.Writer
+	table   string
+	numRows int
+}
+
+func (iw *sqlInsertWriter) add(values ...any) error {
+	if iw.numRows == 0 {
+		if _, err := fmt.Fprintf(iw.w, "INSERT INTO %s VALUES\n(", iw.table); err != nil {
+			return err
+		}
+
+	} else {
+		if _, err := iw.w.WriteString(",\n("); err != nil {
+			return err
+		}
+	}
+	for i, v := range values {
+		if i > 0 {
+			iw.w.WriteByte(',')
+		}
+		iw.w.WriteString(sqlValue(v))
+	}
+	if err := iw.w.WriteByte(')'); err != nil {
+		return err
+	}
+	iw.numRows++
+	if iw.numRows == sqlInsertBatchSize {
+		return iw.flush()
+	}
+	return nil
+}
+
+func (iw *sqlInsertWriter) flush() error {
+	if iw.numRows == 0 {
+		return nil
+	}
+	iw.numRows = 0
+	_, err := iw.w.WriteString(";\n")
+	return err
+}
+
+// ExportSQL writes the lexicon, single token frequencies, collocation
+// frequencies and metadata of the database as an SQL script (SQLite dialect)
+// creating and filling in a relational schema (see sqlSchema). Derived records
+// (rollups, hot lemma summaries, precomputed top collocations and lookup
+// indices) are not exported as they can be obtained with SQL queries.
+// Text types, PoS tags and dependency relations are exported in their
+// readable form. Records which cannot be decoded make the export fail.
+func (db *DB) ExportSQL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("BEGIN TRANSACTION;\n" + sqlSchema); err != nil {
+		return fmt.Errorf("failed to export database to SQL: %w", err)
+	}
+	rawMetadata, err := json.Marshal(db.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to export database to SQL: %w", err)
+	}
+	metaWriter := &sqlInsertWriter{w: bw, table: "metadata"}
+	metaWriter.add("metadata", string(rawMetadata))
+	metaWriter.add("corpusSize", strconv.FormatInt(db.Metadata.CorpusSize, 10))
+	if db.Metadata.ProfileName != "" {
+		metaWriter.add("profileName", db.Metadata.ProfileName)
+	}
+	if err := metaWriter.flush(); err != nil {
+		return fmt.Errorf("failed to export database to SQL: %w", err)
+	}
+
+	tables := map[string]*sqlInsertWriter{
+		"idToLemma":   {w: bw, table: "lemmas"},
+		"tokenFreq":   {w: bw, table: "token_freqs"},
+		"pairFreq":    {w: bw, table: "coll_freqs"},
+		"revPairFreq": {w: bw, table: "coll_freqs"},
+	}
+	var currTable *sqlInsertWriter
+	err = db.Dump(
+		DumpArgs{Namespaces: []string{"idToLemma", "tokenFreq", "pairFreq", "revPairFreq"}},
+		func(rec DumpedRecord) error {
+			if rec.Error != "" {
+				return fmt.Errorf("%s (key: %s)", rec.Error, rec.Key)
+			}
+			iw := tables[rec.Namespace]
+			if iw != currTable && currTable != nil {
+				if err := currTable.flush(); err != nil {
+					return err
+				}
+			}
+			currTable = iw
+			switch rec.Namespace {
+			case "idToLemma":
+				return iw.add(rec.TokenID, rec.Lemma, db.keys.IsWordFormTokenID(rec.TokenID))
+			case "tokenFreq":
+				return iw.add(rec.TokenID, rec.PoS, rec.TextType, rec.Feats, rec.Freq)
+			default:
+				return iw.add(
+					rec.TokenID, rec.PoS, rec.Feats, rec.IsHead, rec.Deprel, rec.Token2ID,
+					rec.PoS2, rec.TextType, rec.Freq, rec.Dist, rec.SurfaceDist,
+				)
+			}
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to export database to SQL: %w", err)
+	}
+	if currTable != nil {
+		if err := currTable.flush(); err != nil {
+			return fmt.Errorf("failed to export database to SQL: %w", err)
+		}
+	}
+	if _, err := bw.WriteString(sqlIndices + "COMMIT;\n"); err != nil {
+		return fmt.Errorf("failed to export database to SQL: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to export database to SQL: %w", err)
+	}
+	return nil
+}
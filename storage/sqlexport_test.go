@@ -0,0 +1,68 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLValue(t *testing.T) {
+	surfaceDist := -1.5
+	assert.Equal(t, "'rock''n''roll'", sqlValue("rock'n'roll"))
+	assert.Equal(t, "NULL", sqlValue(""))
+	assert.Equal(t, "1", sqlValue(true))
+	assert.Equal(t, "42", sqlValue(uint32(42)))
+	assert.Equal(t, "2.5", sqlValue(2.5))
+	assert.Equal(t, "-1.5", sqlValue(&surfaceDist))
+	assert.Equal(t, "NULL", sqlValue((*float64)(nil)))
+}
+
+func TestExportSQL(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: fiction},
+		"2": {Lemma: "o'big", PoS: adj, Freq: 10, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Lemma2: "o'big", PoS2: adj, Freq: 5,
+			AVGDist: 1, TextType: fiction, Direction: record.DirectionHead},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	var buff strings.Builder
+	assert.NoError(t, db.ExportSQL(&buff))
+	script := buff.String()
+	assert.True(t, strings.HasPrefix(script, "BEGIN TRANSACTION;\n"))
+	assert.True(t, strings.HasSuffix(script, "COMMIT;\n"))
+	assert.Contains(t, script, "('corpusSize','1000')")
+	assert.Contains(t, script, ",'dog',0)")
+	assert.Contains(t, script, ",'o''big',0)")
+	assert.Contains(t, script, ",'NOUN','fiction',NULL,20)")
+	assert.Contains(t, script, ",'ADJ','fiction',5,1,0)")
+	assert.Equal(t, 4, strings.Count(script, "INSERT INTO"))
+	assert.Contains(t, script, "CREATE INDEX coll_freqs_token1_idx")
+}
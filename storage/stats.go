@@ -0,0 +1,328 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DeprelExamplePair is an example of a (head, dependent) pair
+// connected via a specific relation.
+type DeprelExamplePair struct {
+	Head      string `json:"head"`
+	Dependent string `json:"dependent"`
+	Freq      int    `json:"freq"`
+}
+
+// DeprelStats contains global statistics of a single syntactic relation.
+type DeprelStats struct {
+	Deprel string `json:"deprel"`
+
+	// NumPairs is the number of distinct (head, dependent) pairs
+	// (with text types and PoS variants counted separately)
+	NumPairs int `json:"numPairs"`
+
+	// Freq is the total frequency of all the pairs
+	Freq int `json:"freq"`
+
+	// AVGDist is a frequency-weighted average absolute distance
+	// of the pairs
+	AVGDist roundedFloat `json:"avgDist"`
+
+	Examples []DeprelExamplePair `json:"examples"`
+}
+
+type rawExamplePair struct {
+	headID uint64
+	depID  uint64
+	freq   int
+}
+
+type deprelStatsAcc struct {
+	numPairs int
+	freq     int
+	distSum  float64
+	examples []rawExamplePair
+}
+
+// addExample keeps the numExamples most frequent pairs. The pair
+// frequency must be complete, i.e. summed over all the text types
+// and PoS variants of the pair (see tokenExamples).
+func (acc *deprelStatsAcc) addExample(ex rawExamplePair, numExamples int) {
+	if numExamples <= 0 {
+		return
+	}
+	if len(acc.examples) < numExamples {
+		acc.examples = append(acc.examples, ex)
+
+	} else if acc.examples[len(acc.examples)-1].freq < ex.freq {
+		acc.examples[len(acc.examples)-1] = ex
+
+	} else {
+		return
+	}
+	acc.sortExamples()
+}
+
+func (acc *deprelStatsAcc) sortExamples() {
+	sort.SliceStable(acc.examples, func(i, j int) bool {
+		return acc.examples[i].freq > acc.examples[j].freq
+	})
+}
+
+type examplePairKey struct {
+	deprel uint16
+	depID  uint64
+}
+
+// tokenExamples sums frequencies of pairs of a single first token.
+// As all the records of a token are stored next to each other,
+// the sums are complete once the token changes and they can be
+// passed to the bounded lists of examples.
+type tokenExamples struct {
+	tokenID uint64
+	freqs   map[examplePairKey]int
+}
+
+func (te *tokenExamples) add(tokenID uint64, deprel uint16, depID uint64, freq int) {
+	te.tokenID = tokenID
+	te.freqs[examplePairKey{deprel: deprel, depID: depID}] += freq
+}
+
+// flush passes the summed pairs of the current token to the
+// respective accumulators (in a fixed order so pairs with the same
+// frequency are always picked the same way)
+func (te *tokenExamples) flush(accs map[uint16]*deprelStatsAcc, numExamples int) {
+	keys := slices.SortedFunc(maps.Keys(te.freqs), func(a, b examplePairKey) int {
+		return cmp.Or(cmp.Compare(a.deprel, b.deprel), cmp.Compare(a.depID, b.depID))
+	})
+	for _, k := range keys {
+		accs[k.deprel].addExample(
+			rawExamplePair{headID: te.tokenID, depID: k.depID, freq: te.freqs[k]},
+			numExamples,
+		)
+	}
+	clear(te.freqs)
+}
+
+// GetDeprelStats walks through all the stored collocation pairs and
+// calculates statistics for each syntactic relation. For each relation,
+// up to numExamples most frequent pairs are attached as examples.
+// Entries of excludedTextTypes are ignored.
+// The result is sorted by relation frequency in descending order.
+//
+// Please note that the function reads the whole pair index so it
+// is not intended for per-request use on large databases (the result
+// should be cached by a caller).
+func (db *DB) GetDeprelStats(ctx context.Context, numExamples int, excludedTextTypes []string) ([]DeprelStats, error) {
+	excludedTT := make(map[byte]bool)
+	for _, tt := range excludedTextTypes {
+		if rawTT := db.textTypes.ReadableToRaw(tt); rawTT > 0 {
+			excludedTT[rawTT] = true
+		}
+	}
+	accs := make(map[uint16]*deprelStatsAcc)
+	lemmaCache := itemsWalktrhoughCache{db: db}
+	ans := make([]DeprelStats, 0, 30)
+	releaseScan, err := db.scanGate.acquire(ctx)
+	if err != nil {
+		return ans, fmt.Errorf("failed to get deprel stats: %w", err)
+	}
+	defer releaseScan()
+	cancelled := cancelCheck{ctx: ctx}
+	err = db.view(func(txn *badger.Txn) error {
+		// A head-dependent pair is stored from the perspective of both
+		// the tokens so we read just the records with the head as
+		// the first token (pairs imported in one direction only, e.g.
+		// grandparent-grandchild ones, are always stored this way).
+		// Siblings have no head so both their orders are stored with
+		// the dependent records and just one of the orders is read.
+		directions := []bool{true}
+		var siblingDeprels map[uint16]bool
+		if db.Metadata.HasFeature(FeatureSiblings) {
+			siblingDeprels = db.siblingDeprels()
+			directions = append(directions, false)
+		}
+		examples := tokenExamples{freqs: make(map[examplePairKey]int)}
+		for _, isHead := range directions {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = record.AllCollFreqs(isHead)
+			it := txn.NewIterator(opts)
+			defer it.Close()
+			for it.Rewind(); it.Valid(); it.Next() {
+				if err := cancelled.err(); err != nil {
+					return err
+				}
+				item := it.Item()
+				key, err := db.keys.DecodeCollFreqKey(item.Key())
+				if err != nil {
+					if err := db.skipMalformed(item.Key(), err); err != nil {
+						return err
+					}
+					continue
+				}
+				if db.keys.IsWordFormTokenID(key.Token1ID) {
+					// word forms (if indexed) duplicate the lemma data
+					continue
+				}
+				if !isHead && (!siblingDeprels[key.Deprel] || key.Token1ID > key.Token2ID) {
+					continue
+				}
+				if excludedTT[key.TextType] {
+					continue
+				}
+				val, err := decodeItemValue(item, record.DecodeCollocValue)
+				if err != nil {
+					if err := db.skipMalformed(item.Key(), err); err != nil {
+						return err
+					}
+					continue
+				}
+				acc, ok := accs[key.Deprel]
+				if !ok {
+					acc = &deprelStatsAcc{}
+					accs[key.Deprel] = acc
+				}
+				acc.numPairs++
+				acc.freq += int(val.Freq)
+				acc.distSum += math.Abs(val.Dist) * float64(val.Freq)
+				if key.Token1ID != examples.tokenID {
+					examples.flush(accs, numExamples)
+				}
+				examples.add(key.Token1ID, key.Deprel, key.Token2ID, int(val.Freq))
+			}
+			examples.flush(accs, numExamples)
+		}
+
+		for deprel, acc := range accs {
+			item := DeprelStats{
+				Deprel:   db.DeprelMapping.GetRev(deprel),
+				NumPairs: acc.numPairs,
+				Freq:     acc.freq,
+				Examples: make([]DeprelExamplePair, 0, len(acc.examples)),
+			}
+			if acc.freq > 0 {
+				item.AVGDist = roundedFloat(acc.distSum / float64(acc.freq))
+			}
+			for _, ex := range acc.examples {
+				head, err := lemmaCache.getLemmaByIDTxn(txn, ex.headID)
+				if err != nil {
+					return err
+				}
+				dep, err := lemmaCache.getLemmaByIDTxn(txn, ex.depID)
+				if err != nil {
+					return err
+				}
+				item.Examples = append(
+					item.Examples,
+					DeprelExamplePair{Head: head, Dependent: dep, Freq: ex.freq},
+				)
+			}
+			ans = append(ans, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return ans, fmt.Errorf("failed to get deprel stats: %w", err)
+	}
+	slices.SortFunc(ans, func(a, b DeprelStats) int {
+		return b.Freq - a.Freq
+	})
+	return ans, nil
+}
+
+// siblingDeprels returns codes of all the relations describing
+// pairs of siblings (see record.IsSiblingDeprel)
+func (db *DB) siblingDeprels() map[uint16]bool {
+	mapping := db.DeprelMapping
+	if mapping == nil {
+		mapping = &record.UDDeprelMapping
+	}
+	ans := make(map[uint16]bool)
+	for label, code := range mapping.AsMap() {
+		if record.IsSiblingDeprel(label) {
+			ans[code] = true
+		}
+	}
+	return ans
+}
+
+// MergeDeprelStats combines statistics obtained from multiple databases
+// (e.g. parts of a larger corpus). Pair counts and frequencies are summed,
+// average distances are weighted by frequencies and the same examples
+// are merged with only numExamples most frequent ones kept. Note that
+// NumPairs may overestimate the number of distinct pairs as the same
+// pair may occur in multiple databases.
+func MergeDeprelStats(numExamples int, stats ...[]DeprelStats) []DeprelStats {
+	merged := make(map[string]*DeprelStats)
+	order := make([]string, 0, 50)
+	for _, part := range stats {
+		for _, item := range part {
+			curr, ok := merged[item.Deprel]
+			if !ok {
+				curr = &DeprelStats{Deprel: item.Deprel}
+				merged[item.Deprel] = curr
+				order = append(order, item.Deprel)
+			}
+			if freq := curr.Freq + item.Freq; freq > 0 {
+				curr.AVGDist = roundedFloat(
+					(float64(curr.AVGDist)*float64(curr.Freq) + float64(item.AVGDist)*float64(item.Freq)) /
+						float64(freq))
+			}
+			curr.NumPairs += item.NumPairs
+			curr.Freq += item.Freq
+			for _, ex := range item.Examples {
+				idx := slices.IndexFunc(curr.Examples, func(v DeprelExamplePair) bool {
+					return v.Head == ex.Head && v.Dependent == ex.Dependent
+				})
+				if idx >= 0 {
+					curr.Examples[idx].Freq += ex.Freq
+
+				} else {
+					curr.Examples = append(curr.Examples, ex)
+				}
+			}
+		}
+	}
+	ans := make([]DeprelStats, 0, len(order))
+	for _, deprel := range order {
+		item := merged[deprel]
+		sort.SliceStable(item.Examples, func(i, j int) bool {
+			return item.Examples[i].Freq > item.Examples[j].Freq
+		})
+		if len(item.Examples) > numExamples {
+			item.Examples = item.Examples[:max(numExamples, 0)]
+		}
+		if item.Examples == nil {
+			item.Examples = []DeprelExamplePair{}
+		}
+		ans = append(ans, *item)
+	}
+	slices.SortFunc(ans, func(a, b DeprelStats) int {
+		return b.Freq - a.Freq
+	})
+	return ans
+}
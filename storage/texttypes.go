@@ -16,6 +16,104 @@
 
 package storage
 
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TextTypeDimSeparator separates values of individual text type
+// dimensions in combined text type values (e.g. "fiction|1990s").
+const TextTypeDimSeparator = "|"
+
+// MaxTextTypeDims is a max. number of text type dimensions
+// (structural attributes) which can be combined.
+const MaxTextTypeDims = 3
+
+// TextTypeAttrs splits a (possibly comma-separated) text types
+// attribute of a profile (see Profile.TextTypesAttr) into structural
+// attributes of individual text type dimensions.
+func TextTypeAttrs(attr string) []string {
+	if attr == "" {
+		return []string{}
+	}
+	ans := strings.Split(attr, ",")
+	for i, v := range ans {
+		ans[i] = strings.TrimSpace(v)
+	}
+	return ans
+}
+
+// TextTypeDimValues splits a combined text type value into values
+// of individual text type dimensions.
+func TextTypeDimValues(textType string) []string {
+	return strings.Split(textType, TextTypeDimSeparator)
+}
+
+// matchesTextTypeDims tests whether a combined text type value matches
+// required values of individual dimensions. Empty required values match
+// anything.
+func matchesTextTypeDims(textType string, dims []string) bool {
+	values := TextTypeDimValues(textType)
+	for i, v := range dims {
+		if v != "" && (i >= len(values) || values[i] != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// textTypeDimsMismatches returns raw values of all the text types
+// not matching required values of individual text type dimensions
+// (see CalculationArgs.TextTypeDims). In case a required value is not
+// present in any of the text types, ErrUnknownFilterValue is returned.
+func (db *DB) textTypeDimsMismatches(dims []string) ([]byte, error) {
+	found := make([]bool, len(dims))
+	for i, v := range dims {
+		found[i] = v == ""
+	}
+	var ans []byte
+	for raw := 1; raw <= math.MaxUint8; raw++ {
+		readable := db.textTypes.RawToReadable(byte(raw))
+		if readable == "" {
+			continue
+		}
+		values := TextTypeDimValues(readable)
+		for i, v := range dims {
+			if i < len(values) && values[i] == v {
+				found[i] = true
+			}
+		}
+		if !matchesTextTypeDims(readable, dims) {
+			ans = append(ans, byte(raw))
+		}
+	}
+	for i, ok := range found {
+		if !ok {
+			return nil, fmt.Errorf("%w: text type dimension %d value %s", ErrUnknownFilterValue, i+1, dims[i])
+		}
+	}
+	return ans, nil
+}
+
+// TextTypesNotMatching returns (readable) text types not matching required
+// values of individual text type dimensions. Empty values match anything.
+// This allows applying the same restriction as CalculationArgs.TextTypeDims
+// in methods accepting a list of excluded text types.
+func (db *DB) TextTypesNotMatching(dims []string) ([]string, error) {
+	raws, err := db.textTypeDimsMismatches(dims)
+	if err != nil {
+		return []string{}, err
+	}
+	ans := make([]string, len(raws))
+	for i, raw := range raws {
+		ans[i] = db.textTypes.RawToReadable(raw)
+	}
+	return ans, nil
+}
+
 // PreconfTextTypeMapping represents a type providing mapping
 // between text types encoded as byte values and their actual human
 // readable string value.
@@ -45,3 +143,59 @@ func NewPreconfTextTypeMapping(data map[string]byte) *PreconfTextTypeMapping {
 		data: normData,
 	}
 }
+
+// ------
+
+// TextTypeLabel provides a human-friendly presentation of a text type.
+// Value is the readable code used in queries and results, DisplayName
+// is intended for end users.
+type TextTypeLabel struct {
+	Value       string `json:"value"`
+	DisplayName string `json:"displayName"`
+}
+
+// resolveTextTypeLabels returns text type labels in their display order.
+// Explicitly configured labels have the highest priority. Otherwise,
+// labels are derived from the text type mapping (ordered by raw values)
+// with display names equal to the readable codes.
+func resolveTextTypeLabels(configured []TextTypeLabel, textTypes map[string]byte) []TextTypeLabel {
+	if len(configured) > 0 {
+		return configured
+	}
+	ans := make([]TextTypeLabel, 0, len(textTypes))
+	for k := range textTypes {
+		ans = append(ans, TextTypeLabel{Value: k, DisplayName: k})
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return textTypes[ans[i].Value] < textTypes[ans[j].Value]
+	})
+	return ans
+}
+
+// ValidateTextTypeLabels tests whether all the labels refer to existing
+// text types and whether there are no duplicities.
+func ValidateTextTypeLabels(labels []TextTypeLabel, textTypes map[string]byte) error {
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		if _, ok := textTypes[label.Value]; !ok {
+			return fmt.Errorf("unknown text type in labels: %s", label.Value)
+		}
+		if seen[label.Value] {
+			return fmt.Errorf("duplicate text type in labels: %s", label.Value)
+		}
+		seen[label.Value] = true
+	}
+	return nil
+}
+
+// TextTypeLabels returns display names of text types in their intended
+// display order. Entries of excludedTextTypes are omitted.
+func (db *DB) TextTypeLabels(excludedTextTypes []string) []TextTypeLabel {
+	ans := make([]TextTypeLabel, 0, len(db.textTypeLabels))
+	for _, label := range db.textTypeLabels {
+		if !slices.Contains(excludedTextTypes, label.Value) {
+			ans = append(ans, label)
+		}
+	}
+	return ans
+}
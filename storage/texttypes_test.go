@@ -0,0 +1,116 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTextTypeLabels(t *testing.T) {
+	textTypes := map[string]byte{"news": 0x02, "fiction": 0x01, "poetry": 0x03}
+	assert.Equal(
+		t,
+		[]TextTypeLabel{
+			{Value: "fiction", DisplayName: "fiction"},
+			{Value: "news", DisplayName: "news"},
+			{Value: "poetry", DisplayName: "poetry"},
+		},
+		resolveTextTypeLabels(nil, textTypes),
+	)
+	configured := []TextTypeLabel{
+		{Value: "news", DisplayName: "News"},
+		{Value: "fiction", DisplayName: "Fiction"},
+	}
+	assert.Equal(t, configured, resolveTextTypeLabels(configured, textTypes))
+}
+
+func TestValidateTextTypeLabels(t *testing.T) {
+	textTypes := map[string]byte{"news": 0x02, "fiction": 0x01}
+	assert.NoError(t, ValidateTextTypeLabels([]TextTypeLabel{{Value: "news", DisplayName: "News"}}, textTypes))
+	assert.Error(t, ValidateTextTypeLabels([]TextTypeLabel{{Value: "poetry", DisplayName: "Poetry"}}, textTypes))
+	assert.Error(t, ValidateTextTypeLabels(
+		[]TextTypeLabel{{Value: "news", DisplayName: "News"}, {Value: "news", DisplayName: "News 2"}}, textTypes))
+}
+
+func TestTextTypeLabelsExcluded(t *testing.T) {
+	db := &DB{textTypeLabels: []TextTypeLabel{
+		{Value: "news", DisplayName: "News"},
+		{Value: "fiction", DisplayName: "Fiction"},
+	}}
+	assert.Equal(t, []TextTypeLabel{{Value: "fiction", DisplayName: "Fiction"}}, db.TextTypeLabels([]string{"news"}))
+}
+
+func TestIntercorpTextTypeLabels(t *testing.T) {
+	prof := FindProfile("intercorp_v16ud")
+	assert.NoError(t, ValidateTextTypeLabels(prof.TextTypeLabels, prof.TextTypes))
+	assert.Len(t, prof.TextTypeLabels, len(prof.TextTypes))
+}
+
+func TestTextTypeAttrs(t *testing.T) {
+	assert.Equal(t, []string{"text.txtype"}, TextTypeAttrs("text.txtype"))
+	assert.Equal(t, []string{"text.genre", "doc.period"}, TextTypeAttrs("text.genre, doc.period"))
+	assert.Empty(t, TextTypeAttrs(""))
+}
+
+func TestCalculateMeasuresTextTypeDims(t *testing.T) {
+	db := openTestDB(t)
+	db.textTypes = NewPreconfTextTypeMapping(
+		map[string]byte{"fiction|1990s": 0x01, "fiction|2000s": 0x02, "news|1990s": 0x03})
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := make(map[record.GroupingKey]record.TokenFreq)
+	pairFreqs := make(map[record.GroupingKey]record.CollocFreq)
+	for i, v := range []string{"fiction|1990s", "fiction|2000s", "news|1990s"} {
+		tt := record.TextType{Raw: db.textTypes.ReadableToRaw(v), Readable: v}
+		singleFreqs[record.GroupingKey("dog"+v)] = record.TokenFreq{Lemma: "dog", PoS: noun, Freq: 10 * (i + 1), TextType: tt}
+		singleFreqs[record.GroupingKey("big"+v)] = record.TokenFreq{Lemma: "big", PoS: adj, Freq: 5 * (i + 1), TextType: tt}
+		pairFreqs[record.GroupingKey(v)] = record.CollocFreq{
+			Lemma1: "dog", PoS1: noun, Lemma2: "big", PoS2: adj, Freq: i + 1, AVGDist: 1, TextType: tt}
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	// all the genres of the 1990s
+	ans, err := db.CalculateMeasures(
+		context.Background(), CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice, TextTypeDims: []string{"", "1990s"}})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 1+3, ans[0].Freq)
+	assert.Equal(t, 10+30, ans[0].LemmaFreq)
+	assert.Equal(t, 5+15, ans[0].CollocateFreq)
+
+	ans, err = db.CalculateMeasures(
+		context.Background(), CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice, TextTypeDims: []string{"fiction"}})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, 1+2, ans[0].Freq)
+
+	_, err = db.CalculateMeasures(
+		context.Background(), CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice, TextTypeDims: []string{"", "1980s"}})
+	assert.True(t, errors.Is(err, ErrUnknownFilterValue))
+
+	excluded, err := db.TextTypesNotMatching([]string{"news"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"fiction|1990s", "fiction|2000s"}, excluded)
+}
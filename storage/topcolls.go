@@ -0,0 +1,340 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ugorji/go/codec"
+)
+
+// DefaultTopCollocationsLimit is a default number of collocations
+// precomputed for each frequent lemma and sorting measure.
+const DefaultTopCollocationsLimit = 100
+
+// TopCollocationsInfo describes precomputed top collocations
+// stored in a database (see DB.StoreTopCollocations)
+type TopCollocationsInfo struct {
+
+	// MinLemmaFreq is a frequency a lemma had to reach
+	// to get its top collocations precomputed
+	MinLemmaFreq int `json:"minLemmaFreq"`
+
+	// Limit is a max. number of collocations stored
+	// for each lemma and sorting measure
+	Limit int `json:"limit"`
+
+	// NumLemmas is a number of lemmas with precomputed collocations
+	NumLemmas int `json:"numLemmas"`
+
+	// ExcludedTextTypes lists text types excluded from the second
+	// variant of the records (empty if there is no such variant)
+	ExcludedTextTypes []string `json:"excludedTextTypes,omitempty"`
+}
+
+// TopCollocationsArgs specifies which top collocations
+// are precomputed by DB.StoreTopCollocations
+type TopCollocationsArgs struct {
+
+	// MinLemmaFreq is a min. frequency of a lemma
+	// to get its top collocations precomputed
+	MinLemmaFreq int
+
+	// Limit is a number of collocations stored for each lemma
+	// and sorting measure
+	Limit int
+
+	// ExcludedTextTypes, if non-empty, makes the function to store also
+	// a variant of the records with the text types excluded. Typically,
+	// these are the restricted text types of the database so searches
+	// without access to them can be served too.
+	ExcludedTextTypes []string
+}
+
+// topCollsRecord is a stored form of precomputed top collocations.
+// Only the raw values are stored, scores are recalculated when
+// the records are read (RRF scores are the only exception as they
+// depend on all the found collocations).
+type topCollsRecord struct {
+	TotalCount int            `json:"totalCount"`
+	Items      []topCollsItem `json:"items"`
+}
+
+type topCollsItem struct {
+	Collocate     CollMember `json:"collocate"`
+	Deprel        string     `json:"deprel"`
+	IsHead        bool       `json:"isHead"`
+	TextType      string     `json:"textType"`
+	MutualDist    float64    `json:"mutualDist"`
+	SurfaceDist   float64    `json:"surfaceDist"`
+	Freq          int        `json:"freq"`
+	LemmaFreq     int        `json:"lemmaFreq"`
+	CollocateFreq int        `json:"collocateFreq"`
+	RRFScore      float64    `json:"rrfScore"`
+}
+
+// StoreTopCollocations finds lemmas with frequency at least args.MinLemmaFreq
+// and for each of them and each sorting measure, it stores args.Limit top
+// collocations along with the total number of found collocations. The values
+// are calculated with default search arguments so CalculateMeasures can use
+// them for searches of an exact lemma without any additional filtering or
+// grouping. Previously stored top collocations are always removed first.
+// The function should be called once the collocation data are imported
+// and db.Metadata is set (the corpus size is needed for scores).
+// It returns the number of lemmas with stored collocations.
+func (db *DB) StoreTopCollocations(ctx context.Context, args TopCollocationsArgs) (int, error) {
+	if err := db.bdb.DropPrefix(record.AllTopColls()); err != nil {
+		return 0, fmt.Errorf("failed to store top collocations: %w", err)
+	}
+	if args.MinLemmaFreq <= 0 || args.Limit <= 0 {
+		return 0, nil
+	}
+	tokenIDs, err := db.findFrequentLemmas(args.MinLemmaFreq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store top collocations: %w", err)
+	}
+	wb := db.bdb.NewWriteBatch()
+	defer wb.Cancel()
+	for _, tokenID := range tokenIDs {
+		lemma, err := db.GetLemmaByID(tokenID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to store top collocations: %w", err)
+		}
+		if err := db.storeTopCollocationsOf(ctx, wb, tokenID, lemma, nil, args.Limit); err != nil {
+			return 0, fmt.Errorf("failed to store top collocations of %s: %w", lemma, err)
+		}
+		if len(args.ExcludedTextTypes) > 0 {
+			err := db.storeTopCollocationsOf(ctx, wb, tokenID, lemma, args.ExcludedTextTypes, args.Limit)
+			if err != nil {
+				return 0, fmt.Errorf("failed to store top collocations of %s: %w", lemma, err)
+			}
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to store top collocations: %w", err)
+	}
+	return len(tokenIDs), nil
+}
+
+// findFrequentLemmas returns token IDs of lemmas (i.e. not word forms)
+// with summed single token frequency at least minFreq
+func (db *DB) findFrequentLemmas(minFreq int) ([]uint64, error) {
+	ans := make([]uint64, 0, 100)
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.AllTokenFreqs()
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		var currToken uint64
+		var freq int
+		for it.Rewind(); it.Valid(); it.Next() {
+			key, err := db.keys.DecodeTokenFreqKey(it.Item().Key())
+			if err != nil {
+				return err
+			}
+			if key.Token1ID != currToken {
+				// (token IDs start from 1 so 0 means no token yet)
+				if currToken > 0 && freq >= minFreq {
+					ans = append(ans, currToken)
+				}
+				currToken = key.Token1ID
+				freq = 0
+			}
+			val, err := decodeItemValue(it.Item(), record.DecodeTokenValue)
+			if err != nil {
+				return err
+			}
+			freq += int(val.Freq)
+		}
+		if currToken > 0 && freq >= minFreq {
+			ans = append(ans, currToken)
+		}
+		return nil
+	})
+	return slices.DeleteFunc(ans, db.keys.IsWordFormTokenID), err
+}
+
+func (db *DB) storeTopCollocationsOf(
+	ctx context.Context,
+	wb *badger.WriteBatch,
+	tokenID uint64,
+	lemma string,
+	excludedTextTypes []string,
+	limit int,
+) error {
+	var totalCount int
+	items, err := db.CalculateMeasures(ctx, CalculationArgs{
+		Lemma:             lemma,
+		SortBy:            sortByLogDice,
+		Limit:             math.MaxInt,
+		ExcludedTextTypes: excludedTextTypes,
+		TotalCount:        &totalCount,
+	})
+	if err != nil {
+		return err
+	}
+	for _, measure := range SortingMeasures {
+		sorted := slices.Clone(items)
+		SortCollocations(sorted, measure)
+		rec := topCollsRecord{
+			TotalCount: totalCount,
+			Items:      make([]topCollsItem, 0, min(limit, len(sorted))),
+		}
+		for _, item := range LimitCollocations(sorted, limit, false) {
+			rec.Items = append(rec.Items, topCollsItem{
+				Collocate:     item.Collocate,
+				Deprel:        item.Deprel,
+				IsHead:        item.IsHead,
+				TextType:      item.TextType,
+				MutualDist:    item.MutualDist,
+				SurfaceDist:   item.SurfaceDist,
+				Freq:          item.Freq,
+				LemmaFreq:     item.LemmaFreq,
+				CollocateFreq: item.CollocateFreq,
+				RRFScore:      item.RRFScore,
+			})
+		}
+		encoded, err := encodeWithHandle(rec, msgpackHandle)
+		if err != nil {
+			return err
+		}
+		key := db.keys.TopCollsKey(tokenID, len(excludedTextTypes) > 0, string(measure))
+		if err := wb.Set(key, encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// topCollocationsApplicable tells whether a search can be served
+// from precomputed top collocations. I.e. the search must be for
+// an exact lemma with no filtering and grouping other than excluded
+// text types matching the stored records and the requested page
+// must be within the stored items (this is tested later once the
+// record is loaded). The second returned value tells which variant
+// of the records (see record.KeyLayout.TopCollsKey) is applicable.
+func (db *DB) topCollocationsApplicable(args CalculationArgs) (bool, bool) {
+	info := db.Metadata.TopCollocations
+	if info == nil || !db.Metadata.HasFeature(FeatureTopCollocations) {
+		return false, false
+	}
+	if args.LemmaIsPrefix || len(args.LemmaSet) > 0 || args.LemmaPattern != "" ||
+		args.IgnoreDiacritics || args.IgnoreCase || args.SearchByWordForm || args.GroupByFeats ||
+		args.PoS != "" || args.TextType != "" || len(args.TextTypeDims) > 0 ||
+		args.IsHead != nil || args.CollocateGroupByPos || args.GroupByDeprel ||
+		args.CollocateGroupByTextType || args.CustomFilter != nil ||
+		args.MaxAvgCollocateDist > 0 || args.MaxAvgSurfaceDist > 0 ||
+		args.CollocateOrder != "" || args.SignedDistance || args.MinCollFreq > 0 ||
+		args.CorpusSize > 0 || args.DeprelGranularity == DeprelGranularityCore ||
+		len(args.Deprels) > 0 || args.PathCollocations || args.VariantSummary != nil ||
+		args.CategoryLexicon != nil || args.CategoryProfile != nil {
+		return false, false
+	}
+	if len(args.ExcludedTextTypes) == 0 {
+		return true, false
+	}
+	if len(info.ExcludedTextTypes) > 0 && sameTextTypeSet(args.ExcludedTextTypes, info.ExcludedTextTypes) {
+		return true, true
+	}
+	return false, false
+}
+
+func sameTextTypeSet(a, b []string) bool {
+	for _, v := range a {
+		if !slices.Contains(b, v) {
+			return false
+		}
+	}
+	for _, v := range b {
+		if !slices.Contains(a, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// getTopCollocations loads precomputed top collocations matching args
+// and returns the requested page of them along with the total number
+// of collocations. In case the record is missing (i.e. the lemma is not
+// frequent enough or it does not exist) or it does not contain enough
+// items, false is returned.
+func (db *DB) getTopCollocations(
+	args CalculationArgs,
+	excludesTextTypes bool,
+	calcFields []ResultField,
+) ([]Collocation, int, bool, error) {
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: args.Lemma})
+	if err == badger.ErrKeyNotFound {
+		return nil, 0, false, nil
+
+	} else if err != nil {
+		return nil, 0, false, err
+	}
+	var rec topCollsRecord
+	var found bool
+	err = db.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(db.keys.TopCollsKey(tokenID, excludesTextTypes, string(args.SortBy)))
+		if err == badger.ErrKeyNotFound {
+			return nil
+
+		} else if err != nil {
+			return err
+		}
+		found = true
+		return item.Value(func(val []byte) error {
+			return codec.NewDecoderBytes(val, msgpackHandle).Decode(&rec)
+		})
+	})
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("failed to read top collocations: %w", err)
+	}
+	if !found || len(rec.Items) < rec.TotalCount && args.Limit > len(rec.Items)-args.Offset {
+		return nil, 0, false, nil
+	}
+	corpusSize := db.Metadata.CorpusSize
+	if excludesTextTypes {
+		corpusSize, err = db.CorpusSizeWithout(args.ExcludedTextTypes)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("failed to read top collocations: %w", err)
+		}
+	}
+	ans := make([]Collocation, len(rec.Items))
+	for i, v := range rec.Items {
+		ans[i] = Collocation{
+			Lemma:         CollMember{Value: args.Lemma},
+			Collocate:     v.Collocate,
+			Deprel:        v.Deprel,
+			IsHead:        v.IsHead,
+			TextType:      v.TextType,
+			MutualDist:    v.MutualDist,
+			SurfaceDist:   v.SurfaceDist,
+			CorpusSize:    corpusSize,
+			Freq:          v.Freq,
+			LemmaFreq:     v.LemmaFreq,
+			CollocateFreq: v.CollocateFreq,
+			RRFScore:      v.RRFScore,
+			Fields:        args.Fields,
+		}
+		ans[i].UpdateScores(calcFields)
+	}
+	return PageCollocations(ans, args.Offset, args.Limit, args.LimitPerVariant), rec.TotalCount, true, nil
+}
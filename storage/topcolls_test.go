@@ -0,0 +1,125 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateMeasuresTopCollocations(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: fiction},
+		"3": {Lemma: "work", PoS: verb, Freq: 30, TextType: news},
+		"4": {Lemma: "busy", PoS: adj, Freq: 30, TextType: news},
+		"5": {Lemma: "free", PoS: adj, Freq: 10, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 2, AVGDist: 2, TextType: news},
+		"3": {Lemma1: "monday", PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 4, AVGDist: 1, TextType: news},
+		"4": {Lemma1: "monday", PoS1: noun, Lemma2: "free", PoS2: adj, Freq: 3, AVGDist: 1, TextType: fiction},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	numLemmas, err := db.StoreTopCollocations(
+		context.Background(),
+		TopCollocationsArgs{MinLemmaFreq: 40, Limit: 2, ExcludedTextTypes: []string{"news"}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, numLemmas) // monday, work
+
+	type search struct {
+		args     CalculationArgs
+		expected []Collocation
+		total    int
+	}
+	searches := []search{
+		{args: CalculationArgs{Lemma: "monday", Limit: 2, SortBy: sortByLogDice}},
+		{args: CalculationArgs{Lemma: "monday", Limit: 1, Offset: 1, SortBy: sortByRRF}},
+		{args: CalculationArgs{Lemma: "monday", Limit: 2, SortBy: sortByLMI, ExcludedTextTypes: []string{"news"}}},
+		{args: CalculationArgs{Lemma: "monday", Limit: 2, SortBy: sortByTScore, Fields: []ResultField{FieldMI}}},
+	}
+	for i := range searches {
+		searches[i].args.TotalCount = &searches[i].total
+		searches[i].expected, err = db.CalculateMeasures(context.Background(), searches[i].args)
+		assert.NoError(t, err)
+	}
+
+	db.Metadata.TopCollocations = &TopCollocationsInfo{
+		MinLemmaFreq: 40, Limit: 2, NumLemmas: numLemmas, ExcludedTextTypes: []string{"news"}}
+	db.Metadata.Features = []DatasetFeature{FeatureTopCollocations}
+	for _, s := range searches {
+		var total int
+		var stats FilterStats
+		s.args.TotalCount = &total
+		s.args.FilterStats = &stats
+		ans, err := db.CalculateMeasures(context.Background(), s.args)
+		assert.NoError(t, err)
+		assert.True(t, stats.UsedTopCollocations, "sortBy: %s", s.args.SortBy)
+		assert.Equal(t, s.total, total)
+		assert.Equal(t, s.expected, ans)
+	}
+
+	// the requested page is not within the stored items
+	var stats FilterStats
+	ans, err := db.CalculateMeasures(
+		context.Background(),
+		CalculationArgs{Lemma: "monday", Limit: 3, SortBy: sortByLogDice, FilterStats: &stats},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 3)
+	assert.False(t, stats.UsedTopCollocations)
+
+	// a lemma below the threshold
+	_, err = db.CalculateMeasures(
+		context.Background(),
+		CalculationArgs{Lemma: "busy", Limit: 2, SortBy: sortByLogDice, FilterStats: &stats},
+	)
+	assert.NoError(t, err)
+	assert.False(t, stats.UsedTopCollocations)
+
+	// searches with filters must use the raw records
+	_, err = db.CalculateMeasures(
+		context.Background(),
+		CalculationArgs{Lemma: "monday", Limit: 2, SortBy: sortByLogDice, PoS: "NOUN", FilterStats: &stats},
+	)
+	assert.NoError(t, err)
+	assert.False(t, stats.UsedTopCollocations)
+	_, err = db.CalculateMeasures(
+		context.Background(),
+		CalculationArgs{
+			Lemma: "monday", Limit: 2, SortBy: sortByLogDice,
+			ExcludedTextTypes: []string{"fiction"}, FilterStats: &stats,
+		},
+	)
+	assert.NoError(t, err)
+	assert.False(t, stats.UsedTopCollocations)
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// TopCollocationsDumpArgs configures DB.ForEachTopCollocations
+type TopCollocationsDumpArgs struct {
+
+	// MinLemmaFreq is a min. frequency of a lemma
+	// (summed over text types) to get its collocations
+	MinLemmaFreq int
+
+	// Limit is a max. number of collocations of each lemma
+	// and measure
+	Limit int
+
+	// Measures lists measures the collocations are ranked by
+	Measures []SortingMeasure
+
+	// ExcludedTextTypes specifies text types ignored completely
+	ExcludedTextTypes []string
+
+	// GroupByDeprel splits collocations by their relations
+	// (like in word sketches)
+	GroupByDeprel bool
+
+	// CollocateGroupByPos splits collocations by PoS tags
+	// of the collocates
+	CollocateGroupByPos bool
+}
+
+// ForEachTopCollocations finds all the lemmas with frequency at least
+// args.MinLemmaFreq and calls fn with top args.Limit collocations
+// of each of them for each of args.Measures. The lemmas are processed
+// in alphabetical order (so outputs of differently built databases
+// can be compared). The collocations are calculated just like
+// CalculateMeasures does with default search arguments (except for
+// the grouping specified by args).
+// It returns the number of processed lemmas.
+func (db *DB) ForEachTopCollocations(
+	ctx context.Context,
+	args TopCollocationsDumpArgs,
+	fn func(lemma string, measure SortingMeasure, items []Collocation) error,
+) (int, error) {
+	tokenIDs, err := db.findFrequentLemmas(args.MinLemmaFreq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find top collocations: %w", err)
+	}
+	lemmas := make([]string, 0, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		lemma, err := db.GetLemmaByID(tokenID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find top collocations: %w", err)
+		}
+		lemmas = append(lemmas, lemma)
+	}
+	slices.Sort(lemmas)
+	for _, lemma := range lemmas {
+		items, err := db.CalculateMeasures(ctx, CalculationArgs{
+			Lemma:               lemma,
+			SortBy:              sortByLogDice,
+			Limit:               math.MaxInt,
+			ExcludedTextTypes:   args.ExcludedTextTypes,
+			GroupByDeprel:       args.GroupByDeprel,
+			CollocateGroupByPos: args.CollocateGroupByPos,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to find top collocations of %s: %w", lemma, err)
+		}
+		for _, measure := range args.Measures {
+			sorted := slices.Clone(items)
+			SortCollocations(sorted, measure)
+			if err := fn(lemma, measure, LimitCollocations(sorted, args.Limit, false)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(lemmas), nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachTopCollocations(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: fiction},
+		"3": {Lemma: "work", PoS: verb, Freq: 30, TextType: news},
+		"4": {Lemma: "busy", PoS: adj, Freq: 30, TextType: news},
+		"5": {Lemma: "free", PoS: adj, Freq: 10, TextType: fiction},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 4, AVGDist: 1, TextType: news},
+		"3": {Lemma1: "monday", PoS1: noun, Lemma2: "free", PoS2: adj, Freq: 3, AVGDist: 1, TextType: fiction},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	type call struct {
+		lemma   string
+		measure SortingMeasure
+		items   []Collocation
+	}
+	var calls []call
+	numLemmas, err := db.ForEachTopCollocations(
+		context.Background(),
+		TopCollocationsDumpArgs{MinLemmaFreq: 40, Limit: 2, Measures: []SortingMeasure{sortByLogDice, sortByTScore}},
+		func(lemma string, measure SortingMeasure, items []Collocation) error {
+			calls = append(calls, call{lemma: lemma, measure: measure, items: items})
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, numLemmas) // monday, work
+	if assert.Len(t, calls, 4) {
+		assert.Equal(t, "monday", calls[0].lemma)
+		assert.Equal(t, sortByLogDice, calls[0].measure)
+		assert.Equal(t, sortByTScore, calls[1].measure)
+		assert.Equal(t, "work", calls[2].lemma)
+
+		expected, err := db.CalculateMeasures(
+			context.Background(), CalculationArgs{Lemma: "monday", Limit: 2, SortBy: sortByTScore})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, calls[1].items)
+		assert.Empty(t, calls[2].items)
+	}
+
+	calls = nil
+	_, err = db.ForEachTopCollocations(
+		context.Background(),
+		TopCollocationsDumpArgs{
+			MinLemmaFreq: 40, Limit: 10, Measures: []SortingMeasure{sortByLogDice},
+			ExcludedTextTypes: []string{"news"}},
+		func(lemma string, measure SortingMeasure, items []Collocation) error {
+			calls = append(calls, call{lemma: lemma, measure: measure, items: items})
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	if assert.Len(t, calls, 2) {
+		assert.Len(t, calls[0].items, 2) // busy is only in news
+	}
+
+	calls = nil
+	_, err = db.ForEachTopCollocations(
+		context.Background(),
+		TopCollocationsDumpArgs{
+			MinLemmaFreq: 40, Limit: 10, Measures: []SortingMeasure{sortByLogDice},
+			GroupByDeprel: true, CollocateGroupByPos: true},
+		func(lemma string, measure SortingMeasure, items []Collocation) error {
+			calls = append(calls, call{lemma: lemma, measure: measure, items: items})
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	if assert.Len(t, calls, 2) && assert.Len(t, calls[0].items, 3) {
+		assert.NotEmpty(t, calls[0].items[0].Collocate.PoS)
+	}
+}
+
+func TestFindFrequentLemmasNoTokenSentinel(t *testing.T) {
+	db := openTestDB(t)
+	tokenIDs, err := db.findFrequentLemmas(0)
+	assert.NoError(t, err)
+	assert.Empty(t, tokenIDs)
+
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 40, TextType: tt},
+		"2": {Lemma: "night", PoS: noun, Freq: 10, TextType: tt},
+	}
+	_, err = db.StoreData(NewTokenIDSequence(), singleFreqs, map[record.GroupingKey]record.CollocFreq{}, 1)
+	assert.NoError(t, err)
+	mondayID, err := db.GetLemmaID(record.TokenFreq{Lemma: "monday"})
+	assert.NoError(t, err)
+	nightID, err := db.GetLemmaID(record.TokenFreq{Lemma: "night"})
+	assert.NoError(t, err)
+
+	tokenIDs, err = db.findFrequentLemmas(0)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{mondayID, nightID}, tokenIDs)
+	tokenIDs, err = db.findFrequentLemmas(20)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{mondayID}, tokenIDs)
+}
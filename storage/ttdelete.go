@@ -0,0 +1,291 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// TextTypeDeletionStats describes records removed by DeleteTextType
+type TextTypeDeletionStats struct {
+	NumLemmaFreqs        int `json:"numLemmaFreqs"`
+	NumWordFormFreqs     int `json:"numWordFormFreqs"`
+	NumCollFreqs         int `json:"numCollFreqs"`
+	NumWordFormCollFreqs int `json:"numWordFormCollFreqs"`
+	NumPathCollFreqs     int `json:"numPathCollFreqs"`
+
+	// NumWordForms is a number of word forms removed from
+	// the word form index as they occurred only in the text type
+	NumWordForms int `json:"numWordForms"`
+
+	// NumRollups is a number of updated (or removed in case
+	// nothing is left) token frequency rollups
+	NumRollups int `json:"numRollups"`
+
+	// CorpusSize is an estimated number of removed corpus
+	// positions (see DeleteTextType)
+	CorpusSize int64 `json:"corpusSize"`
+}
+
+// DeleteTextType removes all the single token and collocation frequency
+// records of the text type (e.g. a corpus section imported by mistake)
+// and updates everything derived from them - token frequency rollups,
+// hot lemma summaries and top collocations are recalculated, metadata
+// counts and relation distance statistics are decreased. As the database
+// does not know the actual size of individual text types, the corpus size
+// is decreased proportionally to the removed share of summed lemma
+// frequencies.
+//
+// Word forms occurring only in the text type are removed from the word
+// form index. Lemma indices and pair examples (which are not split
+// by text types) are kept untouched. The updated metadata are stored.
+// No other operations may run on the database during the deletion.
+func (db *DB) DeleteTextType(tt string) (TextTypeDeletionStats, error) {
+	var stats TextTypeDeletionStats
+	rawTT := db.textTypes.ReadableToRaw(tt)
+	if rawTT == 0 {
+		return stats, fmt.Errorf("failed to delete text type: %w: %s", ErrUnknownTextType, tt)
+	}
+	var totalFreq, removedFreq int64
+	rollupDiffs := make(map[tokenRollupKey]int)
+	// word forms with removed records and word forms with records
+	// in other text types
+	removedForms := make(map[uint64]bool)
+	keptForms := make(map[uint64]bool)
+	removedDists := make(relationDistAccumulator)
+	w := db.newBatchWriter("text type deletion", 0)
+	defer w.Cancel()
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.AllTokenFreqs()
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key, err := db.keys.DecodeTokenFreqKey(item.Key())
+			if err != nil {
+				return err
+			}
+			val, err := decodeItemValue(item, record.DecodeTokenValue)
+			if err != nil {
+				return err
+			}
+			isWordForm := db.keys.IsWordFormTokenID(key.Token1ID)
+			if !isWordForm {
+				totalFreq += int64(val.Freq)
+			}
+			if key.TextType != rawTT {
+				if isWordForm {
+					keptForms[key.Token1ID] = true
+				}
+				continue
+			}
+			if err := w.Delete(item.KeyCopy(nil)); err != nil {
+				return err
+			}
+			rollupDiffs[tokenRollupKey{tokenID: key.Token1ID, pos: key.Pos1}] += int(val.Freq)
+			if isWordForm {
+				stats.NumWordFormFreqs++
+				removedForms[key.Token1ID] = true
+
+			} else {
+				stats.NumLemmaFreqs++
+				removedFreq += int64(val.Freq)
+			}
+		}
+		for _, ns := range []string{"pairFreq", "revPairFreq", "pathPairFreq", "revPathPairFreq"} {
+			if err := db.deleteTextTypePairs(txn, w, ns, rawTT, removedDists, &stats); err != nil {
+				return err
+			}
+		}
+		for tokenID := range removedForms {
+			if keptForms[tokenID] {
+				continue
+			}
+			if err := db.deleteWordForm(txn, w, tokenID); err != nil {
+				return err
+			}
+			stats.NumWordForms++
+		}
+		for rk, freq := range rollupDiffs {
+			updated, err := db.subtractTokenRollup(txn, w, rk, freq)
+			if err != nil {
+				return err
+			}
+			if updated {
+				stats.NumRollups++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to delete text type: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return stats, fmt.Errorf("failed to delete text type: %w", err)
+	}
+
+	if totalFreq > 0 {
+		stats.CorpusSize = int64(math.Round(
+			float64(db.Metadata.CorpusSize) * float64(removedFreq) / float64(totalFreq)))
+	}
+	db.Metadata.CorpusSize -= stats.CorpusSize
+	db.Metadata.NumLemmaFreqs -= stats.NumLemmaFreqs
+	db.Metadata.NumCollFreqs -= stats.NumCollFreqs
+	db.Metadata.NumWordForms -= stats.NumWordForms
+	db.Metadata.RelationDists = subtractRelationDists(db.Metadata.RelationDists, removedDists)
+	if db.Metadata.Features != nil {
+		db.Metadata.Features = slices.DeleteFunc(db.Metadata.Features, func(f DatasetFeature) bool {
+			return f == FeatureWordForms && db.Metadata.NumWordForms == 0 ||
+				f == FeatureRelationDists && len(db.Metadata.RelationDists) == 0
+		})
+	}
+	if err := db.recalcPrecomputedRecords(); err != nil {
+		return stats, fmt.Errorf("failed to delete text type: %w", err)
+	}
+	if err := db.StoreMetadata(db.Metadata); err != nil {
+		return stats, fmt.Errorf("failed to delete text type: %w", err)
+	}
+	return stats, nil
+}
+
+// deleteTextTypePairs removes collocation frequency records
+// of the namespace with the raw text type. Distances of removed
+// lemma pairs are added to removedDists.
+func (db *DB) deleteTextTypePairs(
+	txn *badger.Txn,
+	w *batchWriter,
+	ns string,
+	rawTT byte,
+	removedDists relationDistAccumulator,
+	stats *TextTypeDeletionStats,
+) error {
+	prefix, ok := record.NamespaceKeyPrefix(ns)
+	if !ok {
+		return fmt.Errorf("unknown key namespace: %s", ns)
+	}
+	mapping := db.DeprelMapping
+	if mapping == nil {
+		mapping = &record.UDDeprelMapping
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		key, err := db.keys.DecodeCollFreqKey(it.Item().Key())
+		if err != nil {
+			return err
+		}
+		if key.TextType != rawTT {
+			continue
+		}
+		if err := w.Delete(it.Item().KeyCopy(nil)); err != nil {
+			return err
+		}
+		if ns == "pathPairFreq" || ns == "revPathPairFreq" {
+			stats.NumPathCollFreqs++
+
+		} else if db.keys.IsWordFormTokenID(key.Token1ID) {
+			stats.NumWordFormCollFreqs++
+
+		} else {
+			stats.NumCollFreqs++
+			val, err := decodeItemValue(it.Item(), record.DecodeCollocValue)
+			if err != nil {
+				return err
+			}
+			removedDists.addDist(mapping.GetRev(key.Deprel), int(val.Freq), val.Dist)
+		}
+	}
+	return nil
+}
+
+// deleteWordForm removes a word form from the word form index
+// and from the reverse index
+func (db *DB) deleteWordForm(txn *badger.Txn, w *batchWriter, tokenID uint64) error {
+	revKey := db.keys.TokenIDToRevIndexKey(tokenID)
+	item, err := txn.Get(revKey)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	form, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+	if err := w.Delete(record.EncodeWordFormKey(string(form))); err != nil {
+		return err
+	}
+	return w.Delete(revKey)
+}
+
+// subtractTokenRollup decreases the rollup frequency by freq. Rollups
+// with nothing left are removed. The returned value tells whether
+// there was a rollup to update.
+func (db *DB) subtractTokenRollup(txn *badger.Txn, w *batchWriter, rk tokenRollupKey, freq int) (bool, error) {
+	key := db.keys.TokenFreqRollupKey(rk.tokenID, rk.pos)
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	curr, err := decodeItemValue(item, record.DecodeTokenValue)
+	if err != nil {
+		return false, err
+	}
+	if int(curr.Freq) <= freq {
+		return true, w.Delete(key)
+	}
+	return true, w.Set(key, record.EncodeTokenValue(curr.Freq-uint32(freq)))
+}
+
+// recalcPrecomputedRecords replaces hot lemma summaries and top
+// collocations (if the database has them) with ones calculated
+// from the current data. Related metadata are updated.
+func (db *DB) recalcPrecomputedRecords() error {
+	if db.Metadata.HasFeature(FeatureHotLemmaSummaries) && db.Metadata.HotLemmaThreshold > 0 {
+		numHotLemmas, err := db.StoreHotLemmaSummaries(db.Metadata.HotLemmaThreshold)
+		if err != nil {
+			return err
+		}
+		db.Metadata.NumHotLemmas = numHotLemmas
+	}
+	if info := db.Metadata.TopCollocations; info != nil && db.Metadata.HasFeature(FeatureTopCollocations) {
+		numLemmas, err := db.StoreTopCollocations(context.Background(), TopCollocationsArgs{
+			MinLemmaFreq:      info.MinLemmaFreq,
+			Limit:             info.Limit,
+			ExcludedTextTypes: info.ExcludedTextTypes,
+		})
+		if err != nil {
+			return err
+		}
+		info.NumLemmas = numLemmas
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteTextType(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "night", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "night", PoS: noun, Freq: 40, TextType: news},
+		"3": {Lemma: "dark", PoS: adj, Freq: 30, TextType: fiction},
+		"4": {Lemma: "dark", PoS: adj, Freq: 5, TextType: news},
+		"5": {Lemma: "election", PoS: noun, Freq: 20, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "night", PoS1: noun, Lemma2: "dark", PoS2: adj, Freq: 18, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "night", PoS1: noun, Lemma2: "dark", PoS2: adj, Freq: 2, AVGDist: 1, TextType: news},
+		"3": {Lemma1: "night", PoS1: noun, Lemma2: "election", PoS2: noun, Freq: 10, AVGDist: 1, TextType: news},
+	}
+	stats, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	numHotLemmas, err := db.StoreHotLemmaSummaries(1)
+	assert.NoError(t, err)
+	db.Metadata = Metadata{
+		CorpusSize:        1350,
+		NumLemmas:         stats.NumLemmas,
+		NumLemmaFreqs:     stats.NumLemmaFreqs,
+		NumCollFreqs:      stats.NumCollFreqs,
+		HotLemmaThreshold: 1,
+		NumHotLemmas:      numHotLemmas,
+		Features:          []DatasetFeature{FeatureTokenFreqRollups, FeatureHotLemmaSummaries},
+	}
+
+	_, err = db.DeleteTextType("poetry")
+	assert.ErrorIs(t, err, ErrUnknownTextType)
+
+	ans, err := db.DeleteTextType("news")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, ans.NumLemmaFreqs)
+	assert.Equal(t, 2, ans.NumCollFreqs)
+	assert.Equal(t, 3, ans.NumRollups)
+	// news make 65 of 135 summed lemma frequencies
+	assert.Equal(t, int64(650), ans.CorpusSize)
+	assert.Equal(t, int64(700), db.Metadata.CorpusSize)
+	assert.Equal(t, stats.NumLemmaFreqs-3, db.Metadata.NumLemmaFreqs)
+	assert.Equal(t, stats.NumCollFreqs-2, db.Metadata.NumCollFreqs)
+	// night has just one collocation left
+	assert.Equal(t, 0, db.Metadata.NumHotLemmas)
+
+	stored, err := db.StoredMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, db.Metadata.CorpusSize, stored.CorpusSize)
+
+	freq, err := db.GetLemmaFreq("night", "", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, freq)
+	freq, err = db.GetLemmaFreq("election", "", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, freq)
+
+	items, err := db.CalculateMeasures(
+		context.Background(), CalculationArgs{Lemma: "night", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "dark", items[0].Collocate.Value)
+		assert.Equal(t, 18, items[0].Freq)
+	}
+
+	report, err := db.CheckIntegrity(context.Background(), IntegrityCheckArgs{})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Issues)
+}
+
+func TestDeleteTextTypeWordFormsAndRelationDists(t *testing.T) {
+	db := openTestDB(t)
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amodVal, _ := record.UDDeprelMapping.Get("amod")
+	amod := record.UDDeprelFromUint16(amodVal)
+	nmodVal, _ := record.UDDeprelMapping.Get("nmod")
+	nmod := record.UDDeprelFromUint16(nmodVal)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "night", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "night", PoS: noun, Freq: 40, TextType: news},
+		"3": {Lemma: "dark", PoS: adj, Freq: 30, TextType: fiction},
+		"4": {Lemma: "dark", PoS: adj, Freq: 5, TextType: news},
+		"5": {Lemma: "election", PoS: noun, Freq: 20, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "night", PoS1: noun, Deprel: amod, Lemma2: "dark", PoS2: adj, Freq: 18, AVGDist: 1, TextType: fiction},
+		"2": {Lemma1: "night", PoS1: noun, Deprel: amod, Lemma2: "dark", PoS2: adj, Freq: 2, AVGDist: 3, TextType: news},
+		"3": {Lemma1: "night", PoS1: noun, Deprel: nmod, Lemma2: "election", PoS2: noun, Freq: 10, AVGDist: 2, TextType: news},
+	}
+	stats, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	formSingleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "nights", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "nights", PoS: noun, Freq: 40, TextType: news},
+		"3": {Lemma: "elections", PoS: noun, Freq: 20, TextType: news},
+	}
+	formPairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "nights", PoS1: noun, Deprel: nmod, Lemma2: "elections", PoS2: noun, Freq: 10, AVGDist: 2, TextType: news},
+	}
+	formStats, err := db.StoreWordFormFreqs(formSingleFreqs, formPairFreqs, 1)
+	assert.NoError(t, err)
+	db.Metadata = Metadata{
+		CorpusSize:    1350,
+		NumLemmas:     stats.NumLemmas,
+		NumLemmaFreqs: stats.NumLemmaFreqs,
+		NumCollFreqs:  stats.NumCollFreqs,
+		NumWordForms:  formStats.NumWordForms,
+		RelationDists: stats.RelationDists,
+		DeprelMap:     record.UDDeprelMapping.AsMap(),
+		Features: []DatasetFeature{
+			FeatureTokenFreqRollups, FeatureWordForms, FeatureRelationDists},
+	}
+	assert.Len(t, db.Metadata.RelationDists, 2)
+
+	ans, err := db.DeleteTextType("news")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, ans.NumLemmaFreqs)
+	assert.Equal(t, 2, ans.NumWordFormFreqs)
+	assert.Equal(t, 2, ans.NumCollFreqs)
+	assert.Equal(t, 1, ans.NumWordFormCollFreqs)
+	assert.Equal(t, 1, ans.NumWordForms)
+
+	// "elections" occurred only in news
+	assert.Equal(t, 1, db.Metadata.NumWordForms)
+	_, err = db.GetWordFormID("elections")
+	assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	_, err = db.GetWordFormID("nights")
+	assert.NoError(t, err)
+
+	// only the fiction pair is left
+	assert.Equal(t, map[string]RelationDistStats{"amod": {Freq: 18, AVGDist: 1}}, roundRelationDists(db.Metadata.RelationDists))
+	assert.True(t, db.Metadata.HasFeature(FeatureRelationDists))
+	assert.True(t, db.Metadata.HasFeature(FeatureWordForms))
+
+	report, err := db.CheckIntegrity(context.Background(), IntegrityCheckArgs{})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	// nothing is left of the relation statistics and word forms
+	_, err = db.DeleteTextType("fiction")
+	assert.NoError(t, err)
+	assert.Empty(t, db.Metadata.RelationDists)
+	assert.Equal(t, 0, db.Metadata.NumWordForms)
+	assert.False(t, db.Metadata.HasFeature(FeatureRelationDists))
+	assert.False(t, db.Metadata.HasFeature(FeatureWordForms))
+}
+
+// roundRelationDists rounds the statistics to make them comparable
+func roundRelationDists(stats map[string]RelationDistStats) map[string]RelationDistStats {
+	ans := make(map[string]RelationDistStats, len(stats))
+	for k, v := range stats {
+		ans[k] = RelationDistStats{
+			Freq:    v.Freq,
+			AVGDist: math.Round(v.AVGDist*1000) / 1000,
+			StdDev:  math.Round(v.StdDev*1000) / 1000,
+		}
+	}
+	return ans
+}
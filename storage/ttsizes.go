@@ -0,0 +1,116 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// textTypeSizes caches summed lemma frequencies of individual
+// text types. The cache is valid as long as no new data is written
+// to the database (see DB.numWritten).
+type textTypeSizes struct {
+	mu         sync.Mutex
+	loaded     bool
+	numWritten int64
+	freqs      map[byte]int64
+	total      int64
+}
+
+// textTypeFreqs returns summed lemma frequencies of individual
+// (raw) text types along with the total sum.
+func (db *DB) textTypeFreqs() (map[byte]int64, int64, error) {
+	db.ttSizes.mu.Lock()
+	defer db.ttSizes.mu.Unlock()
+	numWritten := db.numWritten.Load()
+	if db.ttSizes.loaded && db.ttSizes.numWritten == numWritten {
+		return db.ttSizes.freqs, db.ttSizes.total, nil
+	}
+	freqs := make(map[byte]int64)
+	var total int64
+	err := db.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.AllTokenFreqs()
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			key, err := db.keys.DecodeTokenFreqKey(it.Item().Key())
+			if err != nil {
+				return err
+			}
+			if db.keys.IsWordFormTokenID(key.Token1ID) {
+				continue
+			}
+			val, err := decodeItemValue(it.Item(), record.DecodeTokenValue)
+			if err != nil {
+				return err
+			}
+			freqs[key.TextType] += int64(val.Freq)
+			total += int64(val.Freq)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to determine text type sizes: %w", err)
+	}
+	db.ttSizes.freqs = freqs
+	db.ttSizes.total = total
+	db.ttSizes.numWritten = numWritten
+	db.ttSizes.loaded = true
+	return freqs, total, nil
+}
+
+// corpusSizeExcluding estimates size of the corpus without the
+// excluded (raw) text types. As the database does not know actual
+// sizes of text types, the corpus size is reduced proportionally
+// to the share of lemma frequencies of the excluded text types.
+func (db *DB) corpusSizeExcluding(excludedTT map[byte]bool) (int64, error) {
+	if len(excludedTT) == 0 {
+		return db.Metadata.CorpusSize, nil
+	}
+	freqs, total, err := db.textTypeFreqs()
+	if err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return db.Metadata.CorpusSize, nil
+	}
+	var excluded int64
+	for tt := range excludedTT {
+		excluded += freqs[tt]
+	}
+	return int64(math.Round(
+		float64(db.Metadata.CorpusSize) * float64(total-excluded) / float64(total))), nil
+}
+
+// CorpusSizeWithout estimates size of the corpus without the
+// specified text types (see corpusSizeExcluding). Unknown text types
+// are ignored.
+func (db *DB) CorpusSizeWithout(textTypes []string) (int64, error) {
+	excludedTT := make(map[byte]bool)
+	for _, tt := range textTypes {
+		if rawTT := db.textTypes.ReadableToRaw(tt); rawTT > 0 {
+			excludedTT[rawTT] = true
+		}
+	}
+	return db.corpusSizeExcluding(excludedTT)
+}
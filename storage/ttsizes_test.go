@@ -0,0 +1,90 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorpusSizeWithout(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	verb := record.UDPosFromByte(record.PosVERB)
+	adj := record.UDPosFromByte(record.PosADJ)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "monday", PoS: noun, Freq: 40, TextType: fiction},
+		"2": {Lemma: "work", PoS: verb, Freq: 50, TextType: fiction},
+		"3": {Lemma: "work", PoS: verb, Freq: 30, TextType: news},
+		"4": {Lemma: "busy", PoS: adj, Freq: 30, TextType: news},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "monday", PoS1: noun, Lemma2: "work", PoS2: verb, Freq: 6, AVGDist: 1, TextType: fiction, Direction: record.DirectionHead},
+		"2": {Lemma1: "monday", PoS1: noun, Lemma2: "busy", PoS2: adj, Freq: 4, AVGDist: 1, TextType: news, Direction: record.DirectionHead},
+	}
+	_, err := db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+
+	size, err := db.CorpusSizeWithout(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), size)
+	size, err = db.CorpusSizeWithout([]string{"news", "unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(600), size) // 90 of 150
+
+	ans, err := db.CalculateMeasures(
+		context.Background(),
+		CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLogDice},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+	for _, item := range ans {
+		assert.Equal(t, int64(1000), item.CorpusSize)
+	}
+
+	ans, err = db.CalculateMeasures(
+		context.Background(),
+		CalculationArgs{Lemma: "monday", Limit: 10, SortBy: sortByLogDice, ExcludedTextTypes: []string{"news"}},
+	)
+	assert.NoError(t, err)
+	if assert.Len(t, ans, 1) {
+		assert.Equal(t, "work", ans[0].Collocate.Value)
+		assert.Equal(t, 50, ans[0].CollocateFreq)
+		assert.Equal(t, int64(600), ans[0].CorpusSize)
+	}
+
+	// the cached sizes must not survive new data
+	_, err = db.StoreData(
+		NewTokenIDSequence(),
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "tuesday", PoS: noun, Freq: 50, TextType: news},
+		},
+		map[record.GroupingKey]record.CollocFreq{},
+		1,
+	)
+	assert.NoError(t, err)
+	size, err = db.CorpusSizeWithout([]string{"news"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(450), size) // 90 of 200
+}
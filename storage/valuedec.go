@@ -0,0 +1,80 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// readItemValue decodes a value of an item right within Badger's
+// Value callback, i.e. without copying the value first (as item.ValueCopy
+// does). The decode function must not retain the provided slice (or any
+// of its sub-slices) as it is valid only during the callback.
+func readItemValue[T any](item *badger.Item, decode func(val []byte) T) (T, error) {
+	var ans T
+	err := item.Value(func(val []byte) error {
+		ans = decode(val)
+		return nil
+	})
+	return ans, err
+}
+
+// decodeItemValue is a variant of readItemValue for decode functions
+// which can fail on malformed data (see record.ErrMalformedRecord)
+func decodeItemValue[T any](item *badger.Item, decode func(val []byte) (T, error)) (T, error) {
+	var ans T
+	err := item.Value(func(val []byte) error {
+		var err error
+		ans, err = decode(val)
+		return err
+	})
+	return ans, err
+}
+
+// SetLenientDecoding enables or disables the lenient decoding mode.
+// In the mode, searches skip records which cannot be decoded (see
+// record.ErrMalformedRecord) and just log them instead of failing.
+// It is intended for long-running services reading partially corrupted
+// databases. Operations writing data (import, merge) and inspection
+// tools (integrity check, dump) always report such records.
+func (db *DB) SetLenientDecoding(lenient bool) {
+	db.lenientDecoding = lenient
+}
+
+// skipMalformed decides whether a record which failed to decode
+// can be skipped. In the lenient mode, malformed records are logged
+// and nil is returned. Otherwise (and for other errors, e.g. I/O ones),
+// the error is returned.
+func (db *DB) skipMalformed(key []byte, err error) error {
+	if !db.lenientDecoding || !errors.Is(err, record.ErrMalformedRecord) {
+		return err
+	}
+	log.Warn().Err(err).Str("key", hex.EncodeToString(key)).Msg("skipping malformed record")
+	return nil
+}
+
+// DecodeLemma decodes a lemma value of a reverse lemma index record.
+// The result does not share memory with the provided slice.
+func DecodeLemma(val []byte) string {
+	return strings.TrimSpace(string(val))
+}
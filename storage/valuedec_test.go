@@ -0,0 +1,147 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/czcorpus/depreldb/record"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareValueDecodingDB(t testing.TB) *DB {
+	opts := badger.DefaultOptions("").WithInMemory(true).WithLogger(nil)
+	bdb, err := badger.Open(opts)
+	assert.NoError(t, err)
+	t.Cleanup(func() { bdb.Close() })
+	db := &DB{
+		bdb: bdb,
+		textTypes: &PreconfTextTypeMapping{
+			data: map[string]byte{"fiction": 0x01, "news": 0x02},
+		},
+	}
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	singleFreqs := make(map[record.GroupingKey]record.TokenFreq)
+	for i := range 100 {
+		singleFreqs[record.GroupingKey(fmt.Sprint(i))] = record.TokenFreq{
+			Lemma:    fmt.Sprintf("lemma%d", i),
+			PoS:      record.UDPosFromByte(record.PosNOUN),
+			Freq:     i + 1,
+			TextType: tt,
+		}
+	}
+	_, err = db.StoreData(NewTokenIDSequence(), singleFreqs, nil, 1)
+	assert.NoError(t, err)
+	return db
+}
+
+func TestReadItemValue(t *testing.T) {
+	db := prepareValueDecodingDB(t)
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: "lemma10"})
+	assert.NoError(t, err)
+	assert.NotZero(t, tokenID)
+	lemma, err := db.GetLemmaByID(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "lemma10", lemma)
+	freqs, err := db.GetSingleTokenFreq(tokenID, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, freqs, 1)
+	assert.Equal(t, 11, freqs[0].Freq)
+}
+
+func TestLenientDecoding(t *testing.T) {
+	db := prepareValueDecodingDB(t)
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: "lemma10"})
+	assert.NoError(t, err)
+	err = db.bdb.Update(func(txn *badger.Txn) error {
+		return txn.Set(db.keys.TokenFreqKey(tokenID, record.PosVERB, 0x01), []byte{0x01, 0x02})
+	})
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleTokenFreq(tokenID, 0, 0)
+	assert.ErrorIs(t, err, record.ErrMalformedRecord)
+
+	db.SetLenientDecoding(true)
+	freqs, err := db.GetSingleTokenFreq(tokenID, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, freqs, 1)
+	assert.Equal(t, 11, freqs[0].Freq)
+}
+
+func BenchmarkGetLemmaByID(b *testing.B) {
+	db := prepareValueDecodingDB(b)
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: "lemma10"})
+	assert.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := db.GetLemmaByID(tokenID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetRawTokenFreqTx(b *testing.B) {
+	db := prepareValueDecodingDB(b)
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: "lemma10"})
+	assert.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	db.bdb.View(func(txn *badger.Txn) error {
+		for range b.N {
+			if _, err := db.getRawTokenFreqTx(txn, tokenID, 0, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+		return nil
+	})
+}
+
+func benchmarkLemmaValue(b *testing.B, read func(item *badger.Item) (string, error)) {
+	db := prepareValueDecodingDB(b)
+	tokenID, err := db.GetLemmaID(record.TokenFreq{Lemma: "lemma10"})
+	assert.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	db.bdb.View(func(txn *badger.Txn) error {
+		for range b.N {
+			item, err := txn.Get(db.keys.TokenIDToRevIndexKey(tokenID))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := read(item); err != nil {
+				b.Fatal(err)
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkLemmaValueCopy(b *testing.B) {
+	benchmarkLemmaValue(b, func(item *badger.Item) (string, error) {
+		v, err := item.ValueCopy(nil)
+		return DecodeLemma(v), err
+	})
+}
+
+func BenchmarkLemmaValueNoCopy(b *testing.B) {
+	benchmarkLemmaValue(b, func(item *badger.Item) (string, error) {
+		return readItemValue(item, DecodeLemma)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Department of Linguistics,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// NodeVariantSummary describes search results of a single node
+// of a collocation search. For a prefix search, each matching lemma
+// is a separate node (unless the variants are merged). For a lemma
+// set or merged prefix variants, there is just one node.
+type NodeVariantSummary struct {
+
+	// Node is a label of the node as used in Collocation.Lemma.Value
+	Node string `json:"node"`
+
+	// Lemmas contains all the lemmas the node consists of
+	Lemmas []string `json:"lemmas"`
+
+	// NumCandidates is a number of collocations of the node
+	// before the result limit is applied
+	NumCandidates int `json:"numCandidates"`
+
+	// NumReturned is a number of collocations of the node
+	// in the search result
+	NumReturned int `json:"numReturned"`
+}
+
+// summarizeVariants creates per-node summaries of found node variants
+// (in the order of the variants) with the numbers of collocations
+// (both candidates and returned ones) set to zero.
+func summarizeVariants(variants []nodeVariant, labels map[uint64]string) []NodeVariantSummary {
+	ans := make([]NodeVariantSummary, 0, len(labels))
+	nodeIdx := make(map[uint64]int)
+	for _, v := range variants {
+		idx, ok := nodeIdx[v.nodeID]
+		if !ok {
+			idx = len(ans)
+			nodeIdx[v.nodeID] = idx
+			ans = append(ans, NodeVariantSummary{Node: labels[v.nodeID]})
+		}
+		ans[idx].Lemmas = append(ans[idx].Lemmas, v.Value)
+	}
+	return ans
+}
+
+// CountVariantCollocations returns numbers of collocations
+// of individual nodes (identified by their labels, i.e. by
+// Collocation.Lemma.Value)
+func CountVariantCollocations(items []Collocation) map[string]int {
+	ans := make(map[string]int)
+	for _, item := range items {
+		ans[item.Lemma.Value]++
+	}
+	return ans
+}
+
+// LimitCollocations keeps at most limit first items. If perVariant
+// is true, the limit is applied to each node (see NodeVariantSummary)
+// separately. The order of the items is preserved.
+func LimitCollocations(items []Collocation, limit int, perVariant bool) []Collocation {
+	return PageCollocations(items, 0, limit, perVariant)
+}
+
+// PageCollocations skips offset first items and keeps at most limit
+// following items. If perVariant is true, both the offset and the limit
+// are applied to each node (see NodeVariantSummary) separately.
+// The order of the items is preserved.
+func PageCollocations(items []Collocation, offset, limit int, perVariant bool) []Collocation {
+	if !perVariant {
+		if offset >= len(items) {
+			return items[:0]
+		}
+		items = items[offset:]
+		if len(items) > limit {
+			return items[:limit]
+		}
+		return items
+	}
+	counts := make(map[string]int)
+	ans := make([]Collocation, 0, len(items))
+	for _, item := range items {
+		counts[item.Lemma.Value]++
+		if n := counts[item.Lemma.Value]; n <= offset || n > offset+limit {
+			continue
+		}
+		ans = append(ans, item)
+	}
+	return ans
+}
@@ -17,45 +17,69 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/czcorpus/depreldb/record"
 	"github.com/dgraph-io/badger/v4"
 )
 
+// ErrTokenIDOverflow is returned when a token ID sequence runs out
+// of IDs available in the key layout of a database (see record.KeyLayout)
+var ErrTokenIDOverflow = errors.New("token ID overflow")
+
 // tokenIDSequence is a generator of unique sequential integer identifiers (1, 2, ...)
 // for storing lemmas (along with PoS if available)
 type tokenIDSequence struct {
-	value uint32
-	cache map[string]uint32 // key is a hashed mix of lemma and PoS
+	value uint64
+	cache map[string]uint64 // key is a hashed mix of lemma and PoS
+
+	// wordForms makes the sequence to generate IDs of word forms
+	// (see record.KeyLayout.WordFormTokenIDFlag)
+	wordForms bool
+}
+
+// flag returns a value OR-ed with generated values
+func (tseq *tokenIDSequence) flag(keys record.KeyLayout) uint64 {
+	if tseq.wordForms {
+		return keys.WordFormTokenIDFlag()
+	}
+	return 0
 }
 
-// next generates next ID in the stored sequence.
+// next generates next ID in the stored sequence. The key layout
+// determines the range of available IDs - in case they are exhausted,
+// ErrTokenIDOverflow is returned (a database with the wide layout
+// should be created instead, see record.WideKeyLayout).
 // Please note that calling the method with the same lemma produces
 // new ID each time. To test if a lemma has already been registered,
 // use recall().
-func (tseq *tokenIDSequence) next(lemmaHash string) uint32 {
-	tseq.value++
-	if tseq.value == 0 {
-		panic("tokenIDSequence overflow")
+func (tseq *tokenIDSequence) next(lemmaHash string, keys record.KeyLayout) (uint64, error) {
+	if tseq.value >= keys.MaxTokenID() {
+		return 0, fmt.Errorf(
+			"%w: the %d-byte key layout cannot store more than %d tokens",
+			ErrTokenIDOverflow, keys.TokenIDWidth(), keys.MaxTokenID())
 	}
-	tseq.cache[lemmaHash] = tseq.value
-	return tseq.value
+	tseq.value++
+	ans := tseq.flag(keys) | tseq.value
+	tseq.cache[lemmaHash] = ans
+	return ans, nil
 }
 
-func (tseq *tokenIDSequence) nextIfNotFound(lemmaHash string) (uint32, bool) {
+func (tseq *tokenIDSequence) nextIfNotFound(lemmaHash string, keys record.KeyLayout) (uint64, bool, error) {
 	nextID := tseq.recall(lemmaHash)
-	found := true
-	if nextID == 0 {
-		nextID = tseq.next(lemmaHash)
-		found = false
+	if nextID != 0 {
+		return nextID, true, nil
 	}
-	return nextID, found
+	nextID, err := tseq.next(lemmaHash, keys)
+	return nextID, false, err
 }
 
 // recall returns ID of an already registered lemma. If not found,
 // zero is returned (numbers are generated from 1 so the distinction is clear)
-func (tseq *tokenIDSequence) recall(lemmaHash string) uint32 {
+func (tseq *tokenIDSequence) recall(lemmaHash string) uint64 {
 	// zero means = not found (we serve ids from 1)
 	return tseq.cache[lemmaHash]
 }
@@ -65,108 +89,527 @@ func (tseq *tokenIDSequence) recall(lemmaHash string) uint32 {
 func NewTokenIDSequence() *tokenIDSequence {
 	return &tokenIDSequence{
 		value: 0,
-		cache: make(map[string]uint32),
+		cache: make(map[string]uint64),
 	}
 }
 
+// NewWordFormIDSequence creates a properly initialized
+// ID sequence generator for word forms
+func NewWordFormIDSequence() *tokenIDSequence {
+	ans := NewTokenIDSequence()
+	ans.wordForms = true
+	return ans
+}
+
+// TokenIDSequence creates an ID sequence generator initialized with
+// lemmas already stored in the database so new lemmas get IDs following
+// the existing ones and the stored lemmas keep their IDs.
+// It is intended for appending data to an existing database.
+func (db *DB) TokenIDSequence() (*tokenIDSequence, error) {
+	return db.loadIDSequence(NewTokenIDSequence())
+}
+
+// WordFormIDSequence is a word form variant of TokenIDSequence
+func (db *DB) WordFormIDSequence() (*tokenIDSequence, error) {
+	return db.loadIDSequence(NewWordFormIDSequence())
+}
+
+// loadIDSequence registers all the stored tokens with IDs belonging
+// to the sequence (i.e. either lemmas or word forms) to the sequence
+func (db *DB) loadIDSequence(ans *tokenIDSequence) (*tokenIDSequence, error) {
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = record.AllRevIndexKeys()
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			lemma, err := readItemValue(item, DecodeLemma)
+			if err != nil {
+				return err
+			}
+			tokenID := db.keys.DecodeRevIndexKey(item.Key())
+			if db.keys.IsWordFormTokenID(tokenID) != ans.wordForms {
+				continue
+			}
+			ans.cache[lemma] = tokenID
+			ans.value = max(ans.value, tokenID&^db.keys.WordFormTokenIDFlag())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token ID sequence: %w", err)
+	}
+	return ans, nil
+}
+
 // --------------
 
-func (db *DB) StoreSingleTokenFreqTx(txn *badger.Txn, tokenID uint32, freq record.TokenFreq) error {
-	key := record.TokenFreqKey(tokenID, freq.PoS.Byte(), freq.TextType.Byte())
+func (db *DB) storeSingleTokenFreq(w keyValueSetter, tokenID uint64, freq record.TokenFreq) error {
+	key := record.WithFeats(db.keys.TokenFreqKey(tokenID, freq.PoS.Byte(), freq.TextType.Byte()), freq.Feats)
 	encoded := record.EncodeTokenValue(uint32(freq.Freq))
-	return txn.Set(key, encoded)
+	return w.Set(key, encoded)
 }
 
-func (db *DB) StorePairTokenFreqTx(txn *badger.Txn, token1ID, token2ID uint32, collFreq record.CollocFreq) error {
-	key := record.CollFreqKey(
-		collFreq.AVGDist > 0, token1ID, collFreq.PoS1.Byte(), collFreq.TextType.Byte(), collFreq.Deprel.AsUint16(),
+// storeTokenFreqRollup stores an aggregated (over all text types)
+// frequency of a (tokenID, pos) pair.
+func (db *DB) storeTokenFreqRollup(w keyValueSetter, tokenID uint64, pos byte, freq int) error {
+	key := db.keys.TokenFreqRollupKey(tokenID, pos)
+	encoded := record.EncodeTokenValue(uint32(freq))
+	return w.Set(key, encoded)
+}
+
+// mergeTokenValue adds freq to a token frequency record stored
+// under the key (the record is created if it does not exist yet).
+// The existing value is read using txn and the result is written to w.
+// The returned value tells whether a new record has been created.
+func (db *DB) mergeTokenValue(txn *badger.Txn, w keyValueSetter, key []byte, freq int) (bool, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return true, w.Set(key, record.EncodeTokenValue(uint32(freq)))
+	}
+	if err != nil {
+		return false, err
+	}
+	curr, err := decodeItemValue(item, record.DecodeTokenValue)
+	if err != nil {
+		return false, err
+	}
+	return false, w.Set(key, record.EncodeTokenValue(curr.Freq+uint32(freq)))
+}
+
+// pairTokenFreqKey creates a database key of the pair record
+func pairTokenFreqKey(keys record.KeyLayout, token1ID, token2ID uint64, collFreq record.CollocFreq) []byte {
+	key := keys.CollFreqKey(
+		collFreq.IsHead(), token1ID, collFreq.PoS1.Byte(), collFreq.TextType.Byte(), collFreq.Deprel.AsUint16(),
 		token2ID, collFreq.PoS2.Byte())
-	encoded := record.EncodeCollocValue(uint32(collFreq.Freq), collFreq.AVGDist)
-	return txn.Set(key, encoded)
+	return record.WithFeats(key, collFreq.Feats1)
+}
+
+// pathPairTokenFreqKey creates a database key of the path pair record
+// (see record.AsPathCollFreqKey)
+func pathPairTokenFreqKey(keys record.KeyLayout, token1ID, token2ID uint64, collFreq record.CollocFreq) []byte {
+	return record.AsPathCollFreqKey(pairTokenFreqKey(keys, token1ID, token2ID, collFreq))
+}
+
+func (db *DB) storePairTokenFreq(w keyValueSetter, key []byte, collFreq record.CollocFreq) error {
+	encoded := record.EncodeCollocValueWithSurfaceDist(
+		uint32(collFreq.Freq), math.Abs(collFreq.AVGDist), collFreq.AVGSurfaceDist)
+	return w.Set(key, encoded)
 }
 
-func (db *DB) CreateTransaction() *badger.Txn {
-	return db.bdb.NewTransaction(true)
+// mergePairTokenFreq adds the pair frequency to an existing record
+// stored under the key with distances averaged using the frequencies as weights. If there
+// is no such record yet, a new one is created but only in case the
+// frequency reaches minPairFreq. The existing value is read using txn
+// and the result is written to w. The returned values tell whether
+// anything has been written and whether a new record has been created.
+func (db *DB) mergePairTokenFreq(
+	txn *badger.Txn,
+	w keyValueSetter,
+	key []byte,
+	collFreq record.CollocFreq,
+	minPairFreq int,
+) (bool, bool, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		if collFreq.Freq < minPairFreq {
+			return false, false, nil
+		}
+		encoded := record.EncodeCollocValueWithSurfaceDist(
+			uint32(collFreq.Freq), math.Abs(collFreq.AVGDist), collFreq.AVGSurfaceDist)
+		return true, true, w.Set(key, encoded)
+	}
+	if err != nil {
+		return false, false, err
+	}
+	curr, err := decodeItemValue(item, record.DecodeCollocValue)
+	if err != nil {
+		return false, false, err
+	}
+	currFreq := float64(curr.Freq)
+	newFreq := float64(collFreq.Freq)
+	total := currFreq + newFreq
+	if total == 0 {
+		return false, false, nil
+	}
+	dist := (curr.Dist*currFreq + math.Abs(collFreq.AVGDist)*newFreq) / total
+	surfaceDist := collFreq.AVGSurfaceDist
+	if curr.HasSurfaceDist {
+		surfaceDist = (curr.SurfaceDist*currFreq + collFreq.AVGSurfaceDist*newFreq) / total
+	}
+	encoded := record.EncodeCollocValueWithSurfaceDist(uint32(total), dist, surfaceDist)
+	return true, false, w.Set(key, encoded)
 }
 
-func (db *DB) StoreLemmaTx(txn *badger.Txn, lemma record.TokenFreq, tokenID uint32) error {
+func (db *DB) storeLemma(w keyValueSetter, lemma record.TokenFreq, tokenID uint64) error {
+	value := db.keys.TokenIDToBytes(tokenID)
+	if db.keys.IsWordFormTokenID(tokenID) {
+		// word forms (see StoreWordFormFreqs) have their own index
+		// and they are not folded
+		if err := w.Set(record.EncodeWordFormKey(lemma.Lemma), value); err != nil {
+			return err
+		}
+		return w.Set(db.keys.TokenIDToRevIndexKey(tokenID), []byte(lemma.Lemma))
+	}
 	key := record.EncodeLemmaKey(lemma)
-	value := record.TokenIDToBytes(tokenID)
-	if err := txn.Set(key, value); err != nil {
+	if err := w.Set(key, value); err != nil {
 		return err
 	}
+	// Store folded lemma -> tokenID mapping (for diacritics-insensitive
+	// search); lemmas without diacritics are found via the main index
+	if folded := record.FoldLemma(lemma.Lemma); folded != lemma.Lemma {
+		if err := w.Set(record.EncodeFoldedLemmaKey(folded, lemma.Lemma), value); err != nil {
+			return err
+		}
+	}
+	// Store lowercased lemma -> tokenID mapping (for case-insensitive
+	// search); lowercase lemmas are found via the other two indices
+	if strings.ToLower(lemma.Lemma) != lemma.Lemma {
+		err := w.Set(record.EncodeLowerLemmaKey(record.NormalizeLemma(lemma.Lemma), lemma.Lemma), value)
+		if err != nil {
+			return err
+		}
+	}
 	// Store tokenID -> lemma mapping (reverse index)
-	idKey := record.TokenIDToRevIndexKey(tokenID)
-	return txn.Set(idKey, []byte(lemma.Lemma))
+	idKey := db.keys.TokenIDToRevIndexKey(tokenID)
+	return w.Set(idKey, []byte(lemma.Lemma))
 }
 
+// ImportStats describes data written by a single import.
+// In the append mode (see AppendFreqs), the numbers of records
+// include only newly created records (i.e. updated existing
+// records are not counted).
 type ImportStats struct {
-	NumCollFreqs  int
-	NumLemmaFreqs int
-	NumLemmas     int
+	NumCollFreqs    int
+	NumLemmaFreqs   int
+	NumLemmas       int
+	NumLemmaRollups int
+
+	// NumWordForms is set only by imports of word form
+	// frequencies (see StoreWordFormFreqs)
+	NumWordForms int
+
+	// NumPathCollFreqs is set only by imports of path
+	// collocations (see StorePathFreqs)
+	NumPathCollFreqs int
+
+	// NumPairExamples is a number of pairs of tokens with
+	// newly stored examples (see record.CollocFreq.Examples)
+	NumPairExamples int
+
+	// RelationDists contains distance statistics of individual
+	// relations calculated from the stored pairs
+	RelationDists map[string]RelationDistStats
+}
+
+type tokenRollupKey struct {
+	tokenID uint64
+	pos     byte
+}
+
+// StoreFreqs stores collected single token and pair frequencies
+// with token IDs assigned from scratch (i.e. the database is expected
+// to be empty). Pairs with frequency lower than minPairFreq are skipped.
+func (db *DB) StoreFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (ImportStats, error) {
+	return db.StoreData(NewTokenIDSequence(), singleFreqs, pairFreqs, minPairFreq)
+}
+
+// AppendFreqs merges collected single token and pair frequencies
+// into data already stored in the database. Already stored lemmas
+// keep their IDs and frequencies of existing records are increased
+// by the new values. This allows for indexing large corpora
+// file-by-file over multiple runs.
+// The minPairFreq limit applies only to pairs not stored yet - i.e.
+// a pair below the limit in all the individual runs won't be stored
+// even if its total frequency is higher.
+// Please note that hot lemma summaries must be recalculated
+// (see StoreHotLemmaSummaries, it replaces the outdated ones)
+// once the data are appended.
+func (db *DB) AppendFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (ImportStats, error) {
+	tidSeq, err := db.TokenIDSequence()
+	if err != nil {
+		return ImportStats{}, err
+	}
+	return db.storeData(tidSeq, singleFreqs, pairFreqs, minPairFreq, true)
+}
+
+// StoreWordFormFreqs stores single token and pair frequencies
+// collected for word forms (i.e. with the Lemma attributes containing
+// word forms). The records share their types with lemma records but
+// the token IDs are taken from a separate ID space (see
+// record.KeyLayout.WordFormTokenIDFlag) so both kinds of data can be stored
+// in the same database. Relation distances are not calculated
+// for word forms.
+func (db *DB) StoreWordFormFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (ImportStats, error) {
+	return db.storeWordFormData(NewWordFormIDSequence(), singleFreqs, pairFreqs, minPairFreq, false)
+}
+
+// AppendWordFormFreqs is a word form variant of AppendFreqs
+func (db *DB) AppendWordFormFreqs(
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (ImportStats, error) {
+	tidSeq, err := db.WordFormIDSequence()
+	if err != nil {
+		return ImportStats{}, err
+	}
+	return db.storeWordFormData(tidSeq, singleFreqs, pairFreqs, minPairFreq, true)
 }
 
+func (db *DB) storeWordFormData(
+	tidSeq *tokenIDSequence,
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+	merge bool,
+) (ImportStats, error) {
+	res, err := db.storeData(tidSeq, singleFreqs, pairFreqs, minPairFreq, merge)
+	res.NumWordForms = res.NumLemmas
+	res.NumLemmas = 0
+	res.RelationDists = nil
+	if err != nil {
+		return res, fmt.Errorf("failed to store word forms: %w", err)
+	}
+	return res, nil
+}
+
+// StorePathFreqs stores frequencies of pairs connected via other
+// nodes of dependency tree paths (see record.AsPathCollFreqKey). The pairs
+// are stored separately from the regular pairs so they do not affect
+// common collocation searches. All the lemmas must be already stored
+// (see StoreFreqs). Pairs with frequency lower than minPairFreq are skipped.
+func (db *DB) StorePathFreqs(
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (ImportStats, error) {
+	return db.storePathData(pairFreqs, minPairFreq, false)
+}
+
+// AppendPathFreqs is a path collocations variant of AppendFreqs
+func (db *DB) AppendPathFreqs(
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+) (ImportStats, error) {
+	return db.storePathData(pairFreqs, minPairFreq, true)
+}
+
+func (db *DB) storePathData(
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+	merge bool,
+) (ImportStats, error) {
+	var res ImportStats
+	tidSeq, err := db.TokenIDSequence()
+	if err != nil {
+		return res, fmt.Errorf("failed to store path collocations: %w", err)
+	}
+	err = db.bdb.View(func(txn *badger.Txn) error {
+		return db.storePairs(txn, tidSeq, pairFreqs, pathPairTokenFreqKey, minPairFreq, merge, &res)
+	})
+	res.NumPathCollFreqs = res.NumCollFreqs
+	res.NumCollFreqs = 0
+	res.RelationDists = nil
+	if err != nil {
+		return res, fmt.Errorf("failed to store path collocations: %w", err)
+	}
+	return res, nil
+}
+
+// StoreData stores collected single token and pair frequencies
+// with token IDs generated by the provided sequence. Existing
+// records are overwritten. The records are written in batches
+// (see SetWriteBatchSize).
 func (db *DB) StoreData(
 	tidSeq *tokenIDSequence,
 	singleFreqs map[record.GroupingKey]record.TokenFreq,
 	pairFreqs map[record.GroupingKey]record.CollocFreq,
 	minPairFreq int,
+) (ImportStats, error) {
+	return db.storeData(tidSeq, singleFreqs, pairFreqs, minPairFreq, false)
+}
+
+func (db *DB) storeData(
+	tidSeq *tokenIDSequence,
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	minPairFreq int,
+	merge bool,
 ) (ImportStats, error) {
 	var res ImportStats
-	// use singleFreqs as source of lemmas and create indexes
-	for _, lemmaEntry := range singleFreqs {
+	if err := db.storeLemmas(tidSeq, singleFreqs, &res); err != nil {
+		return res, fmt.Errorf("failed to store lemma: %w", err)
+	}
+	// in the merge mode, existing records are read using a read-only
+	// transaction (records of a single import are unique so the pending
+	// writes cannot affect the read values)
+	err := db.bdb.View(func(txn *badger.Txn) error {
+		rollups, err := db.storeSingleFreqs(txn, tidSeq, singleFreqs, merge, &res)
+		if err != nil {
+			return fmt.Errorf("failed to store single freq: %w", err)
+		}
+		if err := db.storeRollups(txn, rollups, merge, &res); err != nil {
+			return fmt.Errorf("failed to store single freq rollup: %w", err)
+		}
+		if err := db.storePairs(txn, tidSeq, pairFreqs, pairTokenFreqKey, minPairFreq, merge, &res); err != nil {
+			return fmt.Errorf("failed to store pair freq: %w", err)
+		}
+		return nil
+	})
+	return res, err
+}
 
-		err := db.bdb.Update(func(txn *badger.Txn) error {
-			nextId, alreadyStored := tidSeq.nextIfNotFound(lemmaEntry.LemmaKey())
-			if alreadyStored {
-				return nil
-			}
-			if err := db.StoreLemmaTx(txn, lemmaEntry, nextId); err != nil {
+// storeLemmas uses singleFreqs as source of lemmas and creates lemma indexes
+func (db *DB) storeLemmas(
+	tidSeq *tokenIDSequence,
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	res *ImportStats,
+) error {
+	bw := db.newBatchWriter("lemmas", len(singleFreqs))
+	defer bw.Cancel()
+	for _, lemmaEntry := range singleFreqs {
+		nextId, alreadyStored, err := tidSeq.nextIfNotFound(lemmaEntry.LemmaKey(), db.keys)
+		if err != nil {
+			return err
+		}
+		if !alreadyStored {
+			if err := db.storeLemma(bw, lemmaEntry, nextId); err != nil {
 				return err
 			}
 			res.NumLemmas++
-			return nil
-		})
-		if err != nil {
-			return res, fmt.Errorf("failed to store lemma: %w", err)
 		}
+		bw.itemDone()
 	}
+	return bw.Flush()
+}
 
-	// Process single token frequencies
+func (db *DB) storeSingleFreqs(
+	txn *badger.Txn,
+	tidSeq *tokenIDSequence,
+	singleFreqs map[record.GroupingKey]record.TokenFreq,
+	merge bool,
+	res *ImportStats,
+) (map[tokenRollupKey]int, error) {
+	rollups := make(map[tokenRollupKey]int)
+	bw := db.newBatchWriter("single token freqs", len(singleFreqs))
+	defer bw.Cancel()
 	for _, lemmaEntry := range singleFreqs {
-		err := db.bdb.Update(func(txn *badger.Txn) error {
-			if err := db.StoreSingleTokenFreqTx(txn, tidSeq.recall(lemmaEntry.LemmaKey()), lemmaEntry); err != nil {
-				return err
+		tokenID := tidSeq.recall(lemmaEntry.LemmaKey())
+		if merge {
+			key := record.WithFeats(
+				db.keys.TokenFreqKey(tokenID, lemmaEntry.PoS.Byte(), lemmaEntry.TextType.Byte()), lemmaEntry.Feats)
+			created, err := db.mergeTokenValue(txn, bw, key, lemmaEntry.Freq)
+			if err != nil {
+				return nil, err
+			}
+			if created {
+				res.NumLemmaFreqs++
+			}
+
+		} else {
+			if err := db.storeSingleTokenFreq(bw, tokenID, lemmaEntry); err != nil {
+				return nil, err
 			}
 			res.NumLemmaFreqs++
-			return nil
-		})
-		if err != nil {
-			return res, fmt.Errorf("failed to store single freq: %w", err)
 		}
+		rollups[tokenRollupKey{tokenID: tokenID, pos: lemmaEntry.PoS.Byte()}] += lemmaEntry.Freq
+		bw.itemDone()
 	}
+	return rollups, bw.Flush()
+}
 
-	// Process pair frequencies
-	for _, pairFreq := range pairFreqs {
-		if pairFreq.Freq < minPairFreq {
-			continue
+// storeRollups stores per-(token, pos) rollups of single token frequencies
+func (db *DB) storeRollups(
+	txn *badger.Txn,
+	rollups map[tokenRollupKey]int,
+	merge bool,
+	res *ImportStats,
+) error {
+	bw := db.newBatchWriter("single token freq rollups", len(rollups))
+	defer bw.Cancel()
+	for rk, freq := range rollups {
+		if merge {
+			created, err := db.mergeTokenValue(txn, bw, db.keys.TokenFreqRollupKey(rk.tokenID, rk.pos), freq)
+			if err != nil {
+				return err
+			}
+			if created {
+				res.NumLemmaRollups++
+			}
+
+		} else {
+			if err := db.storeTokenFreqRollup(bw, rk.tokenID, rk.pos, freq); err != nil {
+				return err
+			}
+			res.NumLemmaRollups++
 		}
-		err := db.bdb.Update(func(txn *badger.Txn) error {
-			if err := db.StorePairTokenFreqTx(
-				txn,
-				tidSeq.recall(pairFreq.Lemma1Key()),
-				tidSeq.recall(pairFreq.Lemma2Key()),
-				pairFreq,
-			); err != nil {
+		bw.itemDone()
+	}
+	return bw.Flush()
+}
+
+func (db *DB) storePairs(
+	txn *badger.Txn,
+	tidSeq *tokenIDSequence,
+	pairFreqs map[record.GroupingKey]record.CollocFreq,
+	keyFn func(keys record.KeyLayout, token1ID, token2ID uint64, collFreq record.CollocFreq) []byte,
+	minPairFreq int,
+	merge bool,
+	res *ImportStats,
+) error {
+	relDists := make(relationDistAccumulator)
+	examples := make(map[pairExamplesKey][]record.PairExample)
+	bw := db.newBatchWriter("pair freqs", len(pairFreqs))
+	defer bw.Cancel()
+	for _, pairFreq := range pairFreqs {
+		bw.itemDone()
+		token1ID := tidSeq.recall(pairFreq.Lemma1Key())
+		token2ID := tidSeq.recall(pairFreq.Lemma2Key())
+		key := keyFn(db.keys, token1ID, token2ID, pairFreq)
+		if merge {
+			stored, created, err := db.mergePairTokenFreq(txn, bw, key, pairFreq, minPairFreq)
+			if err != nil {
+				return err
+			}
+			if created {
+				res.NumCollFreqs++
+			}
+			if !stored {
+				continue
+			}
+
+		} else {
+			if pairFreq.Freq < minPairFreq {
+				continue
+			}
+			if err := db.storePairTokenFreq(bw, key, pairFreq); err != nil {
 				return err
 			}
 			res.NumCollFreqs++
-			return nil
-		})
-		if err != nil {
-			return res, fmt.Errorf("failed to store pair freq: %w", err)
+		}
+		relDists.add(pairFreq)
+		if len(pairFreq.Examples) > 0 {
+			exKey := pairExamplesKey{token1ID: token1ID, token2ID: token2ID}
+			examples[exKey] = record.MergePairExamples(
+				examples[exKey], pairFreq.Examples, db.pairExamplesLimit)
 		}
 	}
-
-	return res, nil
+	res.RelationDists = relDists.result()
+	if err := db.storePairExamples(txn, bw, examples, merge, res); err != nil {
+		return err
+	}
+	return bw.Flush()
 }
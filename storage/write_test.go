@@ -17,7 +17,9 @@
 package storage
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/czcorpus/depreldb/record"
 	"github.com/dgraph-io/badger/v4"
@@ -146,7 +148,7 @@ func TestStoreData(t *testing.T) {
 
 			// Try to retrieve the pair frequency directly from BadgerDB
 			err := db.bdb.View(func(txn *badger.Txn) error {
-				key := record.CollFreqKey(
+				key := db.keys.CollFreqKey(
 					true, tokenID1, pairFreq.PoS1.Byte(), pairFreq.TextType.Byte(), pairFreq.Deprel.AsUint16(), tokenID2, pairFreq.PoS2.Byte())
 				item, err := txn.Get(key)
 				if err != nil {
@@ -154,7 +156,8 @@ func TestStoreData(t *testing.T) {
 				}
 
 				return item.Value(func(val []byte) error {
-					collValue := record.DecodeCollocValue(val)
+					collValue, err := record.DecodeCollocValue(val)
+					assert.NoError(t, err)
 
 					assert.Equal(t, uint32(pairFreq.Freq), collValue.Freq,
 						"Pair frequency should match for %s-%s", pairFreq.Lemma1, pairFreq.Lemma2)
@@ -218,7 +221,7 @@ func TestStoreData(t *testing.T) {
 		tokenID2 := tidSeq.recall(lowFreqPair.Lemma2Key())
 
 		err = db.bdb.View(func(txn *badger.Txn) error {
-			key := record.CollFreqKey(
+			key := db.keys.CollFreqKey(
 				true, tokenID1, lowFreqPair.PoS1.Byte(), lowFreqPair.TextType.Byte(), lowFreqPair.Deprel.AsUint16(), tokenID2, lowFreqPair.PoS2.Byte())
 			_, err := txn.Get(key)
 			return err
@@ -230,3 +233,308 @@ func TestStoreData(t *testing.T) {
 			lowFreqPair.Freq, minPairFreq)
 	})
 }
+
+func TestImportHistory(t *testing.T) {
+	db := openTestDB(t)
+	history, err := db.ImportHistory()
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	run1 := ImportRun{
+		Timestamp:   time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		Files:       []string{"a.vert"},
+		NumLemmas:   10,
+		MinPairFreq: 5,
+	}
+	run2 := ImportRun{
+		Timestamp:       time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC),
+		Files:           []string{"b.vert", "c.vert"},
+		NumCollFreqs:    20,
+		ClearedPrevious: true,
+	}
+	assert.NoError(t, db.AppendImportRun(run1))
+	assert.NoError(t, db.AppendImportRun(run2))
+	history, err = db.ImportHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, []ImportRun{run1, run2}, history)
+
+	// metadata must not interfere with the history
+	assert.NoError(t, db.StoreMetadata(Metadata{CorpusSize: 1000}))
+	history, err = db.ImportHistory()
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestAppendFreqs(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amodVal, _ := record.UDDeprelMapping.Get("amod")
+	amod := record.UDDeprelFromUint16(amodVal)
+
+	_, err := db.StoreFreqs(
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: tt},
+			"2": {Lemma: "big", PoS: adj, Freq: 10, TextType: tt},
+		},
+		map[record.GroupingKey]record.CollocFreq{
+			"1": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj, Freq: 3, AVGDist: 1, TextType: tt},
+		},
+		2,
+	)
+	assert.NoError(t, err)
+	dogID, err := db.GetLemmaID(record.TokenFreq{Lemma: "dog"})
+	assert.NoError(t, err)
+	bigID, err := db.GetLemmaID(record.TokenFreq{Lemma: "big"})
+	assert.NoError(t, err)
+
+	stats, err := db.AppendFreqs(
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "dog", PoS: noun, Freq: 5, TextType: tt},
+			"2": {Lemma: "small", PoS: adj, Freq: 4, TextType: tt},
+			"3": {Lemma: "big", PoS: adj, Freq: 2, TextType: tt},
+		},
+		map[record.GroupingKey]record.CollocFreq{
+			// below the limit but an existing record so it is merged
+			"1": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj, Freq: 1, AVGDist: 3, TextType: tt},
+			// below the limit and a new record so it is skipped
+			"2": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "small", PoS2: adj, Freq: 1, AVGDist: 1, TextType: tt},
+		},
+		2,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.NumLemmas)
+	assert.Equal(t, 1, stats.NumLemmaFreqs)
+	assert.Equal(t, 1, stats.NumLemmaRollups)
+	assert.Equal(t, 0, stats.NumCollFreqs)
+	assert.Equal(t, 1, stats.RelationDists["amod"].Freq)
+
+	// existing lemmas keep their IDs, new ones follow them
+	newDogID, err := db.GetLemmaID(record.TokenFreq{Lemma: "dog"})
+	assert.NoError(t, err)
+	assert.Equal(t, dogID, newDogID)
+	smallID, err := db.GetLemmaID(record.TokenFreq{Lemma: "small"})
+	assert.NoError(t, err)
+	assert.Equal(t, max(dogID, bigID)+1, smallID)
+
+	freqs, err := db.GetSingleTokenFreq(dogID, noun.Byte(), tt.Byte())
+	assert.NoError(t, err)
+	assert.Equal(t, 25, record.SumTokenFreqs(freqs))
+
+	err = db.bdb.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(db.keys.TokenFreqRollupKey(bigID, adj.Byte()))
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		rollup, err := decodeItemValue(item, record.DecodeTokenValue)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(12), rollup.Freq)
+
+		item, err = txn.Get(db.keys.CollFreqKey(
+			true, dogID, noun.Byte(), tt.Byte(), amod.AsUint16(), bigID, adj.Byte()))
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		coll, err := decodeItemValue(item, record.DecodeCollocValue)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(4), coll.Freq)
+		assert.InDelta(t, 1.5, coll.Dist, 0.1)
+
+		_, err = txn.Get(db.keys.CollFreqKey(
+			true, dogID, noun.Byte(), tt.Byte(), amod.AsUint16(), smallID, adj.Byte()))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAppendFreqsHotLemmaSummaries(t *testing.T) {
+	db := openTestDB(t)
+	db.Metadata.CorpusSize = 1000
+	db.DeprelMapping = &record.UDDeprelMapping
+	fiction := record.TextType{Raw: 0x01, Readable: "fiction"}
+	news := record.TextType{Raw: 0x02, Readable: "news"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amodVal, _ := record.UDDeprelMapping.Get("amod")
+	amod := record.UDDeprelFromUint16(amodVal)
+
+	_, err := db.StoreData(
+		NewTokenIDSequence(),
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: fiction},
+			"2": {Lemma: "big", PoS: adj, Freq: 10, TextType: fiction},
+			"3": {Lemma: "big", PoS: adj, Freq: 10, TextType: news},
+		},
+		map[record.GroupingKey]record.CollocFreq{
+			"1": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj, Freq: 3, AVGDist: 1, TextType: fiction},
+			"2": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj, Freq: 2, AVGDist: 1, TextType: news},
+		},
+		1,
+	)
+	assert.NoError(t, err)
+	numHot, err := db.StoreHotLemmaSummaries(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, numHot)
+
+	_, err = db.AppendFreqs(
+		map[record.GroupingKey]record.TokenFreq{
+			"1": {Lemma: "dog", PoS: noun, Freq: 10, TextType: fiction},
+			"2": {Lemma: "big", PoS: adj, Freq: 5, TextType: fiction},
+		},
+		map[record.GroupingKey]record.CollocFreq{
+			"1": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj, Freq: 4, AVGDist: 1, TextType: fiction},
+		},
+		1,
+	)
+	assert.NoError(t, err)
+	// with a higher threshold, "dog" is no longer hot so its summary
+	// (calculated before the data were appended) must be removed
+	numHot, err = db.StoreHotLemmaSummaries(5)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, numHot)
+	dogID, err := db.GetLemmaID(record.TokenFreq{Lemma: "dog"})
+	assert.NoError(t, err)
+	err = db.bdb.View(func(txn *badger.Txn) error {
+		assert.False(t, db.hasHotLemmaSummaryTx(txn, true, dogID))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	db.Metadata.HotLemmaThreshold = 5
+	ans, err := db.CalculateMeasures(
+		context.Background(), CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	if assert.Len(t, ans, 1) {
+		assert.Equal(t, 9, ans[0].Freq)
+	}
+}
+
+func TestStoreDataSmallWriteBatches(t *testing.T) {
+	db := openTestDB(t)
+	db.SetWriteBatchSize(2)
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	amodVal, _ := record.UDDeprelMapping.Get("amod")
+	amod := record.UDDeprelFromUint16(amodVal)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "dog", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "big", PoS: adj, Freq: 10, TextType: tt},
+		"3": {Lemma: "small", PoS: adj, Freq: 10, TextType: tt},
+		"4": {Lemma: "žlutý", PoS: adj, Freq: 10, TextType: tt},
+		"5": {Lemma: "tiny", PoS: adj, Freq: 10, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "big", PoS2: adj, Freq: 3, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "small", PoS2: adj, Freq: 2, AVGDist: 1, TextType: tt},
+		"3": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "žlutý", PoS2: adj, Freq: 2, AVGDist: 1, TextType: tt},
+		"4": {Lemma1: "dog", PoS1: noun, Deprel: amod, Lemma2: "tiny", PoS2: adj, Freq: 1, AVGDist: 1, TextType: tt},
+	}
+	stats, err := db.StoreFreqs(singleFreqs, pairFreqs, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.NumLemmas)
+	assert.Equal(t, 5, stats.NumLemmaFreqs)
+	assert.Equal(t, 5, stats.NumLemmaRollups)
+	assert.Equal(t, 3, stats.NumCollFreqs)
+
+	// all the records must be committed, including incomplete batches
+	for _, freq := range singleFreqs {
+		tokenID, err := db.GetLemmaID(freq)
+		assert.NoError(t, err)
+		lemma, err := db.GetLemmaByID(tokenID)
+		assert.NoError(t, err)
+		assert.Equal(t, freq.Lemma, lemma)
+	}
+	db.Metadata.CorpusSize = 1000
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{Lemma: "dog", Limit: 10, SortBy: sortByLogDice})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 3)
+}
+
+func TestWideKeyLayout(t *testing.T) {
+	path := t.TempDir()
+	db, err := OpenDBIgnoreMetadata(path, &PreconfTextTypeMapping{data: map[string]byte{"fiction": 0x01}})
+	assert.NoError(t, err)
+	db.SetKeyLayout(record.WideKeyLayout)
+	db.DeprelMapping = &record.UDDeprelMapping
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	adj := record.UDPosFromByte(record.PosADJ)
+	verb := record.UDPosFromByte(record.PosVERB)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "big", PoS: adj, Freq: 30, TextType: tt},
+		"3": {Lemma: "stay", PoS: verb, Freq: 50, TextType: tt},
+	}
+	pairFreqs := map[record.GroupingKey]record.CollocFreq{
+		"1": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("amod"), Lemma2: "big", PoS2: adj,
+			Freq: 6, AVGDist: 1, TextType: tt},
+		"2": {Lemma1: "house", PoS1: noun, Deprel: record.ImportUDDeprel("obl"), Lemma2: "stay", PoS2: verb,
+			Freq: 4, AVGDist: -1, TextType: tt},
+	}
+	seq := NewTokenIDSequence()
+	seq.value = uint64(1) << 40
+	_, err = db.StoreData(seq, singleFreqs, pairFreqs, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, db.StoreMetadata(Metadata{
+		SchemaVersion: SchemaVersion,
+		TokenIDWidth:  8,
+		CorpusSize:    1000,
+		NumLemmas:     3,
+		NumLemmaFreqs: 3,
+		NumCollFreqs:  2,
+		DeprelMap:     record.UDDeprelMapping.AsMap(),
+	}))
+	assert.NoError(t, db.Close())
+
+	// the layout is detected from metadata
+	db, err = OpenDB(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, record.WideKeyLayout, db.KeyLayout())
+	houseID, err := db.GetLemmaID(record.TokenFreq{Lemma: "house"})
+	assert.NoError(t, err)
+	assert.Greater(t, houseID, uint64(1)<<40)
+
+	ans, err := db.CalculateMeasures(context.Background(), CalculationArgs{
+		Lemma:   "house",
+		Limit:   10,
+		SortBy:  sortByLogDice,
+		Deprels: []string{"amod"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "big", ans[0].Collocate.Value)
+	assert.Equal(t, 6, ans[0].Freq)
+
+	report, err := db.CheckIntegrity(context.Background(), IntegrityCheckArgs{})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, 3, report.NumLemmas)
+	assert.Equal(t, 2, report.NumCollFreqs)
+}
+
+func TestTokenIDOverflow(t *testing.T) {
+	db := openTestDB(t)
+	tt := record.TextType{Raw: 0x01, Readable: "fiction"}
+	noun := record.UDPosFromByte(record.PosNOUN)
+	singleFreqs := map[record.GroupingKey]record.TokenFreq{
+		"1": {Lemma: "house", PoS: noun, Freq: 20, TextType: tt},
+		"2": {Lemma: "tree", PoS: noun, Freq: 10, TextType: tt},
+	}
+	seq := NewTokenIDSequence()
+	seq.value = db.keys.MaxTokenID() - 1
+	_, err := db.StoreData(seq, singleFreqs, nil, 1)
+	assert.ErrorIs(t, err, ErrTokenIDOverflow)
+
+	// the wide layout has enough IDs
+	db = openTestDB(t)
+	db.SetKeyLayout(record.WideKeyLayout)
+	seq = NewTokenIDSequence()
+	seq.value = record.NarrowKeyLayout.MaxTokenID() - 1
+	_, err = db.StoreData(seq, singleFreqs, nil, 1)
+	assert.NoError(t, err)
+}